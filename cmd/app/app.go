@@ -11,37 +11,144 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/polzovatel/todo-learning/cmd/middleware"
+	"github.com/polzovatel/todo-learning/config"
 	"github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/auth/webauthn"
+	"github.com/polzovatel/todo-learning/internal/blob"
 	"github.com/polzovatel/todo-learning/internal/controller"
+	"github.com/polzovatel/todo-learning/internal/idempotency"
+	"github.com/polzovatel/todo-learning/internal/jobs"
+	"github.com/polzovatel/todo-learning/internal/mail"
 	"github.com/polzovatel/todo-learning/internal/repository"
 	"github.com/polzovatel/todo-learning/internal/service"
 	"github.com/redis/go-redis/v9"
 )
 
 type App struct {
-	Router   *gin.Engine
-	logger   *slog.Logger
-	signer   *auth.JWTSigner
-	userCtrl *controller.UserController
-	todoCtrl *controller.TodoController
+	Router       *gin.Engine
+	logger       *slog.Logger
+	signer       *auth.JWTSigner
+	tokens       service.TokenService
+	cookies      auth.CookieSettings
+	userCtrl     *controller.UserController
+	todoCtrl     *controller.TodoController
+	oauthCtrl    *controller.OAuthController
+	patCtrl      *controller.PersonalTokenController
+	webauthnCtrl *controller.WebAuthnController
+	magicCtrl    *controller.MagicLinkController
+	tagCtrl      *controller.TagController
+	projectCtrl  *controller.ProjectController
+	shareCtrl    *controller.ShareController
+	wsCtrl       *controller.WorkspaceController
+	commentCtrl  *controller.CommentController
+	attachCtrl   *controller.AttachmentController
+	transferCtrl *controller.TransferController
+	importCtrl   *controller.ImportController
+	workspaces   service.WorkspaceService
+	imports      service.ImportJobService
+	idempotency  idempotency.Store
+	idemTTL      time.Duration
+	maxBody      int64
+	periodic     []periodicTask
 }
 
-func NewApp(logger *slog.Logger, userRepo repository.Store, todoRepo repository.TodoStore, redisClient *redis.Client, signer *auth.JWTSigner) *App {
+// periodicTask — фоновая работа, которую Run запускает раз в every; every <= 0 отключает её.
+type periodicTask struct {
+	name  string
+	every time.Duration
+	run   func(ctx context.Context) error
+}
+
+func NewApp(cfg *config.Config, logger *slog.Logger, repo repository.Repository, redisClient *redis.Client, signer *auth.JWTSigner, mailer mail.Mailer, blobs blob.BlobStore) *App {
 	r := gin.New()
+	// Сервисы получают *gin.Context; с fallback он отдаёт и значения контекста запроса,
+	// в том числе текущее рабочее пространство.
+	r.ContextWithFallback = true
 	r.Use(gin.Recovery())
 	r.Use(middleware.RequestLoggerMiddleware(logger))
 
-	userService := service.NewService(userRepo, redisClient, logger)
-	todoService := service.NewTodoService(userRepo, todoRepo, redisClient, logger)
-	contr := controller.NewUserController(userService, signer, logger)
+	userService := service.NewService(repo, redisClient, logger)
+	todoService := service.NewTodoService(repo, repo, repo, repo, repo, repo, redisClient, cfg.TodoMaxDepth, logger)
+	workspaceService := service.NewWorkspaceService(repo, repo, logger)
+	attachmentService := service.NewAttachmentService(repo, repo, repo, repo, blobs, service.AttachmentLimits{
+		MaxSize:      cfg.AttachmentMaxSize,
+		AllowedTypes: cfg.AttachmentTypes,
+	}, logger)
+	clientService := service.NewClientService(repo, logger)
+	tokenService := service.NewTokenService(repo, repo, repo, signer, logger)
+	webAuthnService := service.NewWebAuthnService(repo, repo, webauthn.RelyingParty{
+		ID:     cfg.WebAuthnRPID,
+		Name:   cfg.WebAuthnRPName,
+		Origin: cfg.WebAuthnOrigin,
+	}, logger)
+	magicLinkService := service.NewMagicLinkService(repo, repo, repo, repo, signer, mailer, cfg.MagicLinkURL, cfg.MagicLinkTTL, logger)
+	sameSite, _ := auth.ParseSameSite(cfg.CookieSameSite)
+	cookies := auth.CookieSettings{
+		Enabled:  cfg.AuthCookies,
+		Secure:   cfg.CookieSecure,
+		SameSite: sameSite,
+		Domain:   cfg.CookieDomain,
+	}
+	contr := controller.NewUserController(userService, tokenService, signer, cookies, logger)
 	todoContr := controller.NewTodoController(todoService, signer, logger)
+	tagService := service.NewTagService(repo, repo, repo, repo, logger)
+	projectService := service.NewProjectService(repo, repo, redisClient, logger)
+	tagContr := controller.NewTagController(tagService, logger)
+	projectContr := controller.NewProjectController(projectService, todoService, logger)
+	transferService := service.NewTransferService(todoService, projectService, tagService, repo, redisClient, logger)
+	transferContr := controller.NewTransferController(transferService, logger)
+	shareContr := controller.NewShareController(service.NewShareService(repo, repo, repo, repo, mailer, logger), logger)
+	oauthContr := controller.NewOAuthController(clientService, tokenService, signer, logger)
+	webauthnContr := controller.NewWebAuthnController(webAuthnService, signer, cookies, logger)
+	magicContr := controller.NewMagicLinkController(magicLinkService, signer, cookies, logger)
+	workspaceContr := controller.NewWorkspaceController(workspaceService, signer, logger)
+	commentContr := controller.NewCommentController(service.NewCommentService(repo, repo, repo, repo, repo, logger), logger)
+	attachmentContr := controller.NewAttachmentController(attachmentService, cfg.AttachmentMaxSize, logger)
+	// Без Redis ответы на повторяемые запросы и состояние импортов хранятся в памяти процесса.
+	idempotencyStore := idempotency.NewMemoryStore()
+	jobStore := jobs.NewMemoryStore()
+	if redisClient != nil {
+		idempotencyStore = idempotency.NewRedisStore(redisClient)
+		jobStore = jobs.NewRedisStore(redisClient)
+	}
+	importService := service.NewImportJobService(transferService, jobStore, logger)
+	importContr := controller.NewImportController(importService, logger)
 
 	app := &App{
-		Router:   r,
-		logger:   logger,
-		signer:   signer,
-		userCtrl: contr,
-		todoCtrl: todoContr,
+		Router:       r,
+		logger:       logger,
+		signer:       signer,
+		tokens:       tokenService,
+		cookies:      cookies,
+		userCtrl:     contr,
+		todoCtrl:     todoContr,
+		oauthCtrl:    oauthContr,
+		patCtrl:      controller.NewPersonalTokenController(service.NewPersonalTokenService(repo, logger), logger),
+		webauthnCtrl: webauthnContr,
+		magicCtrl:    magicContr,
+		tagCtrl:      tagContr,
+		projectCtrl:  projectContr,
+		shareCtrl:    shareContr,
+		wsCtrl:       workspaceContr,
+		commentCtrl:  commentContr,
+		attachCtrl:   attachmentContr,
+		transferCtrl: transferContr,
+		importCtrl:   importContr,
+		workspaces:   workspaceService,
+		imports:      importService,
+		idempotency:  idempotencyStore,
+		idemTTL:      cfg.IdempotencyTTL,
+		maxBody:      controller.MaxBodySize(cfg.AttachmentMaxSize),
+		periodic: []periodicTask{
+			{name: "attachment sweep", every: cfg.AttachmentSweepInterval, run: func(ctx context.Context) error {
+				_, err := attachmentService.PurgeOrphans(ctx)
+				return err
+			}},
+			{name: "trash purge", every: cfg.TrashPurgeInterval, run: func(ctx context.Context) error {
+				_, err := todoService.PurgeTrash(ctx, time.Now().Add(-cfg.TrashRetention))
+				return err
+			}},
+		},
 	}
 
 	app.SetupRoutes()
@@ -53,22 +160,119 @@ func (app *App) SetupRoutes() {
 	{
 		api.POST("/register", app.userCtrl.RegisterUser)
 		api.POST("/login", app.userCtrl.LoginUser)
+		api.POST("/login/magic", app.magicCtrl.RequestLink)
+		api.GET("/login/magic/callback", app.magicCtrl.Callback)
+		api.POST("/refresh", middleware.CSRFMiddleware(app.cookies, app.logger), app.userCtrl.RefreshToken)
+		api.POST("/oauth/token", app.oauthCtrl.Token)
+		api.POST("/oauth/introspect", app.oauthCtrl.Introspect)
+		api.POST("/webauthn/login/begin", app.webauthnCtrl.BeginLogin)
+		api.POST("/webauthn/login/finish", app.webauthnCtrl.FinishLogin)
 	}
 
 	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware(app.signer, app.logger))
+	protected.Use(middleware.AuthMiddleware(app.tokens, app.cookies, app.logger))
+
+	idempotent := middleware.IdempotencyMiddleware(app.idempotency, app.idemTTL, app.maxBody, app.logger)
+
+	user := protected.Group("")
+	user.Use(middleware.RequireUser(app.logger))
+	user.Use(idempotent)
 	{
-		protected.GET("/me", app.userCtrl.GetMe)
-		protected.POST("/logout", app.userCtrl.LogoutUser)
-		protected.POST("/todos", app.todoCtrl.CreateTodo)
-		protected.GET("/todos", app.todoCtrl.GetTodos)
-		protected.GET("/todos/:id", app.todoCtrl.GetTodoByID)
-		protected.PUT("/todos/:id", app.todoCtrl.UpdateTodo)
-		protected.DELETE("/todos/:id", app.todoCtrl.DeleteTodo)
+		user.GET("/me", app.userCtrl.GetMe)
+		user.PATCH("/me", app.userCtrl.UpdateMe)
+		user.POST("/logout", app.userCtrl.LogoutUser)
+		user.POST("/tokens", app.patCtrl.CreateToken)
+		user.GET("/tokens", app.patCtrl.GetTokens)
+		user.DELETE("/tokens/:id", app.patCtrl.RevokeToken)
+		user.POST("/webauthn/register/begin", app.webauthnCtrl.BeginRegistration)
+		user.POST("/webauthn/register/finish", app.webauthnCtrl.FinishRegistration)
+		user.GET("/webauthn/credentials", app.webauthnCtrl.GetCredentials)
+		user.POST("/workspaces", app.wsCtrl.CreateWorkspace)
+		user.GET("/workspaces", app.wsCtrl.GetWorkspaces)
+		user.GET("/workspaces/:id", app.wsCtrl.GetWorkspace)
+		user.PUT("/workspaces/:id", app.wsCtrl.UpdateWorkspace)
+		user.DELETE("/workspaces/:id", app.wsCtrl.DeleteWorkspace)
+		user.POST("/workspaces/:id/members", app.wsCtrl.AddMember)
+		user.GET("/workspaces/:id/members", app.wsCtrl.GetMembers)
+		user.DELETE("/workspaces/:id/members/:user_id", app.wsCtrl.RemoveMember)
+		user.POST("/workspaces/:id/switch", app.wsCtrl.SwitchWorkspace)
+	}
+
+	// Данные пространства: задачи, метки, проекты и доступы видны только в текущем
+	// пространстве запроса. Idempotency-Key проверяется после выбора пространства:
+	// оно входит в отпечаток запроса.
+	scoped := protected.Group("")
+	scoped.Use(middleware.RequireUser(app.logger))
+	scoped.Use(middleware.WorkspaceMiddleware(app.workspaces, app.logger))
+	scoped.Use(idempotent)
+	{
+		scoped.POST("/todos", app.todoCtrl.CreateTodo)
+		// Методы вида /todos:batch: gin считает ":method" параметром, разбирает его контроллер.
+		scoped.POST("/todos:method", app.todoCtrl.TodosMethod)
+		scoped.GET("/todos", app.todoCtrl.GetTodos)
+		scoped.GET("/todos/search", app.todoCtrl.SearchTodos)
+		scoped.GET("/todos/export", app.transferCtrl.ExportTodos)
+		scoped.POST("/todos/import", app.transferCtrl.ImportTodos)
+		scoped.POST("/imports", app.importCtrl.StartImport)
+		scoped.GET("/imports/:id", app.importCtrl.GetImport)
+		scoped.GET("/todos/:id", app.todoCtrl.GetTodoByID)
+		scoped.PUT("/todos/:id", app.todoCtrl.UpdateTodo)
+		scoped.PATCH("/todos/:id", app.todoCtrl.PatchTodo)
+		scoped.PUT("/todos/:id/move", app.todoCtrl.MoveTodo)
+		scoped.GET("/todos/:id/children", app.todoCtrl.GetTodoChildren)
+		scoped.GET("/todos/:id/tree", app.todoCtrl.GetTodoTree)
+		scoped.POST("/todos/:id/skip", app.todoCtrl.SkipOccurrence)
+		scoped.DELETE("/todos/:id/recurrence", app.todoCtrl.EndRecurrence)
+		scoped.GET("/todos/:id/history", app.todoCtrl.GetTodoHistory)
+		scoped.POST("/todos/:id/revert/:revision", app.todoCtrl.RevertTodo)
+		scoped.DELETE("/todos/:id", app.todoCtrl.DeleteTodo)
+		scoped.POST("/todos/:id/restore", app.todoCtrl.RestoreTodo)
+		scoped.GET("/trash", app.todoCtrl.GetTrash)
+		scoped.DELETE("/trash/:id", app.todoCtrl.PurgeTodo)
+		scoped.GET("/todos/:id/tags", app.tagCtrl.GetTodoTags)
+		scoped.PUT("/todos/:id/tags/:tag_id", app.tagCtrl.AttachTag)
+		scoped.DELETE("/todos/:id/tags/:tag_id", app.tagCtrl.DetachTag)
+		scoped.POST("/todos/:id/shares", app.shareCtrl.ShareTodo)
+		scoped.GET("/todos/:id/shares", app.shareCtrl.GetTodoShares)
+		scoped.DELETE("/todos/:id/shares/:user_id", app.shareCtrl.RevokeTodoShare)
+		scoped.GET("/todos/:id/comments", app.commentCtrl.GetComments)
+		scoped.POST("/todos/:id/comments", app.commentCtrl.CreateComment)
+		scoped.PUT("/todos/:id/comments/:comment_id", app.commentCtrl.UpdateComment)
+		scoped.DELETE("/todos/:id/comments/:comment_id", app.commentCtrl.DeleteComment)
+		scoped.POST("/todos/:id/attachments", app.attachCtrl.UploadAttachment)
+		scoped.GET("/todos/:id/attachments", app.attachCtrl.GetAttachments)
+		scoped.GET("/todos/:id/attachments/:attachment_id", app.attachCtrl.DownloadAttachment)
+		scoped.DELETE("/todos/:id/attachments/:attachment_id", app.attachCtrl.DeleteAttachment)
+		scoped.POST("/tags", app.tagCtrl.CreateTag)
+		scoped.GET("/tags", app.tagCtrl.GetTags)
+		scoped.PUT("/tags/:id", app.tagCtrl.UpdateTag)
+		scoped.DELETE("/tags/:id", app.tagCtrl.DeleteTag)
+		scoped.POST("/projects", app.projectCtrl.CreateProject)
+		scoped.GET("/projects", app.projectCtrl.GetProjects)
+		scoped.GET("/projects/:id", app.projectCtrl.GetProjectByID)
+		scoped.PUT("/projects/:id", app.projectCtrl.UpdateProject)
+		scoped.DELETE("/projects/:id", app.projectCtrl.DeleteProject)
+		scoped.GET("/projects/:id/todos", app.projectCtrl.GetProjectTodos)
+		scoped.POST("/projects/:id/shares", app.shareCtrl.ShareProject)
+		scoped.GET("/projects/:id/shares", app.shareCtrl.GetProjectShares)
+		scoped.DELETE("/projects/:id/shares/:user_id", app.shareCtrl.RevokeProjectShare)
+		scoped.GET("/shared", app.shareCtrl.SharedWithMe)
+	}
+
+	// Маршруты для машинных клиентов (client credentials), доступ по scopes.
+	{
+		protected.GET("/users", middleware.RequireScope(app.logger, auth.ScopeUsersRead), app.userCtrl.GetUsers)
+		protected.GET("/users/:id/todos", middleware.RequireScope(app.logger, auth.ScopeTodosRead), app.todoCtrl.GetUserTodos)
 	}
 }
 
 func (app *App) Run(server *http.Server, cleanup func()) error {
+	tasksCtx, stopTasks := context.WithCancel(context.Background())
+	defer stopTasks()
+	for _, task := range app.periodic {
+		go app.runPeriodically(tasksCtx, task)
+	}
+
 	errChan := make(chan error, 1)
 	go func() {
 		app.logger.Info("HTTP server starting", slog.String("addr", server.Addr))
@@ -89,6 +293,24 @@ func (app *App) Run(server *http.Server, cleanup func()) error {
 	}
 }
 
+func (app *App) runPeriodically(ctx context.Context, task periodicTask) {
+	if task.every <= 0 {
+		return
+	}
+	ticker := time.NewTicker(task.every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := task.run(ctx); err != nil {
+				app.logger.Error("periodic task failed", slog.String("task", task.name), slog.Any("error", err))
+			}
+		}
+	}
+}
+
 func (app *App) gracefulShutdown(server *http.Server, cleanup func()) error {
 	app.logger.Info("shutting down server...")
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -101,6 +323,10 @@ func (app *App) gracefulShutdown(server *http.Server, cleanup func()) error {
 
 	app.logger.Info("HTTP server stopped")
 
+	if err := app.imports.Shutdown(ctx); err != nil {
+		app.logger.Error("import jobs did not stop in time", slog.Any("error", err))
+	}
+
 	if cleanup != nil {
 		cleanup()
 		app.logger.Info("database connections closed")
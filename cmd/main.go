@@ -9,10 +9,13 @@ import (
 	app2 "github.com/polzovatel/todo-learning/cmd/app"
 	"github.com/polzovatel/todo-learning/config"
 	"github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/blob"
 	"github.com/polzovatel/todo-learning/internal/database"
+	"github.com/polzovatel/todo-learning/internal/mail"
 	"github.com/polzovatel/todo-learning/internal/repository"
 	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
 	"github.com/polzovatel/todo-learning/internal/repository/postgres"
+	"github.com/polzovatel/todo-learning/internal/service"
 	"github.com/polzovatel/todo-learning/logger"
 )
 
@@ -42,13 +45,24 @@ func main() {
 		defer redisClient.Close()
 	}
 
-	userRepo, todoRepo, cleanup, err := initRepository(ctx, cfg, appLogger)
+	repo, cleanup, err := initRepository(ctx, cfg, appLogger)
 	if err != nil {
 		appLogger.Error("failed to init repository", slog.Any("error", err))
 		os.Exit(1)
 	}
 
-	app := app2.NewApp(appLogger, userRepo, todoRepo, redisClient, singer)
+	if err := registerOAuthClients(ctx, cfg, repo, appLogger); err != nil {
+		appLogger.Error("failed to register oauth clients", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	blobs, err := initBlobStore(cfg)
+	if err != nil {
+		appLogger.Error("failed to init blob store", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	app := app2.NewApp(cfg, appLogger, repo, redisClient, singer, initMailer(cfg, appLogger), blobs)
 
 	server := &http.Server{
 		Addr:    cfg.HTTPAddr,
@@ -61,22 +75,60 @@ func main() {
 	}
 }
 
-func initRepository(ctx context.Context, cfg *config.Config, logger *slog.Logger) (repository.Store, repository.TodoStore, func(), error) {
+func initRepository(ctx context.Context, cfg *config.Config, logger *slog.Logger) (repository.Repository, func(), error) {
 	pool, err := database.NewPool(ctx, cfg)
 	if err != nil {
 		logger.Warn("database connection failed, falling back to in-memory", slog.Any("error", err))
 		repo := in_memory.NewInMemoryRepository(logger)
-		return repo, repo, func() {}, nil
+		return repo, func() {}, nil
 	}
 
 	if err := database.RunMigrations(ctx, pool); err != nil {
 		logger.Error("migrations failed, falling back to in-memory", slog.Any("error", err))
 		repo := in_memory.NewInMemoryRepository(logger)
 		pool.Close()
-		return repo, repo, func() {}, nil
+		return repo, func() {}, nil
 	}
 
 	repo := postgres.NewPostgresRepository(pool, logger)
 	cleanup := func() { pool.Close() }
-	return repo, repo, cleanup, nil
+	return repo, cleanup, nil
+}
+
+func initBlobStore(cfg *config.Config) (blob.BlobStore, error) {
+	if cfg.BlobBackend == "s3" {
+		return blob.NewS3Store(blob.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		}, nil), nil
+	}
+	return blob.NewLocalStore(cfg.BlobDir)
+}
+
+// initMailer выбирает SMTP, а без SMTP_HOST (только в dev) — запись писем в лог.
+func initMailer(cfg *config.Config, logger *slog.Logger) mail.Mailer {
+	if cfg.SMTPHost == "" {
+		logger.Warn("SMTP_HOST is not set, emails are only logged")
+		return mail.NewLogMailer(logger)
+	}
+	return mail.NewSMTPMailer(mail.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUser,
+		Password: cfg.SMTPPass,
+		From:     cfg.MailFrom,
+	})
+}
+
+func registerOAuthClients(ctx context.Context, cfg *config.Config, repo repository.ClientStore, logger *slog.Logger) error {
+	clientService := service.NewClientService(repo, logger)
+	for _, client := range cfg.OAuthClients {
+		if _, err := clientService.RegisterClient(ctx, client.ID, client.Secret, client.Scopes); err != nil {
+			return err
+		}
+	}
+	return nil
 }
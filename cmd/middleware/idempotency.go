@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/idempotency"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+const (
+	// IdempotencyKeyHeader — ключ, под которым клиент повторяет один и тот же запрос.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader отмечает ответ, взятый из сохранённого, а не выполненный заново.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// idempotencyLockTTL ограничивает, сколько ключ остаётся занятым, если процесс упал,
+	// не дождавшись ответа. Пока обработчик работает, занятость продлевается каждые
+	// idempotencyExtendInterval, поэтому долгий импорт или пакет операций её не переживёт.
+	idempotencyLockTTL        = time.Minute
+	idempotencyExtendInterval = idempotencyLockTTL / 3
+)
+
+// replayedHeaders — заголовки ответа, которые сохраняются вместе с телом.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location", "Accept-Patch"}
+
+// IdempotencyMiddleware сохраняет на ttl ответ на изменяющий запрос с заголовком
+// Idempotency-Key. Повтор с тем же ключом и телом получает сохранённый ответ, тот же
+// ключ с другим запросом — 422, а пока первый запрос выполняется — 409. Ответы 5xx не
+// сохраняются: такой запрос можно повторить. Ключи у каждого пользователя свои.
+// Тело читается целиком до обработчика, поэтому оно ограничено maxBody: его ограничения
+// размера здесь ещё не действуют. На маршрутах пространства middleware ставится после
+// WorkspaceMiddleware, чтобы в отпечаток попало выбранное пространство.
+func IdempotencyMiddleware(store idempotency.Store, ttl time.Duration, maxBody int64, appLogger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		reqLogger := logger.LoggerFromContext(c, appLogger)
+		if !validIdempotencyKey(key) {
+			reqLogger.Warn("invalid idempotency key", slog.Int("length", len(key)))
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": domain.ErrInvalidIdempotencyKey.Error()})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				reqLogger.Warn("idempotent request body is too large", slog.Int64("limit", maxBody))
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"message": "request body is too large"})
+				return
+			}
+			reqLogger.Warn("failed to read request body", slog.Any("error", err))
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := c.GetString("user_id") + ":" + key
+		fingerprint := requestFingerprint(c.Request, c.GetString("workspace_id"), body)
+		existing, err := store.Reserve(c, storeKey, fingerprint, idempotencyLockTTL)
+		if err != nil {
+			// Без хранилища запрос всё равно выполняется, только без защиты от повтора.
+			reqLogger.Error("idempotency store unavailable", slog.Any("error", err))
+			c.Next()
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				reqLogger.Warn("idempotency key reused for a different request", slog.String("key", key))
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"message": domain.ErrIdempotencyKeyReused.Error()})
+			case !existing.Done:
+				reqLogger.Warn("idempotent request is still in progress", slog.String("key", key))
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": domain.ErrIdempotencyInProgress.Error()})
+			default:
+				reqLogger.Info("replaying idempotent response", slog.String("key", key), slog.Int("status", existing.Status))
+				replay(c, existing)
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		saved := false
+		stopExtending := extendReservation(c.Request.Context(), store, storeKey, reqLogger)
+		// Ключ освобождается и при панике обработчика, иначе повтор получал бы 409.
+		defer func() {
+			stopExtending()
+			if !saved {
+				if err := store.Release(context.WithoutCancel(c), storeKey); err != nil {
+					reqLogger.Error("failed to release idempotency key", slog.Any("error", err))
+				}
+			}
+		}()
+
+		c.Next()
+		// Продление останавливается до сохранения, чтобы не задеть сохранённый ответ.
+		stopExtending()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		record := idempotency.Record{Fingerprint: fingerprint, Done: true, Status: status, Header: http.Header{}, Body: recorder.body.Bytes()}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Values(name); len(value) > 0 {
+				record.Header[name] = value
+			}
+		}
+		if err := store.Save(context.WithoutCancel(c), storeKey, record, ttl); err != nil {
+			reqLogger.Error("failed to save idempotent response", slog.Any("error", err))
+			return
+		}
+		saved = true
+	}
+}
+
+// extendReservation продлевает занятость ключа, пока выполняется обработчик. Возвращённая
+// функция останавливает продление и дожидается его; вызывать её можно несколько раз.
+func extendReservation(ctx context.Context, store idempotency.Store, key string, reqLogger *slog.Logger) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(idempotencyExtendInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := store.Extend(context.WithoutCancel(ctx), key, idempotencyLockTTL); err != nil {
+					reqLogger.Error("failed to extend idempotency key", slog.Any("error", err))
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > 255 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestFingerprint отличает запросы по методу, пути с параметрами, рабочему
+// пространству и телу. Пространство — то, что выбрал WorkspaceMiddleware (из заголовка
+// или из токена), а не сырой заголовок.
+func requestFingerprint(r *http.Request, workspaceID string, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n"+workspaceID+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replay(c *gin.Context, record *idempotency.Record) {
+	for name, values := range record.Header {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Status(record.Status)
+	c.Writer.Write(record.Body)
+	c.Abort()
+}
+
+// responseRecorder пишет ответ клиенту и сохраняет копию тела.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -9,42 +10,85 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/internal/tenant"
 	"github.com/polzovatel/todo-learning/logger"
 )
 
-func AuthMiddleware(signer *auth.JWTSigner, appLogger *slog.Logger) gin.HandlerFunc {
+// Типы субъектов, от имени которых выполняется запрос.
+const (
+	PrincipalUser    = "user"
+	PrincipalService = "service"
+)
+
+func AuthMiddleware(tokens service.TokenService, cookies auth.CookieSettings, appLogger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		reqLogger := logger.LoggerFromContext(c, appLogger)
-		// 1. Извлекаем токен
+		// 1. Извлекаем токен: заголовок Authorization, а в cookie-режиме — access cookie
 		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			reqLogger.Warn("Authorization header is empty", slog.String("value", authHeader))
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Authorization header is empty"})
-			return
+		var tokenString string
+		if authHeader == "" && cookies.Enabled {
+			if cookie, err := c.Cookie(auth.AccessCookieName); err == nil && cookie != "" {
+				if !csrfValid(c) {
+					reqLogger.Warn("CSRF token is missing or invalid")
+					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "CSRF token is missing or invalid"})
+					return
+				}
+				tokenString = cookie
+				c.Set("auth_cookie", true)
+			}
 		}
 
-		// 2. Проверяем формат
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			reqLogger.Warn("Authorization header is invalid", slog.String("value", authHeader))
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Authorization header is invalid"})
-			return
+		if tokenString == "" {
+			if authHeader == "" {
+				reqLogger.Warn("Authorization header is empty", slog.String("value", authHeader))
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Authorization header is empty"})
+				return
+			}
+
+			// 2. Проверяем формат
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				reqLogger.Warn("Authorization header is invalid", slog.String("value", authHeader))
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Authorization header is invalid"})
+				return
+			}
+			tokenString = parts[1]
 		}
-		tokenString := parts[1]
 
-		// 3. Валидировать токен
-		claims, err := signer.ValidateToken(tokenString)
+		// 3. Валидировать токен (подпись, срок действия, отзыв)
+		claims, err := tokens.ValidateToken(c, tokenString)
 		if err != nil {
 			reqLogger.Error("Token validation failed", slog.Any("error", err))
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
 			return
 		}
 
+		// Токен из ссылки входа обменивается на пару токенов и не подходит для API.
+		if claims.Type == auth.TokenTypeMagicLink {
+			reqLogger.Warn("magic link token used as bearer token")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid token type"})
+			return
+		}
+
 		// 4. Сохранить данные из токена в контекст
+		c.Set("claims", claims)
+		c.Set("type", claims.Type)
+		if claims.Type == auth.TokenTypeClient {
+			c.Set("principal", PrincipalService)
+			c.Set("client_id", claims.ClientID)
+			c.Set("scopes", strings.Fields(claims.Scope))
+
+			reqLogger.Info("token validated", slog.String("client_id", claims.ClientID), slog.String("token_type", claims.Type))
+			c.Next()
+			return
+		}
+		c.Set("principal", PrincipalUser)
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
-		c.Set("type", claims.Type)
 
 		// 5. Передать в handler
 		reqLogger.Info("token validated", slog.String("user_id", claims.UserID), slog.String("token_type", claims.Type))
@@ -52,6 +96,107 @@ func AuthMiddleware(signer *auth.JWTSigner, appLogger *slog.Logger) gin.HandlerF
 	}
 }
 
+// CSRFMiddleware защищает публичные маршруты, которые читают refresh cookie (например, /refresh).
+func CSRFMiddleware(cookies auth.CookieSettings, appLogger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cookies.Enabled {
+			if cookie, err := c.Cookie(auth.RefreshCookieName); err == nil && cookie != "" && !csrfValid(c) {
+				logger.LoggerFromContext(c, appLogger).Warn("CSRF token is missing or invalid")
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "CSRF token is missing or invalid"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// csrfValid реализует double-submit: для изменяющих запросов заголовок X-CSRF-Token
+// должен совпадать с csrf cookie.
+func csrfValid(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	cookie, _ := c.Cookie(auth.CSRFCookieName)
+	return auth.ValidCSRF(c.GetHeader(auth.CSRFHeaderName), cookie)
+}
+
+// RequireUser пропускает только токены пользователей.
+func RequireUser(appLogger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("principal") != PrincipalUser {
+			logger.LoggerFromContext(c, appLogger).Warn("user token required", slog.String("principal", c.GetString("principal")))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "user token required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// WorkspaceHeader выбирает рабочее пространство запроса и перекрывает claim wid токена.
+const WorkspaceHeader = "X-Workspace-ID"
+
+// WorkspaceMiddleware определяет рабочее пространство запроса: заголовок X-Workspace-ID,
+// иначе claim wid токена, иначе личное пространство пользователя. Членство проверяется
+// на каждом запросе, так что исключённый участник теряет доступ сразу, а не с истечением токена.
+func WorkspaceMiddleware(workspaces service.WorkspaceService, appLogger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reqLogger := logger.LoggerFromContext(c, appLogger)
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
+			reqLogger.Warn("user id not found in context")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+			return
+		}
+
+		raw := c.GetHeader(WorkspaceHeader)
+		if raw == "" {
+			if claims, ok := c.Get("claims"); ok {
+				raw = claims.(*models.Claims).WorkspaceID
+			}
+		}
+		workspaceID := userID
+		if raw != "" {
+			if workspaceID, err = uuid.Parse(raw); err != nil {
+				reqLogger.Warn("invalid workspace id", slog.String("value", raw))
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid workspace id"})
+				return
+			}
+		}
+
+		if workspaceID != userID {
+			if _, err := workspaces.Membership(c, workspaceID, userID); err != nil {
+				if errors.Is(err, domain.ErrNotWorkspaceMember) {
+					reqLogger.Warn("workspace access denied", slog.String("workspace_id", workspaceID.String()), slog.String("user_id", userID.String()))
+					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": err.Error()})
+					return
+				}
+				reqLogger.Error("workspace membership check failed", slog.Any("error", err))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+				return
+			}
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithWorkspace(c.Request.Context(), workspaceID))
+		c.Set("workspace_id", workspaceID.String())
+		c.Next()
+	}
+}
+
+// RequireScope пропускает только машинных клиентов, которым выданы все перечисленные scopes.
+func RequireScope(appLogger *slog.Logger, scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted := c.GetStringSlice("scopes")
+		if c.GetString("principal") != PrincipalService || !auth.HasScopes(granted, scopes...) {
+			logger.LoggerFromContext(c, appLogger).Warn("insufficient scope", slog.Any("required", scopes), slog.Any("granted", granted))
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+strings.Join(scopes, " ")+`"`)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "insufficient scope"})
+			return
+		}
+		c.Next()
+	}
+}
+
 func RequestLoggerMiddleware(base *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
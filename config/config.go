@@ -26,6 +26,52 @@ type Config struct {
 	AccessTTL     time.Duration
 	RefreshTTL    time.Duration
 
+	// Браузерные сессии: токены в HttpOnly cookies + double-submit CSRF.
+	AuthCookies    bool
+	CookieSecure   bool
+	CookieSameSite string
+	CookieDomain   string
+
+	// WebAuthn: RP ID — домен сайта, Origin — полный адрес фронтенда.
+	WebAuthnRPID   string
+	WebAuthnRPName string
+	WebAuthnOrigin string
+
+	// Вход по ссылке из письма: адрес callback и время жизни ссылки.
+	MagicLinkURL string
+	MagicLinkTTL time.Duration
+
+	// Почта уходит через SMTP. Без SMTP_HOST письма только отмечаются в логе — так можно
+	// только в ENV=dev.
+	SMTPHost string
+	SMTPPort string
+	SMTPUser string
+	SMTPPass string
+	MailFrom string
+
+	// TodoMaxDepth — максимальная глубина подзадач: у задач верхнего уровня глубина 0.
+	TodoMaxDepth int
+
+	// Корзина: удалённые задачи хранятся TrashRetention, проверка — раз в TrashPurgeInterval.
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	// IdempotencyTTL — сколько хранится ответ на запрос с заголовком Idempotency-Key.
+	IdempotencyTTL time.Duration
+
+	// Вложения: содержимое хранится локально (BlobDir) или в S3-совместимом хранилище.
+	// Осиротевшие вложения удалённых задач и пользователей чистятся раз в AttachmentSweepInterval.
+	BlobBackend             string
+	BlobDir                 string
+	S3Endpoint              string
+	S3Region                string
+	S3Bucket                string
+	S3AccessKey             string
+	S3SecretKey             string
+	AttachmentMaxSize       int64
+	AttachmentTypes         []string
+	AttachmentSweepInterval time.Duration
+
 	DBHost string
 	DBPort string
 	DBUser string
@@ -35,6 +81,15 @@ type Config struct {
 	RedisAddr string
 	RedisPass string
 	RedisDB   int
+
+	OAuthClients []OAuthClient
+}
+
+// OAuthClient — машинный клиент, регистрируемый при старте из OAUTH_CLIENTS.
+type OAuthClient struct {
+	ID     string
+	Secret string
+	Scopes []string
 }
 
 func LoadCFG() (*Config, error) {
@@ -53,6 +108,35 @@ func LoadCFG() (*Config, error) {
 		JWTPrivatePEM: getEnv("JWT_PRIVATE_PEM", "secret"),
 		JWTSecret:     getEnv("JWT_SECRET", "secret"),
 
+		AuthCookies:    getEnvBool("AUTH_COOKIES", false),
+		CookieSecure:   getEnvBool("COOKIE_SECURE", true),
+		CookieSameSite: strings.ToLower(getEnv("COOKIE_SAMESITE", "strict")),
+		CookieDomain:   getEnv("COOKIE_DOMAIN", ""),
+
+		WebAuthnRPID:   getEnv("WEBAUTHN_RP_ID", "localhost"),
+		WebAuthnRPName: getEnv("WEBAUTHN_RP_NAME", "Todo"),
+		WebAuthnOrigin: getEnv("WEBAUTHN_ORIGIN", "http://localhost:8080"),
+
+		MagicLinkURL: getEnv("MAGIC_LINK_URL", "http://localhost:8080/api/v1/login/magic/callback"),
+
+		SMTPHost: getEnv("SMTP_HOST", ""),
+		SMTPPort: getEnv("SMTP_PORT", "587"),
+		SMTPUser: getEnv("SMTP_USER", ""),
+		SMTPPass: getEnv("SMTP_PASS", ""),
+		MailFrom: getEnv("MAIL_FROM", ""),
+
+		TodoMaxDepth: getEnvInt("TODO_MAX_DEPTH", 3),
+
+		BlobBackend:       strings.ToLower(getEnv("BLOB_BACKEND", "local")),
+		BlobDir:           getEnv("BLOB_DIR", "./data/blobs"),
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3AccessKey:       getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:       getEnv("S3_SECRET_KEY", ""),
+		AttachmentMaxSize: int64(getEnvInt("ATTACHMENT_MAX_SIZE", 10<<20)),
+		AttachmentTypes:   splitList(getEnv("ATTACHMENT_TYPES", "image/*,application/pdf,text/plain")),
+
 		DBHost: getEnv("DB_HOST", "localhost"),
 		DBPort: getEnv("DB_PORT", "5432"),
 		DBUser: getEnv("DB_USER", "postgres"),
@@ -70,6 +154,24 @@ func LoadCFG() (*Config, error) {
 	if cfg.RefreshTTL, err = parseDuration("REFRESH_TTL", "15m"); err != nil {
 		return nil, err
 	}
+	if cfg.MagicLinkTTL, err = parseDuration("MAGIC_LINK_TTL", "15m"); err != nil {
+		return nil, err
+	}
+	if cfg.AttachmentSweepInterval, err = parseDuration("ATTACHMENT_SWEEP_INTERVAL", "1m"); err != nil {
+		return nil, err
+	}
+	if cfg.TrashRetention, err = parseDuration("TRASH_RETENTION", "720h"); err != nil {
+		return nil, err
+	}
+	if cfg.TrashPurgeInterval, err = parseDuration("TRASH_PURGE_INTERVAL", "1h"); err != nil {
+		return nil, err
+	}
+	if cfg.IdempotencyTTL, err = parseDuration("IDEMPOTENCY_TTL", "24h"); err != nil {
+		return nil, err
+	}
+	if cfg.OAuthClients, err = parseOAuthClients(getEnv("OAUTH_CLIENTS", "")); err != nil {
+		return nil, err
+	}
 	// Критичные проверки безопасности.
 	if cfg.PasswordPepper == "" {
 		return nil, errors.New("PASSWORD_PEPPER is required")
@@ -86,6 +188,39 @@ func LoadCFG() (*Config, error) {
 	default:
 		return nil, fmt.Errorf("unsupported JWT_ALG=%s (use RS256 or HS256)", cfg.JWTAlg)
 	}
+	switch cfg.CookieSameSite {
+	case "strict", "lax":
+	case "none":
+		if !cfg.CookieSecure {
+			return nil, errors.New("COOKIE_SAMESITE=none requires COOKIE_SECURE=true")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported COOKIE_SAMESITE=%s (use strict, lax or none)", cfg.CookieSameSite)
+	}
+	switch cfg.BlobBackend {
+	case "local":
+	case "s3":
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+			return nil, errors.New("BLOB_BACKEND=s3 requires S3_ENDPOINT and S3_BUCKET")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported BLOB_BACKEND=%s (use local or s3)", cfg.BlobBackend)
+	}
+	switch {
+	case cfg.SMTPHost == "" && cfg.Env != "dev":
+		return nil, fmt.Errorf("ENV=%s: SMTP_HOST is required, the log mailer is for dev only", cfg.Env)
+	case cfg.SMTPHost != "" && cfg.MailFrom == "":
+		return nil, errors.New("SMTP_HOST set: MAIL_FROM is required")
+	}
+	if cfg.AttachmentMaxSize <= 0 {
+		return nil, errors.New("ATTACHMENT_MAX_SIZE must be positive")
+	}
+	if cfg.TrashRetention <= 0 {
+		return nil, errors.New("TRASH_RETENTION must be positive")
+	}
+	if cfg.IdempotencyTTL <= 0 {
+		return nil, errors.New("IDEMPOTENCY_TTL must be positive")
+	}
 
 	return cfg, nil
 }
@@ -111,6 +246,17 @@ func getEnvInt(key string, fallback int) int {
 	return fallback
 }
 
+func getEnvBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		result, err := strconv.ParseBool(value)
+		if err != nil {
+			return fallback
+		}
+		return result
+	}
+	return fallback
+}
+
 func parseDuration(key, def string) (time.Duration, error) {
 	raw := getEnv(key, def)
 	d, err := time.ParseDuration(raw)
@@ -119,3 +265,35 @@ func parseDuration(key, def string) (time.Duration, error) {
 	}
 	return d, nil
 }
+
+// parseOAuthClients разбирает строку вида "id:secret:scope1 scope2;id2:secret2:scope1".
+func parseOAuthClients(raw string) ([]OAuthClient, error) {
+	var clients []OAuthClient
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid OAUTH_CLIENTS entry %q (want id:secret:scopes)", entry)
+		}
+		clients = append(clients, OAuthClient{
+			ID:     parts[0],
+			Secret: parts[1],
+			Scopes: strings.Fields(parts[2]),
+		})
+	}
+	return clients, nil
+}
+
+// splitList разбирает список через запятую, пропуская пустые элементы.
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	AccessCookieName  = "access_token"
+	RefreshCookieName = "refresh_token"
+	CSRFCookieName    = "csrf_token"
+	CSRFHeaderName    = "X-CSRF-Token"
+
+	cookiePath = "/api/v1"
+	// csrfCookiePath шире cookiePath: CSRF token читают страницы фронтенда вне /api/v1.
+	csrfCookiePath = "/"
+)
+
+// refreshCookiePaths — маршруты, которым нужен refresh token. Остальным запросам браузер
+// его не отправляет; у cookie один путь, поэтому она ставится на каждый.
+var refreshCookiePaths = []string{cookiePath + "/refresh", cookiePath + "/logout"}
+
+// CookieSettings описывает режим браузерных сессий: токены в HttpOnly cookies
+// и double-submit CSRF token в cookie, доступной JavaScript.
+type CookieSettings struct {
+	Enabled  bool
+	Secure   bool
+	SameSite http.SameSite
+	Domain   string
+}
+
+// ParseSameSite переводит значение из конфига в http.SameSite.
+func ParseSameSite(value string) (http.SameSite, bool) {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode, true
+	case "lax":
+		return http.SameSiteLaxMode, true
+	case "none":
+		return http.SameSiteNoneMode, true
+	default:
+		return http.SameSiteDefaultMode, false
+	}
+}
+
+func NewCSRFToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// ValidCSRF сравнивает токен из заголовка с токеном из cookie за постоянное время.
+func ValidCSRF(header, cookie string) bool {
+	if header == "" || cookie == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(header), []byte(cookie)) == 1
+}
+
+func (s CookieSettings) SetSession(w http.ResponseWriter, accessToken string, accessTTL time.Duration, refreshToken string, refreshTTL time.Duration, csrfToken string) {
+	http.SetCookie(w, s.cookie(AccessCookieName, accessToken, accessTTL, true, cookiePath))
+	for _, path := range refreshCookiePaths {
+		http.SetCookie(w, s.cookie(RefreshCookieName, refreshToken, refreshTTL, true, path))
+	}
+	// CSRF cookie должна читаться фронтендом, поэтому без HttpOnly.
+	http.SetCookie(w, s.cookie(CSRFCookieName, csrfToken, refreshTTL, false, csrfCookiePath))
+}
+
+func (s CookieSettings) ClearSession(w http.ResponseWriter) {
+	http.SetCookie(w, s.expired(AccessCookieName, true, cookiePath))
+	for _, path := range refreshCookiePaths {
+		http.SetCookie(w, s.expired(RefreshCookieName, true, path))
+	}
+	http.SetCookie(w, s.expired(CSRFCookieName, false, csrfCookiePath))
+}
+
+func (s CookieSettings) expired(name string, httpOnly bool, path string) *http.Cookie {
+	c := s.cookie(name, "", 0, httpOnly, path)
+	c.MaxAge = -1
+	return c
+}
+
+func (s CookieSettings) cookie(name, value string, ttl time.Duration, httpOnly bool, path string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   s.Domain,
+		MaxAge:   int(ttl.Seconds()),
+		Secure:   s.Secure,
+		HttpOnly: httpOnly,
+		SameSite: s.SameSite,
+	}
+}
@@ -5,11 +5,23 @@ import (
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/polzovatel/todo-learning/config"
 	"github.com/polzovatel/todo-learning/internal/models"
+	"strings"
 	"time"
 )
 
+const (
+	TokenTypeAccess  = "access_token"
+	TokenTypeRefresh = "refresh_token"
+	TokenTypeClient  = "client_token"
+	// TokenTypeMagicLink — одноразовый токен из письма со ссылкой для входа.
+	TokenTypeMagicLink = "magic_link"
+	// TokenTypePersonal — персональный токен доступа (PAT); это не JWT, а случайная строка.
+	TokenTypePersonal = "personal_token"
+)
+
 type JWTSigner struct {
 	alg        string
 	hsSectet   []byte
@@ -57,13 +69,22 @@ func NewJWTSigner(cfg *config.Config) (*JWTSigner, error) {
 }
 
 func (s *JWTSigner) GenerateAccessToken(userID, email, role string) (string, error) {
+	return s.GenerateWorkspaceAccessToken(userID, email, role, "")
+}
+
+// GenerateWorkspaceAccessToken выпускает access token, привязанный к рабочему пространству
+// (claim wid). Пустой workspaceID — личное пространство пользователя.
+func (s *JWTSigner) GenerateWorkspaceAccessToken(userID, email, role, workspaceID string) (string, error) {
 	now := time.Now()
 	claims := &models.Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		Type:   "access_token",
+		UserID:      userID,
+		Email:       email,
+		Role:        role,
+		Type:        TokenTypeAccess,
+		WorkspaceID: workspaceID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID,
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
 			IssuedAt:  jwt.NewNumericDate(now),
 		},
@@ -78,8 +99,9 @@ func (s *JWTSigner) GenerateRefreshToken(userID, email, role string) (string, er
 		UserID: userID,
 		Email:  email,
 		Role:   role,
-		Type:   "refresh_token",
+		Type:   TokenTypeRefresh,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			Subject:   userID,
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.refreshTTL)),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -89,6 +111,57 @@ func (s *JWTSigner) GenerateRefreshToken(userID, email, role string) (string, er
 	return s.sign(claims)
 }
 
+// GenerateClientToken выпускает access token для машинного клиента (client credentials grant).
+func (s *JWTSigner) GenerateClientToken(clientID string, scopes []string) (string, error) {
+	now := time.Now()
+	claims := &models.Claims{
+		ClientID: clientID,
+		Scope:    strings.Join(scopes, " "),
+		Type:     TokenTypeClient,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   clientID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	return s.sign(claims)
+}
+
+// GenerateMagicLinkToken выпускает короткоживущий токен для ссылки входа.
+// Возвращает сам токен и его jti, по которому хранилище гарантирует одноразовость.
+func (s *JWTSigner) GenerateMagicLinkToken(userID, email string, ttl time.Duration) (string, string, error) {
+	now := time.Now()
+	claims := &models.Claims{
+		UserID: userID,
+		Email:  email,
+		Type:   TokenTypeMagicLink,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token, err := s.sign(claims)
+	if err != nil {
+		return "", "", err
+	}
+	return token, claims.ID, nil
+}
+
+// AccessTTL возвращает время жизни access token (нужно для expires_in в ответах OAuth).
+func (s *JWTSigner) AccessTTL() time.Duration {
+	return s.accessTTL
+}
+
+// RefreshTTL возвращает время жизни refresh token.
+func (s *JWTSigner) RefreshTTL() time.Duration {
+	return s.refreshTTL
+}
+
 func (s *JWTSigner) sign(claims *models.Claims) (string, error) {
 	token := jwt.NewWithClaims(jwt.GetSigningMethod(s.jwtMethodName()), claims)
 	switch s.alg {
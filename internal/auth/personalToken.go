@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// PersonalTokenPrefix отличает персональный токен от JWT и помогает сканерам секретов
+// находить его в коде.
+const PersonalTokenPrefix = "todo_pat_"
+
+// NewPersonalToken создаёт персональный токен и его хэш для хранения.
+func NewPersonalToken() (token, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	token = PersonalTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return token, HashPersonalToken(token), nil
+}
+
+// HashPersonalToken — SHA-256 токена. У токена 256 бит случайности, поэтому медленный
+// хэш, как для паролей, не нужен, а поиск по хэшу остаётся точным.
+func HashPersonalToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsPersonalToken сообщает, похожа ли строка на персональный токен, а не на JWT.
+func IsPersonalToken(token string) bool {
+	return strings.HasPrefix(token, PersonalTokenPrefix)
+}
@@ -0,0 +1,34 @@
+package auth
+
+// Scopes, которые можно выдать машинным клиентам.
+const (
+	ScopeUsersRead = "users:read"
+	ScopeTodosRead = "todos:read"
+	// ScopeTokensIntrospect разрешает вызывать /oauth/introspect.
+	ScopeTokensIntrospect = "tokens:introspect"
+)
+
+var knownScopes = map[string]struct{}{
+	ScopeUsersRead:        {},
+	ScopeTodosRead:        {},
+	ScopeTokensIntrospect: {},
+}
+
+func IsKnownScope(scope string) bool {
+	_, ok := knownScopes[scope]
+	return ok
+}
+
+// HasScopes проверяет, что все required присутствуют в granted.
+func HasScopes(granted []string, required ...string) bool {
+	set := make(map[string]struct{}, len(granted))
+	for _, s := range granted {
+		set[s] = struct{}{}
+	}
+	for _, s := range required {
+		if _, ok := set[s]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Минимальный CBOR (RFC 8949): ровно то, что встречается в attestationObject и COSE-ключах.
+// Неопределённые длины и числа с плавающей точкой не поддерживаются.
+
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+
+	cborMaxDepth = 16
+)
+
+var errCBOR = errors.New("webauthn: malformed cbor")
+
+// cborDecode декодирует первый элемент и возвращает остаток данных.
+// Целые числа возвращаются как int64, ключи map — int64 или string.
+func cborDecode(data []byte) (any, []byte, error) {
+	d := &cborDecoder{data: data}
+	v, err := d.decode(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return v, d.data[d.pos:], nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, errCBOR
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info := b>>5, b&0x1f
+
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("%w: unsupported additional info %d", errCBOR, info)
+	}
+	if d.pos+size > len(d.data) {
+		return 0, 0, errCBOR
+	}
+	var arg uint64
+	for _, c := range d.data[d.pos : d.pos+size] {
+		arg = arg<<8 | uint64(c)
+	}
+	d.pos += size
+	return major, arg, nil
+}
+
+func (d *cborDecoder) decode(depth int) (any, error) {
+	if depth > cborMaxDepth {
+		return nil, fmt.Errorf("%w: nesting too deep", errCBOR)
+	}
+	major, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("%w: integer overflow", errCBOR)
+		}
+		return int64(arg), nil
+	case cborNegInt:
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("%w: integer overflow", errCBOR)
+		}
+		return -1 - int64(arg), nil
+	case cborBytes, cborText:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errCBOR
+		}
+		raw := d.data[d.pos : d.pos+int(arg)]
+		d.pos += int(arg)
+		if major == cborText {
+			return string(raw), nil
+		}
+		return bytes.Clone(raw), nil
+	case cborArray:
+		// Каждый элемент занимает минимум байт — защита от огромных аллокаций.
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errCBOR
+		}
+		items := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			item, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case cborMap:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errCBOR
+		}
+		m := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("%w: unsupported map key", errCBOR)
+			}
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		}
+		return m, nil
+	case cborTag:
+		return d.decode(depth + 1)
+	default:
+		switch arg {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: unsupported simple value %d", errCBOR, arg)
+	}
+}
+
+// cborWriter кодирует значения в том же подмножестве CBOR; нужен виртуальному аутентификатору.
+type cborWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *cborWriter) head(major byte, arg uint64) {
+	switch {
+	case arg < 24:
+		w.buf.WriteByte(major<<5 | byte(arg))
+	case arg <= math.MaxUint8:
+		w.buf.WriteByte(major<<5 | 24)
+		w.buf.WriteByte(byte(arg))
+	case arg <= math.MaxUint16:
+		w.buf.WriteByte(major<<5 | 25)
+		w.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(arg)))
+	case arg <= math.MaxUint32:
+		w.buf.WriteByte(major<<5 | 26)
+		w.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(arg)))
+	default:
+		w.buf.WriteByte(major<<5 | 27)
+		w.buf.Write(binary.BigEndian.AppendUint64(nil, arg))
+	}
+}
+
+func (w *cborWriter) int(v int64) {
+	if v >= 0 {
+		w.head(cborUint, uint64(v))
+		return
+	}
+	w.head(cborNegInt, uint64(-1-v))
+}
+
+func (w *cborWriter) bytes(v []byte) {
+	w.head(cborBytes, uint64(len(v)))
+	w.buf.Write(v)
+}
+
+func (w *cborWriter) text(v string) {
+	w.head(cborText, uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+func (w *cborWriter) mapHeader(n int) {
+	w.head(cborMap, uint64(n))
+}
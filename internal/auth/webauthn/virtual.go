@@ -0,0 +1,111 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+)
+
+// VirtualAuthenticator — программный аутентификатор для тестов: создаёт ES256-ключи
+// и отвечает на церемонии так же, как это делает браузер.
+type VirtualAuthenticator struct {
+	RPID   string
+	Origin string
+
+	credentials map[string]*virtualCredential
+}
+
+type virtualCredential struct {
+	key       *ecdsa.PrivateKey
+	signCount uint32
+}
+
+func NewVirtualAuthenticator(rpID, origin string) *VirtualAuthenticator {
+	return &VirtualAuthenticator{
+		RPID:        rpID,
+		Origin:      origin,
+		credentials: make(map[string]*virtualCredential),
+	}
+}
+
+// Register возвращает credential id, clientDataJSON и attestationObject для challenge.
+func (a *VirtualAuthenticator) Register(challenge string) ([]byte, []byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		return nil, nil, nil, err
+	}
+	cred := &virtualCredential{key: key}
+	a.credentials[string(credentialID)] = cred
+
+	clientData, err := a.clientData(ceremonyCreate, challenge)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	authData := a.authData(flagUserPresent|flagAttestedData, cred.signCount)
+	authData = append(authData, make([]byte, 16)...) // aaguid
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(credentialID)))
+	authData = append(authData, credentialID...)
+	authData = append(authData, encodePublicKey(&key.PublicKey)...)
+
+	var w cborWriter
+	w.mapHeader(3)
+	w.text("fmt")
+	w.text("none")
+	w.text("attStmt")
+	w.mapHeader(0)
+	w.text("authData")
+	w.bytes(authData)
+
+	return credentialID, clientData, w.buf.Bytes(), nil
+}
+
+// Assert подписывает challenge ключом credentialID и увеличивает счётчик подписей.
+// Возвращает clientDataJSON, authenticatorData и подпись.
+func (a *VirtualAuthenticator) Assert(credentialID []byte, challenge string) ([]byte, []byte, []byte, error) {
+	cred, ok := a.credentials[string(credentialID)]
+	if !ok {
+		return nil, nil, nil, errors.New("virtual authenticator: unknown credential")
+	}
+	cred.signCount++
+
+	clientData, err := a.clientData(ceremonyGet, challenge)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	authData := a.authData(flagUserPresent, cred.signCount)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, cred.key, digest[:])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return clientData, authData, signature, nil
+}
+
+// SetSignCount позволяет смоделировать клонированный аутентификатор.
+func (a *VirtualAuthenticator) SetSignCount(credentialID []byte, count uint32) {
+	if cred, ok := a.credentials[string(credentialID)]; ok {
+		cred.signCount = count
+	}
+}
+
+func (a *VirtualAuthenticator) clientData(ceremony, challenge string) ([]byte, error) {
+	return json.Marshal(ClientData{Type: ceremony, Challenge: challenge, Origin: a.Origin})
+}
+
+func (a *VirtualAuthenticator) authData(flags byte, signCount uint32) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	data := append([]byte(nil), rpIDHash[:]...)
+	data = append(data, flags)
+	return binary.BigEndian.AppendUint32(data, signCount)
+}
@@ -0,0 +1,291 @@
+// Package webauthn проверяет WebAuthn-церемонии регистрации и входа.
+// Поддерживается attestation "none" и ключи ES256 (ECDSA P-256) — этого
+// достаточно для passkeys в браузерах и платформенных аутентификаторах.
+package webauthn
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// AlgES256 — идентификатор алгоритма COSE для ECDSA P-256 + SHA-256.
+const AlgES256 = -7
+
+const (
+	flagUserPresent   = 0x01
+	flagAttestedData  = 0x40
+	flagExtensionData = 0x80
+
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+var (
+	ErrInvalidClientData      = errors.New("webauthn: invalid client data")
+	ErrChallengeMismatch      = errors.New("webauthn: challenge mismatch")
+	ErrOriginMismatch         = errors.New("webauthn: origin mismatch")
+	ErrRPIDMismatch           = errors.New("webauthn: rp id hash mismatch")
+	ErrUserNotPresent         = errors.New("webauthn: user presence flag not set")
+	ErrUnsupportedAttestation = errors.New("webauthn: only \"none\" attestation is supported")
+	ErrUnsupportedKey         = errors.New("webauthn: only ES256 P-256 keys are supported")
+	ErrInvalidAuthData        = errors.New("webauthn: invalid authenticator data")
+	ErrInvalidSignature       = errors.New("webauthn: invalid signature")
+	ErrSignCount              = errors.New("webauthn: sign count did not increase, authenticator may be cloned")
+)
+
+// RelyingParty — параметры нашего сервиса как проверяющей стороны.
+type RelyingParty struct {
+	ID     string
+	Name   string
+	Origin string
+}
+
+type ClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// ParseClientData разбирает clientDataJSON. Из него берётся challenge, по которому
+// ищется сохранённая церемония, поэтому функция экспортирована.
+func ParseClientData(raw []byte) (*ClientData, error) {
+	var cd ClientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClientData, err)
+	}
+	if cd.Type == "" || cd.Challenge == "" {
+		return nil, ErrInvalidClientData
+	}
+	return &cd, nil
+}
+
+// NewChallenge возвращает 32 случайных байта в base64url, как их видит clientDataJSON.
+func NewChallenge() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, ErrInvalidAuthData
+	}
+	ad := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if ad.flags&flagAttestedData != 0 {
+		// aaguid (16) + длина credential id (2) + credential id + COSE-ключ
+		if len(rest) < 18 {
+			return nil, ErrInvalidAuthData
+		}
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < idLen {
+			return nil, ErrInvalidAuthData
+		}
+		ad.credentialID = rest[:idLen]
+		rest = rest[idLen:]
+
+		_, after, err := cborDecode(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAuthData, err)
+		}
+		ad.publicKey = rest[:len(rest)-len(after)]
+		rest = after
+	}
+	if ad.flags&flagExtensionData != 0 {
+		_, after, err := cborDecode(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAuthData, err)
+		}
+		rest = after
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing bytes", ErrInvalidAuthData)
+	}
+	return ad, nil
+}
+
+func (rp RelyingParty) verifyClientData(raw []byte, ceremony, challenge string) error {
+	cd, err := ParseClientData(raw)
+	if err != nil {
+		return err
+	}
+	if cd.Type != ceremony {
+		return fmt.Errorf("%w: unexpected type %q", ErrInvalidClientData, cd.Type)
+	}
+	if subtle.ConstantTimeCompare([]byte(cd.Challenge), []byte(challenge)) != 1 {
+		return ErrChallengeMismatch
+	}
+	if cd.Origin != rp.Origin {
+		return ErrOriginMismatch
+	}
+	return nil
+}
+
+func (rp RelyingParty) verifyAuthData(ad *authenticatorData) error {
+	want := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(ad.rpIDHash, want[:]) != 1 {
+		return ErrRPIDMismatch
+	}
+	if ad.flags&flagUserPresent == 0 {
+		return ErrUserNotPresent
+	}
+	return nil
+}
+
+// Registration — данные нового ключа, которые нужно сохранить.
+type Registration struct {
+	CredentialID []byte
+	PublicKey    []byte
+	SignCount    uint32
+}
+
+// VerifyRegistration проверяет ответ navigator.credentials.create().
+func (rp RelyingParty) VerifyRegistration(clientDataJSON, attestationObject []byte, challenge string) (*Registration, error) {
+	if err := rp.verifyClientData(clientDataJSON, ceremonyCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, _, err := cborDecode(attestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAuthData, err)
+	}
+	att, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, ErrInvalidAuthData
+	}
+	if att["fmt"] != "none" {
+		return nil, ErrUnsupportedAttestation
+	}
+	if stmt, ok := att["attStmt"].(map[any]any); !ok || len(stmt) != 0 {
+		return nil, ErrUnsupportedAttestation
+	}
+	rawAuthData, ok := att["authData"].([]byte)
+	if !ok {
+		return nil, ErrInvalidAuthData
+	}
+
+	ad, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthData(ad); err != nil {
+		return nil, err
+	}
+	if ad.flags&flagAttestedData == 0 || len(ad.credentialID) == 0 {
+		return nil, fmt.Errorf("%w: attested credential data missing", ErrInvalidAuthData)
+	}
+	if _, err := ParsePublicKey(ad.publicKey); err != nil {
+		return nil, err
+	}
+
+	return &Registration{
+		CredentialID: ad.credentialID,
+		PublicKey:    ad.publicKey,
+		SignCount:    ad.signCount,
+	}, nil
+}
+
+// VerifyAssertion проверяет ответ navigator.credentials.get() и возвращает новый счётчик подписей.
+func (rp RelyingParty) VerifyAssertion(clientDataJSON, rawAuthData, signature, publicKey []byte, challenge string, storedSignCount uint32) (uint32, error) {
+	if err := rp.verifyClientData(clientDataJSON, ceremonyGet, challenge); err != nil {
+		return 0, err
+	}
+
+	ad, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+	if err := rp.verifyAuthData(ad); err != nil {
+		return 0, err
+	}
+
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), rawAuthData...), clientDataHash[:]...))
+	if !ecdsa.VerifyASN1(key, digest[:], signature) {
+		return 0, ErrInvalidSignature
+	}
+
+	// Аутентификаторы без счётчика всегда присылают 0 — это допустимо.
+	if (ad.signCount != 0 || storedSignCount != 0) && ad.signCount <= storedSignCount {
+		return 0, ErrSignCount
+	}
+	return ad.signCount, nil
+}
+
+// ParsePublicKey разбирает COSE_Key (RFC 9053) с kty=EC2, alg=ES256, crv=P-256.
+func ParsePublicKey(coseKey []byte) (*ecdsa.PublicKey, error) {
+	decoded, _, err := cborDecode(coseKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedKey, err)
+	}
+	m, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	if m[int64(1)] != int64(2) || m[int64(3)] != int64(AlgES256) || m[int64(-1)] != int64(1) {
+		return nil, ErrUnsupportedKey
+	}
+	x, okX := m[int64(-2)].([]byte)
+	y, okY := m[int64(-3)].([]byte)
+	if !okX || !okY || len(x) != 32 || len(y) != 32 {
+		return nil, ErrUnsupportedKey
+	}
+
+	// crypto/ecdh проверяет, что точка лежит на кривой.
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedKey, err)
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
+
+func encodePublicKey(key *ecdsa.PublicKey) []byte {
+	var w cborWriter
+	w.mapHeader(5)
+	w.int(1) // kty
+	w.int(2) // EC2
+	w.int(3) // alg
+	w.int(AlgES256)
+	w.int(-1) // crv
+	w.int(1)  // P-256
+	w.int(-2) // x
+	w.bytes(key.X.FillBytes(make([]byte, 32)))
+	w.int(-3) // y
+	w.bytes(key.Y.FillBytes(make([]byte, 32)))
+	return w.buf.Bytes()
+}
@@ -0,0 +1,90 @@
+package webauthn_test
+
+import (
+	"testing"
+
+	"github.com/polzovatel/todo-learning/internal/auth/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	rp := webauthn.RelyingParty{ID: "localhost", Name: "Todo", Origin: "http://localhost:8080"}
+	authenticator := webauthn.NewVirtualAuthenticator(rp.ID, rp.Origin)
+
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	credentialID, clientData, attestation, err := authenticator.Register(challenge)
+	require.NoError(t, err)
+
+	reg, err := rp.VerifyRegistration(clientData, attestation, challenge)
+	require.NoError(t, err)
+	assert.Equal(t, credentialID, reg.CredentialID)
+	assert.Equal(t, uint32(0), reg.SignCount)
+
+	t.Run("registration with another challenge", func(t *testing.T) {
+		_, err := rp.VerifyRegistration(clientData, attestation, "other")
+		assert.ErrorIs(t, err, webauthn.ErrChallengeMismatch)
+	})
+
+	t.Run("registration for another rp id", func(t *testing.T) {
+		other := webauthn.RelyingParty{ID: "example.com", Origin: rp.Origin}
+		_, err := other.VerifyRegistration(clientData, attestation, challenge)
+		assert.ErrorIs(t, err, webauthn.ErrRPIDMismatch)
+	})
+
+	t.Run("assertion succeeds and bumps sign count", func(t *testing.T) {
+		challenge, _ := webauthn.NewChallenge()
+		clientData, authData, signature, err := authenticator.Assert(credentialID, challenge)
+		require.NoError(t, err)
+
+		count, err := rp.VerifyAssertion(clientData, authData, signature, reg.PublicKey, challenge, reg.SignCount)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), count)
+	})
+
+	t.Run("assertion from wrong origin", func(t *testing.T) {
+		phishing := webauthn.NewVirtualAuthenticator(rp.ID, "https://evil.example")
+		challenge, _ := webauthn.NewChallenge()
+		_, clientData, attestation, _ := phishing.Register(challenge)
+
+		_, err := rp.VerifyRegistration(clientData, attestation, challenge)
+		assert.ErrorIs(t, err, webauthn.ErrOriginMismatch)
+	})
+
+	t.Run("assertion with tampered signature", func(t *testing.T) {
+		challenge, _ := webauthn.NewChallenge()
+		clientData, authData, signature, err := authenticator.Assert(credentialID, challenge)
+		require.NoError(t, err)
+		signature[len(signature)-1] ^= 0xff
+
+		_, err = rp.VerifyAssertion(clientData, authData, signature, reg.PublicKey, challenge, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("assertion with stale sign count", func(t *testing.T) {
+		authenticator.SetSignCount(credentialID, 4)
+		challenge, _ := webauthn.NewChallenge()
+		clientData, authData, signature, err := authenticator.Assert(credentialID, challenge)
+		require.NoError(t, err)
+
+		_, err = rp.VerifyAssertion(clientData, authData, signature, reg.PublicKey, challenge, 10)
+		assert.ErrorIs(t, err, webauthn.ErrSignCount)
+	})
+
+	t.Run("assertion used as registration", func(t *testing.T) {
+		challenge, _ := webauthn.NewChallenge()
+		clientData, _, _, err := authenticator.Assert(credentialID, challenge)
+		require.NoError(t, err)
+
+		_, err = rp.VerifyRegistration(clientData, attestation, challenge)
+		assert.ErrorIs(t, err, webauthn.ErrInvalidClientData)
+	})
+}
+
+func TestParsePublicKey_Malformed(t *testing.T) {
+	for _, data := range [][]byte{nil, {0xa1}, {0xbf, 0xff}, {0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}} {
+		_, err := webauthn.ParsePublicKey(data)
+		assert.ErrorIs(t, err, webauthn.ErrUnsupportedKey)
+	}
+}
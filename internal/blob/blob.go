@@ -0,0 +1,19 @@
+// Package blob хранит содержимое файлов (вложений задач) вне базы данных.
+package blob
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore хранит объекты по ключу. Ключи выдаёт вызывающий код; допустимы буквы,
+// цифры, '-', '_', '.' и '/' как разделитель.
+type BlobStore interface {
+	// Put сохраняет ровно size байт из r под ключом key, заменяя прежний объект.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get открывает объект на чтение; закрыть его должен вызывающий.
+	// Отсутствующий объект — domain.ErrBlobNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет объект; удаление отсутствующего объекта не ошибка.
+	Delete(ctx context.Context, key string) error
+}
@@ -18,14 +18,20 @@ import (
 
 type UserController struct {
 	service   service.Service
+	tokens    service.TokenService
 	jwtSigner *auth2.JWTSigner
+	cookies   auth2.CookieSettings
+	issuer    tokenIssuer
 	logger    *slog.Logger
 }
 
-func NewUserController(service service.Service, jwtSigner *auth2.JWTSigner, logger *slog.Logger) *UserController {
+func NewUserController(service service.Service, tokens service.TokenService, jwtSigner *auth2.JWTSigner, cookies auth2.CookieSettings, logger *slog.Logger) *UserController {
 	return &UserController{
 		service:   service,
+		tokens:    tokens,
 		jwtSigner: jwtSigner,
+		cookies:   cookies,
+		issuer:    tokenIssuer{signer: jwtSigner, cookies: cookies},
 		logger:    logger,
 	}
 }
@@ -108,27 +114,134 @@ func (c *UserController) LoginUser(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, err := c.jwtSigner.GenerateAccessToken(user.ID.String(), user.Email, "access_token")
+	body, err := c.issuer.issue(ctx, user)
 	if err != nil {
-		appLogger.Error("failed to generate access token", slog.Any("error", err))
+		appLogger.Error("failed to issue tokens", slog.Any("error", err))
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	refreshToken, err := c.jwtSigner.GenerateRefreshToken(user.ID.String(), user.Email, "refresh_token")
+	appLogger.Info("user logged in", slog.String("email", user.Email))
+	ctx.JSON(http.StatusOK, body)
+}
+
+// RefreshToken обменивает refresh token (из тела или cookie) на новую пару токенов.
+// Старый refresh token отзывается, так что повторно использовать его нельзя.
+func (c *UserController) RefreshToken(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+
+	var req models.RefreshRequest
+	if c.cookies.Enabled {
+		req.RefreshToken, _ = ctx.Cookie(auth2.RefreshCookieName)
+	}
+	if req.RefreshToken == "" {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			appLogger.Warn("invalid refresh payload", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	claims, err := c.tokens.ValidateToken(ctx, req.RefreshToken)
+	if err != nil || claims.Type != auth2.TokenTypeRefresh {
+		appLogger.Warn("invalid refresh token", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		appLogger.Warn("invalid user id in refresh token", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	user, err := c.service.GetUserById(ctx, userID)
 	if err != nil {
-		appLogger.Error("failed to generate refresh token", slog.Any("error", err))
+		if errors.Is(err, domain.ErrUserNotFound) {
+			appLogger.Warn("user from refresh token not found", slog.String("user_id", claims.UserID))
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		appLogger.Error("failed to fetch user", slog.Any("error", err))
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	appLogger.Info("user logged in", slog.String("email", user.Email))
-	ctx.JSON(http.StatusOK, gin.H{"accessToken": accessToken, "refreshToken": refreshToken})
+	// Отзыв и есть проверка одноразовости: из одновременных обменов одного токена
+	// пройдёт только первый.
+	if err := c.tokens.ConsumeToken(ctx, claims); err != nil {
+		if errors.Is(err, domain.ErrTokenRevoked) {
+			appLogger.Warn("refresh token already redeemed", slog.String("user_id", claims.UserID))
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		appLogger.Error("failed to revoke refresh token", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, err := c.issuer.issue(ctx, user)
+	if err != nil {
+		appLogger.Error("failed to issue tokens", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	appLogger.Info("tokens refreshed", slog.String("user_id", claims.UserID))
+	ctx.JSON(http.StatusOK, body)
 }
 
+// LogoutUser отзывает текущий access token и, если передан (в теле или cookie),
+// refresh token того же пользователя.
 func (c *UserController) LogoutUser(ctx *gin.Context) {
-	// Просто подтверждаем выход
-	// В реальном приложении здесь можно добавить blacklist токенов
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	value, exists := ctx.Get("claims")
+	if !exists {
+		appLogger.Warn("claims missing in context")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	claims := value.(*models.Claims)
+
+	var req models.LogoutRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			appLogger.Warn("invalid logout payload", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.RefreshToken == "" && c.cookies.Enabled {
+		req.RefreshToken, _ = ctx.Cookie(auth2.RefreshCookieName)
+	}
+
+	if req.RefreshToken != "" {
+		refreshClaims, err := c.tokens.ValidateToken(ctx, req.RefreshToken)
+		if err != nil || refreshClaims.Type != auth2.TokenTypeRefresh || refreshClaims.UserID != claims.UserID {
+			appLogger.Warn("invalid refresh token on logout", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid refresh token"})
+			return
+		}
+		if err := c.tokens.RevokeToken(ctx, refreshClaims); err != nil {
+			appLogger.Error("failed to revoke refresh token", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := c.tokens.RevokeToken(ctx, claims); err != nil {
+		appLogger.Error("failed to revoke access token", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.cookies.Enabled {
+		c.cookies.ClearSession(ctx.Writer)
+	}
+
+	appLogger.Info("user logged out", slog.String("user_id", claims.UserID))
 	ctx.JSON(http.StatusOK, gin.H{"message": "Successfully logged out"})
 }
 
@@ -166,5 +279,69 @@ func (c *UserController) GetMe(ctx *gin.Context) {
 		"id":        user.ID,
 		"email":     user.Email,
 		"createdAt": user.CreatedAt,
+		"timezone":  user.Timezone,
 	})
 }
+
+// UpdateMe меняет настройки текущего пользователя; пока это только часовой пояс.
+func (c *UserController) UpdateMe(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateMeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid update me payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validators.ValidateTimezone(req.Timezone); err != nil {
+		appLogger.Warn("invalid timezone", slog.String("timezone", req.Timezone))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := c.service.GetUserById(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		appLogger.Error("failed to fetch user", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated := *user
+	updated.Timezone = req.Timezone
+	saved, err := c.service.UpdateUser(ctx, &updated)
+	if err != nil {
+		appLogger.Error("failed to update user", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	appLogger.Info("user settings updated", slog.String("user_id", userID.String()))
+	ctx.JSON(http.StatusOK, mappers.UserToDTO(*saved))
+}
+
+// GetUsers отдаёт список пользователей машинным клиентам со scope users:read.
+func (c *UserController) GetUsers(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+
+	users, err := c.service.GetAllUsers(ctx)
+	if err != nil {
+		appLogger.Error("failed to list users", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := make([]models.UserResponse, 0, len(users))
+	for _, user := range users {
+		resp = append(resp, mappers.UserToDTO(user))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"users": resp})
+}
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+// multipartOverhead — запас на заголовки и границы multipart поверх размера файла.
+const multipartOverhead = 64 << 10
+
+// MaxBodySize — самое большое тело, которое принимает хоть один маршрут: файл вложения
+// размером maxAttachmentSize, резервная копия для /imports или файл /todos/import.
+// Им ограничивают тело middleware, которые читают его раньше обработчика.
+func MaxBodySize(maxAttachmentSize int64) int64 {
+	return max(maxAttachmentSize+multipartOverhead, maxBackupSize, maxImportSize)
+}
+
+// AttachmentController обслуживает файлы задач: /todos/:id/attachments.
+type AttachmentController struct {
+	service service.AttachmentService
+	maxSize int64
+	logger  *slog.Logger
+}
+
+func NewAttachmentController(service service.AttachmentService, maxSize int64, logger *slog.Logger) *AttachmentController {
+	return &AttachmentController{
+		service: service,
+		maxSize: maxSize,
+		logger:  logger,
+	}
+}
+
+// UploadAttachment принимает multipart/form-data с файлом в поле "file".
+func (c *AttachmentController) UploadAttachment(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	// Слишком большое тело обрываем, не дочитывая его на диск.
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.maxSize+multipartOverhead)
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			abortWithAttachmentError(ctx, appLogger, domain.ErrAttachmentTooLarge)
+			return
+		}
+		appLogger.Warn("invalid attachment upload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		appLogger.Error("open uploaded file failed", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := c.service.UploadAttachment(ctx, todoID, userID, models.AttachmentUpload{
+		FileName: header.Filename,
+		Size:     header.Size,
+		Content:  file,
+	})
+	if err != nil {
+		abortWithAttachmentError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"attachment": attachment})
+}
+
+func (c *AttachmentController) GetAttachments(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	attachments, err := c.service.GetAttachments(ctx, todoID, userID)
+	if err != nil {
+		abortWithAttachmentError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// DownloadAttachment отдаёт содержимое потоком, не загружая файл в память целиком.
+func (c *AttachmentController) DownloadAttachment(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+	attachmentID, ok := uuidParam(ctx, appLogger, "attachment_id")
+	if !ok {
+		return
+	}
+
+	attachment, content, err := c.service.OpenAttachment(ctx, todoID, attachmentID, userID)
+	if err != nil {
+		abortWithAttachmentError(ctx, appLogger, err)
+		return
+	}
+	defer content.Close()
+
+	ctx.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func (c *AttachmentController) DeleteAttachment(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+	attachmentID, ok := uuidParam(ctx, appLogger, "attachment_id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteAttachment(ctx, todoID, attachmentID, userID); err != nil {
+		abortWithAttachmentError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "attachment successfully deleted"})
+}
+
+func abortWithAttachmentError(ctx *gin.Context, appLogger *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, domain.ErrAttachmentTooLarge):
+		ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAttachmentType):
+		ctx.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrTodoNotFound), errors.Is(err, domain.ErrAttachmentNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrForbidden):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		appLogger.Error("attachment request failed", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
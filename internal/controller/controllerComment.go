@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+// CommentController обслуживает обсуждение задачи: /todos/:id/comments.
+type CommentController struct {
+	service service.CommentService
+	logger  *slog.Logger
+}
+
+func NewCommentController(service service.CommentService, logger *slog.Logger) *CommentController {
+	return &CommentController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (c *CommentController) CreateComment(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	var req models.CreateCommentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid comment payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, err := c.service.CreateComment(ctx, todoID, userID, req)
+	if err != nil {
+		abortWithCommentError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"comment": comment})
+}
+
+func (c *CommentController) GetComments(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	comments, err := c.service.GetComments(ctx, todoID, userID)
+	if err != nil {
+		abortWithCommentError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"comments": comments})
+}
+
+func (c *CommentController) UpdateComment(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+	commentID, ok := uuidParam(ctx, appLogger, "comment_id")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateCommentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid comment payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, err := c.service.UpdateComment(ctx, todoID, commentID, userID, req)
+	if err != nil {
+		abortWithCommentError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"comment": comment})
+}
+
+func (c *CommentController) DeleteComment(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+	commentID, ok := uuidParam(ctx, appLogger, "comment_id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteComment(ctx, todoID, commentID, userID); err != nil {
+		abortWithCommentError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "comment successfully deleted"})
+}
+
+func abortWithCommentError(ctx *gin.Context, appLogger *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, validators.ErrCommentBodyEmpty), errors.Is(err, validators.ErrCommentTooLong):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrTodoNotFound), errors.Is(err, domain.ErrCommentNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrForbidden):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		appLogger.Error("comment request failed", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/internal/todoio"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+// maxBackupSize ограничивает тело POST /imports: экспорт доски Trello с историей
+// действий бывает заметно больше файлов /todos/import.
+const maxBackupSize = 50 << 20
+
+// ImportController запускает импорт из Todoist и Trello и отдаёт его прогресс.
+type ImportController struct {
+	service service.ImportJobService
+	logger  *slog.Logger
+}
+
+func NewImportController(service service.ImportJobService, logger *slog.Logger) *ImportController {
+	return &ImportController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// StartImport принимает резервную копию телом запроса и отвечает 202, не дожидаясь
+// импорта; за ним следят через GET /imports/:id из заголовка Location.
+func (c *ImportController) StartImport(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	var req models.StartImportRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		appLogger.Warn("invalid import job request", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	source, err := todoio.LookupSource(req.Source)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBackupSize)
+	// Импорт продолжится после ответа, поэтому сервис получает контекст запроса, а не
+	// gin.Context, который gin переиспользует.
+	job, err := c.service.StartImport(ctx.Request.Context(), userID, source, ctx.Request.Body, req.DryRun)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "import file is too large"})
+		case errors.Is(err, domain.ErrInvalidImport):
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			appLogger.Error("failed to start import", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.Header("Location", "/api/v1/imports/"+job.ID.String())
+	ctx.JSON(http.StatusAccepted, gin.H{"job": job})
+}
+
+func (c *ImportController) GetImport(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	jobID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+	job, err := c.service.GetImport(ctx, userID, jobID)
+	if err != nil {
+		if errors.Is(err, domain.ErrImportJobNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		appLogger.Error("failed to get import job", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"job": job})
+}
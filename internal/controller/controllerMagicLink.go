@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	auth2 "github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+type MagicLinkController struct {
+	service service.MagicLinkService
+	issuer  tokenIssuer
+	logger  *slog.Logger
+}
+
+func NewMagicLinkController(service service.MagicLinkService, jwtSigner *auth2.JWTSigner, cookies auth2.CookieSettings, logger *slog.Logger) *MagicLinkController {
+	return &MagicLinkController{
+		service: service,
+		issuer:  tokenIssuer{signer: jwtSigner, cookies: cookies},
+		logger:  logger,
+	}
+}
+
+// RequestLink всегда отвечает одинаково, чтобы по ответу нельзя было узнать, зарегистрирован ли email.
+func (c *MagicLinkController) RequestLink(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+
+	var req models.MagicLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid magic link payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.RequestLink(ctx, req.Email); err != nil {
+		if errors.Is(err, domain.ErrTooManyRequests) {
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": domain.ErrTooManyRequests.Error()})
+			return
+		}
+		appLogger.Error("failed to send magic link", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "if the email is registered, a login link has been sent"})
+}
+
+func (c *MagicLinkController) Callback(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	ctx.Header("Cache-Control", "no-store")
+
+	token := ctx.Query("token")
+	if token == "" {
+		appLogger.Warn("magic link token missing")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	user, err := c.service.Login(ctx, token)
+	if err != nil {
+		if errors.Is(err, domain.ErrMagicLinkInvalid) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": domain.ErrMagicLinkInvalid.Error()})
+			return
+		}
+		appLogger.Error("failed to login by magic link", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, err := c.issuer.issue(ctx, user)
+	if err != nil {
+		appLogger.Error("failed to issue tokens", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	appLogger.Info("user logged in by magic link", slog.String("email", user.Email))
+	ctx.JSON(http.StatusOK, body)
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	auth2 "github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+type OAuthController struct {
+	service   service.ClientService
+	tokens    service.TokenService
+	jwtSigner *auth2.JWTSigner
+	logger    *slog.Logger
+}
+
+func NewOAuthController(service service.ClientService, tokens service.TokenService, jwtSigner *auth2.JWTSigner, logger *slog.Logger) *OAuthController {
+	return &OAuthController{
+		service:   service,
+		tokens:    tokens,
+		jwtSigner: jwtSigner,
+		logger:    logger,
+	}
+}
+
+// Token реализует client credentials grant (RFC 6749, раздел 4.4).
+func (c *OAuthController) Token(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Pragma", "no-cache")
+
+	if grantType := ctx.PostForm("grant_type"); grantType != "client_credentials" {
+		appLogger.Warn("unsupported grant type", slog.String("grant_type", grantType))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "unsupported_grant_type"})
+		return
+	}
+
+	client, ok := c.authenticateClient(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	scopes, err := c.service.GrantScopes(client, strings.Fields(ctx.PostForm("scope")))
+	if err != nil {
+		appLogger.Warn("invalid scope requested", slog.String("client_id", client.ClientID))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid_scope"})
+		return
+	}
+
+	token, err := c.jwtSigner.GenerateClientToken(client.ClientID, scopes)
+	if err != nil {
+		appLogger.Error("failed to generate client token", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	appLogger.Info("client token issued", slog.String("client_id", client.ClientID))
+	ctx.JSON(http.StatusOK, models.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(c.jwtSigner.AccessTTL().Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	})
+}
+
+// Introspect реализует RFC 7662. Вызывающий аутентифицируется как клиент
+// и должен иметь scope tokens:introspect.
+func (c *OAuthController) Introspect(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	ctx.Header("Cache-Control", "no-store")
+
+	client, ok := c.authenticateClient(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	if !auth2.HasScopes(client.Scopes, auth2.ScopeTokensIntrospect) {
+		appLogger.Warn("client is not allowed to introspect", slog.String("client_id", client.ClientID))
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient_scope"})
+		return
+	}
+
+	token := ctx.PostForm("token")
+	if token == "" {
+		appLogger.Warn("token parameter missing")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
+		return
+	}
+
+	resp := c.tokens.Introspect(ctx, token)
+	appLogger.Info("token introspected", slog.String("client_id", client.ClientID), slog.Bool("active", resp.Active))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// authenticateClient проверяет учётные данные клиента и сам пишет ответ об ошибке.
+func (c *OAuthController) authenticateClient(ctx *gin.Context, appLogger *slog.Logger) (*entities.Client, bool) {
+	clientID, secret, ok := clientCredentials(ctx)
+	if !ok {
+		appLogger.Warn("client credentials missing")
+		ctx.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_client"})
+		return nil, false
+	}
+
+	client, err := c.service.AuthenticateClient(ctx, clientID, secret)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidClient) {
+			ctx.Header("WWW-Authenticate", `Basic realm="oauth"`)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_client"})
+			return nil, false
+		}
+		appLogger.Error("failed to authenticate client", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return nil, false
+	}
+
+	return client, true
+}
+
+// clientCredentials достаёт client_id/client_secret из Basic auth или из тела формы.
+func clientCredentials(ctx *gin.Context) (string, string, bool) {
+	if id, secret, ok := ctx.Request.BasicAuth(); ok {
+		return id, secret, id != ""
+	}
+	id, secret := ctx.PostForm("client_id"), ctx.PostForm("client_secret")
+	return id, secret, id != "" && secret != ""
+}
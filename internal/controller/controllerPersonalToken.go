@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	auth2 "github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+// PersonalTokenController управляет персональными токенами доступа: /tokens.
+type PersonalTokenController struct {
+	service service.PersonalTokenService
+	logger  *slog.Logger
+}
+
+func NewPersonalTokenController(service service.PersonalTokenService, logger *slog.Logger) *PersonalTokenController {
+	return &PersonalTokenController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateToken выпускает токен. Запрос с персональным токеном получает 403: иначе
+// утёкший токен можно было бы продлить, выпустив себе новый.
+func (c *PersonalTokenController) CreateToken(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userIDstr, exists := ctx.Get("user_id")
+	if !exists {
+		appLogger.Warn("user id not found in context")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDstr.(string))
+	if err != nil {
+		appLogger.Error("failed to parse user id", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if ctx.GetString("type") == auth2.TokenTypePersonal {
+		appLogger.Warn("personal token used to issue a personal token")
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": domain.ErrPersonalTokenScope.Error()})
+		return
+	}
+
+	var req models.CreatePersonalTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid personal token payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := c.service.CreateToken(ctx, userID, req)
+	if err != nil {
+		appLogger.Error("failed to create personal token", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+func (c *PersonalTokenController) GetTokens(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userIDstr, exists := ctx.Get("user_id")
+	if !exists {
+		appLogger.Warn("user id not found in context")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDstr.(string))
+	if err != nil {
+		appLogger.Error("failed to parse user id", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := c.service.GetTokens(ctx, userID)
+	if err != nil {
+		appLogger.Error("failed to get personal tokens", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+func (c *PersonalTokenController) RevokeToken(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userIDstr, exists := ctx.Get("user_id")
+	if !exists {
+		appLogger.Warn("user id not found in context")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	userID, err := uuid.Parse(userIDstr.(string))
+	if err != nil {
+		appLogger.Error("failed to parse user id", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokenID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		appLogger.Warn("invalid token id param", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.RevokeToken(ctx, userID, tokenID); err != nil {
+		if errors.Is(err, domain.ErrPersonalTokenNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		appLogger.Error("failed to revoke personal token", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
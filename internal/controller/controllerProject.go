@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+type ProjectController struct {
+	service     service.ProjectService
+	todoService service.TodoService
+	logger      *slog.Logger
+}
+
+func NewProjectController(service service.ProjectService, todoService service.TodoService, logger *slog.Logger) *ProjectController {
+	return &ProjectController{
+		service:     service,
+		todoService: todoService,
+		logger:      logger,
+	}
+}
+
+func (c *ProjectController) CreateProject(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	var req models.CreateProjectRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid project payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, err := c.service.CreateProject(ctx, userID, req)
+	if err != nil {
+		abortWithProjectError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"project": project})
+}
+
+// GetProjects отдаёт проекты пользователя; архивные — только с ?archived=true.
+func (c *ProjectController) GetProjects(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	var req models.ListProjectsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		appLogger.Warn("invalid projects query", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	projects, err := c.service.GetProjects(ctx, userID, req.Archived)
+	if err != nil {
+		abortWithProjectError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"projects": projects})
+}
+
+func (c *ProjectController) GetProjectByID(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	projectID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	project, err := c.service.GetProjectByID(ctx, projectID, userID)
+	if err != nil {
+		abortWithProjectError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+func (c *ProjectController) UpdateProject(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	projectID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateProjectRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid project payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, err := c.service.UpdateProject(ctx, projectID, userID, req)
+	if err != nil {
+		abortWithProjectError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// DeleteProject удаляет проект; ?todos=cascade удаляет и его задачи,
+// ?todos=inbox (по умолчанию) переносит их во «Входящие».
+func (c *ProjectController) DeleteProject(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	projectID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	var req models.DeleteProjectRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		appLogger.Warn("invalid delete project query", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.DeleteProject(ctx, projectID, userID, req.Todos); err != nil {
+		abortWithProjectError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "project successfully deleted"})
+}
+
+// GetProjectTodos отдаёт задачи проекта с теми же фильтрами и пагинацией, что GET /todos.
+func (c *ProjectController) GetProjectTodos(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	projectID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ListTodosRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		appLogger.Warn("invalid todos list query", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Доступ к проекту проверяет сервис задач.
+	req.ProjectID = &projectID
+
+	page, err := c.todoService.ListTodos(ctx, userID, req)
+	if err != nil {
+		abortWithProjectError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+func abortWithProjectError(ctx *gin.Context, appLogger *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, validators.ErrProjectNameEmpty), errors.Is(err, domain.ErrInvalidCursor):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrProjectNotFound), errors.Is(err, domain.ErrUserNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrForbidden):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		appLogger.Error("project request failed", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
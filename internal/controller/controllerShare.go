@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+// ShareController обслуживает одинаковые маршруты доступа для задач (/todos/:id/shares)
+// и проектов (/projects/:id/shares).
+type ShareController struct {
+	service service.ShareService
+	logger  *slog.Logger
+}
+
+func NewShareController(service service.ShareService, logger *slog.Logger) *ShareController {
+	return &ShareController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (c *ShareController) ShareTodo(ctx *gin.Context) {
+	c.share(ctx, entities.ShareResourceTodo)
+}
+
+func (c *ShareController) ShareProject(ctx *gin.Context) {
+	c.share(ctx, entities.ShareResourceProject)
+}
+
+func (c *ShareController) GetTodoShares(ctx *gin.Context) {
+	c.getShares(ctx, entities.ShareResourceTodo)
+}
+
+func (c *ShareController) GetProjectShares(ctx *gin.Context) {
+	c.getShares(ctx, entities.ShareResourceProject)
+}
+
+func (c *ShareController) RevokeTodoShare(ctx *gin.Context) {
+	c.revoke(ctx, entities.ShareResourceTodo)
+}
+
+func (c *ShareController) RevokeProjectShare(ctx *gin.Context) {
+	c.revoke(ctx, entities.ShareResourceProject)
+}
+
+func (c *ShareController) share(ctx *gin.Context, resourceType string) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	resourceID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	var req models.CreateShareRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid share payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	share, err := c.service.Share(ctx, resourceType, resourceID, userID, req)
+	if err != nil {
+		abortWithShareError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"share": share})
+}
+
+func (c *ShareController) getShares(ctx *gin.Context, resourceType string) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	resourceID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	shares, err := c.service.GetShares(ctx, resourceType, resourceID, userID)
+	if err != nil {
+		abortWithShareError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"shares": shares})
+}
+
+// revoke отзывает доступ пользователя :user_id; участник может так отказаться от своего доступа.
+func (c *ShareController) revoke(ctx *gin.Context, resourceType string) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	resourceID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+	collaboratorID, ok := uuidParam(ctx, appLogger, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.RevokeShare(ctx, resourceType, resourceID, userID, collaboratorID); err != nil {
+		abortWithShareError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "share successfully revoked"})
+}
+
+// SharedWithMe отдаёт задачи и проекты других пользователей, к которым выдан доступ.
+func (c *ShareController) SharedWithMe(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	shared, err := c.service.SharedWithMe(ctx, userID)
+	if err != nil {
+		abortWithShareError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, shared)
+}
+
+func abortWithShareError(ctx *gin.Context, appLogger *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidShare), errors.Is(err, domain.ErrNotWorkspaceMember):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrUserNotFound), errors.Is(err, domain.ErrTodoNotFound),
+		errors.Is(err, domain.ErrProjectNotFound), errors.Is(err, domain.ErrShareNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrForbidden):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		appLogger.Error("share request failed", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+type TagController struct {
+	service service.TagService
+	logger  *slog.Logger
+}
+
+func NewTagController(service service.TagService, logger *slog.Logger) *TagController {
+	return &TagController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (c *TagController) CreateTag(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	var req models.CreateTagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid tag payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := c.service.CreateTag(ctx, userID, req)
+	if err != nil {
+		abortWithTagError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"tag": tag})
+}
+
+func (c *TagController) GetTags(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	tags, err := c.service.GetTags(ctx, userID)
+	if err != nil {
+		abortWithTagError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+func (c *TagController) UpdateTag(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	tagID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateTagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid tag payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := c.service.UpdateTag(ctx, tagID, userID, req)
+	if err != nil {
+		abortWithTagError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"tag": tag})
+}
+
+func (c *TagController) DeleteTag(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	tagID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteTag(ctx, tagID, userID); err != nil {
+		abortWithTagError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "tag successfully deleted"})
+}
+
+func (c *TagController) GetTodoTags(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	tags, err := c.service.GetTodoTags(ctx, todoID, userID)
+	if err != nil {
+		abortWithTagError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+func (c *TagController) AttachTag(ctx *gin.Context) {
+	c.changeTodoTag(ctx, c.service.AttachTag)
+}
+
+func (c *TagController) DetachTag(ctx *gin.Context) {
+	c.changeTodoTag(ctx, c.service.DetachTag)
+}
+
+// changeTodoTag — общий обработчик привязки и отвязки метки; оба действия идемпотентны.
+func (c *TagController) changeTodoTag(ctx *gin.Context, change func(ctx context.Context, todoID, tagID, userID uuid.UUID) error) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+	tagID, ok := uuidParam(ctx, appLogger, "tag_id")
+	if !ok {
+		return
+	}
+
+	if err := change(ctx, todoID, tagID, userID); err != nil {
+		abortWithTagError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func uuidParam(ctx *gin.Context, appLogger *slog.Logger, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param(name))
+	if err != nil {
+		appLogger.Warn("invalid id param", slog.String("param", name), slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func abortWithTagError(ctx *gin.Context, appLogger *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, validators.ErrTagNameEmpty):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrTagExists):
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrTagNotFound), errors.Is(err, domain.ErrTodoNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrForbidden):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		appLogger.Error("tag request failed", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
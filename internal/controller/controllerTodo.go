@@ -1,16 +1,21 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	auth2 "github.com/polzovatel/todo-learning/internal/auth"
 	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
 	"github.com/polzovatel/todo-learning/internal/domain/validators"
 	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/recurrence"
 	"github.com/polzovatel/todo-learning/internal/service"
 	"github.com/polzovatel/todo-learning/logger"
 )
@@ -58,13 +63,27 @@ func (c *TodoController) CreateTodo(ctx *gin.Context) {
 		return
 	}
 
-	todo, err := c.service.CreateTodo(ctx, userID, reqTodo.Title, reqTodo.Description)
+	todo, err := c.service.CreateTodo(ctx, userID, reqTodo)
 	if err != nil {
-		if errors.Is(err, domain.ErrUserNotFound) {
-			appLogger.Warn("user not found while creating todo", slog.Any("error", err))
+		if errors.Is(err, validators.ErrRemindAfterDue) || errors.Is(err, domain.ErrMaxDepthExceeded) || isRecurrenceError(err) {
+			appLogger.Warn("invalid todo payload", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) || errors.Is(err, domain.ErrProjectNotFound) || errors.Is(err, domain.ErrTodoNotFound) {
+			appLogger.Warn("user, project or parent todo not found while creating todo", slog.Any("error", err))
 			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, domain.ErrForbidden) {
+			appLogger.Warn("project access forbidden while creating todo", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrProjectArchived) {
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		appLogger.Error("failed to create todo", slog.Any("error", err))
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -115,6 +134,7 @@ func (c *TodoController) GetTodoByID(ctx *gin.Context) {
 		}
 	}
 
+	ctx.Header("ETag", todoETag(todo))
 	ctx.JSON(http.StatusOK, gin.H{
 		"id":          todo.ID,
 		"user_id":     todo.UserID,
@@ -123,9 +143,42 @@ func (c *TodoController) GetTodoByID(ctx *gin.Context) {
 		"completed":   todo.Completed,
 		"created_at":  todo.CreatedAt,
 		"updated_at":  todo.UpdatedAt,
+		"due_at":      todo.DueAt,
+		"remind_at":   todo.RemindAt,
+		"priority":    todo.Priority,
+		"recurrence":  todo.Recurrence,
+		"position":    todo.Position,
+		"project_id":  todo.ProjectID,
+		"parent_id":   todo.ParentID,
+		"version":     todo.Version,
 	})
 }
 
+// todoETag — ETag задачи: её версия в кавычках.
+func todoETag(todo *entities.Todo) string {
+	return `"` + strconv.Itoa(todo.Version) + `"`
+}
+
+// ifMatchVersion разбирает If-Match. Без заголовка и при "*" версия не проверяется;
+// иначе ожидается один ETag, выданный todoETag.
+func ifMatchVersion(ctx *gin.Context, appLogger *slog.Logger) (*int, bool) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+	raw, ok := strings.CutPrefix(header, `"`)
+	if ok {
+		raw, ok = strings.CutSuffix(raw, `"`)
+	}
+	version, err := strconv.Atoi(raw)
+	if !ok || err != nil {
+		appLogger.Warn("invalid If-Match header", slog.String("if_match", header))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid If-Match header"})
+		return nil, false
+	}
+	return &version, true
+}
+
 func (c *TodoController) GetTodos(ctx *gin.Context) {
 	appLogger := logger.LoggerFromContext(ctx, c.logger)
 	userIDstr, exists := ctx.Get("user_id")
@@ -142,21 +195,7 @@ func (c *TodoController) GetTodos(ctx *gin.Context) {
 		return
 	}
 
-	todos, err := c.service.GetTodoByUserID(ctx, userID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrUserNotFound):
-			appLogger.Warn("user not found while listing todos", slog.Any("error", err))
-			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		default:
-			appLogger.Error("failed to list todos", slog.Any("error", err))
-			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	}
-
-	ctx.JSON(http.StatusOK, gin.H{"todos": todos})
+	c.listTodos(ctx, appLogger, userID)
 }
 
 func (c *TodoController) UpdateTodo(ctx *gin.Context) {
@@ -182,39 +221,286 @@ func (c *TodoController) UpdateTodo(ctx *gin.Context) {
 		return
 	}
 
-	var req models.UpdateTodoRequest
-	if err := ctx.ShouldBind(&req); err != nil {
+	var replacement models.ReplaceTodoRequest
+	if err := ctx.ShouldBind(&replacement); err != nil {
 		appLogger.Warn("invalid update todo payload", slog.Any("error", err))
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	var subtasks models.SubtasksRequest
+	if err := ctx.ShouldBindQuery(&subtasks); err != nil {
+		appLogger.Warn("invalid subtasks mode", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	version, ok := ifMatchVersion(ctx, appLogger)
+	if !ok {
+		return
+	}
 
-	if req.Title != nil {
-		if err := validators.ValidateTodo(*req.Title); err != nil {
-			appLogger.Warn("validation failed", slog.String("title", *req.Title), slog.Any("error", err))
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := validators.ValidateTodo(replacement.Title); err != nil {
+		appLogger.Warn("validation failed", slog.String("title", replacement.Title), slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := replacement.UpdateRequest()
+	req.Subtasks = subtasks.Subtasks
+	req.Version = version
+	todo, err := c.service.UpdateTodo(ctx, todoID, userID, req)
+	if err != nil {
+		abortWithUpdateError(ctx, appLogger, todoID, err)
+		return
+	}
+
+	ctx.Header("ETag", todoETag(todo))
+	ctx.JSON(http.StatusOK, gin.H{"todo": todo})
+}
+
+// PatchTodo правит задачу патчем: application/merge-patch+json (RFC 7396) или
+// application/json-patch+json (RFC 6902).
+func (c *TodoController) PatchTodo(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		appLogger.Warn("failed to read patch body", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var subtasks models.SubtasksRequest
+	if err := ctx.ShouldBindQuery(&subtasks); err != nil {
+		appLogger.Warn("invalid subtasks mode", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	version, ok := ifMatchVersion(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	todo, err := c.service.PatchTodo(ctx, todoID, userID, models.TodoPatch{
+		ContentType: ctx.ContentType(),
+		Body:        body,
+		Subtasks:    subtasks.Subtasks,
+		Version:     version,
+	})
+	if err != nil {
+		abortWithUpdateError(ctx, appLogger, todoID, err)
+		return
+	}
+
+	ctx.Header("ETag", todoETag(todo))
+	ctx.JSON(http.StatusOK, gin.H{"todo": todo})
+}
+
+// abortWithUpdateError отвечает на ошибку правки задачи — через PUT, PATCH или откатом к ревизии.
+func abortWithUpdateError(ctx *gin.Context, appLogger *slog.Logger, todoID uuid.UUID, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUnsupportedPatch):
+		ctx.Header("Accept-Patch", models.MergePatchType+", "+models.JSONPatchType)
+		ctx.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidPatch):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrPatchPath):
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrPatchTestFailed):
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, validators.ErrRemindAfterDue), errors.Is(err, domain.ErrInvalidParent), errors.Is(err, domain.ErrMaxDepthExceeded),
+		isRecurrenceError(err):
+		appLogger.Warn("invalid todo update", slog.Any("todo_id", todoID.String()), slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrForbidden):
+		appLogger.Warn("update forbidden for todo", slog.Any("todo_id", todoID.String()))
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrTodoNotFound), errors.Is(err, domain.ErrProjectNotFound), errors.Is(err, domain.ErrRevisionNotFound):
+		appLogger.Warn("todo, project or revision not found for update", slog.Any("todo_id", todoID.String()))
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrProjectArchived), errors.Is(err, domain.ErrTodoHasOpenSubtasks):
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrVersionMismatch):
+		appLogger.Warn("todo version mismatch", slog.Any("todo_id", todoID.String()))
+		ctx.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	default:
+		appLogger.Error("failed to update todo", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// TodosMethod разбирает POST /todos:<метод>. Маршрут ловит любой путь, начинающийся с
+// /todos, поэтому неизвестный метод — 404.
+func (c *TodoController) TodosMethod(ctx *gin.Context) {
+	switch ctx.Param("method") {
+	case ":batch":
+		c.BatchTodos(ctx)
+	default:
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "not found"})
+	}
+}
+
+// BatchTodos выполняет пакет операций атомарно. При ошибке ничего не меняется, а в ответе —
+// номер операции index и статус, который получил бы одиночный запрос.
+func (c *TodoController) BatchTodos(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	var req models.BatchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid batch payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := c.service.BatchTodos(ctx, userID, req.Operations)
+	if err != nil {
+		var batchErr *domain.BatchError
+		if !errors.As(err, &batchErr) {
+			appLogger.Error("failed to apply batch", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		status := batchErrorStatus(batchErr.Err)
+		if status == http.StatusInternalServerError {
+			appLogger.Error("batch operation failed", slog.Int("index", batchErr.Index), slog.Any("error", batchErr.Err))
+		}
+		ctx.AbortWithStatusJSON(status, gin.H{"error": batchErr.Err.Error(), "index": batchErr.Index})
+		return
 	}
 
-	todo, err := c.service.UpdateTodo(ctx, todoID, userID, req)
+	ctx.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// batchErrorStatus — статус, которым на ошибку ответил бы одиночный POST, PATCH или DELETE.
+func batchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidBatch), errors.Is(err, domain.ErrInvalidPatch), errors.Is(err, validators.ErrTitleEmpty),
+		errors.Is(err, validators.ErrRemindAfterDue), errors.Is(err, domain.ErrInvalidParent), errors.Is(err, domain.ErrMaxDepthExceeded),
+		isRecurrenceError(err):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrTodoNotFound), errors.Is(err, domain.ErrProjectNotFound), errors.Is(err, domain.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrProjectArchived), errors.Is(err, domain.ErrTodoHasOpenSubtasks), errors.Is(err, domain.ErrTodoHasSubtasks):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetTodoHistory отдаёт историю изменений задачи, старые ревизии первыми.
+func (c *TodoController) GetTodoHistory(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	revisions, err := c.service.GetTodoHistory(ctx, todoID, userID)
+	if err != nil {
+		abortWithTodoError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// RevertTodo возвращает задаче состояние одной из её ревизий.
+func (c *TodoController) RevertTodo(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+	number, err := strconv.Atoi(ctx.Param("revision"))
+	if err != nil || number < 1 {
+		appLogger.Warn("invalid revision param", slog.String("revision", ctx.Param("revision")))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid revision"})
+		return
+	}
+
+	todo, err := c.service.RevertTodo(ctx, todoID, userID, number)
+	if err != nil {
+		abortWithUpdateError(ctx, appLogger, todoID, err)
+		return
+	}
+
+	ctx.Header("ETag", todoETag(todo))
+	ctx.JSON(http.StatusOK, gin.H{"todo": todo})
+}
+
+// MoveTodo переставляет задачу перед (before) или после (after) другой задачи.
+func (c *TodoController) MoveTodo(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	todoID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		appLogger.Warn("invalid todo id param", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.MoveTodoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid move todo payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	version, ok := ifMatchVersion(ctx, appLogger)
+	if !ok {
+		return
+	}
+	req.Version = version
+
+	todo, err := c.service.MoveTodo(ctx, todoID, userID, req)
 	if err != nil {
 		switch {
+		case errors.Is(err, domain.ErrInvalidMove):
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrPositionConflict):
+			appLogger.Warn("move conflict for todo", slog.Any("todo_id", todoID.String()), slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrVersionMismatch):
+			appLogger.Warn("todo version mismatch on move", slog.Any("todo_id", todoID.String()))
+			ctx.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
 		case errors.Is(err, domain.ErrForbidden):
-			appLogger.Warn("update forbidden for todo", slog.Any("todo_id", todoID.String()))
+			appLogger.Warn("move forbidden for todo", slog.Any("todo_id", todoID.String()))
 			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
 		case errors.Is(err, domain.ErrTodoNotFound):
-			appLogger.Warn("todo not found for update", slog.Any("todo_id", todoID.String()))
 			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
 		default:
-			appLogger.Error("failed to update todo", slog.Any("error", err))
+			appLogger.Error("failed to move todo", slog.Any("error", err))
 			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
 		}
+		return
 	}
 
+	ctx.Header("ETag", todoETag(todo))
 	ctx.JSON(http.StatusOK, gin.H{"todo": todo})
 }
 
@@ -241,8 +527,27 @@ func (c *TodoController) DeleteTodo(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.service.DeleteTodo(ctx, todoID, userID); err != nil {
+	var req models.SubtasksRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		appLogger.Warn("invalid subtasks mode", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version, ok := ifMatchVersion(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteTodo(ctx, todoID, userID, req.Subtasks, version); err != nil {
 		switch {
+		case errors.Is(err, domain.ErrTodoHasSubtasks):
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, domain.ErrVersionMismatch):
+			appLogger.Warn("todo version mismatch on delete", slog.Any("todo_id", todoID.String()))
+			ctx.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+			return
 		case errors.Is(err, domain.ErrForbidden):
 			appLogger.Warn("delete forbidden for todo", slog.Any("todo_id", todoID.String()))
 			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
@@ -259,5 +564,235 @@ func (c *TodoController) DeleteTodo(ctx *gin.Context) {
 	}
 
 	appLogger.Info("todo deleted", slog.String("todo_id", todoID.String()))
-	ctx.JSON(http.StatusOK, gin.H{"message": "todo successfully deleted"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "todo moved to trash"})
+}
+
+// GetTodoChildren отдаёт прямые подзадачи и прогресс их выполнения.
+func (c *TodoController) GetTodoChildren(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	children, err := c.service.GetTodoChildren(ctx, todoID, userID)
+	if err != nil {
+		abortWithTodoError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, children)
+}
+
+// GetTodoTree отдаёт задачу со всем деревом подзадач.
+func (c *TodoController) GetTodoTree(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	tree, err := c.service.GetTodoTree(ctx, todoID, userID)
+	if err != nil {
+		abortWithTodoError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tree)
+}
+
+// SkipOccurrence переносит повторяющуюся задачу на следующее вхождение.
+func (c *TodoController) SkipOccurrence(ctx *gin.Context) {
+	c.changeRecurrence(ctx, c.service.SkipOccurrence)
+}
+
+// EndRecurrence снимает с задачи правило повторения.
+func (c *TodoController) EndRecurrence(ctx *gin.Context) {
+	c.changeRecurrence(ctx, c.service.EndRecurrence)
+}
+
+func (c *TodoController) changeRecurrence(ctx *gin.Context, change func(ctx context.Context, todoID, userID uuid.UUID) (*entities.Todo, error)) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	todo, err := change(ctx, todoID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotRecurring), errors.Is(err, domain.ErrRecurrenceEnded):
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			abortWithTodoError(ctx, appLogger, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"todo": todo})
+}
+
+// GetTrash отдаёт корзину текущего пользователя, недавно удалённые первыми.
+func (c *TodoController) GetTrash(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	todos, err := c.service.GetTrash(ctx, userID)
+	if err != nil {
+		abortWithTodoError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"todos": todos})
+}
+
+// RestoreTodo возвращает задачу из корзины.
+func (c *TodoController) RestoreTodo(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	todo, err := c.service.RestoreTodo(ctx, todoID, userID)
+	if err != nil {
+		abortWithTrashError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"todo": todo})
+}
+
+// PurgeTodo окончательно удаляет задачу из корзины.
+func (c *TodoController) PurgeTodo(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	todoID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.PurgeTodo(ctx, todoID, userID); err != nil {
+		abortWithTrashError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "todo permanently deleted"})
+}
+
+func abortWithTrashError(ctx *gin.Context, appLogger *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, domain.ErrTodoNotTrashed), errors.Is(err, domain.ErrParentTrashed):
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		abortWithTodoError(ctx, appLogger, err)
+	}
+}
+
+func isRecurrenceError(err error) bool {
+	return errors.Is(err, recurrence.ErrInvalidRule) || errors.Is(err, recurrence.ErrUnsupported) ||
+		errors.Is(err, domain.ErrRecurrenceNeedsDue)
+}
+
+func abortWithTodoError(ctx *gin.Context, appLogger *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, domain.ErrTodoNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrForbidden):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		appLogger.Error("todo request failed", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetUserTodos отдаёт задачи указанного пользователя машинным клиентам со scope todos:read.
+func (c *TodoController) GetUserTodos(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		appLogger.Warn("invalid user id param", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.listTodos(ctx, appLogger, userID)
+}
+
+// listTodos отдаёт страницу задач пользователя по query-параметрам запроса.
+func (c *TodoController) listTodos(ctx *gin.Context, appLogger *slog.Logger, userID uuid.UUID) {
+	var req models.ListTodosRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		appLogger.Warn("invalid todos list query", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := c.service.ListTodos(ctx, userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			appLogger.Warn("user not found while listing todos", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrInvalidCursor):
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			appLogger.Error("failed to list todos", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+func (c *TodoController) SearchTodos(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	var req models.SearchTodosRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		appLogger.Warn("invalid todos search query", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := c.service.SearchTodos(ctx, userID, req)
+	if err != nil {
+		if errors.Is(err, domain.ErrEmptyQuery) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		appLogger.Error("failed to search todos", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"results": results})
 }
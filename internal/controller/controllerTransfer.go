@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/internal/todoio"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+// maxImportSize ограничивает тело POST /todos/import.
+const maxImportSize = 10 << 20
+
+// TransferController выгружает и загружает задачи файлами: /todos/export и /todos/import.
+type TransferController struct {
+	service service.TransferService
+	logger  *slog.Logger
+}
+
+func NewTransferController(service service.TransferService, logger *slog.Logger) *TransferController {
+	return &TransferController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ExportTodos отдаёт задачи файлом в формате ?format=, записывая его по мере чтения.
+func (c *TransferController) ExportTodos(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	var req models.ExportTodosRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		appLogger.Warn("invalid export request", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format, err := todoio.Lookup(req.Format)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Type", format.ContentType+"; charset=utf-8")
+	ctx.Header("Content-Disposition", `attachment; filename="todos.`+format.Extension+`"`)
+	if err := c.service.ExportTodos(ctx, userID, format, ctx.Writer); err != nil {
+		// Начатый ответ уже не исправить: клиент получит оборванный файл.
+		if ctx.Writer.Written() {
+			appLogger.Error("export interrupted", slog.Any("error", err))
+			ctx.Abort()
+			return
+		}
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		if errors.Is(err, domain.ErrUserNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		appLogger.Error("failed to export todos", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ImportTodos принимает файл телом запроса; формат — ?format= или Content-Type тела.
+func (c *TransferController) ImportTodos(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	var req models.ImportTodosRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		appLogger.Warn("invalid import request", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var format todoio.Format
+	var err error
+	if req.Format != "" {
+		format, err = todoio.Lookup(req.Format)
+	} else {
+		format, err = todoio.ForContentType(ctx.GetHeader("Content-Type"))
+	}
+	if err != nil {
+		appLogger.Warn("unsupported import format", slog.String("content_type", ctx.GetHeader("Content-Type")))
+		ctx.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportSize)
+	report, err := c.service.ImportTodos(ctx, userID, format, ctx.Request.Body, req.DryRun)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "import file is too large"})
+		case errors.Is(err, domain.ErrInvalidImport):
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrImportRows):
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "report": report})
+		case errors.Is(err, domain.ErrUserNotFound):
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			appLogger.Error("failed to import todos", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	status := http.StatusCreated
+	if req.DryRun {
+		status = http.StatusOK
+	}
+	ctx.JSON(status, gin.H{"report": report})
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	auth2 "github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/controller/mappers"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+type WebAuthnController struct {
+	service service.WebAuthnService
+	issuer  tokenIssuer
+	logger  *slog.Logger
+}
+
+func NewWebAuthnController(service service.WebAuthnService, jwtSigner *auth2.JWTSigner, cookies auth2.CookieSettings, logger *slog.Logger) *WebAuthnController {
+	return &WebAuthnController{
+		service: service,
+		issuer:  tokenIssuer{signer: jwtSigner, cookies: cookies},
+		logger:  logger,
+	}
+}
+
+func (c *WebAuthnController) BeginRegistration(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	options, err := c.service.BeginRegistration(ctx, userID)
+	if err != nil {
+		appLogger.Error("failed to begin webauthn registration", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"publicKey": options})
+}
+
+func (c *WebAuthnController) FinishRegistration(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	var req models.WebAuthnRegistrationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid webauthn registration payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cred, err := c.service.FinishRegistration(ctx, userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrChallengeNotFound), errors.Is(err, domain.ErrWebAuthnFailed):
+			appLogger.Warn("webauthn registration rejected", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrCredentialExists):
+			ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": domain.ErrCredentialExists.Error()})
+		default:
+			appLogger.Error("failed to finish webauthn registration", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	appLogger.Info("passkey registered", slog.String("user_id", userID.String()))
+	ctx.JSON(http.StatusCreated, gin.H{"credential": mappers.WebAuthnCredentialToDTO(cred)})
+}
+
+func (c *WebAuthnController) GetCredentials(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	creds, err := c.service.GetCredentials(ctx, userID)
+	if err != nil {
+		appLogger.Error("failed to list passkeys", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]models.WebAuthnCredentialResponse, 0, len(creds))
+	for _, cred := range creds {
+		response = append(response, mappers.WebAuthnCredentialToDTO(cred))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"credentials": response})
+}
+
+func (c *WebAuthnController) BeginLogin(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+
+	var req models.WebAuthnLoginBeginRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			appLogger.Warn("invalid webauthn login payload", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	options, err := c.service.BeginLogin(ctx, req.Email)
+	if err != nil {
+		appLogger.Error("failed to begin webauthn login", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"publicKey": options})
+}
+
+func (c *WebAuthnController) FinishLogin(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+
+	var req models.WebAuthnLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid webauthn login payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := c.service.FinishLogin(ctx, req)
+	if err != nil {
+		if errors.Is(err, domain.ErrChallengeNotFound) || errors.Is(err, domain.ErrWebAuthnFailed) {
+			appLogger.Warn("webauthn login rejected", slog.Any("error", err))
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		appLogger.Error("failed to finish webauthn login", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, err := c.issuer.issue(ctx, user)
+	if err != nil {
+		appLogger.Error("failed to issue tokens", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	appLogger.Info("user logged in with passkey", slog.String("email", user.Email))
+	ctx.JSON(http.StatusOK, body)
+}
+
+// currentUserID достаёт id пользователя, положенный AuthMiddleware, и сам пишет ответ об ошибке.
+func currentUserID(ctx *gin.Context, appLogger *slog.Logger) (uuid.UUID, bool) {
+	userIDstr, exists := ctx.Get("user_id")
+	if !exists {
+		appLogger.Warn("user id not found in context")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDstr.(string))
+	if err != nil {
+		appLogger.Error("failed to parse user id", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	auth2 "github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/polzovatel/todo-learning/logger"
+)
+
+type WorkspaceController struct {
+	service service.WorkspaceService
+	signer  *auth2.JWTSigner
+	logger  *slog.Logger
+}
+
+func NewWorkspaceController(service service.WorkspaceService, signer *auth2.JWTSigner, logger *slog.Logger) *WorkspaceController {
+	return &WorkspaceController{
+		service: service,
+		signer:  signer,
+		logger:  logger,
+	}
+}
+
+func (c *WorkspaceController) CreateWorkspace(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	var req models.CreateWorkspaceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid workspace payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := c.service.CreateWorkspace(ctx, userID, req)
+	if err != nil {
+		abortWithWorkspaceError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"workspace": workspace})
+}
+
+func (c *WorkspaceController) GetWorkspaces(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+
+	workspaces, err := c.service.GetWorkspaces(ctx, userID)
+	if err != nil {
+		abortWithWorkspaceError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"workspaces": workspaces})
+}
+
+func (c *WorkspaceController) GetWorkspace(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	workspaceID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	workspace, err := c.service.GetWorkspace(ctx, workspaceID, userID)
+	if err != nil {
+		abortWithWorkspaceError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"workspace": workspace})
+}
+
+func (c *WorkspaceController) UpdateWorkspace(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	workspaceID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWorkspaceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid workspace payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := c.service.UpdateWorkspace(ctx, workspaceID, userID, req)
+	if err != nil {
+		abortWithWorkspaceError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"workspace": workspace})
+}
+
+func (c *WorkspaceController) DeleteWorkspace(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	workspaceID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteWorkspace(ctx, workspaceID, userID); err != nil {
+		abortWithWorkspaceError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Workspace deleted"})
+}
+
+func (c *WorkspaceController) AddMember(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	workspaceID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	var req models.AddWorkspaceMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		appLogger.Warn("invalid workspace member payload", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := c.service.AddMember(ctx, workspaceID, userID, req)
+	if err != nil {
+		abortWithWorkspaceError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"member": member})
+}
+
+func (c *WorkspaceController) GetMembers(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	workspaceID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	members, err := c.service.GetMembers(ctx, workspaceID, userID)
+	if err != nil {
+		abortWithWorkspaceError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// RemoveMember исключает участника :user_id; свой id означает выход из пространства.
+func (c *WorkspaceController) RemoveMember(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	workspaceID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+	memberID, ok := uuidParam(ctx, appLogger, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.RemoveMember(ctx, workspaceID, userID, memberID); err != nil {
+		abortWithWorkspaceError(ctx, appLogger, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// SwitchWorkspace выпускает access token, в котором текущим выбрано пространство :id.
+// Refresh token не меняется; после обновления пары снова выбрано личное пространство.
+func (c *WorkspaceController) SwitchWorkspace(ctx *gin.Context) {
+	appLogger := logger.LoggerFromContext(ctx, c.logger)
+	userID, ok := currentUserID(ctx, appLogger)
+	if !ok {
+		return
+	}
+	workspaceID, ok := uuidParam(ctx, appLogger, "id")
+	if !ok {
+		return
+	}
+
+	workspace, err := c.service.GetWorkspace(ctx, workspaceID, userID)
+	if err != nil {
+		abortWithWorkspaceError(ctx, appLogger, err)
+		return
+	}
+
+	accessToken, err := c.signer.GenerateWorkspaceAccessToken(userID.String(), ctx.GetString("email"), "access_token", workspaceID.String())
+	if err != nil {
+		appLogger.Error("failed to generate workspace access token", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"accessToken": accessToken, "workspace": workspace})
+}
+
+func abortWithWorkspaceError(ctx *gin.Context, appLogger *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, validators.ErrWorkspaceNameEmpty), errors.Is(err, domain.ErrNotWorkspaceMember):
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrPersonalWorkspace), errors.Is(err, domain.ErrLastWorkspaceOwner):
+		ctx.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrWorkspaceNotFound), errors.Is(err, domain.ErrUserNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrForbidden):
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		appLogger.Error("workspace request failed", slog.Any("error", err))
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
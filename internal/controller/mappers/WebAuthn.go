@@ -0,0 +1,18 @@
+package mappers
+
+import (
+	"encoding/base64"
+
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+)
+
+func WebAuthnCredentialToDTO(cred entities.WebAuthnCredential) models.WebAuthnCredentialResponse {
+	return models.WebAuthnCredentialResponse{
+		ID:           cred.ID,
+		CredentialID: base64.RawURLEncoding.EncodeToString(cred.CredentialID),
+		SignCount:    cred.SignCount,
+		CreatedAt:    cred.CreatedAt,
+		LastUsedAt:   cred.LastUsedAt,
+	}
+}
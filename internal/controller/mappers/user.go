@@ -10,5 +10,6 @@ func UserToDTO(user entities.User) models.UserResponse {
 		ID:        user.ID,
 		Email:     user.Email,
 		CreatedAt: user.CreatedAt,
+		Timezone:  user.Timezone,
 	}
 }
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	auth2 "github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+// tokenIssuer выдаёт пару access/refresh токенов: в теле ответа или,
+// в cookie-режиме, через HttpOnly cookies вместе с CSRF token.
+type tokenIssuer struct {
+	signer  *auth2.JWTSigner
+	cookies auth2.CookieSettings
+}
+
+func (i tokenIssuer) issue(ctx *gin.Context, user *entities.User) (gin.H, error) {
+	accessToken, err := i.signer.GenerateAccessToken(user.ID.String(), user.Email, "access_token")
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := i.signer.GenerateRefreshToken(user.ID.String(), user.Email, "refresh_token")
+	if err != nil {
+		return nil, err
+	}
+
+	if !i.cookies.Enabled {
+		return gin.H{"accessToken": accessToken, "refreshToken": refreshToken}, nil
+	}
+
+	csrfToken, err := auth2.NewCSRFToken()
+	if err != nil {
+		return nil, err
+	}
+	i.cookies.SetSession(ctx.Writer, accessToken, i.signer.AccessTTL(), refreshToken, i.signer.RefreshTTL(), csrfToken)
+	return gin.H{"csrfToken": csrfToken}, nil
+}
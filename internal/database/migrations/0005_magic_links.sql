@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS magic_links (
+    jti TEXT PRIMARY KEY,
+    email TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS magic_links_email_created_at_idx ON magic_links (email, created_at);
@@ -0,0 +1,4 @@
+-- Индексы под keyset-пагинацию GET /todos: (user_id, ключ сортировки, id).
+CREATE INDEX IF NOT EXISTS todos_user_created_at_idx ON todos (user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS todos_user_updated_at_idx ON todos (user_id, updated_at, id);
+CREATE INDEX IF NOT EXISTS todos_user_title_idx ON todos (user_id, (title COLLATE "C"), id);
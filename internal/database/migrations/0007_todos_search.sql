@@ -0,0 +1,8 @@
+-- Полнотекстовый поиск: конфигурация 'simple' (без стемминга), заголовок весит больше описания.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS todos_search_vector_idx ON todos USING GIN (search_vector);
@@ -0,0 +1,8 @@
+-- Сроки и напоминания задач; «сегодня» и «эта неделя» считаются в часовом поясе пользователя.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS remind_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS todos_user_due_at_idx ON todos (user_id, due_at) WHERE due_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS todos_remind_at_idx ON todos (remind_at) WHERE remind_at IS NOT NULL;
@@ -0,0 +1,18 @@
+-- Приоритет и ручной порядок задач. position — строковый ключ fractional indexing
+-- (пакет ranking), сравнивается побайтно, поэтому индекс и сортировка идут с COLLATE "C".
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'normal'
+    CHECK (priority IN ('low', 'normal', 'high', 'urgent'));
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS position TEXT NOT NULL DEFAULT '';
+
+-- Существующим задачам раздаём ключи в порядке создания, как ranking.Nth: заголовок
+-- задаёт длину целой части, а ширина берётся по числу задач пользователя (не меньше 6),
+-- чтобы номера не обрезались.
+UPDATE todos t SET position = chr(ascii('a') + r.width - 1) || lpad(r.rn::text, r.width, '0')
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY created_at, id) AS rn,
+        greatest(6, length((count(*) OVER (PARTITION BY user_id))::text)) AS width
+    FROM todos WHERE position = ''
+) r
+WHERE t.id = r.id;
+
+CREATE INDEX IF NOT EXISTS todos_user_position_idx ON todos (user_id, (position COLLATE "C"), id);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS projects (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    color TEXT NOT NULL,
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS projects_user_id_idx ON projects (user_id, sort_order);
+
+-- Задачи без проекта — во «Входящих». Выбор между каскадным удалением и переносом
+-- во «Входящие» делает приложение; SET NULL — страховка на случай прямого DELETE.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS todos_project_id_idx ON todos (project_id) WHERE project_id IS NOT NULL;
@@ -0,0 +1,5 @@
+-- Правила удаления родителя (отказ или каскад) проверяет приложение; CASCADE здесь
+-- не даёт остаться подзадачам с несуществующим родителем.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES todos(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS todos_parent_id_idx ON todos (parent_id, position COLLATE "C") WHERE parent_id IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Доступ к чужой задаче или проекту. Ровно одна из ссылок заполнена, так что
+-- доступ исчезает вместе с ресурсом.
+CREATE TABLE IF NOT EXISTS shares (
+    id UUID PRIMARY KEY,
+    todo_id UUID REFERENCES todos(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+    invited_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((todo_id IS NULL) <> (project_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS shares_todo_user_idx ON shares (todo_id, user_id) WHERE todo_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS shares_project_user_idx ON shares (project_id, user_id) WHERE project_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS shares_user_id_idx ON shares (user_id, created_at DESC);
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS workspaces (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    personal BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('member', 'admin', 'owner')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS workspace_members_user_id_idx ON workspace_members (user_id, created_at);
+
+-- Личное пространство пользователя имеет тот же id, что и сам пользователь:
+-- существующие задачи и проекты переезжают в него без перестройки ссылок.
+INSERT INTO workspaces (id, name, personal, created_at)
+SELECT id, 'Personal', TRUE, created_at FROM users
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+SELECT id, id, 'owner', created_at FROM users
+ON CONFLICT (workspace_id, user_id) DO NOTHING;
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces(id) ON DELETE CASCADE;
+UPDATE todos SET workspace_id = user_id WHERE workspace_id IS NULL;
+ALTER TABLE todos ALTER COLUMN workspace_id SET NOT NULL;
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces(id) ON DELETE CASCADE;
+UPDATE projects SET workspace_id = user_id WHERE workspace_id IS NULL;
+ALTER TABLE projects ALTER COLUMN workspace_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS todos_workspace_user_idx ON todos (workspace_id, user_id, position COLLATE "C");
+CREATE INDEX IF NOT EXISTS projects_workspace_user_idx ON projects (workspace_id, user_id, sort_order);
@@ -0,0 +1,13 @@
+-- Обсуждение задачи. Комментарии удаляются мягко (deleted_at), а вместе с задачей или
+-- автором — по-настоящему.
+CREATE TABLE IF NOT EXISTS comments (
+    id UUID PRIMARY KEY,
+    todo_id UUID NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    edited_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS comments_todo_id_idx ON comments (todo_id, created_at) WHERE deleted_at IS NULL;
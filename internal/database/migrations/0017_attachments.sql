@@ -0,0 +1,17 @@
+-- Вложения задач; содержимое лежит в blob-хранилище под storage_key. При удалении задачи
+-- запись не удаляется, а теряет ссылку и становится «осиротевшей»: фоновая очистка удаляет
+-- сначала содержимое, затем запись. Удаление загрузившего пользователя лишь обнуляет user_id.
+CREATE TABLE IF NOT EXISTS attachments (
+    id UUID PRIMARY KEY,
+    todo_id UUID REFERENCES todos(id) ON DELETE SET NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL CHECK (size >= 0),
+    storage_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS attachments_todo_id_idx ON attachments (todo_id, created_at);
+-- Осиротевшим вложение делает только удаление задачи; очистка обходит их по id.
+CREATE INDEX IF NOT EXISTS attachments_orphaned_id_idx ON attachments (id) WHERE todo_id IS NULL;
@@ -0,0 +1,5 @@
+-- Корзина: удалённая задача получает deleted_at и окончательно удаляется после срока хранения.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS todos_trash_idx ON todos (workspace_id, user_id, deleted_at DESC) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS todos_deleted_at_idx ON todos (deleted_at) WHERE deleted_at IS NOT NULL;
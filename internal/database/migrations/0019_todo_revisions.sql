@@ -0,0 +1,13 @@
+-- История изменений задачи. Номер ревизии растёт в пределах задачи; state — состояние
+-- задачи после изменения, changes — изменённые поля со старыми и новыми значениями.
+CREATE TABLE IF NOT EXISTS todo_revisions (
+    todo_id UUID NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    changes JSONB NOT NULL DEFAULT '[]',
+    state JSONB NOT NULL,
+    reverted_to INTEGER,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (todo_id, revision)
+);
@@ -0,0 +1,3 @@
+-- Версия задачи для оптимистичной блокировки: растёт с каждым изменением, запись
+-- проходит, только если версия не поменялась с момента чтения.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
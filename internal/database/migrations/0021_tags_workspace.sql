@@ -0,0 +1,28 @@
+ALTER TABLE tags ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces(id) ON DELETE CASCADE;
+UPDATE tags SET workspace_id = user_id WHERE workspace_id IS NULL;
+ALTER TABLE tags ALTER COLUMN workspace_id SET NOT NULL;
+
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_user_id_name_key;
+
+-- Метки, повешенные на задачи другого пространства, копируются в это пространство,
+-- и связи переводятся на копии. CTE copies материализуется один раз, поэтому
+-- вставка и перевод связей видят одни и те же id.
+WITH copies AS (
+    SELECT moved.tag_id, moved.workspace_id, gen_random_uuid() AS id
+    FROM (
+        SELECT DISTINCT t.id AS tag_id, td.workspace_id
+        FROM todo_tags tt
+        JOIN tags t ON t.id = tt.tag_id
+        JOIN todos td ON td.id = tt.todo_id
+        WHERE td.workspace_id <> t.workspace_id
+    ) moved
+), inserted AS (
+    INSERT INTO tags (id, workspace_id, user_id, name, color, created_at)
+    SELECT c.id, c.workspace_id, t.user_id, t.name, t.color, t.created_at
+    FROM copies c JOIN tags t ON t.id = c.tag_id
+)
+UPDATE todo_tags tt SET tag_id = c.id
+FROM copies c, todos td
+WHERE tt.tag_id = c.tag_id AND td.id = tt.todo_id AND td.workspace_id = c.workspace_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tags_workspace_user_name_idx ON tags (workspace_id, user_id, name);
@@ -0,0 +1,21 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment — файл, прикреплённый к задаче. Содержимое хранится в blob.BlobStore
+// под StorageKey, здесь — только сведения о нём.
+type Attachment struct {
+	ID         uuid.UUID `json:"id"`
+	TodoID     uuid.UUID `json:"todo_id"`
+	UploaderID uuid.UUID `json:"uploader_id"`
+	FileName   string    `json:"file_name"`
+	// ContentType определяется по содержимому файла, а не по заголовкам клиента.
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
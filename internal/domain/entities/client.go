@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Client — зарегистрированный машинный клиент (service-to-service).
+type Client struct {
+	ID         uuid.UUID `json:"id"`
+	ClientID   string    `json:"client_id"`
+	SecretHash string    `json:"-"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Comment — сообщение в обсуждении задачи. Удалённый комментарий остаётся в хранилище
+// с DeletedAt и больше не отдаётся.
+type Comment struct {
+	ID        uuid.UUID  `json:"id"`
+	TodoID    uuid.UUID  `json:"todo_id"`
+	AuthorID  uuid.UUID  `json:"author_id"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	DeletedAt *time.Time `json:"-"`
+}
@@ -0,0 +1,12 @@
+package entities
+
+import "time"
+
+// MagicLink — выданная ссылка входа. Сам токен не хранится, только его jti.
+type MagicLink struct {
+	JTI       string
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
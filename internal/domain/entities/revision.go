@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TodoRevision — запись в истории задачи. Номера ревизий растут в пределах задачи с 1;
+// State — состояние задачи после изменения, к нему задачу можно откатить.
+type TodoRevision struct {
+	TodoID uuid.UUID `json:"todo_id"`
+	Number int       `json:"revision"`
+	Action string    `json:"action"`
+	// ActorID пуст, если автор изменения удалён.
+	ActorID uuid.UUID     `json:"actor_id"`
+	Changes []FieldChange `json:"changes"`
+	State   TodoState     `json:"state"`
+	// RevertedTo — номер ревизии, к которой откатили задачу (у Action == RevisionRevert).
+	RevertedTo *int      `json:"reverted_to,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TodoState — поля задачи, которые отслеживает история.
+type TodoState struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	Priority    string     `json:"priority"`
+	DueAt       *time.Time `json:"due_at"`
+	RemindAt    *time.Time `json:"remind_at"`
+	Recurrence  string     `json:"recurrence"`
+	ProjectID   *uuid.UUID `json:"project_id"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	// Position — ключ ручного порядка; у ревизий, записанных до учёта перестановок, пуст.
+	Position string `json:"position,omitempty"`
+}
+
+// FieldChange — старое и новое значение поля в JSON; у новой задачи Old — нулевое значение.
+type FieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// Действия, которые записываются в историю.
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+	RevisionRevert  = "revert"
+	RevisionMove    = "move"
+)
@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ресурсы, которыми можно поделиться.
+const (
+	ShareResourceTodo    = "todo"
+	ShareResourceProject = "project"
+)
+
+// Уровни доступа по возрастанию: viewer читает, editor меняет, owner ещё и удаляет
+// и управляет доступом.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+)
+
+// RoleRank сравнивает уровни доступа; 0 — доступа нет.
+func RoleRank(role string) int {
+	switch role {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleOwner:
+		return 3
+	}
+	return 0
+}
+
+// Share — доступ пользователя к чужой задаче или проекту. Доступ к проекту
+// распространяется на все его задачи, доступ к задаче — на её подзадачи.
+type Share struct {
+	ID           uuid.UUID `json:"id"`
+	ResourceType string    `json:"resource_type"`
+	ResourceID   uuid.UUID `json:"resource_id"`
+	UserID       uuid.UUID `json:"user_id"`
+	Role         string    `json:"role"`
+	InvitedBy    uuid.UUID `json:"invited_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -6,11 +6,35 @@ import (
 )
 
 type Todo struct {
-	ID          uuid.UUID `json:"id"`
-	UserID      uuid.UUID `json:"user_id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Completed   bool      `json:"completed"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uuid.UUID  `json:"id"`
+	WorkspaceID uuid.UUID  `json:"workspace_id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	RemindAt    *time.Time `json:"remind_at,omitempty"`
+	Priority    string     `json:"priority"`
+	// Recurrence — правило RRULE (RFC 5545) в каноническом виде; серия начинается с DueAt.
+	Recurrence string `json:"recurrence,omitempty"`
+	// ProjectID пуст у задач во «Входящих».
+	ProjectID *uuid.UUID `json:"project_id,omitempty"`
+	// ParentID задан у подзадач; глубина вложенности ограничена настройкой TODO_MAX_DEPTH.
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+	// Position — ключ ручного порядка (см. пакет ranking), сравнивается побайтно.
+	Position string `json:"position"`
+	// DeletedAt задан у задач в корзине.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version растёт с каждым изменением задачи; клиент передаёт её в If-Match.
+	Version int `json:"version"`
 }
+
+// Приоритеты задач по возрастанию срочности.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
@@ -10,4 +10,6 @@ type User struct {
 	Email        string    `json:"email"`
 	PasswordHash string    `json:"-"`
 	CreatedAt    time.Time `json:"created_at"`
+	// Timezone — IANA-зона пользователя, в ней считаются «сегодня» и «эта неделя».
+	Timezone string `json:"timezone"`
 }
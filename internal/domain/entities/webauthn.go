@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential — passkey пользователя. PublicKey хранится в формате COSE_Key.
+type WebAuthnCredential struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	CredentialID []byte     `json:"credential_id"`
+	PublicKey    []byte     `json:"-"`
+	SignCount    uint32     `json:"sign_count"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+}
+
+// WebAuthnChallenge — выданный клиенту challenge, ожидающий завершения церемонии.
+// UserID пуст для входа без указания email (discoverable credentials).
+type WebAuthnChallenge struct {
+	Challenge string
+	UserID    *uuid.UUID
+	Ceremony  string
+	ExpiresAt time.Time
+}
+
+const (
+	WebAuthnCeremonyRegistration   = "registration"
+	WebAuthnCeremonyAuthentication = "authentication"
+)
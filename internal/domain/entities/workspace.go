@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Workspace — изолированное пространство команды: задачи и проекты одного пространства
+// не видны из другого. У каждого пользователя есть пространство по умолчанию с тем же
+// id, что и у пользователя (Personal); его нельзя удалить.
+type Workspace struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Personal  bool      `json:"personal"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const PersonalWorkspaceName = "Personal"
+
+// Роли участников пространства по возрастанию: member работает с задачами,
+// admin управляет участниками, owner ещё переименовывает и удаляет пространство.
+const (
+	WorkspaceRoleMember = "member"
+	WorkspaceRoleAdmin  = "admin"
+	WorkspaceRoleOwner  = "owner"
+)
+
+// WorkspaceRoleRank сравнивает роли участников; 0 — не участник.
+func WorkspaceRoleRank(role string) int {
+	switch role {
+	case WorkspaceRoleMember:
+		return 1
+	case WorkspaceRoleAdmin:
+		return 2
+	case WorkspaceRoleOwner:
+		return 3
+	}
+	return 0
+}
+
+type WorkspaceMember struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // User errors
 var (
@@ -10,6 +13,148 @@ var (
 
 // Todo errors
 var (
-	ErrTodoNotFound = errors.New("todo not found")
-	ErrForbidden    = errors.New("forbidden")
+	ErrTodoNotFound     = errors.New("todo not found")
+	ErrForbidden        = errors.New("forbidden")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrEmptyQuery       = errors.New("search query has no words")
+	ErrInvalidMove      = errors.New("move needs exactly one of before or after, pointing to another todo of the same owner")
+	ErrPositionConflict = errors.New("todo positions could not be resolved, retry the move")
+	ErrVersionMismatch  = errors.New("todo has been modified since it was read")
+)
+
+// Patch errors
+var (
+	ErrUnsupportedPatch = errors.New("unsupported patch type, use application/merge-patch+json or application/json-patch+json")
+	ErrInvalidPatch     = errors.New("invalid patch")
+	ErrPatchPath        = errors.New("patch path does not exist")
+	ErrPatchTestFailed  = errors.New("patch test operation failed")
+)
+
+// Batch errors
+var (
+	ErrInvalidBatch = errors.New("invalid batch operation")
+)
+
+// Import errors
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format, use csv, json, ical or todotxt")
+	ErrInvalidImport     = errors.New("import file cannot be read")
+	ErrImportRows        = errors.New("some rows cannot be imported, nothing was saved")
+	ErrUnsupportedSource = errors.New("unsupported source, use todoist or trello")
+	ErrImportJobNotFound = errors.New("import job not found")
+)
+
+// BatchError — операция пакета, из-за которой откатился весь пакет.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// Trash errors
+var (
+	ErrTodoNotTrashed = errors.New("todo is not in the trash")
+	ErrParentTrashed  = errors.New("parent todo is in the trash, restore it first")
+)
+
+// Revision errors
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+// Recurrence errors
+var (
+	ErrRecurrenceNeedsDue = errors.New("recurring todo needs a due date")
+	ErrNotRecurring       = errors.New("todo is not recurring")
+	ErrRecurrenceEnded    = errors.New("recurrence has no more occurrences")
+)
+
+// Subtask errors
+var (
+	ErrInvalidParent       = errors.New("todo cannot become a subtask of itself or of its own subtask")
+	ErrMaxDepthExceeded    = errors.New("subtask nesting is too deep")
+	ErrTodoHasSubtasks     = errors.New("todo has subtasks")
+	ErrTodoHasOpenSubtasks = errors.New("todo has uncompleted subtasks")
+)
+
+// Tag errors
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag with this name already exists")
+)
+
+// Project errors
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrProjectArchived = errors.New("project is archived")
+)
+
+// Workspace errors
+var (
+	ErrWorkspaceNotFound  = errors.New("workspace not found")
+	ErrNotWorkspaceMember = errors.New("user is not a member of the workspace")
+	ErrPersonalWorkspace  = errors.New("personal workspace cannot be deleted or left")
+	ErrLastWorkspaceOwner = errors.New("workspace must keep at least one owner")
+)
+
+// Comment errors
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+)
+
+// Attachment errors
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+	ErrAttachmentType     = errors.New("attachment type is not allowed")
+	ErrBlobNotFound       = errors.New("blob not found")
+)
+
+// Share errors
+var (
+	ErrShareNotFound = errors.New("share not found")
+	ErrInvalidShare  = errors.New("cannot share with the owner or with yourself")
+)
+
+// OAuth client errors
+var (
+	ErrClientNotFound = errors.New("client not found")
+	ErrInvalidClient  = errors.New("invalid client credentials")
+	ErrInvalidScope   = errors.New("requested scope is not allowed")
+)
+
+// Token errors
+var (
+	ErrTokenRevoked          = errors.New("token has been revoked")
+	ErrPersonalTokenNotFound = errors.New("personal access token not found")
+	ErrPersonalTokenInvalid  = errors.New("personal access token is invalid or expired")
+	ErrPersonalTokenScope    = errors.New("personal access tokens cannot issue new tokens")
+)
+
+// WebAuthn errors
+var (
+	ErrChallengeNotFound  = errors.New("challenge not found or expired")
+	ErrCredentialNotFound = errors.New("credential not found")
+	ErrCredentialExists   = errors.New("credential already registered")
+	ErrWebAuthnFailed     = errors.New("webauthn verification failed")
+)
+
+// Magic link errors
+var (
+	ErrMagicLinkInvalid = errors.New("login link is invalid, expired or already used")
+	ErrTooManyRequests  = errors.New("too many requests")
+)
+
+// Idempotency errors
+var (
+	ErrInvalidIdempotencyKey = errors.New("Idempotency-Key must be 1 to 255 printable ASCII characters")
+	ErrIdempotencyKeyReused  = errors.New("Idempotency-Key was already used for a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this Idempotency-Key is still in progress")
 )
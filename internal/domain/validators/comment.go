@@ -0,0 +1,25 @@
+package validators
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxCommentLength ограничивает длину комментария в символах.
+const MaxCommentLength = 10000
+
+var (
+	ErrCommentBodyEmpty = errors.New("comment body cannot be empty")
+	ErrCommentTooLong   = errors.New("comment body is too long")
+)
+
+func ValidateComment(body string) error {
+	if len(strings.TrimSpace(body)) == 0 {
+		return ErrCommentBodyEmpty
+	}
+	if utf8.RuneCountInString(body) > MaxCommentLength {
+		return ErrCommentTooLong
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package validators
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrProjectNameEmpty = errors.New("project name cannot be empty")
+)
+
+func ValidateProject(name string) error {
+	if len(strings.TrimSpace(name)) == 0 {
+		return ErrProjectNameEmpty
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package validators
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrTagNameEmpty = errors.New("tag name cannot be empty")
+)
+
+func ValidateTag(name string) error {
+	if len(strings.TrimSpace(name)) == 0 {
+		return ErrTagNameEmpty
+	}
+	return nil
+}
@@ -3,10 +3,12 @@ package validators
 import (
 	"errors"
 	"strings"
+	"time"
 )
 
 var (
-	ErrTitleEmpty = errors.New("Title cannot be empty")
+	ErrTitleEmpty     = errors.New("Title cannot be empty")
+	ErrRemindAfterDue = errors.New("remind_at must not be later than due_at")
 )
 
 func ValidateTodo(title string) error {
@@ -15,3 +17,11 @@ func ValidateTodo(title string) error {
 	}
 	return nil
 }
+
+// ValidateDueDates проверяет, что напоминание не позже срока, если заданы оба.
+func ValidateDueDates(dueAt, remindAt *time.Time) error {
+	if dueAt != nil && remindAt != nil && remindAt.After(*dueAt) {
+		return ErrRemindAfterDue
+	}
+	return nil
+}
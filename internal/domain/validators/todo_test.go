@@ -2,6 +2,7 @@ package validators_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/polzovatel/todo-learning/internal/domain/validators"
 	"github.com/stretchr/testify/assert"
@@ -45,3 +46,16 @@ func TestValidateTodo(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateDueDates(t *testing.T) {
+	due := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	before := due.Add(-time.Hour)
+	after := due.Add(time.Hour)
+
+	assert.NoError(t, validators.ValidateDueDates(nil, nil))
+	assert.NoError(t, validators.ValidateDueDates(&due, nil))
+	assert.NoError(t, validators.ValidateDueDates(nil, &after))
+	assert.NoError(t, validators.ValidateDueDates(&due, &before))
+	assert.NoError(t, validators.ValidateDueDates(&due, &due))
+	assert.ErrorIs(t, validators.ValidateDueDates(&due, &after), validators.ErrRemindAfterDue)
+}
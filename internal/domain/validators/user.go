@@ -3,6 +3,9 @@ package validators
 import (
 	"errors"
 	"net/mail"
+	"time"
+	// База зон встроена в бинарник, чтобы не зависеть от tzdata в контейнере.
+	_ "time/tzdata"
 	"unicode"
 )
 
@@ -13,8 +16,20 @@ var (
 	ErrPasswordNoUpper  = errors.New("password must have at least one uppercase letter")
 	ErrPasswordNoSymbol = errors.New("password must have at least one symbol '!'")
 	ErrPasswordNoNumber = errors.New("password must have at least one number")
+	ErrInvalidTimezone  = errors.New("unknown timezone")
 )
 
+// ValidateTimezone принимает имена зон IANA; "Local" не допускается, пустая строка тоже.
+func ValidateTimezone(name string) error {
+	if name == "" || name == "Local" {
+		return ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
+
 func ValidateUser(email, password string) error {
 	if !isEmailValid(email) {
 		return ErrInvalidEmail
@@ -73,3 +73,12 @@ func TestValidateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateTimezone(t *testing.T) {
+	for _, name := range []string{"UTC", "Europe/Moscow", "America/New_York"} {
+		assert.NoError(t, validators.ValidateTimezone(name), name)
+	}
+	for _, name := range []string{"", "Local", "Mars/Olympus", "../etc/passwd"} {
+		assert.ErrorIs(t, validators.ValidateTimezone(name), validators.ErrInvalidTimezone, name)
+	}
+}
@@ -0,0 +1,17 @@
+package validators
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrWorkspaceNameEmpty = errors.New("workspace name cannot be empty")
+)
+
+func ValidateWorkspace(name string) error {
+	if len(strings.TrimSpace(name)) == 0 {
+		return ErrWorkspaceNameEmpty
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// Package idempotency хранит ответы на запросы с заголовком Idempotency-Key, чтобы
+// повтор запроса получил тот же ответ, а не выполнил действие ещё раз.
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Record — запрос с ключом идемпотентности и, когда он выполнен, его ответ.
+type Record struct {
+	// Fingerprint — хэш метода, пути и тела: по нему видно, что ключ повторно
+	// используется для другого запроса.
+	Fingerprint string      `json:"fingerprint"`
+	Done        bool        `json:"done"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// Store хранит записи по ключу; ключ уже включает пользователя.
+type Store interface {
+	// Reserve занимает свободный ключ незавершённой записью с отпечатком fingerprint
+	// на время ttl и возвращает nil. Если ключ занят, возвращает существующую запись.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error)
+	// Extend продлевает на ttl незавершённую запись ключа, пока запрос ещё выполняется.
+	// Свободный или уже сохранённый ключ не меняется.
+	Extend(ctx context.Context, key string, ttl time.Duration) error
+	// Save сохраняет выполненный запрос с ответом на время ttl.
+	Save(ctx context.Context, key string, record Record, ttl time.Duration) error
+	// Release освобождает ключ, чтобы повтор выполнил запрос заново.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,66 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	record    Record
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemoryStore хранит записи в памяти процесса; подходит, когда Redis недоступен
+// и приложение запущено в одном экземпляре.
+func NewMemoryStore() Store {
+	return &memoryStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *memoryStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// Заодно убираем истёкшие записи, чтобы карта не росла бесконечно.
+	for k, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	if entry, ok := s.entries[key]; ok {
+		record := entry.record
+		return &record, nil
+	}
+	s.entries[key] = memoryEntry{record: Record{Fingerprint: fingerprint}, expiresAt: now.Add(ttl)}
+	return nil, nil
+}
+
+func (s *memoryStore) Extend(ctx context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[key]; ok && !entry.record.Done {
+		entry.expiresAt = time.Now().Add(ttl)
+		s.entries[key] = entry
+	}
+	return nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryEntry{record: record, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	existing, err := store.Reserve(ctx, "u1:key", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// Пока запрос выполняется, ключ занят незавершённой записью.
+	existing, err = store.Reserve(ctx, "u1:key", "fp", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.False(t, existing.Done)
+	assert.Equal(t, "fp", existing.Fingerprint)
+
+	// У другого пользователя свой ключ.
+	existing, err = store.Reserve(ctx, "u2:key", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	require.NoError(t, store.Save(ctx, "u1:key", Record{Fingerprint: "fp", Done: true, Status: 201, Body: []byte(`{}`)}, time.Hour))
+	existing, err = store.Reserve(ctx, "u1:key", "other", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.True(t, existing.Done)
+	assert.Equal(t, 201, existing.Status)
+	assert.Equal(t, "fp", existing.Fingerprint)
+
+	require.NoError(t, store.Release(ctx, "u1:key"))
+	existing, err = store.Reserve(ctx, "u1:key", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// Истёкшая запись ключ не занимает.
+	require.NoError(t, store.Save(ctx, "u3:key", Record{Fingerprint: "fp", Done: true}, time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	existing, err = store.Reserve(ctx, "u3:key", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+}
+
+func TestMemoryStore_Extend(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	// Продлённая незавершённая запись переживает свой первоначальный срок.
+	_, err := store.Reserve(ctx, "u1:key", "fp", 20*time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, store.Extend(ctx, "u1:key", time.Minute))
+	time.Sleep(30 * time.Millisecond)
+	existing, err := store.Reserve(ctx, "u1:key", "fp", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.False(t, existing.Done)
+
+	// Сохранённый ответ продление не трогает.
+	require.NoError(t, store.Save(ctx, "u2:key", Record{Fingerprint: "fp", Done: true}, 20*time.Millisecond))
+	require.NoError(t, store.Extend(ctx, "u2:key", time.Minute))
+	time.Sleep(30 * time.Millisecond)
+	existing, err = store.Reserve(ctx, "u2:key", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// Свободный ключ продление не занимает.
+	require.NoError(t, store.Extend(ctx, "u3:key", time.Minute))
+	existing, err = store.Reserve(ctx, "u3:key", "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+}
@@ -0,0 +1,79 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "idempotency:"
+
+type redisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore хранит записи в Redis, общем для всех экземпляров приложения.
+func NewRedisStore(client *redis.Client) Store {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	pending, err := json.Marshal(Record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+	// SETNX занимает ключ атомарно: из одновременных запросов выполнится только один.
+	reserved, err := s.client.SetNX(ctx, redisKeyPrefix+key, pending, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	raw, err := s.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Запись истекла между SETNX и GET — пробуем занять ключ ещё раз.
+		return s.Reserve(ctx, key, fingerprint, ttl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record Record
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// extendScript продлевает ключ, только если в нём ещё незавершённая запись: иначе
+// запоздалое продление урезало бы срок хранения сохранённого ответа.
+var extendScript = redis.NewScript(`
+local raw = redis.call('GET', KEYS[1])
+if not raw then
+	return 0
+end
+if cjson.decode(raw).done then
+	return 0
+end
+return redis.call('PEXPIRE', KEYS[1], ARGV[1])
+`)
+
+func (s *redisStore) Extend(ctx context.Context, key string, ttl time.Duration) error {
+	return extendScript.Run(ctx, s.client, []string{redisKeyPrefix + key}, ttl.Milliseconds()).Err()
+}
+
+func (s *redisStore) Save(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, redisKeyPrefix+key, raw, ttl).Err()
+}
+
+func (s *redisStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, redisKeyPrefix+key).Err()
+}
@@ -0,0 +1,19 @@
+// Package jobs хранит состояние фоновых импортов, чтобы клиент мог следить за их
+// прогрессом, в том числе через другой экземпляр приложения.
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/models"
+)
+
+// Store хранит импорты по ID. Права на импорт проверяет сервис.
+type Store interface {
+	// Save записывает состояние импорта на время ttl, заменяя прежнее.
+	Save(ctx context.Context, job models.ImportJob, ttl time.Duration) error
+	// Get возвращает импорт или nil, если его нет или он истёк.
+	Get(ctx context.Context, id uuid.UUID) (*models.ImportJob, error)
+}
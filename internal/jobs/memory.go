@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/models"
+)
+
+type memoryEntry struct {
+	job       models.ImportJob
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]memoryEntry
+}
+
+// NewMemoryStore хранит импорты в памяти процесса; подходит, когда Redis недоступен
+// и приложение запущено в одном экземпляре.
+func NewMemoryStore() Store {
+	return &memoryStore{entries: make(map[uuid.UUID]memoryEntry)}
+}
+
+func (s *memoryStore) Save(ctx context.Context, job models.ImportJob, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// Заодно убираем истёкшие записи, чтобы карта не росла бесконечно.
+	for id, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, id)
+		}
+	}
+	s.entries[job.ID] = memoryEntry{job: job, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, id uuid.UUID) (*models.ImportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[id]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return nil, nil
+	}
+	job := entry.job
+	return &job, nil
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	job := models.ImportJob{ID: uuid.New(), Status: models.ImportQueued, Total: 3}
+	missing, err := store.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	require.NoError(t, store.Save(ctx, job, time.Hour))
+	job.Status = models.ImportRunning
+	job.Processed = 2
+	require.NoError(t, store.Save(ctx, job, time.Hour))
+	saved, err := store.Get(ctx, job.ID)
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, models.ImportRunning, saved.Status)
+	assert.Equal(t, 2, saved.Processed)
+
+	// Копия из хранилища не меняет сохранённую запись.
+	saved.Status = models.ImportFailed
+	again, _ := store.Get(ctx, job.ID)
+	assert.Equal(t, models.ImportRunning, again.Status)
+
+	expired := models.ImportJob{ID: uuid.New()}
+	require.NoError(t, store.Save(ctx, expired, time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	missing, err = store.Get(ctx, expired.ID)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "import-job:"
+
+type redisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore хранит импорты в Redis, общем для всех экземпляров приложения.
+func NewRedisStore(client *redis.Client) Store {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) Save(ctx context.Context, job models.ImportJob, ttl time.Duration) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, redisKeyPrefix+job.ID.String(), raw, ttl).Err()
+}
+
+func (s *redisStore) Get(ctx context.Context, id uuid.UUID) (*models.ImportJob, error) {
+	raw, err := s.client.Get(ctx, redisKeyPrefix+id.String()).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var job models.ImportJob
+	if err := json.Unmarshal(raw, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
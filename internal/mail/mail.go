@@ -0,0 +1,37 @@
+// Package mail отправляет письма пользователям.
+package mail
+
+import (
+	"context"
+	"log/slog"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type logMailer struct {
+	logger *slog.Logger
+}
+
+// NewLogMailer возвращает Mailer, который только пишет в лог, что письмо отправлено.
+// Нужен для разработки и тестов: тело письма в лог не попадает, потому что в нём бывают
+// одноразовые ссылки входа.
+func NewLogMailer(logger *slog.Logger) Mailer {
+	return &logMailer{logger: logger}
+}
+
+func (m *logMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.Info("mail: message sent",
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.Int("body_bytes", len(msg.Body)),
+	)
+	return nil
+}
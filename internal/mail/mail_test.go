@@ -0,0 +1,112 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP — минимальный SMTP-сервер для тестов: принимает одно письмо без STARTTLS и
+// авторизации и отдаёт конверт и текст письма в канал.
+type fakeSMTP struct {
+	listener net.Listener
+	received chan []string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	f := &fakeSMTP{listener: listener, received: make(chan []string, 1)}
+	go f.serve()
+	return f
+}
+
+func (f *fakeSMTP) serve() {
+	conn, err := f.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	var lines []string
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO", "HELO":
+			reply("250 fake")
+		case "MAIL", "RCPT":
+			lines = append(lines, line)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			for {
+				data, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				data = strings.TrimRight(data, "\r\n")
+				if data == "." {
+					break
+				}
+				lines = append(lines, data)
+			}
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			f.received <- lines
+			return
+		default:
+			reply("502 unknown command")
+		}
+	}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	server := newFakeSMTP(t)
+	host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+	mailer := NewSMTPMailer(SMTPConfig{Host: host, Port: port, From: "todo@example.com"})
+
+	err := mailer.Send(context.Background(), Message{
+		To:      "alice@example.com",
+		Subject: "Вход в Todo",
+		Body:    "Ссылка:\nhttps://example.com/?token=abc",
+	})
+	require.NoError(t, err)
+
+	lines := <-server.received
+	assert.Equal(t, "MAIL FROM:<todo@example.com>", lines[0])
+	assert.Equal(t, "RCPT TO:<alice@example.com>", lines[1])
+	assert.Contains(t, lines, "To: alice@example.com")
+	assert.Contains(t, lines, "Subject: =?utf-8?q?=D0=92=D1=85=D0=BE=D0=B4_=D0=B2_Todo?=")
+	assert.Contains(t, lines, "https://example.com/?token=abc")
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	mailer := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: "1", From: "todo@example.com"})
+	err := mailer.Send(context.Background(), Message{To: "alice@example.com", Subject: "hi\r\nBcc: eve@example.com"})
+	assert.ErrorContains(t, err, "line break")
+}
+
+func TestLogMailerOmitsBody(t *testing.T) {
+	var buf bytes.Buffer
+	mailer := NewLogMailer(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	require.NoError(t, mailer.Send(context.Background(), Message{To: "alice@example.com", Subject: "Вход", Body: "token=secret"}))
+	assert.Contains(t, buf.String(), "alice@example.com")
+	assert.NotContains(t, buf.String(), "secret")
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"io"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,6 +14,12 @@ type UserResponse struct {
 	ID        uuid.UUID `json:"id"`
 	Email     string    `json:"email"`
 	CreatedAt time.Time `json:"created_at"`
+	Timezone  string    `json:"timezone"`
+}
+
+// UpdateMeRequest — PATCH /me. Timezone — имя зоны IANA, например "Europe/Moscow".
+type UpdateMeRequest struct {
+	Timezone string `json:"timezone" binding:"required"`
 }
 
 type RegisterRequest struct {
@@ -37,20 +45,577 @@ type LoginResponse struct {
 }
 
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
-	Type   string `json:"type"`
+	UserID   string `json:"user_id,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Role     string `json:"role,omitempty"`
+	Type     string `json:"type"`
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// WorkspaceID — рабочее пространство, выбранное при выпуске токена; заголовок X-Workspace-ID его перекрывает.
+	WorkspaceID string `json:"wid,omitempty"`
 	jwt.RegisteredClaims
 }
 
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// Сроки задач передаются в RFC 3339 со смещением и хранятся как моменты времени.
 type CreateTodoRequest struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueAt       *time.Time `json:"due_at"`
+	RemindAt    *time.Time `json:"remind_at"`
+	Priority    string     `json:"priority" binding:"omitempty,oneof=low normal high urgent"`
+	ProjectID   *uuid.UUID `json:"project_id"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	// Recurrence — RRULE, например "FREQ=WEEKLY;BYDAY=MO"; требует due_at.
+	Recurrence string `json:"recurrence"`
+}
+
+// ReplaceTodoRequest — PUT /todos/:id: полное новое состояние задачи. Необязательные поля,
+// которых нет в запросе, сбрасываются: описание и повторение — в "", сроки, проект и
+// родитель — в null.
+type ReplaceTodoRequest struct {
+	Title       string     `json:"title" binding:"required"`
+	Description string     `json:"description"`
+	Completed   *bool      `json:"completed" binding:"required"`
+	Priority    string     `json:"priority" binding:"required,oneof=low normal high urgent"`
+	DueAt       *time.Time `json:"due_at"`
+	RemindAt    *time.Time `json:"remind_at"`
+	Recurrence  string     `json:"recurrence"`
+	ProjectID   *uuid.UUID `json:"project_id"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+}
+
+// UpdateRequest превращает полную замену в правку, в которой заданы все поля.
+func (r ReplaceTodoRequest) UpdateRequest() UpdateTodoRequest {
+	return UpdateTodoRequest{
+		Title:       &r.Title,
+		Description: &r.Description,
+		Completed:   r.Completed,
+		DueAt:       Optional[time.Time]{Set: true, Value: r.DueAt},
+		RemindAt:    Optional[time.Time]{Set: true, Value: r.RemindAt},
+		Priority:    &r.Priority,
+		ProjectID:   Optional[uuid.UUID]{Set: true, Value: r.ProjectID},
+		ParentID:    Optional[uuid.UUID]{Set: true, Value: r.ParentID},
+		Recurrence:  Optional[string]{Set: true, Value: &r.Recurrence},
+	}
+}
+
+// Типы тела PATCH /todos/:id.
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// TodoPatch — PATCH /todos/:id. Патч применяется к задаче в виде документа с полями
+// ReplaceTodoRequest; результат проверяется так же, как тело PUT.
+type TodoPatch struct {
+	ContentType string
+	Body        []byte
+	// Subtasks и Version — как в UpdateTodoRequest.
+	Subtasks string
+	Version  *int
+}
+
+// Операции POST /todos:batch.
+const (
+	BatchCreate   = "create"
+	BatchUpdate   = "update"
+	BatchDelete   = "delete"
+	BatchComplete = "complete"
+)
+
+// BatchRequest — POST /todos:batch: операции выполняются по порядку в одной транзакции,
+// ошибка любой из них откатывает весь пакет.
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+// BatchOperation — одна операция пакета; ID нужен всем операциям, кроме create.
+type BatchOperation struct {
+	Op string     `json:"op" binding:"required,oneof=create update delete complete"`
+	ID *uuid.UUID `json:"id"`
+	// Todo — тело POST /todos для create или merge patch (RFC 7396) для update.
+	Todo json.RawMessage `json:"todo"`
+	// Version — ожидаемая версия задачи, как в If-Match; Subtasks — как ?subtasks=.
+	Version  *int   `json:"version"`
+	Subtasks string `json:"subtasks" binding:"omitempty,oneof=refuse cascade"`
+}
+
+// BatchResult — итог операции пакета; у delete нет задачи.
+type BatchResult struct {
+	Index int            `json:"index"`
+	Op    string         `json:"op"`
+	ID    uuid.UUID      `json:"id"`
+	Todo  *entities.Todo `json:"todo,omitempty"`
+}
+
+// ExportTodosRequest — GET /todos/export.
+type ExportTodosRequest struct {
+	Format string `form:"format" binding:"required,oneof=csv json ical todotxt"`
+}
+
+// ImportTodosRequest — POST /todos/import; без format формат определяется по Content-Type тела.
+type ImportTodosRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv json ical todotxt"`
+	DryRun bool   `form:"dry_run"`
+}
+
+// ImportReport — итог импорта. Задачи сохраняются, только если ни одна строка не
+// завершилась ошибкой; иначе Imported равен 0, а Errors перечисляет все такие строки.
+type ImportReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Imported int              `json:"imported"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// ImportRowError — строка файла, которую нельзя импортировать. Title помогает найти
+// задачу там, где номер строки мало что говорит (архивы, экспорт доски).
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Title string `json:"title,omitempty"`
+	Error string `json:"error"`
+}
+
+// StartImportRequest — POST /imports: резервная копия другого сервиса телом запроса.
+type StartImportRequest struct {
+	Source string `form:"source" binding:"required,oneof=todoist trello"`
+	DryRun bool   `form:"dry_run"`
+}
+
+// Состояния фонового импорта.
+const (
+	ImportQueued    = "queued"
+	ImportRunning   = "running"
+	ImportSucceeded = "succeeded"
+	ImportFailed    = "failed"
+)
+
+// ImportJob — фоновый импорт из другого сервиса. Processed растёт по мере создания
+// задач, Report появляется, когда импорт завершён (в том числе с ошибками строк).
+type ImportJob struct {
+	ID          uuid.UUID     `json:"id"`
+	UserID      uuid.UUID     `json:"user_id"`
+	WorkspaceID uuid.UUID     `json:"workspace_id"`
+	Source      string        `json:"source"`
+	DryRun      bool          `json:"dry_run"`
+	Status      string        `json:"status"`
+	Total       int           `json:"total"`
+	Processed   int           `json:"processed"`
+	Report      *ImportReport `json:"report,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	FinishedAt  *time.Time    `json:"finished_at,omitempty"`
+}
+
+// UpdateTodoRequest — внутренняя частичная правка задачи: nil и Set=false оставляют поле
+// как есть. Её собирают PUT, PATCH и откат к ревизии.
+type UpdateTodoRequest struct {
+	Title       *string             `json:"title"`
+	Description *string             `json:"description"`
+	Completed   *bool               `json:"completed"`
+	DueAt       Optional[time.Time] `json:"due_at"`
+	RemindAt    Optional[time.Time] `json:"remind_at"`
+	Priority    *string             `json:"priority" binding:"omitempty,oneof=low normal high urgent"`
+	// ProjectID: null переносит задачу во «Входящие».
+	ProjectID Optional[uuid.UUID] `json:"project_id"`
+	// ParentID: null делает подзадачу задачей верхнего уровня.
+	ParentID Optional[uuid.UUID] `json:"parent_id"`
+	// Recurrence: null или "" завершает повторение.
+	Recurrence Optional[string] `json:"recurrence"`
+	// Subtasks — что делать с невыполненными подзадачами при выполнении задачи;
+	// берётся из query-параметра, см. SubtasksRequest.
+	Subtasks string `json:"-"`
+	// Version — ожидаемая версия задачи из If-Match; nil — без проверки.
+	Version *int `json:"-"`
+}
+
+// Что делать с подзадачами при выполнении или удалении родителя: отказать (409)
+// или применить действие ко всему поддереву.
+const (
+	SubtasksRefuse  = "refuse"
+	SubtasksCascade = "cascade"
+)
+
+// SubtasksRequest — query-параметр ?subtasks= у PUT, PATCH и DELETE /todos/:id.
+type SubtasksRequest struct {
+	Subtasks string `form:"subtasks" binding:"omitempty,oneof=refuse cascade"`
+}
+
+// TodoProgress — сколько прямых подзадач выполнено из общего числа.
+type TodoProgress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+type TodoChildrenResponse struct {
+	Todos    []entities.Todo `json:"todos"`
+	Progress TodoProgress    `json:"progress"`
+}
+
+// TodoTree — задача со всеми подзадачами.
+type TodoTree struct {
+	Todo     entities.Todo `json:"todo"`
+	Progress TodoProgress  `json:"progress"`
+	Children []TodoTree    `json:"children"`
+}
+
+// MoveTodoRequest — PUT /todos/:id/move: ровно одно из полей, id соседней задачи.
+type MoveTodoRequest struct {
+	Before *uuid.UUID `json:"before"`
+	After  *uuid.UUID `json:"after"`
+	// Version — ожидаемая версия задачи из If-Match; nil — без проверки.
+	Version *int `json:"-"`
+}
+
+// Optional различает отсутствующее поле и явный null: Set=true с Value=nil
+// означает «сбросить значение».
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	o.Value = &v
+	return nil
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type CreatePersonalTokenRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// ExpiresInDays — срок действия в днях; без него токен бессрочный.
+	ExpiresInDays *int `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+// PersonalTokenResponse — выпущенный персональный токен. Сам токен показывается только
+// в этом ответе.
+type PersonalTokenResponse struct {
+	Token         string                 `json:"token"`
+	PersonalToken entities.PersonalToken `json:"personal_token"`
+}
+
+// IntrospectionResponse — ответ /oauth/introspect (RFC 7662).
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+}
+
+// WebAuthn: бинарные поля передаются в base64url без паддинга, как в JSON-сериализации
+// PublicKeyCredential в браузере.
+
+type WebAuthnRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type WebAuthnUser struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type WebAuthnCredentialParam struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type WebAuthnCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// WebAuthnCreationOptions — параметры для navigator.credentials.create({publicKey}).
+type WebAuthnCreationOptions struct {
+	Challenge              string                         `json:"challenge"`
+	RP                     WebAuthnRelyingParty           `json:"rp"`
+	User                   WebAuthnUser                   `json:"user"`
+	PubKeyCredParams       []WebAuthnCredentialParam      `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"`
+	Attestation            string                         `json:"attestation"`
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+}
+
+// WebAuthnRequestOptions — параметры для navigator.credentials.get({publicKey}).
+type WebAuthnRequestOptions struct {
+	Challenge        string                         `json:"challenge"`
+	RPID             string                         `json:"rpId"`
+	Timeout          int64                          `json:"timeout"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                         `json:"userVerification"`
+}
+
+type WebAuthnLoginBeginRequest struct {
+	// Email необязателен: без него вход возможен только discoverable credential.
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+type WebAuthnRegistrationRequest struct {
+	RawID    string `json:"rawId" binding:"required"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+		AttestationObject string `json:"attestationObject" binding:"required"`
+	} `json:"response"`
+}
+
+type WebAuthnLoginRequest struct {
+	RawID    string `json:"rawId" binding:"required"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+		AuthenticatorData string `json:"authenticatorData" binding:"required"`
+		Signature         string `json:"signature" binding:"required"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+type WebAuthnCredentialResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	CredentialID string     `json:"credential_id"`
+	SignCount    uint32     `json:"sign_count"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// Поля сортировки и направления для списка задач.
+const (
+	TodoSortPosition  = "position"
+	TodoSortCreatedAt = "created_at"
+	TodoSortUpdatedAt = "updated_at"
+	TodoSortTitle     = "title"
+
+	SortAsc  = "asc"
+	SortDesc = "desc"
+
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
+// ListTodosRequest — query-параметры GET /todos. Даты в RFC 3339.
+type ListTodosRequest struct {
+	Limit     int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor    string `form:"cursor"`
+	Completed *bool  `form:"completed"`
+	Priority  string `form:"priority" binding:"omitempty,oneof=low normal high urgent"`
+	// Tags — имена меток (?tag=a&tag=b); TagMode any — хотя бы одна, all — все сразу.
+	Tags          []string   `form:"tag" binding:"omitempty,dive,required"`
+	TagMode       string     `form:"tag_mode" binding:"omitempty,oneof=any all"`
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedAfter  *time.Time `form:"updated_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	UpdatedBefore *time.Time `form:"updated_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	// Фильтры по сроку считаются в часовом поясе пользователя и могут комбинироваться.
+	Overdue     bool   `form:"overdue"`
+	DueToday    bool   `form:"due_today"`
+	DueThisWeek bool   `form:"due_this_week"`
+	Sort        string `form:"sort" binding:"omitempty,oneof=position created_at updated_at title"`
+	Order       string `form:"order" binding:"omitempty,oneof=asc desc"`
+	// ProjectID задаётся маршрутом GET /projects/:id/todos, а не query-параметром.
+	ProjectID *uuid.UUID `form:"-"`
+}
+
+// TodoCursor — позиция последней выданной задачи. Значение ключа сортировки
+// лежит в Time (created_at/updated_at), Title или Position, ID разрешает равенства.
+type TodoCursor struct {
+	Time     time.Time
+	Title    string
+	Position string
+	ID       uuid.UUID
+}
+
+// TodoListFilter — запрос к хранилищу: задачи пользователя строго после After
+// в порядке (Sort, id) по направлению Order, не больше Limit штук.
+type TodoListFilter struct {
+	WorkspaceID uuid.UUID
+	// UserID равен uuid.Nil для списка проекта: в общем проекте задачи создают разные
+	// пользователи, доступ к проекту проверяет сервис.
+	UserID        uuid.UUID
+	Completed     *bool
+	Priority      string
+	Tags          []string
+	TagsMatchAll  bool
+	ProjectID     *uuid.UUID
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// DueFrom (включительно) и DueBefore (не включительно) оставляют только задачи со сроком.
+	DueFrom   *time.Time
+	DueBefore *time.Time
+	Sort      string
+	Order     string
+	After     *TodoCursor
+	Limit     int
+}
+
+type TodoListResponse struct {
+	Todos      []entities.Todo `json:"todos"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+type SearchTodosRequest struct {
+	Query string `form:"q" binding:"required"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// TodoSearchResult — найденная задача. Highlights содержит заголовок и фрагмент
+// описания, экранированные как HTML, с совпадениями в <b>...</b>.
+type TodoSearchResult struct {
+	Todo       entities.Todo  `json:"todo"`
+	Rank       float64        `json:"rank"`
+	Highlights TodoHighlights `json:"highlights"`
+}
+
+type TodoHighlights struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 }
 
-type UpdateTodoRequest struct {
-	Title       *string `json:"title"`
-	Description *string `json:"description"`
-	Completed   *bool   `json:"completed"`
+type CreateTagRequest struct {
+	Name  string `json:"name" binding:"required,max=50"`
+	Color string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+type UpdateTagRequest struct {
+	Name  *string `json:"name" binding:"omitempty,max=50"`
+	Color *string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+// Что делать с задачами при удалении проекта: удалить вместе с ним или перенести во «Входящие».
+const (
+	ProjectTodosInbox   = "inbox"
+	ProjectTodosCascade = "cascade"
+)
+
+type CreateProjectRequest struct {
+	Name      string `json:"name" binding:"required,max=100"`
+	Color     string `json:"color" binding:"omitempty,hexcolor"`
+	SortOrder int    `json:"sort_order"`
+}
+
+type UpdateProjectRequest struct {
+	Name      *string `json:"name" binding:"omitempty,max=100"`
+	Color     *string `json:"color" binding:"omitempty,hexcolor"`
+	Archived  *bool   `json:"archived"`
+	SortOrder *int    `json:"sort_order"`
+}
+
+type ListProjectsRequest struct {
+	Archived bool `form:"archived"`
+}
+
+type DeleteProjectRequest struct {
+	Todos string `form:"todos" binding:"omitempty,oneof=inbox cascade"`
+}
+
+type CreateShareRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=viewer editor owner"`
+}
+
+// ShareResponse — выданный доступ вместе с почтой участника.
+type ShareResponse struct {
+	entities.Share
+	Email string `json:"email"`
+}
+
+type SharedTodo struct {
+	Todo entities.Todo `json:"todo"`
+	Role string        `json:"role"`
+}
+
+type SharedProject struct {
+	Project entities.Project `json:"project"`
+	Role    string           `json:"role"`
+}
+
+// SharedWithMeResponse — задачи и проекты других пользователей, к которым выдан доступ.
+type SharedWithMeResponse struct {
+	Todos    []SharedTodo    `json:"todos"`
+	Projects []SharedProject `json:"projects"`
+}
+
+type CreateCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// CommentResponse — комментарий вместе с почтой автора.
+type CommentResponse struct {
+	entities.Comment
+	AuthorEmail string `json:"author_email"`
+}
+
+// AttachmentUpload — загружаемый файл; Size известен заранее (из multipart-формы).
+type AttachmentUpload struct {
+	FileName string
+	Size     int64
+	Content  io.Reader
+}
+
+type CreateWorkspaceRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type UpdateWorkspaceRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// WorkspaceResponse — пространство вместе с ролью текущего пользователя в нём.
+type WorkspaceResponse struct {
+	entities.Workspace
+	Role string `json:"role"`
+}
+
+type AddWorkspaceMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=member admin owner"`
+}
+
+// WorkspaceMemberResponse — участник пространства вместе с его почтой.
+type WorkspaceMemberResponse struct {
+	entities.WorkspaceMember
+	Email string `json:"email"`
 }
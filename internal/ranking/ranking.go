@@ -0,0 +1,224 @@
+// Package ranking генерирует строковые ключи порядка (fractional indexing): между
+// любыми двумя ключами всегда есть третий, поэтому перестановка элемента меняет
+// только его собственный ключ. Ключи сравниваются побайтно.
+//
+// Ключ состоит из «целой» части — символ-заголовок задаёт её длину, как в
+// https://observablehq.com/@dgreensp/implementing-fractional-indexing — и дробной
+// части без завершающего нуля. Добавление в конец увеличивает целую часть, так что
+// ключи растут логарифмически, а не на символ за вставку.
+package ranking
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// smallestInteger — минимальная целая часть; ключ, состоящий только из неё, недопустим,
+// иначе перед ним нельзя было бы ничего вставить.
+var smallestInteger = "A" + strings.Repeat(string(digits[0]), 26)
+
+var (
+	ErrInvalidKey = errors.New("invalid ranking key")
+	ErrKeyOrder   = errors.New("ranking keys are not in ascending order")
+	ErrExhausted  = errors.New("ranking key space exhausted")
+)
+
+// Between возвращает ключ строго между a и b. Пустая строка означает отсутствие
+// границы: Between("", "") — первый ключ, Between(last, "") — ключ после last.
+func Between(a, b string) (string, error) {
+	if a != "" {
+		if err := Validate(a); err != nil {
+			return "", err
+		}
+	}
+	if b != "" {
+		if err := Validate(b); err != nil {
+			return "", err
+		}
+	}
+	if a != "" && b != "" && a >= b {
+		return "", ErrKeyOrder
+	}
+
+	if a == "" {
+		if b == "" {
+			return "a" + string(digits[0]), nil
+		}
+		ib := integerPart(b)
+		if ib == smallestInteger {
+			return ib + midpoint("", b[len(ib):]), nil
+		}
+		if ib < b {
+			return ib, nil
+		}
+		res, ok := decrementInteger(ib)
+		if !ok {
+			return "", ErrExhausted
+		}
+		return res, nil
+	}
+
+	ia := integerPart(a)
+	fa := a[len(ia):]
+	if b == "" {
+		if i, ok := incrementInteger(ia); ok {
+			return i, nil
+		}
+		return ia + midpoint(fa, ""), nil
+	}
+
+	ib := integerPart(b)
+	if ia == ib {
+		return ia + midpoint(fa, b[len(ib):]), nil
+	}
+	i, ok := incrementInteger(ia)
+	if !ok {
+		return "", ErrExhausted
+	}
+	if i < b {
+		return i, nil
+	}
+	return ia + midpoint(fa, ""), nil
+}
+
+// Nth возвращает i-й (с 1) из n возрастающих ключей для перенумерации списка: целая часть
+// из десятичных цифр одной ширины, не меньше шести. Так же ключи раздаёт SQL в миграциях
+// и Store.RebalanceTodoPositions.
+func Nth(i, n int) string {
+	width := max(6, len(fmt.Sprint(n)))
+	return fmt.Sprintf("%c%0*d", 'a'+width-1, width, i)
+}
+
+// Validate проверяет, что key может быть границей для Between.
+func Validate(key string) error {
+	if key == "" || key == smallestInteger {
+		return ErrInvalidKey
+	}
+	n := integerLength(key[0])
+	if n == 0 || n > len(key) {
+		return ErrInvalidKey
+	}
+	for i := 1; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return ErrInvalidKey
+		}
+	}
+	if len(key) > n && key[len(key)-1] == digits[0] {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+func integerLength(head byte) int {
+	switch {
+	case head >= 'a' && head <= 'z':
+		return int(head-'a') + 2
+	case head >= 'A' && head <= 'Z':
+		return int('Z'-head) + 2
+	}
+	return 0
+}
+
+func integerPart(key string) string {
+	return key[:integerLength(key[0])]
+}
+
+// midpoint возвращает дробную часть строго между a и b (b == "" — без верхней границы).
+func midpoint(a, b string) string {
+	if b != "" {
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	da := 0
+	if a != "" {
+		da = strings.IndexByte(digits, a[0])
+	}
+	db := len(digits)
+	if b != "" {
+		db = strings.IndexByte(digits, b[0])
+	}
+	if db-da > 1 {
+		return string(digits[(da+db+1)/2])
+	}
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(digits[da]) + midpoint(rest, "")
+}
+
+func digitAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return digits[0]
+}
+
+func incrementInteger(x string) (string, bool) {
+	head, digs := x[0], []byte(x[1:])
+	for i := len(digs) - 1; i >= 0; i-- {
+		d := strings.IndexByte(digits, digs[i]) + 1
+		if d < len(digits) {
+			digs[i] = digits[d]
+			return string(head) + string(digs), true
+		}
+		digs[i] = digits[0]
+	}
+
+	switch head {
+	case 'Z':
+		return "a" + string(digits[0]), true
+	case 'z':
+		return "", false
+	}
+	head++
+	if head > 'a' {
+		digs = append(digs, digits[0])
+	} else {
+		digs = digs[:len(digs)-1]
+	}
+	return string(head) + string(digs), true
+}
+
+func decrementInteger(x string) (string, bool) {
+	head, digs := x[0], []byte(x[1:])
+	last := digits[len(digits)-1]
+	for i := len(digs) - 1; i >= 0; i-- {
+		d := strings.IndexByte(digits, digs[i]) - 1
+		if d >= 0 {
+			digs[i] = digits[d]
+			return string(head) + string(digs), true
+		}
+		digs[i] = last
+	}
+
+	switch head {
+	case 'a':
+		return "Z" + string(last), true
+	case 'A':
+		return "", false
+	}
+	head--
+	if head < 'Z' {
+		digs = append(digs, last)
+	} else {
+		digs = digs[:len(digs)-1]
+	}
+	return string(head) + string(digs), true
+}
@@ -0,0 +1,98 @@
+package ranking_test
+
+import (
+	"testing"
+
+	"github.com/polzovatel/todo-learning/internal/ranking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"", "", "a0"},
+		{"", "a0", "Zz"},
+		{"a0", "", "a1"},
+		{"a0", "a1", "a0V"},
+		{"a1", "a2", "a1V"},
+		{"a0V", "a1", "a0l"},
+		{"Zz", "a0", "ZzV"},
+		{"Zz", "a01", "a0"},
+		{"", "a0V", "a0"},
+		{"az", "", "b00"},
+		{"b00", "b01", "b00V"},
+		{"a0", "a0V", "a0G"},
+	}
+	for _, tt := range tests {
+		got, err := ranking.Between(tt.a, tt.b)
+		require.NoError(t, err, "Between(%q, %q)", tt.a, tt.b)
+		assert.Equal(t, tt.want, got, "Between(%q, %q)", tt.a, tt.b)
+		assert.NoError(t, ranking.Validate(got))
+	}
+}
+
+func TestBetweenErrors(t *testing.T) {
+	_, err := ranking.Between("a1", "a0")
+	assert.ErrorIs(t, err, ranking.ErrKeyOrder)
+	_, err = ranking.Between("a0", "a0")
+	assert.ErrorIs(t, err, ranking.ErrKeyOrder)
+
+	for _, key := range []string{"a", "a00", "b0", "a0!", "0"} {
+		_, err := ranking.Between(key, "")
+		assert.ErrorIs(t, err, ranking.ErrInvalidKey, key)
+	}
+}
+
+func TestBetweenKeepsOrderAndStaysShort(t *testing.T) {
+	// Многократная вставка в конец, в начало и в одну и ту же щель.
+	last, _ := ranking.Between("", "")
+	for i := 0; i < 10000; i++ {
+		next, err := ranking.Between(last, "")
+		require.NoError(t, err)
+		require.Less(t, last, next)
+		last = next
+	}
+	assert.LessOrEqual(t, len(last), 4)
+
+	first, _ := ranking.Between("", "")
+	for i := 0; i < 10000; i++ {
+		prev, err := ranking.Between("", first)
+		require.NoError(t, err)
+		require.Less(t, prev, first)
+		first = prev
+	}
+	assert.LessOrEqual(t, len(first), 4)
+
+	lo, hi := "a0", "a1"
+	for i := 0; i < 200; i++ {
+		mid, err := ranking.Between(lo, hi)
+		require.NoError(t, err)
+		require.Less(t, lo, mid)
+		require.Less(t, mid, hi)
+		if i%2 == 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+}
+
+func TestNth(t *testing.T) {
+	assert.Equal(t, "f000001", ranking.Nth(1, 3))
+	assert.Equal(t, "f999999", ranking.Nth(999999, 999999))
+	assert.Equal(t, "g1000000", ranking.Nth(1000000, 1000000))
+	assert.Equal(t, "g0999999", ranking.Nth(999999, 1000000))
+
+	for _, n := range []int{1, 999999, 1000000} {
+		first, last := ranking.Nth(1, n), ranking.Nth(n, n)
+		require.NoError(t, ranking.Validate(first))
+		require.NoError(t, ranking.Validate(last))
+		assert.LessOrEqual(t, first, last)
+		between, err := ranking.Between(first, "")
+		require.NoError(t, err)
+		assert.Greater(t, between, first)
+	}
+}
@@ -0,0 +1,507 @@
+// Package recurrence разбирает правила повторения RRULE (RFC 5545) и вычисляет
+// следующее вхождение серии.
+//
+// Поддерживается подмножество, которого хватает для бытовых повторов: FREQ
+// DAILY/WEEKLY/MONTHLY/YEARLY, INTERVAL, COUNT, UNTIL, BYDAY (с порядковым номером
+// для MONTHLY/YEARLY), BYMONTHDAY, BYMONTH и WKST. Остальные части правила
+// отклоняются с ErrUnsupported.
+//
+// Вхождения считаются в настенном времени зоны начала серии, поэтому задача
+// «каждый день в 9:00» остаётся в 9:00 и после перехода на летнее время.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidRule = errors.New("invalid recurrence rule")
+	ErrUnsupported = errors.New("unsupported recurrence rule")
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// WeekdayNum — элемент BYDAY: N-й (с конца при N < 0) такой день месяца или года;
+// N == 0 — каждый такой день.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule — разобранное правило. Count — сколько вхождений осталось, включая начало серии.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+
+	until     time.Time
+	untilKind untilKind
+}
+
+type untilKind int
+
+const (
+	untilNone untilKind = iota
+	untilUTC
+	// untilFloating — дата-время без зоны, трактуется в зоне начала серии.
+	untilFloating
+	// untilDate — дата без времени, включает весь этот день.
+	untilDate
+)
+
+const (
+	untilUTCLayout      = "20060102T150405Z"
+	untilFloatingLayout = "20060102T150405"
+	untilDateLayout     = "20060102"
+)
+
+var weekdays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// maxPeriods ограничивает перебор для правил, у которых вхождений нет вовсе
+// (например, BYMONTH=2;BYMONTHDAY=30).
+const maxPeriods = 10000
+
+// Parse разбирает значение RRULE; префикс "RRULE:" допускается.
+func Parse(s string) (Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	r := Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || name == "" || value == "" {
+			return Rule{}, invalid("malformed part %q", part)
+		}
+		if seen[name] {
+			return Rule{}, invalid("%s is repeated", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			switch f := Frequency(value); f {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = f
+			case "SECONDLY", "MINUTELY", "HOURLY":
+				return Rule{}, fmt.Errorf("%w: FREQ=%s", ErrUnsupported, value)
+			default:
+				return Rule{}, invalid("unknown FREQ %q", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parsePositive(name, value)
+		case "COUNT":
+			r.Count, err = parsePositive(name, value)
+		case "UNTIL":
+			err = r.parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseInts(name, value, 1, 31, true)
+		case "BYMONTH":
+			var months []int
+			months, err = parseInts(name, value, 1, 12, false)
+			for _, m := range months {
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "WKST":
+			var day WeekdayNum
+			if day, err = parseWeekdayNum(value); err == nil && day.N != 0 {
+				err = invalid("WKST takes a plain weekday")
+			}
+			r.WeekStart = day.Day
+		case "BYSETPOS", "BYWEEKNO", "BYYEARDAY", "BYHOUR", "BYMINUTE", "BYSECOND":
+			return Rule{}, fmt.Errorf("%w: %s", ErrUnsupported, name)
+		default:
+			return Rule{}, invalid("unknown part %s", name)
+		}
+		if err != nil {
+			return Rule{}, err
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return Rule{}, invalid("FREQ is required")
+	case seen["COUNT"] && seen["UNTIL"]:
+		return Rule{}, invalid("COUNT and UNTIL cannot be combined")
+	case r.Freq == Weekly && len(r.ByMonthDay) > 0:
+		return Rule{}, invalid("BYMONTHDAY cannot be used with FREQ=WEEKLY")
+	}
+	if r.Freq != Monthly && r.Freq != Yearly {
+		for _, day := range r.ByDay {
+			if day.N != 0 {
+				return Rule{}, invalid("numbered BYDAY needs FREQ=MONTHLY or FREQ=YEARLY")
+			}
+		}
+	}
+	return r, nil
+}
+
+// String возвращает правило в каноническом виде (без префикса "RRULE:").
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	switch r.untilKind {
+	case untilUTC:
+		parts = append(parts, "UNTIL="+r.until.Format(untilUTCLayout))
+	case untilFloating:
+		parts = append(parts, "UNTIL="+r.until.Format(untilFloatingLayout))
+	case untilDate:
+		parts = append(parts, "UNTIL="+r.until.Format(untilDateLayout))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = strconv.Itoa(int(m))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = weekdays[d.Day]
+			if d.N != 0 {
+				days[i] = strconv.Itoa(d.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdays[r.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next возвращает первое вхождение строго после start — начала серии — и правило
+// для оставшейся серии, которая начинается с этого вхождения. ok == false, если
+// серия закончилась (COUNT или UNTIL).
+func (r Rule) Next(start time.Time) (next time.Time, rest Rule, ok bool) {
+	if r.Count == 1 {
+		return time.Time{}, Rule{}, false
+	}
+	until, hasUntil := r.untilIn(start.Location())
+
+	for period := 0; period < maxPeriods; period++ {
+		for _, t := range r.candidates(start, period) {
+			if !t.After(start) {
+				continue
+			}
+			if hasUntil && t.After(until) {
+				return time.Time{}, Rule{}, false
+			}
+			rest = r
+			if rest.Count > 0 {
+				rest.Count--
+			}
+			return t, rest, true
+		}
+	}
+	return time.Time{}, Rule{}, false
+}
+
+func (r Rule) untilIn(loc *time.Location) (time.Time, bool) {
+	u := r.until
+	switch r.untilKind {
+	case untilUTC:
+		return u, true
+	case untilFloating:
+		return time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, loc), true
+	case untilDate:
+		return time.Date(u.Year(), u.Month(), u.Day(), 23, 59, 59, 0, loc), true
+	}
+	return time.Time{}, false
+}
+
+// candidates возвращает вхождения периода с номером period (0 — период начала серии)
+// в порядке возрастания; часть из них может предшествовать start.
+func (r Rule) candidates(start time.Time, period int) []time.Time {
+	loc := start.Location()
+	y, m, d := start.Date()
+	hour, min, sec := start.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, min, sec, 0, loc)
+	}
+	n := period * r.Interval
+
+	var out []time.Time
+	switch r.Freq {
+	case Daily:
+		t := at(y, m, d+n)
+		if r.inMonths(t.Month()) && r.inMonthDays(t) && r.inWeekdays(t.Weekday()) {
+			out = append(out, t)
+		}
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			out = append(out, at(y, m, d+7*n))
+			break
+		}
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		for i := 0; i < 7; i++ {
+			t := at(y, m, d-offset+7*n+i)
+			if r.inWeekdays(t.Weekday()) {
+				out = append(out, t)
+			}
+		}
+	case Monthly:
+		first := time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+		if r.inMonths(first.Month()) {
+			for _, day := range r.monthDays(first.Year(), first.Month(), d) {
+				out = append(out, at(first.Year(), first.Month(), day))
+			}
+		}
+	case Yearly:
+		year := y + n
+		if len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0 && len(r.ByDay) > 0 {
+			// Порядковые номера BYDAY без BYMONTH считаются в пределах года.
+			first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+			length := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+			for _, offset := range selectWeekdays(first, length, r.ByDay) {
+				out = append(out, at(year, time.January, 1+offset))
+			}
+			break
+		}
+		// Без BYMONTH правило берёт месяц начала серии, а BYMONTHDAY — каждый месяц года
+		// (RFC 5545: BYMONTHDAY в YEARLY расширяет набор дат).
+		months := r.ByMonth
+		switch {
+		case len(months) == 0 && len(r.ByMonthDay) > 0:
+			months = make([]time.Month, 0, 12)
+			for month := time.January; month <= time.December; month++ {
+				months = append(months, month)
+			}
+		case len(months) == 0:
+			months = []time.Month{m}
+		}
+		for _, month := range months {
+			for _, day := range r.monthDays(year, month, d) {
+				out = append(out, at(year, month, day))
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// monthDays возвращает дни месяца по BYMONTHDAY и BYDAY; без них — день startDay,
+// если он в месяце есть (31-е число пропускает короткие месяцы, как в RFC 5545).
+func (r Rule) monthDays(year int, month time.Month, startDay int) []int {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+
+	var byDay map[int]bool
+	if len(r.ByDay) > 0 {
+		byDay = make(map[int]bool)
+		for _, offset := range selectWeekdays(first, last, r.ByDay) {
+			byDay[offset+1] = true
+		}
+	}
+
+	var days []int
+	switch {
+	case len(r.ByMonthDay) > 0:
+		seen := make(map[int]bool)
+		for _, md := range r.ByMonthDay {
+			day := md
+			if md < 0 {
+				day = last + md + 1
+			}
+			if day < 1 || day > last || seen[day] || (byDay != nil && !byDay[day]) {
+				continue
+			}
+			seen[day] = true
+			days = append(days, day)
+		}
+	case byDay != nil:
+		for day := range byDay {
+			days = append(days, day)
+		}
+	case startDay <= last:
+		days = append(days, startDay)
+	}
+	sort.Ints(days)
+	return days
+}
+
+// selectWeekdays возвращает смещения (от first) дней диапазона длиной length,
+// подходящих под BYDAY с учётом порядковых номеров.
+func selectWeekdays(first time.Time, length int, byDay []WeekdayNum) []int {
+	var byWeekday [7][]int
+	for offset := 0; offset < length; offset++ {
+		wd := (int(first.Weekday()) + offset) % 7
+		byWeekday[wd] = append(byWeekday[wd], offset)
+	}
+
+	selected := make(map[int]bool)
+	for _, day := range byDay {
+		all := byWeekday[day.Day]
+		switch {
+		case day.N == 0:
+			for _, offset := range all {
+				selected[offset] = true
+			}
+		case day.N > 0 && day.N <= len(all):
+			selected[all[day.N-1]] = true
+		case day.N < 0 && -day.N <= len(all):
+			selected[all[len(all)+day.N]] = true
+		}
+	}
+
+	offsets := make([]int, 0, len(selected))
+	for offset := range selected {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+	return offsets
+}
+
+func (r Rule) inMonths(month time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) inMonthDays(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.ByMonthDay {
+		if md == t.Day() || md == t.Day()-last-1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) inWeekdays(wd time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if day.Day == wd {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) parseUntil(value string) error {
+	layouts := []struct {
+		layout string
+		kind   untilKind
+	}{
+		{untilUTCLayout, untilUTC},
+		{untilFloatingLayout, untilFloating},
+		{untilDateLayout, untilDate},
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l.layout, value); err == nil {
+			r.until, r.untilKind = t, l.kind
+			return nil
+		}
+	}
+	return invalid("malformed UNTIL %q", value)
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		day, err := parseWeekdayNum(item)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	if len(value) < 2 {
+		return WeekdayNum{}, invalid("malformed weekday %q", value)
+	}
+	prefix, name := value[:len(value)-2], value[len(value)-2:]
+	day := -1
+	for i, wd := range weekdays {
+		if wd == name {
+			day = i
+		}
+	}
+	if day < 0 {
+		return WeekdayNum{}, invalid("unknown weekday %q", value)
+	}
+
+	n := 0
+	if prefix != "" {
+		var err error
+		if n, err = strconv.Atoi(prefix); err != nil || n == 0 || n < -53 || n > 53 {
+			return WeekdayNum{}, invalid("malformed weekday %q", value)
+		}
+	}
+	return WeekdayNum{N: n, Day: time.Weekday(day)}, nil
+}
+
+func parseInts(name, value string, lo, hi int, allowNegative bool) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		abs := n
+		if n < 0 && allowNegative {
+			abs = -n
+		}
+		if err != nil || abs < lo || abs > hi {
+			return nil, invalid("%s value %q out of range", name, item)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+func parsePositive(name, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, invalid("%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, args...))
+}
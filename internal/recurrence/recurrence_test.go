@@ -0,0 +1,151 @@
+package recurrence_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/polzovatel/todo-learning/internal/recurrence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// occurrences возвращает первые n вхождений серии после start, проходя её так же,
+// как сервис задач: каждое следующее вхождение становится началом оставшейся серии.
+func occurrences(t *testing.T, rule string, start time.Time, n int) []time.Time {
+	t.Helper()
+	r, err := recurrence.Parse(rule)
+	require.NoError(t, err)
+
+	var out []time.Time
+	for len(out) < n {
+		next, rest, ok := r.Next(start)
+		if !ok {
+			break
+		}
+		out = append(out, next)
+		start, r = next, rest
+	}
+	return out
+}
+
+func dates(times []time.Time) []string {
+	out := make([]string, len(times))
+	for i, t := range times {
+		out[i] = t.Format("2006-01-02 15:04 MST")
+	}
+	return out
+}
+
+func TestNext(t *testing.T) {
+	utc := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		n     int
+		want  []string
+	}{
+		{
+			name: "daily", rule: "FREQ=DAILY", start: utc(2026, 1, 30), n: 3,
+			want: []string{"2026-01-31 09:00 UTC", "2026-02-01 09:00 UTC", "2026-02-02 09:00 UTC"},
+		},
+		{
+			name: "every other week on monday and thursday", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", start: utc(2026, 3, 5), n: 4,
+			want: []string{"2026-03-16 09:00 UTC", "2026-03-19 09:00 UTC", "2026-03-30 09:00 UTC", "2026-04-02 09:00 UTC"},
+		},
+		{
+			name: "weekly on start weekday", rule: "FREQ=WEEKLY", start: utc(2026, 3, 5), n: 2,
+			want: []string{"2026-03-12 09:00 UTC", "2026-03-19 09:00 UTC"},
+		},
+		{
+			name: "last friday of the month", rule: "FREQ=MONTHLY;BYDAY=-1FR", start: utc(2026, 1, 30), n: 3,
+			want: []string{"2026-02-27 09:00 UTC", "2026-03-27 09:00 UTC", "2026-04-24 09:00 UTC"},
+		},
+		{
+			name: "31st skips short months", rule: "FREQ=MONTHLY", start: utc(2026, 1, 31), n: 3,
+			want: []string{"2026-03-31 09:00 UTC", "2026-05-31 09:00 UTC", "2026-07-31 09:00 UTC"},
+		},
+		{
+			name: "last day of the month", rule: "FREQ=MONTHLY;BYMONTHDAY=-1", start: utc(2026, 1, 31), n: 3,
+			want: []string{"2026-02-28 09:00 UTC", "2026-03-31 09:00 UTC", "2026-04-30 09:00 UTC"},
+		},
+		{
+			name: "leap day", rule: "FREQ=YEARLY", start: utc(2024, 2, 29), n: 2,
+			want: []string{"2028-02-29 09:00 UTC", "2032-02-29 09:00 UTC"},
+		},
+		{
+			name: "yearly in listed months", rule: "FREQ=YEARLY;BYMONTH=3,9;BYMONTHDAY=1", start: utc(2026, 3, 1), n: 3,
+			want: []string{"2026-09-01 09:00 UTC", "2027-03-01 09:00 UTC", "2027-09-01 09:00 UTC"},
+		},
+		{
+			name: "yearly month day without month means every month", rule: "FREQ=YEARLY;BYMONTHDAY=15", start: utc(2026, 11, 15), n: 3,
+			want: []string{"2026-12-15 09:00 UTC", "2027-01-15 09:00 UTC", "2027-02-15 09:00 UTC"},
+		},
+		{
+			name: "yearly last day of every month", rule: "FREQ=YEARLY;BYMONTHDAY=-1", start: utc(2026, 12, 31), n: 2,
+			want: []string{"2027-01-31 09:00 UTC", "2027-02-28 09:00 UTC"},
+		},
+		{
+			name: "first monday of the year", rule: "FREQ=YEARLY;BYDAY=1MO", start: utc(2026, 1, 5), n: 2,
+			want: []string{"2027-01-04 09:00 UTC", "2028-01-03 09:00 UTC"},
+		},
+		{
+			name: "count includes start", rule: "FREQ=DAILY;COUNT=3", start: utc(2026, 1, 1), n: 10,
+			want: []string{"2026-01-02 09:00 UTC", "2026-01-03 09:00 UTC"},
+		},
+		{
+			name: "until is inclusive", rule: "FREQ=DAILY;UNTIL=20260103T090000Z", start: utc(2026, 1, 1), n: 10,
+			want: []string{"2026-01-02 09:00 UTC", "2026-01-03 09:00 UTC"},
+		},
+		{
+			name: "weekdays only", rule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", start: utc(2026, 3, 6), n: 2,
+			want: []string{"2026-03-09 09:00 UTC", "2026-03-10 09:00 UTC"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, dates(occurrences(t, tt.rule, tt.start, tt.n)))
+		})
+	}
+}
+
+func TestNextKeepsWallClockAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// 29 марта 2026 Берлин переходит на летнее время.
+	start := time.Date(2026, 3, 28, 9, 0, 0, 0, berlin)
+	got := occurrences(t, "FREQ=DAILY", start, 2)
+	assert.Equal(t, []string{"2026-03-29 09:00 CEST", "2026-03-30 09:00 CEST"}, dates(got))
+	assert.Equal(t, 23*time.Hour, got[0].Sub(start))
+
+	// Floating UNTIL трактуется в зоне серии.
+	got = occurrences(t, "FREQ=DAILY;UNTIL=20260329T090000", start, 5)
+	assert.Equal(t, []string{"2026-03-29 09:00 CEST"}, dates(got))
+}
+
+func TestParse(t *testing.T) {
+	r, err := recurrence.Parse("RRULE:freq=weekly;byday=mo,we;interval=2;wkst=su")
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;WKST=SU", r.String())
+
+	r, err = recurrence.Parse("FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20261231")
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=MONTHLY;UNTIL=20261231;BYDAY=-1FR", r.String())
+
+	for _, rule := range []string{
+		"", "FREQ=FORTNIGHTLY", "INTERVAL=2", "FREQ=DAILY;INTERVAL=0", "FREQ=DAILY;COUNT=2;UNTIL=20260101",
+		"FREQ=DAILY;BYDAY=1MO", "FREQ=WEEKLY;BYMONTHDAY=1", "FREQ=MONTHLY;BYMONTHDAY=32", "FREQ=DAILY;FREQ=DAILY",
+		"FREQ=DAILY;UNTIL=tomorrow", "FREQ=DAILY;FOO=1",
+	} {
+		_, err := recurrence.Parse(rule)
+		assert.ErrorIs(t, err, recurrence.ErrInvalidRule, rule)
+	}
+
+	for _, rule := range []string{"FREQ=HOURLY", "FREQ=MONTHLY;BYDAY=MO;BYSETPOS=-1"} {
+		_, err := recurrence.Parse(rule)
+		assert.ErrorIs(t, err, recurrence.ErrUnsupported, rule)
+	}
+}
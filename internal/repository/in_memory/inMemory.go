@@ -3,6 +3,9 @@ package in_memory
 import (
 	"context"
 	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,31 +14,79 @@ import (
 )
 
 type InMemoryRepository struct {
-	users     map[uuid.UUID]*entities.User
-	emailToID map[string]uuid.UUID
-	todos     map[uuid.UUID]*entities.Todo
-	logger    *slog.Logger
+	users       map[uuid.UUID]*entities.User
+	emailToID   map[string]uuid.UUID
+	todos       map[uuid.UUID]*entities.Todo
+	clients     map[string]*entities.Client
+	revoked     map[string]time.Time
+	patTokens   map[uuid.UUID]*entities.PersonalToken
+	challenges  map[string]*entities.WebAuthnChallenge
+	credentials map[string]*entities.WebAuthnCredential // ключ — string(credential id)
+	magicLinks  map[string]*entities.MagicLink
+	searchIndex map[string]map[uuid.UUID]struct{} // слово -> задачи
+	searchTerms map[uuid.UUID][]string            // задача -> её слова, для переиндексации
+	tags        map[uuid.UUID]*entities.Tag
+	todoTags    map[uuid.UUID]map[uuid.UUID]struct{} // задача -> её метки
+	projects    map[uuid.UUID]*entities.Project
+	shares      map[uuid.UUID]*entities.Share
+	workspaces  map[uuid.UUID]*entities.Workspace
+	members     map[uuid.UUID]map[uuid.UUID]*entities.WorkspaceMember // пространство -> участник
+	comments    map[uuid.UUID]*entities.Comment
+	attachments map[uuid.UUID]*entities.Attachment
+	revisions   map[uuid.UUID][]entities.TodoRevision // задача -> ревизии по порядку
+	logger      *slog.Logger
+	// mu — общая блокировка хранилища, holder — её текущий владелец (см. lock).
+	mu     sync.Mutex
+	holder atomic.Pointer[lockHolder]
 }
 
 func NewInMemoryRepository(logger *slog.Logger) *InMemoryRepository {
 	return &InMemoryRepository{
-		users:     make(map[uuid.UUID]*entities.User),
-		emailToID: make(map[string]uuid.UUID),
-		todos:     make(map[uuid.UUID]*entities.Todo),
-		logger:    logger,
+		users:       make(map[uuid.UUID]*entities.User),
+		emailToID:   make(map[string]uuid.UUID),
+		todos:       make(map[uuid.UUID]*entities.Todo),
+		clients:     make(map[string]*entities.Client),
+		revoked:     make(map[string]time.Time),
+		patTokens:   make(map[uuid.UUID]*entities.PersonalToken),
+		challenges:  make(map[string]*entities.WebAuthnChallenge),
+		credentials: make(map[string]*entities.WebAuthnCredential),
+		magicLinks:  make(map[string]*entities.MagicLink),
+		searchIndex: make(map[string]map[uuid.UUID]struct{}),
+		searchTerms: make(map[uuid.UUID][]string),
+		tags:        make(map[uuid.UUID]*entities.Tag),
+		todoTags:    make(map[uuid.UUID]map[uuid.UUID]struct{}),
+		projects:    make(map[uuid.UUID]*entities.Project),
+		shares:      make(map[uuid.UUID]*entities.Share),
+		workspaces:  make(map[uuid.UUID]*entities.Workspace),
+		members:     make(map[uuid.UUID]map[uuid.UUID]*entities.WorkspaceMember),
+		comments:    make(map[uuid.UUID]*entities.Comment),
+		attachments: make(map[uuid.UUID]*entities.Attachment),
+		revisions:   make(map[uuid.UUID][]entities.TodoRevision),
+		logger:      logger,
 	}
 }
 
 func (r *InMemoryRepository) CreateUser(ctx context.Context, email, passwordHash string) (entities.User, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
 	user := &entities.User{
 		ID:           uuid.New(),
 		Email:        email,
 		PasswordHash: passwordHash,
 		CreatedAt:    time.Now(),
+		Timezone:     "UTC",
 	}
 
+	remember(r, r.users, user.ID)
+	remember(r, r.emailToID, user.Email)
+	remember(r, r.workspaces, user.ID)
+	remember(r, r.members, user.ID)
 	r.users[user.ID] = user
 	r.emailToID[user.Email] = user.ID
+	r.workspaces[user.ID] = &entities.Workspace{ID: user.ID, Name: entities.PersonalWorkspaceName, Personal: true, CreatedAt: user.CreatedAt}
+	r.members[user.ID] = map[uuid.UUID]*entities.WorkspaceMember{
+		user.ID: {WorkspaceID: user.ID, UserID: user.ID, Role: entities.WorkspaceRoleOwner, CreatedAt: user.CreatedAt},
+	}
 
 	if r.logger != nil {
 		r.logger.Info("memory: user created", slog.String("user_id", user.ID.String()))
@@ -44,6 +95,8 @@ func (r *InMemoryRepository) CreateUser(ctx context.Context, email, passwordHash
 }
 
 func (r *InMemoryRepository) GetUserByEmail(ctx context.Context, email string) (*entities.User, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
 	userID, ok := r.emailToID[email]
 	if !ok {
 		if r.logger != nil {
@@ -56,6 +109,8 @@ func (r *InMemoryRepository) GetUserByEmail(ctx context.Context, email string) (
 }
 
 func (r *InMemoryRepository) GetUserById(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
 	user, ok := r.users[userID]
 	if !ok {
 		if r.logger != nil {
@@ -64,10 +119,13 @@ func (r *InMemoryRepository) GetUserById(ctx context.Context, userID uuid.UUID)
 		return nil, domain.ErrUserNotFound
 	}
 
-	return user, nil
+	found := *user
+	return &found, nil
 }
 
 func (r *InMemoryRepository) GetAllUsers(ctx context.Context) ([]entities.User, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
 	users := make([]entities.User, 0, len(r.users))
 	for _, user := range r.users {
 		users = append(users, *user)
@@ -77,16 +135,22 @@ func (r *InMemoryRepository) GetAllUsers(ctx context.Context) ([]entities.User,
 }
 
 func (r *InMemoryRepository) UpdateUser(ctx context.Context, user *entities.User) (*entities.User, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
 	oldUser, err := r.GetUserById(ctx, user.ID)
 	if err != nil {
 		return nil, domain.ErrUserNotFound
 	}
 
 	if oldUser.Email != user.Email {
+		remember(r, r.emailToID, oldUser.Email)
 		delete(r.emailToID, oldUser.Email)
 	}
 
-	r.users[user.ID] = user
+	updated := *user
+	remember(r, r.users, user.ID)
+	remember(r, r.emailToID, user.Email)
+	r.users[user.ID] = &updated
 	r.emailToID[user.Email] = user.ID
 
 	if r.logger != nil {
@@ -96,13 +160,95 @@ func (r *InMemoryRepository) UpdateUser(ctx context.Context, user *entities.User
 }
 
 func (r *InMemoryRepository) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
 	user, err := r.GetUserById(ctx, userID)
 	if err != nil {
 		return domain.ErrUserNotFound
 	}
 
+	remember(r, r.users, userID)
+	remember(r, r.emailToID, user.Email)
 	delete(r.users, userID)
 	delete(r.emailToID, user.Email)
+	r.removeWorkspace(userID)
+	// В командных пространствах удаляется всё, что принадлежит пользователю, как
+	// внешние ключи на users с ON DELETE CASCADE в Postgres.
+	for id, todo := range r.todos {
+		if todo.UserID == userID {
+			r.purgeTodo(id)
+		}
+	}
+	for id, project := range r.projects {
+		if project.UserID != userID {
+			continue
+		}
+		for todoID, todo := range r.todos {
+			if todo.ProjectID != nil && *todo.ProjectID == id {
+				rememberPtr(r, r.todos, todoID)
+				todo.ProjectID = nil
+			}
+		}
+		remember(r, r.projects, id)
+		delete(r.projects, id)
+		r.deleteSharesOf(entities.ShareResourceProject, id)
+	}
+	for id, tag := range r.tags {
+		if tag.UserID == userID {
+			r.removeTag(id)
+		}
+	}
+	for id, share := range r.shares {
+		if share.UserID == userID || share.InvitedBy == userID {
+			remember(r, r.shares, id)
+			delete(r.shares, id)
+		}
+	}
+	for key, cred := range r.credentials {
+		if cred.UserID == userID {
+			remember(r, r.credentials, key)
+			delete(r.credentials, key)
+		}
+	}
+	for key, challenge := range r.challenges {
+		if challenge.UserID != nil && *challenge.UserID == userID {
+			remember(r, r.challenges, key)
+			delete(r.challenges, key)
+		}
+	}
+	for workspaceID, members := range r.members {
+		if _, ok := members[userID]; ok {
+			rememberNested(r, r.members, workspaceID, userID)
+			delete(members, userID)
+		}
+	}
+	r.deleteCommentsWhere(func(comment *entities.Comment) bool { return comment.AuthorID == userID })
+	for id, token := range r.patTokens {
+		if token.UserID == userID {
+			remember(r, r.patTokens, id)
+			delete(r.patTokens, id)
+		}
+	}
+	for id, attachment := range r.attachments {
+		if attachment.UploaderID == userID {
+			rememberPtr(r, r.attachments, id)
+			attachment.UploaderID = uuid.Nil
+		}
+	}
+	// Ревизии копируются перед правкой: журнал отката хранит прежний срез.
+	for todoID, revisions := range r.revisions {
+		if !slices.ContainsFunc(revisions, func(revision entities.TodoRevision) bool { return revision.ActorID == userID }) {
+			continue
+		}
+		remember(r, r.revisions, todoID)
+		revisions = slices.Clone(revisions)
+		for i := range revisions {
+			if revisions[i].ActorID == userID {
+				revisions[i].ActorID = uuid.Nil
+			}
+		}
+		r.revisions[todoID] = revisions
+	}
 
 	if r.logger != nil {
 		r.logger.Info("memory: user deleted", slog.String("user_id", userID.String()))
@@ -0,0 +1,103 @@
+package in_memory
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+func (r *InMemoryRepository) CreateAttachment(ctx context.Context, attachment entities.Attachment) (entities.Attachment, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	attachment.ID = uuid.New()
+	attachment.CreatedAt = time.Now()
+	remember(r, r.attachments, attachment.ID)
+	r.attachments[attachment.ID] = &attachment
+
+	if r.logger != nil {
+		r.logger.Info("memory: attachment created", slog.String("attachment_id", attachment.ID.String()), slog.String("todo_id", attachment.TodoID.String()))
+	}
+	return attachment, nil
+}
+
+func (r *InMemoryRepository) GetAttachmentByID(ctx context.Context, attachmentID uuid.UUID) (*entities.Attachment, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	attachment, ok := r.attachments[attachmentID]
+	if !ok || orphaned(attachment) {
+		if r.logger != nil {
+			r.logger.Warn("memory: attachment not found", slog.String("attachment_id", attachmentID.String()))
+		}
+		return nil, domain.ErrAttachmentNotFound
+	}
+
+	found := *attachment
+	return &found, nil
+}
+
+func (r *InMemoryRepository) GetAttachmentsByTodoID(ctx context.Context, todoID uuid.UUID) ([]entities.Attachment, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	return r.findAttachments(func(attachment *entities.Attachment) bool {
+		return attachment.TodoID == todoID && !orphaned(attachment)
+	}, 0), nil
+}
+
+func (r *InMemoryRepository) GetOrphanedAttachments(ctx context.Context, after uuid.UUID, limit int) ([]entities.Attachment, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	attachments := r.findAttachments(func(attachment *entities.Attachment) bool {
+		return orphaned(attachment) && attachment.ID.String() > after.String()
+	}, 0)
+	// Строковый порядок uuid совпадает с порядком uuid в Postgres.
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].ID.String() < attachments[j].ID.String() })
+	if limit > 0 && len(attachments) > limit {
+		attachments = attachments[:limit]
+	}
+	return attachments, nil
+}
+
+func (r *InMemoryRepository) DeleteAttachment(ctx context.Context, attachmentID uuid.UUID) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if _, ok := r.attachments[attachmentID]; !ok {
+		return domain.ErrAttachmentNotFound
+	}
+	remember(r, r.attachments, attachmentID)
+	delete(r.attachments, attachmentID)
+
+	if r.logger != nil {
+		r.logger.Info("memory: attachment deleted", slog.String("attachment_id", attachmentID.String()))
+	}
+	return nil
+}
+
+// findAttachments возвращает подходящие вложения, старые первыми; limit 0 — без ограничения.
+func (r *InMemoryRepository) findAttachments(match func(attachment *entities.Attachment) bool, limit int) []entities.Attachment {
+	attachments := make([]entities.Attachment, 0)
+	for _, attachment := range r.attachments {
+		if match(attachment) {
+			attachments = append(attachments, *attachment)
+		}
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		if !attachments[i].CreatedAt.Equal(attachments[j].CreatedAt) {
+			return attachments[i].CreatedAt.Before(attachments[j].CreatedAt)
+		}
+		return attachments[i].ID.String() < attachments[j].ID.String()
+	})
+	if limit > 0 && len(attachments) > limit {
+		attachments = attachments[:limit]
+	}
+	return attachments
+}
+
+// orphaned — задача удалена (ON DELETE SET NULL в Postgres).
+func orphaned(attachment *entities.Attachment) bool {
+	return attachment.TodoID == uuid.Nil
+}
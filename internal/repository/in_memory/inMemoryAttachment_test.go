@@ -0,0 +1,104 @@
+package in_memory_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepository_Attachments(t *testing.T) {
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	ctx := context.Background()
+
+	owner, _ := repo.CreateUser(ctx, "attachments@example.com", "hash")
+	editor, _ := repo.CreateUser(ctx, "attachments-editor@example.com", "hash")
+	todo, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: owner.ID, UserID: owner.ID, Title: "with files"})
+	other, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: owner.ID, UserID: owner.ID, Title: "other"})
+
+	create := func(todoID, uploaderID uuid.UUID, key string) entities.Attachment {
+		attachment, err := repo.CreateAttachment(ctx, entities.Attachment{
+			TodoID:      todoID,
+			UploaderID:  uploaderID,
+			FileName:    key + ".txt",
+			ContentType: "text/plain",
+			Size:        4,
+			StorageKey:  key,
+		})
+		require.NoError(t, err)
+		return attachment
+	}
+	keys := func(attachments []entities.Attachment) []string {
+		got := []string{}
+		for _, attachment := range attachments {
+			got = append(got, attachment.StorageKey)
+		}
+		return got
+	}
+
+	first := create(todo.ID, owner.ID, "first")
+	create(todo.ID, owner.ID, "second")
+	create(other.ID, owner.ID, "elsewhere")
+	byEditor := create(other.ID, editor.ID, "by-editor")
+
+	t.Run("list by todo oldest first", func(t *testing.T) {
+		attachments, err := repo.GetAttachmentsByTodoID(ctx, todo.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"first", "second"}, keys(attachments))
+
+		got, err := repo.GetAttachmentByID(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first.txt", got.FileName)
+	})
+
+	t.Run("purging the todo orphans its attachments", func(t *testing.T) {
+		require.NoError(t, repo.DeleteTodo(ctx, owner.ID, todo.ID, todo.Version))
+		orphans, err := repo.GetOrphanedAttachments(ctx, uuid.Nil, 0)
+		require.NoError(t, err)
+		assert.Empty(t, orphans)
+
+		require.NoError(t, repo.PurgeTodo(ctx, owner.ID, todo.ID))
+		_, err = repo.GetAttachmentByID(ctx, first.ID)
+		assert.ErrorIs(t, err, domain.ErrAttachmentNotFound)
+
+		orphans, err = repo.GetOrphanedAttachments(ctx, uuid.Nil, 0)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"first", "second"}, keys(orphans))
+
+		// Постраничный обход по id проходит каждое вложение ровно один раз.
+		page, err := repo.GetOrphanedAttachments(ctx, uuid.Nil, 1)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		rest, err := repo.GetOrphanedAttachments(ctx, page[0].ID, 1)
+		require.NoError(t, err)
+		require.Len(t, rest, 1)
+		assert.Equal(t, orphans[1].ID, rest[0].ID)
+		last, err := repo.GetOrphanedAttachments(ctx, rest[0].ID, 1)
+		require.NoError(t, err)
+		assert.Empty(t, last)
+	})
+
+	t.Run("deleting the uploader keeps their attachments", func(t *testing.T) {
+		require.NoError(t, repo.DeleteUser(ctx, editor.ID))
+		attachments, err := repo.GetAttachmentsByTodoID(ctx, other.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"elsewhere", "by-editor"}, keys(attachments))
+		assert.Equal(t, uuid.Nil, attachments[1].UploaderID)
+
+		orphans, _ := repo.GetOrphanedAttachments(ctx, uuid.Nil, 0)
+		assert.ElementsMatch(t, []string{"first", "second"}, keys(orphans))
+	})
+
+	t.Run("delete removes the record", func(t *testing.T) {
+		require.NoError(t, repo.DeleteAttachment(ctx, byEditor.ID))
+		assert.ErrorIs(t, repo.DeleteAttachment(ctx, byEditor.ID), domain.ErrAttachmentNotFound)
+		attachments, _ := repo.GetAttachmentsByTodoID(ctx, other.ID)
+		assert.Equal(t, []string{"elsewhere"}, keys(attachments))
+	})
+}
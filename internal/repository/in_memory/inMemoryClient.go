@@ -0,0 +1,66 @@
+package in_memory
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+func (r *InMemoryRepository) CreateClient(ctx context.Context, clientID, secretHash string, scopes []string) (entities.Client, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	client := &entities.Client{
+		ID:         uuid.New(),
+		ClientID:   clientID,
+		SecretHash: secretHash,
+		Scopes:     append([]string(nil), scopes...),
+		CreatedAt:  time.Now(),
+	}
+
+	remember(r, r.clients, client.ClientID)
+	r.clients[client.ClientID] = client
+
+	if r.logger != nil {
+		r.logger.Info("memory: client created", slog.String("client_id", clientID))
+	}
+	return *client, nil
+}
+
+func (r *InMemoryRepository) GetClientByClientID(ctx context.Context, clientID string) (*entities.Client, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	client, ok := r.clients[clientID]
+	if !ok {
+		if r.logger != nil {
+			r.logger.Warn("memory: client not found", slog.String("client_id", clientID))
+		}
+		return nil, domain.ErrClientNotFound
+	}
+
+	found := *client
+	return &found, nil
+}
+
+func (r *InMemoryRepository) UpdateClient(ctx context.Context, client *entities.Client) (*entities.Client, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if _, ok := r.clients[client.ClientID]; !ok {
+		if r.logger != nil {
+			r.logger.Warn("memory: client not found for update", slog.String("client_id", client.ClientID))
+		}
+		return nil, domain.ErrClientNotFound
+	}
+
+	updated := *client
+	remember(r, r.clients, client.ClientID)
+	r.clients[client.ClientID] = &updated
+
+	if r.logger != nil {
+		r.logger.Info("memory: client updated", slog.String("client_id", client.ClientID))
+	}
+	return client, nil
+}
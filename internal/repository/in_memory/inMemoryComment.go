@@ -0,0 +1,109 @@
+package in_memory
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+func (r *InMemoryRepository) CreateComment(ctx context.Context, comment entities.Comment) (entities.Comment, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	comment.ID = uuid.New()
+	comment.CreatedAt = time.Now()
+	comment.EditedAt, comment.DeletedAt = nil, nil
+	remember(r, r.comments, comment.ID)
+	r.comments[comment.ID] = &comment
+
+	if r.logger != nil {
+		r.logger.Info("memory: comment created", slog.String("comment_id", comment.ID.String()), slog.String("todo_id", comment.TodoID.String()))
+	}
+	return comment, nil
+}
+
+func (r *InMemoryRepository) GetCommentByID(ctx context.Context, commentID uuid.UUID) (*entities.Comment, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	comment, ok := r.comments[commentID]
+	if !ok || comment.DeletedAt != nil {
+		if r.logger != nil {
+			r.logger.Warn("memory: comment not found", slog.String("comment_id", commentID.String()))
+		}
+		return nil, domain.ErrCommentNotFound
+	}
+
+	found := *comment
+	return &found, nil
+}
+
+func (r *InMemoryRepository) GetCommentsByTodoID(ctx context.Context, todoID uuid.UUID) ([]entities.Comment, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	comments := make([]entities.Comment, 0)
+	for _, comment := range r.comments {
+		if comment.TodoID == todoID && comment.DeletedAt == nil {
+			comments = append(comments, *comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID.String() < comments[j].ID.String()
+	})
+
+	return comments, nil
+}
+
+func (r *InMemoryRepository) UpdateComment(ctx context.Context, comment *entities.Comment) (*entities.Comment, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	existing, ok := r.comments[comment.ID]
+	if !ok || existing.DeletedAt != nil {
+		return nil, domain.ErrCommentNotFound
+	}
+
+	rememberPtr(r, r.comments, comment.ID)
+	now := time.Now()
+	existing.Body = comment.Body
+	existing.EditedAt = &now
+
+	if r.logger != nil {
+		r.logger.Info("memory: comment updated", slog.String("comment_id", comment.ID.String()))
+	}
+	updated := *existing
+	return &updated, nil
+}
+
+func (r *InMemoryRepository) DeleteComment(ctx context.Context, commentID uuid.UUID) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	comment, ok := r.comments[commentID]
+	if !ok || comment.DeletedAt != nil {
+		return domain.ErrCommentNotFound
+	}
+
+	rememberPtr(r, r.comments, commentID)
+	now := time.Now()
+	comment.DeletedAt = &now
+
+	if r.logger != nil {
+		r.logger.Info("memory: comment deleted", slog.String("comment_id", commentID.String()))
+	}
+	return nil
+}
+
+// deleteCommentsWhere удаляет комментарии окончательно, как ON DELETE CASCADE в Postgres.
+func (r *InMemoryRepository) deleteCommentsWhere(match func(comment *entities.Comment) bool) {
+	for id, comment := range r.comments {
+		if match(comment) {
+			remember(r, r.comments, id)
+			delete(r.comments, id)
+		}
+	}
+}
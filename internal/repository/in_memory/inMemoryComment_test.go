@@ -0,0 +1,71 @@
+package in_memory_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepository_Comments(t *testing.T) {
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	ctx := context.Background()
+
+	owner, _ := repo.CreateUser(ctx, "comments@example.com", "hash")
+	friend, _ := repo.CreateUser(ctx, "comments-friend@example.com", "hash")
+	todo, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: owner.ID, UserID: owner.ID, Title: "discussed"})
+
+	first, err := repo.CreateComment(ctx, entities.Comment{TodoID: todo.ID, AuthorID: owner.ID, Body: "first"})
+	require.NoError(t, err)
+	second, _ := repo.CreateComment(ctx, entities.Comment{TodoID: todo.ID, AuthorID: friend.ID, Body: "second"})
+
+	bodies := func() []string {
+		comments, err := repo.GetCommentsByTodoID(ctx, todo.ID)
+		require.NoError(t, err)
+		got := []string{}
+		for _, comment := range comments {
+			got = append(got, comment.Body)
+		}
+		return got
+	}
+
+	t.Run("list oldest first", func(t *testing.T) {
+		assert.Equal(t, []string{"first", "second"}, bodies())
+	})
+
+	t.Run("update marks the edit", func(t *testing.T) {
+		assert.Nil(t, first.EditedAt)
+		first.Body = "first, edited"
+		updated, err := repo.UpdateComment(ctx, &first)
+		require.NoError(t, err)
+		assert.Equal(t, "first, edited", updated.Body)
+		require.NotNil(t, updated.EditedAt)
+		assert.Equal(t, first.CreatedAt, updated.CreatedAt)
+	})
+
+	t.Run("soft delete hides the comment", func(t *testing.T) {
+		require.NoError(t, repo.DeleteComment(ctx, second.ID))
+		assert.Equal(t, []string{"first, edited"}, bodies())
+
+		_, err := repo.GetCommentByID(ctx, second.ID)
+		assert.ErrorIs(t, err, domain.ErrCommentNotFound)
+		_, err = repo.UpdateComment(ctx, &second)
+		assert.ErrorIs(t, err, domain.ErrCommentNotFound)
+		assert.ErrorIs(t, repo.DeleteComment(ctx, second.ID), domain.ErrCommentNotFound)
+	})
+
+	t.Run("purging the todo removes its comments", func(t *testing.T) {
+		require.NoError(t, repo.DeleteTodo(ctx, owner.ID, todo.ID, todo.Version))
+		_, err := repo.GetCommentByID(ctx, first.ID)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.PurgeTodo(ctx, owner.ID, todo.ID))
+		_, err = repo.GetCommentByID(ctx, first.ID)
+		assert.ErrorIs(t, err, domain.ErrCommentNotFound)
+	})
+}
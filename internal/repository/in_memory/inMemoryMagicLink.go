@@ -0,0 +1,72 @@
+package in_memory
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+// magicLinkRetention — сколько хранить ссылки после истечения, чтобы считать лимит частоты.
+const magicLinkRetention = 24 * time.Hour
+
+func (r *InMemoryRepository) CreateMagicLink(ctx context.Context, jti, email string, expiresAt time.Time) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	now := time.Now()
+	for id, link := range r.magicLinks {
+		if link.ExpiresAt.Add(magicLinkRetention).Before(now) {
+			remember(r, r.magicLinks, id)
+			delete(r.magicLinks, id)
+		}
+	}
+
+	remember(r, r.magicLinks, jti)
+	r.magicLinks[jti] = &entities.MagicLink{
+		JTI:       jti,
+		Email:     email,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+
+	if r.logger != nil {
+		r.logger.Info("memory: magic link created", slog.String("email", email))
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) UseMagicLink(ctx context.Context, jti string) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	link, ok := r.magicLinks[jti]
+	if !ok || link.UsedAt != nil || link.ExpiresAt.Before(time.Now()) {
+		if r.logger != nil {
+			r.logger.Warn("memory: magic link invalid or already used", slog.String("jti", jti))
+		}
+		return domain.ErrMagicLinkInvalid
+	}
+
+	rememberPtr(r, r.magicLinks, jti)
+	now := time.Now()
+	link.UsedAt = &now
+	return nil
+}
+
+// LockMagicLinks ничего не делает: WithinTransaction и так держит блокировку хранилища.
+func (r *InMemoryRepository) LockMagicLinks(ctx context.Context, email string) error {
+	return nil
+}
+
+func (r *InMemoryRepository) CountMagicLinksSince(ctx context.Context, email string, since time.Time) (int, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	count := 0
+	for _, link := range r.magicLinks {
+		if link.Email == email && !link.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,72 @@
+package in_memory
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+func (r *InMemoryRepository) CreatePersonalToken(ctx context.Context, token entities.PersonalToken) (entities.PersonalToken, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now()
+	remember(r, r.patTokens, token.ID)
+	r.patTokens[token.ID] = &token
+
+	if r.logger != nil {
+		r.logger.Info("memory: personal token created", slog.String("token_id", token.ID.String()), slog.String("user_id", token.UserID.String()))
+	}
+	return token, nil
+}
+
+func (r *InMemoryRepository) GetPersonalTokenByHash(ctx context.Context, hash string) (*entities.PersonalToken, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	for _, token := range r.patTokens {
+		if token.TokenHash == hash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, domain.ErrPersonalTokenNotFound
+}
+
+func (r *InMemoryRepository) GetPersonalTokensByUserID(ctx context.Context, userID uuid.UUID) ([]entities.PersonalToken, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	tokens := make([]entities.PersonalToken, 0)
+	for _, token := range r.patTokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			tokens = append(tokens, *token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.After(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+func (r *InMemoryRepository) RevokePersonalToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	token, ok := r.patTokens[tokenID]
+	if !ok || token.UserID != userID || token.RevokedAt != nil {
+		if r.logger != nil {
+			r.logger.Warn("memory: personal token not found for revoke", slog.String("token_id", tokenID.String()))
+		}
+		return domain.ErrPersonalTokenNotFound
+	}
+
+	rememberPtr(r, r.patTokens, tokenID)
+	now := time.Now()
+	token.RevokedAt = &now
+
+	if r.logger != nil {
+		r.logger.Info("memory: personal token revoked", slog.String("token_id", tokenID.String()))
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package in_memory
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+func (r *InMemoryRepository) CreateProject(ctx context.Context, project entities.Project) (entities.Project, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	project.ID = uuid.New()
+	project.CreatedAt = time.Now()
+	remember(r, r.projects, project.ID)
+	r.projects[project.ID] = &project
+
+	if r.logger != nil {
+		r.logger.Info("memory: project created", slog.String("project_id", project.ID.String()), slog.String("user_id", project.UserID.String()))
+	}
+	return project, nil
+}
+
+func (r *InMemoryRepository) GetProjectByID(ctx context.Context, workspaceID, projectID uuid.UUID) (*entities.Project, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	project, ok := r.projects[projectID]
+	if !ok || project.WorkspaceID != workspaceID {
+		if r.logger != nil {
+			r.logger.Warn("memory: project not found", slog.String("project_id", projectID.String()))
+		}
+		return nil, domain.ErrProjectNotFound
+	}
+
+	found := *project
+	return &found, nil
+}
+
+func (r *InMemoryRepository) GetProjectWorkspaceID(ctx context.Context, projectID uuid.UUID) (uuid.UUID, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	project, ok := r.projects[projectID]
+	if !ok {
+		return uuid.Nil, domain.ErrProjectNotFound
+	}
+	return project.WorkspaceID, nil
+}
+
+func (r *InMemoryRepository) GetProjectsByUserID(ctx context.Context, workspaceID, userID uuid.UUID, includeArchived bool) ([]entities.Project, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	projects := make([]entities.Project, 0)
+	for _, project := range r.projects {
+		if project.WorkspaceID == workspaceID && project.UserID == userID && (includeArchived || !project.Archived) {
+			projects = append(projects, *project)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].SortOrder != projects[j].SortOrder {
+			return projects[i].SortOrder < projects[j].SortOrder
+		}
+		return projects[i].Name < projects[j].Name
+	})
+
+	return projects, nil
+}
+
+func (r *InMemoryRepository) UpdateProject(ctx context.Context, project *entities.Project) (*entities.Project, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if existing, ok := r.projects[project.ID]; !ok || existing.WorkspaceID != project.WorkspaceID {
+		return nil, domain.ErrProjectNotFound
+	}
+
+	updated := *project
+	remember(r, r.projects, project.ID)
+	r.projects[project.ID] = &updated
+
+	if r.logger != nil {
+		r.logger.Info("memory: project updated", slog.String("project_id", project.ID.String()))
+	}
+	return project, nil
+}
+
+func (r *InMemoryRepository) DeleteProject(ctx context.Context, workspaceID, projectID uuid.UUID, cascade bool) ([]uuid.UUID, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if project, ok := r.projects[projectID]; !ok || project.WorkspaceID != workspaceID {
+		return nil, domain.ErrProjectNotFound
+	}
+
+	now := time.Now()
+	affected := make([]uuid.UUID, 0)
+	for id, todo := range r.todos {
+		if todo.ProjectID == nil || *todo.ProjectID != projectID {
+			continue
+		}
+		affected = append(affected, id)
+		rememberPtr(r, r.todos, id)
+		todo.ProjectID = nil
+		todo.UpdatedAt = now
+		todo.Version++
+		if cascade && todo.DeletedAt == nil {
+			r.trashTodo(id, now)
+		}
+	}
+	remember(r, r.projects, projectID)
+	delete(r.projects, projectID)
+	r.deleteSharesOf(entities.ShareResourceProject, projectID)
+
+	if r.logger != nil {
+		r.logger.Info("memory: project deleted", slog.String("project_id", projectID.String()), slog.Bool("cascade", cascade), slog.Int("todos", len(affected)))
+	}
+	return affected, nil
+}
@@ -0,0 +1,86 @@
+package in_memory_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepository_Projects(t *testing.T) {
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	ctx := context.Background()
+
+	user, _ := repo.CreateUser(ctx, "projects@example.com", "hash")
+
+	work, err := repo.CreateProject(ctx, entities.Project{WorkspaceID: user.ID, UserID: user.ID, Name: "work", SortOrder: 2})
+	require.NoError(t, err)
+	home, _ := repo.CreateProject(ctx, entities.Project{WorkspaceID: user.ID, UserID: user.ID, Name: "home", SortOrder: 1})
+	old, _ := repo.CreateProject(ctx, entities.Project{WorkspaceID: user.ID, UserID: user.ID, Name: "old", Archived: true})
+
+	t.Run("list by sort order and hide archived", func(t *testing.T) {
+		projects, err := repo.GetProjectsByUserID(ctx, user.ID, user.ID, false)
+		require.NoError(t, err)
+		require.Len(t, projects, 2)
+		assert.Equal(t, home.ID, projects[0].ID)
+		assert.Equal(t, work.ID, projects[1].ID)
+
+		projects, _ = repo.GetProjectsByUserID(ctx, user.ID, user.ID, true)
+		assert.Len(t, projects, 3)
+		assert.Equal(t, old.ID, projects[0].ID)
+	})
+
+	projectTitles := func(project *entities.Project) []string {
+		todos, err := repo.ListTodos(ctx, models.TodoListFilter{
+			WorkspaceID: user.ID, UserID: user.ID, ProjectID: &project.ID, Sort: models.TodoSortTitle, Order: models.SortAsc,
+		})
+		require.NoError(t, err)
+		titles := []string{}
+		for _, todo := range todos {
+			titles = append(titles, todo.Title)
+		}
+		return titles
+	}
+
+	_, _ = repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "report", ProjectID: &work.ID})
+	_, _ = repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "dishes", ProjectID: &home.ID})
+	inbox, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "inbox"})
+
+	t.Run("filter todos by project", func(t *testing.T) {
+		assert.Equal(t, []string{"report"}, projectTitles(&work))
+		assert.Equal(t, []string{"dishes"}, projectTitles(&home))
+	})
+
+	t.Run("delete moves todos to inbox", func(t *testing.T) {
+		affected, err := repo.DeleteProject(ctx, work.WorkspaceID, work.ID, false)
+		require.NoError(t, err)
+		require.Len(t, affected, 1)
+
+		todo, err := repo.GetTodoByID(ctx, user.ID, affected[0])
+		require.NoError(t, err)
+		assert.Nil(t, todo.ProjectID)
+
+		_, err = repo.GetProjectByID(ctx, work.WorkspaceID, work.ID)
+		assert.ErrorIs(t, err, domain.ErrProjectNotFound)
+	})
+
+	t.Run("cascade delete removes todos", func(t *testing.T) {
+		affected, err := repo.DeleteProject(ctx, home.WorkspaceID, home.ID, true)
+		require.NoError(t, err)
+		require.Len(t, affected, 1)
+
+		_, err = repo.GetTodoByID(ctx, user.ID, affected[0])
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+		_, err = repo.GetTodoByID(ctx, inbox.WorkspaceID, inbox.ID)
+		assert.NoError(t, err)
+
+		_, err = repo.DeleteProject(ctx, home.WorkspaceID, home.ID, true)
+		assert.ErrorIs(t, err, domain.ErrProjectNotFound)
+	})
+}
@@ -0,0 +1,54 @@
+package in_memory
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+func (r *InMemoryRepository) CreateRevision(ctx context.Context, revision entities.TodoRevision) (entities.TodoRevision, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if _, ok := r.todos[revision.TodoID]; !ok {
+		return entities.TodoRevision{}, domain.ErrTodoNotFound
+	}
+
+	revision.Number = len(r.revisions[revision.TodoID]) + 1
+	revision.CreatedAt = time.Now()
+	if revision.Changes == nil {
+		revision.Changes = []entities.FieldChange{}
+	}
+	remember(r, r.revisions, revision.TodoID)
+	r.revisions[revision.TodoID] = append(r.revisions[revision.TodoID], revision)
+
+	if r.logger != nil {
+		r.logger.Info("memory: revision created", slog.String("todo_id", revision.TodoID.String()), slog.Int("revision", revision.Number))
+	}
+	return revision, nil
+}
+
+func (r *InMemoryRepository) GetRevisions(ctx context.Context, todoID uuid.UUID) ([]entities.TodoRevision, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	revisions := make([]entities.TodoRevision, 0, len(r.revisions[todoID]))
+	return append(revisions, r.revisions[todoID]...), nil
+}
+
+func (r *InMemoryRepository) GetRevision(ctx context.Context, todoID uuid.UUID, number int) (*entities.TodoRevision, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	revisions := r.revisions[todoID]
+	if number < 1 || number > len(revisions) {
+		if r.logger != nil {
+			r.logger.Warn("memory: revision not found", slog.String("todo_id", todoID.String()), slog.Int("revision", number))
+		}
+		return nil, domain.ErrRevisionNotFound
+	}
+
+	found := revisions[number-1]
+	return &found, nil
+}
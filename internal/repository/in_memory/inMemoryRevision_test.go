@@ -0,0 +1,77 @@
+package in_memory_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepository_Revisions(t *testing.T) {
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	ctx := context.Background()
+
+	owner, _ := repo.CreateUser(ctx, "revisions@example.com", "hash")
+	editor, _ := repo.CreateUser(ctx, "revisions-editor@example.com", "hash")
+	todo, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: owner.ID, UserID: owner.ID, Title: "draft"})
+	other, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: owner.ID, UserID: owner.ID, Title: "other"})
+
+	first, err := repo.CreateRevision(ctx, entities.TodoRevision{TodoID: todo.ID, Action: entities.RevisionCreate, ActorID: owner.ID, State: entities.TodoState{Title: "draft"}})
+	require.NoError(t, err)
+	second, _ := repo.CreateRevision(ctx, entities.TodoRevision{TodoID: todo.ID, Action: entities.RevisionUpdate, ActorID: editor.ID, State: entities.TodoState{Title: "final"}})
+	otherFirst, _ := repo.CreateRevision(ctx, entities.TodoRevision{TodoID: other.ID, Action: entities.RevisionCreate, ActorID: owner.ID})
+
+	t.Run("numbers grow per todo", func(t *testing.T) {
+		assert.Equal(t, 1, first.Number)
+		assert.Equal(t, 2, second.Number)
+		assert.Equal(t, 1, otherFirst.Number)
+		assert.NotZero(t, second.CreatedAt)
+		assert.NotNil(t, first.Changes)
+	})
+
+	t.Run("history is oldest first", func(t *testing.T) {
+		revisions, err := repo.GetRevisions(ctx, todo.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 2)
+		assert.Equal(t, "draft", revisions[0].State.Title)
+		assert.Equal(t, "final", revisions[1].State.Title)
+
+		revision, err := repo.GetRevision(ctx, todo.ID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, editor.ID, revision.ActorID)
+		_, err = repo.GetRevision(ctx, todo.ID, 3)
+		assert.ErrorIs(t, err, domain.ErrRevisionNotFound)
+		_, err = repo.GetRevision(ctx, todo.ID, 0)
+		assert.ErrorIs(t, err, domain.ErrRevisionNotFound)
+	})
+
+	t.Run("revision needs an existing todo", func(t *testing.T) {
+		_, err := repo.CreateRevision(ctx, entities.TodoRevision{TodoID: uuid.New(), Action: entities.RevisionCreate})
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+	})
+
+	t.Run("deleting the actor keeps the revision", func(t *testing.T) {
+		require.NoError(t, repo.DeleteUser(ctx, editor.ID))
+		revision, err := repo.GetRevision(ctx, todo.ID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, uuid.Nil, revision.ActorID)
+	})
+
+	t.Run("purging the todo removes its history", func(t *testing.T) {
+		require.NoError(t, repo.DeleteTodo(ctx, owner.ID, todo.ID, todo.Version))
+		revisions, _ := repo.GetRevisions(ctx, todo.ID)
+		assert.Len(t, revisions, 2)
+
+		require.NoError(t, repo.PurgeTodo(ctx, owner.ID, todo.ID))
+		revisions, _ = repo.GetRevisions(ctx, todo.ID)
+		assert.Empty(t, revisions)
+		revisions, _ = repo.GetRevisions(ctx, other.ID)
+		assert.Len(t, revisions, 1)
+	})
+}
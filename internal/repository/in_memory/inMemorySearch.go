@@ -0,0 +1,127 @@
+package in_memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/search"
+)
+
+// Веса совпадений, как у setweight 'A' и 'B' в ts_rank.
+const (
+	titleWeight       = 1.0
+	descriptionWeight = 0.4
+)
+
+func (r *InMemoryRepository) indexTodo(todo *entities.Todo) {
+	r.unindexTodo(todo.ID)
+
+	words := append(search.Tokenize(todo.Title), search.Tokenize(todo.Description)...)
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		ids, ok := r.searchIndex[word]
+		if _, seen := ids[todo.ID]; seen {
+			continue
+		}
+		rememberNested(r, r.searchIndex, word, todo.ID)
+		if !ok {
+			ids = make(map[uuid.UUID]struct{})
+			r.searchIndex[word] = ids
+		}
+		ids[todo.ID] = struct{}{}
+		terms = append(terms, word)
+	}
+	remember(r, r.searchTerms, todo.ID)
+	r.searchTerms[todo.ID] = terms
+}
+
+func (r *InMemoryRepository) unindexTodo(todoID uuid.UUID) {
+	for _, word := range r.searchTerms[todoID] {
+		rememberNested(r, r.searchIndex, word, todoID)
+		delete(r.searchIndex[word], todoID)
+		if len(r.searchIndex[word]) == 0 {
+			delete(r.searchIndex, word)
+		}
+	}
+	remember(r, r.searchTerms, todoID)
+	delete(r.searchTerms, todoID)
+}
+
+func (r *InMemoryRepository) SearchTodos(ctx context.Context, workspaceID, userID uuid.UUID, query string, limit int) ([]models.TodoSearchResult, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	results := make([]models.TodoSearchResult, 0)
+	terms := search.Terms(query)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	// Пересекаем списки задач по всем словам, начиная с самого короткого.
+	postings := make([]map[uuid.UUID]struct{}, 0, len(terms))
+	for _, term := range terms {
+		ids, ok := r.searchIndex[term]
+		if !ok {
+			return results, nil
+		}
+		postings = append(postings, ids)
+	}
+	sort.Slice(postings, func(i, j int) bool { return len(postings[i]) < len(postings[j]) })
+
+	for id := range postings[0] {
+		matched := true
+		for _, ids := range postings[1:] {
+			if _, ok := ids[id]; !ok {
+				matched = false
+				break
+			}
+		}
+		todo := r.todos[id]
+		if !matched || todo == nil || todo.WorkspaceID != workspaceID || todo.UserID != userID {
+			continue
+		}
+
+		results = append(results, models.TodoSearchResult{
+			Todo: *todo,
+			Rank: rankTodo(todo, terms),
+			Highlights: models.TodoHighlights{
+				Title:       search.Highlight(todo.Title, terms),
+				Description: search.Fragment(todo.Description, terms),
+			},
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.Todo.CreatedAt.Equal(b.Todo.CreatedAt) {
+			return a.Todo.CreatedAt.After(b.Todo.CreatedAt)
+		}
+		return bytes.Compare(a.Todo.ID[:], b.Todo.ID[:]) < 0
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// rankTodo — сумма весов всех вхождений слов запроса: совпадение в заголовке важнее.
+func rankTodo(todo *entities.Todo, terms []string) float64 {
+	count := func(text string) float64 {
+		n := 0
+		for _, word := range search.Tokenize(text) {
+			for _, term := range terms {
+				if word == term {
+					n++
+				}
+			}
+		}
+		return float64(n)
+	}
+	return titleWeight*count(todo.Title) + descriptionWeight*count(todo.Description)
+}
@@ -0,0 +1,104 @@
+package in_memory
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+func (r *InMemoryRepository) SaveShare(ctx context.Context, share entities.Share) (entities.Share, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if existing := r.findShare(share.ResourceType, share.ResourceID, share.UserID); existing != nil {
+		rememberPtr(r, r.shares, existing.ID)
+		existing.Role, existing.InvitedBy = share.Role, share.InvitedBy
+		return *existing, nil
+	}
+
+	share.ID = uuid.New()
+	share.CreatedAt = time.Now()
+	remember(r, r.shares, share.ID)
+	r.shares[share.ID] = &share
+
+	if r.logger != nil {
+		r.logger.Info("memory: share saved", slog.String("share_id", share.ID.String()), slog.String("role", share.Role))
+	}
+	return share, nil
+}
+
+func (r *InMemoryRepository) GetShare(ctx context.Context, resourceType string, resourceID, userID uuid.UUID) (*entities.Share, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	share := r.findShare(resourceType, resourceID, userID)
+	if share == nil {
+		return nil, domain.ErrShareNotFound
+	}
+	found := *share
+	return &found, nil
+}
+
+func (r *InMemoryRepository) GetSharesByResource(ctx context.Context, resourceType string, resourceID uuid.UUID) ([]entities.Share, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	shares := make([]entities.Share, 0)
+	for _, share := range r.shares {
+		if share.ResourceType == resourceType && share.ResourceID == resourceID {
+			shares = append(shares, *share)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].CreatedAt.Before(shares[j].CreatedAt) })
+	return shares, nil
+}
+
+func (r *InMemoryRepository) GetSharesByUserID(ctx context.Context, userID uuid.UUID) ([]entities.Share, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	shares := make([]entities.Share, 0)
+	for _, share := range r.shares {
+		if share.UserID == userID {
+			shares = append(shares, *share)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].CreatedAt.After(shares[j].CreatedAt) })
+	return shares, nil
+}
+
+func (r *InMemoryRepository) DeleteShare(ctx context.Context, resourceType string, resourceID, userID uuid.UUID) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	share := r.findShare(resourceType, resourceID, userID)
+	if share == nil {
+		return domain.ErrShareNotFound
+	}
+	remember(r, r.shares, share.ID)
+	delete(r.shares, share.ID)
+
+	if r.logger != nil {
+		r.logger.Info("memory: share deleted", slog.String("resource_id", resourceID.String()), slog.String("user_id", userID.String()))
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) findShare(resourceType string, resourceID, userID uuid.UUID) *entities.Share {
+	for _, share := range r.shares {
+		if share.ResourceType == resourceType && share.ResourceID == resourceID && share.UserID == userID {
+			return share
+		}
+	}
+	return nil
+}
+
+// deleteSharesOf снимает доступы к удалённому ресурсу, как ON DELETE CASCADE в Postgres.
+func (r *InMemoryRepository) deleteSharesOf(resourceType string, resourceID uuid.UUID) {
+	for id, share := range r.shares {
+		if share.ResourceType == resourceType && share.ResourceID == resourceID {
+			remember(r, r.shares, id)
+			delete(r.shares, id)
+		}
+	}
+}
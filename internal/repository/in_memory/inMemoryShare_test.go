@@ -0,0 +1,55 @@
+package in_memory_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepository_Shares(t *testing.T) {
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	ctx := context.Background()
+
+	owner, _ := repo.CreateUser(ctx, "shares@example.com", "hash")
+	friend, _ := repo.CreateUser(ctx, "shares-friend@example.com", "hash")
+	todo, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: owner.ID, UserID: owner.ID, Title: "shared"})
+	project, _ := repo.CreateProject(ctx, entities.Project{WorkspaceID: owner.ID, UserID: owner.ID, Name: "shared"})
+
+	created, err := repo.SaveShare(ctx, entities.Share{ResourceType: entities.ShareResourceTodo, ResourceID: todo.ID, UserID: friend.ID, Role: entities.RoleViewer, InvitedBy: owner.ID})
+	require.NoError(t, err)
+	_, err = repo.SaveShare(ctx, entities.Share{ResourceType: entities.ShareResourceProject, ResourceID: project.ID, UserID: friend.ID, Role: entities.RoleEditor, InvitedBy: owner.ID})
+	require.NoError(t, err)
+
+	t.Run("save again changes the role", func(t *testing.T) {
+		updated, err := repo.SaveShare(ctx, entities.Share{ResourceType: entities.ShareResourceTodo, ResourceID: todo.ID, UserID: friend.ID, Role: entities.RoleEditor, InvitedBy: owner.ID})
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, updated.ID)
+
+		share, err := repo.GetShare(ctx, entities.ShareResourceTodo, todo.ID, friend.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entities.RoleEditor, share.Role)
+
+		shares, _ := repo.GetSharesByResource(ctx, entities.ShareResourceTodo, todo.ID)
+		assert.Len(t, shares, 1)
+	})
+
+	t.Run("shares go away with the resource", func(t *testing.T) {
+		require.NoError(t, repo.DeleteTodo(ctx, todo.WorkspaceID, todo.ID, todo.Version))
+		require.NoError(t, repo.PurgeTodo(ctx, todo.WorkspaceID, todo.ID))
+		_, err := repo.GetShare(ctx, entities.ShareResourceTodo, todo.ID, friend.ID)
+		assert.ErrorIs(t, err, domain.ErrShareNotFound)
+
+		_, err = repo.DeleteProject(ctx, project.WorkspaceID, project.ID, false)
+		require.NoError(t, err)
+		shares, _ := repo.GetSharesByUserID(ctx, friend.ID)
+		assert.Empty(t, shares)
+	})
+
+	assert.ErrorIs(t, repo.DeleteShare(ctx, entities.ShareResourceTodo, todo.ID, friend.ID), domain.ErrShareNotFound)
+}
@@ -0,0 +1,181 @@
+package in_memory
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+)
+
+func (r *InMemoryRepository) CreateTag(ctx context.Context, tag entities.Tag) (entities.Tag, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if r.tagNameTaken(tag.WorkspaceID, tag.UserID, tag.Name, uuid.Nil) {
+		return entities.Tag{}, domain.ErrTagExists
+	}
+
+	tag.ID = uuid.New()
+	tag.CreatedAt = time.Now()
+	remember(r, r.tags, tag.ID)
+	r.tags[tag.ID] = &tag
+
+	if r.logger != nil {
+		r.logger.Info("memory: tag created", slog.String("tag_id", tag.ID.String()), slog.String("user_id", tag.UserID.String()))
+	}
+	return tag, nil
+}
+
+func (r *InMemoryRepository) GetTagByID(ctx context.Context, workspaceID, tagID uuid.UUID) (*entities.Tag, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	tag, ok := r.tags[tagID]
+	if !ok || tag.WorkspaceID != workspaceID {
+		if r.logger != nil {
+			r.logger.Warn("memory: tag not found", slog.String("tag_id", tagID.String()))
+		}
+		return nil, domain.ErrTagNotFound
+	}
+
+	found := *tag
+	return &found, nil
+}
+
+func (r *InMemoryRepository) GetTagsByUserID(ctx context.Context, workspaceID, userID uuid.UUID) ([]entities.Tag, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	tags := make([]entities.Tag, 0)
+	for _, tag := range r.tags {
+		if tag.WorkspaceID == workspaceID && tag.UserID == userID {
+			tags = append(tags, *tag)
+		}
+	}
+	sortTags(tags)
+
+	return tags, nil
+}
+
+func (r *InMemoryRepository) UpdateTag(ctx context.Context, tag *entities.Tag) (*entities.Tag, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if existing, ok := r.tags[tag.ID]; !ok || existing.WorkspaceID != tag.WorkspaceID {
+		return nil, domain.ErrTagNotFound
+	}
+	if r.tagNameTaken(tag.WorkspaceID, tag.UserID, tag.Name, tag.ID) {
+		return nil, domain.ErrTagExists
+	}
+
+	updated := *tag
+	remember(r, r.tags, tag.ID)
+	r.tags[tag.ID] = &updated
+
+	if r.logger != nil {
+		r.logger.Info("memory: tag updated", slog.String("tag_id", tag.ID.String()))
+	}
+	return tag, nil
+}
+
+func (r *InMemoryRepository) DeleteTag(ctx context.Context, workspaceID, tagID uuid.UUID) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if tag, ok := r.tags[tagID]; !ok || tag.WorkspaceID != workspaceID {
+		return domain.ErrTagNotFound
+	}
+
+	r.removeTag(tagID)
+
+	if r.logger != nil {
+		r.logger.Info("memory: tag deleted", slog.String("tag_id", tagID.String()))
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) AttachTag(ctx context.Context, todoID, tagID uuid.UUID) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if _, ok := r.todos[todoID]; !ok {
+		return domain.ErrTodoNotFound
+	}
+	if _, ok := r.tags[tagID]; !ok {
+		return domain.ErrTagNotFound
+	}
+
+	rememberNested(r, r.todoTags, todoID, tagID)
+	if r.todoTags[todoID] == nil {
+		r.todoTags[todoID] = make(map[uuid.UUID]struct{})
+	}
+	r.todoTags[todoID][tagID] = struct{}{}
+	return nil
+}
+
+func (r *InMemoryRepository) DetachTag(ctx context.Context, todoID, tagID uuid.UUID) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	rememberNested(r, r.todoTags, todoID, tagID)
+	delete(r.todoTags[todoID], tagID)
+	return nil
+}
+
+func (r *InMemoryRepository) GetTagsByTodoID(ctx context.Context, todoID uuid.UUID) ([]entities.Tag, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	tags := make([]entities.Tag, 0, len(r.todoTags[todoID]))
+	for tagID := range r.todoTags[todoID] {
+		tags = append(tags, *r.tags[tagID])
+	}
+	sortTags(tags)
+
+	return tags, nil
+}
+
+// removeTag удаляет метку и снимает её со всех задач.
+func (r *InMemoryRepository) removeTag(tagID uuid.UUID) {
+	remember(r, r.tags, tagID)
+	delete(r.tags, tagID)
+	for todoID, tagIDs := range r.todoTags {
+		if _, ok := tagIDs[tagID]; ok {
+			rememberNested(r, r.todoTags, todoID, tagID)
+			delete(tagIDs, tagID)
+		}
+	}
+}
+
+func (r *InMemoryRepository) tagNameTaken(workspaceID, userID uuid.UUID, name string, except uuid.UUID) bool {
+	for _, tag := range r.tags {
+		if tag.WorkspaceID == workspaceID && tag.UserID == userID && tag.Name == name && tag.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTags проверяет фильтр по меткам так же, как Postgres: считаются метки, висящие
+// на задаче, кто бы их ни повесил, а для all нужна каждая из запрошенных, поэтому
+// неизвестное имя ничего не пропускает.
+func (r *InMemoryRepository) matchesTags(todo *entities.Todo, filter models.TodoListFilter) bool {
+	if len(filter.Tags) == 0 {
+		return true
+	}
+	// Совпавшие имена собираются в множество: одноимённые метки разных пользователей
+	// на общей задаче считаются один раз.
+	matched := make(map[string]struct{})
+	for tagID := range r.todoTags[todo.ID] {
+		tag := r.tags[tagID]
+		if tag != nil && tag.WorkspaceID == todo.WorkspaceID && slices.Contains(filter.Tags, tag.Name) {
+			matched[tag.Name] = struct{}{}
+		}
+	}
+	if filter.TagsMatchAll {
+		return len(matched) == len(filter.Tags)
+	}
+	return len(matched) > 0
+}
+
+func sortTags(tags []entities.Tag) {
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+}
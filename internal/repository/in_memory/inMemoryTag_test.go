@@ -0,0 +1,108 @@
+package in_memory_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepository_Tags(t *testing.T) {
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	ctx := context.Background()
+
+	user, _ := repo.CreateUser(ctx, "tags@example.com", "hash")
+	other, _ := repo.CreateUser(ctx, "other@example.com", "hash")
+
+	work, err := repo.CreateTag(ctx, entities.Tag{WorkspaceID: user.ID, UserID: user.ID, Name: "work", Color: "#ff0000"})
+	require.NoError(t, err)
+	home, _ := repo.CreateTag(ctx, entities.Tag{WorkspaceID: user.ID, UserID: user.ID, Name: "home", Color: "#00ff00"})
+
+	t.Run("names are unique per user", func(t *testing.T) {
+		_, err := repo.CreateTag(ctx, entities.Tag{WorkspaceID: user.ID, UserID: user.ID, Name: "work"})
+		assert.ErrorIs(t, err, domain.ErrTagExists)
+
+		_, err = repo.CreateTag(ctx, entities.Tag{WorkspaceID: other.ID, UserID: other.ID, Name: "work"})
+		assert.NoError(t, err)
+
+		renamed := home
+		renamed.Name = "work"
+		_, err = repo.UpdateTag(ctx, &renamed)
+		assert.ErrorIs(t, err, domain.ErrTagExists)
+	})
+
+	both, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "both"})
+	onlyWork, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "only work"})
+	_, _ = repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "untagged"})
+	require.NoError(t, repo.AttachTag(ctx, both.ID, work.ID))
+	require.NoError(t, repo.AttachTag(ctx, both.ID, home.ID))
+	require.NoError(t, repo.AttachTag(ctx, onlyWork.ID, work.ID))
+	require.NoError(t, repo.AttachTag(ctx, onlyWork.ID, work.ID))
+
+	list := func(tags []string, all bool) []string {
+		todos, err := repo.ListTodos(ctx, models.TodoListFilter{
+			WorkspaceID: user.ID, UserID: user.ID, Sort: models.TodoSortTitle, Order: models.SortAsc, Tags: tags, TagsMatchAll: all,
+		})
+		require.NoError(t, err)
+		titles := []string{}
+		for _, todo := range todos {
+			titles = append(titles, todo.Title)
+		}
+		return titles
+	}
+
+	t.Run("filter any and all", func(t *testing.T) {
+		assert.Equal(t, []string{"both", "only work"}, list([]string{"work", "home"}, false))
+		assert.Equal(t, []string{"both"}, list([]string{"work", "home"}, true))
+		assert.Equal(t, []string{"both", "only work"}, list([]string{"work", "missing"}, false))
+		assert.Empty(t, list([]string{"work", "missing"}, true))
+	})
+
+	t.Run("all counts distinct names", func(t *testing.T) {
+		// Соавтор повесил на задачу свою метку с тем же именем.
+		theirs, err := repo.CreateTag(ctx, entities.Tag{WorkspaceID: user.ID, UserID: other.ID, Name: "work", Color: "#0000ff"})
+		require.NoError(t, err)
+		require.NoError(t, repo.AttachTag(ctx, onlyWork.ID, theirs.ID))
+		defer repo.DetachTag(ctx, onlyWork.ID, theirs.ID)
+
+		assert.Equal(t, []string{"both"}, list([]string{"work", "home"}, true))
+		assert.Equal(t, []string{"both", "only work"}, list([]string{"work"}, true))
+	})
+
+	t.Run("tags of todo", func(t *testing.T) {
+		tags, err := repo.GetTagsByTodoID(ctx, both.ID)
+		require.NoError(t, err)
+		require.Len(t, tags, 2)
+		assert.Equal(t, "home", tags[0].Name)
+		assert.Equal(t, "work", tags[1].Name)
+	})
+
+	t.Run("detach", func(t *testing.T) {
+		require.NoError(t, repo.DetachTag(ctx, both.ID, home.ID))
+		assert.Empty(t, list([]string{"home"}, false))
+	})
+
+	t.Run("deleting todo removes its links", func(t *testing.T) {
+		require.NoError(t, repo.DeleteTodo(ctx, onlyWork.WorkspaceID, onlyWork.ID, onlyWork.Version))
+		assert.Equal(t, []string{"both"}, list([]string{"work"}, false))
+		require.NoError(t, repo.PurgeTodo(ctx, onlyWork.WorkspaceID, onlyWork.ID))
+		tags, err := repo.GetTagsByTodoID(ctx, onlyWork.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+		assert.Equal(t, []string{"both"}, list([]string{"work"}, false))
+	})
+
+	t.Run("deleting tag detaches it everywhere", func(t *testing.T) {
+		require.NoError(t, repo.DeleteTag(ctx, user.ID, work.ID))
+		tags, err := repo.GetTagsByTodoID(ctx, both.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+		assert.ErrorIs(t, repo.AttachTag(ctx, both.ID, work.ID), domain.ErrTagNotFound)
+	})
+}
@@ -1,84 +1,323 @@
 package in_memory
 
 import (
+	"bytes"
 	"context"
 	"log/slog"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/polzovatel/todo-learning/internal/domain"
 	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/ranking"
 )
 
-func (r *InMemoryRepository) CreateTodo(ctx context.Context, userID uuid.UUID, title, description string) (entities.Todo, error) {
-	todo := &entities.Todo{
-		ID:          uuid.New(),
-		UserID:      userID,
-		Title:       title,
-		Description: description,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
+func (r *InMemoryRepository) CreateTodo(ctx context.Context, todo entities.Todo) (entities.Todo, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	todo.ID = uuid.New()
+	todo.CreatedAt = time.Now()
+	todo.UpdatedAt = todo.CreatedAt
+	todo.Version = 1
 
-	r.todos[todo.ID] = todo
+	remember(r, r.todos, todo.ID)
+	r.todos[todo.ID] = &todo
+	r.indexTodo(&todo)
 
 	if r.logger != nil {
-		r.logger.Info("memory: todo created", slog.String("todo_id", todo.ID.String()), slog.String("user_id", userID.String()))
+		r.logger.Info("memory: todo created", slog.String("todo_id", todo.ID.String()), slog.String("user_id", todo.UserID.String()))
 	}
-	return *todo, nil
+	return todo, nil
 }
 
-func (r *InMemoryRepository) GetTodoByID(ctx context.Context, todoID uuid.UUID) (*entities.Todo, error) {
+func (r *InMemoryRepository) GetTodoByID(ctx context.Context, workspaceID, todoID uuid.UUID) (*entities.Todo, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
 	todo, ok := r.todos[todoID]
-	if !ok {
+	if !ok || todo.WorkspaceID != workspaceID || todo.DeletedAt != nil {
 		if r.logger != nil {
 			r.logger.Warn("memory: todo not found", slog.String("todo_id", todoID.String()))
 		}
 		return nil, domain.ErrTodoNotFound
 	}
 
-	return todo, nil
+	// Копия: вызывающий меняет задачу до UpdateTodo, и проверка версии должна видеть
+	// сохранённую версию, а не его правку.
+	found := *todo
+	return &found, nil
+}
+
+func (r *InMemoryRepository) GetTodoWorkspaceID(ctx context.Context, todoID uuid.UUID) (uuid.UUID, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	todo, ok := r.todos[todoID]
+	if !ok || todo.DeletedAt != nil {
+		return uuid.Nil, domain.ErrTodoNotFound
+	}
+	return todo.WorkspaceID, nil
 }
 
-func (r *InMemoryRepository) GetTodoByUserID(ctx context.Context, userID uuid.UUID) ([]entities.Todo, error) {
+func (r *InMemoryRepository) GetTodoByUserID(ctx context.Context, workspaceID, userID uuid.UUID) ([]entities.Todo, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
 	todos := make([]entities.Todo, 0)
 	for _, todo := range r.todos {
-		if todo.UserID == userID {
+		if todo.WorkspaceID == workspaceID && todo.UserID == userID && todo.DeletedAt == nil {
 			todos = append(todos, *todo)
 		}
 	}
+	sort.Slice(todos, func(i, j int) bool {
+		return compareTodo(&todos[i], todoSortKey(&todos[j], models.TodoSortPosition), models.TodoSortPosition) < 0
+	})
 
 	return todos, nil
 }
 
 func (r *InMemoryRepository) UpdateTodo(ctx context.Context, todo *entities.Todo) (*entities.Todo, error) {
-	if _, ok := r.todos[todo.ID]; !ok {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	existing, ok := r.todos[todo.ID]
+	if !ok || existing.WorkspaceID != todo.WorkspaceID || existing.DeletedAt != nil {
 		if r.logger != nil {
 			r.logger.Warn("memory: todo not found for update", slog.String("todo_id", todo.ID.String()))
 		}
 		return nil, domain.ErrTodoNotFound
 	}
+	if existing.Version != todo.Version {
+		if r.logger != nil {
+			r.logger.Warn("memory: todo version changed before update", slog.String("todo_id", todo.ID.String()), slog.Int("version", todo.Version))
+		}
+		return nil, domain.ErrVersionMismatch
+	}
 
-	r.todos[todo.ID] = todo
+	updated := *todo
+	updated.DeletedAt = nil
+	updated.Version++
+	remember(r, r.todos, todo.ID)
+	r.todos[todo.ID] = &updated
+	r.indexTodo(&updated)
 
 	if r.logger != nil {
 		r.logger.Info("memory: todo updated", slog.String("todo_id", todo.ID.String()))
 	}
+	*todo = updated
 	return todo, nil
 }
 
-func (r *InMemoryRepository) DeleteTodo(ctx context.Context, todoID uuid.UUID) error {
-	if _, ok := r.todos[todoID]; !ok {
+func (r *InMemoryRepository) DeleteTodo(ctx context.Context, workspaceID, todoID uuid.UUID, version int) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	todo, ok := r.todos[todoID]
+	if !ok || todo.WorkspaceID != workspaceID || todo.DeletedAt != nil {
 		if r.logger != nil {
 			r.logger.Warn("memory: todo not found for delete", slog.String("todo_id", todoID.String()))
 		}
 		return domain.ErrTodoNotFound
 	}
+	if todo.Version != version {
+		if r.logger != nil {
+			r.logger.Warn("memory: todo version changed before delete", slog.String("todo_id", todoID.String()), slog.Int("version", version))
+		}
+		return domain.ErrVersionMismatch
+	}
 
-	delete(r.todos, todoID)
+	r.trashTodo(todoID, time.Now())
 
 	if r.logger != nil {
-		r.logger.Info("memory: todo deleted", slog.String("todo_id", todoID.String()))
+		r.logger.Info("memory: todo moved to trash", slog.String("todo_id", todoID.String()))
 	}
 	return nil
 }
+
+func (r *InMemoryRepository) GetTodoChildren(ctx context.Context, workspaceID, parentID uuid.UUID) ([]entities.Todo, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	children := make([]entities.Todo, 0)
+	for _, todo := range r.todos {
+		if todo.WorkspaceID == workspaceID && todo.DeletedAt == nil && todo.ParentID != nil && *todo.ParentID == parentID {
+			children = append(children, *todo)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return compareTodo(&children[i], todoSortKey(&children[j], models.TodoSortPosition), models.TodoSortPosition) < 0
+	})
+
+	return children, nil
+}
+
+func (r *InMemoryRepository) LastTodoPosition(ctx context.Context, workspaceID, userID uuid.UUID) (string, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	last := ""
+	for _, todo := range r.todos {
+		if todo.WorkspaceID == workspaceID && todo.UserID == userID && todo.DeletedAt == nil && todo.Position > last {
+			last = todo.Position
+		}
+	}
+	return last, nil
+}
+
+func (r *InMemoryRepository) AdjacentTodoPosition(ctx context.Context, workspaceID uuid.UUID, anchor *entities.Todo, after bool, exclude uuid.UUID) (string, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	found := ""
+	for _, todo := range r.todos {
+		if todo.WorkspaceID != workspaceID || todo.UserID != anchor.UserID || todo.ID == anchor.ID || todo.ID == exclude || todo.DeletedAt != nil {
+			continue
+		}
+		if after && todo.Position >= anchor.Position && (found == "" || todo.Position < found) {
+			found = todo.Position
+		}
+		if !after && todo.Position <= anchor.Position && todo.Position > found {
+			found = todo.Position
+		}
+	}
+	return found, nil
+}
+
+func (r *InMemoryRepository) RebalanceTodoPositions(ctx context.Context, workspaceID, userID uuid.UUID) ([]uuid.UUID, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	todos := make([]*entities.Todo, 0)
+	for _, todo := range r.todos {
+		if todo.WorkspaceID == workspaceID && todo.UserID == userID {
+			todos = append(todos, todo)
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		if todos[i].Position != todos[j].Position {
+			return todos[i].Position < todos[j].Position
+		}
+		if !todos[i].CreatedAt.Equal(todos[j].CreatedAt) {
+			return todos[i].CreatedAt.Before(todos[j].CreatedAt)
+		}
+		return todos[i].ID.String() < todos[j].ID.String()
+	})
+
+	ids := make([]uuid.UUID, 0, len(todos))
+	for i, todo := range todos {
+		rememberPtr(r, r.todos, todo.ID)
+		todo.Position = ranking.Nth(i+1, len(todos))
+		todo.Version++
+		ids = append(ids, todo.ID)
+	}
+
+	if r.logger != nil {
+		r.logger.Info("memory: todo positions rebalanced", slog.String("user_id", userID.String()), slog.Int("count", len(ids)))
+	}
+	return ids, nil
+}
+
+func (r *InMemoryRepository) ListTodos(ctx context.Context, filter models.TodoListFilter) ([]entities.Todo, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	todos := make([]entities.Todo, 0)
+	for _, todo := range r.todos {
+		if todo.WorkspaceID == filter.WorkspaceID && todo.DeletedAt == nil && (filter.UserID == uuid.Nil || todo.UserID == filter.UserID) && matchesTodoFilter(todo, filter) && r.matchesTags(todo, filter) {
+			todos = append(todos, *todo)
+		}
+	}
+
+	desc := filter.Order == models.SortDesc
+	sort.Slice(todos, func(i, j int) bool {
+		c := compareTodo(&todos[i], todoSortKey(&todos[j], filter.Sort), filter.Sort)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	if filter.Limit > 0 && len(todos) > filter.Limit {
+		todos = todos[:filter.Limit]
+	}
+	return todos, nil
+}
+
+// removeTodo удаляет задачу со всеми связями, как каскадные ссылки в Postgres.
+func (r *InMemoryRepository) removeTodo(todoID uuid.UUID) {
+	remember(r, r.todos, todoID)
+	remember(r, r.todoTags, todoID)
+	delete(r.todos, todoID)
+	delete(r.todoTags, todoID)
+	r.unindexTodo(todoID)
+	r.deleteSharesOf(entities.ShareResourceTodo, todoID)
+	r.deleteCommentsWhere(func(comment *entities.Comment) bool { return comment.TodoID == todoID })
+	remember(r, r.revisions, todoID)
+	delete(r.revisions, todoID)
+	for id, attachment := range r.attachments {
+		if attachment.TodoID == todoID {
+			rememberPtr(r, r.attachments, id)
+			attachment.TodoID = uuid.Nil
+		}
+	}
+}
+
+func matchesTodoFilter(todo *entities.Todo, filter models.TodoListFilter) bool {
+	switch {
+	case filter.Completed != nil && todo.Completed != *filter.Completed:
+		return false
+	case filter.Priority != "" && todo.Priority != filter.Priority:
+		return false
+	case filter.ProjectID != nil && (todo.ProjectID == nil || *todo.ProjectID != *filter.ProjectID):
+		return false
+	case filter.CreatedAfter != nil && !todo.CreatedAt.After(*filter.CreatedAfter):
+		return false
+	case filter.CreatedBefore != nil && !todo.CreatedAt.Before(*filter.CreatedBefore):
+		return false
+	case filter.UpdatedAfter != nil && !todo.UpdatedAt.After(*filter.UpdatedAfter):
+		return false
+	case filter.UpdatedBefore != nil && !todo.UpdatedAt.Before(*filter.UpdatedBefore):
+		return false
+	case (filter.DueFrom != nil || filter.DueBefore != nil) && todo.DueAt == nil:
+		return false
+	case filter.DueFrom != nil && todo.DueAt.Before(*filter.DueFrom):
+		return false
+	case filter.DueBefore != nil && !todo.DueAt.Before(*filter.DueBefore):
+		return false
+	}
+	if filter.After == nil {
+		return true
+	}
+	c := compareTodo(todo, *filter.After, filter.Sort)
+	if filter.Order == models.SortDesc {
+		return c < 0
+	}
+	return c > 0
+}
+
+func todoSortKey(todo *entities.Todo, sortBy string) models.TodoCursor {
+	key := models.TodoCursor{ID: todo.ID}
+	switch sortBy {
+	case models.TodoSortUpdatedAt:
+		key.Time = todo.UpdatedAt
+	case models.TodoSortTitle:
+		key.Title = todo.Title
+	case models.TodoSortPosition:
+		key.Position = todo.Position
+	default:
+		key.Time = todo.CreatedAt
+	}
+	return key
+}
+
+// compareTodo сравнивает задачу с позицией (ключ сортировки, id) так же, как Postgres
+// сравнивает кортежи: строки побайтно (COLLATE "C"), uuid — как 16 байт.
+func compareTodo(todo *entities.Todo, key models.TodoCursor, sortBy string) int {
+	own := todoSortKey(todo, sortBy)
+	var c int
+	switch sortBy {
+	case models.TodoSortTitle:
+		c = strings.Compare(own.Title, key.Title)
+	case models.TodoSortPosition:
+		c = strings.Compare(own.Position, key.Position)
+	default:
+		c = own.Time.Compare(key.Time)
+	}
+	if c != 0 {
+		return c
+	}
+	return bytes.Compare(own.ID[:], key.ID[:])
+}
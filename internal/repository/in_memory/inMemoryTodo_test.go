@@ -4,10 +4,12 @@ import (
 	"context"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/polzovatel/todo-learning/internal/domain"
 	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
 	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -22,7 +24,7 @@ func TestInMemoryTodo_CreateTodo(t *testing.T) {
 	require.NoError(t, err)
 
 	t.Run("create todo successfully", func(t *testing.T) {
-		todo, err := repo.CreateTodo(ctx, user.ID, "Test Todo", "Description")
+		todo, err := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "Test Todo", Description: "Description"})
 
 		require.NoError(t, err)
 		require.NotEmpty(t, todo)
@@ -42,14 +44,14 @@ func TestInMemoryRepository_GetTodoByUserID(t *testing.T) {
 	user2, _ := repo.CreateUser(ctx, "user2@example.com", "hash")
 
 	// Создаем todos для user1
-	todo1, _ := repo.CreateTodo(ctx, user1.ID, "Todo 1", "")
-	todo2, _ := repo.CreateTodo(ctx, user1.ID, "Todo 2", "")
+	todo1, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user1.ID, UserID: user1.ID, Title: "Todo 1"})
+	todo2, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user1.ID, UserID: user1.ID, Title: "Todo 2"})
 
 	// Создаем todo для user2
-	_, _ = repo.CreateTodo(ctx, user2.ID, "Todo 3", "")
+	_, _ = repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user2.ID, UserID: user2.ID, Title: "Todo 3"})
 
 	t.Run("get todos for user1", func(t *testing.T) {
-		todos, err := repo.GetTodoByUserID(ctx, user1.ID)
+		todos, err := repo.GetTodoByUserID(ctx, user1.ID, user1.ID)
 
 		require.NoError(t, err)
 		assert.Len(t, todos, 2)
@@ -65,7 +67,7 @@ func TestInMemoryRepository_GetTodoByUserID(t *testing.T) {
 
 	t.Run("get todos for user with no todos", func(t *testing.T) {
 		newUser, _ := repo.CreateUser(ctx, "new@example.com", "hash")
-		todos, err := repo.GetTodoByUserID(ctx, newUser.ID)
+		todos, err := repo.GetTodoByUserID(ctx, newUser.ID, newUser.ID)
 
 		require.NoError(t, err)
 		assert.Empty(t, todos)
@@ -77,10 +79,10 @@ func TestInMemoryRepository_GetTodoByID(t *testing.T) {
 	ctx := context.Background()
 
 	user, _ := repo.CreateUser(ctx, "getbyid@example.com", "hash")
-	created, _ := repo.CreateTodo(ctx, user.ID, "Test Todo", "Description")
+	created, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "Test Todo", Description: "Description"})
 
 	t.Run("get todo by id successfully", func(t *testing.T) {
-		todo, err := repo.GetTodoByID(ctx, created.ID)
+		todo, err := repo.GetTodoByID(ctx, created.WorkspaceID, created.ID)
 
 		require.NoError(t, err)
 		assert.Equal(t, created.ID, todo.ID)
@@ -89,7 +91,7 @@ func TestInMemoryRepository_GetTodoByID(t *testing.T) {
 	})
 
 	t.Run("get non-existing todo", func(t *testing.T) {
-		_, err := repo.GetTodoByID(ctx, uuid.New())
+		_, err := repo.GetTodoByID(ctx, user.ID, uuid.New())
 
 		assert.Error(t, err)
 		assert.Equal(t, domain.ErrTodoNotFound, err)
@@ -101,7 +103,7 @@ func TestInMemoryRepository_UpdateTodo(t *testing.T) {
 	ctx := context.Background()
 
 	user, _ := repo.CreateUser(ctx, "update@example.com", "hash")
-	created, _ := repo.CreateTodo(ctx, user.ID, "Original Title", "Original Description")
+	created, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "Original Title", Description: "Original Description"})
 
 	t.Run("update todo successfully", func(t *testing.T) {
 		created.Title = "Updated Title"
@@ -115,6 +117,36 @@ func TestInMemoryRepository_UpdateTodo(t *testing.T) {
 		assert.Equal(t, "Updated Title", updated.Title)
 		assert.Equal(t, "Updated Description", updated.Description)
 		assert.True(t, updated.Completed)
+		assert.Equal(t, 2, updated.Version)
+	})
+
+	t.Run("update with a stale version", func(t *testing.T) {
+		stale := created
+		stale.Version = 1
+		stale.Title = "Lost Update"
+
+		_, err := repo.UpdateTodo(ctx, &stale)
+
+		assert.Equal(t, domain.ErrVersionMismatch, err)
+		found, _ := repo.GetTodoByID(ctx, user.ID, created.ID)
+		assert.Equal(t, "Updated Title", found.Title)
+	})
+
+	t.Run("concurrent readers cannot both update", func(t *testing.T) {
+		first, err := repo.GetTodoByID(ctx, user.ID, created.ID)
+		require.NoError(t, err)
+		second, err := repo.GetTodoByID(ctx, user.ID, created.ID)
+		require.NoError(t, err)
+
+		first.Title = "First Writer"
+		_, err = repo.UpdateTodo(ctx, first)
+		require.NoError(t, err)
+		second.Title = "Second Writer"
+		_, err = repo.UpdateTodo(ctx, second)
+
+		assert.Equal(t, domain.ErrVersionMismatch, err)
+		found, _ := repo.GetTodoByID(ctx, user.ID, created.ID)
+		assert.Equal(t, "First Writer", found.Title)
 	})
 
 	t.Run("update non-existing todo", func(t *testing.T) {
@@ -137,21 +169,275 @@ func TestInMemoryRepository_DeleteTodo(t *testing.T) {
 
 	t.Run("delete todo successfully", func(t *testing.T) {
 		user, _ := repo.CreateUser(ctx, "delete@example.com", "hash")
-		created, _ := repo.CreateTodo(ctx, user.ID, "To Delete", "")
+		created, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "To Delete"})
 
-		err := repo.DeleteTodo(ctx, created.ID)
+		err := repo.DeleteTodo(ctx, created.WorkspaceID, created.ID, created.Version)
 		require.NoError(t, err)
 
 		// Проверяем что todo удален
-		_, err = repo.GetTodoByID(ctx, created.ID)
+		_, err = repo.GetTodoByID(ctx, created.WorkspaceID, created.ID)
 		assert.Error(t, err)
 		assert.Equal(t, domain.ErrTodoNotFound, err)
 	})
 
+	t.Run("delete with a stale version", func(t *testing.T) {
+		user, _ := repo.CreateUser(ctx, "delete-stale@example.com", "hash")
+		created, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "Edited Meanwhile"})
+		edited := created
+		edited.Title = "Edited"
+		_, err := repo.UpdateTodo(ctx, &edited)
+		require.NoError(t, err)
+
+		err = repo.DeleteTodo(ctx, created.WorkspaceID, created.ID, created.Version)
+
+		assert.Equal(t, domain.ErrVersionMismatch, err)
+		_, err = repo.GetTodoByID(ctx, created.WorkspaceID, created.ID)
+		assert.NoError(t, err)
+	})
+
 	t.Run("delete non-existing todo", func(t *testing.T) {
-		err := repo.DeleteTodo(ctx, uuid.New())
+		err := repo.DeleteTodo(ctx, uuid.New(), uuid.New(), 1)
 
 		assert.Error(t, err)
 		assert.Equal(t, domain.ErrTodoNotFound, err)
 	})
 }
+
+func TestInMemoryRepository_ListTodos(t *testing.T) {
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	ctx := context.Background()
+
+	user, _ := repo.CreateUser(ctx, "list@example.com", "hash")
+	other, _ := repo.CreateUser(ctx, "other@example.com", "hash")
+	_, _ = repo.CreateTodo(ctx, entities.Todo{WorkspaceID: other.ID, UserID: other.ID, Title: "Foreign"})
+
+	// Одинаковое время создания у всех задач: порядок должен определяться id.
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	titles := []string{"b", "B", "a", "c"}
+	for i, title := range titles {
+		todo, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: title})
+		stored, _ := repo.GetTodoByID(ctx, todo.WorkspaceID, todo.ID)
+		stored.CreatedAt = base
+		stored.UpdatedAt = base.Add(time.Duration(i) * time.Hour)
+		stored.Completed = i%2 == 0
+		_, err := repo.UpdateTodo(ctx, stored)
+		require.NoError(t, err)
+	}
+
+	ids := func(todos []entities.Todo) []uuid.UUID {
+		result := make([]uuid.UUID, 0, len(todos))
+		for _, todo := range todos {
+			result = append(result, todo.ID)
+		}
+		return result
+	}
+
+	t.Run("pages by cursor without gaps or duplicates", func(t *testing.T) {
+		all, err := repo.ListTodos(ctx, models.TodoListFilter{WorkspaceID: user.ID, UserID: user.ID, Sort: models.TodoSortCreatedAt, Order: models.SortAsc})
+		require.NoError(t, err)
+		require.Len(t, all, 4)
+
+		var paged []entities.Todo
+		filter := models.TodoListFilter{WorkspaceID: user.ID, UserID: user.ID, Sort: models.TodoSortCreatedAt, Order: models.SortAsc, Limit: 3}
+		for {
+			page, err := repo.ListTodos(ctx, filter)
+			require.NoError(t, err)
+			if len(page) == 0 {
+				break
+			}
+			paged = append(paged, page...)
+			last := page[len(page)-1]
+			filter.After = &models.TodoCursor{Time: last.CreatedAt, ID: last.ID}
+		}
+		assert.Equal(t, ids(all), ids(paged))
+	})
+
+	t.Run("sorts titles bytewise", func(t *testing.T) {
+		todos, err := repo.ListTodos(ctx, models.TodoListFilter{WorkspaceID: user.ID, UserID: user.ID, Sort: models.TodoSortTitle, Order: models.SortAsc})
+		require.NoError(t, err)
+
+		got := make([]string, 0, len(todos))
+		for _, todo := range todos {
+			got = append(got, todo.Title)
+		}
+		assert.Equal(t, []string{"B", "a", "b", "c"}, got)
+	})
+
+	t.Run("sorts by updated_at descending", func(t *testing.T) {
+		todos, err := repo.ListTodos(ctx, models.TodoListFilter{WorkspaceID: user.ID, UserID: user.ID, Sort: models.TodoSortUpdatedAt, Order: models.SortDesc, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, todos, 2)
+		assert.Equal(t, "c", todos[0].Title)
+		assert.Equal(t, "a", todos[1].Title)
+	})
+
+	t.Run("filters by completed and updated range", func(t *testing.T) {
+		completed := true
+		after := base.Add(30 * time.Minute)
+		todos, err := repo.ListTodos(ctx, models.TodoListFilter{
+			WorkspaceID: user.ID, UserID: user.ID, Sort: models.TodoSortCreatedAt, Order: models.SortAsc,
+			Completed: &completed, UpdatedAfter: &after,
+		})
+		require.NoError(t, err)
+		require.Len(t, todos, 1)
+		assert.Equal(t, "a", todos[0].Title)
+	})
+
+	t.Run("filters by due range", func(t *testing.T) {
+		due := base.Add(24 * time.Hour)
+		withDue, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "due", DueAt: &due})
+		defer repo.DeleteTodo(ctx, withDue.WorkspaceID, withDue.ID, withDue.Version)
+
+		from, before := due, due.Add(time.Hour)
+		todos, err := repo.ListTodos(ctx, models.TodoListFilter{
+			WorkspaceID: user.ID, UserID: user.ID, Sort: models.TodoSortCreatedAt, Order: models.SortAsc,
+			DueFrom: &from, DueBefore: &before,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{withDue.ID}, ids(todos))
+
+		// Правая граница не включается.
+		todos, err = repo.ListTodos(ctx, models.TodoListFilter{
+			WorkspaceID: user.ID, UserID: user.ID, Sort: models.TodoSortCreatedAt, Order: models.SortAsc, DueBefore: &due,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, todos)
+	})
+}
+
+func TestInMemoryRepository_TodoPositions(t *testing.T) {
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	ctx := context.Background()
+
+	user, _ := repo.CreateUser(ctx, "positions@example.com", "hash")
+	other, _ := repo.CreateUser(ctx, "other@example.com", "hash")
+	_, _ = repo.CreateTodo(ctx, entities.Todo{WorkspaceID: other.ID, UserID: other.ID, Title: "foreign", Position: "z0"})
+
+	last, err := repo.LastTodoPosition(ctx, user.ID, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, last)
+
+	c, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "c", Position: "a2"})
+	a, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "a", Position: "a0"})
+	b, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "b", Position: "a1"})
+
+	t.Run("last position", func(t *testing.T) {
+		last, err := repo.LastTodoPosition(ctx, user.ID, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "a2", last)
+	})
+
+	t.Run("adjacent positions skip excluded todo", func(t *testing.T) {
+		next, _ := repo.AdjacentTodoPosition(ctx, user.ID, &a, true, uuid.Nil)
+		assert.Equal(t, "a1", next)
+		prev, _ := repo.AdjacentTodoPosition(ctx, user.ID, &c, false, uuid.Nil)
+		assert.Equal(t, "a1", prev)
+		none, _ := repo.AdjacentTodoPosition(ctx, user.ID, &b, true, c.ID)
+		assert.Empty(t, none)
+		none, _ = repo.AdjacentTodoPosition(ctx, user.ID, &a, false, uuid.Nil)
+		assert.Empty(t, none)
+	})
+
+	t.Run("todos come in position order", func(t *testing.T) {
+		todos, err := repo.GetTodoByUserID(ctx, user.ID, user.ID)
+		require.NoError(t, err)
+		require.Len(t, todos, 3)
+		assert.Equal(t, []string{"a", "b", "c"}, []string{todos[0].Title, todos[1].Title, todos[2].Title})
+
+		listed, err := repo.ListTodos(ctx, models.TodoListFilter{WorkspaceID: user.ID, UserID: user.ID, Sort: models.TodoSortPosition, Order: models.SortAsc, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, listed, 2)
+		assert.Equal(t, "a", listed[0].Title)
+		assert.Equal(t, "b", listed[1].Title)
+	})
+	t.Run("a todo with the same position is a neighbour", func(t *testing.T) {
+		twin, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "twin", Position: "a0"})
+		next, _ := repo.AdjacentTodoPosition(ctx, user.ID, &a, true, uuid.Nil)
+		assert.Equal(t, "a0", next)
+		next, _ = repo.AdjacentTodoPosition(ctx, user.ID, &a, true, twin.ID)
+		assert.Equal(t, "a1", next)
+
+		ids, err := repo.RebalanceTodoPositions(ctx, user.ID, user.ID)
+		require.NoError(t, err)
+		assert.Len(t, ids, 4)
+		positions := map[string]bool{}
+		for _, id := range ids {
+			todo, err := repo.GetTodoByID(ctx, user.ID, id)
+			require.NoError(t, err)
+			positions[todo.Position] = true
+		}
+		assert.Len(t, positions, 4)
+		got, _ := repo.GetTodoByID(ctx, user.ID, c.ID)
+		assert.Equal(t, "f000004", got.Position)
+		assert.Equal(t, c.Version+1, got.Version)
+	})
+
+}
+
+func TestInMemoryRepository_SearchTodos(t *testing.T) {
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	ctx := context.Background()
+
+	user, _ := repo.CreateUser(ctx, "search@example.com", "hash")
+	other, _ := repo.CreateUser(ctx, "other@example.com", "hash")
+
+	inTitle, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "Buy milk", Description: "at the corner shop"})
+	inDescription, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "Groceries", Description: "bread and milk"})
+	_, _ = repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "Call mom"})
+	_, _ = repo.CreateTodo(ctx, entities.Todo{WorkspaceID: other.ID, UserID: other.ID, Title: "Buy milk"})
+
+	t.Run("title matches rank higher", func(t *testing.T) {
+		results, err := repo.SearchTodos(ctx, user.ID, user.ID, "MILK", 10)
+
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, inTitle.ID, results[0].Todo.ID)
+		assert.Equal(t, inDescription.ID, results[1].Todo.ID)
+		assert.Greater(t, results[0].Rank, results[1].Rank)
+		assert.Equal(t, "Buy <b>milk</b>", results[0].Highlights.Title)
+		assert.Equal(t, "bread and <b>milk</b>", results[1].Highlights.Description)
+	})
+
+	t.Run("all words must match", func(t *testing.T) {
+		results, err := repo.SearchTodos(ctx, user.ID, user.ID, "milk bread", 10)
+
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, inDescription.ID, results[0].Todo.ID)
+	})
+
+	t.Run("index follows updates and deletes", func(t *testing.T) {
+		stored, _ := repo.GetTodoByID(ctx, inTitle.WorkspaceID, inTitle.ID)
+		stored.Title = "Buy coffee"
+		_, err := repo.UpdateTodo(ctx, stored)
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteTodo(ctx, inDescription.WorkspaceID, inDescription.ID, inDescription.Version))
+
+		results, _ := repo.SearchTodos(ctx, user.ID, user.ID, "milk", 10)
+		assert.Empty(t, results)
+
+		results, _ = repo.SearchTodos(ctx, user.ID, user.ID, "coffee", 10)
+		assert.Len(t, results, 1)
+	})
+}
+
+func TestInMemoryRepository_GetTodoChildren(t *testing.T) {
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	ctx := context.Background()
+
+	user, _ := repo.CreateUser(ctx, "children@example.com", "hash")
+	parent, _ := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "parent", Position: "a0"})
+	_, _ = repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "second", Position: "a2", ParentID: &parent.ID})
+	_, _ = repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "first", Position: "a1", ParentID: &parent.ID})
+	_, _ = repo.CreateTodo(ctx, entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: "top level", Position: "a3"})
+
+	children, err := repo.GetTodoChildren(ctx, parent.WorkspaceID, parent.ID)
+	require.NoError(t, err)
+	require.Len(t, children, 2)
+	assert.Equal(t, "first", children[0].Title)
+	assert.Equal(t, "second", children[1].Title)
+
+	children, err = repo.GetTodoChildren(ctx, user.ID, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, children)
+}
@@ -0,0 +1,43 @@
+package in_memory
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+)
+
+func (r *InMemoryRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	// Заодно чистим записи, срок действия которых уже истёк.
+	now := time.Now()
+	for id, exp := range r.revoked {
+		if exp.Before(now) {
+			remember(r, r.revoked, id)
+			delete(r.revoked, id)
+		}
+	}
+
+	if _, ok := r.revoked[jti]; ok {
+		if r.logger != nil {
+			r.logger.Warn("memory: token already revoked", slog.String("jti", jti))
+		}
+		return domain.ErrTokenRevoked
+	}
+	remember(r, r.revoked, jti)
+	r.revoked[jti] = expiresAt
+
+	if r.logger != nil {
+		r.logger.Info("memory: token revoked", slog.String("jti", jti))
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	_, ok := r.revoked[jti]
+	return ok, nil
+}
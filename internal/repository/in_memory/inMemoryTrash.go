@@ -0,0 +1,145 @@
+package in_memory
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+func (r *InMemoryRepository) GetTrashedTodo(ctx context.Context, workspaceID, todoID uuid.UUID) (*entities.Todo, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	todo, ok := r.todos[todoID]
+	if !ok || todo.WorkspaceID != workspaceID {
+		if r.logger != nil {
+			r.logger.Warn("memory: trashed todo not found", slog.String("todo_id", todoID.String()))
+		}
+		return nil, domain.ErrTodoNotFound
+	}
+	if todo.DeletedAt == nil {
+		return nil, domain.ErrTodoNotTrashed
+	}
+
+	found := *todo
+	return &found, nil
+}
+
+func (r *InMemoryRepository) GetTrashedTodos(ctx context.Context, workspaceID, userID uuid.UUID) ([]entities.Todo, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	todos := make([]entities.Todo, 0)
+	for _, todo := range r.todos {
+		if todo.WorkspaceID == workspaceID && todo.UserID == userID && todo.DeletedAt != nil {
+			todos = append(todos, *todo)
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		if !todos[i].DeletedAt.Equal(*todos[j].DeletedAt) {
+			return todos[i].DeletedAt.After(*todos[j].DeletedAt)
+		}
+		return bytes.Compare(todos[i].ID[:], todos[j].ID[:]) < 0
+	})
+
+	return todos, nil
+}
+
+func (r *InMemoryRepository) RestoreTodo(ctx context.Context, workspaceID, todoID uuid.UUID) (*entities.Todo, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	todo, ok := r.todos[todoID]
+	if !ok || todo.WorkspaceID != workspaceID || todo.DeletedAt == nil {
+		if r.logger != nil {
+			r.logger.Warn("memory: trashed todo not found for restore", slog.String("todo_id", todoID.String()))
+		}
+		return nil, domain.ErrTodoNotFound
+	}
+
+	r.restoreTodo(todoID, *todo.DeletedAt, time.Now())
+
+	if r.logger != nil {
+		r.logger.Info("memory: todo restored", slog.String("todo_id", todoID.String()))
+	}
+	restored := *todo
+	return &restored, nil
+}
+
+func (r *InMemoryRepository) PurgeTodo(ctx context.Context, workspaceID, todoID uuid.UUID) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	todo, ok := r.todos[todoID]
+	if !ok || todo.WorkspaceID != workspaceID || todo.DeletedAt == nil {
+		if r.logger != nil {
+			r.logger.Warn("memory: trashed todo not found for purge", slog.String("todo_id", todoID.String()))
+		}
+		return domain.ErrTodoNotFound
+	}
+
+	r.purgeTodo(todoID)
+
+	if r.logger != nil {
+		r.logger.Info("memory: todo purged", slog.String("todo_id", todoID.String()))
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	purged := 0
+	for id, todo := range r.todos {
+		if todo.DeletedAt != nil && todo.DeletedAt.Before(before) {
+			r.purgeTodo(id)
+			purged++
+		}
+	}
+
+	if r.logger != nil && purged > 0 {
+		r.logger.Info("memory: trash purged", slog.Int("todos", purged))
+	}
+	return purged, nil
+}
+
+// trashTodo переносит в корзину задачу и её поддерево, кроме уже удалённых раньше подзадач.
+func (r *InMemoryRepository) trashTodo(todoID uuid.UUID, at time.Time) {
+	rememberPtr(r, r.todos, todoID)
+	todo := r.todos[todoID]
+	deletedAt := at
+	todo.DeletedAt = &deletedAt
+	r.unindexTodo(todoID)
+	for _, child := range r.todos {
+		if child.ParentID != nil && *child.ParentID == todoID && child.DeletedAt == nil {
+			r.trashTodo(child.ID, at)
+		}
+	}
+}
+
+// restoreTodo возвращает задачу и подзадачи, удалённые вместе с ней (с тем же deletedAt).
+func (r *InMemoryRepository) restoreTodo(todoID uuid.UUID, deletedAt, now time.Time) {
+	rememberPtr(r, r.todos, todoID)
+	todo := r.todos[todoID]
+	todo.DeletedAt = nil
+	todo.UpdatedAt = now
+	todo.Version++
+	r.indexTodo(todo)
+	for _, child := range r.todos {
+		if child.ParentID != nil && *child.ParentID == todoID && child.DeletedAt != nil && child.DeletedAt.Equal(deletedAt) {
+			r.restoreTodo(child.ID, deletedAt, now)
+		}
+	}
+}
+
+// purgeTodo удаляет задачу вместе с поддеревом, как ON DELETE CASCADE по parent_id.
+func (r *InMemoryRepository) purgeTodo(todoID uuid.UUID) {
+	for id, child := range r.todos {
+		if child.ParentID != nil && *child.ParentID == todoID {
+			r.purgeTodo(id)
+		}
+	}
+	r.removeTodo(todoID)
+}
@@ -0,0 +1,128 @@
+package in_memory_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepository_Trash(t *testing.T) {
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	ctx := context.Background()
+
+	user, _ := repo.CreateUser(ctx, "trash@example.com", "hash")
+	create := func(title string, parent *entities.Todo) entities.Todo {
+		todo := entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: title, Position: title}
+		if parent != nil {
+			todo.ParentID = &parent.ID
+		}
+		created, err := repo.CreateTodo(ctx, todo)
+		require.NoError(t, err)
+		return created
+	}
+	titles := func(todos []entities.Todo) []string {
+		got := []string{}
+		for _, todo := range todos {
+			got = append(got, todo.Title)
+		}
+		return got
+	}
+	live := func() []string {
+		todos, err := repo.ListTodos(ctx, models.TodoListFilter{WorkspaceID: user.ID, UserID: user.ID, Sort: models.TodoSortPosition})
+		require.NoError(t, err)
+		return titles(todos)
+	}
+	trash := func() []string {
+		todos, err := repo.GetTrashedTodos(ctx, user.ID, user.ID)
+		require.NoError(t, err)
+		return titles(todos)
+	}
+
+	root := create("a-root", nil)
+	early := create("b-early", &root)
+	late := create("c-late", &root)
+	grandchild := create("d-grandchild", &late)
+	other := create("e-other", nil)
+
+	t.Run("trashed todos leave normal queries", func(t *testing.T) {
+		require.NoError(t, repo.DeleteTodo(ctx, user.ID, early.ID, early.Version))
+		time.Sleep(time.Millisecond)
+		require.NoError(t, repo.DeleteTodo(ctx, user.ID, root.ID, root.Version))
+
+		assert.Equal(t, []string{"e-other"}, live())
+		_, err := repo.GetTodoByID(ctx, user.ID, late.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+		children, _ := repo.GetTodoChildren(ctx, user.ID, root.ID)
+		assert.Empty(t, children)
+		results, _ := repo.SearchTodos(ctx, user.ID, user.ID, "grandchild", 10)
+		assert.Empty(t, results)
+		position, _ := repo.LastTodoPosition(ctx, user.ID, user.ID)
+		assert.Equal(t, "e-other", position)
+
+		_, err = repo.UpdateTodo(ctx, &entities.Todo{ID: root.ID, WorkspaceID: user.ID, Title: "changed"})
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+		assert.ErrorIs(t, repo.DeleteTodo(ctx, user.ID, root.ID, root.Version), domain.ErrTodoNotFound)
+	})
+
+	t.Run("trash lists the latest deletions first", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"a-root", "c-late", "d-grandchild"}, trash()[:3])
+		assert.Equal(t, "b-early", trash()[3])
+
+		trashed, err := repo.GetTrashedTodo(ctx, user.ID, grandchild.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, trashed.DeletedAt)
+		_, err = repo.GetTrashedTodo(ctx, user.ID, other.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotTrashed)
+		_, err = repo.GetTrashedTodo(ctx, uuid.New(), root.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+	})
+
+	t.Run("restore brings back what was deleted together", func(t *testing.T) {
+		restored, err := repo.RestoreTodo(ctx, user.ID, root.ID)
+		require.NoError(t, err)
+		assert.Nil(t, restored.DeletedAt)
+
+		assert.Equal(t, []string{"a-root", "c-late", "d-grandchild", "e-other"}, live())
+		assert.Equal(t, []string{"b-early"}, trash())
+		results, _ := repo.SearchTodos(ctx, user.ID, user.ID, "grandchild", 10)
+		assert.Len(t, results, 1)
+
+		_, err = repo.RestoreTodo(ctx, user.ID, root.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+	})
+
+	t.Run("purge deletes for good", func(t *testing.T) {
+		assert.ErrorIs(t, repo.PurgeTodo(ctx, user.ID, other.ID), domain.ErrTodoNotFound)
+		require.NoError(t, repo.PurgeTodo(ctx, user.ID, early.ID))
+		assert.Empty(t, trash())
+		_, err := repo.GetTrashedTodo(ctx, user.ID, early.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+	})
+
+	t.Run("purge trash respects the cutoff", func(t *testing.T) {
+		// Восстановление подняло версию.
+		restored, err := repo.GetTodoByID(ctx, user.ID, late.ID)
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteTodo(ctx, user.ID, late.ID, restored.Version))
+		cutoff := time.Now()
+		time.Sleep(time.Millisecond)
+		require.NoError(t, repo.DeleteTodo(ctx, user.ID, other.ID, other.Version))
+
+		purged, err := repo.PurgeTrash(ctx, cutoff)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, purged, 1)
+		assert.Equal(t, []string{"e-other"}, trash())
+		_, err = repo.GetTrashedTodo(ctx, user.ID, grandchild.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+		assert.Equal(t, []string{"a-root"}, live())
+	})
+}
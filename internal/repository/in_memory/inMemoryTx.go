@@ -0,0 +1,133 @@
+package in_memory
+
+import (
+	"context"
+)
+
+// lockKey — ключ контекста, в котором лежит владелец взятой блокировки.
+type lockKey struct{}
+
+// lockHolder — владелец блокировки хранилища: его получает контекст, с которым она взята.
+// Пока идёт транзакция, в undo копятся правки, которые нужно отменить при откате.
+type lockHolder struct {
+	inTx bool
+	undo []func()
+}
+
+// lock берёт блокировку хранилища и возвращает контекст с её владельцем. Методы хранилища
+// вызывают его первым делом, поэтому транзакция выполняется целиком без чужих изменений.
+// Повторно блокировку не берёт только контекст, чей владелец держит её сейчас: контекст
+// уже завершившейся транзакции или вызова ждёт, как любой другой.
+func (r *InMemoryRepository) lock(ctx context.Context) (context.Context, func()) {
+	if r.heldBy(ctx) != nil {
+		return ctx, func() {}
+	}
+	r.mu.Lock()
+	holder := &lockHolder{}
+	r.holder.Store(holder)
+	return context.WithValue(ctx, lockKey{}, holder), func() {
+		r.holder.Store(nil)
+		r.mu.Unlock()
+	}
+}
+
+// heldBy возвращает владельца блокировки из контекста, если он держит её сейчас.
+func (r *InMemoryRepository) heldBy(ctx context.Context) *lockHolder {
+	holder, _ := ctx.Value(lockKey{}).(*lockHolder)
+	if holder == nil || r.holder.Load() != holder {
+		return nil
+	}
+	return holder
+}
+
+// WithinTransaction держит блокировку хранилища, пока выполняется fn, и отменяет её правки
+// по журналу, если fn вернула ошибку или запаниковала. Журнал хранит прежние значения только
+// изменённых ключей, так что откат стоит столько же, сколько сама транзакция. Пока она идёт,
+// другие запросы ждут, поэтому откат не затирает чужих изменений.
+func (r *InMemoryRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.heldBy(ctx) != nil {
+		return fn(ctx)
+	}
+
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	holder := r.heldBy(ctx)
+	holder.inTx = true
+	committed := false
+	defer func() {
+		if !committed {
+			for i := len(holder.undo) - 1; i >= 0; i-- {
+				holder.undo[i]()
+			}
+		}
+	}()
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// journal возвращает владельца блокировки, если идёт транзакция и правки нужно записывать.
+func (r *InMemoryRepository) journal() *lockHolder {
+	holder := r.holder.Load()
+	if holder == nil || !holder.inTx {
+		return nil
+	}
+	return holder
+}
+
+// remember записывает в журнал текущее значение m[key] перед его заменой или удалением.
+func remember[K comparable, V any](r *InMemoryRepository, m map[K]V, key K) {
+	holder := r.journal()
+	if holder == nil {
+		return
+	}
+	old, ok := m[key]
+	holder.undo = append(holder.undo, restoreKey(m, key, old, ok))
+}
+
+// rememberPtr — remember для записей, которые хранилище меняет на месте: в журнал
+// попадает копия записи.
+func rememberPtr[K comparable, V any](r *InMemoryRepository, m map[K]*V, key K) {
+	holder := r.journal()
+	if holder == nil {
+		return
+	}
+	old, ok := m[key]
+	if ok {
+		copied := *old
+		old = &copied
+	}
+	holder.undo = append(holder.undo, restoreKey(m, key, old, ok))
+}
+
+// rememberNested — remember для одного элемента вложенной карты, чтобы не копировать
+// целиком большие наборы вроде поискового индекса. Откат возвращает на место и саму
+// вложенную карту, если её успели убрать.
+func rememberNested[K, I comparable, V any](r *InMemoryRepository, m map[K]map[I]V, key K, inner I) {
+	holder := r.journal()
+	if holder == nil {
+		return
+	}
+	set, hadSet := m[key]
+	old, ok := set[inner]
+	holder.undo = append(holder.undo, func() {
+		if !hadSet {
+			delete(m, key)
+			return
+		}
+		m[key] = set
+		restoreKey(set, inner, old, ok)()
+	})
+}
+
+func restoreKey[K comparable, V any](m map[K]V, key K, old V, ok bool) func() {
+	return func() {
+		if ok {
+			m[key] = old
+		} else {
+			delete(m, key)
+		}
+	}
+}
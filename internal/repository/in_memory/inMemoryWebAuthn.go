@@ -0,0 +1,121 @@
+package in_memory
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+func (r *InMemoryRepository) SaveWebAuthnChallenge(ctx context.Context, challenge entities.WebAuthnChallenge) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	now := time.Now()
+	for key, ch := range r.challenges {
+		if ch.ExpiresAt.Before(now) {
+			remember(r, r.challenges, key)
+			delete(r.challenges, key)
+		}
+	}
+
+	remember(r, r.challenges, challenge.Challenge)
+	r.challenges[challenge.Challenge] = &challenge
+
+	if r.logger != nil {
+		r.logger.Info("memory: webauthn challenge saved", slog.String("ceremony", challenge.Ceremony))
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) ConsumeWebAuthnChallenge(ctx context.Context, challenge string) (*entities.WebAuthnChallenge, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	ch, ok := r.challenges[challenge]
+	if !ok || ch.ExpiresAt.Before(time.Now()) {
+		if r.logger != nil {
+			r.logger.Warn("memory: webauthn challenge not found")
+		}
+		return nil, domain.ErrChallengeNotFound
+	}
+
+	remember(r, r.challenges, challenge)
+	delete(r.challenges, challenge)
+	return ch, nil
+}
+
+func (r *InMemoryRepository) CreateWebAuthnCredential(ctx context.Context, userID uuid.UUID, credentialID, publicKey []byte, signCount uint32) (entities.WebAuthnCredential, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if _, ok := r.credentials[string(credentialID)]; ok {
+		if r.logger != nil {
+			r.logger.Warn("memory: webauthn credential already exists", slog.String("user_id", userID.String()))
+		}
+		return entities.WebAuthnCredential{}, domain.ErrCredentialExists
+	}
+
+	cred := &entities.WebAuthnCredential{
+		ID:           uuid.New(),
+		UserID:       userID,
+		CredentialID: credentialID,
+		PublicKey:    publicKey,
+		SignCount:    signCount,
+		CreatedAt:    time.Now(),
+	}
+	remember(r, r.credentials, string(credentialID))
+	r.credentials[string(credentialID)] = cred
+
+	if r.logger != nil {
+		r.logger.Info("memory: webauthn credential created", slog.String("user_id", userID.String()))
+	}
+	return *cred, nil
+}
+
+func (r *InMemoryRepository) GetWebAuthnCredential(ctx context.Context, credentialID []byte) (*entities.WebAuthnCredential, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	cred, ok := r.credentials[string(credentialID)]
+	if !ok {
+		if r.logger != nil {
+			r.logger.Warn("memory: webauthn credential not found")
+		}
+		return nil, domain.ErrCredentialNotFound
+	}
+
+	found := *cred
+	return &found, nil
+}
+
+func (r *InMemoryRepository) GetWebAuthnCredentialsByUserID(ctx context.Context, userID uuid.UUID) ([]entities.WebAuthnCredential, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	creds := make([]entities.WebAuthnCredential, 0)
+	for _, cred := range r.credentials {
+		if cred.UserID == userID {
+			creds = append(creds, *cred)
+		}
+	}
+
+	return creds, nil
+}
+
+func (r *InMemoryRepository) UpdateWebAuthnSignCount(ctx context.Context, id uuid.UUID, signCount uint32) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	for key, cred := range r.credentials {
+		if cred.ID == id {
+			rememberPtr(r, r.credentials, key)
+			now := time.Now()
+			cred.SignCount = signCount
+			cred.LastUsedAt = &now
+			return nil
+		}
+	}
+
+	if r.logger != nil {
+		r.logger.Warn("memory: webauthn credential not found for update", slog.String("credential_id", id.String()))
+	}
+	return domain.ErrCredentialNotFound
+}
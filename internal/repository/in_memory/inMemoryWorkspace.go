@@ -0,0 +1,180 @@
+package in_memory
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+func (r *InMemoryRepository) CreateWorkspace(ctx context.Context, workspace entities.Workspace, ownerID uuid.UUID) (entities.Workspace, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	workspace.ID = uuid.New()
+	workspace.Personal = false
+	workspace.CreatedAt = time.Now()
+	remember(r, r.workspaces, workspace.ID)
+	remember(r, r.members, workspace.ID)
+	r.workspaces[workspace.ID] = &workspace
+	r.members[workspace.ID] = map[uuid.UUID]*entities.WorkspaceMember{
+		ownerID: {WorkspaceID: workspace.ID, UserID: ownerID, Role: entities.WorkspaceRoleOwner, CreatedAt: workspace.CreatedAt},
+	}
+
+	if r.logger != nil {
+		r.logger.Info("memory: workspace created", slog.String("workspace_id", workspace.ID.String()), slog.String("owner_id", ownerID.String()))
+	}
+	return workspace, nil
+}
+
+func (r *InMemoryRepository) GetWorkspaceByID(ctx context.Context, workspaceID uuid.UUID) (*entities.Workspace, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	workspace, ok := r.workspaces[workspaceID]
+	if !ok {
+		return nil, domain.ErrWorkspaceNotFound
+	}
+	found := *workspace
+	return &found, nil
+}
+
+func (r *InMemoryRepository) UpdateWorkspace(ctx context.Context, workspace *entities.Workspace) (*entities.Workspace, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	existing, ok := r.workspaces[workspace.ID]
+	if !ok {
+		return nil, domain.ErrWorkspaceNotFound
+	}
+	rememberPtr(r, r.workspaces, workspace.ID)
+	existing.Name = workspace.Name
+
+	updated := *existing
+	return &updated, nil
+}
+
+func (r *InMemoryRepository) DeleteWorkspace(ctx context.Context, workspaceID uuid.UUID) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if _, ok := r.workspaces[workspaceID]; !ok {
+		return domain.ErrWorkspaceNotFound
+	}
+	r.removeWorkspace(workspaceID)
+
+	if r.logger != nil {
+		r.logger.Info("memory: workspace deleted", slog.String("workspace_id", workspaceID.String()))
+	}
+	return nil
+}
+
+// removeWorkspace удаляет пространство с задачами, проектами, метками и участниками,
+// как ON DELETE CASCADE в Postgres.
+func (r *InMemoryRepository) removeWorkspace(workspaceID uuid.UUID) {
+	for id, todo := range r.todos {
+		if todo.WorkspaceID == workspaceID {
+			r.removeTodo(id)
+		}
+	}
+	for id, project := range r.projects {
+		if project.WorkspaceID == workspaceID {
+			remember(r, r.projects, id)
+			delete(r.projects, id)
+			r.deleteSharesOf(entities.ShareResourceProject, id)
+		}
+	}
+	for id, tag := range r.tags {
+		if tag.WorkspaceID == workspaceID {
+			r.removeTag(id)
+		}
+	}
+	remember(r, r.workspaces, workspaceID)
+	remember(r, r.members, workspaceID)
+	delete(r.workspaces, workspaceID)
+	delete(r.members, workspaceID)
+}
+
+func (r *InMemoryRepository) SaveWorkspaceMember(ctx context.Context, member entities.WorkspaceMember) (entities.WorkspaceMember, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	members, ok := r.members[member.WorkspaceID]
+	if !ok {
+		return entities.WorkspaceMember{}, domain.ErrWorkspaceNotFound
+	}
+	rememberNested(r, r.members, member.WorkspaceID, member.UserID)
+	if existing, ok := members[member.UserID]; ok {
+		updated := *existing
+		updated.Role = member.Role
+		members[member.UserID] = &updated
+		return updated, nil
+	}
+
+	member.CreatedAt = time.Now()
+	members[member.UserID] = &member
+
+	if r.logger != nil {
+		r.logger.Info("memory: workspace member saved", slog.String("workspace_id", member.WorkspaceID.String()),
+			slog.String("user_id", member.UserID.String()), slog.String("role", member.Role))
+	}
+	return member, nil
+}
+
+func (r *InMemoryRepository) GetWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) (*entities.WorkspaceMember, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	member, ok := r.members[workspaceID][userID]
+	if !ok {
+		return nil, domain.ErrNotWorkspaceMember
+	}
+	found := *member
+	return &found, nil
+}
+
+func (r *InMemoryRepository) GetWorkspaceMembers(ctx context.Context, workspaceID uuid.UUID) ([]entities.WorkspaceMember, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	members := make([]entities.WorkspaceMember, 0, len(r.members[workspaceID]))
+	for _, member := range r.members[workspaceID] {
+		members = append(members, *member)
+	}
+	sortMembers(members)
+	return members, nil
+}
+
+func (r *InMemoryRepository) GetWorkspaceMemberships(ctx context.Context, userID uuid.UUID) ([]entities.WorkspaceMember, error) {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	memberships := make([]entities.WorkspaceMember, 0)
+	for _, members := range r.members {
+		if member, ok := members[userID]; ok {
+			memberships = append(memberships, *member)
+		}
+	}
+	sortMembers(memberships)
+	return memberships, nil
+}
+
+func (r *InMemoryRepository) DeleteWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) error {
+	ctx, unlock := r.lock(ctx)
+	defer unlock()
+	if _, ok := r.members[workspaceID][userID]; !ok {
+		return domain.ErrNotWorkspaceMember
+	}
+	rememberNested(r, r.members, workspaceID, userID)
+	delete(r.members[workspaceID], userID)
+
+	if r.logger != nil {
+		r.logger.Info("memory: workspace member removed", slog.String("workspace_id", workspaceID.String()), slog.String("user_id", userID.String()))
+	}
+	return nil
+}
+
+func sortMembers(members []entities.WorkspaceMember) {
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].CreatedAt.Equal(members[j].CreatedAt) {
+			return members[i].CreatedAt.Before(members[j].CreatedAt)
+		}
+		return members[i].UserID.String() < members[j].UserID.String()
+	})
+}
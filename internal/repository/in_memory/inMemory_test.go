@@ -156,6 +156,55 @@ func TestInMemoryUser_DeleteUser(t *testing.T) {
 		assert.Equal(t, domain.ErrUserNotFound, err)
 	})
 
+	t.Run("delete team member removes their data in the team workspace", func(t *testing.T) {
+		owner, err := repo.CreateUser(ctx, "team-owner@example.com", "hash")
+		require.NoError(t, err)
+		member, err := repo.CreateUser(ctx, "team-member@example.com", "hash")
+		require.NoError(t, err)
+		team, err := repo.CreateWorkspace(ctx, entities.Workspace{Name: "team"}, owner.ID)
+		require.NoError(t, err)
+		_, err = repo.SaveWorkspaceMember(ctx, entities.WorkspaceMember{WorkspaceID: team.ID, UserID: member.ID, Role: entities.WorkspaceRoleMember})
+		require.NoError(t, err)
+
+		project, err := repo.CreateProject(ctx, entities.Project{WorkspaceID: team.ID, UserID: member.ID, Name: "theirs"})
+		require.NoError(t, err)
+		todo, err := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: team.ID, UserID: member.ID, Title: "member todo", ProjectID: &project.ID})
+		require.NoError(t, err)
+		kept, err := repo.CreateTodo(ctx, entities.Todo{WorkspaceID: team.ID, UserID: owner.ID, Title: "owner todo", ProjectID: &project.ID})
+		require.NoError(t, err)
+		tag, err := repo.CreateTag(ctx, entities.Tag{WorkspaceID: team.ID, UserID: member.ID, Name: "theirs"})
+		require.NoError(t, err)
+		require.NoError(t, repo.AttachTag(ctx, kept.ID, tag.ID))
+		_, err = repo.SaveShare(ctx, entities.Share{ResourceType: entities.ShareResourceTodo, ResourceID: kept.ID, UserID: member.ID, Role: entities.RoleEditor, InvitedBy: owner.ID})
+		require.NoError(t, err)
+		attachment, err := repo.CreateAttachment(ctx, entities.Attachment{TodoID: todo.ID, UploaderID: owner.ID, FileName: "a.txt"})
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteUser(ctx, member.ID))
+
+		_, err = repo.GetTodoByID(ctx, team.ID, todo.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+		_, err = repo.GetProjectByID(ctx, team.ID, project.ID)
+		assert.ErrorIs(t, err, domain.ErrProjectNotFound)
+		_, err = repo.GetTagByID(ctx, team.ID, tag.ID)
+		assert.ErrorIs(t, err, domain.ErrTagNotFound)
+		shares, err := repo.GetSharesByResource(ctx, entities.ShareResourceTodo, kept.ID)
+		require.NoError(t, err)
+		assert.Empty(t, shares)
+
+		found, err := repo.GetTodoByID(ctx, team.ID, kept.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.ProjectID)
+		tags, err := repo.GetTagsByTodoID(ctx, kept.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+
+		orphaned, err := repo.GetOrphanedAttachments(ctx, uuid.Nil, 10)
+		require.NoError(t, err)
+		require.Len(t, orphaned, 1)
+		assert.Equal(t, attachment.ID, orphaned[0].ID)
+	})
+
 	t.Run("delete non-existing user", func(t *testing.T) {
 		err := repo.DeleteUser(ctx, uuid.New())
 
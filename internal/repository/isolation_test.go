@@ -0,0 +1,223 @@
+package repository_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/polzovatel/todo-learning/internal/database"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository"
+	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
+	"github.com/polzovatel/todo-learning/internal/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Изоляция проверяется на обоих хранилищах; Postgres — только если задан TEST_DATABASE_URL.
+func TestTenantIsolation(t *testing.T) {
+	t.Run("in_memory", func(t *testing.T) {
+		testTenantIsolation(t, in_memory.NewInMemoryRepository(slog.Default()))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv("TEST_DATABASE_URL")
+		if dsn == "" {
+			t.Skip("TEST_DATABASE_URL is not set")
+		}
+		ctx := context.Background()
+		pool, err := pgxpool.New(ctx, dsn)
+		require.NoError(t, err)
+		defer pool.Close()
+		require.NoError(t, database.RunMigrations(ctx, pool))
+		testTenantIsolation(t, postgres.NewPostgresRepository(pool, slog.Default()))
+	})
+}
+
+func testTenantIsolation(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	suffix := uuid.NewString()
+
+	alice, err := repo.CreateUser(ctx, "alice-"+suffix+"@example.com", "hash")
+	require.NoError(t, err)
+	bob, err := repo.CreateUser(ctx, "bob-"+suffix+"@example.com", "hash")
+	require.NoError(t, err)
+	defer repo.DeleteUser(ctx, alice.ID)
+	defer repo.DeleteUser(ctx, bob.ID)
+
+	team, err := repo.CreateWorkspace(ctx, entities.Workspace{Name: "team"}, alice.ID)
+	require.NoError(t, err)
+	defer repo.DeleteWorkspace(ctx, team.ID)
+
+	createTodo := func(workspaceID, userID uuid.UUID, title string) entities.Todo {
+		todo, err := repo.CreateTodo(ctx, entities.Todo{
+			WorkspaceID: workspaceID,
+			UserID:      userID,
+			Title:       title,
+			Priority:    entities.PriorityNormal,
+			Position:    "m",
+		})
+		require.NoError(t, err)
+		return todo
+	}
+	alicePersonal := createTodo(alice.ID, alice.ID, "isolation alice")
+	aliceTeam := createTodo(team.ID, alice.ID, "isolation team")
+	bobPersonal := createTodo(bob.ID, bob.ID, "isolation bob")
+
+	project, err := repo.CreateProject(ctx, entities.Project{WorkspaceID: bob.ID, UserID: bob.ID, Name: "secret", Color: "#808080"})
+	require.NoError(t, err)
+	tag, err := repo.CreateTag(ctx, entities.Tag{WorkspaceID: alice.ID, UserID: alice.ID, Name: "secret", Color: "#808080"})
+	require.NoError(t, err)
+
+	t.Run("todos cannot be read across workspaces", func(t *testing.T) {
+		for _, probe := range []struct {
+			workspaceID uuid.UUID
+			todo        entities.Todo
+		}{
+			{alice.ID, bobPersonal},
+			{team.ID, bobPersonal},
+			{bob.ID, alicePersonal},
+			{alice.ID, aliceTeam},
+			{team.ID, alicePersonal},
+		} {
+			_, err := repo.GetTodoByID(ctx, probe.workspaceID, probe.todo.ID)
+			assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+			children, err := repo.GetTodoChildren(ctx, probe.workspaceID, probe.todo.ID)
+			require.NoError(t, err)
+			assert.Empty(t, children)
+		}
+
+		got, err := repo.GetTodoByID(ctx, team.ID, aliceTeam.ID)
+		require.NoError(t, err)
+		assert.Equal(t, team.ID, got.WorkspaceID)
+	})
+
+	t.Run("lists and search stay inside the workspace", func(t *testing.T) {
+		titles := func(workspaceID, userID uuid.UUID) []string {
+			todos, err := repo.ListTodos(ctx, models.TodoListFilter{WorkspaceID: workspaceID, UserID: userID, Sort: "title", Order: "asc"})
+			require.NoError(t, err)
+			got := []string{}
+			for _, todo := range todos {
+				got = append(got, todo.Title)
+			}
+			return got
+		}
+		assert.Equal(t, []string{"isolation alice"}, titles(alice.ID, alice.ID))
+		assert.Equal(t, []string{"isolation team"}, titles(team.ID, alice.ID))
+		assert.Empty(t, titles(alice.ID, bob.ID))
+		assert.Empty(t, titles(team.ID, bob.ID))
+
+		todos, err := repo.GetTodoByUserID(ctx, alice.ID, bob.ID)
+		require.NoError(t, err)
+		assert.Empty(t, todos)
+
+		results, err := repo.SearchTodos(ctx, alice.ID, alice.ID, "isolation", 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, alicePersonal.ID, results[0].Todo.ID)
+		results, err = repo.SearchTodos(ctx, alice.ID, bob.ID, "isolation", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		position, err := repo.LastTodoPosition(ctx, team.ID, bob.ID)
+		require.NoError(t, err)
+		assert.Empty(t, position)
+		position, err = repo.AdjacentTodoPosition(ctx, alice.ID, &entities.Todo{UserID: bob.ID, Position: "a"}, true, uuid.Nil)
+		require.NoError(t, err)
+		assert.Empty(t, position)
+	})
+
+	t.Run("todos cannot be changed across workspaces", func(t *testing.T) {
+		forged := bobPersonal
+		forged.WorkspaceID = alice.ID
+		forged.Title = "hijacked"
+		_, err := repo.UpdateTodo(ctx, &forged)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+		assert.ErrorIs(t, repo.DeleteTodo(ctx, alice.ID, bobPersonal.ID, bobPersonal.Version), domain.ErrTodoNotFound)
+
+		got, err := repo.GetTodoByID(ctx, bob.ID, bobPersonal.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "isolation bob", got.Title)
+	})
+
+	t.Run("projects are isolated too", func(t *testing.T) {
+		_, err := repo.GetProjectByID(ctx, alice.ID, project.ID)
+		assert.ErrorIs(t, err, domain.ErrProjectNotFound)
+		projects, err := repo.GetProjectsByUserID(ctx, alice.ID, bob.ID, true)
+		require.NoError(t, err)
+		assert.Empty(t, projects)
+
+		forged := project
+		forged.WorkspaceID = alice.ID
+		_, err = repo.UpdateProject(ctx, &forged)
+		assert.ErrorIs(t, err, domain.ErrProjectNotFound)
+		_, err = repo.DeleteProject(ctx, alice.ID, project.ID, true)
+		assert.ErrorIs(t, err, domain.ErrProjectNotFound)
+
+		_, err = repo.GetProjectByID(ctx, bob.ID, project.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("tags are isolated too", func(t *testing.T) {
+		_, err := repo.GetTagByID(ctx, team.ID, tag.ID)
+		assert.ErrorIs(t, err, domain.ErrTagNotFound)
+		tags, err := repo.GetTagsByUserID(ctx, team.ID, alice.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+
+		forged := tag
+		forged.WorkspaceID = team.ID
+		forged.Name = "hijacked"
+		_, err = repo.UpdateTag(ctx, &forged)
+		assert.ErrorIs(t, err, domain.ErrTagNotFound)
+		assert.ErrorIs(t, repo.DeleteTag(ctx, team.ID, tag.ID), domain.ErrTagNotFound)
+
+		// То же имя в другом пространстве — другая метка.
+		teamTag, err := repo.CreateTag(ctx, entities.Tag{WorkspaceID: team.ID, UserID: alice.ID, Name: "secret", Color: "#808080"})
+		require.NoError(t, err)
+		assert.NotEqual(t, tag.ID, teamTag.ID)
+
+		got, err := repo.GetTagByID(ctx, alice.ID, tag.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", got.Name)
+	})
+
+	t.Run("tags attached by a shared editor filter the owner's list", func(t *testing.T) {
+		_, err := repo.SaveShare(ctx, entities.Share{ResourceType: entities.ShareResourceTodo, ResourceID: aliceTeam.ID, UserID: bob.ID, Role: entities.RoleEditor, InvitedBy: alice.ID})
+		require.NoError(t, err)
+		bobTag, err := repo.CreateTag(ctx, entities.Tag{WorkspaceID: team.ID, UserID: bob.ID, Name: "review", Color: "#808080"})
+		require.NoError(t, err)
+		require.NoError(t, repo.AttachTag(ctx, aliceTeam.ID, bobTag.ID))
+
+		tagged := func(workspaceID uuid.UUID) []uuid.UUID {
+			todos, err := repo.ListTodos(ctx, models.TodoListFilter{WorkspaceID: workspaceID, UserID: alice.ID, Tags: []string{"review"}, TagsMatchAll: true})
+			require.NoError(t, err)
+			ids := []uuid.UUID{}
+			for _, todo := range todos {
+				ids = append(ids, todo.ID)
+			}
+			return ids
+		}
+		assert.Equal(t, []uuid.UUID{aliceTeam.ID}, tagged(team.ID))
+		assert.Empty(t, tagged(alice.ID))
+	})
+
+	t.Run("deleting a workspace removes only its data", func(t *testing.T) {
+		require.NoError(t, repo.DeleteWorkspace(ctx, team.ID))
+		_, err := repo.GetWorkspaceByID(ctx, team.ID)
+		assert.ErrorIs(t, err, domain.ErrWorkspaceNotFound)
+		_, err = repo.GetTodoByID(ctx, team.ID, aliceTeam.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+		_, err = repo.GetTodoByID(ctx, alice.ID, alicePersonal.ID)
+		assert.NoError(t, err)
+		tags, err := repo.GetTagsByUserID(ctx, team.ID, alice.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+		_, err = repo.GetTagByID(ctx, alice.ID, tag.ID)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,69 @@
+package mocks
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+type MockAttachmentStore struct {
+	Attachments []entities.Attachment
+}
+
+func NewMockAttachmentStore() *MockAttachmentStore {
+	return &MockAttachmentStore{}
+}
+
+func (m *MockAttachmentStore) CreateAttachment(ctx context.Context, attachment entities.Attachment) (entities.Attachment, error) {
+	attachment.ID = uuid.New()
+	attachment.CreatedAt = time.Now()
+	m.Attachments = append(m.Attachments, attachment)
+	return attachment, nil
+}
+
+func (m *MockAttachmentStore) GetAttachmentByID(ctx context.Context, attachmentID uuid.UUID) (*entities.Attachment, error) {
+	for _, attachment := range m.Attachments {
+		if attachment.ID == attachmentID && attachment.TodoID != uuid.Nil {
+			return &attachment, nil
+		}
+	}
+	return nil, domain.ErrAttachmentNotFound
+}
+
+func (m *MockAttachmentStore) GetAttachmentsByTodoID(ctx context.Context, todoID uuid.UUID) ([]entities.Attachment, error) {
+	var attachments []entities.Attachment
+	for _, attachment := range m.Attachments {
+		if attachment.TodoID == todoID {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+func (m *MockAttachmentStore) GetOrphanedAttachments(ctx context.Context, after uuid.UUID, limit int) ([]entities.Attachment, error) {
+	var attachments []entities.Attachment
+	for _, attachment := range m.Attachments {
+		if attachment.TodoID == uuid.Nil && attachment.ID.String() > after.String() {
+			attachments = append(attachments, attachment)
+		}
+	}
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].ID.String() < attachments[j].ID.String() })
+	if len(attachments) > limit {
+		attachments = attachments[:limit]
+	}
+	return attachments, nil
+}
+
+func (m *MockAttachmentStore) DeleteAttachment(ctx context.Context, attachmentID uuid.UUID) error {
+	for i, attachment := range m.Attachments {
+		if attachment.ID == attachmentID {
+			m.Attachments = append(m.Attachments[:i], m.Attachments[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrAttachmentNotFound
+}
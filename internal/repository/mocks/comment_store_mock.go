@@ -0,0 +1,68 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+type MockCommentStore struct {
+	Comments []entities.Comment
+}
+
+func NewMockCommentStore() *MockCommentStore {
+	return &MockCommentStore{}
+}
+
+func (m *MockCommentStore) CreateComment(ctx context.Context, comment entities.Comment) (entities.Comment, error) {
+	comment.ID = uuid.New()
+	comment.CreatedAt = time.Now()
+	m.Comments = append(m.Comments, comment)
+	return comment, nil
+}
+
+func (m *MockCommentStore) GetCommentByID(ctx context.Context, commentID uuid.UUID) (*entities.Comment, error) {
+	for _, comment := range m.Comments {
+		if comment.ID == commentID && comment.DeletedAt == nil {
+			return &comment, nil
+		}
+	}
+	return nil, domain.ErrCommentNotFound
+}
+
+func (m *MockCommentStore) GetCommentsByTodoID(ctx context.Context, todoID uuid.UUID) ([]entities.Comment, error) {
+	var comments []entities.Comment
+	for _, comment := range m.Comments {
+		if comment.TodoID == todoID && comment.DeletedAt == nil {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+func (m *MockCommentStore) UpdateComment(ctx context.Context, comment *entities.Comment) (*entities.Comment, error) {
+	for i := range m.Comments {
+		existing := &m.Comments[i]
+		if existing.ID == comment.ID && existing.DeletedAt == nil {
+			now := time.Now()
+			existing.Body, existing.EditedAt = comment.Body, &now
+			updated := *existing
+			return &updated, nil
+		}
+	}
+	return nil, domain.ErrCommentNotFound
+}
+
+func (m *MockCommentStore) DeleteComment(ctx context.Context, commentID uuid.UUID) error {
+	for i := range m.Comments {
+		if m.Comments[i].ID == commentID && m.Comments[i].DeletedAt == nil {
+			now := time.Now()
+			m.Comments[i].DeletedAt = &now
+			return nil
+		}
+	}
+	return domain.ErrCommentNotFound
+}
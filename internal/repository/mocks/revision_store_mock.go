@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+type MockRevisionStore struct {
+	Revisions map[uuid.UUID][]entities.TodoRevision
+}
+
+func NewMockRevisionStore() *MockRevisionStore {
+	return &MockRevisionStore{Revisions: make(map[uuid.UUID][]entities.TodoRevision)}
+}
+
+func (m *MockRevisionStore) CreateRevision(ctx context.Context, revision entities.TodoRevision) (entities.TodoRevision, error) {
+	revision.Number = len(m.Revisions[revision.TodoID]) + 1
+	revision.CreatedAt = time.Now()
+	m.Revisions[revision.TodoID] = append(m.Revisions[revision.TodoID], revision)
+	return revision, nil
+}
+
+func (m *MockRevisionStore) GetRevisions(ctx context.Context, todoID uuid.UUID) ([]entities.TodoRevision, error) {
+	return append([]entities.TodoRevision{}, m.Revisions[todoID]...), nil
+}
+
+func (m *MockRevisionStore) GetRevision(ctx context.Context, todoID uuid.UUID, number int) (*entities.TodoRevision, error) {
+	revisions := m.Revisions[todoID]
+	if number < 1 || number > len(revisions) {
+		return nil, domain.ErrRevisionNotFound
+	}
+	revision := revisions[number-1]
+	return &revision, nil
+}
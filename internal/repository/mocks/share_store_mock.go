@@ -0,0 +1,71 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+type MockShareStore struct {
+	Shares []entities.Share
+}
+
+func NewMockShareStore() *MockShareStore {
+	return &MockShareStore{}
+}
+
+func (m *MockShareStore) SaveShare(ctx context.Context, share entities.Share) (entities.Share, error) {
+	for i := range m.Shares {
+		existing := &m.Shares[i]
+		if existing.ResourceType == share.ResourceType && existing.ResourceID == share.ResourceID && existing.UserID == share.UserID {
+			existing.Role, existing.InvitedBy = share.Role, share.InvitedBy
+			return *existing, nil
+		}
+	}
+	share.ID = uuid.New()
+	share.CreatedAt = time.Now()
+	m.Shares = append(m.Shares, share)
+	return share, nil
+}
+
+func (m *MockShareStore) GetShare(ctx context.Context, resourceType string, resourceID, userID uuid.UUID) (*entities.Share, error) {
+	for _, share := range m.Shares {
+		if share.ResourceType == resourceType && share.ResourceID == resourceID && share.UserID == userID {
+			return &share, nil
+		}
+	}
+	return nil, domain.ErrShareNotFound
+}
+
+func (m *MockShareStore) GetSharesByResource(ctx context.Context, resourceType string, resourceID uuid.UUID) ([]entities.Share, error) {
+	var shares []entities.Share
+	for _, share := range m.Shares {
+		if share.ResourceType == resourceType && share.ResourceID == resourceID {
+			shares = append(shares, share)
+		}
+	}
+	return shares, nil
+}
+
+func (m *MockShareStore) GetSharesByUserID(ctx context.Context, userID uuid.UUID) ([]entities.Share, error) {
+	var shares []entities.Share
+	for i := len(m.Shares) - 1; i >= 0; i-- {
+		if m.Shares[i].UserID == userID {
+			shares = append(shares, m.Shares[i])
+		}
+	}
+	return shares, nil
+}
+
+func (m *MockShareStore) DeleteShare(ctx context.Context, resourceType string, resourceID, userID uuid.UUID) error {
+	for i, share := range m.Shares {
+		if share.ResourceType == resourceType && share.ResourceID == resourceID && share.UserID == userID {
+			m.Shares = append(m.Shares[:i], m.Shares[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrShareNotFound
+}
@@ -27,6 +27,7 @@ func (s *MockStore) CreateUser(ctx context.Context, email, passwordHash string)
 		Email:        email,
 		PasswordHash: passwordHash,
 		CreatedAt:    time.Now(),
+		Timezone:     "UTC",
 	}
 	s.Users[user.ID] = &user
 	s.UsersByEmail[user.Email] = &user
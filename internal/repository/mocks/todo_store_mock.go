@@ -1,12 +1,17 @@
 package mocks
 
 import (
+	"bytes"
 	"context"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/polzovatel/todo-learning/internal/domain"
 	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/ranking"
 )
 
 type MockTodoStore struct {
@@ -19,32 +24,35 @@ func NewMockTodoStore() *MockTodoStore {
 	}
 }
 
-func (m *MockTodoStore) CreateTodo(ctx context.Context, userID uuid.UUID, title, description string) (entities.Todo, error) {
-	todo := entities.Todo{
-		ID:          uuid.New(),
-		UserID:      userID,
-		Title:       title,
-		Description: description,
-		Completed:   false,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
+func (m *MockTodoStore) CreateTodo(ctx context.Context, todo entities.Todo) (entities.Todo, error) {
+	todo.ID = uuid.New()
+	todo.CreatedAt = time.Now()
+	todo.UpdatedAt = todo.CreatedAt
+	todo.Version = 1
 	m.Todos[todo.ID] = &todo
 	return todo, nil
 }
 
-func (m *MockTodoStore) GetTodoByID(ctx context.Context, todoID uuid.UUID) (*entities.Todo, error) {
+func (m *MockTodoStore) GetTodoByID(ctx context.Context, workspaceID, todoID uuid.UUID) (*entities.Todo, error) {
 	todo, ok := m.Todos[todoID]
-	if !ok {
+	if !ok || todo.WorkspaceID != workspaceID || todo.DeletedAt != nil {
 		return nil, domain.ErrTodoNotFound
 	}
 	return todo, nil
 }
 
-func (m *MockTodoStore) GetTodoByUserID(ctx context.Context, userID uuid.UUID) ([]entities.Todo, error) {
+func (m *MockTodoStore) GetTodoWorkspaceID(ctx context.Context, todoID uuid.UUID) (uuid.UUID, error) {
+	todo, ok := m.Todos[todoID]
+	if !ok || todo.DeletedAt != nil {
+		return uuid.Nil, domain.ErrTodoNotFound
+	}
+	return todo.WorkspaceID, nil
+}
+
+func (m *MockTodoStore) GetTodoByUserID(ctx context.Context, workspaceID, userID uuid.UUID) ([]entities.Todo, error) {
 	var todos []entities.Todo
 	for _, todo := range m.Todos {
-		if todo.UserID == userID {
+		if todo.WorkspaceID == workspaceID && todo.UserID == userID && todo.DeletedAt == nil {
 			todos = append(todos, *todo)
 		}
 	}
@@ -52,18 +60,235 @@ func (m *MockTodoStore) GetTodoByUserID(ctx context.Context, userID uuid.UUID) (
 }
 
 func (m *MockTodoStore) UpdateTodo(ctx context.Context, todo *entities.Todo) (*entities.Todo, error) {
-	if _, ok := m.Todos[todo.ID]; !ok {
+	existing, ok := m.Todos[todo.ID]
+	if !ok || existing.WorkspaceID != todo.WorkspaceID || existing.DeletedAt != nil {
 		return nil, domain.ErrTodoNotFound
 	}
+	if existing.Version != todo.Version {
+		return nil, domain.ErrVersionMismatch
+	}
 	todo.UpdatedAt = time.Now()
+	todo.Version++
 	m.Todos[todo.ID] = todo
 	return todo, nil
 }
 
-func (m *MockTodoStore) DeleteTodo(ctx context.Context, todoID uuid.UUID) error {
-	if _, ok := m.Todos[todoID]; !ok {
+func (m *MockTodoStore) DeleteTodo(ctx context.Context, workspaceID, todoID uuid.UUID, version int) error {
+	todo, ok := m.Todos[todoID]
+	if !ok || todo.WorkspaceID != workspaceID || todo.DeletedAt != nil {
 		return domain.ErrTodoNotFound
 	}
-	delete(m.Todos, todoID)
+	if todo.Version != version {
+		return domain.ErrVersionMismatch
+	}
+	m.trash(todoID, time.Now())
 	return nil
 }
+
+func (m *MockTodoStore) trash(todoID uuid.UUID, at time.Time) {
+	m.Todos[todoID].DeletedAt = &at
+	for _, child := range m.Todos {
+		if child.ParentID != nil && *child.ParentID == todoID && child.DeletedAt == nil {
+			m.trash(child.ID, at)
+		}
+	}
+}
+
+func (m *MockTodoStore) GetTrashedTodo(ctx context.Context, workspaceID, todoID uuid.UUID) (*entities.Todo, error) {
+	todo, ok := m.Todos[todoID]
+	if !ok || todo.WorkspaceID != workspaceID {
+		return nil, domain.ErrTodoNotFound
+	}
+	if todo.DeletedAt == nil {
+		return nil, domain.ErrTodoNotTrashed
+	}
+	return todo, nil
+}
+
+func (m *MockTodoStore) GetTrashedTodos(ctx context.Context, workspaceID, userID uuid.UUID) ([]entities.Todo, error) {
+	var todos []entities.Todo
+	for _, todo := range m.Todos {
+		if todo.WorkspaceID == workspaceID && todo.UserID == userID && todo.DeletedAt != nil {
+			todos = append(todos, *todo)
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool { return todos[i].DeletedAt.After(*todos[j].DeletedAt) })
+	return todos, nil
+}
+
+func (m *MockTodoStore) RestoreTodo(ctx context.Context, workspaceID, todoID uuid.UUID) (*entities.Todo, error) {
+	todo, ok := m.Todos[todoID]
+	if !ok || todo.WorkspaceID != workspaceID || todo.DeletedAt == nil {
+		return nil, domain.ErrTodoNotFound
+	}
+	m.restore(todoID, *todo.DeletedAt)
+	return todo, nil
+}
+
+func (m *MockTodoStore) restore(todoID uuid.UUID, deletedAt time.Time) {
+	m.Todos[todoID].DeletedAt = nil
+	m.Todos[todoID].Version++
+	for _, child := range m.Todos {
+		if child.ParentID != nil && *child.ParentID == todoID && child.DeletedAt != nil && child.DeletedAt.Equal(deletedAt) {
+			m.restore(child.ID, deletedAt)
+		}
+	}
+}
+
+func (m *MockTodoStore) PurgeTodo(ctx context.Context, workspaceID, todoID uuid.UUID) error {
+	todo, ok := m.Todos[todoID]
+	if !ok || todo.WorkspaceID != workspaceID || todo.DeletedAt == nil {
+		return domain.ErrTodoNotFound
+	}
+	m.purge(todoID)
+	return nil
+}
+
+func (m *MockTodoStore) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+	for id, todo := range m.Todos {
+		if todo.DeletedAt != nil && todo.DeletedAt.Before(before) {
+			m.purge(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *MockTodoStore) purge(todoID uuid.UUID) {
+	for id, child := range m.Todos {
+		if child.ParentID != nil && *child.ParentID == todoID {
+			m.purge(id)
+		}
+	}
+	delete(m.Todos, todoID)
+}
+
+func (m *MockTodoStore) GetTodoChildren(ctx context.Context, workspaceID, parentID uuid.UUID) ([]entities.Todo, error) {
+	var children []entities.Todo
+	for _, todo := range m.Todos {
+		if todo.WorkspaceID == workspaceID && todo.DeletedAt == nil && todo.ParentID != nil && *todo.ParentID == parentID {
+			children = append(children, *todo)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].Position < children[j].Position })
+	return children, nil
+}
+
+// ListTodos поддерживает только сортировку по позиции и created_at; этого хватает тестам сервиса.
+func (m *MockTodoStore) ListTodos(ctx context.Context, filter models.TodoListFilter) ([]entities.Todo, error) {
+	desc := filter.Order == models.SortDesc
+	byPosition := filter.Sort == models.TodoSortPosition
+	less := func(a, b *entities.Todo) bool {
+		c := a.CreatedAt.Compare(b.CreatedAt)
+		if byPosition {
+			c = strings.Compare(a.Position, b.Position)
+		}
+		if c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(a.ID[:], b.ID[:]) < 0
+	}
+
+	var todos []entities.Todo
+	for _, todo := range m.Todos {
+		if todo.WorkspaceID != filter.WorkspaceID || todo.DeletedAt != nil || (filter.UserID != uuid.Nil && todo.UserID != filter.UserID) {
+			continue
+		}
+		if filter.Completed != nil && todo.Completed != *filter.Completed {
+			continue
+		}
+		if filter.Priority != "" && todo.Priority != filter.Priority {
+			continue
+		}
+		if filter.ProjectID != nil && (todo.ProjectID == nil || *todo.ProjectID != *filter.ProjectID) {
+			continue
+		}
+		if filter.DueFrom != nil || filter.DueBefore != nil {
+			if todo.DueAt == nil ||
+				(filter.DueFrom != nil && todo.DueAt.Before(*filter.DueFrom)) ||
+				(filter.DueBefore != nil && !todo.DueAt.Before(*filter.DueBefore)) {
+				continue
+			}
+		}
+		if filter.After != nil {
+			after := &entities.Todo{ID: filter.After.ID, CreatedAt: filter.After.Time, Position: filter.After.Position}
+			if (!desc && !less(after, todo)) || (desc && !less(todo, after)) {
+				continue
+			}
+		}
+		todos = append(todos, *todo)
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		if desc {
+			return less(&todos[j], &todos[i])
+		}
+		return less(&todos[i], &todos[j])
+	})
+	if filter.Limit > 0 && len(todos) > filter.Limit {
+		todos = todos[:filter.Limit]
+	}
+	return todos, nil
+}
+
+func (m *MockTodoStore) SearchTodos(ctx context.Context, workspaceID, userID uuid.UUID, query string, limit int) ([]models.TodoSearchResult, error) {
+	var results []models.TodoSearchResult
+	for _, todo := range m.Todos {
+		if todo.WorkspaceID == workspaceID && todo.UserID == userID && todo.DeletedAt == nil && strings.Contains(strings.ToLower(todo.Title+" "+todo.Description), strings.ToLower(query)) {
+			results = append(results, models.TodoSearchResult{Todo: *todo, Rank: 1})
+		}
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (m *MockTodoStore) LastTodoPosition(ctx context.Context, workspaceID, userID uuid.UUID) (string, error) {
+	last := ""
+	for _, todo := range m.Todos {
+		if todo.WorkspaceID == workspaceID && todo.UserID == userID && todo.DeletedAt == nil && todo.Position > last {
+			last = todo.Position
+		}
+	}
+	return last, nil
+}
+
+func (m *MockTodoStore) AdjacentTodoPosition(ctx context.Context, workspaceID uuid.UUID, anchor *entities.Todo, after bool, exclude uuid.UUID) (string, error) {
+	found := ""
+	for _, todo := range m.Todos {
+		if todo.WorkspaceID != workspaceID || todo.UserID != anchor.UserID || todo.ID == anchor.ID || todo.ID == exclude || todo.DeletedAt != nil {
+			continue
+		}
+		if after && todo.Position >= anchor.Position && (found == "" || todo.Position < found) {
+			found = todo.Position
+		}
+		if !after && todo.Position <= anchor.Position && todo.Position > found {
+			found = todo.Position
+		}
+	}
+	return found, nil
+}
+
+func (m *MockTodoStore) RebalanceTodoPositions(ctx context.Context, workspaceID, userID uuid.UUID) ([]uuid.UUID, error) {
+	todos := make([]*entities.Todo, 0)
+	for _, todo := range m.Todos {
+		if todo.WorkspaceID == workspaceID && todo.UserID == userID {
+			todos = append(todos, todo)
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		if todos[i].Position != todos[j].Position {
+			return todos[i].Position < todos[j].Position
+		}
+		return todos[i].CreatedAt.Before(todos[j].CreatedAt)
+	})
+	ids := make([]uuid.UUID, 0, len(todos))
+	for i, todo := range todos {
+		todo.Position = ranking.Nth(i+1, len(todos))
+		todo.Version++
+		ids = append(ids, todo.ID)
+	}
+	return ids, nil
+}
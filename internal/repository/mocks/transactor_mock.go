@@ -0,0 +1,24 @@
+package mocks
+
+import "context"
+
+type txKey struct{}
+
+// MockTransactor вызывает fn без транзакции: моки не умеют откатывать изменения.
+// Calls считает только внешние транзакции — вложенные, как и в настоящих хранилищах,
+// идут в рамках внешней.
+type MockTransactor struct {
+	Calls int
+}
+
+func NewMockTransactor() *MockTransactor {
+	return &MockTransactor{}
+}
+
+func (m *MockTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+	m.Calls++
+	return fn(context.WithValue(ctx, txKey{}, true))
+}
@@ -0,0 +1,112 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+type MockWorkspaceStore struct {
+	Workspaces map[uuid.UUID]*entities.Workspace
+	Members    []entities.WorkspaceMember
+}
+
+func NewMockWorkspaceStore() *MockWorkspaceStore {
+	return &MockWorkspaceStore{
+		Workspaces: make(map[uuid.UUID]*entities.Workspace),
+	}
+}
+
+func (m *MockWorkspaceStore) CreateWorkspace(ctx context.Context, workspace entities.Workspace, ownerID uuid.UUID) (entities.Workspace, error) {
+	workspace.ID = uuid.New()
+	workspace.CreatedAt = time.Now()
+	m.Workspaces[workspace.ID] = &workspace
+	m.Members = append(m.Members, entities.WorkspaceMember{WorkspaceID: workspace.ID, UserID: ownerID, Role: entities.WorkspaceRoleOwner, CreatedAt: workspace.CreatedAt})
+	return workspace, nil
+}
+
+func (m *MockWorkspaceStore) GetWorkspaceByID(ctx context.Context, workspaceID uuid.UUID) (*entities.Workspace, error) {
+	workspace, ok := m.Workspaces[workspaceID]
+	if !ok {
+		return nil, domain.ErrWorkspaceNotFound
+	}
+	return workspace, nil
+}
+
+func (m *MockWorkspaceStore) UpdateWorkspace(ctx context.Context, workspace *entities.Workspace) (*entities.Workspace, error) {
+	if _, ok := m.Workspaces[workspace.ID]; !ok {
+		return nil, domain.ErrWorkspaceNotFound
+	}
+	m.Workspaces[workspace.ID] = workspace
+	return workspace, nil
+}
+
+func (m *MockWorkspaceStore) DeleteWorkspace(ctx context.Context, workspaceID uuid.UUID) error {
+	if _, ok := m.Workspaces[workspaceID]; !ok {
+		return domain.ErrWorkspaceNotFound
+	}
+	delete(m.Workspaces, workspaceID)
+	members := m.Members[:0]
+	for _, member := range m.Members {
+		if member.WorkspaceID != workspaceID {
+			members = append(members, member)
+		}
+	}
+	m.Members = members
+	return nil
+}
+
+func (m *MockWorkspaceStore) SaveWorkspaceMember(ctx context.Context, member entities.WorkspaceMember) (entities.WorkspaceMember, error) {
+	for i := range m.Members {
+		existing := &m.Members[i]
+		if existing.WorkspaceID == member.WorkspaceID && existing.UserID == member.UserID {
+			existing.Role = member.Role
+			return *existing, nil
+		}
+	}
+	member.CreatedAt = time.Now()
+	m.Members = append(m.Members, member)
+	return member, nil
+}
+
+func (m *MockWorkspaceStore) GetWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) (*entities.WorkspaceMember, error) {
+	for _, member := range m.Members {
+		if member.WorkspaceID == workspaceID && member.UserID == userID {
+			return &member, nil
+		}
+	}
+	return nil, domain.ErrNotWorkspaceMember
+}
+
+func (m *MockWorkspaceStore) GetWorkspaceMembers(ctx context.Context, workspaceID uuid.UUID) ([]entities.WorkspaceMember, error) {
+	var members []entities.WorkspaceMember
+	for _, member := range m.Members {
+		if member.WorkspaceID == workspaceID {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+func (m *MockWorkspaceStore) GetWorkspaceMemberships(ctx context.Context, userID uuid.UUID) ([]entities.WorkspaceMember, error) {
+	var memberships []entities.WorkspaceMember
+	for _, member := range m.Members {
+		if member.UserID == userID {
+			memberships = append(memberships, member)
+		}
+	}
+	return memberships, nil
+}
+
+func (m *MockWorkspaceStore) DeleteWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) error {
+	for i, member := range m.Members {
+		if member.WorkspaceID == workspaceID && member.UserID == userID {
+			m.Members = append(m.Members[:i], m.Members[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrNotWorkspaceMember
+}
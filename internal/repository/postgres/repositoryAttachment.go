@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+const attachmentColumns = `id, todo_id, user_id, file_name, content_type, size, storage_key, created_at`
+
+// attachmentAlive отсекает осиротевшие вложения; вложение удалённого пользователя остаётся у задачи.
+const attachmentAlive = `todo_id IS NOT NULL`
+
+func scanAttachment(row pgx.Row) (entities.Attachment, error) {
+	var attachment entities.Attachment
+	var todoID, userID *uuid.UUID
+	err := row.Scan(&attachment.ID, &todoID, &userID, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.StorageKey, &attachment.CreatedAt)
+	if todoID != nil {
+		attachment.TodoID = *todoID
+	}
+	if userID != nil {
+		attachment.UploaderID = *userID
+	}
+	return attachment, err
+}
+
+func (r *PostgresRepository) CreateAttachment(ctx context.Context, attachment entities.Attachment) (entities.Attachment, error) {
+	const q = `INSERT INTO attachments (id, todo_id, user_id, file_name, content_type, size, storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING ` + attachmentColumns
+
+	created, err := scanAttachment(r.db(ctx).QueryRow(ctx, q, uuid.New(), attachment.TodoID, attachment.UploaderID,
+		attachment.FileName, attachment.ContentType, attachment.Size, attachment.StorageKey))
+	if err != nil {
+		r.logger.Error("postgres: create attachment failed", slog.String("todo_id", attachment.TodoID.String()), slog.Any("error", err))
+		return entities.Attachment{}, err
+	}
+
+	r.logger.Info("postgres: attachment created", slog.String("attachment_id", created.ID.String()), slog.String("todo_id", created.TodoID.String()))
+	return created, nil
+}
+
+func (r *PostgresRepository) GetAttachmentByID(ctx context.Context, attachmentID uuid.UUID) (*entities.Attachment, error) {
+	const q = `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = $1 AND ` + attachmentAlive
+
+	attachment, err := scanAttachment(r.db(ctx).QueryRow(ctx, q, attachmentID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			r.logger.Warn("postgres: attachment not found", slog.String("attachment_id", attachmentID.String()))
+			return nil, domain.ErrAttachmentNotFound
+		}
+		r.logger.Error("postgres: get attachment failed", slog.String("attachment_id", attachmentID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+func (r *PostgresRepository) GetAttachmentsByTodoID(ctx context.Context, todoID uuid.UUID) ([]entities.Attachment, error) {
+	const q = `SELECT ` + attachmentColumns + ` FROM attachments WHERE todo_id = $1 AND ` + attachmentAlive + ` ORDER BY created_at, id`
+	return r.queryAttachments(ctx, q, todoID)
+}
+
+func (r *PostgresRepository) GetOrphanedAttachments(ctx context.Context, after uuid.UUID, limit int) ([]entities.Attachment, error) {
+	const q = `SELECT ` + attachmentColumns + ` FROM attachments WHERE todo_id IS NULL AND id > $1 ORDER BY id LIMIT $2`
+	return r.queryAttachments(ctx, q, after, limit)
+}
+
+func (r *PostgresRepository) queryAttachments(ctx context.Context, q string, args ...any) ([]entities.Attachment, error) {
+	rows, err := r.db(ctx).Query(ctx, q, args...)
+	if err != nil {
+		r.logger.Error("postgres: list attachments failed", slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make([]entities.Attachment, 0)
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			r.logger.Error("postgres: scan attachment failed", slog.Any("error", err))
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: rows iteration failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (r *PostgresRepository) DeleteAttachment(ctx context.Context, attachmentID uuid.UUID) error {
+	const q = `DELETE FROM attachments WHERE id = $1`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, attachmentID)
+	if err != nil {
+		r.logger.Error("postgres: delete attachment failed", slog.String("attachment_id", attachmentID.String()), slog.Any("error", err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return domain.ErrAttachmentNotFound
+	}
+
+	r.logger.Info("postgres: attachment deleted", slog.String("attachment_id", attachmentID.String()))
+	return nil
+}
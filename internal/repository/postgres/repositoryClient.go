@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+func (r *PostgresRepository) CreateClient(ctx context.Context, clientID, secretHash string, scopes []string) (entities.Client, error) {
+	id := uuid.New()
+	const q = `INSERT INTO oauth_clients (id, client_id, secret_hash, scopes) VALUES ($1, $2, $3, $4) RETURNING id, client_id, secret_hash, scopes, created_at`
+
+	var client entities.Client
+	if err := r.db(ctx).QueryRow(ctx, q, id, clientID, secretHash, scopes).
+		Scan(&client.ID, &client.ClientID, &client.SecretHash, &client.Scopes, &client.CreatedAt); err != nil {
+		r.logger.Error("postgres: create client failed", slog.String("client_id", clientID), slog.Any("error", err))
+		return entities.Client{}, err
+	}
+
+	r.logger.Info("postgres: client created", slog.String("client_id", client.ClientID))
+	return client, nil
+}
+
+func (r *PostgresRepository) GetClientByClientID(ctx context.Context, clientID string) (*entities.Client, error) {
+	const q = `SELECT id, client_id, secret_hash, scopes, created_at FROM oauth_clients WHERE client_id = $1`
+
+	var client entities.Client
+	if err := r.db(ctx).QueryRow(ctx, q, clientID).
+		Scan(&client.ID, &client.ClientID, &client.SecretHash, &client.Scopes, &client.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			r.logger.Warn("postgres: client not found", slog.String("client_id", clientID))
+			return nil, domain.ErrClientNotFound
+		}
+		r.logger.Error("postgres: get client failed", slog.String("client_id", clientID), slog.Any("error", err))
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+func (r *PostgresRepository) UpdateClient(ctx context.Context, client *entities.Client) (*entities.Client, error) {
+	const q = `UPDATE oauth_clients SET secret_hash = $1, scopes = $2 WHERE client_id = $3 RETURNING id, client_id, secret_hash, scopes, created_at`
+
+	if err := r.db(ctx).QueryRow(ctx, q, client.SecretHash, client.Scopes, client.ClientID).
+		Scan(&client.ID, &client.ClientID, &client.SecretHash, &client.Scopes, &client.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			r.logger.Warn("postgres: update client target not found", slog.String("client_id", client.ClientID))
+			return nil, domain.ErrClientNotFound
+		}
+		r.logger.Error("postgres: update client failed", slog.String("client_id", client.ClientID), slog.Any("error", err))
+		return nil, err
+	}
+
+	r.logger.Info("postgres: client updated", slog.String("client_id", client.ClientID))
+	return client, nil
+}
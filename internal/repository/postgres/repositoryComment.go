@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+const commentColumns = `id, todo_id, user_id, body, created_at, edited_at, deleted_at`
+
+func scanComment(row pgx.Row) (entities.Comment, error) {
+	var comment entities.Comment
+	err := row.Scan(&comment.ID, &comment.TodoID, &comment.AuthorID, &comment.Body, &comment.CreatedAt, &comment.EditedAt, &comment.DeletedAt)
+	return comment, err
+}
+
+func (r *PostgresRepository) CreateComment(ctx context.Context, comment entities.Comment) (entities.Comment, error) {
+	const q = `INSERT INTO comments (id, todo_id, user_id, body) VALUES ($1, $2, $3, $4) RETURNING ` + commentColumns
+
+	created, err := scanComment(r.db(ctx).QueryRow(ctx, q, uuid.New(), comment.TodoID, comment.AuthorID, comment.Body))
+	if err != nil {
+		r.logger.Error("postgres: create comment failed", slog.String("todo_id", comment.TodoID.String()), slog.Any("error", err))
+		return entities.Comment{}, err
+	}
+
+	r.logger.Info("postgres: comment created", slog.String("comment_id", created.ID.String()), slog.String("todo_id", created.TodoID.String()))
+	return created, nil
+}
+
+func (r *PostgresRepository) GetCommentByID(ctx context.Context, commentID uuid.UUID) (*entities.Comment, error) {
+	const q = `SELECT ` + commentColumns + ` FROM comments WHERE id = $1 AND deleted_at IS NULL`
+
+	comment, err := scanComment(r.db(ctx).QueryRow(ctx, q, commentID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			r.logger.Warn("postgres: comment not found", slog.String("comment_id", commentID.String()))
+			return nil, domain.ErrCommentNotFound
+		}
+		r.logger.Error("postgres: get comment failed", slog.String("comment_id", commentID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+func (r *PostgresRepository) GetCommentsByTodoID(ctx context.Context, todoID uuid.UUID) ([]entities.Comment, error) {
+	const q = `SELECT ` + commentColumns + ` FROM comments WHERE todo_id = $1 AND deleted_at IS NULL ORDER BY created_at, id`
+
+	rows, err := r.db(ctx).Query(ctx, q, todoID)
+	if err != nil {
+		r.logger.Error("postgres: list comments failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := make([]entities.Comment, 0)
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			r.logger.Error("postgres: scan comment failed", slog.Any("error", err))
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: rows iteration failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (r *PostgresRepository) UpdateComment(ctx context.Context, comment *entities.Comment) (*entities.Comment, error) {
+	const q = `UPDATE comments SET body = $1, edited_at = NOW() WHERE id = $2 AND deleted_at IS NULL RETURNING ` + commentColumns
+
+	updated, err := scanComment(r.db(ctx).QueryRow(ctx, q, comment.Body, comment.ID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			r.logger.Warn("postgres: comment not found for update", slog.String("comment_id", comment.ID.String()))
+			return nil, domain.ErrCommentNotFound
+		}
+		r.logger.Error("postgres: update comment failed", slog.String("comment_id", comment.ID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	r.logger.Info("postgres: comment updated", slog.String("comment_id", updated.ID.String()))
+	return &updated, nil
+}
+
+func (r *PostgresRepository) DeleteComment(ctx context.Context, commentID uuid.UUID) error {
+	const q = `UPDATE comments SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, commentID)
+	if err != nil {
+		r.logger.Error("postgres: delete comment failed", slog.String("comment_id", commentID.String()), slog.Any("error", err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		r.logger.Warn("postgres: comment not found for delete", slog.String("comment_id", commentID.String()))
+		return domain.ErrCommentNotFound
+	}
+
+	r.logger.Info("postgres: comment deleted", slog.String("comment_id", commentID.String()))
+	return nil
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+)
+
+func (r *PostgresRepository) CreateMagicLink(ctx context.Context, jti, email string, expiresAt time.Time) error {
+	const q = `INSERT INTO magic_links (jti, email, expires_at) VALUES ($1, $2, $3)`
+
+	if _, err := r.db(ctx).Exec(ctx, q, jti, email, expiresAt); err != nil {
+		r.logger.Error("postgres: create magic link failed", slog.String("email", email), slog.Any("error", err))
+		return err
+	}
+
+	// Истёкшие ссылки держим сутки — по ним считается лимит частоты.
+	const purge = `DELETE FROM magic_links WHERE expires_at < NOW() - INTERVAL '1 day'`
+	if _, err := r.db(ctx).Exec(ctx, purge); err != nil {
+		r.logger.Warn("postgres: purge magic links failed", slog.Any("error", err))
+	}
+
+	r.logger.Info("postgres: magic link created", slog.String("email", email))
+	return nil
+}
+
+func (r *PostgresRepository) UseMagicLink(ctx context.Context, jti string) error {
+	const q = `UPDATE magic_links SET used_at = NOW() WHERE jti = $1 AND used_at IS NULL AND expires_at > NOW()`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, jti)
+	if err != nil {
+		r.logger.Error("postgres: use magic link failed", slog.String("jti", jti), slog.Any("error", err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		r.logger.Warn("postgres: magic link invalid or already used", slog.String("jti", jti))
+		return domain.ErrMagicLinkInvalid
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) LockMagicLinks(ctx context.Context, email string) error {
+	const q = `SELECT pg_advisory_xact_lock(hashtextextended('magic_link:' || $1, 0))`
+
+	if _, err := r.db(ctx).Exec(ctx, q, email); err != nil {
+		r.logger.Error("postgres: lock magic links failed", slog.String("email", email), slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresRepository) CountMagicLinksSince(ctx context.Context, email string, since time.Time) (int, error) {
+	const q = `SELECT COUNT(*) FROM magic_links WHERE email = $1 AND created_at >= $2`
+
+	var count int
+	if err := r.db(ctx).QueryRow(ctx, q, email, since).Scan(&count); err != nil {
+		r.logger.Error("postgres: count magic links failed", slog.String("email", email), slog.Any("error", err))
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+const personalTokenColumns = `id, user_id, name, token_hash, created_at, expires_at, revoked_at`
+
+func personalTokenFields(token *entities.PersonalToken) []any {
+	return []any{&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.CreatedAt, &token.ExpiresAt, &token.RevokedAt}
+}
+
+func (r *PostgresRepository) CreatePersonalToken(ctx context.Context, token entities.PersonalToken) (entities.PersonalToken, error) {
+	const q = `INSERT INTO personal_tokens (id, user_id, name, token_hash, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING ` + personalTokenColumns
+
+	var created entities.PersonalToken
+	if err := r.db(ctx).QueryRow(ctx, q, uuid.New(), token.UserID, token.Name, token.TokenHash, token.ExpiresAt).
+		Scan(personalTokenFields(&created)...); err != nil {
+		r.logger.Error("postgres: create personal token failed", slog.String("user_id", token.UserID.String()), slog.Any("error", err))
+		return entities.PersonalToken{}, err
+	}
+
+	r.logger.Info("postgres: personal token created", slog.String("token_id", created.ID.String()), slog.String("user_id", created.UserID.String()))
+	return created, nil
+}
+
+func (r *PostgresRepository) GetPersonalTokenByHash(ctx context.Context, hash string) (*entities.PersonalToken, error) {
+	const q = `SELECT ` + personalTokenColumns + ` FROM personal_tokens WHERE token_hash = $1`
+
+	var token entities.PersonalToken
+	if err := r.db(ctx).QueryRow(ctx, q, hash).Scan(personalTokenFields(&token)...); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrPersonalTokenNotFound
+		}
+		r.logger.Error("postgres: get personal token failed", slog.Any("error", err))
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *PostgresRepository) GetPersonalTokensByUserID(ctx context.Context, userID uuid.UUID) ([]entities.PersonalToken, error) {
+	const q = `SELECT ` + personalTokenColumns + ` FROM personal_tokens WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at DESC`
+
+	rows, err := r.db(ctx).Query(ctx, q, userID)
+	if err != nil {
+		r.logger.Error("postgres: get personal tokens failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]entities.PersonalToken, 0)
+	for rows.Next() {
+		var token entities.PersonalToken
+		if err := rows.Scan(personalTokenFields(&token)...); err != nil {
+			r.logger.Error("postgres: scan personal token failed", slog.Any("error", err))
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: iterate personal tokens failed", slog.Any("error", err))
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *PostgresRepository) RevokePersonalToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	const q = `UPDATE personal_tokens SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, tokenID, userID)
+	if err != nil {
+		r.logger.Error("postgres: revoke personal token failed", slog.String("token_id", tokenID.String()), slog.Any("error", err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		r.logger.Warn("postgres: personal token not found for revoke", slog.String("token_id", tokenID.String()))
+		return domain.ErrPersonalTokenNotFound
+	}
+
+	r.logger.Info("postgres: personal token revoked", slog.String("token_id", tokenID.String()))
+	return nil
+}
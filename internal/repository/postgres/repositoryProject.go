@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+const projectColumns = `id, workspace_id, user_id, name, color, archived, sort_order, created_at`
+
+func scanProject(row pgx.Row) (entities.Project, error) {
+	var project entities.Project
+	err := row.Scan(&project.ID, &project.WorkspaceID, &project.UserID, &project.Name, &project.Color, &project.Archived, &project.SortOrder, &project.CreatedAt)
+	return project, err
+}
+
+func (r *PostgresRepository) CreateProject(ctx context.Context, project entities.Project) (entities.Project, error) {
+	const q = `INSERT INTO projects (id, workspace_id, user_id, name, color, archived, sort_order) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING ` + projectColumns
+
+	created, err := scanProject(r.db(ctx).QueryRow(ctx, q, uuid.New(), project.WorkspaceID, project.UserID, project.Name, project.Color, project.Archived, project.SortOrder))
+	if err != nil {
+		r.logger.Error("postgres: create project failed", slog.String("user_id", project.UserID.String()), slog.Any("error", err))
+		return entities.Project{}, err
+	}
+
+	r.logger.Info("postgres: project created", slog.String("project_id", created.ID.String()))
+	return created, nil
+}
+
+func (r *PostgresRepository) GetProjectByID(ctx context.Context, workspaceID, projectID uuid.UUID) (*entities.Project, error) {
+	const q = `SELECT ` + projectColumns + ` FROM projects WHERE id = $1 AND workspace_id = $2`
+
+	project, err := scanProject(r.db(ctx).QueryRow(ctx, q, projectID, workspaceID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			r.logger.Warn("postgres: project not found", slog.String("project_id", projectID.String()))
+			return nil, domain.ErrProjectNotFound
+		}
+		r.logger.Error("postgres: get project failed", slog.String("project_id", projectID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+func (r *PostgresRepository) GetProjectWorkspaceID(ctx context.Context, projectID uuid.UUID) (uuid.UUID, error) {
+	const q = `SELECT workspace_id FROM projects WHERE id = $1`
+
+	var workspaceID uuid.UUID
+	if err := r.db(ctx).QueryRow(ctx, q, projectID).Scan(&workspaceID); err != nil {
+		if err == pgx.ErrNoRows {
+			return uuid.Nil, domain.ErrProjectNotFound
+		}
+		r.logger.Error("postgres: get project workspace failed", slog.String("project_id", projectID.String()), slog.Any("error", err))
+		return uuid.Nil, err
+	}
+
+	return workspaceID, nil
+}
+
+func (r *PostgresRepository) GetProjectsByUserID(ctx context.Context, workspaceID, userID uuid.UUID, includeArchived bool) ([]entities.Project, error) {
+	const q = `SELECT ` + projectColumns + ` FROM projects WHERE workspace_id = $3 AND user_id = $1 AND ($2 OR NOT archived)
+		ORDER BY sort_order, name COLLATE "C"`
+
+	rows, err := r.db(ctx).Query(ctx, q, userID, includeArchived, workspaceID)
+	if err != nil {
+		r.logger.Error("postgres: list projects failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := make([]entities.Project, 0)
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			r.logger.Error("postgres: scan project failed", slog.Any("error", err))
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: rows iteration failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func (r *PostgresRepository) UpdateProject(ctx context.Context, project *entities.Project) (*entities.Project, error) {
+	const q = `UPDATE projects SET name = $1, color = $2, archived = $3, sort_order = $4 WHERE id = $5 AND workspace_id = $6 RETURNING ` + projectColumns
+
+	updated, err := scanProject(r.db(ctx).QueryRow(ctx, q, project.Name, project.Color, project.Archived, project.SortOrder, project.ID, project.WorkspaceID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrProjectNotFound
+		}
+		r.logger.Error("postgres: update project failed", slog.String("project_id", project.ID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	r.logger.Info("postgres: project updated", slog.String("project_id", project.ID.String()))
+	return &updated, nil
+}
+
+func (r *PostgresRepository) DeleteProject(ctx context.Context, workspaceID, projectID uuid.UUID, cascade bool) ([]uuid.UUID, error) {
+	tx, err := r.db(ctx).Begin(ctx)
+	if err != nil {
+		r.logger.Error("postgres: begin delete project failed", slog.Any("error", err))
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Сначала убеждаемся, что проект в этом пространстве: иначе его задачи трогать нельзя.
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND workspace_id = $2)`, projectID, workspaceID).Scan(&exists); err != nil {
+		r.logger.Error("postgres: check project failed", slog.String("project_id", projectID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.ErrProjectNotFound
+	}
+
+	if cascade {
+		// Задачи проекта уходят в корзину вместе с подзадачами; NOW() одно на транзакцию,
+		// так что всё удалённое восстанавливается вместе.
+		const trashQuery = `WITH RECURSIVE subtree AS (
+				SELECT id FROM todos WHERE project_id = $1 AND deleted_at IS NULL
+				UNION
+				SELECT t.id FROM todos t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at IS NULL
+			)
+			UPDATE todos SET deleted_at = NOW() WHERE id IN (SELECT id FROM subtree)`
+		if _, err := tx.Exec(ctx, trashQuery, projectID); err != nil {
+			r.logger.Error("postgres: trash project todos failed", slog.String("project_id", projectID.String()), slog.Any("error", err))
+			return nil, err
+		}
+	}
+	rows, err := tx.Query(ctx, `UPDATE todos SET project_id = NULL, updated_at = NOW(), version = version + 1 WHERE project_id = $1 RETURNING id`, projectID)
+	if err != nil {
+		r.logger.Error("postgres: detach project todos failed", slog.String("project_id", projectID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	affected, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		r.logger.Error("postgres: collect project todos failed", slog.String("project_id", projectID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	cmdTag, err := tx.Exec(ctx, `DELETE FROM projects WHERE id = $1`, projectID)
+	if err != nil {
+		r.logger.Error("postgres: delete project failed", slog.String("project_id", projectID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return nil, domain.ErrProjectNotFound
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("postgres: commit delete project failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	r.logger.Info("postgres: project deleted", slog.String("project_id", projectID.String()), slog.Bool("cascade", cascade), slog.Int("todos", len(affected)))
+	return affected, nil
+}
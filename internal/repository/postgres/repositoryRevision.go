@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+const revisionColumns = `todo_id, revision, action, user_id, changes, state, reverted_to, created_at`
+
+func scanRevision(row pgx.Row) (entities.TodoRevision, error) {
+	var revision entities.TodoRevision
+	var actorID *uuid.UUID
+	err := row.Scan(&revision.TodoID, &revision.Number, &revision.Action, &actorID, &revision.Changes, &revision.State, &revision.RevertedTo, &revision.CreatedAt)
+	if actorID != nil {
+		revision.ActorID = *actorID
+	}
+	return revision, err
+}
+
+func (r *PostgresRepository) CreateRevision(ctx context.Context, revision entities.TodoRevision) (entities.TodoRevision, error) {
+	// Одновременные ревизии одной задачи получат один номер, и вторая упадёт на первичном ключе.
+	const q = `INSERT INTO todo_revisions (todo_id, revision, action, user_id, changes, state, reverted_to)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6 FROM todo_revisions WHERE todo_id = $1
+		RETURNING ` + revisionColumns
+
+	var actorID *uuid.UUID
+	if revision.ActorID != uuid.Nil {
+		actorID = &revision.ActorID
+	}
+	changes := revision.Changes
+	if changes == nil {
+		changes = []entities.FieldChange{}
+	}
+	created, err := scanRevision(r.db(ctx).QueryRow(ctx, q, revision.TodoID, revision.Action, actorID, changes, revision.State, revision.RevertedTo))
+	if err != nil {
+		r.logger.Error("postgres: create revision failed", slog.String("todo_id", revision.TodoID.String()), slog.Any("error", err))
+		return entities.TodoRevision{}, err
+	}
+
+	r.logger.Info("postgres: revision created", slog.String("todo_id", created.TodoID.String()), slog.Int("revision", created.Number))
+	return created, nil
+}
+
+func (r *PostgresRepository) GetRevisions(ctx context.Context, todoID uuid.UUID) ([]entities.TodoRevision, error) {
+	const q = `SELECT ` + revisionColumns + ` FROM todo_revisions WHERE todo_id = $1 ORDER BY revision`
+
+	rows, err := r.db(ctx).Query(ctx, q, todoID)
+	if err != nil {
+		r.logger.Error("postgres: list revisions failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]entities.TodoRevision, 0)
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			r.logger.Error("postgres: scan revision failed", slog.Any("error", err))
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: rows iteration failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (r *PostgresRepository) GetRevision(ctx context.Context, todoID uuid.UUID, number int) (*entities.TodoRevision, error) {
+	const q = `SELECT ` + revisionColumns + ` FROM todo_revisions WHERE todo_id = $1 AND revision = $2`
+
+	revision, err := scanRevision(r.db(ctx).QueryRow(ctx, q, todoID, number))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			r.logger.Warn("postgres: revision not found", slog.String("todo_id", todoID.String()), slog.Int("revision", number))
+			return nil, domain.ErrRevisionNotFound
+		}
+		r.logger.Error("postgres: get revision failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	return &revision, nil
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+const shareColumns = `id, todo_id, project_id, user_id, role, invited_by, created_at`
+
+func scanShare(row pgx.Row) (entities.Share, error) {
+	var share entities.Share
+	var todoID, projectID *uuid.UUID
+	err := row.Scan(&share.ID, &todoID, &projectID, &share.UserID, &share.Role, &share.InvitedBy, &share.CreatedAt)
+	if todoID != nil {
+		share.ResourceType, share.ResourceID = entities.ShareResourceTodo, *todoID
+	} else if projectID != nil {
+		share.ResourceType, share.ResourceID = entities.ShareResourceProject, *projectID
+	}
+	return share, err
+}
+
+// shareColumn возвращает колонку shares, ссылающуюся на ресурс данного типа.
+func shareColumn(resourceType string) string {
+	if resourceType == entities.ShareResourceProject {
+		return "project_id"
+	}
+	return "todo_id"
+}
+
+func (r *PostgresRepository) SaveShare(ctx context.Context, share entities.Share) (entities.Share, error) {
+	column := shareColumn(share.ResourceType)
+	q := `INSERT INTO shares (id, ` + column + `, user_id, role, invited_by) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (` + column + `, user_id) WHERE ` + column + ` IS NOT NULL
+		DO UPDATE SET role = EXCLUDED.role, invited_by = EXCLUDED.invited_by
+		RETURNING ` + shareColumns
+
+	saved, err := scanShare(r.db(ctx).QueryRow(ctx, q, uuid.New(), share.ResourceID, share.UserID, share.Role, share.InvitedBy))
+	if err != nil {
+		r.logger.Error("postgres: save share failed", slog.String("resource_id", share.ResourceID.String()), slog.Any("error", err))
+		return entities.Share{}, err
+	}
+
+	r.logger.Info("postgres: share saved", slog.String("share_id", saved.ID.String()), slog.String("role", saved.Role))
+	return saved, nil
+}
+
+func (r *PostgresRepository) GetShare(ctx context.Context, resourceType string, resourceID, userID uuid.UUID) (*entities.Share, error) {
+	q := `SELECT ` + shareColumns + ` FROM shares WHERE ` + shareColumn(resourceType) + ` = $1 AND user_id = $2`
+
+	share, err := scanShare(r.db(ctx).QueryRow(ctx, q, resourceID, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrShareNotFound
+		}
+		r.logger.Error("postgres: get share failed", slog.String("resource_id", resourceID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	return &share, nil
+}
+
+func (r *PostgresRepository) GetSharesByResource(ctx context.Context, resourceType string, resourceID uuid.UUID) ([]entities.Share, error) {
+	q := `SELECT ` + shareColumns + ` FROM shares WHERE ` + shareColumn(resourceType) + ` = $1 ORDER BY created_at, id`
+	return r.queryShares(ctx, q, resourceID)
+}
+
+func (r *PostgresRepository) GetSharesByUserID(ctx context.Context, userID uuid.UUID) ([]entities.Share, error) {
+	const q = `SELECT ` + shareColumns + ` FROM shares WHERE user_id = $1 ORDER BY created_at DESC, id`
+	return r.queryShares(ctx, q, userID)
+}
+
+func (r *PostgresRepository) queryShares(ctx context.Context, q string, arg uuid.UUID) ([]entities.Share, error) {
+	rows, err := r.db(ctx).Query(ctx, q, arg)
+	if err != nil {
+		r.logger.Error("postgres: list shares failed", slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := make([]entities.Share, 0)
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			r.logger.Error("postgres: scan share failed", slog.Any("error", err))
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: rows iteration failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+func (r *PostgresRepository) DeleteShare(ctx context.Context, resourceType string, resourceID, userID uuid.UUID) error {
+	q := `DELETE FROM shares WHERE ` + shareColumn(resourceType) + ` = $1 AND user_id = $2`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, resourceID, userID)
+	if err != nil {
+		r.logger.Error("postgres: delete share failed", slog.String("resource_id", resourceID.String()), slog.Any("error", err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return domain.ErrShareNotFound
+	}
+
+	r.logger.Info("postgres: share deleted", slog.String("resource_id", resourceID.String()), slog.String("user_id", userID.String()))
+	return nil
+}
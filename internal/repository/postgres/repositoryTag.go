@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+const tagColumns = `id, workspace_id, user_id, name, color, created_at`
+
+func scanTag(row pgx.Row) (entities.Tag, error) {
+	var tag entities.Tag
+	err := row.Scan(&tag.ID, &tag.WorkspaceID, &tag.UserID, &tag.Name, &tag.Color, &tag.CreatedAt)
+	return tag, err
+}
+
+func (r *PostgresRepository) CreateTag(ctx context.Context, tag entities.Tag) (entities.Tag, error) {
+	const q = `INSERT INTO tags (id, workspace_id, user_id, name, color) VALUES ($1, $2, $3, $4, $5) RETURNING ` + tagColumns
+
+	created, err := scanTag(r.db(ctx).QueryRow(ctx, q, uuid.New(), tag.WorkspaceID, tag.UserID, tag.Name, tag.Color))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			r.logger.Warn("postgres: tag name already taken", slog.String("user_id", tag.UserID.String()))
+			return entities.Tag{}, domain.ErrTagExists
+		}
+		r.logger.Error("postgres: create tag failed", slog.String("user_id", tag.UserID.String()), slog.Any("error", err))
+		return entities.Tag{}, err
+	}
+
+	r.logger.Info("postgres: tag created", slog.String("tag_id", created.ID.String()))
+	return created, nil
+}
+
+func (r *PostgresRepository) GetTagByID(ctx context.Context, workspaceID, tagID uuid.UUID) (*entities.Tag, error) {
+	const q = `SELECT ` + tagColumns + ` FROM tags WHERE id = $1 AND workspace_id = $2`
+
+	tag, err := scanTag(r.db(ctx).QueryRow(ctx, q, tagID, workspaceID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			r.logger.Warn("postgres: tag not found", slog.String("tag_id", tagID.String()))
+			return nil, domain.ErrTagNotFound
+		}
+		r.logger.Error("postgres: get tag failed", slog.String("tag_id", tagID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+func (r *PostgresRepository) GetTagsByUserID(ctx context.Context, workspaceID, userID uuid.UUID) ([]entities.Tag, error) {
+	const q = `SELECT ` + tagColumns + ` FROM tags WHERE workspace_id = $1 AND user_id = $2 ORDER BY name COLLATE "C"`
+	return r.queryTags(ctx, q, workspaceID, userID)
+}
+
+func (r *PostgresRepository) GetTagsByTodoID(ctx context.Context, todoID uuid.UUID) ([]entities.Tag, error) {
+	const q = `SELECT t.id, t.workspace_id, t.user_id, t.name, t.color, t.created_at FROM tags t
+		JOIN todo_tags tt ON tt.tag_id = t.id
+		WHERE tt.todo_id = $1 ORDER BY t.name COLLATE "C"`
+	return r.queryTags(ctx, q, todoID)
+}
+
+func (r *PostgresRepository) queryTags(ctx context.Context, q string, args ...any) ([]entities.Tag, error) {
+	rows, err := r.db(ctx).Query(ctx, q, args...)
+	if err != nil {
+		r.logger.Error("postgres: list tags failed", slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]entities.Tag, 0)
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			r.logger.Error("postgres: scan tag failed", slog.Any("error", err))
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: rows iteration failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (r *PostgresRepository) UpdateTag(ctx context.Context, tag *entities.Tag) (*entities.Tag, error) {
+	const q = `UPDATE tags SET name = $1, color = $2 WHERE id = $3 AND workspace_id = $4 RETURNING ` + tagColumns
+
+	updated, err := scanTag(r.db(ctx).QueryRow(ctx, q, tag.Name, tag.Color, tag.ID, tag.WorkspaceID))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case err == pgx.ErrNoRows:
+			return nil, domain.ErrTagNotFound
+		case errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+			return nil, domain.ErrTagExists
+		}
+		r.logger.Error("postgres: update tag failed", slog.String("tag_id", tag.ID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	r.logger.Info("postgres: tag updated", slog.String("tag_id", tag.ID.String()))
+	return &updated, nil
+}
+
+func (r *PostgresRepository) DeleteTag(ctx context.Context, workspaceID, tagID uuid.UUID) error {
+	// Связи с задачами удаляются каскадом.
+	const q = `DELETE FROM tags WHERE id = $1 AND workspace_id = $2`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, tagID, workspaceID)
+	if err != nil {
+		r.logger.Error("postgres: delete tag failed", slog.String("tag_id", tagID.String()), slog.Any("error", err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return domain.ErrTagNotFound
+	}
+
+	r.logger.Info("postgres: tag deleted", slog.String("tag_id", tagID.String()))
+	return nil
+}
+
+func (r *PostgresRepository) AttachTag(ctx context.Context, todoID, tagID uuid.UUID) error {
+	const q = `INSERT INTO todo_tags (todo_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+	if _, err := r.db(ctx).Exec(ctx, q, todoID, tagID); err != nil {
+		r.logger.Error("postgres: attach tag failed", slog.String("todo_id", todoID.String()), slog.String("tag_id", tagID.String()), slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresRepository) DetachTag(ctx context.Context, todoID, tagID uuid.UUID) error {
+	const q = `DELETE FROM todo_tags WHERE todo_id = $1 AND tag_id = $2`
+
+	if _, err := r.db(ctx).Exec(ctx, q, todoID, tagID); err != nil {
+		r.logger.Error("postgres: detach tag failed", slog.String("todo_id", todoID.String()), slog.String("tag_id", tagID.String()), slog.Any("error", err))
+		return err
+	}
+	return nil
+}
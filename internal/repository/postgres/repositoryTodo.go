@@ -2,21 +2,34 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/polzovatel/todo-learning/internal/domain"
 	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/search"
 )
 
-func (r *PostgresRepository) CreateTodo(ctx context.Context, userID uuid.UUID, title, description string) (entities.Todo, error) {
+const todoColumns = `id, workspace_id, user_id, title, description, completed, created_at, updated_at, due_at, remind_at, priority, position, project_id, parent_id, recurrence, deleted_at, version`
+
+// todoFields возвращает поля задачи для Scan в порядке todoColumns.
+func todoFields(todo *entities.Todo) []any {
+	return []any{&todo.ID, &todo.WorkspaceID, &todo.UserID, &todo.Title, &todo.Description, &todo.Completed, &todo.CreatedAt, &todo.UpdatedAt, &todo.DueAt, &todo.RemindAt, &todo.Priority, &todo.Position, &todo.ProjectID, &todo.ParentID, &todo.Recurrence, &todo.DeletedAt, &todo.Version}
+}
+
+func (r *PostgresRepository) CreateTodo(ctx context.Context, todo entities.Todo) (entities.Todo, error) {
 	todoID := uuid.New()
-	const q = `INSERT INTO todos (id, user_id, title, description) VALUES ($1, $2, $3, $4) RETURNING id, user_id, title, description, completed, created_at, updated_at`
+	userID := todo.UserID
+	const q = `INSERT INTO todos (id, workspace_id, user_id, title, description, completed, due_at, remind_at, priority, position, project_id, parent_id, recurrence)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING ` + todoColumns
 
-	var todo entities.Todo
-	if err := r.pool.QueryRow(ctx, q, todoID, userID, title, description).
-		Scan(&todo.ID, &todo.UserID, &todo.Title, &todo.Description, &todo.Completed, &todo.CreatedAt, &todo.UpdatedAt); err != nil {
+	if err := r.db(ctx).QueryRow(ctx, q, todoID, todo.WorkspaceID, userID, todo.Title, todo.Description, todo.Completed, todo.DueAt, todo.RemindAt,
+		todo.Priority, todo.Position, todo.ProjectID, todo.ParentID, todo.Recurrence).
+		Scan(todoFields(&todo)...); err != nil {
 		r.logger.Error("postgres: create todo failed", slog.String("user_id", userID.String()), slog.Any("error", err))
 		return entities.Todo{}, err
 	}
@@ -25,12 +38,12 @@ func (r *PostgresRepository) CreateTodo(ctx context.Context, userID uuid.UUID, t
 	return todo, nil
 }
 
-func (r *PostgresRepository) GetTodoByID(ctx context.Context, todoID uuid.UUID) (*entities.Todo, error) {
-	const q = `SELECT id, user_id, title, description, completed, created_at, updated_at FROM todos WHERE id = $1`
+func (r *PostgresRepository) GetTodoByID(ctx context.Context, workspaceID, todoID uuid.UUID) (*entities.Todo, error) {
+	const q = `SELECT ` + todoColumns + ` FROM todos WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL`
 
 	var todo entities.Todo
-	if err := r.pool.QueryRow(ctx, q, todoID).
-		Scan(&todo.ID, &todo.UserID, &todo.Title, &todo.Description, &todo.Completed, &todo.CreatedAt, &todo.UpdatedAt); err != nil {
+	if err := r.db(ctx).QueryRow(ctx, q, todoID, workspaceID).
+		Scan(todoFields(&todo)...); err != nil {
 		if err == pgx.ErrNoRows {
 			r.logger.Warn("postgres: todo not found", slog.String("todo_id", todoID.String()))
 			return nil, domain.ErrTodoNotFound
@@ -42,10 +55,25 @@ func (r *PostgresRepository) GetTodoByID(ctx context.Context, todoID uuid.UUID)
 	return &todo, nil
 }
 
-func (r *PostgresRepository) GetTodoByUserID(ctx context.Context, userID uuid.UUID) ([]entities.Todo, error) {
-	const q = `SELECT id, user_id, title, description, completed, created_at, updated_at FROM todos WHERE user_id = $1`
+func (r *PostgresRepository) GetTodoWorkspaceID(ctx context.Context, todoID uuid.UUID) (uuid.UUID, error) {
+	const q = `SELECT workspace_id FROM todos WHERE id = $1 AND deleted_at IS NULL`
+
+	var workspaceID uuid.UUID
+	if err := r.db(ctx).QueryRow(ctx, q, todoID).Scan(&workspaceID); err != nil {
+		if err == pgx.ErrNoRows {
+			return uuid.Nil, domain.ErrTodoNotFound
+		}
+		r.logger.Error("postgres: get todo workspace failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return uuid.Nil, err
+	}
+
+	return workspaceID, nil
+}
+
+func (r *PostgresRepository) GetTodoByUserID(ctx context.Context, workspaceID, userID uuid.UUID) ([]entities.Todo, error) {
+	const q = `SELECT ` + todoColumns + ` FROM todos WHERE workspace_id = $1 AND user_id = $2 AND deleted_at IS NULL ORDER BY position COLLATE "C", id`
 
-	rows, err := r.pool.Query(ctx, q, userID)
+	rows, err := r.db(ctx).Query(ctx, q, workspaceID, userID)
 	if err != nil {
 		r.logger.Error("postgres: list todos failed", slog.String("user_id", userID.String()), slog.Any("error", err))
 		return nil, err
@@ -55,7 +83,7 @@ func (r *PostgresRepository) GetTodoByUserID(ctx context.Context, userID uuid.UU
 	todos := make([]entities.Todo, 0)
 	for rows.Next() {
 		var todo entities.Todo
-		if err := rows.Scan(&todo.ID, &todo.UserID, &todo.Title, &todo.Description, &todo.Completed, &todo.CreatedAt, &todo.UpdatedAt); err != nil {
+		if err := rows.Scan(todoFields(&todo)...); err != nil {
 			r.logger.Error("postgres: scan todo failed", slog.Any("error", err))
 			return nil, err
 		}
@@ -69,14 +97,44 @@ func (r *PostgresRepository) GetTodoByUserID(ctx context.Context, userID uuid.UU
 	return todos, nil
 }
 
+func (r *PostgresRepository) GetTodoChildren(ctx context.Context, workspaceID, parentID uuid.UUID) ([]entities.Todo, error) {
+	const q = `SELECT ` + todoColumns + ` FROM todos WHERE workspace_id = $1 AND parent_id = $2 AND deleted_at IS NULL ORDER BY position COLLATE "C", id`
+
+	rows, err := r.db(ctx).Query(ctx, q, workspaceID, parentID)
+	if err != nil {
+		r.logger.Error("postgres: list subtasks failed", slog.String("todo_id", parentID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	children := make([]entities.Todo, 0)
+	for rows.Next() {
+		var todo entities.Todo
+		if err := rows.Scan(todoFields(&todo)...); err != nil {
+			r.logger.Error("postgres: scan todo failed", slog.Any("error", err))
+			return nil, err
+		}
+		children = append(children, todo)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: rows iteration failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return children, nil
+}
+
 func (r *PostgresRepository) UpdateTodo(ctx context.Context, todo *entities.Todo) (*entities.Todo, error) {
-	const q = `UPDATE todos SET user_id = $1, title = $2, description = $3, completed = $4, updated_at = NOW() WHERE id = $5 RETURNING id, user_id, title, description, completed, created_at, updated_at`
+	const q = `UPDATE todos SET user_id = $1, title = $2, description = $3, completed = $4, due_at = $5, remind_at = $6,
+		priority = $7, position = $8, project_id = $9, parent_id = $10, recurrence = $11,
+		updated_at = NOW(), version = version + 1
+		WHERE id = $12 AND workspace_id = $13 AND deleted_at IS NULL AND version = $14 RETURNING ` + todoColumns
 
-	if err := r.pool.QueryRow(ctx, q, todo.UserID, todo.Title, todo.Description, todo.Completed, todo.ID).
-		Scan(&todo.ID, &todo.UserID, &todo.Title, &todo.Description, &todo.Completed, &todo.CreatedAt, &todo.UpdatedAt); err != nil {
+	if err := r.db(ctx).QueryRow(ctx, q, todo.UserID, todo.Title, todo.Description, todo.Completed, todo.DueAt, todo.RemindAt,
+		todo.Priority, todo.Position, todo.ProjectID, todo.ParentID, todo.Recurrence, todo.ID, todo.WorkspaceID, todo.Version).
+		Scan(todoFields(todo)...); err != nil {
 		if err == pgx.ErrNoRows {
-			r.logger.Warn("postgres: update todo target not found", slog.String("todo_id", todo.ID.String()))
-			return nil, domain.ErrTodoNotFound
+			return nil, r.todoWriteMissed(ctx, todo.WorkspaceID, todo.ID, todo.Version)
 		}
 		r.logger.Error("postgres: update todo failed", slog.String("todo_id", todo.ID.String()), slog.Any("error", err))
 		return nil, err
@@ -86,20 +144,258 @@ func (r *PostgresRepository) UpdateTodo(ctx context.Context, todo *entities.Todo
 	return todo, nil
 }
 
-func (r *PostgresRepository) DeleteTodo(ctx context.Context, todoID uuid.UUID) error {
-	const q = `DELETE FROM todos WHERE id = $1`
+// todoWriteMissed объясняет, почему UpdateTodo или DeleteTodo не нашли строку: задачи нет
+// или её версия уже не та, что была прочитана.
+func (r *PostgresRepository) todoWriteMissed(ctx context.Context, workspaceID, todoID uuid.UUID, version int) error {
+	const q = `SELECT EXISTS (SELECT 1 FROM todos WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL)`
 
-	cmdTag, err := r.pool.Exec(ctx, q, todoID)
+	var exists bool
+	if err := r.db(ctx).QueryRow(ctx, q, todoID, workspaceID).Scan(&exists); err != nil {
+		r.logger.Error("postgres: check todo existence failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return err
+	}
+	if exists {
+		r.logger.Warn("postgres: todo version changed before write", slog.String("todo_id", todoID.String()), slog.Int("version", version))
+		return domain.ErrVersionMismatch
+	}
+	r.logger.Warn("postgres: todo write target not found", slog.String("todo_id", todoID.String()))
+	return domain.ErrTodoNotFound
+}
+
+func (r *PostgresRepository) DeleteTodo(ctx context.Context, workspaceID, todoID uuid.UUID, version int) error {
+	// Подзадачи, удалённые раньше, сохраняют своё время удаления.
+	const q = `WITH RECURSIVE subtree AS (
+			SELECT id FROM todos WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL AND version = $3
+			UNION
+			SELECT t.id FROM todos t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at IS NULL
+		)
+		UPDATE todos SET deleted_at = NOW() WHERE id IN (SELECT id FROM subtree)`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, todoID, workspaceID, version)
 	if err != nil {
-		r.logger.Error("postgres: delete todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		r.logger.Error("postgres: trash todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
 		return err
 	}
 
 	if cmdTag.RowsAffected() == 0 {
-		r.logger.Warn("postgres: delete todo target not found", slog.String("todo_id", todoID.String()))
-		return domain.ErrTodoNotFound
+		return r.todoWriteMissed(ctx, workspaceID, todoID, version)
 	}
 
-	r.logger.Info("postgres: todo deleted", slog.String("todo_id", todoID.String()))
+	r.logger.Info("postgres: todo moved to trash", slog.String("todo_id", todoID.String()), slog.Int64("todos", cmdTag.RowsAffected()))
 	return nil
 }
+
+func (r *PostgresRepository) LastTodoPosition(ctx context.Context, workspaceID, userID uuid.UUID) (string, error) {
+	const q = `SELECT COALESCE(MAX(position COLLATE "C"), '') FROM todos WHERE workspace_id = $1 AND user_id = $2 AND deleted_at IS NULL`
+
+	var position string
+	if err := r.db(ctx).QueryRow(ctx, q, workspaceID, userID).Scan(&position); err != nil {
+		r.logger.Error("postgres: last todo position failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return "", err
+	}
+	return position, nil
+}
+
+func (r *PostgresRepository) AdjacentTodoPosition(ctx context.Context, workspaceID uuid.UUID, anchor *entities.Todo, after bool, exclude uuid.UUID) (string, error) {
+	q := `SELECT position FROM todos WHERE workspace_id = $5 AND user_id = $1 AND id <> $3 AND id <> $4 AND deleted_at IS NULL AND position COLLATE "C" <= $2 ORDER BY position COLLATE "C" DESC LIMIT 1`
+	if after {
+		q = `SELECT position FROM todos WHERE workspace_id = $5 AND user_id = $1 AND id <> $3 AND id <> $4 AND deleted_at IS NULL AND position COLLATE "C" >= $2 ORDER BY position COLLATE "C" LIMIT 1`
+	}
+
+	var adjacent string
+	if err := r.db(ctx).QueryRow(ctx, q, anchor.UserID, anchor.Position, anchor.ID, exclude, workspaceID).Scan(&adjacent); err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+		r.logger.Error("postgres: adjacent todo position failed", slog.String("user_id", anchor.UserID.String()), slog.Any("error", err))
+		return "", err
+	}
+	return adjacent, nil
+}
+
+func (r *PostgresRepository) RebalanceTodoPositions(ctx context.Context, workspaceID, userID uuid.UUID) ([]uuid.UUID, error) {
+	// Ключи те же, что у ranking.Nth; задачи в корзине тоже перенумеровываются, чтобы
+	// после восстановления встать на прежнее место.
+	const q = `UPDATE todos t SET position = chr(ascii('a') + r.width - 1) || lpad(r.rn::text, r.width, '0'), version = t.version + 1
+		FROM (
+			SELECT id, row_number() OVER (ORDER BY position COLLATE "C", created_at, id) AS rn,
+				greatest(6, length((count(*) OVER ())::text)) AS width
+			FROM todos WHERE workspace_id = $1 AND user_id = $2
+		) r
+		WHERE t.id = r.id
+		RETURNING t.id`
+
+	rows, err := r.db(ctx).Query(ctx, q, workspaceID, userID)
+	if err != nil {
+		r.logger.Error("postgres: rebalance todo positions failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		r.logger.Error("postgres: rebalance todo positions failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	r.logger.Info("postgres: todo positions rebalanced", slog.String("user_id", userID.String()), slog.Int("count", len(ids)))
+	return ids, nil
+}
+
+func (r *PostgresRepository) ListTodos(ctx context.Context, filter models.TodoListFilter) ([]entities.Todo, error) {
+	var conds []string
+	var args []any
+	add := func(cond string, value any) {
+		args = append(args, value)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	conds = append(conds, "deleted_at IS NULL")
+	add("workspace_id = $%d", filter.WorkspaceID)
+	if filter.UserID != uuid.Nil {
+		add("user_id = $%d", filter.UserID)
+	}
+
+	if filter.Completed != nil {
+		add("completed = $%d", *filter.Completed)
+	}
+	if filter.Priority != "" {
+		add("priority = $%d", filter.Priority)
+	}
+	if filter.ProjectID != nil {
+		add("project_id = $%d", *filter.ProjectID)
+	}
+	if len(filter.Tags) > 0 {
+		const tagged = `FROM todo_tags tt JOIN tags t ON t.id = tt.tag_id
+			WHERE tt.todo_id = todos.id AND t.name = ANY($%[1]d)`
+		if filter.TagsMatchAll {
+			// Имена в фильтре без повторов. Считаются имена, а не метки: на общей задаче
+			// могут висеть одноимённые метки разных пользователей.
+			add("(SELECT COUNT(DISTINCT t.name) "+tagged+") = cardinality($%[1]d::text[])", filter.Tags)
+		} else {
+			add("EXISTS (SELECT 1 "+tagged+")", filter.Tags)
+		}
+	}
+	if filter.CreatedAfter != nil {
+		add("created_at > $%d", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		add("created_at < $%d", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		add("updated_at > $%d", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		add("updated_at < $%d", *filter.UpdatedBefore)
+	}
+	if filter.DueFrom != nil {
+		add("due_at >= $%d", *filter.DueFrom)
+	}
+	if filter.DueBefore != nil {
+		add("due_at < $%d", *filter.DueBefore)
+	}
+
+	// Заголовки сравниваем побайтно (COLLATE "C"), чтобы порядок совпадал с in-memory хранилищем.
+	column := "created_at"
+	var cursorValue any
+	if filter.After != nil {
+		cursorValue = filter.After.Time
+	}
+	switch filter.Sort {
+	case models.TodoSortUpdatedAt:
+		column = "updated_at"
+	case models.TodoSortTitle:
+		column = `title COLLATE "C"`
+		if filter.After != nil {
+			cursorValue = filter.After.Title
+		}
+	case models.TodoSortPosition:
+		column = `position COLLATE "C"`
+		if filter.After != nil {
+			cursorValue = filter.After.Position
+		}
+	}
+	direction, cmp := "ASC", ">"
+	if filter.Order == models.SortDesc {
+		direction, cmp = "DESC", "<"
+	}
+	if filter.After != nil {
+		args = append(args, cursorValue, filter.After.ID)
+		conds = append(conds, fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, cmp, len(args)-1, len(args)))
+	}
+
+	q := `SELECT ` + todoColumns + ` FROM todos WHERE ` +
+		strings.Join(conds, " AND ") +
+		fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction)
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		q += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db(ctx).Query(ctx, q, args...)
+	if err != nil {
+		r.logger.Error("postgres: list todos page failed", slog.String("user_id", filter.UserID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := make([]entities.Todo, 0)
+	for rows.Next() {
+		var todo entities.Todo
+		if err := rows.Scan(todoFields(&todo)...); err != nil {
+			r.logger.Error("postgres: scan todo failed", slog.Any("error", err))
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: rows iteration failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+func (r *PostgresRepository) SearchTodos(ctx context.Context, workspaceID, userID uuid.UUID, query string, limit int) ([]models.TodoSearchResult, error) {
+	const q = `SELECT ` + todoColumns + `,
+		ts_rank(search_vector, query),
+		ts_headline('simple', translate(title, $5, ''), query, $6),
+		ts_headline('simple', translate(description, $5, ''), query, $7)
+		FROM todos, plainto_tsquery('simple', $2) AS query
+		WHERE workspace_id = $4 AND user_id = $1 AND deleted_at IS NULL AND search_vector @@ query
+		ORDER BY ts_rank(search_vector, query) DESC, created_at DESC, id
+		LIMIT $3`
+
+	// Совпадения размечаются не-HTML метками (и вырезаются из исходного текста),
+	// чтобы затем экранировать заголовок и фрагмент целиком.
+	marks := search.MarkStart + search.MarkStop
+	sel := "StartSel=" + search.MarkStart + ", StopSel=" + search.MarkStop
+	titleOpts := sel + ", HighlightAll=true"
+	fragmentOpts := fmt.Sprintf("%s, MaxWords=%d, MinWords=10", sel, search.FragmentWords)
+
+	rows, err := r.db(ctx).Query(ctx, q, userID, query, limit, workspaceID, marks, titleOpts, fragmentOpts)
+	if err != nil {
+		r.logger.Error("postgres: search todos failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.TodoSearchResult, 0)
+	for rows.Next() {
+		var res models.TodoSearchResult
+		var rank float32
+		fields := append(todoFields(&res.Todo), &rank, &res.Highlights.Title, &res.Highlights.Description)
+		if err := rows.Scan(fields...); err != nil {
+			r.logger.Error("postgres: scan search result failed", slog.Any("error", err))
+			return nil, err
+		}
+		res.Rank = float64(rank)
+		res.Highlights.Title = search.Markup(res.Highlights.Title)
+		res.Highlights.Description = search.Markup(res.Highlights.Description)
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: rows iteration failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return results, nil
+}
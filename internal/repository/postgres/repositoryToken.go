@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+)
+
+func (r *PostgresRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	const q = `INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, jti, expiresAt)
+	if err != nil {
+		r.logger.Error("postgres: revoke token failed", slog.String("jti", jti), slog.Any("error", err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		r.logger.Warn("postgres: token already revoked", slog.String("jti", jti))
+		return domain.ErrTokenRevoked
+	}
+
+	// Заодно чистим записи, срок действия которых уже истёк.
+	const purge = `DELETE FROM revoked_tokens WHERE expires_at < NOW()`
+	if _, err := r.db(ctx).Exec(ctx, purge); err != nil {
+		r.logger.Warn("postgres: purge revoked tokens failed", slog.Any("error", err))
+	}
+
+	r.logger.Info("postgres: token revoked", slog.String("jti", jti))
+	return nil
+}
+
+func (r *PostgresRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	const q = `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+
+	var revoked bool
+	if err := r.db(ctx).QueryRow(ctx, q, jti).Scan(&revoked); err != nil {
+		r.logger.Error("postgres: check revoked token failed", slog.String("jti", jti), slog.Any("error", err))
+		return false, err
+	}
+
+	return revoked, nil
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+func (r *PostgresRepository) GetTrashedTodo(ctx context.Context, workspaceID, todoID uuid.UUID) (*entities.Todo, error) {
+	const q = `SELECT ` + todoColumns + ` FROM todos WHERE id = $1 AND workspace_id = $2`
+
+	var todo entities.Todo
+	if err := r.db(ctx).QueryRow(ctx, q, todoID, workspaceID).
+		Scan(todoFields(&todo)...); err != nil {
+		if err == pgx.ErrNoRows {
+			r.logger.Warn("postgres: trashed todo not found", slog.String("todo_id", todoID.String()))
+			return nil, domain.ErrTodoNotFound
+		}
+		r.logger.Error("postgres: get trashed todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	if todo.DeletedAt == nil {
+		return nil, domain.ErrTodoNotTrashed
+	}
+
+	return &todo, nil
+}
+
+func (r *PostgresRepository) GetTrashedTodos(ctx context.Context, workspaceID, userID uuid.UUID) ([]entities.Todo, error) {
+	const q = `SELECT ` + todoColumns + ` FROM todos WHERE workspace_id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id`
+
+	rows, err := r.db(ctx).Query(ctx, q, workspaceID, userID)
+	if err != nil {
+		r.logger.Error("postgres: list trash failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := make([]entities.Todo, 0)
+	for rows.Next() {
+		var todo entities.Todo
+		if err := rows.Scan(todoFields(&todo)...); err != nil {
+			r.logger.Error("postgres: scan todo failed", slog.Any("error", err))
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: rows iteration failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+func (r *PostgresRepository) RestoreTodo(ctx context.Context, workspaceID, todoID uuid.UUID) (*entities.Todo, error) {
+	// Вместе с задачей возвращаются только подзадачи с тем же временем удаления.
+	const q = `WITH RECURSIVE subtree AS (
+			SELECT id, deleted_at FROM todos WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NOT NULL
+			UNION
+			SELECT t.id, t.deleted_at FROM todos t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at = s.deleted_at
+		)
+		UPDATE todos SET deleted_at = NULL, updated_at = NOW(), version = version + 1 WHERE id IN (SELECT id FROM subtree) RETURNING ` + todoColumns
+
+	rows, err := r.db(ctx).Query(ctx, q, todoID, workspaceID)
+	if err != nil {
+		r.logger.Error("postgres: restore todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var restored *entities.Todo
+	for rows.Next() {
+		var todo entities.Todo
+		if err := rows.Scan(todoFields(&todo)...); err != nil {
+			r.logger.Error("postgres: scan todo failed", slog.Any("error", err))
+			return nil, err
+		}
+		if todo.ID == todoID {
+			restored = &todo
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: restore todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	if restored == nil {
+		r.logger.Warn("postgres: trashed todo not found for restore", slog.String("todo_id", todoID.String()))
+		return nil, domain.ErrTodoNotFound
+	}
+
+	r.logger.Info("postgres: todo restored", slog.String("todo_id", todoID.String()))
+	return restored, nil
+}
+
+func (r *PostgresRepository) PurgeTodo(ctx context.Context, workspaceID, todoID uuid.UUID) error {
+	// Подзадачи удаляет ON DELETE CASCADE по parent_id.
+	const q = `DELETE FROM todos WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NOT NULL`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, todoID, workspaceID)
+	if err != nil {
+		r.logger.Error("postgres: purge todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		r.logger.Warn("postgres: trashed todo not found for purge", slog.String("todo_id", todoID.String()))
+		return domain.ErrTodoNotFound
+	}
+
+	r.logger.Info("postgres: todo purged", slog.String("todo_id", todoID.String()))
+	return nil
+}
+
+func (r *PostgresRepository) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	const q = `DELETE FROM todos WHERE deleted_at < $1`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, before)
+	if err != nil {
+		r.logger.Error("postgres: purge trash failed", slog.Any("error", err))
+		return 0, err
+	}
+
+	if cmdTag.RowsAffected() > 0 {
+		r.logger.Info("postgres: trash purged", slog.Int64("todos", cmdTag.RowsAffected()))
+	}
+	return int(cmdTag.RowsAffected()), nil
+}
@@ -25,25 +25,46 @@ func NewPostgresRepository(pool *pgxpool.Pool, logger *slog.Logger) *PostgresRep
 
 func (r *PostgresRepository) CreateUser(ctx context.Context, email, passwordHash string) (entities.User, error) {
 	userID := uuid.New()
-	const q = `INSERT INTO users (id, email, password_hash) VALUES ($1, $2, $3) RETURNING id, email, password_hash, created_at`
+	const q = `INSERT INTO users (id, email, password_hash) VALUES ($1, $2, $3) RETURNING id, email, password_hash, created_at, timezone`
+
+	tx, err := r.db(ctx).Begin(ctx)
+	if err != nil {
+		r.logger.Error("postgres: begin create user failed", slog.Any("error", err))
+		return entities.User{}, err
+	}
+	defer tx.Rollback(ctx)
 
 	var user entities.User
-	if err := r.pool.QueryRow(ctx, q, userID, email, passwordHash).
-		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt); err != nil {
+	if err := tx.QueryRow(ctx, q, userID, email, passwordHash).
+		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.Timezone); err != nil {
 		r.logger.Error("postgres: create user failed", slog.String("email", email), slog.Any("error", err))
 		return entities.User{}, err
 	}
 
+	// Личное пространство создаётся вместе с пользователем и имеет его id.
+	if _, err := tx.Exec(ctx, `INSERT INTO workspaces (id, name, personal) VALUES ($1, $2, TRUE)`, userID, entities.PersonalWorkspaceName); err != nil {
+		r.logger.Error("postgres: create personal workspace failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return entities.User{}, err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $1, $2)`, userID, entities.WorkspaceRoleOwner); err != nil {
+		r.logger.Error("postgres: create personal membership failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return entities.User{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("postgres: commit create user failed", slog.Any("error", err))
+		return entities.User{}, err
+	}
+
 	r.logger.Info("postgres: user created", slog.String("user_id", user.ID.String()))
 	return user, nil
 }
 
 func (r *PostgresRepository) GetUserByEmail(ctx context.Context, email string) (*entities.User, error) {
-	const q = `SELECT id, email, password_hash, created_at FROM users WHERE email = $1`
+	const q = `SELECT id, email, password_hash, created_at, timezone FROM users WHERE email = $1`
 
 	var user entities.User
-	if err := r.pool.QueryRow(ctx, q, email).
-		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt); err != nil {
+	if err := r.db(ctx).QueryRow(ctx, q, email).
+		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.Timezone); err != nil {
 		if err == pgx.ErrNoRows {
 			r.logger.Warn("postgres: user not found by email", slog.String("email", email))
 			return nil, domain.ErrUserNotFound
@@ -56,11 +77,11 @@ func (r *PostgresRepository) GetUserByEmail(ctx context.Context, email string) (
 }
 
 func (r *PostgresRepository) GetUserById(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
-	const q = `SELECT id, email, password_hash, created_at FROM users WHERE id = $1`
+	const q = `SELECT id, email, password_hash, created_at, timezone FROM users WHERE id = $1`
 
 	var user entities.User
-	if err := r.pool.QueryRow(ctx, q, userID).
-		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt); err != nil {
+	if err := r.db(ctx).QueryRow(ctx, q, userID).
+		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.Timezone); err != nil {
 		if err == pgx.ErrNoRows {
 			r.logger.Warn("postgres: user not found by id", slog.String("user_id", userID.String()))
 			return nil, domain.ErrUserNotFound
@@ -73,9 +94,9 @@ func (r *PostgresRepository) GetUserById(ctx context.Context, userID uuid.UUID)
 }
 
 func (r *PostgresRepository) GetAllUsers(ctx context.Context) ([]entities.User, error) {
-	const q = `SELECT id, email, password_hash, created_at FROM users`
+	const q = `SELECT id, email, password_hash, created_at, timezone FROM users`
 
-	rows, err := r.pool.Query(ctx, q)
+	rows, err := r.db(ctx).Query(ctx, q)
 	if err != nil {
 		r.logger.Error("postgres: list users failed", slog.Any("error", err))
 		return nil, err
@@ -85,7 +106,7 @@ func (r *PostgresRepository) GetAllUsers(ctx context.Context) ([]entities.User,
 	users := make([]entities.User, 0)
 	for rows.Next() {
 		var user entities.User
-		if err := rows.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt); err != nil {
+		if err := rows.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.Timezone); err != nil {
 			r.logger.Error("postgres: scan user failed", slog.Any("error", err))
 			return nil, err
 		}
@@ -101,10 +122,10 @@ func (r *PostgresRepository) GetAllUsers(ctx context.Context) ([]entities.User,
 }
 
 func (r *PostgresRepository) UpdateUser(ctx context.Context, user *entities.User) (*entities.User, error) {
-	const q = `UPDATE users SET email = $1, password_hash = $2 WHERE id = $3 RETURNING id, email, password_hash, created_at`
+	const q = `UPDATE users SET email = $1, password_hash = $2, timezone = $3 WHERE id = $4 RETURNING id, email, password_hash, created_at, timezone`
 
-	if err := r.pool.QueryRow(ctx, q, user.Email, user.PasswordHash, user.ID).
-		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt); err != nil {
+	if err := r.db(ctx).QueryRow(ctx, q, user.Email, user.PasswordHash, user.Timezone, user.ID).
+		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.Timezone); err != nil {
 		if err == pgx.ErrNoRows {
 			r.logger.Warn("postgres: update user target not found", slog.String("user_id", user.ID.String()))
 			return nil, domain.ErrUserNotFound
@@ -120,7 +141,19 @@ func (r *PostgresRepository) UpdateUser(ctx context.Context, user *entities.User
 func (r *PostgresRepository) DeleteUser(ctx context.Context, userID uuid.UUID) error {
 	const q = `DELETE FROM users WHERE id = $1`
 
-	cmdTag, err := r.pool.Exec(ctx, q, userID)
+	tx, err := r.db(ctx).Begin(ctx)
+	if err != nil {
+		r.logger.Error("postgres: begin delete user failed", slog.Any("error", err))
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Личное пространство уходит вместе с владельцем, командные остаются остальным участникам.
+	if _, err := tx.Exec(ctx, `DELETE FROM workspaces WHERE id = $1 AND personal`, userID); err != nil {
+		r.logger.Error("postgres: delete personal workspace failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return err
+	}
+	cmdTag, err := tx.Exec(ctx, q, userID)
 	if err != nil {
 		r.logger.Error("postgres: delete user failed", slog.String("user_id", userID.String()), slog.Any("error", err))
 		return err
@@ -130,6 +163,10 @@ func (r *PostgresRepository) DeleteUser(ctx context.Context, userID uuid.UUID) e
 		r.logger.Warn("postgres: delete user target not found", slog.String("user_id", userID.String()))
 		return domain.ErrUserNotFound
 	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("postgres: commit delete user failed", slog.Any("error", err))
+		return err
+	}
 
 	r.logger.Info("postgres: user deleted", slog.String("user_id", userID.String()))
 	return nil
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+const uniqueViolation = "23505"
+
+func (r *PostgresRepository) SaveWebAuthnChallenge(ctx context.Context, challenge entities.WebAuthnChallenge) error {
+	const purge = `DELETE FROM webauthn_challenges WHERE expires_at < NOW()`
+	if _, err := r.db(ctx).Exec(ctx, purge); err != nil {
+		r.logger.Warn("postgres: purge webauthn challenges failed", slog.Any("error", err))
+	}
+
+	const q = `INSERT INTO webauthn_challenges (challenge, user_id, ceremony, expires_at) VALUES ($1, $2, $3, $4)`
+	if _, err := r.db(ctx).Exec(ctx, q, challenge.Challenge, challenge.UserID, challenge.Ceremony, challenge.ExpiresAt); err != nil {
+		r.logger.Error("postgres: save webauthn challenge failed", slog.Any("error", err))
+		return err
+	}
+
+	r.logger.Info("postgres: webauthn challenge saved", slog.String("ceremony", challenge.Ceremony))
+	return nil
+}
+
+func (r *PostgresRepository) ConsumeWebAuthnChallenge(ctx context.Context, challenge string) (*entities.WebAuthnChallenge, error) {
+	const q = `DELETE FROM webauthn_challenges WHERE challenge = $1 AND expires_at > NOW() RETURNING challenge, user_id, ceremony, expires_at`
+
+	var ch entities.WebAuthnChallenge
+	if err := r.db(ctx).QueryRow(ctx, q, challenge).Scan(&ch.Challenge, &ch.UserID, &ch.Ceremony, &ch.ExpiresAt); err != nil {
+		if err == pgx.ErrNoRows {
+			r.logger.Warn("postgres: webauthn challenge not found")
+			return nil, domain.ErrChallengeNotFound
+		}
+		r.logger.Error("postgres: consume webauthn challenge failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &ch, nil
+}
+
+func (r *PostgresRepository) CreateWebAuthnCredential(ctx context.Context, userID uuid.UUID, credentialID, publicKey []byte, signCount uint32) (entities.WebAuthnCredential, error) {
+	id := uuid.New()
+	const q = `INSERT INTO webauthn_credentials (id, user_id, credential_id, public_key, sign_count) VALUES ($1, $2, $3, $4, $5) RETURNING id, user_id, credential_id, public_key, sign_count, created_at, last_used_at`
+
+	cred, err := scanWebAuthnCredential(r.db(ctx).QueryRow(ctx, q, id, userID, credentialID, publicKey, int64(signCount)))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			r.logger.Warn("postgres: webauthn credential already exists", slog.String("user_id", userID.String()))
+			return entities.WebAuthnCredential{}, domain.ErrCredentialExists
+		}
+		r.logger.Error("postgres: create webauthn credential failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return entities.WebAuthnCredential{}, err
+	}
+
+	r.logger.Info("postgres: webauthn credential created", slog.String("user_id", userID.String()))
+	return *cred, nil
+}
+
+func (r *PostgresRepository) GetWebAuthnCredential(ctx context.Context, credentialID []byte) (*entities.WebAuthnCredential, error) {
+	const q = `SELECT id, user_id, credential_id, public_key, sign_count, created_at, last_used_at FROM webauthn_credentials WHERE credential_id = $1`
+
+	cred, err := scanWebAuthnCredential(r.db(ctx).QueryRow(ctx, q, credentialID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			r.logger.Warn("postgres: webauthn credential not found")
+			return nil, domain.ErrCredentialNotFound
+		}
+		r.logger.Error("postgres: get webauthn credential failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return cred, nil
+}
+
+func (r *PostgresRepository) GetWebAuthnCredentialsByUserID(ctx context.Context, userID uuid.UUID) ([]entities.WebAuthnCredential, error) {
+	const q = `SELECT id, user_id, credential_id, public_key, sign_count, created_at, last_used_at FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := r.db(ctx).Query(ctx, q, userID)
+	if err != nil {
+		r.logger.Error("postgres: list webauthn credentials failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	creds := make([]entities.WebAuthnCredential, 0)
+	for rows.Next() {
+		cred, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			r.logger.Error("postgres: scan webauthn credential failed", slog.Any("error", err))
+			return nil, err
+		}
+		creds = append(creds, *cred)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: rows iteration failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return creds, nil
+}
+
+func (r *PostgresRepository) UpdateWebAuthnSignCount(ctx context.Context, id uuid.UUID, signCount uint32) error {
+	const q = `UPDATE webauthn_credentials SET sign_count = $1, last_used_at = NOW() WHERE id = $2`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, int64(signCount), id)
+	if err != nil {
+		r.logger.Error("postgres: update webauthn sign count failed", slog.String("credential_id", id.String()), slog.Any("error", err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		r.logger.Warn("postgres: webauthn credential not found for update", slog.String("credential_id", id.String()))
+		return domain.ErrCredentialNotFound
+	}
+
+	return nil
+}
+
+func scanWebAuthnCredential(row pgx.Row) (*entities.WebAuthnCredential, error) {
+	var cred entities.WebAuthnCredential
+	var signCount int64
+	if err := row.Scan(&cred.ID, &cred.UserID, &cred.CredentialID, &cred.PublicKey, &signCount, &cred.CreatedAt, &cred.LastUsedAt); err != nil {
+		return nil, err
+	}
+	cred.SignCount = uint32(signCount)
+	return &cred, nil
+}
@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+)
+
+const (
+	workspaceColumns = `id, name, personal, created_at`
+	memberColumns    = `workspace_id, user_id, role, created_at`
+)
+
+func scanWorkspace(row pgx.Row) (entities.Workspace, error) {
+	var workspace entities.Workspace
+	err := row.Scan(&workspace.ID, &workspace.Name, &workspace.Personal, &workspace.CreatedAt)
+	return workspace, err
+}
+
+func scanMember(row pgx.Row) (entities.WorkspaceMember, error) {
+	var member entities.WorkspaceMember
+	err := row.Scan(&member.WorkspaceID, &member.UserID, &member.Role, &member.CreatedAt)
+	return member, err
+}
+
+func (r *PostgresRepository) CreateWorkspace(ctx context.Context, workspace entities.Workspace, ownerID uuid.UUID) (entities.Workspace, error) {
+	tx, err := r.db(ctx).Begin(ctx)
+	if err != nil {
+		r.logger.Error("postgres: begin create workspace failed", slog.Any("error", err))
+		return entities.Workspace{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	const q = `INSERT INTO workspaces (id, name, personal) VALUES ($1, $2, FALSE) RETURNING ` + workspaceColumns
+
+	created, err := scanWorkspace(tx.QueryRow(ctx, q, uuid.New(), workspace.Name))
+	if err != nil {
+		r.logger.Error("postgres: create workspace failed", slog.Any("error", err))
+		return entities.Workspace{}, err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)`,
+		created.ID, ownerID, entities.WorkspaceRoleOwner); err != nil {
+		r.logger.Error("postgres: add workspace owner failed", slog.String("workspace_id", created.ID.String()), slog.Any("error", err))
+		return entities.Workspace{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("postgres: commit create workspace failed", slog.Any("error", err))
+		return entities.Workspace{}, err
+	}
+
+	r.logger.Info("postgres: workspace created", slog.String("workspace_id", created.ID.String()), slog.String("owner_id", ownerID.String()))
+	return created, nil
+}
+
+func (r *PostgresRepository) GetWorkspaceByID(ctx context.Context, workspaceID uuid.UUID) (*entities.Workspace, error) {
+	const q = `SELECT ` + workspaceColumns + ` FROM workspaces WHERE id = $1`
+
+	workspace, err := scanWorkspace(r.db(ctx).QueryRow(ctx, q, workspaceID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrWorkspaceNotFound
+		}
+		r.logger.Error("postgres: get workspace failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+func (r *PostgresRepository) UpdateWorkspace(ctx context.Context, workspace *entities.Workspace) (*entities.Workspace, error) {
+	const q = `UPDATE workspaces SET name = $1 WHERE id = $2 RETURNING ` + workspaceColumns
+
+	updated, err := scanWorkspace(r.db(ctx).QueryRow(ctx, q, workspace.Name, workspace.ID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrWorkspaceNotFound
+		}
+		r.logger.Error("postgres: update workspace failed", slog.String("workspace_id", workspace.ID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *PostgresRepository) DeleteWorkspace(ctx context.Context, workspaceID uuid.UUID) error {
+	const q = `DELETE FROM workspaces WHERE id = $1`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, workspaceID)
+	if err != nil {
+		r.logger.Error("postgres: delete workspace failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return domain.ErrWorkspaceNotFound
+	}
+
+	r.logger.Info("postgres: workspace deleted", slog.String("workspace_id", workspaceID.String()))
+	return nil
+}
+
+func (r *PostgresRepository) SaveWorkspaceMember(ctx context.Context, member entities.WorkspaceMember) (entities.WorkspaceMember, error) {
+	const q = `INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING ` + memberColumns
+
+	saved, err := scanMember(r.db(ctx).QueryRow(ctx, q, member.WorkspaceID, member.UserID, member.Role))
+	if err != nil {
+		r.logger.Error("postgres: save workspace member failed", slog.String("workspace_id", member.WorkspaceID.String()), slog.Any("error", err))
+		return entities.WorkspaceMember{}, err
+	}
+
+	r.logger.Info("postgres: workspace member saved", slog.String("workspace_id", saved.WorkspaceID.String()),
+		slog.String("user_id", saved.UserID.String()), slog.String("role", saved.Role))
+	return saved, nil
+}
+
+func (r *PostgresRepository) GetWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) (*entities.WorkspaceMember, error) {
+	const q = `SELECT ` + memberColumns + ` FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`
+
+	member, err := scanMember(r.db(ctx).QueryRow(ctx, q, workspaceID, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrNotWorkspaceMember
+		}
+		r.logger.Error("postgres: get workspace member failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+func (r *PostgresRepository) GetWorkspaceMembers(ctx context.Context, workspaceID uuid.UUID) ([]entities.WorkspaceMember, error) {
+	const q = `SELECT ` + memberColumns + ` FROM workspace_members WHERE workspace_id = $1 ORDER BY created_at, user_id`
+	return r.queryMembers(ctx, q, workspaceID)
+}
+
+func (r *PostgresRepository) GetWorkspaceMemberships(ctx context.Context, userID uuid.UUID) ([]entities.WorkspaceMember, error) {
+	const q = `SELECT ` + memberColumns + ` FROM workspace_members WHERE user_id = $1 ORDER BY created_at, workspace_id`
+	return r.queryMembers(ctx, q, userID)
+}
+
+func (r *PostgresRepository) queryMembers(ctx context.Context, q string, arg uuid.UUID) ([]entities.WorkspaceMember, error) {
+	rows, err := r.db(ctx).Query(ctx, q, arg)
+	if err != nil {
+		r.logger.Error("postgres: list workspace members failed", slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]entities.WorkspaceMember, 0)
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			r.logger.Error("postgres: scan workspace member failed", slog.Any("error", err))
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("postgres: rows iteration failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (r *PostgresRepository) DeleteWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) error {
+	const q = `DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`
+
+	cmdTag, err := r.db(ctx).Exec(ctx, q, workspaceID, userID)
+	if err != nil {
+		r.logger.Error("postgres: delete workspace member failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return domain.ErrNotWorkspaceMember
+	}
+
+	r.logger.Info("postgres: workspace member removed", slog.String("workspace_id", workspaceID.String()), slog.String("user_id", userID.String()))
+	return nil
+}
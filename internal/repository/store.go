@@ -2,8 +2,11 @@ package repository
 
 import (
 	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
 )
 
 type Store interface {
@@ -15,10 +18,218 @@ type Store interface {
 	DeleteUser(ctx context.Context, userID uuid.UUID) error
 }
 
+// TodoStore хранит задачи. Каждый запрос ограничен рабочим пространством: задача из
+// другого пространства не находится (domain.ErrTodoNotFound), даже если известен её id.
+// Задачи в корзине видны только методам корзины (Trashed*, RestoreTodo, PurgeTodo).
 type TodoStore interface {
-	CreateTodo(ctx context.Context, userID uuid.UUID, title, description string) (entities.Todo, error)
-	GetTodoByID(ctx context.Context, todoID uuid.UUID) (*entities.Todo, error)
-	GetTodoByUserID(ctx context.Context, userID uuid.UUID) ([]entities.Todo, error)
+	// CreateTodo сохраняет новую задачу в todo.WorkspaceID; ID и время создания назначает хранилище.
+	CreateTodo(ctx context.Context, todo entities.Todo) (entities.Todo, error)
+	GetTodoByID(ctx context.Context, workspaceID, todoID uuid.UUID) (*entities.Todo, error)
+	// GetTodoWorkspaceID возвращает пространство задачи вне корзины. Это единственный
+	// запрос без пространства: по нему находят задачи, которыми поделились из другого
+	// пространства, и право доступа проверяет вызывающий.
+	GetTodoWorkspaceID(ctx context.Context, todoID uuid.UUID) (uuid.UUID, error)
+	GetTodoByUserID(ctx context.Context, workspaceID, userID uuid.UUID) ([]entities.Todo, error)
+	ListTodos(ctx context.Context, filter models.TodoListFilter) ([]entities.Todo, error)
+	// SearchTodos ищет задачи, содержащие все слова запроса, в порядке убывания релевантности.
+	SearchTodos(ctx context.Context, workspaceID, userID uuid.UUID, query string, limit int) ([]models.TodoSearchResult, error)
+	// UpdateTodo меняет задачу только в её пространстве todo.WorkspaceID.
 	UpdateTodo(ctx context.Context, todo *entities.Todo) (*entities.Todo, error)
-	DeleteTodo(ctx context.Context, todoID uuid.UUID) error
+	// DeleteTodo переносит задачу в корзину вместе со всеми её подзадачами; у всего
+	// поддерева одно время удаления. Задача должна быть версии version, иначе
+	// domain.ErrVersionMismatch.
+	DeleteTodo(ctx context.Context, workspaceID, todoID uuid.UUID, version int) error
+	// GetTrashedTodo находит задачу в корзине; задача вне корзины — domain.ErrTodoNotTrashed.
+	GetTrashedTodo(ctx context.Context, workspaceID, todoID uuid.UUID) (*entities.Todo, error)
+	// GetTrashedTodos возвращает корзину пользователя, недавно удалённые первыми.
+	GetTrashedTodos(ctx context.Context, workspaceID, userID uuid.UUID) ([]entities.Todo, error)
+	// RestoreTodo возвращает задачу из корзины вместе с подзадачами, удалёнными одновременно с ней.
+	RestoreTodo(ctx context.Context, workspaceID, todoID uuid.UUID) (*entities.Todo, error)
+	// PurgeTodo окончательно удаляет задачу из корзины вместе с подзадачами.
+	PurgeTodo(ctx context.Context, workspaceID, todoID uuid.UUID) error
+	// PurgeTrash окончательно удаляет задачи всех пространств, попавшие в корзину раньше before.
+	// Возвращает число удалённых задач.
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
+	// GetTodoChildren возвращает прямые подзадачи в ручном порядке.
+	GetTodoChildren(ctx context.Context, workspaceID, parentID uuid.UUID) ([]entities.Todo, error)
+	// LastTodoPosition возвращает наибольшую позицию задач пользователя или "", если задач нет.
+	LastTodoPosition(ctx context.Context, workspaceID, userID uuid.UUID) (string, error)
+	// AdjacentTodoPosition возвращает ближайшую позицию после (after) или до anchor среди задач
+	// его владельца, не считая саму anchor и задачу exclude; задача с той же позицией, что у
+	// anchor, тоже сосед. "" — соседа нет.
+	AdjacentTodoPosition(ctx context.Context, workspaceID uuid.UUID, anchor *entities.Todo, after bool, exclude uuid.UUID) (string, error)
+	// RebalanceTodoPositions заново раздаёт позиции задачам пользователя (ranking.Nth),
+	// сохраняя порядок, и возвращает id изменённых задач.
+	RebalanceTodoPositions(ctx context.Context, workspaceID, userID uuid.UUID) ([]uuid.UUID, error)
+}
+
+type ClientStore interface {
+	CreateClient(ctx context.Context, clientID, secretHash string, scopes []string) (entities.Client, error)
+	GetClientByClientID(ctx context.Context, clientID string) (*entities.Client, error)
+	UpdateClient(ctx context.Context, client *entities.Client) (*entities.Client, error)
+}
+
+// TokenStore хранит отозванные токены (по jti) до истечения их срока действия.
+type TokenStore interface {
+	// RevokeToken отзывает токен; если он уже отозван, возвращает domain.ErrTokenRevoked.
+	// Проверка и запись атомарны: из двух одновременных вызовов успешен только один.
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// PersonalTokenStore хранит персональные токены доступа. Сам токен не хранится, только
+// его хэш (auth.HashPersonalToken).
+type PersonalTokenStore interface {
+	CreatePersonalToken(ctx context.Context, token entities.PersonalToken) (entities.PersonalToken, error)
+	// GetPersonalTokenByHash находит токен, в том числе отозванный и истёкший.
+	GetPersonalTokenByHash(ctx context.Context, hash string) (*entities.PersonalToken, error)
+	// GetPersonalTokensByUserID возвращает неотозванные токены пользователя, новые первыми.
+	GetPersonalTokensByUserID(ctx context.Context, userID uuid.UUID) ([]entities.PersonalToken, error)
+	// RevokePersonalToken отзывает токен пользователя; чужой или уже отозванный —
+	// domain.ErrPersonalTokenNotFound.
+	RevokePersonalToken(ctx context.Context, userID, tokenID uuid.UUID) error
+}
+
+type WebAuthnStore interface {
+	SaveWebAuthnChallenge(ctx context.Context, challenge entities.WebAuthnChallenge) error
+	// ConsumeWebAuthnChallenge возвращает и удаляет challenge, так что его нельзя использовать повторно.
+	ConsumeWebAuthnChallenge(ctx context.Context, challenge string) (*entities.WebAuthnChallenge, error)
+	CreateWebAuthnCredential(ctx context.Context, userID uuid.UUID, credentialID, publicKey []byte, signCount uint32) (entities.WebAuthnCredential, error)
+	GetWebAuthnCredential(ctx context.Context, credentialID []byte) (*entities.WebAuthnCredential, error)
+	GetWebAuthnCredentialsByUserID(ctx context.Context, userID uuid.UUID) ([]entities.WebAuthnCredential, error)
+	UpdateWebAuthnSignCount(ctx context.Context, id uuid.UUID, signCount uint32) error
+}
+
+// MagicLinkStore хранит выданные ссылки входа (по jti) для одноразовости и ограничения частоты.
+type MagicLinkStore interface {
+	CreateMagicLink(ctx context.Context, jti, email string, expiresAt time.Time) error
+	// UseMagicLink помечает ссылку использованной; повторный вызов возвращает domain.ErrMagicLinkInvalid.
+	UseMagicLink(ctx context.Context, jti string) error
+	CountMagicLinksSince(ctx context.Context, email string, since time.Time) (int, error)
+	// LockMagicLinks до конца транзакции не даёт другим транзакциям выдавать ссылки на email,
+	// чтобы подсчёт и запись новой ссылки не разошлись. Вызывается внутри WithinTransaction.
+	LockMagicLinks(ctx context.Context, email string) error
+}
+
+// TagStore хранит метки и их связь с задачами (многие ко многим); как и проекты,
+// метки ограничены рабочим пространством.
+type TagStore interface {
+	CreateTag(ctx context.Context, tag entities.Tag) (entities.Tag, error)
+	GetTagByID(ctx context.Context, workspaceID, tagID uuid.UUID) (*entities.Tag, error)
+	GetTagsByUserID(ctx context.Context, workspaceID, userID uuid.UUID) ([]entities.Tag, error)
+	UpdateTag(ctx context.Context, tag *entities.Tag) (*entities.Tag, error)
+	// DeleteTag удаляет метку и снимает её со всех задач.
+	DeleteTag(ctx context.Context, workspaceID, tagID uuid.UUID) error
+	// AttachTag и DetachTag идемпотентны.
+	AttachTag(ctx context.Context, todoID, tagID uuid.UUID) error
+	DetachTag(ctx context.Context, todoID, tagID uuid.UUID) error
+	GetTagsByTodoID(ctx context.Context, todoID uuid.UUID) ([]entities.Tag, error)
+}
+
+// ProjectStore хранит проекты; как и задачи, они ограничены рабочим пространством.
+type ProjectStore interface {
+	CreateProject(ctx context.Context, project entities.Project) (entities.Project, error)
+	GetProjectByID(ctx context.Context, workspaceID, projectID uuid.UUID) (*entities.Project, error)
+	// GetProjectWorkspaceID, как TodoStore.GetTodoWorkspaceID, находит пространство общего проекта.
+	GetProjectWorkspaceID(ctx context.Context, projectID uuid.UUID) (uuid.UUID, error)
+	// GetProjectsByUserID возвращает проекты в порядке sort_order, затем имени.
+	GetProjectsByUserID(ctx context.Context, workspaceID, userID uuid.UUID, includeArchived bool) ([]entities.Project, error)
+	UpdateProject(ctx context.Context, project *entities.Project) (*entities.Project, error)
+	// DeleteProject удаляет проект, а его задачи переносит во «Входящие», при cascade — ещё и
+	// в корзину вместе с подзадачами. Возвращает id затронутых задач.
+	DeleteProject(ctx context.Context, workspaceID, projectID uuid.UUID, cascade bool) ([]uuid.UUID, error)
+}
+
+// ShareStore хранит выданные доступы к задачам и проектам.
+type ShareStore interface {
+	// SaveShare создаёт доступ или меняет роль уже выданного тому же пользователю.
+	SaveShare(ctx context.Context, share entities.Share) (entities.Share, error)
+	GetShare(ctx context.Context, resourceType string, resourceID, userID uuid.UUID) (*entities.Share, error)
+	GetSharesByResource(ctx context.Context, resourceType string, resourceID uuid.UUID) ([]entities.Share, error)
+	// GetSharesByUserID возвращает всё, чем поделились с пользователем, новые первыми.
+	GetSharesByUserID(ctx context.Context, userID uuid.UUID) ([]entities.Share, error)
+	DeleteShare(ctx context.Context, resourceType string, resourceID, userID uuid.UUID) error
+}
+
+// CommentStore хранит комментарии к задачам. Удаление мягкое: удалённые комментарии
+// не находятся (domain.ErrCommentNotFound) и не попадают в списки.
+type CommentStore interface {
+	CreateComment(ctx context.Context, comment entities.Comment) (entities.Comment, error)
+	GetCommentByID(ctx context.Context, commentID uuid.UUID) (*entities.Comment, error)
+	// GetCommentsByTodoID возвращает комментарии задачи, старые первыми.
+	GetCommentsByTodoID(ctx context.Context, todoID uuid.UUID) ([]entities.Comment, error)
+	// UpdateComment меняет текст и отмечает время правки.
+	UpdateComment(ctx context.Context, comment *entities.Comment) (*entities.Comment, error)
+	DeleteComment(ctx context.Context, commentID uuid.UUID) error
+}
+
+// RevisionStore хранит историю изменений задач; ревизии удаляются вместе с задачей.
+type RevisionStore interface {
+	// CreateRevision сохраняет ревизию; следующий номер и время создания назначает хранилище.
+	CreateRevision(ctx context.Context, revision entities.TodoRevision) (entities.TodoRevision, error)
+	// GetRevisions возвращает историю задачи, старые ревизии первыми.
+	GetRevisions(ctx context.Context, todoID uuid.UUID) ([]entities.TodoRevision, error)
+	GetRevision(ctx context.Context, todoID uuid.UUID, number int) (*entities.TodoRevision, error)
+}
+
+// AttachmentStore хранит сведения о вложениях задач. Когда задача удаляется, вложение
+// становится осиротевшим: оно больше не находится по id и не попадает в списки, а ждёт
+// очистки содержимого (GetOrphanedAttachments). Удаление загрузившего пользователя лишь
+// обнуляет UploaderID.
+type AttachmentStore interface {
+	// CreateAttachment сохраняет вложение; ID и время создания назначает хранилище.
+	CreateAttachment(ctx context.Context, attachment entities.Attachment) (entities.Attachment, error)
+	GetAttachmentByID(ctx context.Context, attachmentID uuid.UUID) (*entities.Attachment, error)
+	// GetAttachmentsByTodoID возвращает вложения задачи, старые первыми.
+	GetAttachmentsByTodoID(ctx context.Context, todoID uuid.UUID) ([]entities.Attachment, error)
+	// GetOrphanedAttachments возвращает осиротевшие вложения по возрастанию id, начиная
+	// после after (uuid.Nil — с начала).
+	GetOrphanedAttachments(ctx context.Context, after uuid.UUID, limit int) ([]entities.Attachment, error)
+	DeleteAttachment(ctx context.Context, attachmentID uuid.UUID) error
+}
+
+// WorkspaceStore хранит рабочие пространства и их участников. Пространство по умолчанию
+// создаёт Store.CreateUser.
+type WorkspaceStore interface {
+	// CreateWorkspace создаёт пространство, ownerID становится его владельцем.
+	CreateWorkspace(ctx context.Context, workspace entities.Workspace, ownerID uuid.UUID) (entities.Workspace, error)
+	GetWorkspaceByID(ctx context.Context, workspaceID uuid.UUID) (*entities.Workspace, error)
+	UpdateWorkspace(ctx context.Context, workspace *entities.Workspace) (*entities.Workspace, error)
+	// DeleteWorkspace удаляет пространство вместе с его задачами, проектами и участниками.
+	DeleteWorkspace(ctx context.Context, workspaceID uuid.UUID) error
+	// SaveWorkspaceMember добавляет участника или меняет его роль.
+	SaveWorkspaceMember(ctx context.Context, member entities.WorkspaceMember) (entities.WorkspaceMember, error)
+	// GetWorkspaceMember возвращает domain.ErrNotWorkspaceMember, если пользователь не участник.
+	GetWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) (*entities.WorkspaceMember, error)
+	GetWorkspaceMembers(ctx context.Context, workspaceID uuid.UUID) ([]entities.WorkspaceMember, error)
+	// GetWorkspaceMemberships возвращает членства пользователя в порядке вступления.
+	GetWorkspaceMemberships(ctx context.Context, userID uuid.UUID) ([]entities.WorkspaceMember, error)
+	DeleteWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) error
+}
+
+// Transactor выполняет несколько вызовов хранилищ атомарно.
+type Transactor interface {
+	// WithinTransaction вызывает fn с контекстом транзакции: изменения, сделанные через этот
+	// контекст, сохраняются вместе, если fn вернула nil, и откатываются, если ошибку.
+	// Вложенный вызов присоединяется к внешней транзакции.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Repository объединяет все хранилища; его реализуют postgres и in-memory репозитории.
+type Repository interface {
+	Transactor
+	Store
+	TodoStore
+	ClientStore
+	TokenStore
+	PersonalTokenStore
+	WebAuthnStore
+	MagicLinkStore
+	TagStore
+	ProjectStore
+	ShareStore
+	WorkspaceStore
+	CommentStore
+	AttachmentStore
+	RevisionStore
 }
@@ -0,0 +1,220 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/polzovatel/todo-learning/internal/database"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/repository"
+	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
+	"github.com/polzovatel/todo-learning/internal/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithinTransaction(t *testing.T) {
+	t.Run("in_memory", func(t *testing.T) {
+		testWithinTransaction(t, in_memory.NewInMemoryRepository(slog.Default()))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv("TEST_DATABASE_URL")
+		if dsn == "" {
+			t.Skip("TEST_DATABASE_URL is not set")
+		}
+		ctx := context.Background()
+		pool, err := pgxpool.New(ctx, dsn)
+		require.NoError(t, err)
+		defer pool.Close()
+		require.NoError(t, database.RunMigrations(ctx, pool))
+		testWithinTransaction(t, postgres.NewPostgresRepository(pool, slog.Default()))
+	})
+}
+
+func testWithinTransaction(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	user, err := repo.CreateUser(ctx, "tx-"+uuid.NewString()+"@example.com", "hash")
+	require.NoError(t, err)
+	defer repo.DeleteUser(ctx, user.ID)
+
+	newTodo := func(title string) entities.Todo {
+		return entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: title, Priority: entities.PriorityNormal, Position: "m"}
+	}
+	existing, err := repo.CreateTodo(ctx, newTodo("existing"))
+	require.NoError(t, err)
+	failure := errors.New("stop")
+
+	t.Run("error rolls back every change", func(t *testing.T) {
+		var created entities.Todo
+		err := repo.WithinTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			if created, err = repo.CreateTodo(ctx, newTodo("rolled back")); err != nil {
+				return err
+			}
+			todo, err := repo.GetTodoByID(ctx, user.ID, existing.ID)
+			if err != nil {
+				return err
+			}
+			todo.Title = "renamed"
+			if _, err := repo.UpdateTodo(ctx, todo); err != nil {
+				return err
+			}
+			// Вложенный вызов присоединяется к внешней транзакции и откатывается вместе с ней.
+			if err := repo.WithinTransaction(ctx, func(ctx context.Context) error {
+				return repo.DeleteTodo(ctx, user.ID, existing.ID, todo.Version)
+			}); err != nil {
+				return err
+			}
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		_, err = repo.GetTodoByID(ctx, user.ID, created.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+		found, err := repo.GetTodoByID(ctx, user.ID, existing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "existing", found.Title)
+		assert.Equal(t, existing.Version, found.Version)
+	})
+
+	t.Run("success keeps every change", func(t *testing.T) {
+		var created entities.Todo
+		err := repo.WithinTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			created, err = repo.CreateTodo(ctx, newTodo("kept"))
+			if err != nil {
+				return err
+			}
+			return repo.DeleteTodo(ctx, user.ID, existing.ID, existing.Version)
+		})
+		require.NoError(t, err)
+
+		_, err = repo.GetTodoByID(ctx, user.ID, created.ID)
+		assert.NoError(t, err)
+		_, err = repo.GetTodoByID(ctx, user.ID, existing.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+	})
+
+	t.Run("panic rolls back", func(t *testing.T) {
+		var created entities.Todo
+		assert.Panics(t, func() {
+			repo.WithinTransaction(ctx, func(ctx context.Context) error {
+				created, _ = repo.CreateTodo(ctx, newTodo("panicked"))
+				panic("boom")
+			})
+		})
+		_, err := repo.GetTodoByID(ctx, user.ID, created.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+	})
+
+	t.Run("rollback keeps concurrent writes", func(t *testing.T) {
+		done := make(chan entities.Todo)
+		err := repo.WithinTransaction(ctx, func(txCtx context.Context) error {
+			if _, err := repo.CreateTodo(txCtx, newTodo("rolled back")); err != nil {
+				return err
+			}
+			// Запись вне транзакции ждёт её окончания и не теряется при откате.
+			go func() {
+				todo, _ := repo.CreateTodo(ctx, newTodo("concurrent"))
+				done <- todo
+			}()
+			time.Sleep(20 * time.Millisecond)
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		concurrent := <-done
+		found, err := repo.GetTodoByID(ctx, user.ID, concurrent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "concurrent", found.Title)
+	})
+
+	t.Run("rollback restores related data", func(t *testing.T) {
+		todo, err := repo.CreateTodo(ctx, newTodo("quarterly report"))
+		require.NoError(t, err)
+		tag, err := repo.CreateTag(ctx, entities.Tag{WorkspaceID: user.ID, UserID: user.ID, Name: "tx-" + uuid.NewString(), Color: "#808080"})
+		require.NoError(t, err)
+		require.NoError(t, repo.AttachTag(ctx, todo.ID, tag.ID))
+
+		err = repo.WithinTransaction(ctx, func(ctx context.Context) error {
+			found, err := repo.GetTodoByID(ctx, user.ID, todo.ID)
+			if err != nil {
+				return err
+			}
+			found.Title = "annual summary"
+			if _, err := repo.UpdateTodo(ctx, found); err != nil {
+				return err
+			}
+			if err := repo.DeleteTag(ctx, user.ID, tag.ID); err != nil {
+				return err
+			}
+			if _, err := repo.CreateWorkspace(ctx, entities.Workspace{Name: "rolled back"}, user.ID); err != nil {
+				return err
+			}
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		results, err := repo.SearchTodos(ctx, user.ID, user.ID, "quarterly", 10)
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+		results, err = repo.SearchTodos(ctx, user.ID, user.ID, "annual", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		tags, err := repo.GetTagsByTodoID(ctx, todo.ID)
+		require.NoError(t, err)
+		require.Len(t, tags, 1)
+		assert.Equal(t, tag.ID, tags[0].ID)
+		memberships, err := repo.GetWorkspaceMemberships(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Len(t, memberships, 1)
+	})
+}
+
+// Контекст завершившейся транзакции не должен обходить блокировку: иначе запись с ним
+// попадёт внутрь чужой транзакции и пропадёт при её откате.
+func TestInMemoryTransactionLock(t *testing.T) {
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	ctx := context.Background()
+	user, err := repo.CreateUser(ctx, "tx-lock@example.com", "hash")
+	require.NoError(t, err)
+	newTodo := func(title string) entities.Todo {
+		return entities.Todo{WorkspaceID: user.ID, UserID: user.ID, Title: title, Priority: entities.PriorityNormal, Position: "m"}
+	}
+
+	var stale context.Context
+	require.NoError(t, repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		stale = ctx
+		return nil
+	}))
+
+	failure := errors.New("stop")
+	started := make(chan struct{})
+	finished := make(chan error, 1)
+	go func() {
+		finished <- repo.WithinTransaction(ctx, func(ctx context.Context) error {
+			if _, err := repo.CreateTodo(ctx, newTodo("rolled back")); err != nil {
+				return err
+			}
+			close(started)
+			time.Sleep(20 * time.Millisecond)
+			return failure
+		})
+	}()
+	<-started
+	written, err := repo.CreateTodo(stale, newTodo("after rollback"))
+	require.NoError(t, err)
+	assert.ErrorIs(t, <-finished, failure)
+
+	todos, err := repo.GetTodoByUserID(ctx, user.ID, user.ID)
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+	assert.Equal(t, written.ID, todos[0].ID)
+}
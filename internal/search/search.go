@@ -0,0 +1,141 @@
+// Package search — токенизатор и подсветка для in-memory поиска по задачам.
+// Правила повторяют конфигурацию Postgres 'simple': слова из букв и цифр,
+// приведённые к нижнему регистру, без стемминга и стоп-слов.
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	HighlightStart = "<b>"
+	HighlightStop  = "</b>"
+
+	// MarkStart и MarkStop — символы из области частного использования, которыми
+	// ts_headline размечает совпадения вместо HTML; Markup превращает их в теги.
+	MarkStart = "\uE000"
+	MarkStop  = "\uE001"
+
+	// FragmentWords — длина фрагмента описания в словах (MaxWords в ts_headline).
+	FragmentWords = 30
+)
+
+type token struct {
+	word       string
+	start, end int // байтовые границы в исходном тексте
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func scan(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		switch {
+		case isWordRune(r) && start < 0:
+			start = i
+		case !isWordRune(r) && start >= 0:
+			tokens = append(tokens, token{word: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{word: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// Tokenize возвращает слова текста в нижнем регистре в порядке появления.
+func Tokenize(text string) []string {
+	tokens := scan(text)
+	words := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		words = append(words, t.word)
+	}
+	return words
+}
+
+// Terms возвращает уникальные слова запроса; все они должны встретиться в документе.
+func Terms(query string) []string {
+	seen := make(map[string]struct{})
+	var terms []string
+	for _, word := range Tokenize(query) {
+		if _, ok := seen[word]; !ok {
+			seen[word] = struct{}{}
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// Markup экранирует размеченный MarkStart/MarkStop текст как HTML и заменяет
+// метки на HighlightStart/HighlightStop.
+func Markup(marked string) string {
+	escaped := html.EscapeString(marked)
+	return strings.NewReplacer(MarkStart, HighlightStart, MarkStop, HighlightStop).Replace(escaped)
+}
+
+// Highlight экранирует текст как HTML и оборачивает каждое вхождение terms
+// в HighlightStart/HighlightStop.
+func Highlight(text string, terms []string) string {
+	return highlight(text, scan(text), terms)
+}
+
+// Fragment возвращает до FragmentWords слов текста, начиная чуть раньше первого
+// совпадения, с подсветкой. Короткий текст возвращается целиком.
+func Fragment(text string, terms []string) string {
+	tokens := scan(text)
+	if len(tokens) <= FragmentWords {
+		return highlight(text, tokens, terms)
+	}
+
+	first := 0
+	for i, t := range tokens {
+		if contains(terms, t.word) {
+			first = i
+			break
+		}
+	}
+	from := max(0, first-FragmentWords/4)
+	to := min(len(tokens), from+FragmentWords)
+	from = max(0, to-FragmentWords)
+
+	window := tokens[from:to]
+	offset := window[0].start
+	part := text[offset:window[len(window)-1].end]
+	shifted := make([]token, len(window))
+	for i, t := range window {
+		shifted[i] = token{word: t.word, start: t.start - offset, end: t.end - offset}
+	}
+	return highlight(part, shifted, terms)
+}
+
+func highlight(text string, tokens []token, terms []string) string {
+	var b strings.Builder
+	last := 0
+	for _, t := range tokens {
+		if !contains(terms, t.word) {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[last:t.start]))
+		b.WriteString(HighlightStart)
+		b.WriteString(html.EscapeString(text[t.start:t.end]))
+		b.WriteString(HighlightStop)
+		last = t.end
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
+
+func contains(terms []string, word string) bool {
+	for _, term := range terms {
+		if term == word {
+			return true
+		}
+	}
+	return false
+}
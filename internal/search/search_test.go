@@ -0,0 +1,69 @@
+package search_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/todo-learning/internal/search"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "lowercases and splits on punctuation", text: "Buy MILK, eggs!", want: []string{"buy", "milk", "eggs"}},
+		{name: "keeps digits", text: "release v2.0", want: []string{"release", "v2", "0"}},
+		{name: "unicode letters", text: "Купить молоко", want: []string{"купить", "молоко"}},
+		{name: "no words", text: " -- ", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, search.Tokenize(tt.text))
+		})
+	}
+}
+
+func TestTerms_Deduplicates(t *testing.T) {
+	assert.Equal(t, []string{"milk", "eggs"}, search.Terms("milk Eggs MILK"))
+}
+
+func TestHighlight(t *testing.T) {
+	got := search.Highlight("Buy Milk and milkshake", []string{"milk"})
+
+	assert.Equal(t, "Buy <b>Milk</b> and milkshake", got)
+}
+
+func TestHighlight_EscapesHTML(t *testing.T) {
+	got := search.Highlight(`<script>alert("milk")</script> & milk`, []string{"milk", "script"})
+
+	assert.Equal(t, "&lt;<b>script</b>&gt;alert(&#34;<b>milk</b>&#34;)&lt;/<b>script</b>&gt; &amp; <b>milk</b>", got)
+}
+
+func TestMarkup(t *testing.T) {
+	marked := "<img src=x> " + search.MarkStart + "milk" + search.MarkStop
+
+	assert.Equal(t, "&lt;img src=x&gt; <b>milk</b>", search.Markup(marked))
+}
+
+func TestFragment(t *testing.T) {
+	t.Run("short text is returned whole", func(t *testing.T) {
+		assert.Equal(t, "get <b>eggs</b> too", search.Fragment("get eggs too", []string{"eggs"}))
+	})
+
+	t.Run("long text is cut around the first match", func(t *testing.T) {
+		words := make([]string, 100)
+		for i := range words {
+			words[i] = "word"
+		}
+		words[70] = "needle"
+
+		got := search.Fragment(strings.Join(words, " "), []string{"needle"})
+
+		assert.Contains(t, got, "<b>needle</b>")
+		assert.Len(t, search.Tokenize(got), search.FragmentWords+2) // +2 за слово "b" в тегах
+	})
+}
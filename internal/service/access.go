@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/repository"
+	"github.com/polzovatel/todo-learning/internal/tenant"
+)
+
+// access определяет роль пользователя в задаче или проекте. Создатель ресурса — его
+// владелец; остальные получают наибольшую из ролей, выданных на саму задачу, на её
+// проект и на родительские задачи. Без хранилища доступов (тесты) роль есть только
+// у создателя.
+type access struct {
+	todoRepo    repository.TodoStore
+	projectRepo repository.ProjectStore
+	shareRepo   repository.ShareStore
+	logger      *slog.Logger
+}
+
+// findTodo находит задачу в текущем пространстве, а если её там нет — в любом другом:
+// так получатель доступа видит общую задачу из своего пространства. Свою задачу из
+// чужого пространства пользователь не находит (domain.ErrTodoNotFound), иначе
+// пространства не были бы изолированы. Роль проверяет вызывающий.
+func (a access) findTodo(ctx context.Context, todoID, userID uuid.UUID) (*entities.Todo, error) {
+	todo, err := a.todoRepo.GetTodoByID(ctx, tenant.WorkspaceID(ctx, userID), todoID)
+	if !errors.Is(err, domain.ErrTodoNotFound) {
+		return todo, err
+	}
+	workspaceID, err := a.todoRepo.GetTodoWorkspaceID(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if todo, err = a.todoRepo.GetTodoByID(ctx, workspaceID, todoID); err != nil {
+		return nil, err
+	}
+	if todo.UserID == userID {
+		return nil, domain.ErrTodoNotFound
+	}
+	return todo, nil
+}
+
+// findProject — то же, что findTodo, для проектов.
+func (a access) findProject(ctx context.Context, projectID, userID uuid.UUID) (*entities.Project, error) {
+	project, err := a.projectRepo.GetProjectByID(ctx, tenant.WorkspaceID(ctx, userID), projectID)
+	if !errors.Is(err, domain.ErrProjectNotFound) {
+		return project, err
+	}
+	workspaceID, err := a.projectRepo.GetProjectWorkspaceID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project, err = a.projectRepo.GetProjectByID(ctx, workspaceID, projectID); err != nil {
+		return nil, err
+	}
+	if project.UserID == userID {
+		return nil, domain.ErrProjectNotFound
+	}
+	return project, nil
+}
+
+func (a access) todoRole(ctx context.Context, todo *entities.Todo, userID uuid.UUID) (string, error) {
+	best := ""
+	for current := todo; ; {
+		if current.UserID == userID {
+			return entities.RoleOwner, nil
+		}
+		role, err := a.sharedRole(ctx, entities.ShareResourceTodo, current.ID, userID)
+		if err != nil {
+			return "", err
+		}
+		best = higherRole(best, role)
+
+		if current.ProjectID != nil && a.projectRepo != nil {
+			project, err := a.projectRepo.GetProjectByID(ctx, current.WorkspaceID, *current.ProjectID)
+			if err != nil {
+				if !errors.Is(err, domain.ErrProjectNotFound) {
+					a.logger.Error("service: get todo project failed", slog.String("project_id", current.ProjectID.String()), slog.Any("error", err))
+					return "", err
+				}
+			} else {
+				role, err := a.projectRole(ctx, project, userID)
+				if err != nil {
+					return "", err
+				}
+				best = higherRole(best, role)
+			}
+		}
+
+		if current.ParentID == nil || best == entities.RoleOwner {
+			return best, nil
+		}
+		parent, err := a.todoRepo.GetTodoByID(ctx, current.WorkspaceID, *current.ParentID)
+		if err != nil {
+			a.logger.Error("service: get parent todo failed", slog.String("todo_id", current.ParentID.String()), slog.Any("error", err))
+			return "", err
+		}
+		current = parent
+	}
+}
+
+func (a access) projectRole(ctx context.Context, project *entities.Project, userID uuid.UUID) (string, error) {
+	if project.UserID == userID {
+		return entities.RoleOwner, nil
+	}
+	return a.sharedRole(ctx, entities.ShareResourceProject, project.ID, userID)
+}
+
+func (a access) sharedRole(ctx context.Context, resourceType string, resourceID, userID uuid.UUID) (string, error) {
+	if a.shareRepo == nil {
+		return "", nil
+	}
+	share, err := a.shareRepo.GetShare(ctx, resourceType, resourceID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrShareNotFound) {
+			return "", nil
+		}
+		a.logger.Error("service: get share failed", slog.String("resource_id", resourceID.String()), slog.Any("error", err))
+		return "", err
+	}
+	return share.Role, nil
+}
+
+// authorizeTodo возвращает domain.ErrForbidden, если роль пользователя ниже need.
+func (a access) authorizeTodo(ctx context.Context, todo *entities.Todo, userID uuid.UUID, need string) error {
+	role, err := a.todoRole(ctx, todo, userID)
+	if err != nil {
+		return err
+	}
+	if entities.RoleRank(role) < entities.RoleRank(need) {
+		a.logger.Warn("service: todo access forbidden", slog.String("todo_id", todo.ID.String()), slog.String("user_id", userID.String()), slog.String("need", need))
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+func (a access) authorizeProject(ctx context.Context, project *entities.Project, userID uuid.UUID, need string) error {
+	role, err := a.projectRole(ctx, project, userID)
+	if err != nil {
+		return err
+	}
+	if entities.RoleRank(role) < entities.RoleRank(need) {
+		a.logger.Warn("service: project access forbidden", slog.String("project_id", project.ID.String()), slog.String("user_id", userID.String()), slog.String("need", need))
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+func higherRole(a, b string) string {
+	if entities.RoleRank(b) > entities.RoleRank(a) {
+		return b
+	}
+	return a
+}
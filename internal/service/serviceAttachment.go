@@ -0,0 +1,275 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/blob"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository"
+)
+
+const (
+	// sniffLen — сколько байт нужно http.DetectContentType.
+	sniffLen            = 512
+	maxFileNameLength   = 255
+	orphanPurgeBatch    = 100
+	defaultFileName     = "file"
+	attachmentKeyPrefix = "attachments/"
+)
+
+// AttachmentLimits ограничивает загружаемые файлы. AllowedTypes — MIME-типы вида
+// "application/pdf" или "image/*"; тип определяется по содержимому файла.
+type AttachmentLimits struct {
+	MaxSize      int64
+	AllowedTypes []string
+}
+
+// AttachmentService хранит файлы задач: смотреть и скачивать их может каждый, кто видит
+// задачу, загружать и удалять — роль editor и выше.
+type AttachmentService interface {
+	UploadAttachment(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, upload models.AttachmentUpload) (entities.Attachment, error)
+	GetAttachments(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) ([]entities.Attachment, error)
+	// OpenAttachment возвращает вложение и поток его содержимого; поток закрывает вызывающий.
+	OpenAttachment(ctx context.Context, todoID, attachmentID uuid.UUID, userID uuid.UUID) (*entities.Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, todoID, attachmentID uuid.UUID, userID uuid.UUID) error
+	// PurgeOrphans удаляет содержимое и записи вложений удалённых задач и пользователей.
+	// Возвращает число удалённых вложений.
+	PurgeOrphans(ctx context.Context) (int, error)
+}
+
+type attachmentService struct {
+	attachmentRepo repository.AttachmentStore
+	blobs          blob.BlobStore
+	limits         AttachmentLimits
+	access         access
+	logger         *slog.Logger
+}
+
+func NewAttachmentService(todoRepo repository.TodoStore, projectRepo repository.ProjectStore, shareRepo repository.ShareStore, attachmentRepo repository.AttachmentStore, blobs blob.BlobStore, limits AttachmentLimits, logger *slog.Logger) AttachmentService {
+	return &attachmentService{
+		attachmentRepo: attachmentRepo,
+		blobs:          blobs,
+		limits:         limits,
+		access:         access{todoRepo: todoRepo, projectRepo: projectRepo, shareRepo: shareRepo, logger: logger},
+		logger:         logger,
+	}
+}
+
+// authorizedTodo находит задачу (access.findTodo) и проверяет роль пользователя.
+func (s *attachmentService) authorizedTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, need string) error {
+	todo, err := s.access.findTodo(ctx, todoID, userID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrTodoNotFound) {
+			s.logger.Error("service: get todo for attachment failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		}
+		return err
+	}
+	return s.access.authorizeTodo(ctx, todo, userID, need)
+}
+
+// todoAttachment возвращает вложение задачи todoID.
+func (s *attachmentService) todoAttachment(ctx context.Context, todoID, attachmentID uuid.UUID) (*entities.Attachment, error) {
+	attachment, err := s.attachmentRepo.GetAttachmentByID(ctx, attachmentID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrAttachmentNotFound) {
+			s.logger.Error("service: get attachment failed", slog.String("attachment_id", attachmentID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+	if attachment.TodoID != todoID {
+		return nil, domain.ErrAttachmentNotFound
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) UploadAttachment(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, upload models.AttachmentUpload) (entities.Attachment, error) {
+	if err := s.authorizedTodo(ctx, todoID, userID, entities.RoleEditor); err != nil {
+		return entities.Attachment{}, err
+	}
+	if upload.Size > s.limits.MaxSize {
+		s.logger.Warn("service: attachment too large", slog.String("todo_id", todoID.String()), slog.Int64("size", upload.Size))
+		return entities.Attachment{}, domain.ErrAttachmentTooLarge
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(upload.Content, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return entities.Attachment{}, err
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !s.typeAllowed(contentType) {
+		s.logger.Warn("service: attachment type rejected", slog.String("todo_id", todoID.String()), slog.String("content_type", contentType))
+		return entities.Attachment{}, domain.ErrAttachmentType
+	}
+
+	key := attachmentKeyPrefix + uuid.NewString()
+	content := io.MultiReader(bytes.NewReader(head), upload.Content)
+	if err := s.blobs.Put(ctx, key, content, upload.Size, contentType); err != nil {
+		s.logger.Error("service: store attachment content failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return entities.Attachment{}, err
+	}
+
+	attachment, err := s.attachmentRepo.CreateAttachment(ctx, entities.Attachment{
+		TodoID:      todoID,
+		UploaderID:  userID,
+		FileName:    cleanFileName(upload.FileName),
+		ContentType: contentType,
+		Size:        upload.Size,
+		StorageKey:  key,
+	})
+	if err != nil {
+		s.logger.Error("service: create attachment failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		if delErr := s.blobs.Delete(ctx, key); delErr != nil {
+			s.logger.Error("service: delete unreferenced blob failed", slog.String("key", key), slog.Any("error", delErr))
+		}
+		return entities.Attachment{}, err
+	}
+
+	s.logger.Info("service: attachment uploaded", slog.String("attachment_id", attachment.ID.String()), slog.String("todo_id", todoID.String()), slog.Int64("size", attachment.Size))
+	return attachment, nil
+}
+
+func (s *attachmentService) GetAttachments(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) ([]entities.Attachment, error) {
+	if err := s.authorizedTodo(ctx, todoID, userID, entities.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.attachmentRepo.GetAttachmentsByTodoID(ctx, todoID)
+	if err != nil {
+		s.logger.Error("service: list attachments failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (s *attachmentService) OpenAttachment(ctx context.Context, todoID, attachmentID uuid.UUID, userID uuid.UUID) (*entities.Attachment, io.ReadCloser, error) {
+	if err := s.authorizedTodo(ctx, todoID, userID, entities.RoleViewer); err != nil {
+		return nil, nil, err
+	}
+	attachment, err := s.todoAttachment(ctx, todoID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.blobs.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		// Запись без содержимого — сбой хранилища, а не «не найдено» для клиента.
+		s.logger.Error("service: open attachment content failed", slog.String("attachment_id", attachmentID.String()), slog.Any("error", err))
+		return nil, nil, err
+	}
+	return attachment, content, nil
+}
+
+func (s *attachmentService) DeleteAttachment(ctx context.Context, todoID, attachmentID uuid.UUID, userID uuid.UUID) error {
+	if err := s.authorizedTodo(ctx, todoID, userID, entities.RoleEditor); err != nil {
+		return err
+	}
+	attachment, err := s.todoAttachment(ctx, todoID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.attachmentRepo.DeleteAttachment(ctx, attachmentID); err != nil {
+		if !errors.Is(err, domain.ErrAttachmentNotFound) {
+			s.logger.Error("service: delete attachment failed", slog.String("attachment_id", attachmentID.String()), slog.Any("error", err))
+		}
+		return err
+	}
+	// Запись уже удалена; если содержимое удалить не удалось, остаётся лишь мусор в хранилище.
+	if err := s.blobs.Delete(ctx, attachment.StorageKey); err != nil {
+		s.logger.Error("service: delete attachment content failed", slog.String("key", attachment.StorageKey), slog.Any("error", err))
+	}
+
+	s.logger.Info("service: attachment deleted", slog.String("attachment_id", attachmentID.String()))
+	return nil
+}
+
+// PurgeOrphans сначала удаляет содержимое, затем запись, так что при сбое вложение
+// останется осиротевшим и будет удалено при следующем запуске. Сбой одного вложения
+// не останавливает очистку: обход идёт по id, и неудачные записи остаются позади.
+func (s *attachmentService) PurgeOrphans(ctx context.Context) (int, error) {
+	purged, failed := 0, 0
+	after := uuid.Nil
+	for {
+		orphans, err := s.attachmentRepo.GetOrphanedAttachments(ctx, after, orphanPurgeBatch)
+		if err != nil {
+			s.logger.Error("service: list orphaned attachments failed", slog.Any("error", err))
+			return purged, err
+		}
+		for _, attachment := range orphans {
+			after = attachment.ID
+			if err := s.blobs.Delete(ctx, attachment.StorageKey); err != nil {
+				s.logger.Error("service: delete orphaned blob failed", slog.String("key", attachment.StorageKey), slog.Any("error", err))
+				failed++
+				continue
+			}
+			if err := s.attachmentRepo.DeleteAttachment(ctx, attachment.ID); err != nil && !errors.Is(err, domain.ErrAttachmentNotFound) {
+				s.logger.Error("service: delete orphaned attachment failed", slog.String("attachment_id", attachment.ID.String()), slog.Any("error", err))
+				failed++
+				continue
+			}
+			purged++
+		}
+		if len(orphans) < orphanPurgeBatch {
+			break
+		}
+	}
+
+	if failed > 0 {
+		s.logger.Warn("service: some orphaned attachments were not purged", slog.Int("count", failed))
+	}
+	if purged > 0 {
+		s.logger.Info("service: orphaned attachments purged", slog.Int("count", purged))
+	}
+	return purged, nil
+}
+
+func (s *attachmentService) typeAllowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range s.limits.AllowedTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanFileName оставляет от имени файла только последний элемент пути без управляющих
+// символов и обрезает его до maxFileNameLength символов.
+func cleanFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return defaultFileName
+	}
+	if utf8.RuneCountInString(name) > maxFileNameLength {
+		name = string([]rune(name)[:maxFileNameLength])
+	}
+	return name
+}
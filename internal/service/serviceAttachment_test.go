@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/blob"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository/mocks"
+	"github.com/polzovatel/todo-learning/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentService(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	mockShareStore := mocks.NewMockShareStore()
+	mockAttachmentStore := mocks.NewMockAttachmentStore()
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	limits := AttachmentLimits{MaxSize: 1024, AllowedTypes: []string{"text/plain", "image/*"}}
+	attachments := NewAttachmentService(mockTodoStore, nil, mockShareStore, mockAttachmentStore, blobs, limits, slog.Default())
+
+	owner, _ := mockUserStore.CreateUser(ctx, "attach-owner@example.com", "hash")
+	viewer, _ := mockUserStore.CreateUser(ctx, "attach-viewer@example.com", "hash")
+	stranger, _ := mockUserStore.CreateUser(ctx, "attach-stranger@example.com", "hash")
+
+	ctx = tenant.WithWorkspace(ctx, owner.ID)
+	todo, _ := mockTodoStore.CreateTodo(ctx, entities.Todo{WorkspaceID: owner.ID, UserID: owner.ID, Title: "report"})
+	_, _ = mockShareStore.SaveShare(ctx, entities.Share{ResourceType: entities.ShareResourceTodo, ResourceID: todo.ID, UserID: viewer.ID, Role: entities.RoleViewer, InvitedBy: owner.ID})
+
+	upload := func(userID uuid.UUID, name, content string) (entities.Attachment, error) {
+		return attachments.UploadAttachment(ctx, todo.ID, userID, models.AttachmentUpload{
+			FileName: name,
+			Size:     int64(len(content)),
+			Content:  strings.NewReader(content),
+		})
+	}
+	read := func(attachmentID uuid.UUID, userID uuid.UUID) string {
+		_, content, err := attachments.OpenAttachment(ctx, todo.ID, attachmentID, userID)
+		require.NoError(t, err)
+		defer content.Close()
+		body, err := io.ReadAll(content)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	notes, err := upload(owner.ID, `C:\Users\owner\notes.txt`, "meeting notes")
+	require.NoError(t, err)
+	assert.Equal(t, "notes.txt", notes.FileName)
+	assert.Equal(t, "text/plain; charset=utf-8", notes.ContentType)
+	assert.Equal(t, int64(13), notes.Size)
+
+	t.Run("viewers read, editors write", func(t *testing.T) {
+		assert.Equal(t, "meeting notes", read(notes.ID, viewer.ID))
+		list, err := attachments.GetAttachments(ctx, todo.ID, viewer.ID)
+		require.NoError(t, err)
+		assert.Len(t, list, 1)
+
+		_, err = upload(viewer.ID, "mine.txt", "not allowed")
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		assert.ErrorIs(t, attachments.DeleteAttachment(ctx, todo.ID, notes.ID, viewer.ID), domain.ErrForbidden)
+
+		_, err = attachments.GetAttachments(ctx, todo.ID, stranger.ID)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, _, err = attachments.OpenAttachment(ctx, todo.ID, notes.ID, stranger.ID)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("size and type are limited", func(t *testing.T) {
+		_, err := upload(owner.ID, "big.txt", strings.Repeat("a", 1025))
+		assert.ErrorIs(t, err, domain.ErrAttachmentTooLarge)
+
+		_, err = upload(owner.ID, "archive.txt", "PK\x03\x04 not really text")
+		assert.ErrorIs(t, err, domain.ErrAttachmentType)
+
+		image, err := upload(owner.ID, "pixel.png", "\x89PNG\r\n\x1a\n")
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", image.ContentType)
+		assert.Equal(t, "\x89PNG\r\n\x1a\n", read(image.ID, owner.ID))
+		require.NoError(t, attachments.DeleteAttachment(ctx, todo.ID, image.ID, owner.ID))
+	})
+
+	t.Run("attachment must belong to the todo", func(t *testing.T) {
+		other, _ := mockTodoStore.CreateTodo(ctx, entities.Todo{WorkspaceID: owner.ID, UserID: owner.ID, Title: "other"})
+		_, _, err := attachments.OpenAttachment(ctx, other.ID, notes.ID, owner.ID)
+		assert.ErrorIs(t, err, domain.ErrAttachmentNotFound)
+		assert.ErrorIs(t, attachments.DeleteAttachment(ctx, other.ID, notes.ID, owner.ID), domain.ErrAttachmentNotFound)
+	})
+
+	t.Run("todos of other workspaces are not found", func(t *testing.T) {
+		_, err := attachments.GetAttachments(tenant.WithWorkspace(ctx, stranger.ID), todo.ID, owner.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+	})
+
+	t.Run("delete removes the content", func(t *testing.T) {
+		draft, err := upload(owner.ID, "draft.txt", "draft")
+		require.NoError(t, err)
+		require.NoError(t, attachments.DeleteAttachment(ctx, todo.ID, draft.ID, owner.ID))
+
+		_, err = blobs.Get(ctx, draft.StorageKey)
+		assert.ErrorIs(t, err, domain.ErrBlobNotFound)
+		_, _, err = attachments.OpenAttachment(ctx, todo.ID, draft.ID, owner.ID)
+		assert.ErrorIs(t, err, domain.ErrAttachmentNotFound)
+	})
+
+	t.Run("orphans are purged", func(t *testing.T) {
+		// Так хранилище помечает вложения удалённой задачи.
+		for i := range mockAttachmentStore.Attachments {
+			mockAttachmentStore.Attachments[i].TodoID = uuid.Nil
+		}
+
+		purged, err := attachments.PurgeOrphans(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		assert.Empty(t, mockAttachmentStore.Attachments)
+		_, err = blobs.Get(ctx, notes.StorageKey)
+		assert.ErrorIs(t, err, domain.ErrBlobNotFound)
+
+		purged, err = attachments.PurgeOrphans(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+	})
+
+	t.Run("a failing blob does not block the sweep", func(t *testing.T) {
+		stuck, err := upload(owner.ID, "stuck.txt", "stuck")
+		require.NoError(t, err)
+		loose, err := upload(owner.ID, "loose.txt", "loose")
+		require.NoError(t, err)
+		for i := range mockAttachmentStore.Attachments {
+			mockAttachmentStore.Attachments[i].TodoID = uuid.Nil
+		}
+
+		flaky := NewAttachmentService(mockTodoStore, nil, mockShareStore, mockAttachmentStore,
+			failingBlobs{BlobStore: blobs, key: stuck.StorageKey}, limits, slog.Default())
+		purged, err := flaky.PurgeOrphans(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		require.Len(t, mockAttachmentStore.Attachments, 1)
+		assert.Equal(t, stuck.ID, mockAttachmentStore.Attachments[0].ID)
+		_, err = blobs.Get(ctx, loose.StorageKey)
+		assert.ErrorIs(t, err, domain.ErrBlobNotFound)
+
+		purged, err = attachments.PurgeOrphans(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		assert.Empty(t, mockAttachmentStore.Attachments)
+	})
+
+	t.Run("deleted uploader does not orphan", func(t *testing.T) {
+		kept, err := upload(owner.ID, "kept.txt", "kept")
+		require.NoError(t, err)
+		// Так хранилище помечает вложения удалённого пользователя.
+		for i := range mockAttachmentStore.Attachments {
+			mockAttachmentStore.Attachments[i].UploaderID = uuid.Nil
+		}
+
+		purged, err := attachments.PurgeOrphans(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+		assert.Equal(t, "kept", read(kept.ID, owner.ID))
+	})
+}
+
+// failingBlobs не может удалить объект key.
+type failingBlobs struct {
+	blob.BlobStore
+	key string
+}
+
+func (b failingBlobs) Delete(ctx context.Context, key string) error {
+	if key == b.key {
+		return errors.New("storage unavailable")
+	}
+	return b.BlobStore.Delete(ctx, key)
+}
+
+func TestCleanFileName(t *testing.T) {
+	assert.Equal(t, "report.pdf", cleanFileName("../../etc/report.pdf"))
+	assert.Equal(t, "a.txt", cleanFileName(`dir\a.txt`))
+	assert.Equal(t, "evil.txt", cleanFileName("evil\r\n.txt"))
+	assert.Equal(t, "file", cleanFileName("  "))
+	assert.Equal(t, "file", cleanFileName("/"))
+	assert.Equal(t, maxFileNameLength, len([]rune(cleanFileName(strings.Repeat("я", 300)))))
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/repository"
+)
+
+type ClientService interface {
+	RegisterClient(ctx context.Context, clientID, secret string, scopes []string) (entities.Client, error)
+	AuthenticateClient(ctx context.Context, clientID, secret string) (*entities.Client, error)
+	GrantScopes(client *entities.Client, requested []string) ([]string, error)
+}
+
+type clientService struct {
+	clientRepo repository.ClientStore
+	logger     *slog.Logger
+}
+
+func NewClientService(clientRepo repository.ClientStore, logger *slog.Logger) ClientService {
+	return &clientService{
+		clientRepo: clientRepo,
+		logger:     logger,
+	}
+}
+
+// RegisterClient создаёт клиента или обновляет секрет и scopes уже существующего.
+func (s *clientService) RegisterClient(ctx context.Context, clientID, secret string, scopes []string) (entities.Client, error) {
+	for _, scope := range scopes {
+		if !auth.IsKnownScope(scope) {
+			s.logger.Warn("service: unknown client scope", slog.String("client_id", clientID), slog.String("scope", scope))
+			return entities.Client{}, fmt.Errorf("%w: %s", domain.ErrInvalidScope, scope)
+		}
+	}
+
+	hash, err := auth.HashPassword(secret)
+	if err != nil {
+		s.logger.Error("service: hash client secret failed", slog.String("client_id", clientID), slog.Any("error", err))
+		return entities.Client{}, err
+	}
+
+	existing, err := s.clientRepo.GetClientByClientID(ctx, clientID)
+	if err != nil && !errors.Is(err, domain.ErrClientNotFound) {
+		s.logger.Error("service: client lookup failed", slog.String("client_id", clientID), slog.Any("error", err))
+		return entities.Client{}, err
+	}
+
+	if existing != nil {
+		existing.SecretHash = hash
+		existing.Scopes = scopes
+		updated, err := s.clientRepo.UpdateClient(ctx, existing)
+		if err != nil {
+			s.logger.Error("service: update client failed", slog.String("client_id", clientID), slog.Any("error", err))
+			return entities.Client{}, err
+		}
+		s.logger.Info("service: client updated", slog.String("client_id", clientID))
+		return *updated, nil
+	}
+
+	client, err := s.clientRepo.CreateClient(ctx, clientID, hash, scopes)
+	if err != nil {
+		s.logger.Error("service: create client failed", slog.String("client_id", clientID), slog.Any("error", err))
+		return entities.Client{}, err
+	}
+
+	s.logger.Info("service: client registered", slog.String("client_id", clientID))
+	return client, nil
+}
+
+func (s *clientService) AuthenticateClient(ctx context.Context, clientID, secret string) (*entities.Client, error) {
+	client, err := s.clientRepo.GetClientByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, domain.ErrClientNotFound) {
+			s.logger.Warn("service: unknown client", slog.String("client_id", clientID))
+			return nil, domain.ErrInvalidClient
+		}
+		s.logger.Error("service: client lookup failed", slog.String("client_id", clientID), slog.Any("error", err))
+		return nil, err
+	}
+
+	if ok, _ := auth.CheckPasswordHash(secret, client.SecretHash); !ok {
+		s.logger.Warn("service: invalid client secret", slog.String("client_id", clientID))
+		return nil, domain.ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+// GrantScopes возвращает scopes для токена: без запроса — все разрешённые клиенту,
+// иначе запрошенные, если каждый из них разрешён.
+func (s *clientService) GrantScopes(client *entities.Client, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return client.Scopes, nil
+	}
+	if !auth.HasScopes(client.Scopes, requested...) {
+		s.logger.Warn("service: client requested scope outside of allowed", slog.String("client_id", client.ClientID))
+		return nil, domain.ErrInvalidScope
+	}
+	return requested, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository"
+)
+
+// CommentService ведёт обсуждение задачи. Читать и писать комментарии может каждый, кто
+// видит задачу (роль viewer и выше), менять и удалять — только автор.
+type CommentService interface {
+	CreateComment(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, req models.CreateCommentRequest) (models.CommentResponse, error)
+	GetComments(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) ([]models.CommentResponse, error)
+	UpdateComment(ctx context.Context, todoID, commentID uuid.UUID, userID uuid.UUID, req models.UpdateCommentRequest) (models.CommentResponse, error)
+	DeleteComment(ctx context.Context, todoID, commentID uuid.UUID, userID uuid.UUID) error
+}
+
+type commentService struct {
+	userRepo    repository.Store
+	commentRepo repository.CommentStore
+	access      access
+	logger      *slog.Logger
+}
+
+func NewCommentService(userRepo repository.Store, todoRepo repository.TodoStore, projectRepo repository.ProjectStore, shareRepo repository.ShareStore, commentRepo repository.CommentStore, logger *slog.Logger) CommentService {
+	return &commentService{
+		userRepo:    userRepo,
+		commentRepo: commentRepo,
+		access:      access{todoRepo: todoRepo, projectRepo: projectRepo, shareRepo: shareRepo, logger: logger},
+		logger:      logger,
+	}
+}
+
+// visibleTodo находит задачу (access.findTodo) и проверяет, что пользователь её видит.
+func (s *commentService) visibleTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (*entities.Todo, error) {
+	todo, err := s.access.findTodo(ctx, todoID, userID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrTodoNotFound) {
+			s.logger.Error("service: get commented todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+	if err := s.access.authorizeTodo(ctx, todo, userID, entities.RoleViewer); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// ownComment возвращает комментарий задачи todoID, если его автор — userID.
+func (s *commentService) ownComment(ctx context.Context, todoID, commentID uuid.UUID, userID uuid.UUID) (*entities.Comment, error) {
+	if _, err := s.visibleTodo(ctx, todoID, userID); err != nil {
+		return nil, err
+	}
+	comment, err := s.commentRepo.GetCommentByID(ctx, commentID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrCommentNotFound) {
+			s.logger.Error("service: get comment failed", slog.String("comment_id", commentID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+	if comment.TodoID != todoID {
+		return nil, domain.ErrCommentNotFound
+	}
+	if comment.AuthorID != userID {
+		s.logger.Warn("service: comment belongs to another user", slog.String("comment_id", commentID.String()), slog.String("user_id", userID.String()))
+		return nil, domain.ErrForbidden
+	}
+	return comment, nil
+}
+
+func (s *commentService) CreateComment(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, req models.CreateCommentRequest) (models.CommentResponse, error) {
+	if err := validators.ValidateComment(req.Body); err != nil {
+		return models.CommentResponse{}, err
+	}
+	if _, err := s.visibleTodo(ctx, todoID, userID); err != nil {
+		return models.CommentResponse{}, err
+	}
+
+	comment, err := s.commentRepo.CreateComment(ctx, entities.Comment{TodoID: todoID, AuthorID: userID, Body: req.Body})
+	if err != nil {
+		s.logger.Error("service: create comment failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return models.CommentResponse{}, err
+	}
+
+	s.logger.Info("service: comment created", slog.String("comment_id", comment.ID.String()), slog.String("todo_id", todoID.String()))
+	return s.withAuthors(ctx, []entities.Comment{comment})[0], nil
+}
+
+func (s *commentService) GetComments(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) ([]models.CommentResponse, error) {
+	if _, err := s.visibleTodo(ctx, todoID, userID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.commentRepo.GetCommentsByTodoID(ctx, todoID)
+	if err != nil {
+		s.logger.Error("service: list comments failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	return s.withAuthors(ctx, comments), nil
+}
+
+func (s *commentService) UpdateComment(ctx context.Context, todoID, commentID uuid.UUID, userID uuid.UUID, req models.UpdateCommentRequest) (models.CommentResponse, error) {
+	if err := validators.ValidateComment(req.Body); err != nil {
+		return models.CommentResponse{}, err
+	}
+	comment, err := s.ownComment(ctx, todoID, commentID, userID)
+	if err != nil {
+		return models.CommentResponse{}, err
+	}
+
+	comment.Body = req.Body
+	updated, err := s.commentRepo.UpdateComment(ctx, comment)
+	if err != nil {
+		if !errors.Is(err, domain.ErrCommentNotFound) {
+			s.logger.Error("service: update comment failed", slog.String("comment_id", commentID.String()), slog.Any("error", err))
+		}
+		return models.CommentResponse{}, err
+	}
+
+	s.logger.Info("service: comment updated", slog.String("comment_id", commentID.String()))
+	return s.withAuthors(ctx, []entities.Comment{*updated})[0], nil
+}
+
+func (s *commentService) DeleteComment(ctx context.Context, todoID, commentID uuid.UUID, userID uuid.UUID) error {
+	if _, err := s.ownComment(ctx, todoID, commentID, userID); err != nil {
+		return err
+	}
+
+	if err := s.commentRepo.DeleteComment(ctx, commentID); err != nil {
+		if !errors.Is(err, domain.ErrCommentNotFound) {
+			s.logger.Error("service: delete comment failed", slog.String("comment_id", commentID.String()), slog.Any("error", err))
+		}
+		return err
+	}
+
+	s.logger.Info("service: comment deleted", slog.String("comment_id", commentID.String()))
+	return nil
+}
+
+// withAuthors добавляет к комментариям почту авторов; каждого автора ищет один раз.
+func (s *commentService) withAuthors(ctx context.Context, comments []entities.Comment) []models.CommentResponse {
+	emails := make(map[uuid.UUID]string)
+	resp := make([]models.CommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		email, ok := emails[comment.AuthorID]
+		if !ok {
+			if user, err := s.userRepo.GetUserById(ctx, comment.AuthorID); err == nil {
+				email = user.Email
+			}
+			emails[comment.AuthorID] = email
+		}
+		resp = append(resp, models.CommentResponse{Comment: comment, AuthorEmail: email})
+	}
+	return resp
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository/mocks"
+	"github.com/polzovatel/todo-learning/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentService(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	mockShareStore := mocks.NewMockShareStore()
+	mockCommentStore := mocks.NewMockCommentStore()
+	comments := NewCommentService(mockUserStore, mockTodoStore, nil, mockShareStore, mockCommentStore, slog.Default())
+
+	owner, _ := mockUserStore.CreateUser(ctx, "comment-owner@example.com", "hash")
+	viewer, _ := mockUserStore.CreateUser(ctx, "comment-viewer@example.com", "hash")
+	stranger, _ := mockUserStore.CreateUser(ctx, "comment-stranger@example.com", "hash")
+
+	ctx = tenant.WithWorkspace(ctx, owner.ID)
+	todo, _ := mockTodoStore.CreateTodo(ctx, entities.Todo{WorkspaceID: owner.ID, UserID: owner.ID, Title: "plan"})
+	_, _ = mockShareStore.SaveShare(ctx, entities.Share{ResourceType: entities.ShareResourceTodo, ResourceID: todo.ID, UserID: viewer.ID, Role: entities.RoleViewer, InvitedBy: owner.ID})
+
+	mine, err := comments.CreateComment(ctx, todo.ID, owner.ID, models.CreateCommentRequest{Body: "let's start"})
+	require.NoError(t, err)
+	assert.Equal(t, owner.Email, mine.AuthorEmail)
+
+	t.Run("anyone who sees the todo can discuss it", func(t *testing.T) {
+		reply, err := comments.CreateComment(ctx, todo.ID, viewer.ID, models.CreateCommentRequest{Body: "agreed"})
+		require.NoError(t, err)
+		assert.Equal(t, viewer.ID, reply.AuthorID)
+
+		list, err := comments.GetComments(ctx, todo.ID, viewer.ID)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, owner.Email, list[0].AuthorEmail)
+		assert.Equal(t, viewer.Email, list[1].AuthorEmail)
+
+		_, err = comments.GetComments(ctx, todo.ID, stranger.ID)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, err = comments.CreateComment(ctx, todo.ID, stranger.ID, models.CreateCommentRequest{Body: "hi"})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("only the author edits and deletes", func(t *testing.T) {
+		_, err := comments.UpdateComment(ctx, todo.ID, mine.ID, viewer.ID, models.UpdateCommentRequest{Body: "hijacked"})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		assert.ErrorIs(t, comments.DeleteComment(ctx, todo.ID, mine.ID, viewer.ID), domain.ErrForbidden)
+
+		edited, err := comments.UpdateComment(ctx, todo.ID, mine.ID, owner.ID, models.UpdateCommentRequest{Body: "let's start today"})
+		require.NoError(t, err)
+		assert.Equal(t, "let's start today", edited.Body)
+		assert.NotNil(t, edited.EditedAt)
+
+		require.NoError(t, comments.DeleteComment(ctx, todo.ID, mine.ID, owner.ID))
+		list, _ := comments.GetComments(ctx, todo.ID, owner.ID)
+		assert.Len(t, list, 1)
+		assert.ErrorIs(t, comments.DeleteComment(ctx, todo.ID, mine.ID, owner.ID), domain.ErrCommentNotFound)
+	})
+
+	t.Run("comment must belong to the todo", func(t *testing.T) {
+		other, _ := mockTodoStore.CreateTodo(ctx, entities.Todo{WorkspaceID: owner.ID, UserID: owner.ID, Title: "other"})
+		list, _ := comments.GetComments(ctx, todo.ID, owner.ID)
+		_, err := comments.UpdateComment(ctx, other.ID, list[0].ID, viewer.ID, models.UpdateCommentRequest{Body: "moved"})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, err = comments.UpdateComment(ctx, other.ID, list[0].ID, owner.ID, models.UpdateCommentRequest{Body: "moved"})
+		assert.ErrorIs(t, err, domain.ErrCommentNotFound)
+	})
+
+	t.Run("body is validated", func(t *testing.T) {
+		_, err := comments.CreateComment(ctx, todo.ID, owner.ID, models.CreateCommentRequest{Body: "  "})
+		assert.ErrorIs(t, err, validators.ErrCommentBodyEmpty)
+		_, err = comments.CreateComment(ctx, todo.ID, owner.ID, models.CreateCommentRequest{Body: strings.Repeat("a", validators.MaxCommentLength+1)})
+		assert.ErrorIs(t, err, validators.ErrCommentTooLong)
+	})
+
+	t.Run("todos of other workspaces are not found", func(t *testing.T) {
+		_, err := comments.GetComments(tenant.WithWorkspace(ctx, stranger.ID), todo.ID, owner.ID)
+		assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+	})
+}
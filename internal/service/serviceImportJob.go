@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/jobs"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/tenant"
+	"github.com/polzovatel/todo-learning/internal/todoio"
+)
+
+const (
+	// importJobTTL — сколько хранится состояние импорта после последнего изменения.
+	importJobTTL = 24 * time.Hour
+	// maxImportJobs — сколько импортов процесс выполняет одновременно, остальные ждут.
+	maxImportJobs = 2
+	// importProgressEvery — через сколько строк сохраняется прогресс.
+	importProgressEvery = 100
+)
+
+// errImportInterrupted — импорт прервала остановка сервера.
+var errImportInterrupted = errors.New("import interrupted by server shutdown, nothing was saved")
+
+// ImportJobService импортирует резервные копии других сервисов в фоне: файл читается
+// сразу, а задачи создаются после ответа, и клиент опрашивает прогресс по ID импорта.
+type ImportJobService interface {
+	// StartImport читает файл source и ставит импорт его строк в очередь. Нечитаемый
+	// файл возвращает ошибку с domain.ErrInvalidImport, и импорт не создаётся.
+	StartImport(ctx context.Context, userID uuid.UUID, source todoio.Source, r io.Reader, dryRun bool) (models.ImportJob, error)
+	// GetImport возвращает импорт пользователя в текущем рабочем пространстве.
+	GetImport(ctx context.Context, userID, jobID uuid.UUID) (models.ImportJob, error)
+	// Shutdown прерывает импорты и ждёт, пока они откатятся и запишут итог.
+	Shutdown(ctx context.Context) error
+}
+
+type importJobService struct {
+	transfers TransferService
+	store     jobs.Store
+	logger    *slog.Logger
+	slots     chan struct{}
+	running   sync.WaitGroup
+	stopping  context.Context
+	stop      context.CancelFunc
+}
+
+func NewImportJobService(transfers TransferService, store jobs.Store, logger *slog.Logger) ImportJobService {
+	stopping, stop := context.WithCancel(context.Background())
+	return &importJobService{
+		transfers: transfers,
+		store:     store,
+		logger:    logger,
+		slots:     make(chan struct{}, maxImportJobs),
+		stopping:  stopping,
+		stop:      stop,
+	}
+}
+
+func (s *importJobService) StartImport(ctx context.Context, userID uuid.UUID, source todoio.Source, r io.Reader, dryRun bool) (models.ImportJob, error) {
+	rows, err := source.Decode(r)
+	if err == nil {
+		err = checkImportSize(rows)
+	}
+	if err != nil {
+		s.logger.Warn("service: import file rejected", slog.String("user_id", userID.String()), slog.String("source", source.Name), slog.Any("error", err))
+		return models.ImportJob{}, err
+	}
+
+	now := time.Now().UTC()
+	job := models.ImportJob{
+		ID:          uuid.New(),
+		UserID:      userID,
+		WorkspaceID: tenant.WorkspaceID(ctx, userID),
+		Source:      source.Name,
+		DryRun:      dryRun,
+		Status:      models.ImportQueued,
+		Total:       len(rows),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.store.Save(ctx, job, importJobTTL); err != nil {
+		s.logger.Error("service: failed to save import job", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return models.ImportJob{}, err
+	}
+
+	// Импорт переживает запрос, но сохраняет его значения — рабочее пространство.
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		defer cancel()
+		defer context.AfterFunc(s.stopping, cancel)()
+		s.run(jobCtx, job, rows)
+	}()
+
+	s.logger.Info("service: import queued", slog.String("user_id", userID.String()), slog.String("job_id", job.ID.String()), slog.String("source", source.Name), slog.Int("rows", len(rows)))
+	return job, nil
+}
+
+func (s *importJobService) run(ctx context.Context, job models.ImportJob, rows []todoio.Row) {
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		s.finish(ctx, job, nil, ctx.Err())
+		return
+	}
+
+	job.Status = models.ImportRunning
+	s.save(ctx, job)
+	report, err := s.transfers.ImportRows(ctx, job.UserID, job.Source, rows, job.DryRun, func(processed int) {
+		if processed-job.Processed < importProgressEvery {
+			return
+		}
+		job.Processed = processed
+		s.save(ctx, job)
+	})
+	var result *models.ImportReport
+	if err == nil || errors.Is(err, domain.ErrImportRows) {
+		result = &report
+	}
+	s.finish(ctx, job, result, err)
+}
+
+func (s *importJobService) finish(ctx context.Context, job models.ImportJob, report *models.ImportReport, err error) {
+	// Итог записывается и после отмены контекста импорта.
+	ctx = context.WithoutCancel(ctx)
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+	job.Report = report
+	switch {
+	case err == nil:
+		job.Status = models.ImportSucceeded
+		job.Processed = job.Total
+		s.logger.Info("service: import finished", slog.String("job_id", job.ID.String()), slog.Int("imported", report.Imported))
+	case errors.Is(err, context.Canceled):
+		job.Status = models.ImportFailed
+		job.Error = errImportInterrupted.Error()
+		s.logger.Warn("service: import interrupted", slog.String("job_id", job.ID.String()))
+	default:
+		job.Status = models.ImportFailed
+		job.Error = err.Error()
+		if report != nil {
+			job.Processed = job.Total
+		}
+		s.logger.Warn("service: import failed", slog.String("job_id", job.ID.String()), slog.Any("error", err))
+	}
+	s.save(ctx, job)
+}
+
+// save записывает состояние импорта; ошибка хранилища не прерывает сам импорт.
+func (s *importJobService) save(ctx context.Context, job models.ImportJob) {
+	job.UpdatedAt = time.Now().UTC()
+	if err := s.store.Save(ctx, job, importJobTTL); err != nil {
+		s.logger.Error("service: failed to save import job", slog.String("job_id", job.ID.String()), slog.Any("error", err))
+	}
+}
+
+func (s *importJobService) GetImport(ctx context.Context, userID, jobID uuid.UUID) (models.ImportJob, error) {
+	job, err := s.store.Get(ctx, jobID)
+	if err != nil {
+		s.logger.Error("service: failed to get import job", slog.String("job_id", jobID.String()), slog.Any("error", err))
+		return models.ImportJob{}, err
+	}
+	// Чужой импорт неотличим от несуществующего.
+	if job == nil || job.UserID != userID || job.WorkspaceID != tenant.WorkspaceID(ctx, userID) {
+		return models.ImportJob{}, domain.ErrImportJobNotFound
+	}
+	return *job, nil
+}
+
+func (s *importJobService) Shutdown(ctx context.Context) error {
+	s.stop()
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/jobs"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/tenant"
+	"github.com/polzovatel/todo-learning/internal/todoio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingTransfers сообщает о прогрессе и ждёт отмены импорта или release.
+type blockingTransfers struct {
+	TransferService
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingTransfers) ImportRows(ctx context.Context, userID uuid.UUID, source string, rows []todoio.Row, dryRun bool, progress func(processed int)) (models.ImportReport, error) {
+	progress(importProgressEvery)
+	close(b.started)
+	select {
+	case <-ctx.Done():
+		return models.ImportReport{}, ctx.Err()
+	case <-b.release:
+		return models.ImportReport{Total: len(rows), Valid: len(rows), Imported: len(rows)}, nil
+	}
+}
+
+func linesSource(count int) (todoio.Source, io.Reader) {
+	source := todoio.Source{Name: "test", Decode: func(r io.Reader) ([]todoio.Row, error) {
+		return make([]todoio.Row, count), nil
+	}}
+	return source, strings.NewReader("")
+}
+
+func waitForStatus(t *testing.T, service ImportJobService, ctx context.Context, userID, jobID uuid.UUID, status string) models.ImportJob {
+	var job models.ImportJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = service.GetImport(ctx, userID, jobID)
+		require.NoError(t, err)
+		return job.Status == status
+	}, time.Second, time.Millisecond)
+	return job
+}
+
+func TestImportJobService(t *testing.T) {
+	userID := uuid.New()
+	workspaceID := uuid.New()
+	ctx := tenant.WithWorkspace(context.Background(), workspaceID)
+
+	t.Run("progress and result", func(t *testing.T) {
+		transfers := &blockingTransfers{started: make(chan struct{}), release: make(chan struct{})}
+		service := NewImportJobService(transfers, jobs.NewMemoryStore(), slog.Default())
+		source, r := linesSource(250)
+
+		job, err := service.StartImport(ctx, userID, source, r, false)
+		require.NoError(t, err)
+		assert.Equal(t, models.ImportQueued, job.Status)
+		assert.Equal(t, 250, job.Total)
+		assert.Equal(t, workspaceID, job.WorkspaceID)
+
+		<-transfers.started
+		running := waitForStatus(t, service, ctx, userID, job.ID, models.ImportRunning)
+		assert.Equal(t, importProgressEvery, running.Processed)
+
+		// Импорт виден только владельцу в том же рабочем пространстве.
+		_, err = service.GetImport(ctx, uuid.New(), job.ID)
+		assert.ErrorIs(t, err, domain.ErrImportJobNotFound)
+		_, err = service.GetImport(context.Background(), userID, job.ID)
+		assert.ErrorIs(t, err, domain.ErrImportJobNotFound)
+
+		close(transfers.release)
+		done := waitForStatus(t, service, ctx, userID, job.ID, models.ImportSucceeded)
+		assert.Equal(t, 250, done.Processed)
+		require.NotNil(t, done.Report)
+		assert.Equal(t, 250, done.Report.Imported)
+		assert.NotNil(t, done.FinishedAt)
+	})
+
+	t.Run("shutdown interrupts running imports", func(t *testing.T) {
+		transfers := &blockingTransfers{started: make(chan struct{}), release: make(chan struct{})}
+		store := jobs.NewMemoryStore()
+		service := NewImportJobService(transfers, store, slog.Default())
+		source, r := linesSource(1)
+
+		job, err := service.StartImport(ctx, userID, source, r, false)
+		require.NoError(t, err)
+		<-transfers.started
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, service.Shutdown(shutdownCtx))
+		saved, err := store.Get(ctx, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ImportFailed, saved.Status)
+		assert.Equal(t, errImportInterrupted.Error(), saved.Error)
+		assert.Nil(t, saved.Report)
+	})
+
+	t.Run("unreadable file creates no job", func(t *testing.T) {
+		service := NewImportJobService(&blockingTransfers{}, jobs.NewMemoryStore(), slog.Default())
+		source, _ := todoio.LookupSource(todoio.SourceTrello)
+		_, err := service.StartImport(ctx, userID, source, strings.NewReader("{"), false)
+		assert.ErrorIs(t, err, domain.ErrInvalidImport)
+
+		source, r := linesSource(maxImportRows + 1)
+		_, err = service.StartImport(ctx, userID, source, r, false)
+		assert.ErrorIs(t, err, domain.ErrInvalidImport)
+	})
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/mail"
+	"github.com/polzovatel/todo-learning/internal/repository"
+)
+
+// Не больше magicLinkLimit ссылок на один email за magicLinkWindow.
+const (
+	magicLinkLimit  = 3
+	magicLinkWindow = 15 * time.Minute
+)
+
+type MagicLinkService interface {
+	// RequestLink отправляет ссылку входа. Для неизвестного email молча ничего не делает.
+	RequestLink(ctx context.Context, email string) error
+	// Login обменивает токен из ссылки на пользователя; токен можно использовать один раз.
+	Login(ctx context.Context, token string) (*entities.User, error)
+}
+
+type magicLinkService struct {
+	userRepo   repository.Store
+	linkRepo   repository.MagicLinkStore
+	tokenRepo  repository.TokenStore
+	transactor repository.Transactor
+	signer     *auth.JWTSigner
+	mailer     mail.Mailer
+	callback   string
+	ttl        time.Duration
+	logger     *slog.Logger
+}
+
+func NewMagicLinkService(userRepo repository.Store, linkRepo repository.MagicLinkStore, tokenRepo repository.TokenStore, transactor repository.Transactor, signer *auth.JWTSigner, mailer mail.Mailer, callback string, ttl time.Duration, logger *slog.Logger) MagicLinkService {
+	return &magicLinkService{
+		userRepo:   userRepo,
+		linkRepo:   linkRepo,
+		tokenRepo:  tokenRepo,
+		transactor: transactor,
+		signer:     signer,
+		mailer:     mailer,
+		callback:   callback,
+		ttl:        ttl,
+		logger:     logger,
+	}
+}
+
+func (s *magicLinkService) RequestLink(ctx context.Context, email string) error {
+	// Подсчёт и запись идут в одной транзакции под блокировкой email: иначе одновременные
+	// запросы увидели бы один и тот же счётчик и выдали ссылок больше лимита.
+	var user *entities.User
+	var token string
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.linkRepo.LockMagicLinks(ctx, email); err != nil {
+			return err
+		}
+		count, err := s.linkRepo.CountMagicLinksSince(ctx, email, time.Now().Add(-magicLinkWindow))
+		if err != nil {
+			s.logger.Error("service: count magic links failed", slog.String("email", email), slog.Any("error", err))
+			return err
+		}
+		if count >= magicLinkLimit {
+			s.logger.Warn("service: magic link rate limit exceeded", slog.String("email", email))
+			return domain.ErrTooManyRequests
+		}
+
+		user, err = s.userRepo.GetUserByEmail(ctx, email)
+		if err != nil {
+			if errors.Is(err, domain.ErrUserNotFound) {
+				s.logger.Info("service: magic link requested for unknown email", slog.String("email", email))
+				user = nil
+				return nil
+			}
+			s.logger.Error("service: get user for magic link failed", slog.String("email", email), slog.Any("error", err))
+			return err
+		}
+
+		var jti string
+		token, jti, err = s.signer.GenerateMagicLinkToken(user.ID.String(), user.Email, s.ttl)
+		if err != nil {
+			s.logger.Error("service: generate magic link token failed", slog.String("email", email), slog.Any("error", err))
+			return err
+		}
+		if err := s.linkRepo.CreateMagicLink(ctx, jti, user.Email, time.Now().Add(s.ttl)); err != nil {
+			s.logger.Error("service: save magic link failed", slog.String("email", email), slog.Any("error", err))
+			return err
+		}
+		return nil
+	})
+	if err != nil || user == nil {
+		return err
+	}
+
+	link, err := url.Parse(s.callback)
+	if err != nil {
+		s.logger.Error("service: invalid magic link callback url", slog.String("url", s.callback), slog.Any("error", err))
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Вход в Todo",
+		Body:    fmt.Sprintf("Чтобы войти, перейдите по ссылке (действует %s, одноразовая):\n%s", s.ttl, link),
+	})
+	if err != nil {
+		s.logger.Error("service: send magic link failed", slog.String("email", email), slog.Any("error", err))
+		return err
+	}
+
+	s.logger.Info("service: magic link sent", slog.String("email", email))
+	return nil
+}
+
+func (s *magicLinkService) Login(ctx context.Context, token string) (*entities.User, error) {
+	claims, err := s.signer.ValidateToken(token)
+	if err != nil || claims.Type != auth.TokenTypeMagicLink || claims.ID == "" || claims.ExpiresAt == nil {
+		s.logger.Warn("service: invalid magic link token", slog.Any("error", err))
+		return nil, domain.ErrMagicLinkInvalid
+	}
+
+	// Использованный токен ещё и отзывается, чтобы интроспекция не считала его активным.
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.linkRepo.UseMagicLink(ctx, claims.ID); err != nil {
+			return err
+		}
+		return s.tokenRepo.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time)
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrTokenRevoked) {
+			err = domain.ErrMagicLinkInvalid
+		}
+		if !errors.Is(err, domain.ErrMagicLinkInvalid) {
+			s.logger.Error("service: use magic link failed", slog.String("jti", claims.ID), slog.Any("error", err))
+		}
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		s.logger.Warn("service: magic link with invalid user id", slog.String("jti", claims.ID))
+		return nil, domain.ErrMagicLinkInvalid
+	}
+	user, err := s.userRepo.GetUserById(ctx, userID)
+	if err != nil {
+		s.logger.Error("service: get user for magic link login failed", slog.String("user_id", claims.UserID), slog.Any("error", err))
+		return nil, err
+	}
+
+	s.logger.Info("service: magic link login succeeded", slog.String("user_id", user.ID.String()))
+	return user, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/polzovatel/todo-learning/config"
+	"github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/mail"
+	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowCountRepository растягивает подсчёт ссылок, чтобы одновременные запросы
+// гарантированно пересеклись между подсчётом и записью.
+type slowCountRepository struct {
+	*in_memory.InMemoryRepository
+}
+
+func (r slowCountRepository) CountMagicLinksSince(ctx context.Context, email string, since time.Time) (int, error) {
+	count, err := r.InMemoryRepository.CountMagicLinksSince(ctx, email, since)
+	time.Sleep(20 * time.Millisecond)
+	return count, err
+}
+
+func TestMagicLinkService_ConcurrentRequestsStayWithinLimit(t *testing.T) {
+	ctx := context.Background()
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	signer, err := auth.NewJWTSigner(&config.Config{JWTAlg: "HS256", JWTSecret: "secret", AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	require.NoError(t, err)
+	links := NewMagicLinkService(repo, slowCountRepository{repo}, repo, repo, signer, mail.NewLogMailer(slog.Default()), "http://localhost/callback", 10*time.Minute, slog.Default())
+
+	_, err = repo.CreateUser(ctx, "burst@example.com", "hash")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2*magicLinkLimit)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = links.RequestLink(ctx, "burst@example.com")
+		}()
+	}
+	wg.Wait()
+
+	sent := 0
+	for _, err := range errs {
+		if err == nil {
+			sent++
+			continue
+		}
+		assert.ErrorIs(t, err, domain.ErrTooManyRequests)
+	}
+	assert.Equal(t, magicLinkLimit, sent)
+	count, err := repo.CountMagicLinksSince(ctx, "burst@example.com", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, magicLinkLimit, count)
+}
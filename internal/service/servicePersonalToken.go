@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository"
+)
+
+// PersonalTokenService выпускает и отзывает персональные токены доступа пользователя.
+// Проверяет такие токены TokenService.
+type PersonalTokenService interface {
+	CreateToken(ctx context.Context, userID uuid.UUID, req models.CreatePersonalTokenRequest) (models.PersonalTokenResponse, error)
+	GetTokens(ctx context.Context, userID uuid.UUID) ([]entities.PersonalToken, error)
+	RevokeToken(ctx context.Context, userID, tokenID uuid.UUID) error
+}
+
+type personalTokenService struct {
+	patRepo repository.PersonalTokenStore
+	logger  *slog.Logger
+}
+
+func NewPersonalTokenService(patRepo repository.PersonalTokenStore, logger *slog.Logger) PersonalTokenService {
+	return &personalTokenService{
+		patRepo: patRepo,
+		logger:  logger,
+	}
+}
+
+func (s *personalTokenService) CreateToken(ctx context.Context, userID uuid.UUID, req models.CreatePersonalTokenRequest) (models.PersonalTokenResponse, error) {
+	token, hash, err := auth.NewPersonalToken()
+	if err != nil {
+		s.logger.Error("service: generate personal token failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return models.PersonalTokenResponse{}, err
+	}
+	pat := entities.PersonalToken{UserID: userID, Name: req.Name, TokenHash: hash}
+	if req.ExpiresInDays != nil {
+		expiresAt := time.Now().UTC().AddDate(0, 0, *req.ExpiresInDays)
+		pat.ExpiresAt = &expiresAt
+	}
+
+	created, err := s.patRepo.CreatePersonalToken(ctx, pat)
+	if err != nil {
+		s.logger.Error("service: create personal token failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return models.PersonalTokenResponse{}, err
+	}
+
+	s.logger.Info("service: personal token created", slog.String("user_id", userID.String()), slog.String("token_id", created.ID.String()))
+	return models.PersonalTokenResponse{Token: token, PersonalToken: created}, nil
+}
+
+func (s *personalTokenService) GetTokens(ctx context.Context, userID uuid.UUID) ([]entities.PersonalToken, error) {
+	tokens, err := s.patRepo.GetPersonalTokensByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("service: get personal tokens failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (s *personalTokenService) RevokeToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	if err := s.patRepo.RevokePersonalToken(ctx, userID, tokenID); err != nil {
+		if errors.Is(err, domain.ErrPersonalTokenNotFound) {
+			s.logger.Warn("service: personal token not found for revoke", slog.String("token_id", tokenID.String()))
+			return err
+		}
+		s.logger.Error("service: revoke personal token failed", slog.String("token_id", tokenID.String()), slog.Any("error", err))
+		return err
+	}
+
+	s.logger.Info("service: personal token revoked", slog.String("user_id", userID.String()), slog.String("token_id", tokenID.String()))
+	return nil
+}
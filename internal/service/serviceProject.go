@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository"
+	"github.com/polzovatel/todo-learning/internal/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultProjectColor = "#808080"
+
+type ProjectService interface {
+	CreateProject(ctx context.Context, userID uuid.UUID, req models.CreateProjectRequest) (entities.Project, error)
+	GetProjectByID(ctx context.Context, projectID uuid.UUID, userID uuid.UUID) (*entities.Project, error)
+	GetProjects(ctx context.Context, userID uuid.UUID, includeArchived bool) ([]entities.Project, error)
+	UpdateProject(ctx context.Context, projectID uuid.UUID, userID uuid.UUID, req models.UpdateProjectRequest) (*entities.Project, error)
+	DeleteProject(ctx context.Context, projectID uuid.UUID, userID uuid.UUID, todos string) error
+}
+
+type projectService struct {
+	projectRepo repository.ProjectStore
+	access      access
+	cache       *redis.Client
+	logger      *slog.Logger
+}
+
+func NewProjectService(projectRepo repository.ProjectStore, shareRepo repository.ShareStore, redis *redis.Client, logger *slog.Logger) ProjectService {
+	return &projectService{
+		projectRepo: projectRepo,
+		access:      access{projectRepo: projectRepo, shareRepo: shareRepo, logger: logger},
+		cache:       redis,
+		logger:      logger,
+	}
+}
+
+func (s *projectService) CreateProject(ctx context.Context, userID uuid.UUID, req models.CreateProjectRequest) (entities.Project, error) {
+	if err := validators.ValidateProject(req.Name); err != nil {
+		return entities.Project{}, err
+	}
+	color := req.Color
+	if color == "" {
+		color = defaultProjectColor
+	}
+
+	project, err := s.projectRepo.CreateProject(ctx, entities.Project{
+		WorkspaceID: tenant.WorkspaceID(ctx, userID),
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		Color:       color,
+		SortOrder:   req.SortOrder,
+	})
+	if err != nil {
+		s.logger.Error("service: create project failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return entities.Project{}, err
+	}
+
+	s.logger.Info("service: project created", slog.String("project_id", project.ID.String()), slog.String("user_id", userID.String()))
+	return project, nil
+}
+
+func (s *projectService) GetProjectByID(ctx context.Context, projectID uuid.UUID, userID uuid.UUID) (*entities.Project, error) {
+	return s.authorizedProject(ctx, projectID, userID, entities.RoleViewer)
+}
+
+func (s *projectService) authorizedProject(ctx context.Context, projectID uuid.UUID, userID uuid.UUID, need string) (*entities.Project, error) {
+	project, err := s.access.findProject(ctx, projectID, userID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrProjectNotFound) {
+			s.logger.Error("service: get project failed", slog.String("project_id", projectID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+	if err := s.access.authorizeProject(ctx, project, userID, need); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (s *projectService) GetProjects(ctx context.Context, userID uuid.UUID, includeArchived bool) ([]entities.Project, error) {
+	projects, err := s.projectRepo.GetProjectsByUserID(ctx, tenant.WorkspaceID(ctx, userID), userID, includeArchived)
+	if err != nil {
+		s.logger.Error("service: list projects failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (s *projectService) UpdateProject(ctx context.Context, projectID uuid.UUID, userID uuid.UUID, req models.UpdateProjectRequest) (*entities.Project, error) {
+	project, err := s.authorizedProject(ctx, projectID, userID, entities.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *project
+	if req.Name != nil {
+		if err := validators.ValidateProject(*req.Name); err != nil {
+			return nil, err
+		}
+		updated.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Color != nil {
+		updated.Color = *req.Color
+	}
+	if req.Archived != nil {
+		updated.Archived = *req.Archived
+	}
+	if req.SortOrder != nil {
+		updated.SortOrder = *req.SortOrder
+	}
+
+	saved, err := s.projectRepo.UpdateProject(ctx, &updated)
+	if err != nil {
+		s.logger.Error("service: update project failed", slog.String("project_id", projectID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	s.logger.Info("service: project updated", slog.String("project_id", projectID.String()))
+	return saved, nil
+}
+
+// DeleteProject удаляет проект; нужна роль owner. todos=cascade переносит его задачи в корзину,
+// inbox (по умолчанию) — во «Входящие» их владельцев.
+func (s *projectService) DeleteProject(ctx context.Context, projectID uuid.UUID, userID uuid.UUID, todos string) error {
+	project, err := s.authorizedProject(ctx, projectID, userID, entities.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	cascade := todos == models.ProjectTodosCascade
+	affected, err := s.projectRepo.DeleteProject(ctx, project.WorkspaceID, projectID, cascade)
+	if err != nil {
+		s.logger.Error("service: delete project failed", slog.String("project_id", projectID.String()), slog.Any("error", err))
+		return err
+	}
+
+	if s.cache != nil {
+		keys := []string{todosListKey(project.WorkspaceID, project.UserID)}
+		for _, id := range affected {
+			keys = append(keys, "todo:"+id.String())
+		}
+		if err := s.cache.Del(ctx, keys...).Err(); err != nil {
+			s.logger.Warn("service: failed to invalidate todo cache", slog.String("project_id", projectID.String()))
+		}
+	}
+
+	s.logger.Info("service: project deleted", slog.String("project_id", projectID.String()), slog.Bool("cascade", cascade), slog.Int("todos", len(affected)))
+	return nil
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/mail"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository"
+)
+
+// ShareService выдаёт доступ к задачам и проектам. Общий ресурс виден получателю из
+// любого его пространства (access.findTodo). resourceType — entities.ShareResourceTodo
+// или entities.ShareResourceProject.
+type ShareService interface {
+	// Share выдаёт доступ пользователю с указанной почтой или меняет его роль; нужна роль owner.
+	Share(ctx context.Context, resourceType string, resourceID uuid.UUID, userID uuid.UUID, req models.CreateShareRequest) (models.ShareResponse, error)
+	GetShares(ctx context.Context, resourceType string, resourceID uuid.UUID, userID uuid.UUID) ([]models.ShareResponse, error)
+	// RevokeShare отзывает доступ collaboratorID; владелец может отозвать любой доступ,
+	// участник — отказаться от своего.
+	RevokeShare(ctx context.Context, resourceType string, resourceID uuid.UUID, userID uuid.UUID, collaboratorID uuid.UUID) error
+	SharedWithMe(ctx context.Context, userID uuid.UUID) (models.SharedWithMeResponse, error)
+}
+
+type shareService struct {
+	userRepo  repository.Store
+	shareRepo repository.ShareStore
+	access    access
+	mailer    mail.Mailer
+	logger    *slog.Logger
+}
+
+func NewShareService(userRepo repository.Store, todoRepo repository.TodoStore, projectRepo repository.ProjectStore, shareRepo repository.ShareStore, mailer mail.Mailer, logger *slog.Logger) ShareService {
+	return &shareService{
+		userRepo:  userRepo,
+		shareRepo: shareRepo,
+		access:    access{todoRepo: todoRepo, projectRepo: projectRepo, shareRepo: shareRepo, logger: logger},
+		mailer:    mailer,
+		logger:    logger,
+	}
+}
+
+// sharedResource — то, чем делятся: его создатель и название для письма.
+type sharedResource struct {
+	ownerID uuid.UUID
+	title   string
+	role    string
+}
+
+func (s *shareService) resource(ctx context.Context, resourceType string, resourceID uuid.UUID, userID uuid.UUID) (sharedResource, error) {
+	if resourceType == entities.ShareResourceProject {
+		project, err := s.access.findProject(ctx, resourceID, userID)
+		if err != nil {
+			if !errors.Is(err, domain.ErrProjectNotFound) {
+				s.logger.Error("service: get shared project failed", slog.String("project_id", resourceID.String()), slog.Any("error", err))
+			}
+			return sharedResource{}, err
+		}
+		role, err := s.access.projectRole(ctx, project, userID)
+		return sharedResource{ownerID: project.UserID, title: project.Name, role: role}, err
+	}
+
+	todo, err := s.access.findTodo(ctx, resourceID, userID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrTodoNotFound) {
+			s.logger.Error("service: get shared todo failed", slog.String("todo_id", resourceID.String()), slog.Any("error", err))
+		}
+		return sharedResource{}, err
+	}
+	role, err := s.access.todoRole(ctx, todo, userID)
+	return sharedResource{ownerID: todo.UserID, title: todo.Title, role: role}, err
+}
+
+func (s *shareService) authorized(ctx context.Context, resourceType string, resourceID uuid.UUID, userID uuid.UUID, need string) (sharedResource, error) {
+	res, err := s.resource(ctx, resourceType, resourceID, userID)
+	if err != nil {
+		return sharedResource{}, err
+	}
+	if entities.RoleRank(res.role) < entities.RoleRank(need) {
+		s.logger.Warn("service: share access forbidden", slog.String("resource_id", resourceID.String()), slog.String("user_id", userID.String()), slog.String("need", need))
+		return sharedResource{}, domain.ErrForbidden
+	}
+	return res, nil
+}
+
+func (s *shareService) Share(ctx context.Context, resourceType string, resourceID uuid.UUID, userID uuid.UUID, req models.CreateShareRequest) (models.ShareResponse, error) {
+	res, err := s.authorized(ctx, resourceType, resourceID, userID, entities.RoleOwner)
+	if err != nil {
+		return models.ShareResponse{}, err
+	}
+
+	collaborator, err := s.userRepo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if !errors.Is(err, domain.ErrUserNotFound) {
+			s.logger.Error("service: share user lookup failed", slog.Any("error", err))
+		}
+		return models.ShareResponse{}, err
+	}
+	if collaborator.ID == res.ownerID || collaborator.ID == userID {
+		return models.ShareResponse{}, domain.ErrInvalidShare
+	}
+
+	share, err := s.shareRepo.SaveShare(ctx, entities.Share{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		UserID:       collaborator.ID,
+		Role:         req.Role,
+		InvitedBy:    userID,
+	})
+	if err != nil {
+		s.logger.Error("service: save share failed", slog.String("resource_id", resourceID.String()), slog.Any("error", err))
+		return models.ShareResponse{}, err
+	}
+
+	// Доступ уже выдан, поэтому неотправленное письмо запрос не проваливает.
+	kind, kindTo := "задачей", "задаче"
+	if resourceType == entities.ShareResourceProject {
+		kind, kindTo = "проектом", "проекту"
+	}
+	inviter := userID.String()
+	if user, err := s.userRepo.GetUserById(ctx, userID); err == nil {
+		inviter = user.Email
+	}
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      collaborator.Email,
+		Subject: fmt.Sprintf("С вами поделились %s «%s»", kind, res.title),
+		Body:    fmt.Sprintf("Пользователь %s открыл вам доступ к %s «%s» (роль: %s).", inviter, kindTo, res.title, share.Role),
+	})
+	if err != nil {
+		s.logger.Warn("service: send share invite failed", slog.String("share_id", share.ID.String()), slog.Any("error", err))
+	}
+
+	s.logger.Info("service: resource shared", slog.String("resource_id", resourceID.String()), slog.String("user_id", collaborator.ID.String()), slog.String("role", share.Role))
+	return models.ShareResponse{Share: share, Email: collaborator.Email}, nil
+}
+
+func (s *shareService) GetShares(ctx context.Context, resourceType string, resourceID uuid.UUID, userID uuid.UUID) ([]models.ShareResponse, error) {
+	if _, err := s.authorized(ctx, resourceType, resourceID, userID, entities.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	shares, err := s.shareRepo.GetSharesByResource(ctx, resourceType, resourceID)
+	if err != nil {
+		s.logger.Error("service: list shares failed", slog.String("resource_id", resourceID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	resp := make([]models.ShareResponse, 0, len(shares))
+	for _, share := range shares {
+		item := models.ShareResponse{Share: share}
+		if user, err := s.userRepo.GetUserById(ctx, share.UserID); err == nil {
+			item.Email = user.Email
+		}
+		resp = append(resp, item)
+	}
+	return resp, nil
+}
+
+func (s *shareService) RevokeShare(ctx context.Context, resourceType string, resourceID uuid.UUID, userID uuid.UUID, collaboratorID uuid.UUID) error {
+	need := entities.RoleOwner
+	if collaboratorID == userID {
+		need = entities.RoleViewer
+	}
+	if _, err := s.authorized(ctx, resourceType, resourceID, userID, need); err != nil {
+		return err
+	}
+
+	if err := s.shareRepo.DeleteShare(ctx, resourceType, resourceID, collaboratorID); err != nil {
+		if !errors.Is(err, domain.ErrShareNotFound) {
+			s.logger.Error("service: delete share failed", slog.String("resource_id", resourceID.String()), slog.Any("error", err))
+		}
+		return err
+	}
+
+	s.logger.Info("service: share revoked", slog.String("resource_id", resourceID.String()), slog.String("user_id", collaboratorID.String()))
+	return nil
+}
+
+// SharedWithMe возвращает ресурсы всех пространств, к которым пользователю выдан доступ
+// напрямую, новые первыми. Подзадачи и задачи общих проектов отдельно не перечисляются.
+func (s *shareService) SharedWithMe(ctx context.Context, userID uuid.UUID) (models.SharedWithMeResponse, error) {
+	shares, err := s.shareRepo.GetSharesByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("service: list shared with user failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return models.SharedWithMeResponse{}, err
+	}
+
+	resp := models.SharedWithMeResponse{Todos: make([]models.SharedTodo, 0), Projects: make([]models.SharedProject, 0)}
+	for _, share := range shares {
+		switch share.ResourceType {
+		case entities.ShareResourceTodo:
+			todo, err := s.access.findTodo(ctx, share.ResourceID, userID)
+			if errors.Is(err, domain.ErrTodoNotFound) {
+				continue
+			}
+			if err != nil {
+				s.logger.Error("service: get shared todo failed", slog.String("todo_id", share.ResourceID.String()), slog.Any("error", err))
+				return models.SharedWithMeResponse{}, err
+			}
+			resp.Todos = append(resp.Todos, models.SharedTodo{Todo: *todo, Role: share.Role})
+		case entities.ShareResourceProject:
+			project, err := s.access.findProject(ctx, share.ResourceID, userID)
+			if errors.Is(err, domain.ErrProjectNotFound) {
+				continue
+			}
+			if err != nil {
+				s.logger.Error("service: get shared project failed", slog.String("project_id", share.ResourceID.String()), slog.Any("error", err))
+				return models.SharedWithMeResponse{}, err
+			}
+			resp.Projects = append(resp.Projects, models.SharedProject{Project: *project, Role: share.Role})
+		}
+	}
+	return resp, nil
+}
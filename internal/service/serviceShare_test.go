@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/mail"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingMailer struct {
+	sent []mail.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestShareService_TodoShares(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	mockShareStore := mocks.NewMockShareStore()
+	mailer := &recordingMailer{}
+	todos := NewTodoService(mockUserStore, mockTodoStore, nil, mockShareStore, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
+	shares := NewShareService(mockUserStore, mockTodoStore, nil, mockShareStore, mailer, slog.Default())
+
+	owner, _ := mockUserStore.CreateUser(ctx, "share-owner@example.com", "hash")
+	viewer, _ := mockUserStore.CreateUser(ctx, "share-viewer@example.com", "hash")
+	editor, _ := mockUserStore.CreateUser(ctx, "share-editor@example.com", "hash")
+	stranger, _ := mockUserStore.CreateUser(ctx, "share-stranger@example.com", "hash")
+
+	todo, _ := todos.CreateTodo(ctx, owner.ID, models.CreateTodoRequest{Title: "plan"})
+	subtask, _ := todos.CreateTodo(ctx, owner.ID, models.CreateTodoRequest{Title: "step", ParentID: &todo.ID})
+
+	share := func(email, role string) error {
+		_, err := shares.Share(ctx, entities.ShareResourceTodo, todo.ID, owner.ID, models.CreateShareRequest{Email: email, Role: role})
+		return err
+	}
+	require.NoError(t, share(viewer.Email, entities.RoleViewer))
+	require.NoError(t, share(editor.Email, entities.RoleEditor))
+	require.Len(t, mailer.sent, 2)
+	assert.Equal(t, viewer.Email, mailer.sent[0].To)
+
+	t.Run("roles apply to the todo and its subtasks", func(t *testing.T) {
+		_, err := todos.GetTodoByID(ctx, subtask.ID, viewer.ID)
+		assert.NoError(t, err)
+		_, err = todos.GetTodoByID(ctx, todo.ID, stranger.ID)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+
+		title := "plan v2"
+		_, err = todos.UpdateTodo(ctx, todo.ID, viewer.ID, models.UpdateTodoRequest{Title: &title})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		updated, err := todos.UpdateTodo(ctx, todo.ID, editor.ID, models.UpdateTodoRequest{Title: &title})
+		require.NoError(t, err)
+		assert.Equal(t, owner.ID, updated.UserID)
+
+		assert.ErrorIs(t, todos.DeleteTodo(ctx, subtask.ID, editor.ID, "", nil), domain.ErrForbidden)
+	})
+
+	t.Run("editor subtasks belong to the owner", func(t *testing.T) {
+		created, err := todos.CreateTodo(ctx, editor.ID, models.CreateTodoRequest{Title: "editor step", ParentID: &todo.ID})
+		require.NoError(t, err)
+		assert.Equal(t, owner.ID, created.UserID)
+
+		_, err = todos.CreateTodo(ctx, viewer.ID, models.CreateTodoRequest{Title: "viewer step", ParentID: &todo.ID})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("only owners manage shares", func(t *testing.T) {
+		_, err := shares.Share(ctx, entities.ShareResourceTodo, todo.ID, editor.ID, models.CreateShareRequest{Email: stranger.Email, Role: entities.RoleViewer})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		assert.ErrorIs(t, share(owner.Email, entities.RoleViewer), domain.ErrInvalidShare)
+		assert.ErrorIs(t, share("nobody@example.com", entities.RoleViewer), domain.ErrUserNotFound)
+
+		list, err := shares.GetShares(ctx, entities.ShareResourceTodo, todo.ID, viewer.ID)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, viewer.Email, list[0].Email)
+
+		assert.ErrorIs(t, shares.RevokeShare(ctx, entities.ShareResourceTodo, todo.ID, viewer.ID, editor.ID), domain.ErrForbidden)
+	})
+
+	t.Run("role upgrade and shared with me", func(t *testing.T) {
+		require.NoError(t, share(viewer.Email, entities.RoleOwner))
+		assert.NoError(t, todos.DeleteTodo(ctx, subtask.ID, viewer.ID, "", nil))
+
+		shared, err := shares.SharedWithMe(ctx, viewer.ID)
+		require.NoError(t, err)
+		require.Len(t, shared.Todos, 1)
+		assert.Equal(t, todo.ID, shared.Todos[0].Todo.ID)
+		assert.Equal(t, entities.RoleOwner, shared.Todos[0].Role)
+	})
+
+	t.Run("collaborator leaves", func(t *testing.T) {
+		require.NoError(t, shares.RevokeShare(ctx, entities.ShareResourceTodo, todo.ID, editor.ID, editor.ID))
+		_, err := todos.GetTodoByID(ctx, todo.ID, editor.ID)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		assert.ErrorIs(t, shares.RevokeShare(ctx, entities.ShareResourceTodo, todo.ID, owner.ID, editor.ID), domain.ErrShareNotFound)
+	})
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository"
+	"github.com/polzovatel/todo-learning/internal/tenant"
+)
+
+const defaultTagColor = "#808080"
+
+type TagService interface {
+	CreateTag(ctx context.Context, userID uuid.UUID, req models.CreateTagRequest) (entities.Tag, error)
+	GetTags(ctx context.Context, userID uuid.UUID) ([]entities.Tag, error)
+	UpdateTag(ctx context.Context, tagID uuid.UUID, userID uuid.UUID, req models.UpdateTagRequest) (*entities.Tag, error)
+	DeleteTag(ctx context.Context, tagID uuid.UUID, userID uuid.UUID) error
+	AttachTag(ctx context.Context, todoID, tagID uuid.UUID, userID uuid.UUID) error
+	DetachTag(ctx context.Context, todoID, tagID uuid.UUID, userID uuid.UUID) error
+	GetTodoTags(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) ([]entities.Tag, error)
+}
+
+type tagService struct {
+	tagRepo repository.TagStore
+	access  access
+	logger  *slog.Logger
+}
+
+func NewTagService(todoRepo repository.TodoStore, projectRepo repository.ProjectStore, shareRepo repository.ShareStore, tagRepo repository.TagStore, logger *slog.Logger) TagService {
+	return &tagService{
+		tagRepo: tagRepo,
+		access:  access{todoRepo: todoRepo, projectRepo: projectRepo, shareRepo: shareRepo, logger: logger},
+		logger:  logger,
+	}
+}
+
+func (s *tagService) CreateTag(ctx context.Context, userID uuid.UUID, req models.CreateTagRequest) (entities.Tag, error) {
+	if err := validators.ValidateTag(req.Name); err != nil {
+		return entities.Tag{}, err
+	}
+	color := req.Color
+	if color == "" {
+		color = defaultTagColor
+	}
+
+	tag, err := s.tagRepo.CreateTag(ctx, entities.Tag{WorkspaceID: tenant.WorkspaceID(ctx, userID), UserID: userID, Name: strings.TrimSpace(req.Name), Color: color})
+	if err != nil {
+		if errors.Is(err, domain.ErrTagExists) {
+			s.logger.Warn("service: tag name taken", slog.String("user_id", userID.String()))
+			return entities.Tag{}, err
+		}
+		s.logger.Error("service: create tag failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return entities.Tag{}, err
+	}
+
+	s.logger.Info("service: tag created", slog.String("tag_id", tag.ID.String()), slog.String("user_id", userID.String()))
+	return tag, nil
+}
+
+func (s *tagService) GetTags(ctx context.Context, userID uuid.UUID) ([]entities.Tag, error) {
+	tags, err := s.tagRepo.GetTagsByUserID(ctx, tenant.WorkspaceID(ctx, userID), userID)
+	if err != nil {
+		s.logger.Error("service: list tags failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (s *tagService) UpdateTag(ctx context.Context, tagID uuid.UUID, userID uuid.UUID, req models.UpdateTagRequest) (*entities.Tag, error) {
+	tag, err := s.ownTag(ctx, tagID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *tag
+	if req.Name != nil {
+		if err := validators.ValidateTag(*req.Name); err != nil {
+			return nil, err
+		}
+		updated.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Color != nil {
+		updated.Color = *req.Color
+	}
+
+	saved, err := s.tagRepo.UpdateTag(ctx, &updated)
+	if err != nil {
+		if !errors.Is(err, domain.ErrTagExists) {
+			s.logger.Error("service: update tag failed", slog.String("tag_id", tagID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+
+	s.logger.Info("service: tag updated", slog.String("tag_id", tagID.String()))
+	return saved, nil
+}
+
+func (s *tagService) DeleteTag(ctx context.Context, tagID uuid.UUID, userID uuid.UUID) error {
+	if _, err := s.ownTag(ctx, tagID, userID); err != nil {
+		return err
+	}
+
+	if err := s.tagRepo.DeleteTag(ctx, tenant.WorkspaceID(ctx, userID), tagID); err != nil {
+		s.logger.Error("service: delete tag failed", slog.String("tag_id", tagID.String()), slog.Any("error", err))
+		return err
+	}
+
+	s.logger.Info("service: tag deleted", slog.String("tag_id", tagID.String()))
+	return nil
+}
+
+// AttachTag вешает на задачу свою метку; метка и задача должны быть из одного
+// пространства, иначе метка не находится.
+func (s *tagService) AttachTag(ctx context.Context, todoID, tagID uuid.UUID, userID uuid.UUID) error {
+	todo, err := s.todo(ctx, todoID, userID, entities.RoleEditor)
+	if err != nil {
+		return err
+	}
+	tag, err := s.ownTag(ctx, tagID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.WorkspaceID != todo.WorkspaceID {
+		s.logger.Warn("service: tag from another workspace", slog.String("todo_id", todoID.String()), slog.String("tag_id", tagID.String()))
+		return domain.ErrTagNotFound
+	}
+
+	if err := s.tagRepo.AttachTag(ctx, todoID, tagID); err != nil {
+		s.logger.Error("service: attach tag failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return err
+	}
+
+	s.logger.Info("service: tag attached", slog.String("todo_id", todoID.String()), slog.String("tag_id", tagID.String()))
+	return nil
+}
+
+// DetachTag снимает с задачи любую метку пространства: редактор общей задачи
+// может убрать и метку её владельца.
+func (s *tagService) DetachTag(ctx context.Context, todoID, tagID uuid.UUID, userID uuid.UUID) error {
+	if _, err := s.todo(ctx, todoID, userID, entities.RoleEditor); err != nil {
+		return err
+	}
+	if _, err := s.tag(ctx, tagID, userID); err != nil {
+		return err
+	}
+
+	if err := s.tagRepo.DetachTag(ctx, todoID, tagID); err != nil {
+		s.logger.Error("service: detach tag failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return err
+	}
+
+	s.logger.Info("service: tag detached", slog.String("todo_id", todoID.String()), slog.String("tag_id", tagID.String()))
+	return nil
+}
+
+func (s *tagService) GetTodoTags(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) ([]entities.Tag, error) {
+	if _, err := s.todo(ctx, todoID, userID, entities.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagRepo.GetTagsByTodoID(ctx, todoID)
+	if err != nil {
+		s.logger.Error("service: list todo tags failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	return tags, nil
+}
+
+// tag находит метку в текущем пространстве.
+func (s *tagService) tag(ctx context.Context, tagID uuid.UUID, userID uuid.UUID) (*entities.Tag, error) {
+	tag, err := s.tagRepo.GetTagByID(ctx, tenant.WorkspaceID(ctx, userID), tagID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrTagNotFound) {
+			s.logger.Error("service: get tag failed", slog.String("tag_id", tagID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *tagService) ownTag(ctx context.Context, tagID uuid.UUID, userID uuid.UUID) (*entities.Tag, error) {
+	tag, err := s.tag(ctx, tagID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.UserID != userID {
+		s.logger.Warn("service: tag access forbidden", slog.String("tag_id", tagID.String()), slog.String("user_id", userID.String()))
+		return nil, domain.ErrForbidden
+	}
+	return tag, nil
+}
+
+// todo находит задачу (access.findTodo) и проверяет, что роль пользователя в ней не ниже need.
+func (s *tagService) todo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, need string) (*entities.Todo, error) {
+	todo, err := s.access.findTodo(ctx, todoID, userID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrTodoNotFound) {
+			s.logger.Error("service: get todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+	if err := s.access.authorizeTodo(ctx, todo, userID, need); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
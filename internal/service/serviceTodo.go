@@ -10,36 +10,107 @@ import (
 	"github.com/google/uuid"
 	"github.com/polzovatel/todo-learning/internal/domain"
 	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
 	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/ranking"
 	"github.com/polzovatel/todo-learning/internal/repository"
+	"github.com/polzovatel/todo-learning/internal/search"
+	"github.com/polzovatel/todo-learning/internal/tenant"
 	"github.com/redis/go-redis/v9"
 )
 
 type TodoService interface {
-	CreateTodo(ctx context.Context, userID uuid.UUID, title, description string) (entities.Todo, error)
+	CreateTodo(ctx context.Context, userID uuid.UUID, req models.CreateTodoRequest) (entities.Todo, error)
 	GetTodoByID(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (*entities.Todo, error)
 	GetTodoByUserID(ctx context.Context, userID uuid.UUID) ([]entities.Todo, error)
+	GetTodoChildren(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (models.TodoChildrenResponse, error)
+	GetTodoTree(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (models.TodoTree, error)
+	ListTodos(ctx context.Context, userID uuid.UUID, req models.ListTodosRequest) (models.TodoListResponse, error)
+	SearchTodos(ctx context.Context, userID uuid.UUID, req models.SearchTodosRequest) ([]models.TodoSearchResult, error)
 	UpdateTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, req models.UpdateTodoRequest) (*entities.Todo, error)
-	DeleteTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) error
+	// PatchTodo применяет к задаче JSON Merge Patch или JSON Patch (по patch.ContentType) и
+	// сохраняет результат с проверками UpdateTodo; нужна роль editor.
+	PatchTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, patch models.TodoPatch) (*entities.Todo, error)
+	MoveTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, req models.MoveTodoRequest) (*entities.Todo, error)
+	// BatchTodos выполняет операции по порядку в одной транзакции. Каждая операция проверяется
+	// так же, как одиночный запрос; если хоть одна не удалась, все изменения откатываются и
+	// возвращается *domain.BatchError с номером операции.
+	BatchTodos(ctx context.Context, userID uuid.UUID, ops []models.BatchOperation) ([]models.BatchResult, error)
+	// SkipOccurrence переносит повторяющуюся задачу на следующее вхождение, не выполняя её.
+	SkipOccurrence(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (*entities.Todo, error)
+	// EndRecurrence снимает правило повторения; сама задача остаётся.
+	EndRecurrence(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (*entities.Todo, error)
+	// DeleteTodo переносит задачу в корзину; задачу с подзадачами — только при subtasks=cascade,
+	// вместе с ними. Нужна роль owner. Если задана version, а у задачи уже другая версия —
+	// domain.ErrVersionMismatch.
+	DeleteTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, subtasks string, version *int) error
+	// GetTrash возвращает корзину пользователя в текущем пространстве.
+	GetTrash(ctx context.Context, userID uuid.UUID) ([]entities.Todo, error)
+	// RestoreTodo возвращает задачу из корзины вместе с подзадачами, удалёнными с ней; нужна роль owner.
+	RestoreTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (*entities.Todo, error)
+	// PurgeTodo окончательно удаляет задачу из корзины; нужна роль owner.
+	PurgeTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) error
+	// PurgeTrash окончательно удаляет задачи, попавшие в корзину раньше before.
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
+	// GetTodoHistory возвращает ревизии задачи, старые первыми; нужна роль viewer.
+	GetTodoHistory(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) ([]entities.TodoRevision, error)
+	// RevertTodo возвращает задаче состояние ревизии number и записывает это новой ревизией.
+	// Проверки те же, что у UpdateTodo; нужна роль editor.
+	RevertTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, number int) (*entities.Todo, error)
 }
 
 type todoService struct {
-	userRepo repository.Store
-	todoRepo repository.TodoStore
-	cache    *redis.Client
-	logger   *slog.Logger
+	userRepo     repository.Store
+	todoRepo     repository.TodoStore
+	projectRepo  repository.ProjectStore
+	revisionRepo repository.RevisionStore
+	transactor   repository.Transactor
+	access       access
+	cache        *redis.Client
+	maxDepth     int
+	logger       *slog.Logger
 }
 
-func NewTodoService(userRepo repository.Store, todoRepo repository.TodoStore, redis *redis.Client, logger *slog.Logger) TodoService {
+func NewTodoService(userRepo repository.Store, todoRepo repository.TodoStore, projectRepo repository.ProjectStore, shareRepo repository.ShareStore, revisionRepo repository.RevisionStore, transactor repository.Transactor, redis *redis.Client, maxDepth int, logger *slog.Logger) TodoService {
 	return &todoService{
-		userRepo: userRepo,
-		todoRepo: todoRepo,
-		cache:    redis,
-		logger:   logger,
+		userRepo:     userRepo,
+		todoRepo:     todoRepo,
+		projectRepo:  projectRepo,
+		revisionRepo: revisionRepo,
+		transactor:   transactor,
+		access:       access{todoRepo: todoRepo, projectRepo: projectRepo, shareRepo: shareRepo, logger: logger},
+		cache:        redis,
+		maxDepth:     maxDepth,
+		logger:       logger,
 	}
 }
 
-func (s *todoService) CreateTodo(ctx context.Context, userID uuid.UUID, title, description string) (entities.Todo, error) {
+// todosListKey — ключ кэша списка задач владельца в пространстве.
+func todosListKey(workspaceID, userID uuid.UUID) string {
+	return "todos:workspace:" + workspaceID.String() + ":user:" + userID.String()
+}
+
+// invalidateTodo сбрасывает кэш задачи и списка задач её владельца.
+func (s *todoService) invalidateTodo(ctx context.Context, todo *entities.Todo) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Del(ctx, "todo:"+todo.ID.String(), todosListKey(todo.WorkspaceID, todo.UserID)).Err(); err != nil {
+		s.logger.Warn("service: failed to invalidate todo cache", slog.String("todo_id", todo.ID.String()))
+	}
+}
+
+func (s *todoService) CreateTodo(ctx context.Context, userID uuid.UUID, req models.CreateTodoRequest) (entities.Todo, error) {
+	if err := validators.ValidateDueDates(req.DueAt, req.RemindAt); err != nil {
+		s.logger.Warn("service: invalid todo due dates", slog.String("user_id", userID.String()))
+		return entities.Todo{}, err
+	}
+	rule, err := normalizeRecurrence(req.Recurrence, req.DueAt)
+	if err != nil {
+		s.logger.Warn("service: invalid todo recurrence", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return entities.Todo{}, err
+	}
+
 	if _, err := s.userRepo.GetUserById(ctx, userID); err != nil {
 		if errors.Is(err, domain.ErrUserNotFound) {
 			s.logger.Warn("service: create todo user not found", slog.String("user_id", userID.String()))
@@ -48,10 +119,69 @@ func (s *todoService) CreateTodo(ctx context.Context, userID uuid.UUID, title, d
 		s.logger.Error("service: create todo user lookup failed", slog.String("user_id", userID.String()), slog.Any("error", err))
 		return entities.Todo{}, err
 	}
+	// Задача в общем проекте или подзадача общей задачи создаётся в их пространстве.
+	workspaceID := tenant.WorkspaceID(ctx, userID)
+	if req.ProjectID != nil {
+		project, err := s.checkProject(ctx, *req.ProjectID, userID)
+		if err != nil {
+			return entities.Todo{}, err
+		}
+		workspaceID = project.WorkspaceID
+	}
+	// Подзадача принадлежит владельцу родителя, так что соавтор общей задачи
+	// может её дробить, а доступ к подзадачам наследуется.
+	ownerID := userID
+	if req.ParentID != nil {
+		parent, err := s.checkParent(ctx, nil, *req.ParentID, userID)
+		if err != nil {
+			return entities.Todo{}, err
+		}
+		if req.ProjectID != nil && parent.WorkspaceID != workspaceID {
+			return entities.Todo{}, domain.ErrInvalidParent
+		}
+		ownerID, workspaceID = parent.UserID, parent.WorkspaceID
+	}
+
+	// Новая задача встаёт в конец ручного порядка.
+	last, err := s.todoRepo.LastTodoPosition(ctx, workspaceID, ownerID)
+	if err != nil {
+		s.logger.Error("service: last todo position failed", slog.String("user_id", ownerID.String()), slog.Any("error", err))
+		return entities.Todo{}, err
+	}
+	position, err := ranking.Between(last, "")
+	if err != nil {
+		s.logger.Error("service: next todo position failed", slog.String("user_id", ownerID.String()), slog.Any("error", err))
+		return entities.Todo{}, err
+	}
+	priority := req.Priority
+	if priority == "" {
+		priority = entities.PriorityNormal
+	}
 
-	todo, err := s.todoRepo.CreateTodo(ctx, userID, title, description)
+	// Задача и её первая ревизия сохраняются вместе, чтобы история не расходилась с задачей.
+	var todo entities.Todo
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		created, err := s.todoRepo.CreateTodo(ctx, entities.Todo{
+			WorkspaceID: workspaceID,
+			UserID:      ownerID,
+			Title:       req.Title,
+			Description: req.Description,
+			DueAt:       req.DueAt,
+			RemindAt:    req.RemindAt,
+			Priority:    priority,
+			Position:    position,
+			ProjectID:   req.ProjectID,
+			ParentID:    req.ParentID,
+			Recurrence:  rule,
+		})
+		if err != nil {
+			s.logger.Error("service: create todo failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+			return err
+		}
+		todo = created
+		return s.recordRevision(ctx, entities.RevisionCreate, &entities.TodoState{}, &todo, userID, nil)
+	})
 	if err != nil {
-		s.logger.Error("service: create todo failed", slog.String("user_id", userID.String()), slog.Any("error", err))
 		return entities.Todo{}, err
 	}
 
@@ -67,9 +197,9 @@ func (s *todoService) CreateTodo(ctx context.Context, userID uuid.UUID, title, d
 		}
 	}
 	if s.cache != nil {
-		listKey := "todos:user:" + userID.String()
+		listKey := todosListKey(workspaceID, ownerID)
 		if err := s.cache.Del(ctx, listKey).Err(); err != nil {
-			s.logger.Warn("service: failed to invalidate todos list cache", slog.String("user_id", userID.String()))
+			s.logger.Warn("service: failed to invalidate todos list cache", slog.String("user_id", ownerID.String()))
 		}
 	}
 
@@ -86,16 +216,18 @@ func (s *todoService) GetTodoByID(ctx context.Context, todoID uuid.UUID, userID
 			s.logger.Info("todo found in cache", slog.String("todo_id", todoID.String()))
 			if err := json.Unmarshal([]byte(cacheTodo), &todo); err != nil {
 				s.logger.Error("service: unmarshal todo failed", slog.String("todo_id", todoID.String()))
-			} else {
-				if todo.UserID != userID {
-					return nil, domain.ErrForbidden
+			} else if todo.WorkspaceID == tenant.WorkspaceID(ctx, userID) {
+				// Кэш общий для всех пространств: задачу другого пространства ищем в
+				// хранилище, как общую.
+				if err := s.access.authorizeTodo(ctx, &todo, userID, entities.RoleViewer); err != nil {
+					return nil, err
 				}
 				return &todo, nil
 			}
 		}
 	}
 
-	todo, err := s.todoRepo.GetTodoByID(ctx, todoID)
+	todo, err := s.access.findTodo(ctx, todoID, userID)
 	if err != nil {
 		if errors.Is(err, domain.ErrTodoNotFound) {
 			s.logger.Warn("service: todo not found", slog.String("todo_id", todoID.String()))
@@ -105,9 +237,8 @@ func (s *todoService) GetTodoByID(ctx context.Context, todoID uuid.UUID, userID
 		return nil, err
 	}
 
-	if todo.UserID != userID {
-		s.logger.Warn("service: todo access forbidden", slog.String("todo_id", todoID.String()), slog.String("user_id", userID.String()))
-		return nil, domain.ErrForbidden
+	if err := s.access.authorizeTodo(ctx, todo, userID, entities.RoleViewer); err != nil {
+		return nil, err
 	}
 
 	todoJSON, err := json.Marshal(todo)
@@ -127,8 +258,9 @@ func (s *todoService) GetTodoByID(ctx context.Context, todoID uuid.UUID, userID
 }
 
 func (s *todoService) GetTodoByUserID(ctx context.Context, userID uuid.UUID) ([]entities.Todo, error) {
+	workspaceID := tenant.WorkspaceID(ctx, userID)
 	if s.cache != nil {
-		key := "todos:user:" + userID.String()
+		key := todosListKey(workspaceID, userID)
 		cacheTodo, err := s.cache.Get(ctx, key).Result()
 		if err == nil {
 			var todos []entities.Todo
@@ -150,7 +282,7 @@ func (s *todoService) GetTodoByUserID(ctx context.Context, userID uuid.UUID) ([]
 		return nil, err
 	}
 
-	todos, err := s.todoRepo.GetTodoByUserID(ctx, userID)
+	todos, err := s.todoRepo.GetTodoByUserID(ctx, workspaceID, userID)
 	if err != nil {
 		s.logger.Error("service: list todos failed", slog.String("user_id", userID.String()), slog.Any("error", err))
 		return nil, err
@@ -158,7 +290,7 @@ func (s *todoService) GetTodoByUserID(ctx context.Context, userID uuid.UUID) ([]
 
 	// Сохраняем в кэш
 	if s.cache != nil {
-		key := "todos:user:" + userID.String()
+		key := todosListKey(workspaceID, userID)
 		todosJSON, err := json.Marshal(todos)
 		if err == nil {
 			if err := s.cache.Set(ctx, key, todosJSON, 5*time.Minute).Err(); err != nil {
@@ -174,8 +306,205 @@ func (s *todoService) GetTodoByUserID(ctx context.Context, userID uuid.UUID) ([]
 	return todos, nil
 }
 
+const (
+	defaultTodoPageSize = 20
+	maxTodoPageSize     = 100
+)
+
+// ListTodos возвращает страницу задач. Страницы не кэшируются: курсоров слишком много,
+// а keyset-запрос по индексу и так дешёвый.
+func (s *todoService) ListTodos(ctx context.Context, userID uuid.UUID, req models.ListTodosRequest) (models.TodoListResponse, error) {
+	user, err := s.userRepo.GetUserById(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			s.logger.Warn("service: list todos user not found", slog.String("user_id", userID.String()))
+			return models.TodoListResponse{}, domain.ErrUserNotFound
+		}
+		s.logger.Error("service: list todos user lookup failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return models.TodoListResponse{}, err
+	}
+
+	filter := models.TodoListFilter{
+		WorkspaceID:   tenant.WorkspaceID(ctx, userID),
+		UserID:        userID,
+		Completed:     req.Completed,
+		Priority:      req.Priority,
+		ProjectID:     req.ProjectID,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		UpdatedAfter:  req.UpdatedAfter,
+		UpdatedBefore: req.UpdatedBefore,
+		Sort:          req.Sort,
+		Order:         req.Order,
+		Limit:         req.Limit,
+	}
+	if len(req.Tags) > 0 {
+		filter.Tags = uniqueStrings(req.Tags)
+		filter.TagsMatchAll = req.TagMode == models.TagModeAll
+	}
+	if req.Overdue || req.DueToday || req.DueThisWeek {
+		applyDueFilters(&filter, req, time.Now().In(userLocation(user)))
+	}
+	if req.ProjectID != nil {
+		// В общем проекте лежат задачи всех его участников.
+		project, err := s.authorizedProject(ctx, *req.ProjectID, userID, entities.RoleViewer)
+		if err != nil {
+			return models.TodoListResponse{}, err
+		}
+		filter.WorkspaceID, filter.UserID = project.WorkspaceID, uuid.Nil
+	}
+	if filter.Sort == "" {
+		filter.Sort = models.TodoSortPosition
+	}
+	if filter.Order == "" {
+		filter.Order = models.SortDesc
+		if filter.Sort == models.TodoSortTitle || filter.Sort == models.TodoSortPosition {
+			filter.Order = models.SortAsc
+		}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultTodoPageSize
+	}
+	filter.Limit = min(filter.Limit, maxTodoPageSize)
+	if req.Cursor != "" {
+		after, err := decodeTodoCursor(req.Cursor, filter.Sort, filter.Order)
+		if err != nil {
+			s.logger.Warn("service: invalid todos cursor", slog.String("user_id", userID.String()))
+			return models.TodoListResponse{}, err
+		}
+		filter.After = after
+	}
+
+	// Берём на одну задачу больше, чтобы понять, есть ли следующая страница.
+	pageSize := filter.Limit
+	filter.Limit++
+	todos, err := s.todoRepo.ListTodos(ctx, filter)
+	if err != nil {
+		s.logger.Error("service: list todos page failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return models.TodoListResponse{}, err
+	}
+
+	resp := models.TodoListResponse{Todos: todos}
+	if len(todos) > pageSize {
+		resp.Todos = todos[:pageSize]
+		resp.NextCursor = encodeTodoCursor(resp.Todos[pageSize-1], filter.Sort, filter.Order)
+	}
+	return resp, nil
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// userLocation возвращает часовой пояс пользователя; неизвестная зона считается UTC.
+func userLocation(user *entities.User) *time.Location {
+	if user.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// applyDueFilters переводит overdue/due_today/due_this_week в диапазон сроков.
+// Границы дня и недели (с понедельника) берутся в зоне now; при нескольких флагах
+// диапазоны пересекаются.
+func applyDueFilters(filter *models.TodoListFilter, req models.ListTodosRequest, now time.Time) {
+	narrow := func(from, before time.Time) {
+		if filter.DueFrom == nil || from.After(*filter.DueFrom) {
+			filter.DueFrom = &from
+		}
+		if filter.DueBefore == nil || before.Before(*filter.DueBefore) {
+			filter.DueBefore = &before
+		}
+	}
+
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if req.Overdue {
+		before := now
+		if filter.DueBefore == nil || before.Before(*filter.DueBefore) {
+			filter.DueBefore = &before
+		}
+		completed := false
+		filter.Completed = &completed
+	}
+	if req.DueToday {
+		narrow(startOfDay, startOfDay.AddDate(0, 0, 1))
+	}
+	if req.DueThisWeek {
+		weekday := (int(startOfDay.Weekday()) + 6) % 7 // понедельник — 0
+		startOfWeek := startOfDay.AddDate(0, 0, -weekday)
+		narrow(startOfWeek, startOfWeek.AddDate(0, 0, 7))
+	}
+}
+
+// checkProject проверяет, что задачу можно положить в проект, и возвращает его:
+// пользователь может его редактировать, и проект не в архиве.
+func (s *todoService) checkProject(ctx context.Context, projectID uuid.UUID, userID uuid.UUID) (*entities.Project, error) {
+	project, err := s.authorizedProject(ctx, projectID, userID, entities.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if project.Archived {
+		return nil, domain.ErrProjectArchived
+	}
+	return project, nil
+}
+
+func (s *todoService) authorizedProject(ctx context.Context, projectID uuid.UUID, userID uuid.UUID, need string) (*entities.Project, error) {
+	project, err := s.access.findProject(ctx, projectID, userID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrProjectNotFound) {
+			s.logger.Error("service: get project failed", slog.String("project_id", projectID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+	if err := s.access.authorizeProject(ctx, project, userID, need); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (s *todoService) SearchTodos(ctx context.Context, userID uuid.UUID, req models.SearchTodosRequest) ([]models.TodoSearchResult, error) {
+	if len(search.Terms(req.Query)) == 0 {
+		s.logger.Warn("service: search query without words", slog.String("user_id", userID.String()))
+		return nil, domain.ErrEmptyQuery
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultTodoPageSize
+	}
+	limit = min(limit, maxTodoPageSize)
+
+	results, err := s.todoRepo.SearchTodos(ctx, tenant.WorkspaceID(ctx, userID), userID, req.Query, limit)
+	if err != nil {
+		s.logger.Error("service: search todos failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	s.logger.Info("service: todos searched", slog.String("user_id", userID.String()), slog.Int("found", len(results)))
+	return results, nil
+}
+
 func (s *todoService) UpdateTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, req models.UpdateTodoRequest) (*entities.Todo, error) {
-	todo, err := s.todoRepo.GetTodoByID(ctx, todoID)
+	return s.updateTodo(ctx, todoID, userID, req, nil)
+}
+
+// updateTodo применяет правку и записывает её в историю; revertedTo задан, если правка —
+// откат к ревизии.
+func (s *todoService) updateTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, req models.UpdateTodoRequest, revertedTo *int) (*entities.Todo, error) {
+	todo, err := s.access.findTodo(ctx, todoID, userID)
 	if err != nil {
 		if errors.Is(err, domain.ErrTodoNotFound) {
 			s.logger.Warn("service: todo not found for update", slog.String("todo_id", todoID.String()))
@@ -185,9 +514,91 @@ func (s *todoService) UpdateTodo(ctx context.Context, todoID uuid.UUID, userID u
 		return nil, err
 	}
 
-	if todo.UserID != userID {
-		s.logger.Warn("service: todo update forbidden", slog.String("todo_id", todoID.String()), slog.String("user_id", userID.String()))
-		return nil, domain.ErrForbidden
+	if err := s.access.authorizeTodo(ctx, todo, userID, entities.RoleEditor); err != nil {
+		return nil, err
+	}
+	if req.Version != nil && *req.Version != todo.Version {
+		s.logger.Warn("service: todo version mismatch on update", slog.String("todo_id", todoID.String()), slog.Int("version", todo.Version))
+		return nil, domain.ErrVersionMismatch
+	}
+	before := todoState(todo)
+
+	dueAt, remindAt := todo.DueAt, todo.RemindAt
+	if req.DueAt.Set {
+		dueAt = req.DueAt.Value
+	}
+	if req.RemindAt.Set {
+		remindAt = req.RemindAt.Value
+	}
+	if err := validators.ValidateDueDates(dueAt, remindAt); err != nil {
+		s.logger.Warn("service: invalid todo due dates", slog.String("todo_id", todoID.String()))
+		return nil, err
+	}
+	rule := todo.Recurrence
+	if req.Recurrence.Set {
+		rule = ""
+		if req.Recurrence.Value != nil {
+			rule = *req.Recurrence.Value
+		}
+	}
+	rule, err = normalizeRecurrence(rule, dueAt)
+	if err != nil {
+		s.logger.Warn("service: invalid todo recurrence", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	// Неизменные проект и родитель не проверяются: полная замена повторяет их, и задача
+	// в архивном проекте должна оставаться редактируемой. Задача не покидает своё
+	// пространство, поэтому новые проект и родитель должны лежать в нём же.
+	if req.ProjectID.Set && req.ProjectID.Value != nil && !sameID(req.ProjectID.Value, todo.ProjectID) {
+		project, err := s.checkProject(ctx, *req.ProjectID.Value, userID)
+		if err != nil {
+			return nil, err
+		}
+		if project.WorkspaceID != todo.WorkspaceID {
+			return nil, domain.ErrProjectNotFound
+		}
+	}
+	if req.ParentID.Set && req.ParentID.Value != nil && !sameID(req.ParentID.Value, todo.ParentID) {
+		parent, err := s.checkParent(ctx, todo, *req.ParentID.Value, userID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.WorkspaceID != todo.WorkspaceID {
+			return nil, domain.ErrInvalidParent
+		}
+	}
+	// Выполнить задачу с невыполненными подзадачами можно только вместе с ними.
+	var openSubtasks []entities.Todo
+	if req.Completed != nil && *req.Completed && !todo.Completed {
+		levels, err := s.subtaskLevels(ctx, todo)
+		if err != nil {
+			return nil, err
+		}
+		for _, level := range levels {
+			for _, subtask := range level {
+				if !subtask.Completed {
+					openSubtasks = append(openSubtasks, subtask)
+				}
+			}
+		}
+		if len(openSubtasks) > 0 && req.Subtasks != models.SubtasksCascade {
+			s.logger.Warn("service: todo has open subtasks", slog.String("todo_id", todoID.String()), slog.Int("open", len(openSubtasks)))
+			return nil, domain.ErrTodoHasOpenSubtasks
+		}
+	}
+	// Выполненная повторяющаяся задача передаёт правило следующему вхождению
+	// (или серия на ней заканчивается).
+	var next *time.Time
+	var nextRule string
+	if req.Completed != nil && *req.Completed && !todo.Completed && rule != "" {
+		occurrence, rest, ok, err := s.nextOccurrence(ctx, todo.UserID, *dueAt, rule)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			next, nextRule = &occurrence, rest
+		}
+		rule = ""
 	}
 
 	if req.Title != nil {
@@ -199,22 +610,74 @@ func (s *todoService) UpdateTodo(ctx context.Context, todoID uuid.UUID, userID u
 	if req.Completed != nil {
 		todo.Completed = *req.Completed
 	}
+	if req.Priority != nil {
+		todo.Priority = *req.Priority
+	}
+	if req.ProjectID.Set {
+		todo.ProjectID = req.ProjectID.Value
+	}
+	if req.ParentID.Set {
+		todo.ParentID = req.ParentID.Value
+	}
+	todo.DueAt, todo.RemindAt = dueAt, remindAt
+	todo.Recurrence = rule
 	todo.UpdatedAt = time.Now()
 
-	if _, err := s.todoRepo.UpdateTodo(ctx, todo); err != nil {
-		if errors.Is(err, domain.ErrTodoNotFound) {
-			s.logger.Warn("service: todo not found during update write", slog.String("todo_id", todoID.String()))
-			return nil, domain.ErrTodoNotFound
+	// Подзадачи, сама задача и её следующее вхождение сохраняются вместе: без этого сбой
+	// после записи выполненной задачи оставил бы серию без продолжения.
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for i := range openSubtasks {
+			subtask := &openSubtasks[i]
+			subtaskBefore := todoState(subtask)
+			subtask.Completed = true
+			subtask.UpdatedAt = todo.UpdatedAt
+			if _, err := s.todoRepo.UpdateTodo(ctx, subtask); err != nil {
+				s.logger.Error("service: complete subtask failed", slog.String("todo_id", subtask.ID.String()), slog.Any("error", err))
+				return err
+			}
+			if err := s.recordRevision(ctx, entities.RevisionUpdate, &subtaskBefore, subtask, userID, nil); err != nil {
+				return err
+			}
+		}
+
+		if _, err := s.todoRepo.UpdateTodo(ctx, todo); err != nil {
+			if errors.Is(err, domain.ErrTodoNotFound) {
+				s.logger.Warn("service: todo not found during update write", slog.String("todo_id", todoID.String()))
+				return domain.ErrTodoNotFound
+			}
+			if errors.Is(err, domain.ErrVersionMismatch) {
+				s.logger.Warn("service: todo changed concurrently", slog.String("todo_id", todoID.String()))
+				return err
+			}
+			s.logger.Error("service: update todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+			return err
+		}
+		action := entities.RevisionUpdate
+		if revertedTo != nil {
+			action = entities.RevisionRevert
+		}
+		if err := s.recordRevision(ctx, action, &before, todo, userID, revertedTo); err != nil {
+			return err
+		}
+		if next != nil {
+			spawned, err := s.spawnOccurrence(ctx, todo, *next, nextRule)
+			if err != nil {
+				return err
+			}
+			return s.recordRevision(ctx, entities.RevisionCreate, &entities.TodoState{}, &spawned, userID, nil)
 		}
-		s.logger.Error("service: update todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil
+	})
+	for i := range openSubtasks {
+		s.invalidateTodo(ctx, &openSubtasks[i])
+	}
+	if err != nil {
 		return nil, err
 	}
 
+	s.invalidateTodo(ctx, todo)
 	if s.cache != nil {
 		key := "todo:" + todo.ID.String()
-		if err := s.cache.Del(ctx, key).Err(); err != nil {
-			s.logger.Error("service: delete cache failed", slog.String("todo_id", todo.ID.String()))
-		}
 		jsonTodo, err := json.Marshal(todo)
 		if err == nil {
 			if err := s.cache.Set(ctx, key, jsonTodo, 5*time.Minute).Err(); err != nil {
@@ -224,19 +687,150 @@ func (s *todoService) UpdateTodo(ctx context.Context, todoID uuid.UUID, userID u
 			s.logger.Error("service: marshal todo failed", slog.String("todo_id", todo.ID.String()))
 		}
 	}
-	if s.cache != nil {
-		listKey := "todos:user:" + userID.String()
-		if err := s.cache.Del(ctx, listKey).Err(); err != nil {
-			s.logger.Warn("service: failed to invalidate todos list cache", slog.String("user_id", userID.String()))
+
+	s.logger.Info("service: todo updated", slog.String("todo_id", todo.ID.String()))
+	return todo, nil
+}
+
+// MoveTodo ставит задачу непосредственно перед или после другой задачи того же владельца:
+// ручной порядок у каждого владельца свой. Новая позиция берётся между соседями,
+// остальные задачи не переписываются — кроме случая, когда ключи соседей совпадают или
+// испорчены: тогда список владельца перенумеровывается и перестановка повторяется.
+func (s *todoService) MoveTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, req models.MoveTodoRequest) (*entities.Todo, error) {
+	if (req.Before == nil) == (req.After == nil) {
+		return nil, domain.ErrInvalidMove
+	}
+	anchorID, after := req.Before, false
+	if req.After != nil {
+		anchorID, after = req.After, true
+	}
+	if *anchorID == todoID {
+		return nil, domain.ErrInvalidMove
+	}
+
+	todo, err := s.access.findTodo(ctx, todoID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTodoNotFound) {
+			s.logger.Warn("service: todo not found for move", slog.String("todo_id", todoID.String()))
+			return nil, domain.ErrTodoNotFound
+		}
+		s.logger.Error("service: get todo before move failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	anchor, err := s.access.findTodo(ctx, *anchorID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTodoNotFound) {
+			s.logger.Warn("service: move anchor not found", slog.String("todo_id", anchorID.String()))
+			return nil, domain.ErrTodoNotFound
 		}
+		s.logger.Error("service: get move anchor failed", slog.String("todo_id", anchorID.String()), slog.Any("error", err))
+		return nil, err
 	}
+	if err := s.access.authorizeTodo(ctx, todo, userID, entities.RoleEditor); err != nil {
+		return nil, err
+	}
+	if req.Version != nil && *req.Version != todo.Version {
+		s.logger.Warn("service: todo version mismatch on move", slog.String("todo_id", todoID.String()), slog.Int("version", todo.Version))
+		return nil, domain.ErrVersionMismatch
+	}
+	if err := s.access.authorizeTodo(ctx, anchor, userID, entities.RoleEditor); err != nil {
+		return nil, err
+	}
+	if anchor.UserID != todo.UserID || anchor.WorkspaceID != todo.WorkspaceID {
+		return nil, domain.ErrInvalidMove
+	}
+	workspaceID := todo.WorkspaceID
 
-	s.logger.Info("service: todo updated", slog.String("todo_id", todo.ID.String()))
+	position, err := s.positionNextTo(ctx, workspaceID, anchor, after, todo.ID)
+	if isRankingError(err) {
+		s.logger.Warn("service: todo positions need rebalance", slog.String("user_id", todo.UserID.String()), slog.Any("error", err))
+		if err := s.rebalanceTodos(ctx, workspaceID, todo.UserID); err != nil {
+			return nil, err
+		}
+		// Перенумерация сменила позиции и версии, поэтому обе задачи перечитываются.
+		if todo, err = s.todoRepo.GetTodoByID(ctx, workspaceID, todoID); err != nil {
+			s.logger.Error("service: get todo after rebalance failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+			return nil, err
+		}
+		if anchor, err = s.todoRepo.GetTodoByID(ctx, workspaceID, *anchorID); err != nil {
+			s.logger.Error("service: get move anchor after rebalance failed", slog.String("todo_id", anchorID.String()), slog.Any("error", err))
+			return nil, err
+		}
+		position, err = s.positionNextTo(ctx, workspaceID, anchor, after, todo.ID)
+		if isRankingError(err) {
+			s.logger.Error("service: todo position between failed after rebalance", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+			return nil, domain.ErrPositionConflict
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Перестановка и её ревизия сохраняются вместе; перенумерация ревизий не пишет —
+	// порядок задач она не меняет.
+	before := todoState(todo)
+	todo.Position = position
+	todo.UpdatedAt = time.Now()
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.todoRepo.UpdateTodo(ctx, todo); err != nil {
+			if !errors.Is(err, domain.ErrVersionMismatch) {
+				s.logger.Error("service: move todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+			}
+			return err
+		}
+		return s.recordRevision(ctx, entities.RevisionMove, &before, todo, userID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateTodo(ctx, todo)
+
+	s.logger.Info("service: todo moved", slog.String("todo_id", todo.ID.String()), slog.String("position", position))
 	return todo, nil
 }
 
-func (s *todoService) DeleteTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) error {
-	todo, err := s.todoRepo.GetTodoByID(ctx, todoID)
+// positionNextTo возвращает ключ между anchor и её соседом с нужной стороны.
+func (s *todoService) positionNextTo(ctx context.Context, workspaceID uuid.UUID, anchor *entities.Todo, after bool, exclude uuid.UUID) (string, error) {
+	neighbour, err := s.todoRepo.AdjacentTodoPosition(ctx, workspaceID, anchor, after, exclude)
+	if err != nil {
+		s.logger.Error("service: adjacent todo position failed", slog.String("todo_id", anchor.ID.String()), slog.Any("error", err))
+		return "", err
+	}
+	lo, hi := neighbour, anchor.Position
+	if after {
+		lo, hi = anchor.Position, neighbour
+	}
+	return ranking.Between(lo, hi)
+}
+
+// rebalanceTodos перенумеровывает задачи владельца и сбрасывает их кэш.
+func (s *todoService) rebalanceTodos(ctx context.Context, workspaceID, ownerID uuid.UUID) error {
+	ids, err := s.todoRepo.RebalanceTodoPositions(ctx, workspaceID, ownerID)
+	if err != nil {
+		s.logger.Error("service: rebalance todo positions failed", slog.String("user_id", ownerID.String()), slog.Any("error", err))
+		return err
+	}
+	if s.cache != nil {
+		keys := []string{todosListKey(workspaceID, ownerID)}
+		for _, id := range ids {
+			keys = append(keys, "todo:"+id.String())
+		}
+		if err := s.cache.Del(ctx, keys...).Err(); err != nil {
+			s.logger.Warn("service: failed to invalidate todo cache after rebalance", slog.String("user_id", ownerID.String()))
+		}
+	}
+	s.logger.Info("service: todo positions rebalanced", slog.String("user_id", ownerID.String()), slog.Int("count", len(ids)))
+	return nil
+}
+
+// isRankingError — ключи соседей не позволяют вставить задачу между ними.
+func isRankingError(err error) bool {
+	return errors.Is(err, ranking.ErrKeyOrder) || errors.Is(err, ranking.ErrInvalidKey) || errors.Is(err, ranking.ErrExhausted)
+}
+
+func (s *todoService) DeleteTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, subtasks string, version *int) error {
+	todo, err := s.access.findTodo(ctx, todoID, userID)
 	if err != nil {
 		if errors.Is(err, domain.ErrTodoNotFound) {
 			s.logger.Warn("service: todo not found for delete", slog.String("todo_id", todoID.String()))
@@ -246,33 +840,60 @@ func (s *todoService) DeleteTodo(ctx context.Context, todoID uuid.UUID, userID u
 		return err
 	}
 
-	if todo.UserID != userID {
-		s.logger.Warn("service: todo delete forbidden", slog.String("todo_id", todoID.String()), slog.String("user_id", userID.String()))
-		return domain.ErrForbidden
+	if err := s.access.authorizeTodo(ctx, todo, userID, entities.RoleOwner); err != nil {
+		return err
+	}
+	if version != nil && *version != todo.Version {
+		s.logger.Warn("service: todo version mismatch on delete", slog.String("todo_id", todoID.String()), slog.Int("version", todo.Version))
+		return domain.ErrVersionMismatch
 	}
 
-	if err := s.todoRepo.DeleteTodo(ctx, todoID); err != nil {
-		if errors.Is(err, domain.ErrTodoNotFound) {
-			s.logger.Warn("service: todo not found during delete write", slog.String("todo_id", todoID.String()))
-			return domain.ErrTodoNotFound
-		}
-		s.logger.Error("service: delete todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+	levels, err := s.subtaskLevels(ctx, todo)
+	if err != nil {
 		return err
 	}
-
-	if s.cache != nil {
-		key := "todo:" + todoID.String()
-		if err := s.cache.Del(ctx, key).Err(); err != nil {
-			s.logger.Error("service: delete cache failed", slog.String("todo_id", todoID.String()))
+	if len(levels) > 0 && subtasks != models.SubtasksCascade {
+		s.logger.Warn("service: todo has subtasks", slog.String("todo_id", todoID.String()))
+		return domain.ErrTodoHasSubtasks
+	}
+	// Подзадачи хранилище переносит в корзину вместе с задачей. Удаляется только
+	// прочитанная версия: правка между проверкой и удалением даёт ErrVersionMismatch.
+	// Ревизии удаления пишутся в той же транзакции.
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.todoRepo.DeleteTodo(ctx, todo.WorkspaceID, todoID, todo.Version); err != nil {
+			if errors.Is(err, domain.ErrTodoNotFound) {
+				s.logger.Warn("service: todo not found during delete write", slog.String("todo_id", todoID.String()))
+				return domain.ErrTodoNotFound
+			}
+			if errors.Is(err, domain.ErrVersionMismatch) {
+				s.logger.Warn("service: todo changed during delete", slog.String("todo_id", todoID.String()))
+				return domain.ErrVersionMismatch
+			}
+			s.logger.Error("service: delete todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+			return err
+		}
+		if err := s.recordRevision(ctx, entities.RevisionDelete, nil, todo, userID, nil); err != nil {
+			return err
 		}
+		for _, level := range levels {
+			for _, subtask := range level {
+				if err := s.recordRevision(ctx, entities.RevisionDelete, nil, &subtask, userID, nil); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	if s.cache != nil {
-		listKey := "todos:user:" + userID.String()
-		if err := s.cache.Del(ctx, listKey).Err(); err != nil {
-			s.logger.Warn("service: failed to invalidate todos list cache", slog.String("user_id", userID.String()))
+	for _, level := range levels {
+		for _, subtask := range level {
+			s.invalidateTodo(ctx, &subtask)
 		}
 	}
+	s.invalidateTodo(ctx, todo)
 
-	s.logger.Info("service: todo deleted", slog.String("todo_id", todoID.String()))
+	s.logger.Info("service: todo moved to trash", slog.String("todo_id", todoID.String()))
 	return nil
 }
@@ -2,13 +2,22 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
 	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/recurrence"
+	"github.com/polzovatel/todo-learning/internal/repository"
+	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
 	"github.com/polzovatel/todo-learning/internal/repository/mocks"
+	"github.com/polzovatel/todo-learning/internal/tenant"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -17,14 +26,14 @@ func TestTodoService_CreateTodo(t *testing.T) {
 	ctx := context.Background()
 	mockUserStore := mocks.NewMockStore()
 	mockTodoStore := mocks.NewMockTodoStore()
-	service := NewTodoService(mockUserStore, mockTodoStore, nil, slog.Default())
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
 
 	// Создаем пользователя
 	user, err := mockUserStore.CreateUser(ctx, "todo@example.com", "hash")
 	require.NoError(t, err)
 
 	t.Run("create todo successfully", func(t *testing.T) {
-		todo, err := service.CreateTodo(ctx, user.ID, "Test Todo", "Description")
+		todo, err := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "Test Todo", Description: "Description"})
 
 		require.NoError(t, err)
 		assert.NotEqual(t, uuid.Nil, todo.ID)
@@ -34,8 +43,27 @@ func TestTodoService_CreateTodo(t *testing.T) {
 		assert.False(t, todo.Completed)
 	})
 
+	t.Run("create todo with due dates", func(t *testing.T) {
+		due := time.Now().Add(24 * time.Hour)
+		remind := due.Add(-time.Hour)
+		todo, err := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "Due", DueAt: &due, RemindAt: &remind})
+
+		require.NoError(t, err)
+		require.NotNil(t, todo.DueAt)
+		assert.True(t, due.Equal(*todo.DueAt))
+		assert.True(t, remind.Equal(*todo.RemindAt))
+	})
+
+	t.Run("reminder after due date", func(t *testing.T) {
+		due := time.Now()
+		remind := due.Add(time.Hour)
+		_, err := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "Due", DueAt: &due, RemindAt: &remind})
+
+		assert.ErrorIs(t, err, validators.ErrRemindAfterDue)
+	})
+
 	t.Run("create todo with non-existing user", func(t *testing.T) {
-		_, err := service.CreateTodo(ctx, uuid.New(), "Test Todo", "Description")
+		_, err := service.CreateTodo(ctx, uuid.New(), models.CreateTodoRequest{Title: "Test Todo", Description: "Description"})
 
 		assert.Error(t, err)
 		assert.Equal(t, domain.ErrUserNotFound, err)
@@ -46,10 +74,10 @@ func TestTodoService_GetTodoByID(t *testing.T) {
 	ctx := context.Background()
 	mockUserStore := mocks.NewMockStore()
 	mockTodoStore := mocks.NewMockTodoStore()
-	service := NewTodoService(mockUserStore, mockTodoStore, nil, slog.Default())
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
 
 	user, _ := mockUserStore.CreateUser(ctx, "get@example.com", "hash")
-	created, _ := service.CreateTodo(ctx, user.ID, "Test Todo", "Description")
+	created, _ := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "Test Todo", Description: "Description"})
 
 	t.Run("get todo by id successfully", func(t *testing.T) {
 		todo, err := service.GetTodoByID(ctx, created.ID, user.ID)
@@ -81,14 +109,14 @@ func TestTodoService_GetTodoByUserID(t *testing.T) {
 	ctx := context.Background()
 	mockUserStore := mocks.NewMockStore()
 	mockTodoStore := mocks.NewMockTodoStore()
-	service := NewTodoService(mockUserStore, mockTodoStore, nil, slog.Default())
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
 
 	user1, _ := mockUserStore.CreateUser(ctx, "user1@example.com", "hash")
 	user2, _ := mockUserStore.CreateUser(ctx, "user2@example.com", "hash")
 
-	todo1, _ := service.CreateTodo(ctx, user1.ID, "Todo 1", "")
-	todo2, _ := service.CreateTodo(ctx, user1.ID, "Todo 2", "")
-	_, _ = service.CreateTodo(ctx, user2.ID, "Todo 3", "")
+	todo1, _ := service.CreateTodo(ctx, user1.ID, models.CreateTodoRequest{Title: "Todo 1"})
+	todo2, _ := service.CreateTodo(ctx, user1.ID, models.CreateTodoRequest{Title: "Todo 2"})
+	_, _ = service.CreateTodo(ctx, user2.ID, models.CreateTodoRequest{Title: "Todo 3"})
 
 	t.Run("get todos for user successfully", func(t *testing.T) {
 		todos, err := service.GetTodoByUserID(ctx, user1.ID)
@@ -113,14 +141,140 @@ func TestTodoService_GetTodoByUserID(t *testing.T) {
 	})
 }
 
+func TestTodoService_ListTodos(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
+
+	user, _ := mockUserStore.CreateUser(ctx, "list@example.com", "hash")
+	for i := 0; i < 5; i++ {
+		_, _ = service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "Todo"})
+	}
+
+	t.Run("walks all pages", func(t *testing.T) {
+		seen := make(map[uuid.UUID]bool)
+		req := models.ListTodosRequest{Limit: 2}
+		pages := 0
+		for {
+			page, err := service.ListTodos(ctx, user.ID, req)
+			require.NoError(t, err)
+			pages++
+			for _, todo := range page.Todos {
+				assert.False(t, seen[todo.ID], "duplicate todo across pages")
+				seen[todo.ID] = true
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			req.Cursor = page.NextCursor
+		}
+		assert.Equal(t, 3, pages)
+		assert.Len(t, seen, 5)
+	})
+
+	t.Run("no cursor on the last page", func(t *testing.T) {
+		page, err := service.ListTodos(ctx, user.ID, models.ListTodosRequest{Limit: 5})
+
+		require.NoError(t, err)
+		assert.Len(t, page.Todos, 5)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("malformed cursor", func(t *testing.T) {
+		_, err := service.ListTodos(ctx, user.ID, models.ListTodosRequest{Cursor: "not-a-cursor"})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+	})
+
+	t.Run("cursor from another sort order", func(t *testing.T) {
+		page, err := service.ListTodos(ctx, user.ID, models.ListTodosRequest{Limit: 1})
+		require.NoError(t, err)
+
+		_, err = service.ListTodos(ctx, user.ID, models.ListTodosRequest{Cursor: page.NextCursor, Order: models.SortDesc})
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := service.ListTodos(ctx, uuid.New(), models.ListTodosRequest{})
+
+		assert.Equal(t, domain.ErrUserNotFound, err)
+	})
+}
+
+func TestApplyDueFilters(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	// Четверг, 23:30 по Москве — в UTC это ещё 20:30 того же дня.
+	now := time.Date(2024, 3, 14, 23, 30, 0, 0, moscow)
+
+	t.Run("due today uses user day boundaries", func(t *testing.T) {
+		var filter models.TodoListFilter
+		applyDueFilters(&filter, models.ListTodosRequest{DueToday: true}, now)
+
+		assert.True(t, filter.DueFrom.Equal(time.Date(2024, 3, 14, 0, 0, 0, 0, moscow)))
+		assert.True(t, filter.DueBefore.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, moscow)))
+		assert.Nil(t, filter.Completed)
+	})
+
+	t.Run("due this week starts on monday", func(t *testing.T) {
+		var filter models.TodoListFilter
+		applyDueFilters(&filter, models.ListTodosRequest{DueThisWeek: true}, now)
+
+		assert.True(t, filter.DueFrom.Equal(time.Date(2024, 3, 11, 0, 0, 0, 0, moscow)))
+		assert.True(t, filter.DueBefore.Equal(time.Date(2024, 3, 18, 0, 0, 0, 0, moscow)))
+	})
+
+	t.Run("overdue excludes completed", func(t *testing.T) {
+		var filter models.TodoListFilter
+		applyDueFilters(&filter, models.ListTodosRequest{Overdue: true}, now)
+
+		assert.Nil(t, filter.DueFrom)
+		assert.True(t, filter.DueBefore.Equal(now))
+		require.NotNil(t, filter.Completed)
+		assert.False(t, *filter.Completed)
+	})
+
+	t.Run("flags intersect", func(t *testing.T) {
+		var filter models.TodoListFilter
+		applyDueFilters(&filter, models.ListTodosRequest{Overdue: true, DueToday: true}, now)
+
+		assert.True(t, filter.DueFrom.Equal(time.Date(2024, 3, 14, 0, 0, 0, 0, moscow)))
+		assert.True(t, filter.DueBefore.Equal(now))
+	})
+}
+
+func TestTodoService_SearchTodos(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
+
+	user, _ := mockUserStore.CreateUser(ctx, "search@example.com", "hash")
+	_, _ = service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "Buy milk"})
+
+	t.Run("finds todos", func(t *testing.T) {
+		results, err := service.SearchTodos(ctx, user.ID, models.SearchTodosRequest{Query: "milk"})
+
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("query without words", func(t *testing.T) {
+		_, err := service.SearchTodos(ctx, user.ID, models.SearchTodosRequest{Query: " ?! "})
+
+		assert.ErrorIs(t, err, domain.ErrEmptyQuery)
+	})
+}
+
 func TestTodoService_UpdateTodo(t *testing.T) {
 	ctx := context.Background()
 	mockUserStore := mocks.NewMockStore()
 	mockTodoStore := mocks.NewMockTodoStore()
-	service := NewTodoService(mockUserStore, mockTodoStore, nil, slog.Default())
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
 
 	user, _ := mockUserStore.CreateUser(ctx, "update@example.com", "hash")
-	created, _ := service.CreateTodo(ctx, user.ID, "Original Title", "Original Description")
+	created, _ := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "Original Title", Description: "Original Description"})
 
 	t.Run("update todo successfully", func(t *testing.T) {
 		newTitle := "Updated Title"
@@ -142,6 +296,30 @@ func TestTodoService_UpdateTodo(t *testing.T) {
 		assert.True(t, updated.Completed)
 	})
 
+	t.Run("set and clear due date", func(t *testing.T) {
+		due := time.Now().Add(time.Hour)
+		updated, err := service.UpdateTodo(ctx, created.ID, user.ID, models.UpdateTodoRequest{
+			DueAt: models.Optional[time.Time]{Set: true, Value: &due},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, updated.DueAt)
+
+		// Напоминание позже срока отклоняется и не портит задачу.
+		late := due.Add(time.Hour)
+		_, err = service.UpdateTodo(ctx, created.ID, user.ID, models.UpdateTodoRequest{
+			RemindAt: models.Optional[time.Time]{Set: true, Value: &late},
+		})
+		assert.ErrorIs(t, err, validators.ErrRemindAfterDue)
+		todo, _ := service.GetTodoByID(ctx, created.ID, user.ID)
+		assert.Nil(t, todo.RemindAt)
+
+		updated, err = service.UpdateTodo(ctx, created.ID, user.ID, models.UpdateTodoRequest{
+			DueAt: models.Optional[time.Time]{Set: true},
+		})
+		require.NoError(t, err)
+		assert.Nil(t, updated.DueAt)
+	})
+
 	t.Run("update todo with wrong user", func(t *testing.T) {
 		otherUser, _ := mockUserStore.CreateUser(ctx, "other@example.com", "hash")
 		newTitle := "Hacked Title"
@@ -169,17 +347,88 @@ func TestTodoService_UpdateTodo(t *testing.T) {
 	})
 }
 
+func TestTodoService_MoveTodo(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
+
+	user, _ := mockUserStore.CreateUser(ctx, "move@example.com", "hash")
+	other, _ := mockUserStore.CreateUser(ctx, "other@example.com", "hash")
+	a, _ := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "a"})
+	b, _ := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "b"})
+	c, _ := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "c"})
+	foreign, _ := service.CreateTodo(ctx, other.ID, models.CreateTodoRequest{Title: "foreign"})
+
+	order := func() []string {
+		page, err := service.ListTodos(ctx, user.ID, models.ListTodosRequest{})
+		require.NoError(t, err)
+		titles := make([]string, 0, len(page.Todos))
+		for _, todo := range page.Todos {
+			titles = append(titles, todo.Title)
+		}
+		return titles
+	}
+
+	t.Run("new todos are appended with normal priority", func(t *testing.T) {
+		assert.Equal(t, []string{"a", "b", "c"}, order())
+		assert.Equal(t, entities.PriorityNormal, a.Priority)
+	})
+
+	t.Run("move before and after", func(t *testing.T) {
+		positionB := mockTodoStore.Todos[b.ID].Position
+
+		_, err := service.MoveTodo(ctx, c.ID, user.ID, models.MoveTodoRequest{Before: &a.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c", "a", "b"}, order())
+
+		_, err = service.MoveTodo(ctx, c.ID, user.ID, models.MoveTodoRequest{After: &a.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "c", "b"}, order())
+
+		// Соседние задачи не переписываются.
+		assert.Equal(t, positionB, mockTodoStore.Todos[b.ID].Position)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err := service.MoveTodo(ctx, a.ID, user.ID, models.MoveTodoRequest{})
+		assert.ErrorIs(t, err, domain.ErrInvalidMove)
+		_, err = service.MoveTodo(ctx, a.ID, user.ID, models.MoveTodoRequest{Before: &b.ID, After: &c.ID})
+		assert.ErrorIs(t, err, domain.ErrInvalidMove)
+		_, err = service.MoveTodo(ctx, a.ID, user.ID, models.MoveTodoRequest{After: &a.ID})
+		assert.ErrorIs(t, err, domain.ErrInvalidMove)
+	})
+
+	t.Run("anchor of another user", func(t *testing.T) {
+		_, err := service.MoveTodo(ctx, a.ID, user.ID, models.MoveTodoRequest{After: &foreign.ID})
+		assert.Equal(t, domain.ErrForbidden, err)
+	})
+
+	t.Run("duplicate positions are rebalanced", func(t *testing.T) {
+		// Так выглядят ключи, совпавшие после гонки или старой миграции.
+		mockTodoStore.Todos[c.ID].Position = mockTodoStore.Todos[a.ID].Position
+		mockTodoStore.Todos[c.ID].CreatedAt = mockTodoStore.Todos[a.ID].CreatedAt.Add(time.Millisecond)
+
+		moved, err := service.MoveTodo(ctx, b.ID, user.ID, models.MoveTodoRequest{After: &a.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, order())
+		assert.Equal(t, "f000001", mockTodoStore.Todos[a.ID].Position)
+		assert.Less(t, mockTodoStore.Todos[a.ID].Position, moved.Position)
+		assert.Less(t, moved.Position, mockTodoStore.Todos[c.ID].Position)
+	})
+}
+
 func TestTodoService_DeleteTodo(t *testing.T) {
 	ctx := context.Background()
 	mockUserStore := mocks.NewMockStore()
 	mockTodoStore := mocks.NewMockTodoStore()
-	service := NewTodoService(mockUserStore, mockTodoStore, nil, slog.Default())
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
 
 	t.Run("delete todo successfully", func(t *testing.T) {
 		user, _ := mockUserStore.CreateUser(ctx, "delete@example.com", "hash")
-		created, _ := service.CreateTodo(ctx, user.ID, "To Delete", "")
+		created, _ := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "To Delete"})
 
-		err := service.DeleteTodo(ctx, created.ID, user.ID)
+		err := service.DeleteTodo(ctx, created.ID, user.ID, models.SubtasksRefuse, nil)
 		require.NoError(t, err)
 
 		// Проверяем что todo удален
@@ -191,9 +440,9 @@ func TestTodoService_DeleteTodo(t *testing.T) {
 	t.Run("delete todo with wrong user", func(t *testing.T) {
 		user1, _ := mockUserStore.CreateUser(ctx, "user1@example.com", "hash")
 		user2, _ := mockUserStore.CreateUser(ctx, "user2@example.com", "hash")
-		created, _ := service.CreateTodo(ctx, user1.ID, "To Delete", "")
+		created, _ := service.CreateTodo(ctx, user1.ID, models.CreateTodoRequest{Title: "To Delete"})
 
-		err := service.DeleteTodo(ctx, created.ID, user2.ID)
+		err := service.DeleteTodo(ctx, created.ID, user2.ID, models.SubtasksRefuse, nil)
 
 		assert.Error(t, err)
 		assert.Equal(t, domain.ErrForbidden, err)
@@ -202,9 +451,600 @@ func TestTodoService_DeleteTodo(t *testing.T) {
 	t.Run("delete non-existing todo", func(t *testing.T) {
 		user, _ := mockUserStore.CreateUser(ctx, "delete2@example.com", "hash")
 
-		err := service.DeleteTodo(ctx, uuid.New(), user.ID)
+		err := service.DeleteTodo(ctx, uuid.New(), user.ID, models.SubtasksRefuse, nil)
 
 		assert.Error(t, err)
 		assert.Equal(t, domain.ErrTodoNotFound, err)
 	})
 }
+
+func TestTodoService_Subtasks(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 2, slog.Default())
+
+	user, _ := mockUserStore.CreateUser(ctx, "subtasks@example.com", "hash")
+	other, _ := mockUserStore.CreateUser(ctx, "subtasks-other@example.com", "hash")
+	create := func(title string, parent *entities.Todo) (entities.Todo, error) {
+		req := models.CreateTodoRequest{Title: title}
+		if parent != nil {
+			req.ParentID = &parent.ID
+		}
+		return service.CreateTodo(ctx, user.ID, req)
+	}
+
+	root, _ := create("root", nil)
+	child, err := create("child", &root)
+	require.NoError(t, err)
+	grandchild, err := create("grandchild", &child)
+	require.NoError(t, err)
+
+	t.Run("max depth", func(t *testing.T) {
+		_, err := create("too deep", &grandchild)
+		assert.ErrorIs(t, err, domain.ErrMaxDepthExceeded)
+
+		// child с подзадачей не помещается под grandchild другой ветки.
+		branch, _ := create("branch", &root)
+		_, err = service.UpdateTodo(ctx, child.ID, user.ID, models.UpdateTodoRequest{
+			ParentID: models.Optional[uuid.UUID]{Set: true, Value: &branch.ID},
+		})
+		assert.ErrorIs(t, err, domain.ErrMaxDepthExceeded)
+	})
+
+	t.Run("cycles are rejected", func(t *testing.T) {
+		for _, parent := range []uuid.UUID{root.ID, grandchild.ID} {
+			_, err := service.UpdateTodo(ctx, root.ID, user.ID, models.UpdateTodoRequest{
+				ParentID: models.Optional[uuid.UUID]{Set: true, Value: &parent},
+			})
+			assert.ErrorIs(t, err, domain.ErrInvalidParent)
+		}
+	})
+
+	t.Run("parent of another user", func(t *testing.T) {
+		_, err := service.CreateTodo(ctx, other.ID, models.CreateTodoRequest{Title: "foreign", ParentID: &root.ID})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		completed := true
+		_, err := service.UpdateTodo(ctx, grandchild.ID, user.ID, models.UpdateTodoRequest{Completed: &completed})
+		require.NoError(t, err)
+
+		children, err := service.GetTodoChildren(ctx, child.ID, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.TodoProgress{Completed: 1, Total: 1}, children.Progress)
+
+		tree, err := service.GetTodoTree(ctx, root.ID, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.TodoProgress{Completed: 0, Total: 2}, tree.Progress)
+		require.Len(t, tree.Children, 2)
+		assert.Equal(t, "child", tree.Children[0].Todo.Title)
+		assert.Equal(t, "grandchild", tree.Children[0].Children[0].Todo.Title)
+	})
+
+	t.Run("complete parent refuses or cascades", func(t *testing.T) {
+		completed := true
+		_, err := service.UpdateTodo(ctx, root.ID, user.ID, models.UpdateTodoRequest{Completed: &completed})
+		assert.ErrorIs(t, err, domain.ErrTodoHasOpenSubtasks)
+		stored, _ := mockTodoStore.GetTodoByID(ctx, user.ID, root.ID)
+		assert.False(t, stored.Completed)
+
+		_, err = service.UpdateTodo(ctx, root.ID, user.ID, models.UpdateTodoRequest{Completed: &completed, Subtasks: models.SubtasksCascade})
+		require.NoError(t, err)
+		tree, _ := service.GetTodoTree(ctx, root.ID, user.ID)
+		assert.Equal(t, models.TodoProgress{Completed: 2, Total: 2}, tree.Progress)
+	})
+
+	t.Run("delete parent refuses or cascades", func(t *testing.T) {
+		err := service.DeleteTodo(ctx, child.ID, user.ID, models.SubtasksRefuse, nil)
+		assert.ErrorIs(t, err, domain.ErrTodoHasSubtasks)
+
+		require.NoError(t, service.DeleteTodo(ctx, root.ID, user.ID, models.SubtasksCascade, nil))
+		for _, id := range []uuid.UUID{root.ID, child.ID, grandchild.ID} {
+			_, err := mockTodoStore.GetTodoByID(ctx, user.ID, id)
+			assert.ErrorIs(t, err, domain.ErrTodoNotFound)
+		}
+	})
+}
+
+func TestTodoService_Recurrence(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
+
+	user, _ := mockUserStore.CreateUser(ctx, "recurring@example.com", "hash")
+	user.Timezone = "Europe/Berlin"
+	_, _ = mockUserStore.UpdateUser(ctx, &user)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// Суббота перед переходом на летнее время.
+	due := time.Date(2026, 3, 28, 9, 0, 0, 0, berlin)
+	remind := due.Add(-30 * time.Minute)
+
+	t.Run("rule is validated", func(t *testing.T) {
+		_, err := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "bad", DueAt: &due, Recurrence: "FREQ=SOMETIMES"})
+		assert.ErrorIs(t, err, recurrence.ErrInvalidRule)
+
+		_, err = service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "no due", Recurrence: "FREQ=DAILY"})
+		assert.ErrorIs(t, err, domain.ErrRecurrenceNeedsDue)
+	})
+
+	t.Run("completing spawns the next occurrence", func(t *testing.T) {
+		todo, err := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{
+			Title: "water plants", DueAt: &due, RemindAt: &remind, Recurrence: "freq=daily;count=2",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "FREQ=DAILY;COUNT=2", todo.Recurrence)
+
+		completed := true
+		done, err := service.UpdateTodo(ctx, todo.ID, user.ID, models.UpdateTodoRequest{Completed: &completed})
+		require.NoError(t, err)
+		assert.Empty(t, done.Recurrence)
+
+		var next *entities.Todo
+		for _, candidate := range mockTodoStore.Todos {
+			if candidate.Title == "water plants" && !candidate.Completed {
+				next = candidate
+			}
+		}
+		require.NotNil(t, next)
+		assert.Equal(t, "2026-03-29 09:00 CEST", next.DueAt.In(berlin).Format("2006-01-02 15:04 MST"))
+		assert.Equal(t, 30*time.Minute, next.DueAt.Sub(*next.RemindAt))
+		assert.Equal(t, "FREQ=DAILY;COUNT=1", next.Recurrence)
+
+		// Последнее вхождение серии новых задач не порождает.
+		before := len(mockTodoStore.Todos)
+		_, err = service.UpdateTodo(ctx, next.ID, user.ID, models.UpdateTodoRequest{Completed: &completed})
+		require.NoError(t, err)
+		assert.Len(t, mockTodoStore.Todos, before)
+	})
+
+	t.Run("skip and end", func(t *testing.T) {
+		todo, err := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "report", DueAt: &due, Recurrence: "FREQ=WEEKLY;BYDAY=MO,SA"})
+		require.NoError(t, err)
+
+		skipped, err := service.SkipOccurrence(ctx, todo.ID, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "2026-03-30 09:00 CEST", skipped.DueAt.In(berlin).Format("2006-01-02 15:04 MST"))
+		assert.False(t, skipped.Completed)
+
+		ended, err := service.EndRecurrence(ctx, todo.ID, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, ended.Recurrence)
+
+		_, err = service.SkipOccurrence(ctx, todo.ID, user.ID)
+		assert.ErrorIs(t, err, domain.ErrNotRecurring)
+	})
+}
+
+// failingSpawnStore не может создать задачу — так имитируется сбой на полпути.
+type failingSpawnStore struct {
+	repository.TodoStore
+}
+
+func (failingSpawnStore) CreateTodo(ctx context.Context, todo entities.Todo) (entities.Todo, error) {
+	return entities.Todo{}, errors.New("storage unavailable")
+}
+
+func TestTodoService_RecurrenceIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	user, _ := repo.CreateUser(ctx, "atomic@example.com", "hash")
+	due := time.Date(2026, 3, 28, 9, 0, 0, 0, time.UTC)
+	todo, err := NewTodoService(repo, repo, nil, nil, repo, repo, nil, 3, slog.Default()).
+		CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "water plants", DueAt: &due, Recurrence: "FREQ=DAILY"})
+	require.NoError(t, err)
+
+	service := NewTodoService(repo, failingSpawnStore{repo}, nil, nil, repo, repo, nil, 3, slog.Default())
+	completed := true
+	_, err = service.UpdateTodo(ctx, todo.ID, user.ID, models.UpdateTodoRequest{Completed: &completed})
+	require.Error(t, err)
+
+	// Следующее вхождение не создано, значит и выполнение задачи откатилось.
+	got, err := repo.GetTodoByID(ctx, user.ID, todo.ID)
+	require.NoError(t, err)
+	assert.False(t, got.Completed)
+	assert.Equal(t, "FREQ=DAILY", got.Recurrence)
+	assert.Equal(t, todo.Version, got.Version)
+}
+
+func TestTodoService_Trash(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
+
+	user, _ := mockUserStore.CreateUser(ctx, "trash@example.com", "hash")
+	other, _ := mockUserStore.CreateUser(ctx, "trash-other@example.com", "hash")
+	root, _ := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "root"})
+	child, err := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "child", ParentID: &root.ID})
+	require.NoError(t, err)
+
+	t.Run("live todo is not in the trash", func(t *testing.T) {
+		_, err := service.RestoreTodo(ctx, root.ID, user.ID)
+		assert.Equal(t, domain.ErrTodoNotTrashed, err)
+		assert.Equal(t, domain.ErrTodoNotTrashed, service.PurgeTodo(ctx, root.ID, user.ID))
+
+		err = service.PurgeTodo(tenant.WithWorkspace(ctx, user.ID), root.ID, other.ID)
+		assert.Equal(t, domain.ErrForbidden, err)
+	})
+
+	require.NoError(t, service.DeleteTodo(ctx, root.ID, user.ID, models.SubtasksCascade, nil))
+
+	t.Run("trash lists the deleted subtree", func(t *testing.T) {
+		trash, err := service.GetTrash(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Len(t, trash, 2)
+
+		trash, err = service.GetTrash(ctx, other.ID)
+		require.NoError(t, err)
+		assert.Empty(t, trash)
+	})
+
+	t.Run("only the owner restores", func(t *testing.T) {
+		_, err := service.RestoreTodo(ctx, root.ID, other.ID)
+		assert.Equal(t, domain.ErrTodoNotFound, err)
+		_, err = service.RestoreTodo(tenant.WithWorkspace(ctx, user.ID), root.ID, other.ID)
+		assert.Equal(t, domain.ErrForbidden, err)
+	})
+
+	t.Run("subtask waits for its parent", func(t *testing.T) {
+		_, err := service.RestoreTodo(ctx, child.ID, user.ID)
+		assert.Equal(t, domain.ErrParentTrashed, err)
+	})
+
+	t.Run("restore brings the subtree back", func(t *testing.T) {
+		restored, err := service.RestoreTodo(ctx, root.ID, user.ID)
+		require.NoError(t, err)
+		assert.Nil(t, restored.DeletedAt)
+
+		_, err = service.GetTodoByID(ctx, child.ID, user.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("purge removes the todo for good", func(t *testing.T) {
+		require.NoError(t, service.DeleteTodo(ctx, root.ID, user.ID, models.SubtasksCascade, nil))
+		require.NoError(t, service.PurgeTodo(ctx, root.ID, user.ID))
+
+		_, err := service.RestoreTodo(ctx, child.ID, user.ID)
+		assert.Equal(t, domain.ErrTodoNotFound, err)
+		trash, _ := service.GetTrash(ctx, user.ID)
+		assert.Empty(t, trash)
+	})
+
+	t.Run("purge trash drops old entries", func(t *testing.T) {
+		old, _ := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "old"})
+		require.NoError(t, service.DeleteTodo(ctx, old.ID, user.ID, models.SubtasksRefuse, nil))
+
+		purged, err := service.PurgeTrash(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+
+		purged, err = service.PurgeTrash(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+	})
+}
+
+func TestTodoService_History(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
+
+	user, _ := mockUserStore.CreateUser(ctx, "history@example.com", "hash")
+	other, _ := mockUserStore.CreateUser(ctx, "history-other@example.com", "hash")
+	todo, err := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "draft"})
+	require.NoError(t, err)
+	history := func() []entities.TodoRevision {
+		revisions, err := service.GetTodoHistory(ctx, todo.ID, user.ID)
+		require.NoError(t, err)
+		return revisions
+	}
+	fields := func(revision entities.TodoRevision) []string {
+		got := []string{}
+		for _, change := range revision.Changes {
+			got = append(got, change.Field)
+		}
+		return got
+	}
+
+	t.Run("create is the first revision", func(t *testing.T) {
+		revisions := history()
+		require.Len(t, revisions, 1)
+		assert.Equal(t, entities.RevisionCreate, revisions[0].Action)
+		assert.Equal(t, user.ID, revisions[0].ActorID)
+		assert.Equal(t, []string{"title", "priority", "position"}, fields(revisions[0]))
+	})
+
+	t.Run("update records changed fields only", func(t *testing.T) {
+		same := "draft"
+		_, err := service.UpdateTodo(ctx, todo.ID, user.ID, models.UpdateTodoRequest{Title: &same})
+		require.NoError(t, err)
+		assert.Len(t, history(), 1)
+
+		title, priority := "final", entities.PriorityHigh
+		_, err = service.UpdateTodo(ctx, todo.ID, user.ID, models.UpdateTodoRequest{Title: &title, Priority: &priority})
+		require.NoError(t, err)
+
+		revisions := history()
+		require.Len(t, revisions, 2)
+		assert.Equal(t, entities.RevisionUpdate, revisions[1].Action)
+		assert.Equal(t, []string{"title", "priority"}, fields(revisions[1]))
+		assert.JSONEq(t, `"draft"`, string(revisions[1].Changes[0].Old))
+		assert.JSONEq(t, `"final"`, string(revisions[1].Changes[0].New))
+	})
+
+	t.Run("revert restores the state as a new revision", func(t *testing.T) {
+		reverted, err := service.RevertTodo(ctx, todo.ID, user.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "draft", reverted.Title)
+		assert.Equal(t, entities.PriorityNormal, reverted.Priority)
+
+		revisions := history()
+		require.Len(t, revisions, 3)
+		assert.Equal(t, entities.RevisionRevert, revisions[2].Action)
+		require.NotNil(t, revisions[2].RevertedTo)
+		assert.Equal(t, 1, *revisions[2].RevertedTo)
+		assert.Equal(t, revisions[0].State, revisions[2].State)
+	})
+
+	t.Run("revert errors", func(t *testing.T) {
+		_, err := service.RevertTodo(ctx, todo.ID, user.ID, 10)
+		assert.Equal(t, domain.ErrRevisionNotFound, err)
+
+		_, err = service.RevertTodo(tenant.WithWorkspace(ctx, user.ID), todo.ID, other.ID, 2)
+		assert.Equal(t, domain.ErrForbidden, err)
+		_, err = service.GetTodoHistory(tenant.WithWorkspace(ctx, user.ID), todo.ID, other.ID)
+		assert.Equal(t, domain.ErrForbidden, err)
+	})
+
+	t.Run("delete and restore are recorded for the subtree", func(t *testing.T) {
+		child, err := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "child", ParentID: &todo.ID})
+		require.NoError(t, err)
+		require.NoError(t, service.DeleteTodo(ctx, todo.ID, user.ID, models.SubtasksCascade, nil))
+		_, err = service.RestoreTodo(ctx, todo.ID, user.ID)
+		require.NoError(t, err)
+
+		revisions := history()
+		assert.Equal(t, entities.RevisionDelete, revisions[3].Action)
+		assert.Equal(t, entities.RevisionRestore, revisions[4].Action)
+		assert.Empty(t, revisions[4].Changes)
+
+		childHistory, err := service.GetTodoHistory(ctx, child.ID, user.ID)
+		require.NoError(t, err)
+		actions := []string{}
+		for _, revision := range childHistory {
+			actions = append(actions, revision.Action)
+		}
+		assert.Equal(t, []string{entities.RevisionCreate, entities.RevisionDelete, entities.RevisionRestore}, actions)
+	})
+
+	t.Run("move records the new position", func(t *testing.T) {
+		anchor, err := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "anchor"})
+		require.NoError(t, err)
+		moved, err := service.MoveTodo(ctx, todo.ID, user.ID, models.MoveTodoRequest{After: &anchor.ID})
+		require.NoError(t, err)
+
+		revisions := history()
+		last := revisions[len(revisions)-1]
+		assert.Equal(t, entities.RevisionMove, last.Action)
+		assert.Equal(t, []string{"position"}, fields(last))
+		assert.Equal(t, moved.Position, last.State.Position)
+
+		// Откат к ревизии до перестановки порядок не меняет.
+		reverted, err := service.RevertTodo(ctx, todo.ID, user.ID, last.Number-1)
+		require.NoError(t, err)
+		assert.Equal(t, moved.Position, reverted.Position)
+	})
+}
+
+type failingRevisionStore struct {
+	repository.RevisionStore
+}
+
+func (failingRevisionStore) CreateRevision(ctx context.Context, revision entities.TodoRevision) (entities.TodoRevision, error) {
+	return entities.TodoRevision{}, errors.New("storage unavailable")
+}
+
+func TestTodoService_HistoryIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	repo := in_memory.NewInMemoryRepository(slog.Default())
+	user, _ := repo.CreateUser(ctx, "history-atomic@example.com", "hash")
+	due := time.Date(2026, 3, 28, 9, 0, 0, 0, time.UTC)
+	healthy := NewTodoService(repo, repo, nil, nil, repo, repo, nil, 3, slog.Default())
+	todo, err := healthy.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "draft", DueAt: &due, Recurrence: "FREQ=DAILY"})
+	require.NoError(t, err)
+
+	// Без записи в историю изменение не сохраняется.
+	service := NewTodoService(repo, repo, nil, nil, failingRevisionStore{repo}, repo, nil, 3, slog.Default())
+	current := func() *entities.Todo {
+		got, err := repo.GetTodoByID(ctx, user.ID, todo.ID)
+		require.NoError(t, err)
+		return got
+	}
+
+	_, err = service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "lost"})
+	assert.EqualError(t, err, "storage unavailable")
+	todos, err := repo.GetTodoByUserID(ctx, user.ID, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, todos, 1)
+
+	title := "renamed"
+	_, err = service.UpdateTodo(ctx, todo.ID, user.ID, models.UpdateTodoRequest{Title: &title})
+	assert.EqualError(t, err, "storage unavailable")
+	assert.Equal(t, "draft", current().Title)
+
+	_, err = service.SkipOccurrence(ctx, todo.ID, user.ID)
+	assert.EqualError(t, err, "storage unavailable")
+	_, err = service.EndRecurrence(ctx, todo.ID, user.ID)
+	assert.EqualError(t, err, "storage unavailable")
+	assert.Equal(t, todo.DueAt, current().DueAt)
+	assert.Equal(t, "FREQ=DAILY", current().Recurrence)
+
+	assert.EqualError(t, service.DeleteTodo(ctx, todo.ID, user.ID, "", nil), "storage unavailable")
+	assert.Nil(t, current().DeletedAt)
+
+	require.NoError(t, healthy.DeleteTodo(ctx, todo.ID, user.ID, "", nil))
+	_, err = service.RestoreTodo(ctx, todo.ID, user.ID)
+	assert.EqualError(t, err, "storage unavailable")
+	trash, err := repo.GetTrashedTodos(ctx, user.ID, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, trash, 1)
+}
+
+func TestTodoService_Version(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
+
+	user, _ := mockUserStore.CreateUser(ctx, "version@example.com", "hash")
+	todo, _ := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "versioned"})
+	require.Equal(t, 1, todo.Version)
+	version := func(v int) *int { return &v }
+
+	t.Run("update without If-Match bumps the version", func(t *testing.T) {
+		title := "first"
+		updated, err := service.UpdateTodo(ctx, todo.ID, user.ID, models.UpdateTodoRequest{Title: &title})
+		require.NoError(t, err)
+		assert.Equal(t, 2, updated.Version)
+	})
+
+	t.Run("matching version is accepted", func(t *testing.T) {
+		title := "second"
+		updated, err := service.UpdateTodo(ctx, todo.ID, user.ID, models.UpdateTodoRequest{Title: &title, Version: version(2)})
+		require.NoError(t, err)
+		assert.Equal(t, 3, updated.Version)
+	})
+
+	t.Run("stale version is rejected", func(t *testing.T) {
+		title := "lost"
+		_, err := service.UpdateTodo(ctx, todo.ID, user.ID, models.UpdateTodoRequest{Title: &title, Version: version(2)})
+		assert.Equal(t, domain.ErrVersionMismatch, err)
+
+		found, _ := service.GetTodoByID(ctx, todo.ID, user.ID)
+		assert.Equal(t, "second", found.Title)
+
+		err = service.DeleteTodo(ctx, todo.ID, user.ID, models.SubtasksRefuse, version(1))
+		assert.Equal(t, domain.ErrVersionMismatch, err)
+		require.NoError(t, service.DeleteTodo(ctx, todo.ID, user.ID, models.SubtasksRefuse, version(3)))
+	})
+}
+
+func TestTodoService_Patch(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), mocks.NewMockTransactor(), nil, 3, slog.Default())
+
+	user, _ := mockUserStore.CreateUser(ctx, "patch@example.com", "hash")
+	due := time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC)
+	todo, _ := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "report", Description: "draft", DueAt: &due})
+	patch := func(contentType, body string) (*entities.Todo, error) {
+		return service.PatchTodo(ctx, todo.ID, user.ID, models.TodoPatch{ContentType: contentType, Body: []byte(body)})
+	}
+
+	t.Run("merge patch changes listed fields and clears nulls", func(t *testing.T) {
+		patched, err := patch(models.MergePatchType, `{"title":"final report","due_at":null}`)
+		require.NoError(t, err)
+		assert.Equal(t, "final report", patched.Title)
+		assert.Equal(t, "draft", patched.Description)
+		assert.Nil(t, patched.DueAt)
+		assert.Equal(t, 2, patched.Version)
+	})
+
+	t.Run("json patch with test and replace", func(t *testing.T) {
+		patched, err := patch(models.JSONPatchType+"; charset=utf-8", `[
+			{"op":"test","path":"/title","value":"final report"},
+			{"op":"replace","path":"/completed","value":true},
+			{"op":"remove","path":"/description"},
+			{"op":"add","path":"/description","value":"done"}
+		]`)
+		require.NoError(t, err)
+		assert.True(t, patched.Completed)
+		assert.Equal(t, "done", patched.Description)
+	})
+
+	t.Run("errors leave the todo unchanged", func(t *testing.T) {
+		tests := []struct {
+			contentType, body string
+			want              error
+		}{
+			{"application/json", `{"title":"x"}`, domain.ErrUnsupportedPatch},
+			{models.MergePatchType, `{"title":`, domain.ErrInvalidPatch},
+			{models.MergePatchType, `{"title":null}`, domain.ErrInvalidPatch},
+			{models.MergePatchType, `{"priority":"asap"}`, domain.ErrInvalidPatch},
+			{models.MergePatchType, `{"version":7}`, domain.ErrInvalidPatch},
+			{models.JSONPatchType, `[{"op":"remove","path":"/completed"}]`, domain.ErrInvalidPatch},
+			{models.JSONPatchType, `[{"op":"replace","path":"/owner","value":"x"}]`, domain.ErrPatchPath},
+			{models.JSONPatchType, `[{"op":"test","path":"/title","value":"report"},{"op":"replace","path":"/title","value":"x"}]`, domain.ErrPatchTestFailed},
+		}
+		for _, tt := range tests {
+			_, err := patch(tt.contentType, tt.body)
+			assert.ErrorIs(t, err, tt.want, tt.body)
+		}
+
+		found, _ := service.GetTodoByID(ctx, todo.ID, user.ID)
+		assert.Equal(t, "final report", found.Title)
+		assert.Equal(t, 3, found.Version)
+	})
+
+	t.Run("stale If-Match is rejected", func(t *testing.T) {
+		stale := 1
+		_, err := service.PatchTodo(ctx, todo.ID, user.ID, models.TodoPatch{ContentType: models.MergePatchType, Body: []byte(`{"title":"lost"}`), Version: &stale})
+		assert.Equal(t, domain.ErrVersionMismatch, err)
+	})
+}
+
+func TestTodoService_Batch(t *testing.T) {
+	ctx := context.Background()
+	mockUserStore := mocks.NewMockStore()
+	mockTodoStore := mocks.NewMockTodoStore()
+	transactor := mocks.NewMockTransactor()
+	service := NewTodoService(mockUserStore, mockTodoStore, nil, nil, mocks.NewMockRevisionStore(), transactor, nil, 3, slog.Default())
+
+	user, _ := mockUserStore.CreateUser(ctx, "batch@example.com", "hash")
+	todo, _ := service.CreateTodo(ctx, user.ID, models.CreateTodoRequest{Title: "existing"})
+
+	t.Run("results follow the operations", func(t *testing.T) {
+		calls := transactor.Calls
+		results, err := service.BatchTodos(ctx, user.ID, []models.BatchOperation{
+			{Op: models.BatchCreate, Todo: json.RawMessage(`{"title":"new","priority":"urgent"}`)},
+			{Op: models.BatchUpdate, ID: &todo.ID, Todo: json.RawMessage(`{"description":"updated"}`)},
+			{Op: models.BatchComplete, ID: &todo.ID},
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, calls+1, transactor.Calls)
+		assert.Equal(t, "new", results[0].Todo.Title)
+		assert.Equal(t, entities.PriorityUrgent, results[0].Todo.Priority)
+		assert.Equal(t, todo.ID, results[1].ID)
+		assert.Equal(t, "updated", results[1].Todo.Description)
+		assert.Equal(t, 2, results[2].Index)
+		assert.True(t, results[2].Todo.Completed)
+	})
+
+	t.Run("failed operation is reported with its index", func(t *testing.T) {
+		missing := uuid.New()
+		tests := []struct {
+			ops  []models.BatchOperation
+			want error
+		}{
+			{[]models.BatchOperation{{Op: models.BatchCreate, Todo: json.RawMessage(`{"title":"ok"}`)}, {Op: models.BatchDelete}}, domain.ErrInvalidBatch},
+			{[]models.BatchOperation{{Op: models.BatchCreate, Todo: json.RawMessage(`{"title":"x","priority":"asap"}`)}}, domain.ErrInvalidBatch},
+			{[]models.BatchOperation{{Op: models.BatchCreate}}, domain.ErrInvalidBatch},
+			{[]models.BatchOperation{{Op: models.BatchCreate, Todo: json.RawMessage(`{"title":"ok"}`)}, {Op: models.BatchComplete, ID: &missing}}, domain.ErrTodoNotFound},
+		}
+		for _, tt := range tests {
+			_, err := service.BatchTodos(ctx, user.ID, tt.ops)
+			var batchErr *domain.BatchError
+			require.ErrorAs(t, err, &batchErr)
+			assert.Equal(t, len(tt.ops)-1, batchErr.Index)
+			assert.ErrorIs(t, err, tt.want)
+		}
+	})
+}
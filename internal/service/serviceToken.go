@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository"
+)
+
+type TokenService interface {
+	ValidateToken(ctx context.Context, token string) (*models.Claims, error)
+	// RevokeToken отзывает токен; повторный отзыв не ошибка.
+	RevokeToken(ctx context.Context, claims *models.Claims) error
+	// ConsumeToken отзывает одноразовый токен (refresh) и возвращает domain.ErrTokenRevoked,
+	// если его уже отозвал другой запрос: токен можно обменять только один раз.
+	ConsumeToken(ctx context.Context, claims *models.Claims) error
+	Introspect(ctx context.Context, token string) models.IntrospectionResponse
+}
+
+type tokenService struct {
+	tokenRepo repository.TokenStore
+	patRepo   repository.PersonalTokenStore
+	userRepo  repository.Store
+	signer    *auth.JWTSigner
+	logger    *slog.Logger
+}
+
+func NewTokenService(tokenRepo repository.TokenStore, patRepo repository.PersonalTokenStore, userRepo repository.Store, signer *auth.JWTSigner, logger *slog.Logger) TokenService {
+	return &tokenService{
+		tokenRepo: tokenRepo,
+		patRepo:   patRepo,
+		userRepo:  userRepo,
+		signer:    signer,
+		logger:    logger,
+	}
+}
+
+// ValidateToken проверяет подпись и срок действия токена, а также то, что он не отозван.
+// Персональный токен ищется в хранилище и описывается такими же claims, как JWT.
+func (s *tokenService) ValidateToken(ctx context.Context, token string) (*models.Claims, error) {
+	if auth.IsPersonalToken(token) {
+		return s.validatePersonalToken(ctx, token)
+	}
+	claims, err := s.signer.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.ID != "" {
+		revoked, err := s.tokenRepo.IsTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			s.logger.Error("service: check token revocation failed", slog.String("jti", claims.ID), slog.Any("error", err))
+			return nil, err
+		}
+		if revoked {
+			s.logger.Warn("service: revoked token used", slog.String("jti", claims.ID))
+			return nil, domain.ErrTokenRevoked
+		}
+	}
+
+	return claims, nil
+}
+
+func (s *tokenService) validatePersonalToken(ctx context.Context, token string) (*models.Claims, error) {
+	pat, err := s.patRepo.GetPersonalTokenByHash(ctx, auth.HashPersonalToken(token))
+	if err != nil {
+		if errors.Is(err, domain.ErrPersonalTokenNotFound) {
+			s.logger.Warn("service: unknown personal token used")
+			return nil, domain.ErrPersonalTokenInvalid
+		}
+		s.logger.Error("service: personal token lookup failed", slog.Any("error", err))
+		return nil, err
+	}
+	if pat.RevokedAt != nil {
+		s.logger.Warn("service: revoked personal token used", slog.String("token_id", pat.ID.String()))
+		return nil, domain.ErrTokenRevoked
+	}
+	if pat.ExpiresAt != nil && !pat.ExpiresAt.After(time.Now()) {
+		s.logger.Warn("service: expired personal token used", slog.String("token_id", pat.ID.String()))
+		return nil, domain.ErrPersonalTokenInvalid
+	}
+	user, err := s.userRepo.GetUserById(ctx, pat.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrPersonalTokenInvalid
+		}
+		s.logger.Error("service: personal token owner lookup failed", slog.String("token_id", pat.ID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	claims := &models.Claims{
+		UserID: user.ID.String(),
+		Email:  user.Email,
+		Type:   auth.TokenTypePersonal,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       pat.ID.String(),
+			Subject:  user.ID.String(),
+			IssuedAt: jwt.NewNumericDate(pat.CreatedAt),
+		},
+	}
+	if pat.ExpiresAt != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*pat.ExpiresAt)
+	}
+	return claims, nil
+}
+
+func (s *tokenService) RevokeToken(ctx context.Context, claims *models.Claims) error {
+	if claims.Type == auth.TokenTypePersonal {
+		return s.revokePersonalToken(ctx, claims)
+	}
+	if err := s.ConsumeToken(ctx, claims); err != nil && !errors.Is(err, domain.ErrTokenRevoked) {
+		return err
+	}
+	return nil
+}
+
+func (s *tokenService) ConsumeToken(ctx context.Context, claims *models.Claims) error {
+	if claims.ID == "" {
+		// Старые токены без jti отозвать нельзя, они истекут сами.
+		s.logger.Warn("service: token without jti cannot be revoked", slog.String("sub", claims.Subject))
+		return nil
+	}
+
+	expiresAt := time.Now()
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	if err := s.tokenRepo.RevokeToken(ctx, claims.ID, expiresAt); err != nil {
+		if errors.Is(err, domain.ErrTokenRevoked) {
+			s.logger.Warn("service: token already revoked", slog.String("jti", claims.ID))
+			return err
+		}
+		s.logger.Error("service: revoke token failed", slog.String("jti", claims.ID), slog.Any("error", err))
+		return err
+	}
+
+	s.logger.Info("service: token revoked", slog.String("jti", claims.ID), slog.String("token_type", claims.Type))
+	return nil
+}
+
+// revokePersonalToken отзывает персональный токен, которым подписан запрос (logout с PAT).
+func (s *tokenService) revokePersonalToken(ctx context.Context, claims *models.Claims) error {
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return domain.ErrPersonalTokenInvalid
+	}
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return domain.ErrPersonalTokenInvalid
+	}
+	if err := s.patRepo.RevokePersonalToken(ctx, userID, tokenID); err != nil && !errors.Is(err, domain.ErrPersonalTokenNotFound) {
+		s.logger.Error("service: revoke personal token failed", slog.String("token_id", claims.ID), slog.Any("error", err))
+		return err
+	}
+	s.logger.Info("service: token revoked", slog.String("jti", claims.ID), slog.String("token_type", claims.Type))
+	return nil
+}
+
+// Introspect возвращает active=false для любого недействительного токена, не раскрывая причину.
+func (s *tokenService) Introspect(ctx context.Context, token string) models.IntrospectionResponse {
+	claims, err := s.ValidateToken(ctx, token)
+	if err != nil {
+		s.logger.Info("service: introspected inactive token", slog.Any("reason", err))
+		return models.IntrospectionResponse{Active: false}
+	}
+
+	resp := models.IntrospectionResponse{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Username:  claims.Email,
+		TokenType: claims.Type,
+		Sub:       claims.Subject,
+		Jti:       claims.ID,
+	}
+	if resp.Sub == "" {
+		resp.Sub = claims.UserID
+	}
+	if claims.ExpiresAt != nil {
+		resp.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.Iat = claims.IssuedAt.Unix()
+	}
+	return resp
+}
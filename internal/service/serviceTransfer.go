@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository"
+	"github.com/polzovatel/todo-learning/internal/todoio"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// exportPageSize — сколько задач экспорт читает и отправляет за раз.
+	exportPageSize = 100
+	maxImportRows  = 10000
+)
+
+// errDryRun откатывает транзакцию пробного импорта.
+var errDryRun = errors.New("dry run")
+
+// TransferService переносит задачи пользователя в текущем рабочем пространстве в файлы
+// обмена и обратно; форматы описаны в пакете todoio.
+type TransferService interface {
+	// ExportTodos пишет задачи в w страницами, не собирая файл в памяти.
+	ExportTodos(ctx context.Context, userID uuid.UUID, format todoio.Format, w io.Writer) error
+	// ImportTodos создаёт задачи из файла в одной транзакции с проверками POST /todos.
+	// Проекты и метки находятся по имени или создаются, подзадачи связываются с родителем
+	// по id из файла. Если хоть одна строка не импортируется, не сохраняется ничего и
+	// вместе с отчётом возвращается domain.ErrImportRows. dryRun проверяет файл так же,
+	// но откатывает изменения.
+	ImportTodos(ctx context.Context, userID uuid.UUID, format todoio.Format, r io.Reader, dryRun bool) (models.ImportReport, error)
+	// ImportRows импортирует уже прочитанные строки так же, как ImportTodos; source —
+	// имя формата или сервиса для журнала. progress, если задан, получает число
+	// обработанных строк после каждой задачи верхнего уровня.
+	ImportRows(ctx context.Context, userID uuid.UUID, source string, rows []todoio.Row, dryRun bool, progress func(processed int)) (models.ImportReport, error)
+}
+
+type transferService struct {
+	todos      TodoService
+	projects   ProjectService
+	tags       TagService
+	transactor repository.Transactor
+	cache      *redis.Client
+	logger     *slog.Logger
+}
+
+func NewTransferService(todos TodoService, projects ProjectService, tags TagService, transactor repository.Transactor, redis *redis.Client, logger *slog.Logger) TransferService {
+	return &transferService{
+		todos:      todos,
+		projects:   projects,
+		tags:       tags,
+		transactor: transactor,
+		cache:      redis,
+		logger:     logger,
+	}
+}
+
+func (s *transferService) ExportTodos(ctx context.Context, userID uuid.UUID, format todoio.Format, w io.Writer) error {
+	projects, err := s.projects.GetProjects(ctx, userID, true)
+	if err != nil {
+		return err
+	}
+	projectNames := make(map[uuid.UUID]string, len(projects))
+	for _, project := range projects {
+		projectNames[project.ID] = project.Name
+	}
+
+	encoder := format.NewEncoder(w)
+	// По дате создания родитель идёт раньше подзадач, и импорт восстановит порядок.
+	req := models.ListTodosRequest{Sort: models.TodoSortCreatedAt, Order: models.SortAsc, Limit: exportPageSize}
+	count := 0
+	for {
+		page, err := s.todos.ListTodos(ctx, userID, req)
+		if err != nil {
+			return err
+		}
+		for _, todo := range page.Todos {
+			tags, err := s.tags.GetTodoTags(ctx, todo.ID, userID)
+			if err != nil {
+				return err
+			}
+			if err := encoder.Encode(exportItem(todo, projectNames, tags)); err != nil {
+				s.logger.Warn("service: export write failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+				return err
+			}
+		}
+		count += len(page.Todos)
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		req.Cursor = page.NextCursor
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	s.logger.Info("service: todos exported", slog.String("user_id", userID.String()), slog.String("format", format.Name), slog.Int("count", count))
+	return nil
+}
+
+func exportItem(todo entities.Todo, projectNames map[uuid.UUID]string, tags []entities.Tag) todoio.Item {
+	created := todo.CreatedAt
+	item := todoio.Item{
+		ID:          todo.ID.String(),
+		Title:       todo.Title,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		Priority:    todo.Priority,
+		DueAt:       todo.DueAt,
+		RemindAt:    todo.RemindAt,
+		Recurrence:  todo.Recurrence,
+		CreatedAt:   &created,
+	}
+	if todo.ParentID != nil {
+		item.ParentID = todo.ParentID.String()
+	}
+	if todo.ProjectID != nil {
+		item.Project = projectNames[*todo.ProjectID]
+	}
+	for _, tag := range tags {
+		item.Tags = append(item.Tags, tag.Name)
+	}
+	return item
+}
+
+func (s *transferService) ImportTodos(ctx context.Context, userID uuid.UUID, format todoio.Format, r io.Reader, dryRun bool) (models.ImportReport, error) {
+	rows, err := format.Decode(r)
+	if err != nil {
+		s.logger.Warn("service: import file unreadable", slog.String("user_id", userID.String()), slog.String("format", format.Name), slog.Any("error", err))
+		return models.ImportReport{}, err
+	}
+	return s.ImportRows(ctx, userID, format.Name, rows, dryRun, nil)
+}
+
+// checkImportSize отклоняет файл, в котором задач больше maxImportRows.
+func checkImportSize(rows []todoio.Row) error {
+	if len(rows) > maxImportRows {
+		return fmt.Errorf("%w: more than %d todos", domain.ErrInvalidImport, maxImportRows)
+	}
+	return nil
+}
+
+func (s *transferService) ImportRows(ctx context.Context, userID uuid.UUID, source string, rows []todoio.Row, dryRun bool, progress func(processed int)) (models.ImportReport, error) {
+	if err := checkImportSize(rows); err != nil {
+		s.logger.Warn("service: import file too large", slog.String("user_id", userID.String()), slog.Int("rows", len(rows)))
+		return models.ImportReport{}, err
+	}
+
+	report := models.ImportReport{DryRun: dryRun, Total: len(rows), Errors: []models.ImportRowError{}}
+	imp := newTodoImport(s, userID, rows)
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := imp.load(ctx); err != nil {
+			return err
+		}
+		for i := range rows {
+			// Прерванный импорт откатывается целиком.
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			imp.process(ctx, i)
+			if progress != nil {
+				progress(imp.processed)
+			}
+		}
+		for i, err := range imp.errs {
+			if err != nil {
+				report.Errors = append(report.Errors, models.ImportRowError{Row: rows[i].Number, Title: rows[i].Item.Title, Error: err.Error()})
+			}
+		}
+		report.Valid = len(rows) - len(report.Errors)
+		switch {
+		case len(report.Errors) > 0:
+			return domain.ErrImportRows
+		case dryRun:
+			return errDryRun
+		}
+		return nil
+	})
+	if err == nil {
+		report.Imported = len(rows)
+		s.logger.Info("service: todos imported", slog.String("user_id", userID.String()), slog.String("source", source), slog.Int("count", report.Imported))
+		return report, nil
+	}
+
+	// Откатились задачи, которые сервис задач уже положил в кэш.
+	s.invalidate(ctx, imp.created)
+	switch {
+	case errors.Is(err, errDryRun):
+		s.logger.Info("service: import dry run", slog.String("user_id", userID.String()), slog.Int("valid", report.Valid))
+		return report, nil
+	case errors.Is(err, domain.ErrImportRows):
+		s.logger.Warn("service: import rejected", slog.String("user_id", userID.String()), slog.Int("errors", len(report.Errors)))
+		if dryRun {
+			return report, nil
+		}
+		return report, err
+	default:
+		s.logger.Error("service: import failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return models.ImportReport{}, err
+	}
+}
+
+func (s *transferService) invalidate(ctx context.Context, todos []entities.Todo) {
+	if s.cache == nil || len(todos) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(todos)+1)
+	for _, todo := range todos {
+		keys = append(keys, "todo:"+todo.ID.String())
+	}
+	keys = append(keys, todosListKey(todos[0].WorkspaceID, todos[0].UserID))
+	if err := s.cache.Del(ctx, keys...).Err(); err != nil {
+		s.logger.Warn("service: failed to invalidate imported todos cache", slog.Any("error", err))
+	}
+}
+
+// todoImport — состояние одного импорта: какие строки уже созданы и под какими id,
+// какие проекты и метки пользователя известны по имени.
+type todoImport struct {
+	s        *transferService
+	userID   uuid.UUID
+	rows     []todoio.Row
+	byID     map[string]int
+	ids      []uuid.UUID
+	errs     []error
+	done     []bool
+	visiting []bool
+	projects map[string]uuid.UUID
+	tags     map[string]uuid.UUID
+	created  []entities.Todo
+	// processed — сколько строк уже создано или отклонено.
+	processed int
+}
+
+func newTodoImport(s *transferService, userID uuid.UUID, rows []todoio.Row) *todoImport {
+	imp := &todoImport{
+		s:        s,
+		userID:   userID,
+		rows:     rows,
+		byID:     make(map[string]int),
+		ids:      make([]uuid.UUID, len(rows)),
+		errs:     make([]error, len(rows)),
+		done:     make([]bool, len(rows)),
+		visiting: make([]bool, len(rows)),
+		projects: make(map[string]uuid.UUID),
+		tags:     make(map[string]uuid.UUID),
+	}
+	for i, row := range rows {
+		if row.Err != nil || row.Item.ID == "" {
+			continue
+		}
+		if first, ok := imp.byID[row.Item.ID]; ok {
+			imp.errs[i] = fmt.Errorf("id %q repeats row %d", row.Item.ID, rows[first].Number)
+			imp.done[i] = true
+			imp.processed++
+			continue
+		}
+		imp.byID[row.Item.ID] = i
+	}
+	return imp
+}
+
+func (imp *todoImport) load(ctx context.Context) error {
+	projects, err := imp.s.projects.GetProjects(ctx, imp.userID, false)
+	if err != nil {
+		return err
+	}
+	for _, project := range projects {
+		imp.projects[nameKey(project.Name)] = project.ID
+	}
+	tags, err := imp.s.tags.GetTags(ctx, imp.userID)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		imp.tags[nameKey(tag.Name)] = tag.ID
+	}
+	return nil
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// process создаёт задачу строки i, сначала создав её родителя из файла.
+func (imp *todoImport) process(ctx context.Context, i int) {
+	if imp.done[i] {
+		return
+	}
+	imp.visiting[i] = true
+	row := imp.rows[i]
+	err := row.Err
+	var parentID *uuid.UUID
+	if err == nil && row.Item.ParentID != "" {
+		parent, ok := imp.byID[row.Item.ParentID]
+		switch {
+		case !ok:
+			err = fmt.Errorf("parent %q is not in the file", row.Item.ParentID)
+		case imp.visiting[parent]:
+			err = fmt.Errorf("parent %q is its own descendant", row.Item.ParentID)
+		default:
+			imp.process(ctx, parent)
+			if imp.errs[parent] != nil {
+				err = fmt.Errorf("parent row %d cannot be imported", imp.rows[parent].Number)
+			} else {
+				parentID = &imp.ids[parent]
+			}
+		}
+	}
+	if err == nil {
+		imp.ids[i], err = imp.create(ctx, row.Item, parentID)
+	}
+	imp.errs[i] = err
+	imp.done[i] = true
+	imp.visiting[i] = false
+	imp.processed++
+}
+
+func (imp *todoImport) create(ctx context.Context, item todoio.Item, parentID *uuid.UUID) (uuid.UUID, error) {
+	if err := validators.ValidateTodo(item.Title); err != nil {
+		return uuid.Nil, err
+	}
+	if item.Priority != "" && !validPriority(item.Priority) {
+		return uuid.Nil, fmt.Errorf("invalid priority %q", item.Priority)
+	}
+	req := models.CreateTodoRequest{
+		Title:       strings.TrimSpace(item.Title),
+		Description: item.Description,
+		DueAt:       item.DueAt,
+		RemindAt:    item.RemindAt,
+		Priority:    item.Priority,
+		ParentID:    parentID,
+		Recurrence:  item.Recurrence,
+	}
+	if item.Project != "" {
+		projectID, err := imp.project(ctx, item.Project)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		req.ProjectID = &projectID
+	}
+	todo, err := imp.s.todos.CreateTodo(ctx, imp.userID, req)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	imp.created = append(imp.created, todo)
+
+	attached := make(map[uuid.UUID]bool, len(item.Tags))
+	for _, name := range item.Tags {
+		tagID, err := imp.tag(ctx, name)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if attached[tagID] {
+			continue
+		}
+		attached[tagID] = true
+		if err := imp.s.tags.AttachTag(ctx, todo.ID, tagID, imp.userID); err != nil {
+			return uuid.Nil, err
+		}
+	}
+	if item.Completed {
+		completed := true
+		if _, err := imp.s.todos.UpdateTodo(ctx, todo.ID, imp.userID, models.UpdateTodoRequest{Completed: &completed}); err != nil {
+			return uuid.Nil, err
+		}
+	}
+	return todo.ID, nil
+}
+
+func (imp *todoImport) project(ctx context.Context, name string) (uuid.UUID, error) {
+	if id, ok := imp.projects[nameKey(name)]; ok {
+		return id, nil
+	}
+	project, err := imp.s.projects.CreateProject(ctx, imp.userID, models.CreateProjectRequest{Name: name})
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("project %q: %w", name, err)
+	}
+	imp.projects[nameKey(name)] = project.ID
+	return project.ID, nil
+}
+
+func (imp *todoImport) tag(ctx context.Context, name string) (uuid.UUID, error) {
+	if id, ok := imp.tags[nameKey(name)]; ok {
+		return id, nil
+	}
+	tag, err := imp.s.tags.CreateTag(ctx, imp.userID, models.CreateTagRequest{Name: name})
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("tag %q: %w", name, err)
+	}
+	imp.tags[nameKey(name)] = tag.ID
+	return tag.ID, nil
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/auth/webauthn"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository"
+)
+
+const webAuthnChallengeTTL = 5 * time.Minute
+
+type WebAuthnService interface {
+	BeginRegistration(ctx context.Context, userID uuid.UUID) (models.WebAuthnCreationOptions, error)
+	FinishRegistration(ctx context.Context, userID uuid.UUID, req models.WebAuthnRegistrationRequest) (entities.WebAuthnCredential, error)
+	BeginLogin(ctx context.Context, email string) (models.WebAuthnRequestOptions, error)
+	FinishLogin(ctx context.Context, req models.WebAuthnLoginRequest) (*entities.User, error)
+	GetCredentials(ctx context.Context, userID uuid.UUID) ([]entities.WebAuthnCredential, error)
+}
+
+type webAuthnService struct {
+	userRepo     repository.Store
+	webAuthnRepo repository.WebAuthnStore
+	rp           webauthn.RelyingParty
+	logger       *slog.Logger
+}
+
+func NewWebAuthnService(userRepo repository.Store, webAuthnRepo repository.WebAuthnStore, rp webauthn.RelyingParty, logger *slog.Logger) WebAuthnService {
+	return &webAuthnService{
+		userRepo:     userRepo,
+		webAuthnRepo: webAuthnRepo,
+		rp:           rp,
+		logger:       logger,
+	}
+}
+
+func (s *webAuthnService) BeginRegistration(ctx context.Context, userID uuid.UUID) (models.WebAuthnCreationOptions, error) {
+	user, err := s.userRepo.GetUserById(ctx, userID)
+	if err != nil {
+		s.logger.Error("service: get user for webauthn registration failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return models.WebAuthnCreationOptions{}, err
+	}
+
+	creds, err := s.webAuthnRepo.GetWebAuthnCredentialsByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("service: list webauthn credentials failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return models.WebAuthnCreationOptions{}, err
+	}
+
+	challenge, err := s.newChallenge(ctx, &userID, entities.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return models.WebAuthnCreationOptions{}, err
+	}
+
+	return models.WebAuthnCreationOptions{
+		Challenge: challenge,
+		RP:        models.WebAuthnRelyingParty{ID: s.rp.ID, Name: s.rp.Name},
+		User: models.WebAuthnUser{
+			ID:          base64.RawURLEncoding.EncodeToString(user.ID[:]),
+			Name:        user.Email,
+			DisplayName: user.Email,
+		},
+		PubKeyCredParams:   []models.WebAuthnCredentialParam{{Type: "public-key", Alg: webauthn.AlgES256}},
+		Timeout:            webAuthnChallengeTTL.Milliseconds(),
+		Attestation:        "none",
+		ExcludeCredentials: credentialDescriptors(creds),
+		AuthenticatorSelection: models.WebAuthnAuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "preferred",
+		},
+	}, nil
+}
+
+func (s *webAuthnService) FinishRegistration(ctx context.Context, userID uuid.UUID, req models.WebAuthnRegistrationRequest) (entities.WebAuthnCredential, error) {
+	clientData, err := decodeBase64URL(req.Response.ClientDataJSON)
+	if err != nil {
+		return entities.WebAuthnCredential{}, fmt.Errorf("%w: clientDataJSON: %v", domain.ErrWebAuthnFailed, err)
+	}
+	attestation, err := decodeBase64URL(req.Response.AttestationObject)
+	if err != nil {
+		return entities.WebAuthnCredential{}, fmt.Errorf("%w: attestationObject: %v", domain.ErrWebAuthnFailed, err)
+	}
+
+	challenge, err := s.consumeChallenge(ctx, clientData, entities.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return entities.WebAuthnCredential{}, err
+	}
+	if challenge.UserID == nil || *challenge.UserID != userID {
+		s.logger.Warn("service: webauthn challenge issued for another user", slog.String("user_id", userID.String()))
+		return entities.WebAuthnCredential{}, domain.ErrChallengeNotFound
+	}
+
+	reg, err := s.rp.VerifyRegistration(clientData, attestation, challenge.Challenge)
+	if err != nil {
+		s.logger.Warn("service: webauthn registration rejected", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return entities.WebAuthnCredential{}, fmt.Errorf("%w: %v", domain.ErrWebAuthnFailed, err)
+	}
+
+	cred, err := s.webAuthnRepo.CreateWebAuthnCredential(ctx, userID, reg.CredentialID, reg.PublicKey, reg.SignCount)
+	if err != nil {
+		s.logger.Error("service: save webauthn credential failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return entities.WebAuthnCredential{}, err
+	}
+
+	s.logger.Info("service: webauthn credential registered", slog.String("user_id", userID.String()))
+	return cred, nil
+}
+
+func (s *webAuthnService) BeginLogin(ctx context.Context, email string) (models.WebAuthnRequestOptions, error) {
+	var userID *uuid.UUID
+	allow := make([]models.WebAuthnCredentialDescriptor, 0)
+
+	// Для неизвестного email и для пользователя без ключей allowCredentials пуст, а для
+	// пользователя с ключами — нет, так что по ответу видно, есть ли у email ключи. Такое
+	// перечисление допускается: без списка браузер не знает, какой ключ предложить.
+	if email != "" {
+		user, err := s.userRepo.GetUserByEmail(ctx, email)
+		if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+			s.logger.Error("service: get user for webauthn login failed", slog.Any("error", err))
+			return models.WebAuthnRequestOptions{}, err
+		}
+		if user != nil {
+			creds, err := s.webAuthnRepo.GetWebAuthnCredentialsByUserID(ctx, user.ID)
+			if err != nil {
+				s.logger.Error("service: list webauthn credentials failed", slog.String("user_id", user.ID.String()), slog.Any("error", err))
+				return models.WebAuthnRequestOptions{}, err
+			}
+			userID = &user.ID
+			allow = credentialDescriptors(creds)
+		}
+	}
+
+	challenge, err := s.newChallenge(ctx, userID, entities.WebAuthnCeremonyAuthentication)
+	if err != nil {
+		return models.WebAuthnRequestOptions{}, err
+	}
+
+	return models.WebAuthnRequestOptions{
+		Challenge:        challenge,
+		RPID:             s.rp.ID,
+		Timeout:          webAuthnChallengeTTL.Milliseconds(),
+		AllowCredentials: allow,
+		UserVerification: "preferred",
+	}, nil
+}
+
+func (s *webAuthnService) FinishLogin(ctx context.Context, req models.WebAuthnLoginRequest) (*entities.User, error) {
+	credentialID, err := decodeBase64URL(req.RawID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: rawId: %v", domain.ErrWebAuthnFailed, err)
+	}
+	clientData, err := decodeBase64URL(req.Response.ClientDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%w: clientDataJSON: %v", domain.ErrWebAuthnFailed, err)
+	}
+	authData, err := decodeBase64URL(req.Response.AuthenticatorData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: authenticatorData: %v", domain.ErrWebAuthnFailed, err)
+	}
+	signature, err := decodeBase64URL(req.Response.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", domain.ErrWebAuthnFailed, err)
+	}
+	userHandle, err := decodeBase64URL(req.Response.UserHandle)
+	if err != nil {
+		return nil, fmt.Errorf("%w: userHandle: %v", domain.ErrWebAuthnFailed, err)
+	}
+
+	challenge, err := s.consumeChallenge(ctx, clientData, entities.WebAuthnCeremonyAuthentication)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := s.webAuthnRepo.GetWebAuthnCredential(ctx, credentialID)
+	if err != nil {
+		if errors.Is(err, domain.ErrCredentialNotFound) {
+			s.logger.Warn("service: unknown webauthn credential")
+			return nil, domain.ErrWebAuthnFailed
+		}
+		s.logger.Error("service: get webauthn credential failed", slog.Any("error", err))
+		return nil, err
+	}
+	if challenge.UserID != nil && *challenge.UserID != cred.UserID {
+		s.logger.Warn("service: webauthn credential belongs to another user", slog.String("user_id", cred.UserID.String()))
+		return nil, domain.ErrWebAuthnFailed
+	}
+	if len(userHandle) != 0 && !bytes.Equal(userHandle, cred.UserID[:]) {
+		s.logger.Warn("service: webauthn user handle mismatch", slog.String("user_id", cred.UserID.String()))
+		return nil, domain.ErrWebAuthnFailed
+	}
+
+	signCount, err := s.rp.VerifyAssertion(clientData, authData, signature, cred.PublicKey, challenge.Challenge, cred.SignCount)
+	if err != nil {
+		s.logger.Warn("service: webauthn assertion rejected", slog.String("user_id", cred.UserID.String()), slog.Any("error", err))
+		return nil, fmt.Errorf("%w: %v", domain.ErrWebAuthnFailed, err)
+	}
+	if err := s.webAuthnRepo.UpdateWebAuthnSignCount(ctx, cred.ID, signCount); err != nil {
+		s.logger.Error("service: update webauthn sign count failed", slog.String("user_id", cred.UserID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserById(ctx, cred.UserID)
+	if err != nil {
+		s.logger.Error("service: get user for webauthn login failed", slog.String("user_id", cred.UserID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	s.logger.Info("service: webauthn login succeeded", slog.String("user_id", user.ID.String()))
+	return user, nil
+}
+
+func (s *webAuthnService) GetCredentials(ctx context.Context, userID uuid.UUID) ([]entities.WebAuthnCredential, error) {
+	creds, err := s.webAuthnRepo.GetWebAuthnCredentialsByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("service: list webauthn credentials failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	return creds, nil
+}
+
+func (s *webAuthnService) newChallenge(ctx context.Context, userID *uuid.UUID, ceremony string) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		s.logger.Error("service: generate webauthn challenge failed", slog.Any("error", err))
+		return "", err
+	}
+
+	err = s.webAuthnRepo.SaveWebAuthnChallenge(ctx, entities.WebAuthnChallenge{
+		Challenge: challenge,
+		UserID:    userID,
+		Ceremony:  ceremony,
+		ExpiresAt: time.Now().Add(webAuthnChallengeTTL),
+	})
+	if err != nil {
+		s.logger.Error("service: save webauthn challenge failed", slog.Any("error", err))
+		return "", err
+	}
+	return challenge, nil
+}
+
+// consumeChallenge находит по clientDataJSON выданный challenge и гасит его.
+func (s *webAuthnService) consumeChallenge(ctx context.Context, clientData []byte, ceremony string) (*entities.WebAuthnChallenge, error) {
+	cd, err := webauthn.ParseClientData(clientData)
+	if err != nil {
+		s.logger.Warn("service: invalid webauthn client data", slog.Any("error", err))
+		return nil, fmt.Errorf("%w: %v", domain.ErrWebAuthnFailed, err)
+	}
+
+	challenge, err := s.webAuthnRepo.ConsumeWebAuthnChallenge(ctx, cd.Challenge)
+	if err != nil {
+		if !errors.Is(err, domain.ErrChallengeNotFound) {
+			s.logger.Error("service: consume webauthn challenge failed", slog.Any("error", err))
+		}
+		return nil, err
+	}
+	if challenge.Ceremony != ceremony {
+		s.logger.Warn("service: webauthn challenge issued for another ceremony", slog.String("ceremony", challenge.Ceremony))
+		return nil, domain.ErrChallengeNotFound
+	}
+	return challenge, nil
+}
+
+func credentialDescriptors(creds []entities.WebAuthnCredential) []models.WebAuthnCredentialDescriptor {
+	descriptors := make([]models.WebAuthnCredentialDescriptor, 0, len(creds))
+	for _, cred := range creds {
+		descriptors = append(descriptors, models.WebAuthnCredentialDescriptor{
+			Type: "public-key",
+			ID:   base64.RawURLEncoding.EncodeToString(cred.CredentialID),
+		})
+	}
+	return descriptors
+}
+
+// decodeBase64URL принимает base64url как с паддингом, так и без него.
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
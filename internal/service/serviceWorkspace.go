@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
+	"github.com/polzovatel/todo-learning/internal/models"
+	"github.com/polzovatel/todo-learning/internal/repository"
+)
+
+// WorkspaceService управляет рабочими пространствами и их участниками. Участниками
+// управляют admin и owner; назначить или снять владельца может только owner, а
+// последнего владельца пространства снять нельзя.
+type WorkspaceService interface {
+	CreateWorkspace(ctx context.Context, userID uuid.UUID, req models.CreateWorkspaceRequest) (models.WorkspaceResponse, error)
+	// GetWorkspaces возвращает пространства пользователя, личное первым.
+	GetWorkspaces(ctx context.Context, userID uuid.UUID) ([]models.WorkspaceResponse, error)
+	GetWorkspace(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) (models.WorkspaceResponse, error)
+	UpdateWorkspace(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID, req models.UpdateWorkspaceRequest) (models.WorkspaceResponse, error)
+	// DeleteWorkspace удаляет пространство со всеми задачами и проектами; личное удалить нельзя.
+	DeleteWorkspace(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) error
+	// AddMember добавляет пользователя с указанной почтой или меняет его роль.
+	AddMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID, req models.AddWorkspaceMemberRequest) (models.WorkspaceMemberResponse, error)
+	GetMembers(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) ([]models.WorkspaceMemberResponse, error)
+	// RemoveMember исключает memberID; участник может так покинуть пространство сам.
+	RemoveMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID, memberID uuid.UUID) error
+	// Membership возвращает domain.ErrNotWorkspaceMember, если пользователь не участник.
+	Membership(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) (*entities.WorkspaceMember, error)
+}
+
+type workspaceService struct {
+	userRepo      repository.Store
+	workspaceRepo repository.WorkspaceStore
+	logger        *slog.Logger
+}
+
+func NewWorkspaceService(userRepo repository.Store, workspaceRepo repository.WorkspaceStore, logger *slog.Logger) WorkspaceService {
+	return &workspaceService{
+		userRepo:      userRepo,
+		workspaceRepo: workspaceRepo,
+		logger:        logger,
+	}
+}
+
+func (s *workspaceService) CreateWorkspace(ctx context.Context, userID uuid.UUID, req models.CreateWorkspaceRequest) (models.WorkspaceResponse, error) {
+	if err := validators.ValidateWorkspace(req.Name); err != nil {
+		return models.WorkspaceResponse{}, err
+	}
+
+	workspace, err := s.workspaceRepo.CreateWorkspace(ctx, entities.Workspace{Name: strings.TrimSpace(req.Name)}, userID)
+	if err != nil {
+		s.logger.Error("service: create workspace failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return models.WorkspaceResponse{}, err
+	}
+
+	s.logger.Info("service: workspace created", slog.String("workspace_id", workspace.ID.String()), slog.String("user_id", userID.String()))
+	return models.WorkspaceResponse{Workspace: workspace, Role: entities.WorkspaceRoleOwner}, nil
+}
+
+func (s *workspaceService) GetWorkspaces(ctx context.Context, userID uuid.UUID) ([]models.WorkspaceResponse, error) {
+	memberships, err := s.workspaceRepo.GetWorkspaceMemberships(ctx, userID)
+	if err != nil {
+		s.logger.Error("service: list memberships failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	resp := make([]models.WorkspaceResponse, 0, len(memberships))
+	for _, member := range memberships {
+		workspace, err := s.workspaceRepo.GetWorkspaceByID(ctx, member.WorkspaceID)
+		if errors.Is(err, domain.ErrWorkspaceNotFound) {
+			continue
+		}
+		if err != nil {
+			s.logger.Error("service: get workspace failed", slog.String("workspace_id", member.WorkspaceID.String()), slog.Any("error", err))
+			return nil, err
+		}
+		item := models.WorkspaceResponse{Workspace: *workspace, Role: member.Role}
+		if workspace.ID == userID {
+			resp = append([]models.WorkspaceResponse{item}, resp...)
+		} else {
+			resp = append(resp, item)
+		}
+	}
+	return resp, nil
+}
+
+func (s *workspaceService) GetWorkspace(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) (models.WorkspaceResponse, error) {
+	workspace, member, err := s.authorized(ctx, workspaceID, userID, entities.WorkspaceRoleMember)
+	if err != nil {
+		return models.WorkspaceResponse{}, err
+	}
+	return models.WorkspaceResponse{Workspace: *workspace, Role: member.Role}, nil
+}
+
+func (s *workspaceService) UpdateWorkspace(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID, req models.UpdateWorkspaceRequest) (models.WorkspaceResponse, error) {
+	if err := validators.ValidateWorkspace(req.Name); err != nil {
+		return models.WorkspaceResponse{}, err
+	}
+	workspace, member, err := s.authorized(ctx, workspaceID, userID, entities.WorkspaceRoleOwner)
+	if err != nil {
+		return models.WorkspaceResponse{}, err
+	}
+
+	workspace.Name = strings.TrimSpace(req.Name)
+	saved, err := s.workspaceRepo.UpdateWorkspace(ctx, workspace)
+	if err != nil {
+		s.logger.Error("service: update workspace failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		return models.WorkspaceResponse{}, err
+	}
+
+	s.logger.Info("service: workspace updated", slog.String("workspace_id", workspaceID.String()))
+	return models.WorkspaceResponse{Workspace: *saved, Role: member.Role}, nil
+}
+
+func (s *workspaceService) DeleteWorkspace(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) error {
+	workspace, _, err := s.authorized(ctx, workspaceID, userID, entities.WorkspaceRoleOwner)
+	if err != nil {
+		return err
+	}
+	if workspace.Personal {
+		return domain.ErrPersonalWorkspace
+	}
+
+	if err := s.workspaceRepo.DeleteWorkspace(ctx, workspaceID); err != nil {
+		if !errors.Is(err, domain.ErrWorkspaceNotFound) {
+			s.logger.Error("service: delete workspace failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		}
+		return err
+	}
+
+	s.logger.Info("service: workspace deleted", slog.String("workspace_id", workspaceID.String()), slog.String("user_id", userID.String()))
+	return nil
+}
+
+func (s *workspaceService) AddMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID, req models.AddWorkspaceMemberRequest) (models.WorkspaceMemberResponse, error) {
+	workspace, actor, err := s.authorized(ctx, workspaceID, userID, entities.WorkspaceRoleAdmin)
+	if err != nil {
+		return models.WorkspaceMemberResponse{}, err
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if !errors.Is(err, domain.ErrUserNotFound) {
+			s.logger.Error("service: workspace member lookup failed", slog.Any("error", err))
+		}
+		return models.WorkspaceMemberResponse{}, err
+	}
+
+	current, err := s.workspaceRepo.GetWorkspaceMember(ctx, workspaceID, user.ID)
+	if err != nil && !errors.Is(err, domain.ErrNotWorkspaceMember) {
+		s.logger.Error("service: get workspace member failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		return models.WorkspaceMemberResponse{}, err
+	}
+	changesOwner := req.Role == entities.WorkspaceRoleOwner || (current != nil && current.Role == entities.WorkspaceRoleOwner)
+	if changesOwner && actor.Role != entities.WorkspaceRoleOwner {
+		s.logger.Warn("service: only owner manages owners", slog.String("workspace_id", workspaceID.String()), slog.String("user_id", userID.String()))
+		return models.WorkspaceMemberResponse{}, domain.ErrForbidden
+	}
+	if current != nil && current.Role == entities.WorkspaceRoleOwner && req.Role != entities.WorkspaceRoleOwner {
+		if err := s.checkOwnerRemovable(ctx, workspace, user.ID); err != nil {
+			return models.WorkspaceMemberResponse{}, err
+		}
+	}
+
+	member, err := s.workspaceRepo.SaveWorkspaceMember(ctx, entities.WorkspaceMember{WorkspaceID: workspaceID, UserID: user.ID, Role: req.Role})
+	if err != nil {
+		s.logger.Error("service: save workspace member failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		return models.WorkspaceMemberResponse{}, err
+	}
+
+	s.logger.Info("service: workspace member saved", slog.String("workspace_id", workspaceID.String()), slog.String("user_id", user.ID.String()), slog.String("role", member.Role))
+	return models.WorkspaceMemberResponse{WorkspaceMember: member, Email: user.Email}, nil
+}
+
+func (s *workspaceService) GetMembers(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) ([]models.WorkspaceMemberResponse, error) {
+	if _, _, err := s.authorized(ctx, workspaceID, userID, entities.WorkspaceRoleMember); err != nil {
+		return nil, err
+	}
+
+	members, err := s.workspaceRepo.GetWorkspaceMembers(ctx, workspaceID)
+	if err != nil {
+		s.logger.Error("service: list workspace members failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	resp := make([]models.WorkspaceMemberResponse, 0, len(members))
+	for _, member := range members {
+		item := models.WorkspaceMemberResponse{WorkspaceMember: member}
+		if user, err := s.userRepo.GetUserById(ctx, member.UserID); err == nil {
+			item.Email = user.Email
+		}
+		resp = append(resp, item)
+	}
+	return resp, nil
+}
+
+func (s *workspaceService) RemoveMember(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID, memberID uuid.UUID) error {
+	need := entities.WorkspaceRoleAdmin
+	if memberID == userID {
+		need = entities.WorkspaceRoleMember
+	}
+	workspace, actor, err := s.authorized(ctx, workspaceID, userID, need)
+	if err != nil {
+		return err
+	}
+
+	target, err := s.workspaceRepo.GetWorkspaceMember(ctx, workspaceID, memberID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotWorkspaceMember) {
+			s.logger.Error("service: get workspace member failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		}
+		return err
+	}
+	if target.Role == entities.WorkspaceRoleOwner {
+		if actor.Role != entities.WorkspaceRoleOwner {
+			return domain.ErrForbidden
+		}
+		if err := s.checkOwnerRemovable(ctx, workspace, memberID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.workspaceRepo.DeleteWorkspaceMember(ctx, workspaceID, memberID); err != nil {
+		if !errors.Is(err, domain.ErrNotWorkspaceMember) {
+			s.logger.Error("service: delete workspace member failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		}
+		return err
+	}
+
+	s.logger.Info("service: workspace member removed", slog.String("workspace_id", workspaceID.String()), slog.String("user_id", memberID.String()))
+	return nil
+}
+
+func (s *workspaceService) Membership(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID) (*entities.WorkspaceMember, error) {
+	member, err := s.workspaceRepo.GetWorkspaceMember(ctx, workspaceID, userID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotWorkspaceMember) {
+			s.logger.Error("service: get workspace member failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+// authorized возвращает пространство и членство пользователя, если его роль не ниже need.
+// Чужое пространство для пользователя не существует.
+func (s *workspaceService) authorized(ctx context.Context, workspaceID uuid.UUID, userID uuid.UUID, need string) (*entities.Workspace, *entities.WorkspaceMember, error) {
+	member, err := s.Membership(ctx, workspaceID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotWorkspaceMember) {
+			return nil, nil, domain.ErrWorkspaceNotFound
+		}
+		return nil, nil, err
+	}
+	if entities.WorkspaceRoleRank(member.Role) < entities.WorkspaceRoleRank(need) {
+		s.logger.Warn("service: workspace access forbidden", slog.String("workspace_id", workspaceID.String()), slog.String("user_id", userID.String()), slog.String("need", need))
+		return nil, nil, domain.ErrForbidden
+	}
+
+	workspace, err := s.workspaceRepo.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrWorkspaceNotFound) {
+			s.logger.Error("service: get workspace failed", slog.String("workspace_id", workspaceID.String()), slog.Any("error", err))
+		}
+		return nil, nil, err
+	}
+	return workspace, member, nil
+}
+
+// checkOwnerRemovable проверяет, что ownerID можно лишить роли владельца: хозяин личного
+// пространства остаётся в нём всегда, а у командного должен остаться другой владелец.
+func (s *workspaceService) checkOwnerRemovable(ctx context.Context, workspace *entities.Workspace, ownerID uuid.UUID) error {
+	if workspace.Personal && workspace.ID == ownerID {
+		return domain.ErrPersonalWorkspace
+	}
+
+	members, err := s.workspaceRepo.GetWorkspaceMembers(ctx, workspace.ID)
+	if err != nil {
+		s.logger.Error("service: list workspace members failed", slog.String("workspace_id", workspace.ID.String()), slog.Any("error", err))
+		return err
+	}
+	owners := 0
+	for _, member := range members {
+		if member.Role == entities.WorkspaceRoleOwner {
+			owners++
+		}
+	}
+	if owners <= 1 {
+		return domain.ErrLastWorkspaceOwner
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
+	"github.com/polzovatel/todo-learning/internal/models"
+)
+
+func (s *todoService) BatchTodos(ctx context.Context, userID uuid.UUID, ops []models.BatchOperation) ([]models.BatchResult, error) {
+	results := make([]models.BatchResult, 0, len(ops))
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for i, op := range ops {
+			result, err := s.batchOperation(ctx, userID, op)
+			if err != nil {
+				return &domain.BatchError{Index: i, Err: err}
+			}
+			result.Index = i
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		// Операции до сбоя успели положить задачи в кэш, а их изменения откатились.
+		for _, result := range results {
+			if result.Todo != nil {
+				s.invalidateTodo(ctx, result.Todo)
+			}
+		}
+		var batchErr *domain.BatchError
+		if errors.As(err, &batchErr) {
+			s.logger.Warn("service: batch rolled back", slog.String("user_id", userID.String()), slog.Int("index", batchErr.Index), slog.Any("error", batchErr.Err))
+		} else {
+			s.logger.Error("service: batch transaction failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+
+	s.logger.Info("service: batch applied", slog.String("user_id", userID.String()), slog.Int("operations", len(ops)))
+	return results, nil
+}
+
+func (s *todoService) batchOperation(ctx context.Context, userID uuid.UUID, op models.BatchOperation) (models.BatchResult, error) {
+	result := models.BatchResult{Op: op.Op}
+	if op.Op == models.BatchCreate {
+		req, err := batchCreateRequest(op.Todo)
+		if err != nil {
+			return result, err
+		}
+		todo, err := s.CreateTodo(ctx, userID, req)
+		if err != nil {
+			return result, err
+		}
+		result.ID, result.Todo = todo.ID, &todo
+		return result, nil
+	}
+
+	if op.ID == nil {
+		return result, fmt.Errorf("%w: %s needs id", domain.ErrInvalidBatch, op.Op)
+	}
+	result.ID = *op.ID
+	var todo *entities.Todo
+	var err error
+	switch op.Op {
+	case models.BatchUpdate:
+		if len(op.Todo) == 0 {
+			return result, fmt.Errorf("%w: update needs todo", domain.ErrInvalidBatch)
+		}
+		todo, err = s.PatchTodo(ctx, *op.ID, userID, models.TodoPatch{
+			ContentType: models.MergePatchType,
+			Body:        op.Todo,
+			Subtasks:    op.Subtasks,
+			Version:     op.Version,
+		})
+	case models.BatchComplete:
+		completed := true
+		todo, err = s.UpdateTodo(ctx, *op.ID, userID, models.UpdateTodoRequest{Completed: &completed, Subtasks: op.Subtasks, Version: op.Version})
+	case models.BatchDelete:
+		err = s.DeleteTodo(ctx, *op.ID, userID, op.Subtasks, op.Version)
+	default:
+		err = fmt.Errorf("%w: unknown op %q", domain.ErrInvalidBatch, op.Op)
+	}
+	result.Todo = todo
+	return result, err
+}
+
+// batchCreateRequest разбирает и проверяет новую задачу так же, как POST /todos.
+func batchCreateRequest(raw json.RawMessage) (models.CreateTodoRequest, error) {
+	var req models.CreateTodoRequest
+	if len(raw) == 0 {
+		return req, fmt.Errorf("%w: create needs todo", domain.ErrInvalidBatch)
+	}
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return req, fmt.Errorf("%w: %v", domain.ErrInvalidBatch, err)
+	}
+	if err := validators.ValidateTodo(req.Title); err != nil {
+		return req, err
+	}
+	if req.Priority != "" && !validPriority(req.Priority) {
+		return req, fmt.Errorf("%w: invalid priority %q", domain.ErrInvalidBatch, req.Priority)
+	}
+	return req, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+)
+
+// todoCursorPayload — содержимое непрозрачного курсора. Сортировка и направление
+// зашиты в курсор, чтобы его нельзя было применить к другому порядку.
+type todoCursorPayload struct {
+	Sort  string    `json:"s"`
+	Order string    `json:"o"`
+	Time  time.Time `json:"t,omitempty"`
+	Value string    `json:"v,omitempty"` // заголовок или позиция
+	ID    uuid.UUID `json:"id"`
+}
+
+func encodeTodoCursor(todo entities.Todo, sortBy, order string) string {
+	payload := todoCursorPayload{Sort: sortBy, Order: order, ID: todo.ID}
+	switch sortBy {
+	case models.TodoSortUpdatedAt:
+		payload.Time = todo.UpdatedAt
+	case models.TodoSortTitle:
+		payload.Value = todo.Title
+	case models.TodoSortPosition:
+		payload.Value = todo.Position
+	default:
+		payload.Time = todo.CreatedAt
+	}
+
+	raw, _ := json.Marshal(payload)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeTodoCursor(cursor, sortBy, order string) (*models.TodoCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	var payload todoCursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	if payload.Sort != sortBy || payload.Order != order || payload.ID == uuid.Nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	after := &models.TodoCursor{Time: payload.Time, ID: payload.ID}
+	switch sortBy {
+	case models.TodoSortTitle:
+		after.Title = payload.Value
+	case models.TodoSortPosition:
+		after.Position = payload.Value
+	}
+	return after, nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+)
+
+// todoStateFields — поля TodoState в порядке, в котором они перечисляются в изменениях.
+var todoStateFields = []string{"title", "description", "completed", "priority", "due_at", "remind_at", "recurrence", "project_id", "parent_id", "position"}
+
+func (s *todoService) GetTodoHistory(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) ([]entities.TodoRevision, error) {
+	if _, err := s.authorizedTodo(ctx, todoID, userID, entities.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.revisionRepo.GetRevisions(ctx, todoID)
+	if err != nil {
+		s.logger.Error("service: list revisions failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (s *todoService) RevertTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, number int) (*entities.Todo, error) {
+	todo, err := s.authorizedTodo(ctx, todoID, userID, entities.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := s.revisionRepo.GetRevision(ctx, todoID, number)
+	if err != nil {
+		if !errors.Is(err, domain.ErrRevisionNotFound) {
+			s.logger.Error("service: get revision failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+
+	s.logger.Info("service: reverting todo", slog.String("todo_id", todoID.String()), slog.Int("revision", number))
+	return s.updateTodo(ctx, todoID, userID, revertRequest(todo, revision.State), &number)
+}
+
+// revertRequest собирает правку, которая возвращает задаче состояние state. В правку
+// попадают только отличающиеся поля, чтобы, например, неизменный архивный проект не мешал откату.
+// Позиция не откатывается: старый ключ мог совпасть с ключами соседей, порядок меняет только MoveTodo.
+func revertRequest(todo *entities.Todo, state entities.TodoState) models.UpdateTodoRequest {
+	current := todoState(todo)
+	var req models.UpdateTodoRequest
+	if state.Title != current.Title {
+		req.Title = &state.Title
+	}
+	if state.Description != current.Description {
+		req.Description = &state.Description
+	}
+	if state.Completed != current.Completed {
+		req.Completed = &state.Completed
+	}
+	if state.Priority != current.Priority {
+		req.Priority = &state.Priority
+	}
+	if !sameTime(state.DueAt, current.DueAt) {
+		req.DueAt = models.Optional[time.Time]{Set: true, Value: state.DueAt}
+	}
+	if !sameTime(state.RemindAt, current.RemindAt) {
+		req.RemindAt = models.Optional[time.Time]{Set: true, Value: state.RemindAt}
+	}
+	if state.Recurrence != current.Recurrence {
+		req.Recurrence = models.Optional[string]{Set: true, Value: &state.Recurrence}
+	}
+	if !sameID(state.ProjectID, current.ProjectID) {
+		req.ProjectID = models.Optional[uuid.UUID]{Set: true, Value: state.ProjectID}
+	}
+	if !sameID(state.ParentID, current.ParentID) {
+		req.ParentID = models.Optional[uuid.UUID]{Set: true, Value: state.ParentID}
+	}
+	return req
+}
+
+// recordRevision записывает изменение задачи в историю. before — состояние до изменения
+// (нулевое у новой задачи), nil — изменение без правки полей, как удаление. Правка без
+// изменений не записывается. Вызывается в одной транзакции с самим изменением: при
+// ошибке изменение откатывается, чтобы история не расходилась с задачей.
+func (s *todoService) recordRevision(ctx context.Context, action string, before *entities.TodoState, todo *entities.Todo, actorID uuid.UUID, revertedTo *int) error {
+	state := todoState(todo)
+	var changes []entities.FieldChange
+	if before != nil {
+		changes = diffStates(*before, state)
+		if len(changes) == 0 {
+			return nil
+		}
+	}
+
+	if _, err := s.revisionRepo.CreateRevision(ctx, entities.TodoRevision{
+		TodoID:     todo.ID,
+		Action:     action,
+		ActorID:    actorID,
+		Changes:    changes,
+		State:      state,
+		RevertedTo: revertedTo,
+	}); err != nil {
+		s.logger.Error("service: record revision failed", slog.String("todo_id", todo.ID.String()), slog.String("action", action), slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+// todoState снимает отслеживаемые поля задачи; время приводится к UTC, чтобы один и тот же
+// момент в разных зонах не считался изменением.
+func todoState(todo *entities.Todo) entities.TodoState {
+	return entities.TodoState{
+		Title:       todo.Title,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		Priority:    todo.Priority,
+		DueAt:       utcTime(todo.DueAt),
+		RemindAt:    utcTime(todo.RemindAt),
+		Recurrence:  todo.Recurrence,
+		ProjectID:   todo.ProjectID,
+		ParentID:    todo.ParentID,
+		Position:    todo.Position,
+	}
+}
+
+func diffStates(before, after entities.TodoState) []entities.FieldChange {
+	old, updated := stateJSON(before), stateJSON(after)
+	var changes []entities.FieldChange
+	for _, field := range todoStateFields {
+		if string(old[field]) != string(updated[field]) {
+			changes = append(changes, entities.FieldChange{Field: field, Old: old[field], New: updated[field]})
+		}
+	}
+	return changes
+}
+
+// stateJSON раскладывает состояние на JSON-значения полей; строки, время и uuid
+// сериализуются без ошибок.
+func stateJSON(state entities.TodoState) map[string]json.RawMessage {
+	raw, _ := json.Marshal(state)
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(raw, &fields)
+	return fields
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func sameID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
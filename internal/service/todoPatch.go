@@ -0,0 +1,89 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/domain/validators"
+	"github.com/polzovatel/todo-learning/internal/jsonpatch"
+	"github.com/polzovatel/todo-learning/internal/models"
+)
+
+func (s *todoService) PatchTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, patch models.TodoPatch) (*entities.Todo, error) {
+	mediaType, _, err := mime.ParseMediaType(patch.ContentType)
+	if err != nil || (mediaType != models.MergePatchType && mediaType != models.JSONPatchType) {
+		s.logger.Warn("service: unsupported patch type", slog.String("content_type", patch.ContentType))
+		return nil, domain.ErrUnsupportedPatch
+	}
+	todo, err := s.authorizedTodo(ctx, todoID, userID, entities.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Version != nil && *patch.Version != todo.Version {
+		s.logger.Warn("service: todo version mismatch on patch", slog.String("todo_id", todoID.String()), slog.Int("version", todo.Version))
+		return nil, domain.ErrVersionMismatch
+	}
+
+	// Документ патча — поля тела PUT; порядок меняет только перестановка.
+	state := todoState(todo)
+	state.Position = ""
+	doc, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	if mediaType == models.MergePatchType {
+		doc, err = jsonpatch.MergePatch(doc, patch.Body)
+	} else {
+		doc, err = jsonpatch.Apply(doc, patch.Body)
+	}
+	if err != nil {
+		s.logger.Warn("service: patch not applied", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	replacement, err := patchedTodo(doc)
+	if err != nil {
+		s.logger.Warn("service: patched todo is invalid", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+
+	// Патч построен по прочитанной версии: если задачу успели изменить, он не применяется.
+	req := replacement.UpdateRequest()
+	req.Subtasks = patch.Subtasks
+	req.Version = &todo.Version
+	return s.updateTodo(ctx, todoID, userID, req, nil)
+}
+
+// patchedTodo разбирает документ задачи после патча и проверяет его, как тело PUT.
+func patchedTodo(doc []byte) (models.ReplaceTodoRequest, error) {
+	var req models.ReplaceTodoRequest
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return req, fmt.Errorf("%w: %v", domain.ErrInvalidPatch, err)
+	}
+	if err := validators.ValidateTodo(req.Title); err != nil {
+		return req, fmt.Errorf("%w: %v", domain.ErrInvalidPatch, err)
+	}
+	if req.Completed == nil {
+		return req, fmt.Errorf("%w: completed is required", domain.ErrInvalidPatch)
+	}
+	if !validPriority(req.Priority) {
+		return req, fmt.Errorf("%w: invalid priority %q", domain.ErrInvalidPatch, req.Priority)
+	}
+	return req, nil
+}
+
+func validPriority(priority string) bool {
+	switch priority {
+	case entities.PriorityLow, entities.PriorityNormal, entities.PriorityHigh, entities.PriorityUrgent:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/ranking"
+	"github.com/polzovatel/todo-learning/internal/recurrence"
+)
+
+func (s *todoService) SkipOccurrence(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (*entities.Todo, error) {
+	todo, err := s.authorizedTodo(ctx, todoID, userID, entities.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if todo.Recurrence == "" || todo.DueAt == nil {
+		return nil, domain.ErrNotRecurring
+	}
+
+	next, rest, ok, err := s.nextOccurrence(ctx, todo.UserID, *todo.DueAt, todo.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domain.ErrRecurrenceEnded
+	}
+
+	before := todoState(todo)
+	updated := *todo
+	updated.DueAt, updated.RemindAt = &next, shiftReminder(todo, next)
+	updated.Recurrence = rest
+	updated.UpdatedAt = time.Now()
+	if err := s.updateWithRevision(ctx, before, &updated, userID); err != nil {
+		s.logger.Error("service: skip occurrence failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	s.invalidateTodo(ctx, todo)
+
+	s.logger.Info("service: occurrence skipped", slog.String("todo_id", todoID.String()), slog.Time("due_at", next))
+	return &updated, nil
+}
+
+func (s *todoService) EndRecurrence(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (*entities.Todo, error) {
+	todo, err := s.authorizedTodo(ctx, todoID, userID, entities.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if todo.Recurrence == "" {
+		return nil, domain.ErrNotRecurring
+	}
+
+	before := todoState(todo)
+	updated := *todo
+	updated.Recurrence = ""
+	updated.UpdatedAt = time.Now()
+	if err := s.updateWithRevision(ctx, before, &updated, userID); err != nil {
+		s.logger.Error("service: end recurrence failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	s.invalidateTodo(ctx, todo)
+
+	s.logger.Info("service: recurrence ended", slog.String("todo_id", todoID.String()))
+	return &updated, nil
+}
+
+// updateWithRevision сохраняет правку задачи и её ревизию в одной транзакции.
+func (s *todoService) updateWithRevision(ctx context.Context, before entities.TodoState, todo *entities.Todo, userID uuid.UUID) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.todoRepo.UpdateTodo(ctx, todo); err != nil {
+			return err
+		}
+		return s.recordRevision(ctx, entities.RevisionUpdate, &before, todo, userID, nil)
+	})
+}
+
+// normalizeRecurrence проверяет правило и приводит его к каноническому виду;
+// пустое правило означает отсутствие повторения.
+func normalizeRecurrence(rule string, dueAt *time.Time) (string, error) {
+	if rule == "" {
+		return "", nil
+	}
+	parsed, err := recurrence.Parse(rule)
+	if err != nil {
+		return "", err
+	}
+	if dueAt == nil {
+		return "", domain.ErrRecurrenceNeedsDue
+	}
+	return parsed.String(), nil
+}
+
+// nextOccurrence возвращает срок следующего вхождения после dueAt и правило для
+// оставшейся серии. Вхождения считаются в часовом поясе владельца задачи, так что
+// настенное время срока сохраняется при переходе на летнее время и обратно.
+func (s *todoService) nextOccurrence(ctx context.Context, userID uuid.UUID, dueAt time.Time, rule string) (time.Time, string, bool, error) {
+	parsed, err := recurrence.Parse(rule)
+	if err != nil {
+		s.logger.Error("service: stored recurrence is invalid", slog.String("rule", rule), slog.Any("error", err))
+		return time.Time{}, "", false, err
+	}
+	user, err := s.userRepo.GetUserById(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrUserNotFound) {
+			s.logger.Error("service: recurrence user lookup failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		}
+		return time.Time{}, "", false, err
+	}
+
+	next, rest, ok := parsed.Next(dueAt.In(userLocation(user)))
+	if !ok {
+		return time.Time{}, "", false, nil
+	}
+	return next, rest.String(), true, nil
+}
+
+// spawnOccurrence создаёт следующее вхождение выполненной задачи в конце ручного порядка.
+func (s *todoService) spawnOccurrence(ctx context.Context, todo *entities.Todo, next time.Time, rule string) (entities.Todo, error) {
+	last, err := s.todoRepo.LastTodoPosition(ctx, todo.WorkspaceID, todo.UserID)
+	if err != nil {
+		s.logger.Error("service: last todo position failed", slog.String("user_id", todo.UserID.String()), slog.Any("error", err))
+		return entities.Todo{}, err
+	}
+	position, err := ranking.Between(last, "")
+	if err != nil {
+		s.logger.Error("service: next todo position failed", slog.String("user_id", todo.UserID.String()), slog.Any("error", err))
+		return entities.Todo{}, err
+	}
+
+	spawned, err := s.todoRepo.CreateTodo(ctx, entities.Todo{
+		WorkspaceID: todo.WorkspaceID,
+		UserID:      todo.UserID,
+		Title:       todo.Title,
+		Description: todo.Description,
+		DueAt:       &next,
+		RemindAt:    shiftReminder(todo, next),
+		Priority:    todo.Priority,
+		ProjectID:   todo.ProjectID,
+		ParentID:    todo.ParentID,
+		Recurrence:  rule,
+		Position:    position,
+	})
+	if err != nil {
+		s.logger.Error("service: spawn occurrence failed", slog.String("todo_id", todo.ID.String()), slog.Any("error", err))
+		return entities.Todo{}, err
+	}
+
+	s.logger.Info("service: next occurrence created", slog.String("todo_id", spawned.ID.String()), slog.String("previous_id", todo.ID.String()))
+	return spawned, nil
+}
+
+// shiftReminder сохраняет отступ напоминания от срока для нового срока next.
+func shiftReminder(todo *entities.Todo, next time.Time) *time.Time {
+	if todo.RemindAt == nil || todo.DueAt == nil {
+		return nil
+	}
+	remindAt := next.Add(todo.RemindAt.Sub(*todo.DueAt))
+	return &remindAt
+}
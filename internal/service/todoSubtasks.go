@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/models"
+)
+
+func (s *todoService) GetTodoChildren(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (models.TodoChildrenResponse, error) {
+	todo, err := s.authorizedTodo(ctx, todoID, userID, entities.RoleViewer)
+	if err != nil {
+		return models.TodoChildrenResponse{}, err
+	}
+
+	children, err := s.todoRepo.GetTodoChildren(ctx, todo.WorkspaceID, todoID)
+	if err != nil {
+		s.logger.Error("service: list subtasks failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		return models.TodoChildrenResponse{}, err
+	}
+	return models.TodoChildrenResponse{Todos: children, Progress: progressOf(children)}, nil
+}
+
+// GetTodoTree возвращает задачу со всем деревом подзадач; прогресс у каждого узла
+// считается по его прямым подзадачам.
+func (s *todoService) GetTodoTree(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (models.TodoTree, error) {
+	todo, err := s.authorizedTodo(ctx, todoID, userID, entities.RoleViewer)
+	if err != nil {
+		return models.TodoTree{}, err
+	}
+	return s.buildTree(ctx, *todo)
+}
+
+func (s *todoService) buildTree(ctx context.Context, todo entities.Todo) (models.TodoTree, error) {
+	children, err := s.todoRepo.GetTodoChildren(ctx, todo.WorkspaceID, todo.ID)
+	if err != nil {
+		s.logger.Error("service: list subtasks failed", slog.String("todo_id", todo.ID.String()), slog.Any("error", err))
+		return models.TodoTree{}, err
+	}
+
+	node := models.TodoTree{Todo: todo, Progress: progressOf(children), Children: make([]models.TodoTree, 0, len(children))}
+	for _, child := range children {
+		subtree, err := s.buildTree(ctx, child)
+		if err != nil {
+			return models.TodoTree{}, err
+		}
+		node.Children = append(node.Children, subtree)
+	}
+	return node, nil
+}
+
+func progressOf(children []entities.Todo) models.TodoProgress {
+	progress := models.TodoProgress{Total: len(children)}
+	for _, child := range children {
+		if child.Completed {
+			progress.Completed++
+		}
+	}
+	return progress
+}
+
+// authorizedTodo возвращает задачу текущего пространства или общую задачу другого,
+// если роль пользователя в ней не ниже need.
+func (s *todoService) authorizedTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID, need string) (*entities.Todo, error) {
+	todo, err := s.access.findTodo(ctx, todoID, userID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrTodoNotFound) {
+			s.logger.Error("service: get todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+	if err := s.access.authorizeTodo(ctx, todo, userID, need); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// checkParent проверяет, что todo (nil — новая задача) можно сделать подзадачей parentID,
+// и возвращает родителя: пользователь может редактировать родителя, у существующей
+// todo тот же владелец, родитель не лежит внутри самой todo, а поддерево todo после
+// переноса не превысит максимальную глубину.
+func (s *todoService) checkParent(ctx context.Context, todo *entities.Todo, parentID uuid.UUID, userID uuid.UUID) (*entities.Todo, error) {
+	exclude := uuid.Nil
+	if todo != nil {
+		exclude = todo.ID
+	}
+	if parentID == exclude {
+		return nil, domain.ErrInvalidParent
+	}
+
+	parent, err := s.authorizedTodo(ctx, parentID, userID, entities.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if todo != nil && parent.UserID != todo.UserID {
+		s.logger.Warn("service: parent of another owner", slog.String("todo_id", todo.ID.String()), slog.String("parent_id", parentID.String()))
+		return nil, domain.ErrForbidden
+	}
+	depth, err := s.todoDepth(ctx, parent, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	height := 0
+	if todo != nil {
+		levels, err := s.subtaskLevels(ctx, todo)
+		if err != nil {
+			return nil, err
+		}
+		height = len(levels)
+	}
+	if depth+1+height > s.maxDepth {
+		s.logger.Warn("service: subtask too deep", slog.String("parent_id", parentID.String()), slog.Int("depth", depth+1+height))
+		return nil, domain.ErrMaxDepthExceeded
+	}
+	return parent, nil
+}
+
+// todoDepth возвращает число предков задачи. Если среди предков есть exclude,
+// перенос создал бы цикл — возвращается ErrInvalidParent.
+func (s *todoService) todoDepth(ctx context.Context, todo *entities.Todo, exclude uuid.UUID) (int, error) {
+	depth := 0
+	for current := todo; current.ParentID != nil; depth++ {
+		if *current.ParentID == exclude {
+			return 0, domain.ErrInvalidParent
+		}
+		parent, err := s.todoRepo.GetTodoByID(ctx, current.WorkspaceID, *current.ParentID)
+		if err != nil {
+			s.logger.Error("service: get parent todo failed", slog.String("todo_id", current.ParentID.String()), slog.Any("error", err))
+			return 0, err
+		}
+		current = parent
+	}
+	return depth, nil
+}
+
+// subtaskLevels возвращает подзадачи по уровням: [0] — прямые, [1] — их подзадачи и т.д.
+// Число уровней — высота поддерева.
+func (s *todoService) subtaskLevels(ctx context.Context, todo *entities.Todo) ([][]entities.Todo, error) {
+	var levels [][]entities.Todo
+	for parents := []uuid.UUID{todo.ID}; len(parents) > 0; {
+		var level []entities.Todo
+		var next []uuid.UUID
+		for _, id := range parents {
+			children, err := s.todoRepo.GetTodoChildren(ctx, todo.WorkspaceID, id)
+			if err != nil {
+				s.logger.Error("service: list subtasks failed", slog.String("todo_id", id.String()), slog.Any("error", err))
+				return nil, err
+			}
+			for _, child := range children {
+				level = append(level, child)
+				next = append(next, child.ID)
+			}
+		}
+		if len(level) > 0 {
+			levels = append(levels, level)
+		}
+		parents = next
+	}
+	return levels, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/domain/entities"
+	"github.com/polzovatel/todo-learning/internal/tenant"
+)
+
+func (s *todoService) GetTrash(ctx context.Context, userID uuid.UUID) ([]entities.Todo, error) {
+	todos, err := s.todoRepo.GetTrashedTodos(ctx, tenant.WorkspaceID(ctx, userID), userID)
+	if err != nil {
+		s.logger.Error("service: list trash failed", slog.String("user_id", userID.String()), slog.Any("error", err))
+		return nil, err
+	}
+	return todos, nil
+}
+
+func (s *todoService) RestoreTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (*entities.Todo, error) {
+	todo, err := s.trashedTodo(ctx, todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if todo.ParentID != nil {
+		if _, err := s.todoRepo.GetTodoByID(ctx, todo.WorkspaceID, *todo.ParentID); err != nil {
+			if errors.Is(err, domain.ErrTodoNotFound) {
+				s.logger.Warn("service: parent of restored todo is trashed", slog.String("todo_id", todoID.String()))
+				return nil, domain.ErrParentTrashed
+			}
+			s.logger.Error("service: get parent of restored todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+			return nil, err
+		}
+	}
+
+	// Вместе с задачей возвращаются все её подзадачи, которые сейчас не в корзине; их
+	// ревизии пишутся в той же транзакции, что и само восстановление.
+	var restored *entities.Todo
+	var levels [][]entities.Todo
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		restored, err = s.todoRepo.RestoreTodo(ctx, todo.WorkspaceID, todoID)
+		if err != nil {
+			if !errors.Is(err, domain.ErrTodoNotFound) {
+				s.logger.Error("service: restore todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+			}
+			return err
+		}
+		if err := s.recordRevision(ctx, entities.RevisionRestore, nil, restored, userID, nil); err != nil {
+			return err
+		}
+		levels, err = s.subtaskLevels(ctx, restored)
+		if err != nil {
+			return err
+		}
+		for _, level := range levels {
+			for _, subtask := range level {
+				if err := s.recordRevision(ctx, entities.RevisionRestore, nil, &subtask, userID, nil); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, level := range levels {
+		for _, subtask := range level {
+			s.invalidateTodo(ctx, &subtask)
+		}
+	}
+	s.invalidateTodo(ctx, restored)
+	s.logger.Info("service: todo restored", slog.String("todo_id", todoID.String()))
+	return restored, nil
+}
+
+func (s *todoService) PurgeTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) error {
+	todo, err := s.trashedTodo(ctx, todoID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.todoRepo.PurgeTodo(ctx, todo.WorkspaceID, todoID); err != nil {
+		if !errors.Is(err, domain.ErrTodoNotFound) {
+			s.logger.Error("service: purge todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		}
+		return err
+	}
+
+	s.logger.Info("service: todo purged", slog.String("todo_id", todoID.String()))
+	return nil
+}
+
+func (s *todoService) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	purged, err := s.todoRepo.PurgeTrash(ctx, before)
+	if err != nil {
+		s.logger.Error("service: purge trash failed", slog.Any("error", err))
+		return 0, err
+	}
+	if purged > 0 {
+		s.logger.Info("service: trash purged", slog.Int("count", purged))
+	}
+	return purged, nil
+}
+
+// trashedTodo находит задачу в корзине текущего пространства; распоряжаться ею может
+// только владелец. Что задача не в корзине, сообщается лишь тому, кто мог бы её удалить.
+func (s *todoService) trashedTodo(ctx context.Context, todoID uuid.UUID, userID uuid.UUID) (*entities.Todo, error) {
+	todo, err := s.todoRepo.GetTrashedTodo(ctx, tenant.WorkspaceID(ctx, userID), todoID)
+	if errors.Is(err, domain.ErrTodoNotTrashed) {
+		if _, err := s.authorizedTodo(ctx, todoID, userID, entities.RoleOwner); err != nil {
+			return nil, err
+		}
+		s.logger.Warn("service: todo is not in the trash", slog.String("todo_id", todoID.String()))
+		return nil, domain.ErrTodoNotTrashed
+	}
+	if err != nil {
+		if !errors.Is(err, domain.ErrTodoNotFound) {
+			s.logger.Error("service: get trashed todo failed", slog.String("todo_id", todoID.String()), slog.Any("error", err))
+		}
+		return nil, err
+	}
+	if err := s.access.authorizeTodo(ctx, todo, userID, entities.RoleOwner); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
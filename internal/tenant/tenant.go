@@ -0,0 +1,25 @@
+// Package tenant передаёт текущее рабочее пространство запроса от middleware до сервисов.
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type workspaceKey struct{}
+
+// WithWorkspace возвращает контекст с текущим рабочим пространством. Кладёт его туда
+// только middleware, проверив членство пользователя.
+func WithWorkspace(ctx context.Context, workspaceID uuid.UUID) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, workspaceID)
+}
+
+// WorkspaceID возвращает рабочее пространство из контекста или fallback, если его нет
+// (машинные клиенты, фоновые задачи, тесты сервисов).
+func WorkspaceID(ctx context.Context, fallback uuid.UUID) uuid.UUID {
+	if id, ok := ctx.Value(workspaceKey{}).(uuid.UUID); ok {
+		return id
+	}
+	return fallback
+}
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polzovatel/todo-learning/config"
+	"github.com/polzovatel/todo-learning/internal/blob"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachments(t *testing.T) {
+	blobDir := t.TempDir()
+	server, repo := setupTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.BlobDir = blobDir
+		cfg.AttachmentMaxSize = 1024
+	})
+	defer server.Close()
+
+	client := server.Client()
+
+	ownerToken := loginAs(t, server, "attachments-owner@example.com")
+	ownerID := userIDOf(t, server, ownerToken)
+	viewerToken := loginAs(t, server, "attachments-viewer@example.com")
+
+	send := func(token string, req *http.Request) *http.Response {
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-Workspace-ID", ownerID)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+	doAs := func(token, method, path string, body any) (int, map[string]interface{}) {
+		status, _, result := doJSONWithHeader(t, server, token, method, path, body, http.Header{"X-Workspace-ID": {ownerID}})
+		return status, result
+	}
+	uploadAs := func(token, path, fileName string, content []byte) (int, map[string]interface{}) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", fileName)
+		require.NoError(t, err)
+		part.Write(content)
+		require.NoError(t, form.Close())
+
+		req, _ := http.NewRequest("POST", server.URL+"/api/v1"+path, &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		resp := send(token, req)
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+
+	status, _ := doAs(ownerToken, "POST", "/workspaces/"+ownerID+"/members", map[string]any{"email": "attachments-viewer@example.com", "role": "member"})
+	require.Equal(t, http.StatusOK, status)
+	status, result := doAs(ownerToken, "POST", "/todos", map[string]any{"title": "bug report"})
+	require.Equal(t, http.StatusCreated, status)
+	todoID := result["todo"].(map[string]interface{})["id"].(string)
+	status, _ = doAs(ownerToken, "POST", "/todos/"+todoID+"/shares", map[string]any{"email": "attachments-viewer@example.com", "role": "viewer"})
+	require.Equal(t, http.StatusOK, status)
+
+	attachmentsPath := "/todos/" + todoID + "/attachments"
+	status, result = uploadAs(ownerToken, attachmentsPath, "steps.txt", []byte("1. open the app\n2. crash"))
+	require.Equal(t, http.StatusCreated, status)
+	attachment := result["attachment"].(map[string]interface{})
+	attachmentID := attachment["id"].(string)
+	assert.Equal(t, "steps.txt", attachment["file_name"])
+	assert.Equal(t, float64(24), attachment["size"])
+	assert.NotContains(t, attachment, "storage_key")
+
+	t.Run("viewer lists and downloads", func(t *testing.T) {
+		status, result := doAs(viewerToken, "GET", attachmentsPath, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, result["attachments"], 1)
+
+		req, _ := http.NewRequest("GET", server.URL+"/api/v1"+attachmentsPath+"/"+attachmentID, nil)
+		resp := send(viewerToken, req)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "1. open the app\n2. crash", string(body))
+		assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=steps.txt`, resp.Header.Get("Content-Disposition"))
+		assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+	})
+
+	t.Run("viewer cannot upload or delete", func(t *testing.T) {
+		status, _ := uploadAs(viewerToken, attachmentsPath, "mine.txt", []byte("hello"))
+		assert.Equal(t, http.StatusForbidden, status)
+		status, _ = doAs(viewerToken, "DELETE", attachmentsPath+"/"+attachmentID, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("limits are enforced", func(t *testing.T) {
+		status, _ := uploadAs(ownerToken, attachmentsPath, "big.txt", bytes.Repeat([]byte("a"), 2048))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+		status, _ = uploadAs(ownerToken, attachmentsPath, "huge.txt", bytes.Repeat([]byte("a"), 128<<10))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+		status, _ = uploadAs(ownerToken, attachmentsPath, "notes.txt", []byte("PK\x03\x04 zipped"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, status)
+	})
+
+	t.Run("delete removes the attachment", func(t *testing.T) {
+		status, result := uploadAs(ownerToken, attachmentsPath, "pixel.png", []byte("\x89PNG\r\n\x1a\n"))
+		require.Equal(t, http.StatusCreated, status)
+		pixelID := result["attachment"].(map[string]interface{})["id"].(string)
+
+		status, _ = doAs(ownerToken, "DELETE", attachmentsPath+"/"+pixelID, nil)
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = doAs(ownerToken, "GET", attachmentsPath+"/"+pixelID, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("purging the todo frees its blobs", func(t *testing.T) {
+		assert.Equal(t, 1, countFiles(t, blobDir))
+		status, _ := doAs(ownerToken, "DELETE", "/todos/"+todoID, nil)
+		require.Equal(t, http.StatusOK, status)
+		status, _ = doAs(ownerToken, "GET", attachmentsPath+"/"+attachmentID, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+
+		// Содержимое удаляет фоновая очистка; здесь запускаем её вручную.
+		blobs, err := blob.NewLocalStore(blobDir)
+		require.NoError(t, err)
+		limits := service.AttachmentLimits{MaxSize: 1024}
+		attachments := service.NewAttachmentService(repo, repo, repo, repo, blobs, limits, slog.Default())
+		purged, err := attachments.PurgeOrphans(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, purged, "todo in the trash keeps its attachments")
+
+		status, _ = doAs(ownerToken, "DELETE", "/trash/"+todoID, nil)
+		require.Equal(t, http.StatusOK, status)
+		purged, err = attachments.PurgeOrphans(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		assert.Zero(t, countFiles(t, blobDir))
+	})
+}
+
+func countFiles(t *testing.T, dir string) int {
+	count := 0
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			count++
+		}
+		return err
+	})
+	require.NoError(t, err)
+	return count
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoBatch(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	token := loginAs(t, server, "batch@example.com")
+
+	do := func(method, path string, body any) (int, map[string]interface{}) {
+		return doJSON(t, server, token, method, path, body)
+	}
+	createTodo := func(title string) string {
+		status, result := do("POST", "/todos", map[string]any{"title": title})
+		require.Equal(t, http.StatusCreated, status)
+		return result["todo"].(map[string]interface{})["id"].(string)
+	}
+	titles := func() []string {
+		status, result := do("GET", "/todos?sort=title", nil)
+		require.Equal(t, http.StatusOK, status)
+		var out []string
+		for _, todo := range result["todos"].([]interface{}) {
+			out = append(out, todo.(map[string]interface{})["title"].(string))
+		}
+		return out
+	}
+
+	groceries := createTodo("groceries")
+	laundry := createTodo("laundry")
+	taxes := createTodo("taxes")
+
+	t.Run("operations apply in order", func(t *testing.T) {
+		status, result := do("POST", "/todos:batch", map[string]any{"operations": []map[string]any{
+			{"op": "create", "todo": map[string]any{"title": "dentist", "priority": "high"}},
+			{"op": "update", "id": groceries, "todo": map[string]any{"title": "groceries and milk"}},
+			{"op": "complete", "id": laundry, "version": 1},
+			{"op": "delete", "id": taxes},
+		}})
+		require.Equal(t, http.StatusOK, status)
+		results := result["results"].([]interface{})
+		require.Len(t, results, 4)
+		created := results[0].(map[string]interface{})
+		assert.Equal(t, "create", created["op"])
+		assert.Equal(t, "high", created["todo"].(map[string]interface{})["priority"])
+		assert.Equal(t, true, results[2].(map[string]interface{})["todo"].(map[string]interface{})["completed"])
+		deleted := results[3].(map[string]interface{})
+		assert.Equal(t, taxes, deleted["id"])
+		assert.Nil(t, deleted["todo"])
+
+		assert.Equal(t, []string{"dentist", "groceries and milk", "laundry"}, titles())
+	})
+
+	t.Run("a failed operation rolls back the whole batch", func(t *testing.T) {
+		before := titles()
+		status, result := do("POST", "/todos:batch", map[string]any{"operations": []map[string]any{
+			{"op": "create", "todo": map[string]any{"title": "never saved"}},
+			{"op": "update", "id": groceries, "todo": map[string]any{"title": "rolled back"}},
+			{"op": "delete", "id": laundry},
+			{"op": "complete", "id": groceries, "version": 1},
+		}})
+		assert.Equal(t, http.StatusPreconditionFailed, status)
+		assert.Equal(t, float64(3), result["index"])
+		assert.Equal(t, before, titles())
+
+		status, result = do("POST", "/todos:batch", map[string]any{"operations": []map[string]any{
+			{"op": "delete", "id": laundry},
+			{"op": "update", "id": taxes, "todo": map[string]any{"title": "trashed"}},
+		}})
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, float64(1), result["index"])
+		assert.Equal(t, before, titles())
+	})
+
+	t.Run("invalid batches", func(t *testing.T) {
+		status, _ := do("POST", "/todos:batch", map[string]any{"operations": []map[string]any{}})
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = do("POST", "/todos:sync", map[string]any{"operations": []map[string]any{{"op": "create"}}})
+		assert.Equal(t, http.StatusNotFound, status)
+
+		status, _ = do("POST", "/todos:batch", map[string]any{"operations": []map[string]any{{"op": "rename", "id": groceries}}})
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, result := do("POST", "/todos:batch", map[string]any{"operations": []map[string]any{
+			{"op": "create", "todo": map[string]any{"title": "ok"}},
+			{"op": "complete"},
+		}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, float64(1), result["index"])
+
+		status, result = do("POST", "/todos:batch", map[string]any{"operations": []map[string]any{{"op": "create", "todo": map[string]any{"title": " "}}}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, float64(0), result["index"])
+	})
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComments(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	ownerToken := loginAs(t, server, "comments-owner@example.com")
+	ownerID := userIDOf(t, server, ownerToken)
+	friendToken := loginAs(t, server, "comments-friend@example.com")
+	strangerToken := loginAs(t, server, "comments-stranger@example.com")
+
+	doAs := func(token, method, path string, body any) (int, map[string]interface{}) {
+		status, _, result := doJSONWithHeader(t, server, token, method, path, body, http.Header{"X-Workspace-ID": {ownerID}})
+		return status, result
+	}
+
+	for _, email := range []string{"comments-friend@example.com", "comments-stranger@example.com"} {
+		status, _ := doAs(ownerToken, "POST", "/workspaces/"+ownerID+"/members", map[string]any{"email": email, "role": "member"})
+		require.Equal(t, http.StatusOK, status)
+	}
+	status, result := doAs(ownerToken, "POST", "/todos", map[string]any{"title": "release"})
+	require.Equal(t, http.StatusCreated, status)
+	todoID := result["todo"].(map[string]interface{})["id"].(string)
+	status, _ = doAs(ownerToken, "POST", "/todos/"+todoID+"/shares", map[string]any{"email": "comments-friend@example.com", "role": "viewer"})
+	require.Equal(t, http.StatusOK, status)
+
+	commentsPath := "/todos/" + todoID + "/comments"
+	bodies := func(token string) []string {
+		status, result := doAs(token, "GET", commentsPath, nil)
+		require.Equal(t, http.StatusOK, status)
+		got := []string{}
+		for _, item := range result["comments"].([]interface{}) {
+			got = append(got, item.(map[string]interface{})["body"].(string))
+		}
+		return got
+	}
+
+	status, result = doAs(ownerToken, "POST", commentsPath, map[string]any{"body": "ship on friday?"})
+	require.Equal(t, http.StatusCreated, status)
+	comment := result["comment"].(map[string]interface{})
+	ownerComment := comment["id"].(string)
+	assert.Equal(t, ownerID, comment["author_id"])
+	assert.Equal(t, "comments-owner@example.com", comment["author_email"])
+	assert.NotContains(t, comment, "edited_at")
+
+	t.Run("viewers read and reply", func(t *testing.T) {
+		status, result := doAs(friendToken, "POST", commentsPath, map[string]any{"body": "works for me"})
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, "comments-friend@example.com", result["comment"].(map[string]interface{})["author_email"])
+
+		assert.Equal(t, []string{"ship on friday?", "works for me"}, bodies(ownerToken))
+		assert.Equal(t, []string{"ship on friday?", "works for me"}, bodies(friendToken))
+	})
+
+	t.Run("others cannot see the thread", func(t *testing.T) {
+		status, _ := doAs(strangerToken, "GET", commentsPath, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+		status, _ = doAs(strangerToken, "POST", commentsPath, map[string]any{"body": "hello"})
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("only the author edits and deletes", func(t *testing.T) {
+		status, _ := doAs(friendToken, "PUT", commentsPath+"/"+ownerComment, map[string]any{"body": "monday"})
+		assert.Equal(t, http.StatusForbidden, status)
+		status, _ = doAs(friendToken, "DELETE", commentsPath+"/"+ownerComment, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, result := doAs(ownerToken, "PUT", commentsPath+"/"+ownerComment, map[string]any{"body": "ship on monday?"})
+		require.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, result["comment"].(map[string]interface{})["edited_at"])
+
+		status, _ = doAs(ownerToken, "DELETE", commentsPath+"/"+ownerComment, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{"works for me"}, bodies(ownerToken))
+		status, _ = doAs(ownerToken, "PUT", commentsPath+"/"+ownerComment, map[string]any{"body": "again"})
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("empty bodies are rejected", func(t *testing.T) {
+		status, _ := doAs(ownerToken, "POST", commentsPath, map[string]any{"body": "   "})
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = doAs(ownerToken, "POST", commentsPath, map[string]any{})
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoHistory(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	token := loginAs(t, server, "history@example.com")
+	otherToken := loginAs(t, server, "history-other@example.com")
+
+	doAs := func(token, method, path string, body any) (int, map[string]interface{}) {
+		return doJSON(t, server, token, method, path, body)
+	}
+	do := func(method, path string, body any) (int, map[string]interface{}) {
+		return doAs(token, method, path, body)
+	}
+
+	status, result := do("POST", "/todos", map[string]any{"title": "report", "description": "q3"})
+	require.Equal(t, http.StatusCreated, status)
+	todoID := result["todo"].(map[string]interface{})["id"].(string)
+	historyPath := "/todos/" + todoID + "/history"
+	revisions := func() []map[string]interface{} {
+		status, result := do("GET", historyPath, nil)
+		require.Equal(t, http.StatusOK, status)
+		got := []map[string]interface{}{}
+		for _, item := range result["revisions"].([]interface{}) {
+			got = append(got, item.(map[string]interface{}))
+		}
+		return got
+	}
+
+	status, _ = do("PATCH", "/todos/"+todoID, map[string]any{"title": "annual report", "due_at": "2030-01-10T09:00:00Z"})
+	require.Equal(t, http.StatusOK, status)
+
+	t.Run("history lists every change", func(t *testing.T) {
+		history := revisions()
+		require.Len(t, history, 2)
+		assert.Equal(t, "create", history[0]["action"])
+		assert.Equal(t, float64(1), history[0]["revision"])
+
+		update := history[1]
+		assert.Equal(t, "update", update["action"])
+		assert.NotEmpty(t, update["actor_id"])
+		changes := update["changes"].([]interface{})
+		require.Len(t, changes, 2)
+		title := changes[0].(map[string]interface{})
+		assert.Equal(t, "title", title["field"])
+		assert.Equal(t, "report", title["old"])
+		assert.Equal(t, "annual report", title["new"])
+		due := changes[1].(map[string]interface{})
+		assert.Equal(t, "due_at", due["field"])
+		assert.Nil(t, due["old"])
+		assert.Equal(t, "annual report", update["state"].(map[string]interface{})["title"])
+	})
+
+	t.Run("revert brings back an earlier state", func(t *testing.T) {
+		status, result := do("POST", "/todos/"+todoID+"/revert/1", nil)
+		require.Equal(t, http.StatusOK, status)
+		todo := result["todo"].(map[string]interface{})
+		assert.Equal(t, "report", todo["title"])
+		assert.Nil(t, todo["due_at"])
+
+		history := revisions()
+		require.Len(t, history, 3)
+		assert.Equal(t, "revert", history[2]["action"])
+		assert.Equal(t, float64(1), history[2]["reverted_to"])
+	})
+
+	t.Run("revert errors", func(t *testing.T) {
+		status, _ := do("POST", "/todos/"+todoID+"/revert/9", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		status, _ = do("POST", "/todos/"+todoID+"/revert/zero", nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = doAs(otherToken, "POST", "/todos/"+todoID+"/revert/2", nil)
+		assert.Equal(t, http.StatusForbidden, status)
+		status, _ = doAs(otherToken, "GET", historyPath, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("deleted todo keeps its history until purged", func(t *testing.T) {
+		status, _ := do("DELETE", "/todos/"+todoID, nil)
+		require.Equal(t, http.StatusOK, status)
+		status, _ = do("POST", "/todos/"+todoID+"/restore", nil)
+		require.Equal(t, http.StatusOK, status)
+
+		history := revisions()
+		require.Len(t, history, 5)
+		assert.Equal(t, "delete", history[3]["action"])
+		assert.Equal(t, "restore", history[4]["action"])
+	})
+}
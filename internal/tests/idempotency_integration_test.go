@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	client := server.Client()
+
+	alice := loginAs(t, server, "idem-alice@example.com")
+	bob := loginAs(t, server, "idem-bob@example.com")
+
+	doAs := func(token, method, path, key string, body any) (int, http.Header, map[string]interface{}) {
+		header := http.Header{}
+		if key != "" {
+			header.Set("Idempotency-Key", key)
+		}
+		return doJSONWithHeader(t, server, token, method, path, body, header)
+	}
+	countTodos := func(token string) int {
+		status, _, result := doAs(token, "GET", "/todos", "", nil)
+		require.Equal(t, http.StatusOK, status)
+		return len(result["todos"].([]interface{}))
+	}
+
+	t.Run("retry replays the first response", func(t *testing.T) {
+		body := map[string]any{"title": "pay rent"}
+		status, header, first := doAs(alice, "POST", "/todos", "create-rent", body)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Empty(t, header.Get("Idempotent-Replayed"))
+
+		status, header, second := doAs(alice, "POST", "/todos", "create-rent", body)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, "true", header.Get("Idempotent-Replayed"))
+		assert.Equal(t, "application/json; charset=utf-8", header.Get("Content-Type"))
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, countTodos(alice))
+	})
+
+	t.Run("same key with a different body is rejected", func(t *testing.T) {
+		status, _, result := doAs(alice, "POST", "/todos", "create-rent", map[string]any{"title": "pay bills"})
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.Contains(t, result["message"], "different request")
+
+		status, _, _ = doAs(alice, "POST", "/projects", "create-rent", map[string]any{"title": "pay rent"})
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.Equal(t, 1, countTodos(alice))
+	})
+
+	t.Run("keys are per user", func(t *testing.T) {
+		status, header, _ := doAs(bob, "POST", "/todos", "create-rent", map[string]any{"title": "pay rent"})
+		require.Equal(t, http.StatusCreated, status)
+		assert.Empty(t, header.Get("Idempotent-Replayed"))
+		assert.Equal(t, 1, countTodos(bob))
+		assert.Equal(t, 1, countTodos(alice))
+	})
+
+	t.Run("same key in another workspace is rejected", func(t *testing.T) {
+		status, _, result := doAs(alice, "POST", "/workspaces", "", map[string]any{"name": "Household"})
+		require.Equal(t, http.StatusCreated, status)
+		workspaceID := result["workspace"].(map[string]interface{})["id"].(string)
+		status, _, result = doAs(alice, "POST", "/workspaces/"+workspaceID+"/switch", "", nil)
+		require.Equal(t, http.StatusOK, status)
+		householdToken := result["accessToken"].(string)
+
+		body := map[string]any{"title": "buy groceries"}
+		status, _, _ = doAs(alice, "POST", "/todos", "create-groceries", body)
+		require.Equal(t, http.StatusCreated, status)
+		// Пространство выбрано утверждением в токене, заголовка нет.
+		status, header, _ := doAs(householdToken, "POST", "/todos", "create-groceries", body)
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.Empty(t, header.Get("Idempotent-Replayed"))
+	})
+
+	t.Run("repeated delete keeps its first answer", func(t *testing.T) {
+		status, _, result := doAs(alice, "POST", "/todos", "", map[string]any{"title": "old note"})
+		require.Equal(t, http.StatusCreated, status)
+		path := "/todos/" + result["todo"].(map[string]interface{})["id"].(string)
+
+		status, _, _ = doAs(alice, "DELETE", path, "delete-note", nil)
+		require.Equal(t, http.StatusOK, status)
+		status, header, _ := doAs(alice, "DELETE", path, "delete-note", nil)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "true", header.Get("Idempotent-Replayed"))
+
+		// Без ключа повтор выполняется заново.
+		status, _, _ = doAs(alice, "DELETE", path, "", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("requests without a key are not deduplicated", func(t *testing.T) {
+		before := countTodos(alice)
+		doAs(alice, "POST", "/todos", "", map[string]any{"title": "water plants"})
+		doAs(alice, "POST", "/todos", "", map[string]any{"title": "water plants"})
+		assert.Equal(t, before+2, countTodos(alice))
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		status, _, _ := doAs(alice, "POST", "/todos", strings.Repeat("k", 256), map[string]any{"title": "x"})
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _, _ = doAs(alice, "POST", "/todos", "bad\tkey", map[string]any{"title": "x"})
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("body over the largest accepted size is rejected", func(t *testing.T) {
+		body := strings.Repeat("x", 51<<20)
+		req, _ := http.NewRequest("POST", server.URL+"/api/v1/todos", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+alice)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "too-large")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+}
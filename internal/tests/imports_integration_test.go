@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportJobs(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	client := server.Client()
+
+	alice := loginAs(t, server, "imports-alice@example.com")
+	bob := loginAs(t, server, "imports-bob@example.com")
+
+	raw := func(token, method, path, body string) (int, http.Header, map[string]interface{}) {
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		var result map[string]interface{}
+		json.Unmarshal(data, &result)
+		return resp.StatusCode, resp.Header, result
+	}
+	// start запускает импорт и ждёт его завершения.
+	start := func(token, query, body string) map[string]interface{} {
+		status, header, result := raw(token, "POST", "/api/v1/imports?"+query, body)
+		require.Equal(t, http.StatusAccepted, status, result)
+		job := result["job"].(map[string]interface{})
+		assert.Equal(t, "/api/v1/imports/"+job["id"].(string), header.Get("Location"))
+		require.Eventually(t, func() bool {
+			status, _, result := raw(token, "GET", header.Get("Location"), "")
+			require.Equal(t, http.StatusOK, status)
+			job = result["job"].(map[string]interface{})
+			return job["status"] == "succeeded" || job["status"] == "failed"
+		}, 5*time.Second, 10*time.Millisecond)
+		return job
+	}
+	todosOf := func(token string) map[string]map[string]interface{} {
+		status, _, result := raw(token, "GET", "/api/v1/todos?limit=100", "")
+		require.Equal(t, http.StatusOK, status)
+		byTitle := map[string]map[string]interface{}{}
+		for _, todo := range result["todos"].([]interface{}) {
+			todo := todo.(map[string]interface{})
+			byTitle[todo["title"].(string)] = todo
+		}
+		return byTitle
+	}
+
+	t.Run("trello board", func(t *testing.T) {
+		board := `{"name": "Launch",
+			"labels": [{"id": "l1", "name": "Design", "color": "blue"}],
+			"lists": [{"id": "todo", "name": "To Do", "pos": 1}],
+			"cards": [{"id": "c1", "name": "Write copy", "desc": "Landing page", "idList": "todo", "idLabels": ["l1"],
+				"due": "2026-05-01T12:00:00.000Z", "dueComplete": true, "pos": 1}],
+			"checklists": [{"idCard": "c1", "pos": 1, "checkItems": [
+				{"id": "i1", "name": "Draft", "state": "complete", "pos": 1},
+				{"id": "i2", "name": "Edit", "state": "incomplete", "pos": 2}]}]}`
+		job := start(alice, "source=trello", board)
+		assert.Equal(t, "succeeded", job["status"], job)
+		assert.Equal(t, "trello", job["source"])
+		assert.Equal(t, float64(3), job["total"])
+		assert.Equal(t, float64(3), job["processed"])
+		assert.Equal(t, float64(3), job["report"].(map[string]interface{})["imported"])
+		assert.NotEmpty(t, job["finished_at"])
+
+		todos := todosOf(alice)
+		card := todos["Write copy"]
+		require.NotNil(t, card)
+		assert.Equal(t, true, card["completed"])
+		assert.Equal(t, "2026-05-01T12:00:00Z", card["due_at"])
+		assert.NotNil(t, card["project_id"])
+		assert.Equal(t, card["id"], todos["Draft"]["parent_id"])
+		assert.Equal(t, true, todos["Draft"]["completed"])
+		assert.Equal(t, false, todos["Edit"]["completed"])
+
+		status, _, tags := raw(alice, "GET", "/api/v1/todos/"+card["id"].(string)+"/tags", "")
+		require.Equal(t, http.StatusOK, status)
+		names := []string{}
+		for _, tag := range tags["tags"].([]interface{}) {
+			names = append(names, tag.(map[string]interface{})["name"].(string))
+		}
+		assert.ElementsMatch(t, []string{"To Do", "Design"}, names)
+	})
+
+	t.Run("todoist backup dry run", func(t *testing.T) {
+		var buf bytes.Buffer
+		archive := zip.NewWriter(&buf)
+		w, _ := archive.Create("Home [42].csv")
+		io.WriteString(w, "TYPE,CONTENT,PRIORITY,INDENT,DATE,TIMEZONE\n"+
+			"task,Clean up @chores,1,1,2026-04-01,\n"+
+			"task,Wash dishes,4,2,,\n")
+		require.NoError(t, archive.Close())
+
+		job := start(bob, "source=todoist&dry_run=true", buf.String())
+		assert.Equal(t, "succeeded", job["status"], job)
+		assert.Equal(t, true, job["dry_run"])
+		report := job["report"].(map[string]interface{})
+		assert.Equal(t, float64(2), report["valid"])
+		assert.Equal(t, float64(0), report["imported"])
+		assert.Empty(t, todosOf(bob))
+
+		job = start(bob, "source=todoist", buf.String())
+		assert.Equal(t, "succeeded", job["status"], job)
+		todos := todosOf(bob)
+		assert.Equal(t, "urgent", todos["Clean up"]["priority"])
+		assert.Equal(t, todos["Clean up"]["id"], todos["Wash dishes"]["parent_id"])
+	})
+
+	t.Run("row errors fail the job", func(t *testing.T) {
+		before := len(todosOf(bob))
+		job := start(bob, "source=todoist", `{"items": [{"id": "1", "content": "Fine"}, {"id": "2", "content": "  "}]}`)
+		assert.Equal(t, "failed", job["status"])
+		assert.Contains(t, job["error"], "nothing was saved")
+		report := job["report"].(map[string]interface{})
+		require.Len(t, report["errors"], 1)
+		rowErr := report["errors"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, float64(2), rowErr["row"])
+		assert.Equal(t, before, len(todosOf(bob)))
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		status, _, _ := raw(alice, "POST", "/api/v1/imports?source=asana", "{}")
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _, _ = raw(alice, "POST", "/api/v1/imports", "{}")
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _, result := raw(alice, "POST", "/api/v1/imports?source=trello", "name,list\nx,y\n")
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Contains(t, result["error"], "Card Name")
+		status, _, _ = raw(alice, "POST", "/api/v1/imports?source=todoist", `{"items": `)
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		// Чужой импорт не виден.
+		job := start(alice, "source=trello", `{"name": "Empty", "cards": []}`)
+		assert.Equal(t, "succeeded", job["status"])
+		status, _, _ = raw(bob, "GET", "/api/v1/imports/"+job["id"].(string), "")
+		assert.Equal(t, http.StatusNotFound, status)
+		status, _, _ = raw(alice, "GET", "/api/v1/imports/not-a-uuid", "")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
@@ -3,6 +3,7 @@ package tests
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -13,12 +14,22 @@ import (
 	"github.com/polzovatel/todo-learning/cmd/app"
 	"github.com/polzovatel/todo-learning/config"
 	"github.com/polzovatel/todo-learning/internal/auth"
+	"github.com/polzovatel/todo-learning/internal/blob"
+	"github.com/polzovatel/todo-learning/internal/mail"
 	"github.com/polzovatel/todo-learning/internal/repository/in_memory"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func setupTestServer(t *testing.T) (*httptest.Server, *in_memory.InMemoryRepository) {
+	return setupTestServerWithConfig(t, func(*config.Config) {})
+}
+
+func setupTestServerWithConfig(t *testing.T, configure func(cfg *config.Config)) (*httptest.Server, *in_memory.InMemoryRepository) {
+	return newTestServer(t, configure, mail.NewLogMailer(slog.Default()))
+}
+
+func newTestServer(t *testing.T, configure func(cfg *config.Config), mailer mail.Mailer) (*httptest.Server, *in_memory.InMemoryRepository) {
 	// Настраиваем тестовое окружение
 	gin.SetMode(gin.TestMode)
 
@@ -27,16 +38,28 @@ func setupTestServer(t *testing.T) (*httptest.Server, *in_memory.InMemoryReposit
 
 	// Создаем JWT signer
 	cfg := &config.Config{
-		JWTAlg:     "HS256",
-		JWTSecret:  "test-secret-key",
-		AccessTTL:  15 * time.Minute,
-		RefreshTTL: 24 * time.Hour,
+		JWTAlg:       "HS256",
+		JWTSecret:    "test-secret-key",
+		AccessTTL:    15 * time.Minute,
+		RefreshTTL:   24 * time.Hour,
+		TodoMaxDepth: 3,
+
+		IdempotencyTTL: time.Hour,
+
+		AttachmentMaxSize: 1 << 20,
+		AttachmentTypes:   []string{"image/*", "application/pdf", "text/plain"},
 	}
+	configure(cfg)
+	if cfg.BlobDir == "" {
+		cfg.BlobDir = t.TempDir()
+	}
+	blobs, err := blob.NewLocalStore(cfg.BlobDir)
+	require.NoError(t, err)
 	signer, err := auth.NewJWTSigner(cfg)
 	require.NoError(t, err)
 
 	// Создаем приложение
-	app := app.NewApp(slog.Default(), repo, repo, nil, signer)
+	app := app.NewApp(cfg, slog.Default(), repo, nil, signer, mailer, blobs)
 
 	// Создаем тестовый HTTP сервер
 	server := httptest.NewServer(app.Router)
@@ -44,6 +67,75 @@ func setupTestServer(t *testing.T) (*httptest.Server, *in_memory.InMemoryReposit
 	return server, repo
 }
 
+// loginAs регистрирует пользователя с тестовым паролем, если его ещё нет, и возвращает его access token.
+func loginAs(t *testing.T, server *httptest.Server, email string) string {
+	t.Helper()
+	client := server.Client()
+	creds, _ := json.Marshal(map[string]string{"email": email, "password": "Test123!"})
+	resp, err := client.Post(server.URL+"/api/v1/register", "application/json", bytes.NewBuffer(creds))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = client.Post(server.URL+"/api/v1/login", "application/json", bytes.NewBuffer(creds))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var login map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&login)
+	token, ok := login["accessToken"].(string)
+	require.True(t, ok, "login as %s: %v", email, login)
+	return token
+}
+
+// userIDOf возвращает id владельца токена из GET /me.
+func userIDOf(t *testing.T, server *httptest.Server, token string) string {
+	t.Helper()
+	status, me := doJSON(t, server, token, "GET", "/me", nil)
+	require.Equal(t, http.StatusOK, status)
+	return me["id"].(string)
+}
+
+// doJSON отправляет запрос к /api/v1 от имени token (пустой — без авторизации) с телом в JSON
+// и разбирает JSON-ответ. PATCH уходит как merge patch.
+func doJSON(t *testing.T, server *httptest.Server, token, method, path string, body any) (int, map[string]interface{}) {
+	t.Helper()
+	status, _, result := doJSONWithHeader(t, server, token, method, path, body, nil)
+	return status, result
+}
+
+// doJSONWithHeader — doJSON с дополнительными заголовками запроса, которые заменяют
+// стандартные, и с заголовками ответа. json.RawMessage отправляется как есть.
+func doJSONWithHeader(t *testing.T, server *httptest.Server, token, method, path string, body any, header http.Header) (int, http.Header, map[string]interface{}) {
+	t.Helper()
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		raw, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, server.URL+"/api/v1"+path, reader)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if method == http.MethodPatch {
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+	}
+	for name, values := range header {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, resp.Header, result
+}
+
 func TestRegisterAndLogin(t *testing.T) {
 	server, _ := setupTestServer(t)
 	defer server.Close()
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polzovatel/todo-learning/config"
+	"github.com/polzovatel/todo-learning/internal/mail"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMailer запоминает отправленные письма вместо отправки.
+type recordingMailer struct {
+	sent []mail.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var linkPattern = regexp.MustCompile(`https?://\S+`)
+
+func TestMagicLinkLogin(t *testing.T) {
+	mailer := &recordingMailer{}
+	server, repo := newTestServer(t, func(cfg *config.Config) {
+		cfg.MagicLinkURL = "http://localhost:8080/api/v1/login/magic/callback"
+		cfg.MagicLinkTTL = 10 * time.Minute
+	}, mailer)
+	defer server.Close()
+
+	client := server.Client()
+
+	creds, _ := json.Marshal(map[string]string{"email": "magic@example.com", "password": "Test123!"})
+	client.Post(server.URL+"/api/v1/register", "application/json", bytes.NewBuffer(creds))
+
+	requestLink := func(email string) int {
+		body, _ := json.Marshal(map[string]string{"email": email})
+		resp, err := client.Post(server.URL+"/api/v1/login/magic", "application/json", bytes.NewBuffer(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	callback := func(token string) (int, map[string]interface{}) {
+		resp, err := client.Get(server.URL + "/api/v1/login/magic/callback?token=" + url.QueryEscape(token))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+	lastToken := func(t *testing.T) string {
+		require.NotEmpty(t, mailer.sent)
+		link, err := url.Parse(linkPattern.FindString(mailer.sent[len(mailer.sent)-1].Body))
+		require.NoError(t, err)
+		return link.Query().Get("token")
+	}
+
+	t.Run("link is emailed and exchanged for tokens", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, requestLink("magic@example.com"))
+		require.Len(t, mailer.sent, 1)
+		assert.Equal(t, "magic@example.com", mailer.sent[0].To)
+
+		status, result := callback(lastToken(t))
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, result["accessToken"])
+		assert.NotEmpty(t, result["refreshToken"])
+	})
+
+	t.Run("link is single use", func(t *testing.T) {
+		status, _ := callback(lastToken(t))
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("link token is not a bearer token", func(t *testing.T) {
+		requestLink("magic@example.com")
+		req, _ := http.NewRequest("GET", server.URL+"/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer "+lastToken(t))
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("tampered token is rejected", func(t *testing.T) {
+		status, _ := callback(lastToken(t) + "x")
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("unknown email gets the same answer and no mail", func(t *testing.T) {
+		sent := len(mailer.sent)
+		assert.Equal(t, http.StatusAccepted, requestLink("nobody@example.com"))
+		assert.Len(t, mailer.sent, sent)
+	})
+
+	t.Run("requests are rate limited per email", func(t *testing.T) {
+		// Две ссылки уже выданы выше, лимит — три.
+		assert.Equal(t, http.StatusAccepted, requestLink("magic@example.com"))
+		assert.Equal(t, http.StatusTooManyRequests, requestLink("magic@example.com"))
+	})
+
+	t.Run("redeemed link token introspects inactive", func(t *testing.T) {
+		_, err := service.NewClientService(repo, slog.Default()).RegisterClient(context.Background(), "gateway", "gateway-secret", []string{"tokens:introspect"})
+		require.NoError(t, err)
+		introspect := func(token string) map[string]interface{} {
+			form := url.Values{"token": {token}}
+			req, _ := http.NewRequest("POST", server.URL+"/api/v1/oauth/introspect", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.SetBasicAuth("gateway", "gateway-secret")
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			var result map[string]interface{}
+			json.NewDecoder(resp.Body).Decode(&result)
+			return result
+		}
+
+		creds, _ := json.Marshal(map[string]string{"email": "magic-introspect@example.com", "password": "Test123!"})
+		client.Post(server.URL+"/api/v1/register", "application/json", bytes.NewBuffer(creds))
+		requestLink("magic-introspect@example.com")
+		token := lastToken(t)
+		result := introspect(token)
+		assert.Equal(t, true, result["active"])
+		assert.Equal(t, "magic_link", result["token_type"])
+
+		status, _ := callback(token)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, map[string]interface{}{"active": false}, introspect(token))
+	})
+}
@@ -0,0 +1,296 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/polzovatel/todo-learning/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientCredentials(t *testing.T) {
+	server, repo := setupTestServer(t)
+	defer server.Close()
+
+	client := server.Client()
+
+	clientService := service.NewClientService(repo, slog.Default())
+	_, err := clientService.RegisterClient(context.Background(), "reporter", "reporter-secret", []string{"users:read"})
+	require.NoError(t, err)
+
+	requestToken := func(t *testing.T, form url.Values, basicID, basicSecret string) *http.Response {
+		req, _ := http.NewRequest("POST", server.URL+"/api/v1/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if basicID != "" {
+			req.SetBasicAuth(basicID, basicSecret)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	getToken := func(t *testing.T, scope string) string {
+		form := url.Values{"grant_type": {"client_credentials"}}
+		if scope != "" {
+			form.Set("scope", scope)
+		}
+		resp := requestToken(t, form, "reporter", "reporter-secret")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, "Bearer", result["token_type"])
+		return result["access_token"].(string)
+	}
+
+	t.Run("issue token with basic auth", func(t *testing.T) {
+		token := getToken(t, "")
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("issue token with credentials in body", func(t *testing.T) {
+		form := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {"reporter"},
+			"client_secret": {"reporter-secret"},
+		}
+		resp := requestToken(t, form, "", "")
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		resp := requestToken(t, url.Values{"grant_type": {"client_credentials"}}, "reporter", "wrong")
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("scope outside of allowed", func(t *testing.T) {
+		form := url.Values{"grant_type": {"client_credentials"}, "scope": {"todos:read"}}
+		resp := requestToken(t, form, "reporter", "reporter-secret")
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("unsupported grant type", func(t *testing.T) {
+		resp := requestToken(t, url.Values{"grant_type": {"password"}}, "reporter", "reporter-secret")
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("service token can use scoped route", func(t *testing.T) {
+		token := getToken(t, "users:read")
+
+		req, _ := http.NewRequest("GET", server.URL+"/api/v1/users", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("service token without scope is rejected", func(t *testing.T) {
+		token := getToken(t, "users:read")
+
+		req, _ := http.NewRequest("GET", server.URL+"/api/v1/users/"+uuid.NewString()+"/todos", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("service token cannot use user routes", func(t *testing.T) {
+		token := getToken(t, "")
+
+		req, _ := http.NewRequest("GET", server.URL+"/api/v1/todos", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("user token cannot use scoped route", func(t *testing.T) {
+		token := loginAs(t, server, "human@example.com")
+
+		status, _ := doJSON(t, server, token, "GET", "/users", nil)
+
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+}
+
+func TestTokenIntrospection(t *testing.T) {
+	server, repo := setupTestServer(t)
+	defer server.Close()
+
+	client := server.Client()
+
+	clientService := service.NewClientService(repo, slog.Default())
+	_, err := clientService.RegisterClient(context.Background(), "gateway", "gateway-secret", []string{"tokens:introspect"})
+	require.NoError(t, err)
+	_, err = clientService.RegisterClient(context.Background(), "reporter", "reporter-secret", []string{"users:read"})
+	require.NoError(t, err)
+
+	introspect := func(t *testing.T, clientID, secret, token string) (int, map[string]interface{}) {
+		form := url.Values{"token": {token}}
+		req, _ := http.NewRequest("POST", server.URL+"/api/v1/oauth/introspect", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(clientID, secret)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+
+	body, _ := json.Marshal(map[string]string{"email": "introspect@example.com", "password": "Test123!"})
+	client.Post(server.URL+"/api/v1/register", "application/json", bytes.NewBuffer(body))
+	resp, err := client.Post(server.URL+"/api/v1/login", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	var login map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&login)
+	resp.Body.Close()
+	accessToken := login["accessToken"].(string)
+	refreshToken := login["refreshToken"].(string)
+
+	t.Run("active access token", func(t *testing.T) {
+		status, result := introspect(t, "gateway", "gateway-secret", accessToken)
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, true, result["active"])
+		assert.Equal(t, "access_token", result["token_type"])
+		assert.Equal(t, "introspect@example.com", result["username"])
+		assert.NotEmpty(t, result["jti"])
+		assert.NotEmpty(t, result["exp"])
+	})
+
+	t.Run("active refresh token", func(t *testing.T) {
+		_, result := introspect(t, "gateway", "gateway-secret", refreshToken)
+
+		assert.Equal(t, true, result["active"])
+		assert.Equal(t, "refresh_token", result["token_type"])
+		assert.Equal(t, "introspect@example.com", result["username"])
+	})
+
+	doAs := func(t *testing.T, token, method, path string, payload any) (int, map[string]interface{}) {
+		var reader *bytes.Buffer
+		if payload != nil {
+			raw, _ := json.Marshal(payload)
+			reader = bytes.NewBuffer(raw)
+		} else {
+			reader = &bytes.Buffer{}
+		}
+		req, _ := http.NewRequest(method, server.URL+"/api/v1"+path, reader)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+
+	t.Run("personal access token", func(t *testing.T) {
+		status, created := doAs(t, accessToken, "POST", "/tokens", map[string]any{"name": "ci", "expires_in_days": 30})
+		require.Equal(t, http.StatusCreated, status)
+		pat := created["token"].(string)
+		patID := created["personal_token"].(map[string]interface{})["id"].(string)
+		assert.True(t, strings.HasPrefix(pat, "todo_pat_"))
+
+		status, result := introspect(t, "gateway", "gateway-secret", pat)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, true, result["active"])
+		assert.Equal(t, "personal_token", result["token_type"])
+		assert.Equal(t, "introspect@example.com", result["username"])
+		assert.Equal(t, patID, result["jti"])
+		assert.NotEmpty(t, result["exp"])
+
+		status, _ = doAs(t, pat, "GET", "/me", nil)
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = doAs(t, pat, "POST", "/tokens", map[string]any{"name": "escalate"})
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, list := doAs(t, accessToken, "GET", "/tokens", nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, list["tokens"], 1)
+		assert.NotContains(t, list["tokens"].([]interface{})[0], "token_hash")
+
+		status, _ = doAs(t, accessToken, "DELETE", "/tokens/"+patID, nil)
+		assert.Equal(t, http.StatusNoContent, status)
+		_, result = introspect(t, "gateway", "gateway-secret", pat)
+		assert.Equal(t, map[string]interface{}{"active": false}, result)
+		status, _ = doAs(t, pat, "GET", "/me", nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+		status, _ = doAs(t, accessToken, "DELETE", "/tokens/"+patID, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("unknown personal token is inactive", func(t *testing.T) {
+		_, result := introspect(t, "gateway", "gateway-secret", "todo_pat_unknown")
+
+		assert.Equal(t, map[string]interface{}{"active": false}, result)
+	})
+
+	t.Run("garbage token is inactive", func(t *testing.T) {
+		status, result := introspect(t, "gateway", "gateway-secret", "not-a-token")
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, map[string]interface{}{"active": false}, result)
+	})
+
+	t.Run("client without introspect scope", func(t *testing.T) {
+		status, _ := introspect(t, "reporter", "reporter-secret", accessToken)
+
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("invalid client credentials", func(t *testing.T) {
+		status, _ := introspect(t, "gateway", "wrong", accessToken)
+
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("tokens are inactive after logout", func(t *testing.T) {
+		logoutBody, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+		req, _ := http.NewRequest("POST", server.URL+"/api/v1/logout", bytes.NewBuffer(logoutBody))
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		_, result := introspect(t, "gateway", "gateway-secret", accessToken)
+		assert.Equal(t, false, result["active"])
+		_, result = introspect(t, "gateway", "gateway-secret", refreshToken)
+		assert.Equal(t, false, result["active"])
+
+		req, _ = http.NewRequest("GET", server.URL+"/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		resp, err = client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
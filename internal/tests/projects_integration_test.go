@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjects(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	token := loginAs(t, server, "projects@example.com")
+	otherToken := loginAs(t, server, "projects-other@example.com")
+
+	doAs := func(token, method, path string, body any) (int, map[string]interface{}) {
+		return doJSON(t, server, token, method, path, body)
+	}
+	do := func(method, path string, body any) (int, map[string]interface{}) {
+		return doAs(token, method, path, body)
+	}
+	createProject := func(name string) string {
+		status, result := do("POST", "/projects", map[string]any{"name": name})
+		require.Equal(t, http.StatusCreated, status)
+		project := result["project"].(map[string]interface{})
+		assert.Equal(t, "#808080", project["color"])
+		return project["id"].(string)
+	}
+	createTodo := func(title string, projectID any) string {
+		status, result := do("POST", "/todos", map[string]any{"title": title, "project_id": projectID})
+		require.Equal(t, http.StatusCreated, status)
+		return result["todo"].(map[string]interface{})["id"].(string)
+	}
+	projectTitles := func(projectID string) []string {
+		status, result := do("GET", "/projects/"+projectID+"/todos?sort=title", nil)
+		require.Equal(t, http.StatusOK, status)
+		got := []string{}
+		for _, item := range result["todos"].([]interface{}) {
+			got = append(got, item.(map[string]interface{})["title"].(string))
+		}
+		return got
+	}
+
+	work := createProject("work")
+	home := createProject("home")
+	report := createTodo("report", work)
+	createTodo("slides", work)
+	createTodo("dishes", home)
+	loose := createTodo("loose", nil)
+
+	t.Run("project todos", func(t *testing.T) {
+		assert.Equal(t, []string{"report", "slides"}, projectTitles(work))
+		assert.Equal(t, []string{"dishes"}, projectTitles(home))
+
+		status, todo := do("GET", "/todos/"+report, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, work, todo["project_id"])
+	})
+
+	t.Run("move todo between projects and to inbox", func(t *testing.T) {
+		status, _ := do("PATCH", "/todos/"+loose, map[string]any{"project_id": home})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{"dishes", "loose"}, projectTitles(home))
+
+		status, result := do("PATCH", "/todos/"+loose, map[string]any{"project_id": nil})
+		require.Equal(t, http.StatusOK, status)
+		assert.NotContains(t, result["todo"], "project_id")
+		assert.Equal(t, []string{"dishes"}, projectTitles(home))
+	})
+
+	t.Run("other users cannot use the project", func(t *testing.T) {
+		status, _ := doAs(otherToken, "GET", "/projects/"+work, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+		status, _ = doAs(otherToken, "GET", "/projects/"+work+"/todos", nil)
+		assert.Equal(t, http.StatusForbidden, status)
+		status, _ = doAs(otherToken, "POST", "/todos", map[string]any{"title": "sneaky", "project_id": work})
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("archived projects are hidden and closed for new todos", func(t *testing.T) {
+		archived := createProject("someday")
+		status, _ := do("PUT", "/projects/"+archived, map[string]any{"archived": true, "sort_order": 5})
+		require.Equal(t, http.StatusOK, status)
+
+		status, result := do("GET", "/projects", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, result["projects"], 2)
+		status, result = do("GET", "/projects?archived=true", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, result["projects"], 3)
+
+		status, _ = do("POST", "/todos", map[string]any{"title": "late", "project_id": archived})
+		assert.Equal(t, http.StatusConflict, status)
+	})
+
+	t.Run("delete moves todos to inbox by default", func(t *testing.T) {
+		status, _ := do("DELETE", "/projects/"+work, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		status, todo := do("GET", "/todos/"+report, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Nil(t, todo["project_id"])
+
+		status, _ = do("GET", "/projects/"+work, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("cascade delete removes todos", func(t *testing.T) {
+		status, _ := do("DELETE", "/projects/"+home+"?todos=bogus", nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = do("DELETE", "/projects/"+home+"?todos=cascade", nil)
+		require.Equal(t, http.StatusOK, status)
+
+		status, result := do("GET", "/todos?sort=title", nil)
+		require.Equal(t, http.StatusOK, status)
+		titles := []string{}
+		for _, item := range result["todos"].([]interface{}) {
+			titles = append(titles, item.(map[string]interface{})["title"].(string))
+		}
+		assert.Equal(t, []string{"loose", "report", "slides"}, titles)
+	})
+}
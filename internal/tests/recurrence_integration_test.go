@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurringTodos(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	token := loginAs(t, server, "recurring@example.com")
+
+	do := func(method, path string, body any) (int, map[string]interface{}) {
+		return doJSON(t, server, token, method, path, body)
+	}
+
+	status, _ := do("PATCH", "/me", map[string]string{"timezone": "America/New_York"})
+	require.Equal(t, http.StatusOK, status)
+
+	// Последнее воскресенье перед переходом Нью-Йорка на летнее время (8 марта 2026).
+	due := "2026-03-01T18:00:00-05:00"
+	status, result := do("POST", "/todos", map[string]any{"title": "take out trash", "due_at": due, "recurrence": "FREQ=WEEKLY"})
+	require.Equal(t, http.StatusCreated, status)
+	todo := result["todo"].(map[string]interface{})
+	assert.Equal(t, "FREQ=WEEKLY", todo["recurrence"])
+	id := todo["id"].(string)
+
+	t.Run("invalid rules are rejected", func(t *testing.T) {
+		status, _ := do("POST", "/todos", map[string]any{"title": "x", "due_at": due, "recurrence": "FREQ=HOURLY"})
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = do("POST", "/todos", map[string]any{"title": "x", "recurrence": "FREQ=DAILY"})
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	openDue := func() []string {
+		status, result := do("GET", "/todos?completed=false", nil)
+		require.Equal(t, http.StatusOK, status)
+		got := []string{}
+		for _, item := range result["todos"].([]interface{}) {
+			item := item.(map[string]interface{})
+			if item["title"] == "take out trash" {
+				got = append(got, item["due_at"].(string))
+			}
+		}
+		return got
+	}
+	sameInstant := func(want string, got string) {
+		w, err := time.Parse(time.RFC3339, want)
+		require.NoError(t, err)
+		g, err := time.Parse(time.RFC3339, got)
+		require.NoError(t, err)
+		assert.True(t, w.Equal(g), "want %s, got %s", want, got)
+	}
+
+	t.Run("skip keeps local time across DST", func(t *testing.T) {
+		status, result := do("POST", "/todos/"+id+"/skip", nil)
+		require.Equal(t, http.StatusOK, status)
+		sameInstant("2026-03-08T18:00:00-04:00", result["todo"].(map[string]interface{})["due_at"].(string))
+	})
+
+	t.Run("completing creates the next occurrence", func(t *testing.T) {
+		status, result := do("PATCH", "/todos/"+id, map[string]bool{"completed": true})
+		require.Equal(t, http.StatusOK, status)
+		assert.NotContains(t, result["todo"], "recurrence")
+
+		dues := openDue()
+		require.Len(t, dues, 1)
+		sameInstant("2026-03-15T18:00:00-04:00", dues[0])
+	})
+
+	t.Run("end recurrence", func(t *testing.T) {
+		status, result := do("GET", "/todos?completed=false", nil)
+		require.Equal(t, http.StatusOK, status)
+		next := result["todos"].([]interface{})[0].(map[string]interface{})["id"].(string)
+
+		status, _ = do("DELETE", "/todos/"+next+"/recurrence", nil)
+		require.Equal(t, http.StatusOK, status)
+		status, _ = do("POST", "/todos/"+next+"/skip", nil)
+		assert.Equal(t, http.StatusConflict, status)
+
+		status, _ = do("PATCH", "/todos/"+next, map[string]bool{"completed": true})
+		require.Equal(t, http.StatusOK, status)
+		assert.Empty(t, openDue())
+	})
+}
@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/polzovatel/todo-learning/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshToken(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	client := server.Client()
+
+	body, _ := json.Marshal(map[string]string{"email": "refresh@example.com", "password": "Test123!"})
+	client.Post(server.URL+"/api/v1/register", "application/json", bytes.NewBuffer(body))
+	resp, err := client.Post(server.URL+"/api/v1/login", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	var login map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&login)
+	resp.Body.Close()
+
+	refresh := func(token string) (int, map[string]interface{}) {
+		reqBody, _ := json.Marshal(map[string]string{"refresh_token": token})
+		resp, err := client.Post(server.URL+"/api/v1/refresh", "application/json", bytes.NewBuffer(reqBody))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+
+	t.Run("refresh returns new pair", func(t *testing.T) {
+		status, result := refresh(login["refreshToken"].(string))
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, result["accessToken"])
+		assert.NotEmpty(t, result["refreshToken"])
+	})
+
+	t.Run("refresh token cannot be reused", func(t *testing.T) {
+		status, _ := refresh(login["refreshToken"].(string))
+
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("access token is not accepted as refresh token", func(t *testing.T) {
+		status, _ := refresh(login["accessToken"].(string))
+
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("concurrent refreshes redeem the token once", func(t *testing.T) {
+		resp, err := client.Post(server.URL+"/api/v1/login", "application/json", bytes.NewBuffer(body))
+		require.NoError(t, err)
+		var fresh map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&fresh)
+		resp.Body.Close()
+
+		const attempts = 8
+		statuses := make(chan int, attempts)
+		var wg sync.WaitGroup
+		for range attempts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				status, _ := refresh(fresh["refreshToken"].(string))
+				statuses <- status
+			}()
+		}
+		wg.Wait()
+		close(statuses)
+
+		succeeded := 0
+		for status := range statuses {
+			if status == http.StatusOK {
+				succeeded++
+			} else {
+				assert.Equal(t, http.StatusUnauthorized, status)
+			}
+		}
+		assert.Equal(t, 1, succeeded)
+	})
+}
+
+func TestCookieSessions(t *testing.T) {
+	server, _ := setupTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.AuthCookies = true
+		// httptest отдаёт http, а cookiejar не отправляет Secure cookies без TLS.
+		cfg.CookieSecure = false
+		cfg.CookieSameSite = "strict"
+	})
+	defer server.Close()
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	client := server.Client()
+	client.Jar = jar
+
+	// Cookie, которые браузер отправит на данный маршрут.
+	cookieAt := func(path, name string) string {
+		pathURL, _ := url.Parse(server.URL + path)
+		for _, c := range jar.Cookies(pathURL) {
+			if c.Name == name {
+				return c.Value
+			}
+		}
+		return ""
+	}
+	cookieValue := func(name string) string {
+		return cookieAt("/api/v1/me", name)
+	}
+
+	body, _ := json.Marshal(map[string]string{"email": "cookie@example.com", "password": "Test123!"})
+	client.Post(server.URL+"/api/v1/register", "application/json", bytes.NewBuffer(body))
+
+	t.Run("login sets cookies instead of returning tokens", func(t *testing.T) {
+		resp, err := client.Post(server.URL+"/api/v1/login", "application/json", bytes.NewBuffer(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Nil(t, result["accessToken"])
+		assert.Equal(t, cookieValue("csrf_token"), result["csrfToken"])
+
+		for _, c := range resp.Cookies() {
+			switch c.Name {
+			case "access_token", "refresh_token":
+				assert.True(t, c.HttpOnly, c.Name)
+				assert.Equal(t, http.SameSiteStrictMode, c.SameSite, c.Name)
+			case "csrf_token":
+				assert.False(t, c.HttpOnly)
+			}
+		}
+		assert.NotEmpty(t, cookieValue("access_token"))
+		// Refresh token уходит только на /refresh и /logout.
+		assert.Empty(t, cookieValue("refresh_token"))
+		assert.NotEmpty(t, cookieAt("/api/v1/refresh", "refresh_token"))
+		assert.NotEmpty(t, cookieAt("/api/v1/logout", "refresh_token"))
+		// CSRF token доступен страницам вне /api/v1, access token — нет.
+		assert.Equal(t, cookieValue("csrf_token"), cookieAt("/app", "csrf_token"))
+		assert.Empty(t, cookieAt("/app", "access_token"))
+	})
+
+	t.Run("safe request is authenticated by cookie", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/api/v1/me")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("state-changing request without CSRF token", func(t *testing.T) {
+		todo, _ := json.Marshal(map[string]string{"title": "Cookie todo"})
+		resp, err := client.Post(server.URL+"/api/v1/todos", "application/json", bytes.NewBuffer(todo))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("state-changing request with wrong CSRF token", func(t *testing.T) {
+		todo, _ := json.Marshal(map[string]string{"title": "Cookie todo"})
+		req, _ := http.NewRequest("POST", server.URL+"/api/v1/todos", bytes.NewBuffer(todo))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-CSRF-Token", "forged")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("state-changing request with CSRF token", func(t *testing.T) {
+		todo, _ := json.Marshal(map[string]string{"title": "Cookie todo"})
+		req, _ := http.NewRequest("POST", server.URL+"/api/v1/todos", bytes.NewBuffer(todo))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-CSRF-Token", cookieValue("csrf_token"))
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("refresh by cookie requires CSRF token", func(t *testing.T) {
+		resp, err := client.Post(server.URL+"/api/v1/refresh", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		oldAccess := cookieValue("access_token")
+		req, _ := http.NewRequest("POST", server.URL+"/api/v1/refresh", nil)
+		req.Header.Set("X-CSRF-Token", cookieValue("csrf_token"))
+		resp, err = client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEqual(t, oldAccess, cookieValue("access_token"))
+	})
+
+	t.Run("logout clears cookies", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", server.URL+"/api/v1/logout", nil)
+		req.Header.Set("X-CSRF-Token", cookieValue("csrf_token"))
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		assert.Empty(t, cookieValue("access_token"))
+		assert.Empty(t, cookieAt("/api/v1/refresh", "refresh_token"))
+		assert.Empty(t, cookieAt("/api/v1/logout", "refresh_token"))
+
+		resp, err = client.Get(server.URL + "/api/v1/me")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShares(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	ownerToken := loginAs(t, server, "shares-owner@example.com")
+	ownerID := userIDOf(t, server, ownerToken)
+	friendToken := loginAs(t, server, "shares-friend@example.com")
+	friendID := userIDOf(t, server, friendToken)
+	strangerToken := loginAs(t, server, "shares-stranger@example.com")
+
+	doAs := func(token, method, path string, body any) (int, map[string]interface{}) {
+		return doJSON(t, server, token, method, path, body)
+	}
+
+	status, result := doAs(ownerToken, "POST", "/projects", map[string]any{"name": "trip"})
+	require.Equal(t, http.StatusCreated, status)
+	projectID := result["project"].(map[string]interface{})["id"].(string)
+	status, result = doAs(ownerToken, "POST", "/todos", map[string]any{"title": "tickets", "project_id": projectID})
+	require.Equal(t, http.StatusCreated, status)
+	todoID := result["todo"].(map[string]interface{})["id"].(string)
+
+	t.Run("todo share", func(t *testing.T) {
+		status, _ := doAs(friendToken, "GET", "/todos/"+todoID, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, result := doAs(ownerToken, "POST", "/todos/"+todoID+"/shares", map[string]any{"email": "shares-friend@example.com", "role": "viewer"})
+		require.Equal(t, http.StatusOK, status)
+		share := result["share"].(map[string]interface{})
+		assert.Equal(t, "viewer", share["role"])
+		assert.Equal(t, "shares-friend@example.com", share["email"])
+
+		status, _ = doAs(friendToken, "GET", "/todos/"+todoID, nil)
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = doAs(friendToken, "PATCH", "/todos/"+todoID, map[string]any{"completed": true})
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, result = doAs(friendToken, "GET", "/shared", nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, result["todos"], 1)
+		assert.Equal(t, "viewer", result["todos"].([]interface{})[0].(map[string]interface{})["role"])
+
+		status, _ = doAs(ownerToken, "POST", "/todos/"+todoID+"/shares", map[string]any{"email": "nobody@example.com", "role": "viewer"})
+		assert.Equal(t, http.StatusNotFound, status)
+		status, _ = doAs(ownerToken, "POST", "/todos/"+todoID+"/shares", map[string]any{"email": "shares-owner@example.com", "role": "viewer"})
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = doAs(ownerToken, "POST", "/todos/"+todoID+"/shares", map[string]any{"email": "shares-friend@example.com", "role": "admin"})
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = doAs(ownerToken, "DELETE", "/todos/"+todoID+"/shares/"+friendID, nil)
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = doAs(friendToken, "GET", "/todos/"+todoID, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("editors tag shared todos", func(t *testing.T) {
+		// Метки живут в пространстве, поэтому друг вешает свою метку, работая в пространстве владельца.
+		status, _ := doAs(ownerToken, "POST", "/workspaces/"+ownerID+"/members", map[string]any{"email": "shares-friend@example.com", "role": "member"})
+		require.Equal(t, http.StatusOK, status)
+		inOwnerSpace := func(token, method, path string) (int, map[string]interface{}) {
+			status, _, result := doJSONWithHeader(t, server, token, method, path, nil, http.Header{"X-Workspace-ID": {ownerID}})
+			return status, result
+		}
+
+		status, result := doAs(ownerToken, "POST", "/tags", map[string]any{"name": "travel"})
+		require.Equal(t, http.StatusCreated, status)
+		ownerTag := result["tag"].(map[string]interface{})["id"].(string)
+		status, _ = doAs(ownerToken, "PUT", "/todos/"+todoID+"/tags/"+ownerTag, nil)
+		require.Equal(t, http.StatusNoContent, status)
+		status, _, result = doJSONWithHeader(t, server, friendToken, "POST", "/tags", map[string]any{"name": "mine"}, http.Header{"X-Workspace-ID": {ownerID}})
+		require.Equal(t, http.StatusCreated, status)
+		friendTag := result["tag"].(map[string]interface{})["id"].(string)
+
+		status, _ = inOwnerSpace(friendToken, "PUT", "/todos/"+todoID+"/tags/"+friendTag)
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, _ = doAs(ownerToken, "POST", "/todos/"+todoID+"/shares", map[string]any{"email": "shares-friend@example.com", "role": "viewer"})
+		require.Equal(t, http.StatusOK, status)
+		status, result = doAs(friendToken, "GET", "/todos/"+todoID+"/tags", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, result["tags"], 1)
+		status, _ = inOwnerSpace(friendToken, "PUT", "/todos/"+todoID+"/tags/"+friendTag)
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, _ = doAs(ownerToken, "POST", "/todos/"+todoID+"/shares", map[string]any{"email": "shares-friend@example.com", "role": "editor"})
+		require.Equal(t, http.StatusOK, status)
+		status, _ = inOwnerSpace(friendToken, "PUT", "/todos/"+todoID+"/tags/"+friendTag)
+		assert.Equal(t, http.StatusNoContent, status)
+		// Редактор может снять и метку владельца, но не чужую метку повесить.
+		status, _ = inOwnerSpace(friendToken, "DELETE", "/todos/"+todoID+"/tags/"+ownerTag)
+		assert.Equal(t, http.StatusNoContent, status)
+		status, _ = inOwnerSpace(friendToken, "PUT", "/todos/"+todoID+"/tags/"+ownerTag)
+		assert.Equal(t, http.StatusForbidden, status)
+		// Своя метка из другого пространства к задаче не подходит.
+		status, result = doAs(friendToken, "POST", "/tags", map[string]any{"name": "personal"})
+		require.Equal(t, http.StatusCreated, status)
+		status, _ = doAs(friendToken, "PUT", "/todos/"+todoID+"/tags/"+result["tag"].(map[string]interface{})["id"].(string), nil)
+		assert.Equal(t, http.StatusNotFound, status)
+
+		status, result = doAs(ownerToken, "GET", "/todos/"+todoID+"/tags", nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, result["tags"], 1)
+		assert.Equal(t, "mine", result["tags"].([]interface{})[0].(map[string]interface{})["name"])
+
+		status, _ = doAs(ownerToken, "DELETE", "/todos/"+todoID+"/shares/"+friendID, nil)
+		require.Equal(t, http.StatusOK, status)
+		status, _ = doAs(ownerToken, "DELETE", "/workspaces/"+ownerID+"/members/"+friendID, nil)
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("project share covers its todos", func(t *testing.T) {
+		status, _ := doAs(ownerToken, "POST", "/projects/"+projectID+"/shares", map[string]any{"email": "shares-friend@example.com", "role": "editor"})
+		require.Equal(t, http.StatusOK, status)
+
+		status, _ = doAs(friendToken, "PATCH", "/todos/"+todoID, map[string]any{"completed": true})
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = doAs(friendToken, "POST", "/todos", map[string]any{"title": "hotel", "project_id": projectID})
+		require.Equal(t, http.StatusCreated, status)
+
+		// Список проекта одинаков для всех участников и включает задачи соавтора.
+		for _, token := range []string{ownerToken, friendToken} {
+			status, result := doAs(token, "GET", "/projects/"+projectID+"/todos?sort=title", nil)
+			require.Equal(t, http.StatusOK, status)
+			require.Len(t, result["todos"], 2)
+		}
+		status, _ = doAs(strangerToken, "GET", "/projects/"+projectID+"/todos", nil)
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, _ = doAs(friendToken, "DELETE", "/projects/"+projectID, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+		status, result := doAs(friendToken, "GET", "/projects/"+projectID+"/shares", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, result["shares"], 1)
+
+		// Участник может сам отказаться от доступа.
+		status, _ = doAs(friendToken, "DELETE", "/projects/"+projectID+"/shares/"+friendID, nil)
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = doAs(friendToken, "GET", "/projects/"+projectID, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubtasks(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	token := loginAs(t, server, "subtasks@example.com")
+
+	do := func(method, path string, body any) (int, map[string]interface{}) {
+		return doJSON(t, server, token, method, path, body)
+	}
+	createTodo := func(title string, parentID any) string {
+		status, result := do("POST", "/todos", map[string]any{"title": title, "parent_id": parentID})
+		require.Equal(t, http.StatusCreated, status)
+		return result["todo"].(map[string]interface{})["id"].(string)
+	}
+
+	root := createTodo("release", nil)
+	build := createTodo("build", root)
+	createTodo("docs", root)
+	createTodo("binaries", build)
+
+	t.Run("children with progress", func(t *testing.T) {
+		status, _ := do("PATCH", "/todos/"+build, map[string]bool{"completed": true})
+		assert.Equal(t, http.StatusConflict, status)
+		status, _ = do("PATCH", "/todos/"+build+"?subtasks=cascade", map[string]bool{"completed": true})
+		require.Equal(t, http.StatusOK, status)
+
+		status, result := do("GET", "/todos/"+root+"/children", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, result["todos"], 2)
+		assert.Equal(t, map[string]interface{}{"completed": float64(1), "total": float64(2)}, result["progress"])
+	})
+
+	t.Run("tree", func(t *testing.T) {
+		status, tree := do("GET", "/todos/"+root+"/tree", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "release", tree["todo"].(map[string]interface{})["title"])
+		children := tree["children"].([]interface{})
+		require.Len(t, children, 2)
+		first := children[0].(map[string]interface{})
+		assert.Equal(t, "build", first["todo"].(map[string]interface{})["title"])
+		assert.Len(t, first["children"], 1)
+	})
+
+	t.Run("depth and cycles", func(t *testing.T) {
+		level3 := createTodo("level 3", createTodo("level 2", build))
+		status, _ := do("POST", "/todos", map[string]any{"title": "level 4", "parent_id": level3})
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = do("PATCH", "/todos/"+root, map[string]any{"parent_id": build})
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("delete parent", func(t *testing.T) {
+		status, _ := do("DELETE", "/todos/"+root, nil)
+		assert.Equal(t, http.StatusConflict, status)
+
+		status, _ = do("DELETE", "/todos/"+root+"?subtasks=cascade", nil)
+		require.Equal(t, http.StatusOK, status)
+
+		status, result := do("GET", "/todos", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Empty(t, result["todos"])
+	})
+}
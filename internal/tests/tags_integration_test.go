@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTags(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	token := loginAs(t, server, "tags@example.com")
+	otherToken := loginAs(t, server, "tags-other@example.com")
+
+	doAs := func(token, method, path string, body any) (int, map[string]interface{}) {
+		return doJSON(t, server, token, method, path, body)
+	}
+	do := func(method, path string, body any) (int, map[string]interface{}) {
+		return doAs(token, method, path, body)
+	}
+	titles := func(query string) []string {
+		status, result := do("GET", "/todos?sort=title&"+query, nil)
+		require.Equal(t, http.StatusOK, status)
+		got := []string{}
+		for _, item := range result["todos"].([]interface{}) {
+			got = append(got, item.(map[string]interface{})["title"].(string))
+		}
+		return got
+	}
+
+	createTag := func(name, color string) string {
+		status, result := do("POST", "/tags", map[string]string{"name": name, "color": color})
+		require.Equal(t, http.StatusCreated, status)
+		return result["tag"].(map[string]interface{})["id"].(string)
+	}
+	createTodo := func(title string) string {
+		status, result := do("POST", "/todos", map[string]string{"title": title})
+		require.Equal(t, http.StatusCreated, status)
+		return result["todo"].(map[string]interface{})["id"].(string)
+	}
+
+	work := createTag("work", "#ff0000")
+	home := createTag("home", "")
+	both := createTodo("both")
+	onlyHome := createTodo("only home")
+	createTodo("untagged")
+
+	t.Run("tag CRUD", func(t *testing.T) {
+		status, _ := do("POST", "/tags", map[string]string{"name": "work"})
+		assert.Equal(t, http.StatusConflict, status)
+		status, _ = do("POST", "/tags", map[string]string{"name": "bad", "color": "red"})
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = do("POST", "/tags", map[string]string{"name": "  "})
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, result := do("PUT", "/tags/"+home, map[string]string{"color": "#00ff00"})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "#00ff00", result["tag"].(map[string]interface{})["color"])
+
+		status, result = do("GET", "/tags", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, result["tags"], 2)
+
+		// Метка лежит в чужом личном пространстве, поэтому её как будто нет.
+		status, _ = doAs(otherToken, "PUT", "/tags/"+home, map[string]string{"name": "mine"})
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("attach, detach and filter", func(t *testing.T) {
+		for _, link := range [][2]string{{both, work}, {both, home}, {onlyHome, home}} {
+			status, _ := do("PUT", "/todos/"+link[0]+"/tags/"+link[1], nil)
+			require.Equal(t, http.StatusNoContent, status)
+		}
+
+		status, result := do("GET", "/todos/"+both+"/tags", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, result["tags"], 2)
+
+		assert.Equal(t, []string{"both", "only home"}, titles("tag=work&tag=home"))
+		assert.Equal(t, []string{"both", "only home"}, titles("tag=work&tag=home&tag_mode=any"))
+		assert.Equal(t, []string{"both"}, titles("tag=work&tag=home&tag_mode=all"))
+		assert.Equal(t, []string{"both"}, titles("tag=work&tag=work&tag_mode=all"))
+
+		status, _ = do("GET", "/todos?tag=work&tag_mode=some", nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = do("DELETE", "/todos/"+both+"/tags/"+work, nil)
+		require.Equal(t, http.StatusNoContent, status)
+		assert.Empty(t, titles("tag=work"))
+	})
+
+	t.Run("cannot tag foreign todos", func(t *testing.T) {
+		status, _ := doAs(otherToken, "PUT", "/todos/"+both+"/tags/"+work, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("deleting a tag detaches it", func(t *testing.T) {
+		status, _ := do("DELETE", "/tags/"+home, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		status, result := do("GET", "/todos/"+onlyHome+"/tags", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Empty(t, result["tags"])
+	})
+}
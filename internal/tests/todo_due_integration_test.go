@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoDueDates(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	token := loginAs(t, server, "due@example.com")
+
+	do := func(method, path string, body any) (int, map[string]interface{}) {
+		return doJSON(t, server, token, method, path, body)
+	}
+	titles := func(result map[string]interface{}) []string {
+		got := []string{}
+		for _, item := range result["todos"].([]interface{}) {
+			got = append(got, item.(map[string]interface{})["title"].(string))
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	t.Run("timezone defaults to UTC and can be changed", func(t *testing.T) {
+		status, me := do("GET", "/me", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "UTC", me["timezone"])
+
+		status, _ = do("PATCH", "/me", map[string]string{"timezone": "Mars/Olympus"})
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, updated := do("PATCH", "/me", map[string]string{"timezone": "Pacific/Kiritimati"})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "Pacific/Kiritimati", updated["timezone"])
+	})
+
+	// UTC+14: «сегодня» пользователя почти всегда не совпадает с днём по UTC.
+	loc, err := time.LoadLocation("Pacific/Kiritimati")
+	require.NoError(t, err)
+	now := time.Now().In(loc)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	dues := map[string]time.Time{
+		"past":        now.Add(-time.Hour),
+		"start today": startOfDay.Add(time.Minute),
+		"done past":   now.Add(-2 * time.Hour),
+		"far":         now.AddDate(0, 0, 8),
+	}
+	for title, due := range dues {
+		status, result := do("POST", "/todos", map[string]any{"title": title, "due_at": due.Format(time.RFC3339)})
+		require.Equal(t, http.StatusCreated, status)
+		if title == "done past" {
+			id := result["todo"].(map[string]interface{})["id"].(string)
+			do("PATCH", "/todos/"+id, map[string]bool{"completed": true})
+		}
+	}
+	status, _ := do("POST", "/todos", map[string]string{"title": "no due"})
+	require.Equal(t, http.StatusCreated, status)
+
+	expect := func(match func(due time.Time) bool, skipCompleted bool) []string {
+		want := []string{}
+		for title, due := range dues {
+			if skipCompleted && title == "done past" {
+				continue
+			}
+			if match(due) {
+				want = append(want, title)
+			}
+		}
+		sort.Strings(want)
+		return want
+	}
+
+	t.Run("overdue", func(t *testing.T) {
+		status, result := do("GET", "/todos?overdue=true", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, expect(func(due time.Time) bool { return due.Before(time.Now()) }, true), titles(result))
+	})
+
+	t.Run("due today in user timezone", func(t *testing.T) {
+		status, result := do("GET", "/todos?due_today=true", nil)
+		require.Equal(t, http.StatusOK, status)
+		want := expect(func(due time.Time) bool {
+			return !due.Before(startOfDay) && due.Before(startOfDay.AddDate(0, 0, 1))
+		}, false)
+		assert.Contains(t, want, "start today")
+		assert.Equal(t, want, titles(result))
+	})
+
+	t.Run("due this week", func(t *testing.T) {
+		status, result := do("GET", "/todos?due_this_week=true", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.NotContains(t, titles(result), "far")
+		assert.NotContains(t, titles(result), "no due")
+		assert.Contains(t, titles(result), "start today")
+	})
+
+	t.Run("reminder after due date is rejected", func(t *testing.T) {
+		due := now.Add(time.Hour)
+		status, _ := do("POST", "/todos", map[string]any{
+			"title":     "bad reminder",
+			"due_at":    due.Format(time.RFC3339),
+			"remind_at": due.Add(time.Minute).Format(time.RFC3339),
+		})
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("null clears due date", func(t *testing.T) {
+		due := now.Add(time.Hour)
+		status, result := do("POST", "/todos", map[string]any{
+			"title":     "clear me",
+			"due_at":    due.Format(time.RFC3339),
+			"remind_at": due.Add(-time.Minute).Format(time.RFC3339),
+		})
+		require.Equal(t, http.StatusCreated, status)
+		todo := result["todo"].(map[string]interface{})
+		assert.NotEmpty(t, todo["remind_at"])
+
+		status, result = do("PATCH", "/todos/"+todo["id"].(string), map[string]any{"due_at": nil, "remind_at": nil})
+		require.Equal(t, http.StatusOK, status)
+		updated := result["todo"].(map[string]interface{})
+		assert.NotContains(t, updated, "due_at")
+		assert.NotContains(t, updated, "remind_at")
+	})
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTodosPagination(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	token := loginAs(t, server, "pages@example.com")
+
+	do := func(method, path string, body any) (int, map[string]interface{}) {
+		return doJSON(t, server, token, method, path, body)
+	}
+	titles := func(result map[string]interface{}) []string {
+		var got []string
+		for _, item := range result["todos"].([]interface{}) {
+			got = append(got, item.(map[string]interface{})["title"].(string))
+		}
+		return got
+	}
+
+	for _, title := range []string{"delta", "alpha", "echo", "charlie", "bravo"} {
+		status, result := do("POST", "/todos", map[string]string{"title": title})
+		require.Equal(t, http.StatusCreated, status)
+		if title == "alpha" || title == "echo" {
+			id := result["todo"].(map[string]interface{})["id"].(string)
+			do("PATCH", "/todos/"+id, map[string]bool{"completed": true})
+		}
+	}
+
+	t.Run("sorted pages with next_cursor", func(t *testing.T) {
+		var all []string
+		query := url.Values{"sort": {"title"}, "limit": {"2"}}
+		for {
+			status, result := do("GET", "/todos?"+query.Encode(), nil)
+			require.Equal(t, http.StatusOK, status)
+			all = append(all, titles(result)...)
+
+			cursor, _ := result["next_cursor"].(string)
+			if cursor == "" {
+				break
+			}
+			query.Set("cursor", cursor)
+		}
+		assert.Equal(t, []string{"alpha", "bravo", "charlie", "delta", "echo"}, all)
+	})
+
+	t.Run("descending order", func(t *testing.T) {
+		_, result := do("GET", "/todos?sort=title&order=desc&limit=2", nil)
+		assert.Equal(t, []string{"echo", "delta"}, titles(result))
+	})
+
+	t.Run("filter by completed", func(t *testing.T) {
+		_, result := do("GET", "/todos?sort=title&completed=true", nil)
+		assert.Equal(t, []string{"alpha", "echo"}, titles(result))
+		assert.Nil(t, result["next_cursor"])
+	})
+
+	t.Run("cursor from another sort is rejected", func(t *testing.T) {
+		_, result := do("GET", "/todos?sort=title&limit=1", nil)
+
+		status, _ := do("GET", fmt.Sprintf("/todos?sort=created_at&cursor=%s", result["next_cursor"]), nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("invalid query parameters", func(t *testing.T) {
+		for _, query := range []string{"limit=-1", "limit=101", "sort=priority", "order=up", "created_after=yesterday"} {
+			status, _ := do("GET", "/todos?"+query, nil)
+			assert.Equal(t, http.StatusBadRequest, status, query)
+		}
+	})
+}
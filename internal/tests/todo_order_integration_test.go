@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoPriorityAndOrder(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	token := loginAs(t, server, "order@example.com")
+
+	do := func(method, path string, body any) (int, map[string]interface{}) {
+		return doJSON(t, server, token, method, path, body)
+	}
+	titles := func(query string) []string {
+		status, result := do("GET", "/todos"+query, nil)
+		require.Equal(t, http.StatusOK, status)
+		got := []string{}
+		for _, item := range result["todos"].([]interface{}) {
+			got = append(got, item.(map[string]interface{})["title"].(string))
+		}
+		return got
+	}
+
+	ids := map[string]string{}
+	for _, title := range []string{"first", "second", "third"} {
+		status, result := do("POST", "/todos", map[string]string{"title": title})
+		require.Equal(t, http.StatusCreated, status)
+		todo := result["todo"].(map[string]interface{})
+		assert.Equal(t, "normal", todo["priority"])
+		ids[title] = todo["id"].(string)
+	}
+
+	t.Run("default order is creation order", func(t *testing.T) {
+		assert.Equal(t, []string{"first", "second", "third"}, titles(""))
+	})
+
+	t.Run("move before and after", func(t *testing.T) {
+		status, _ := do("PUT", "/todos/"+ids["third"]+"/move", map[string]string{"before": ids["first"]})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{"third", "first", "second"}, titles(""))
+
+		status, _ = do("PUT", "/todos/"+ids["third"]+"/move", map[string]string{"after": ids["second"]})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{"first", "second", "third"}, titles(""))
+
+		status, _ = do("PUT", "/todos/"+ids["first"]+"/move", map[string]string{"after": ids["second"]})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{"second", "first", "third"}, titles(""))
+		assert.Equal(t, []string{"second"}, titles("?limit=1&sort=position"))
+	})
+
+	t.Run("invalid moves", func(t *testing.T) {
+		status, _ := do("PUT", "/todos/"+ids["first"]+"/move", map[string]string{})
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = do("PUT", "/todos/"+ids["first"]+"/move", map[string]string{"before": ids["first"]})
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = do("PUT", "/todos/"+ids["first"]+"/move", map[string]string{"before": "not-a-uuid"})
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = do("PUT", "/todos/"+ids["first"]+"/move", map[string]string{"after": "00000000-0000-0000-0000-000000000001"})
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("priority can be set and filtered", func(t *testing.T) {
+		status, _ := do("PATCH", "/todos/"+ids["second"], map[string]string{"priority": "urgent"})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{"second"}, titles("?priority=urgent"))
+
+		status, _ = do("PATCH", "/todos/"+ids["second"], map[string]string{"priority": "asap"})
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = do("POST", "/todos", map[string]string{"title": "x", "priority": "asap"})
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
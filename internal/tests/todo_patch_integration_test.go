@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoPatch(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	token := loginAs(t, server, "patch@example.com")
+
+	do := func(method, path, contentType, body string) (int, http.Header, map[string]interface{}) {
+		return doJSONWithHeader(t, server, token, method, path, json.RawMessage(body), http.Header{"Content-Type": {contentType}})
+	}
+
+	status, _, result := do("POST", "/todos", "application/json",
+		`{"title":"report","description":"draft","priority":"high","due_at":"2030-01-10T09:00:00Z"}`)
+	require.Equal(t, http.StatusCreated, status)
+	todoPath := "/todos/" + result["todo"].(map[string]interface{})["id"].(string)
+
+	t.Run("PUT replaces the whole todo", func(t *testing.T) {
+		status, _, _ := do("PUT", todoPath, "application/json", `{"title":"report"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, _, result := do("PUT", todoPath, "application/json", `{"title":"report v2","completed":false,"priority":"normal"}`)
+		require.Equal(t, http.StatusOK, status)
+		todo := result["todo"].(map[string]interface{})
+		assert.Equal(t, "report v2", todo["title"])
+		assert.Equal(t, "", todo["description"])
+		assert.Nil(t, todo["due_at"])
+	})
+
+	t.Run("merge patch", func(t *testing.T) {
+		status, header, result := do("PATCH", todoPath, "application/merge-patch+json", `{"description":"final","due_at":"2030-02-01T10:00:00Z"}`)
+		require.Equal(t, http.StatusOK, status)
+		todo := result["todo"].(map[string]interface{})
+		assert.Equal(t, "report v2", todo["title"])
+		assert.Equal(t, "final", todo["description"])
+		assert.Equal(t, "2030-02-01T10:00:00Z", todo["due_at"])
+		assert.Equal(t, `"3"`, header.Get("ETag"))
+
+		status, _, result = do("PATCH", todoPath, "application/merge-patch+json", `{"due_at":null}`)
+		require.Equal(t, http.StatusOK, status)
+		assert.Nil(t, result["todo"].(map[string]interface{})["due_at"])
+	})
+
+	t.Run("json patch", func(t *testing.T) {
+		status, _, result := do("PATCH", todoPath, "application/json-patch+json",
+			`[{"op":"test","path":"/description","value":"final"},{"op":"replace","path":"/completed","value":true}]`)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, true, result["todo"].(map[string]interface{})["completed"])
+
+		status, _, _ = do("PATCH", todoPath, "application/json-patch+json",
+			`[{"op":"test","path":"/description","value":"draft"},{"op":"replace","path":"/title","value":"lost"}]`)
+		assert.Equal(t, http.StatusConflict, status)
+
+		status, _, _ = do("PATCH", todoPath, "application/json-patch+json", `[{"op":"replace","path":"/owner","value":"x"}]`)
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+
+		status, _, _ = do("PATCH", todoPath, "application/json-patch+json", `{"op":"replace"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("invalid result and unsupported type", func(t *testing.T) {
+		status, _, _ := do("PATCH", todoPath, "application/merge-patch+json", `{"title":null}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, header, _ := do("PATCH", todoPath, "application/json", `{"title":"x"}`)
+		assert.Equal(t, http.StatusUnsupportedMediaType, status)
+		assert.Contains(t, header.Get("Accept-Patch"), "application/merge-patch+json")
+
+		_, result := doJSON(t, server, token, "GET", todoPath, nil)
+		assert.Equal(t, "report v2", result["title"])
+	})
+}
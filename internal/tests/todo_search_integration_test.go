@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchTodos(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	client := server.Client()
+
+	token := loginAs(t, server, "search@example.com")
+
+	for _, todo := range []map[string]string{
+		{"title": "Quarterly report", "description": "collect numbers for the report"},
+		{"title": "Dentist", "description": "bring the insurance report"},
+		{"title": "Water plants", "description": ""},
+		{"title": "<img src=x onerror=alert(1)> urgent", "description": ""},
+	} {
+		status, _ := doJSON(t, server, token, "POST", "/todos", todo)
+		require.Equal(t, http.StatusCreated, status)
+	}
+
+	searchTodos := func(q string) (int, []interface{}) {
+		req, _ := http.NewRequest("GET", server.URL+"/api/v1/todos/search?q="+url.QueryEscape(q), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		results, _ := result["results"].([]interface{})
+		return resp.StatusCode, results
+	}
+
+	t.Run("ranked results with highlights", func(t *testing.T) {
+		status, results := searchTodos("Report")
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, results, 2)
+
+		first := results[0].(map[string]interface{})
+		assert.Equal(t, "Quarterly report", first["todo"].(map[string]interface{})["title"])
+		highlights := first["highlights"].(map[string]interface{})
+		assert.Equal(t, "Quarterly <b>report</b>", highlights["title"])
+		assert.Equal(t, "collect numbers for the <b>report</b>", highlights["description"])
+	})
+
+	t.Run("highlights are escaped", func(t *testing.T) {
+		status, results := searchTodos("urgent")
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, results, 1)
+
+		highlights := results[0].(map[string]interface{})["highlights"].(map[string]interface{})
+		assert.Equal(t, "&lt;img src=x onerror=alert(1)&gt; <b>urgent</b>", highlights["title"])
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		status, results := searchTodos("vacation")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Empty(t, results)
+	})
+
+	t.Run("query without words", func(t *testing.T) {
+		status, _ := searchTodos("?!")
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = searchTodos("")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+}
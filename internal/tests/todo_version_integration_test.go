@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoVersion(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	token := loginAs(t, server, "version@example.com")
+
+	do := func(method, path, ifMatch string, body any) (int, http.Header, map[string]interface{}) {
+		header := http.Header{}
+		if ifMatch != "" {
+			header.Set("If-Match", ifMatch)
+		}
+		return doJSONWithHeader(t, server, token, method, path, body, header)
+	}
+
+	status, _, result := do("POST", "/todos", "", map[string]any{"title": "shared doc"})
+	require.Equal(t, http.StatusCreated, status)
+	todoPath := "/todos/" + result["todo"].(map[string]interface{})["id"].(string)
+
+	status, header, result := do("GET", todoPath, "", nil)
+	require.Equal(t, http.StatusOK, status)
+	etag := header.Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+	assert.Equal(t, float64(1), result["version"])
+
+	t.Run("first writer wins, second gets 412", func(t *testing.T) {
+		status, header, result := do("PATCH", todoPath, etag, map[string]any{"title": "alice"})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, `"2"`, header.Get("ETag"))
+		assert.Equal(t, float64(2), result["todo"].(map[string]interface{})["version"])
+
+		status, _, result = do("PATCH", todoPath, etag, map[string]any{"title": "bob"})
+		assert.Equal(t, http.StatusPreconditionFailed, status)
+		assert.Equal(t, "todo has been modified since it was read", result["error"])
+
+		_, _, result = do("GET", todoPath, "", nil)
+		assert.Equal(t, "alice", result["title"])
+	})
+
+	t.Run("If-Match is optional", func(t *testing.T) {
+		status, header, _ := do("PATCH", todoPath, "", map[string]any{"description": "no check"})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, `"3"`, header.Get("ETag"))
+
+		status, _, _ = do("PATCH", todoPath, "*", map[string]any{"description": "any version"})
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("malformed If-Match", func(t *testing.T) {
+		status, _, _ := do("PATCH", todoPath, "4", map[string]any{"title": "x"})
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _, _ = do("DELETE", todoPath, `W/"4"`, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("delete honors If-Match", func(t *testing.T) {
+		status, _, _ := do("DELETE", todoPath, `"3"`, nil)
+		assert.Equal(t, http.StatusPreconditionFailed, status)
+
+		status, _, _ = do("DELETE", todoPath, `"4"`, nil)
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("move honors If-Match", func(t *testing.T) {
+		ids := map[string]string{}
+		for _, title := range []string{"first", "second"} {
+			status, _, result := do("POST", "/todos", "", map[string]any{"title": title})
+			require.Equal(t, http.StatusCreated, status)
+			ids[title] = result["todo"].(map[string]interface{})["id"].(string)
+		}
+		movePath := "/todos/" + ids["second"] + "/move"
+
+		status, _, _ := do("PUT", movePath, `"2"`, map[string]string{"before": ids["first"]})
+		assert.Equal(t, http.StatusPreconditionFailed, status)
+
+		status, header, _ := do("PUT", movePath, `"1"`, map[string]string{"before": ids["first"]})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, `"2"`, header.Get("ETag"))
+	})
+}
@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoExportImport(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	client := server.Client()
+
+	alice := loginAs(t, server, "export-alice@example.com")
+	bob := loginAs(t, server, "export-bob@example.com")
+
+	raw := func(token, method, path, contentType, body string) (int, http.Header, string) {
+		req, _ := http.NewRequest(method, server.URL+"/api/v1"+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header, string(data)
+	}
+	doAs := func(token, method, path string, body any) (int, map[string]interface{}) {
+		return doJSON(t, server, token, method, path, body)
+	}
+	todosOf := func(token string) map[string]map[string]interface{} {
+		status, result := doAs(token, "GET", "/todos?limit=100", nil)
+		require.Equal(t, http.StatusOK, status)
+		byTitle := map[string]map[string]interface{}{}
+		for _, todo := range result["todos"].([]interface{}) {
+			todo := todo.(map[string]interface{})
+			byTitle[todo["title"].(string)] = todo
+		}
+		return byTitle
+	}
+
+	status, result := doAs(alice, "POST", "/projects", map[string]any{"name": "Work"})
+	require.Equal(t, http.StatusCreated, status)
+	projectID := result["project"].(map[string]interface{})["id"].(string)
+	status, result = doAs(alice, "POST", "/todos", map[string]any{
+		"title": "Quarterly report", "description": "Numbers\nand charts", "priority": "high",
+		"project_id": projectID, "due_at": "2026-07-01T12:00:00Z", "remind_at": "2026-07-01T09:00:00Z",
+	})
+	require.Equal(t, http.StatusCreated, status)
+	reportID := result["todo"].(map[string]interface{})["id"].(string)
+	status, result = doAs(alice, "POST", "/tags", map[string]any{"name": "office"})
+	require.Equal(t, http.StatusCreated, status)
+	tagID := result["tag"].(map[string]interface{})["id"].(string)
+	status, _ = doAs(alice, "PUT", "/todos/"+reportID+"/tags/"+tagID, nil)
+	require.Equal(t, http.StatusNoContent, status)
+	status, result = doAs(alice, "POST", "/todos", map[string]any{"title": "Collect numbers", "parent_id": reportID, "project_id": projectID})
+	require.Equal(t, http.StatusCreated, status)
+	status, _, _ = raw(alice, "PATCH", "/todos/"+result["todo"].(map[string]interface{})["id"].(string), "application/merge-patch+json", `{"completed":true}`)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = doAs(alice, "POST", "/todos", map[string]any{"title": "Buy stamps"})
+	require.Equal(t, http.StatusCreated, status)
+
+	t.Run("export formats", func(t *testing.T) {
+		status, header, body := raw(alice, "GET", "/todos/export?format=csv", "", "")
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "text/csv; charset=utf-8", header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="todos.csv"`, header.Get("Content-Disposition"))
+		lines := strings.Split(strings.TrimSpace(body), "\n")
+		assert.Equal(t, "id,parent_id,title,description,completed,priority,due_at,remind_at,recurrence,project,tags,created_at", lines[0])
+
+		status, _, body = raw(alice, "GET", "/todos/export?format=json", "", "")
+		require.Equal(t, http.StatusOK, status)
+		var items []map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(body), &items))
+		require.Len(t, items, 3)
+		assert.Equal(t, "Quarterly report", items[0]["title"])
+		assert.Equal(t, "Work", items[0]["project"])
+		assert.Equal(t, []interface{}{"office"}, items[0]["tags"])
+		assert.Equal(t, items[0]["id"], items[1]["parent_id"])
+		assert.Equal(t, true, items[1]["completed"])
+
+		status, header, body = raw(alice, "GET", "/todos/export?format=ical", "", "")
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "text/calendar; charset=utf-8", header.Get("Content-Type"))
+		assert.Equal(t, 3, strings.Count(body, "BEGIN:VTODO"))
+		assert.Contains(t, body, "TRIGGER;VALUE=DATE-TIME:20260701T090000Z")
+
+		status, _, body = raw(alice, "GET", "/todos/export?format=todotxt", "", "")
+		require.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "(B) ")
+		assert.Contains(t, body, "Quarterly report +Work @office due:2026-07-01T12:00:00Z\n")
+		assert.Contains(t, body, "x Collect numbers +Work\n")
+
+		status, _, _ = raw(alice, "GET", "/todos/export?format=xlsx", "", "")
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _, _ = raw(alice, "GET", "/todos/export", "", "")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	_, _, exported := raw(alice, "GET", "/todos/export?format=json", "", "")
+
+	t.Run("dry run saves nothing", func(t *testing.T) {
+		status, _, body := raw(bob, "POST", "/todos/import?format=json&dry_run=true", "", exported)
+		require.Equal(t, http.StatusOK, status, body)
+		var result map[string]map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(body), &result))
+		assert.Equal(t, true, result["report"]["dry_run"])
+		assert.Equal(t, float64(3), result["report"]["total"])
+		assert.Equal(t, float64(3), result["report"]["valid"])
+		assert.Equal(t, float64(0), result["report"]["imported"])
+		assert.Empty(t, todosOf(bob))
+
+		status, projects := doAs(bob, "GET", "/projects", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Empty(t, projects["projects"])
+	})
+
+	t.Run("import recreates todos, projects and tags", func(t *testing.T) {
+		status, _, body := raw(bob, "POST", "/todos/import", "application/json", exported)
+		require.Equal(t, http.StatusCreated, status, body)
+		assert.Contains(t, body, `"imported":3`)
+
+		todos := todosOf(bob)
+		require.Len(t, todos, 3)
+		report := todos["Quarterly report"]
+		assert.Equal(t, "high", report["priority"])
+		assert.Equal(t, "Numbers\nand charts", report["description"])
+		assert.Equal(t, "2026-07-01T09:00:00Z", report["remind_at"])
+		assert.NotNil(t, report["project_id"])
+		child := todos["Collect numbers"]
+		assert.Equal(t, report["id"], child["parent_id"])
+		assert.Equal(t, true, child["completed"])
+		assert.Equal(t, report["project_id"], child["project_id"])
+
+		status, tags := doAs(bob, "GET", "/todos/"+report["id"].(string)+"/tags", nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, tags["tags"], 1)
+		assert.Equal(t, "office", tags["tags"].([]interface{})[0].(map[string]interface{})["name"])
+
+		// Повторный импорт находит проект и метку по имени, а не создаёт новые.
+		status, _, _ = raw(bob, "POST", "/todos/import?format=json", "", exported)
+		require.Equal(t, http.StatusCreated, status)
+		status, projects := doAs(bob, "GET", "/projects", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, projects["projects"], 1)
+		status, allTags := doAs(bob, "GET", "/tags", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, allTags["tags"], 1)
+	})
+
+	t.Run("row errors reject the whole file", func(t *testing.T) {
+		before := len(todosOf(alice))
+		csv := "title,priority,due_at,remind_at,parent_id,id\n" +
+			"Fine,low,,,,\n" +
+			",normal,,,,\n" +
+			"Bad priority,asap,,,,\n" +
+			"Remind late,,2026-01-01,2026-02-01,,\n" +
+			"Orphan,,,,missing,\n" +
+			"Child of bad,,,,p1,\n" +
+			"Bad parent,asap,,,,p1\n"
+		status, _, body := raw(alice, "POST", "/todos/import?format=csv", "", csv)
+		require.Equal(t, http.StatusUnprocessableEntity, status, body)
+		var result struct {
+			Report struct {
+				Valid    int
+				Imported int
+				Errors   []struct {
+					Row   int
+					Error string
+				}
+			}
+		}
+		require.NoError(t, json.Unmarshal([]byte(body), &result))
+		assert.Equal(t, 1, result.Report.Valid)
+		assert.Equal(t, 0, result.Report.Imported)
+		rows := []int{}
+		for _, rowErr := range result.Report.Errors {
+			rows = append(rows, rowErr.Row)
+		}
+		assert.Equal(t, []int{3, 4, 5, 6, 7, 8}, rows)
+		assert.Contains(t, result.Report.Errors[3].Error, "parent \"missing\"")
+		assert.Contains(t, result.Report.Errors[4].Error, "parent row 8")
+		assert.Equal(t, before, len(todosOf(alice)))
+
+		// В пробном режиме тот же отчёт приходит с 200.
+		status, _, body = raw(alice, "POST", "/todos/import?format=csv&dry_run=true", "", csv)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"valid":1`)
+	})
+
+	t.Run("format from content type", func(t *testing.T) {
+		ical := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nSUMMARY:From calendar\r\nCATEGORIES:imported\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+		status, _, body := raw(bob, "POST", "/todos/import", "text/calendar", ical)
+		require.Equal(t, http.StatusCreated, status, body)
+		status, _, body = raw(bob, "POST", "/todos/import", "text/plain", "(A) Call plumber @home due:2026-02-01\n")
+		require.Equal(t, http.StatusCreated, status, body)
+
+		todos := todosOf(bob)
+		assert.Contains(t, todos, "From calendar")
+		assert.Equal(t, "urgent", todos["Call plumber"]["priority"])
+		assert.Equal(t, "2026-02-01T00:00:00Z", todos["Call plumber"]["due_at"])
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		status, _, _ := raw(bob, "POST", "/todos/import", "application/pdf", "%PDF")
+		assert.Equal(t, http.StatusUnsupportedMediaType, status)
+		status, _, _ = raw(bob, "POST", "/todos/import?format=json", "", `{"title":"not an array"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _, _ = raw(bob, "POST", "/todos/import?format=csv", "", "name\nx\n")
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _, _ = raw(bob, "POST", "/todos/import?format=yaml", "", "- x")
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _, _ = raw(bob, "POST", "/todos/import?format=todotxt", "", strings.Repeat("x\n", 6<<20))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	})
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrash(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	token := loginAs(t, server, "trash@example.com")
+	otherToken := loginAs(t, server, "trash-other@example.com")
+
+	doAs := func(token, method, path string, body any) (int, map[string]interface{}) {
+		return doJSON(t, server, token, method, path, body)
+	}
+	do := func(method, path string, body any) (int, map[string]interface{}) {
+		return doAs(token, method, path, body)
+	}
+	create := func(body map[string]any) string {
+		status, result := do("POST", "/todos", body)
+		require.Equal(t, http.StatusCreated, status)
+		return result["todo"].(map[string]interface{})["id"].(string)
+	}
+	titles := func(path string) []string {
+		status, result := do("GET", path, nil)
+		require.Equal(t, http.StatusOK, status)
+		got := []string{}
+		for _, item := range result["todos"].([]interface{}) {
+			got = append(got, item.(map[string]interface{})["title"].(string))
+		}
+		return got
+	}
+
+	root := create(map[string]any{"title": "release"})
+	child := create(map[string]any{"title": "changelog", "parent_id": root})
+	create(map[string]any{"title": "groceries"})
+
+	t.Run("delete moves the subtree to the trash", func(t *testing.T) {
+		status, result := do("DELETE", "/todos/"+root+"?subtasks=cascade", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "todo moved to trash", result["message"])
+
+		assert.Equal(t, []string{"groceries"}, titles("/todos?sort=title"))
+		assert.ElementsMatch(t, []string{"release", "changelog"}, titles("/trash"))
+
+		status, _ = do("GET", "/todos/"+root, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		status, result = do("GET", "/trash", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, result["todos"].([]interface{})[0].(map[string]interface{})["deleted_at"])
+	})
+
+	t.Run("restore conflicts", func(t *testing.T) {
+		status, result := do("POST", "/todos/"+child+"/restore", nil)
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, "parent todo is in the trash, restore it first", result["error"])
+
+		status, _ = doAs(otherToken, "POST", "/todos/"+root+"/restore", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Len(t, titles("/trash"), 2)
+	})
+
+	t.Run("restore brings the subtree back", func(t *testing.T) {
+		status, result := do("POST", "/todos/"+root+"/restore", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "release", result["todo"].(map[string]interface{})["title"])
+
+		assert.Equal(t, []string{"changelog", "groceries", "release"}, titles("/todos?sort=title"))
+		assert.Empty(t, titles("/trash"))
+
+		status, result = do("POST", "/todos/"+root+"/restore", nil)
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, "todo is not in the trash", result["error"])
+		status, _ = do("DELETE", "/trash/"+root, nil)
+		assert.Equal(t, http.StatusConflict, status)
+	})
+
+	t.Run("purge deletes for good", func(t *testing.T) {
+		status, _ := do("DELETE", "/todos/"+child, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		status, result := do("DELETE", "/trash/"+child, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "todo permanently deleted", result["message"])
+
+		assert.Empty(t, titles("/trash"))
+		status, _ = do("POST", "/todos/"+child+"/restore", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("project cascade delete trashes its todos", func(t *testing.T) {
+		status, result := do("POST", "/projects", map[string]any{"name": "garden"})
+		require.Equal(t, http.StatusCreated, status)
+		project := result["project"].(map[string]interface{})["id"].(string)
+		planted := create(map[string]any{"title": "tomatoes", "project_id": project})
+
+		status, _ = do("DELETE", "/projects/"+project+"?todos=cascade", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{"tomatoes"}, titles("/trash"))
+
+		status, result = do("POST", "/todos/"+planted+"/restore", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Nil(t, result["todo"].(map[string]interface{})["project_id"])
+	})
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/polzovatel/todo-learning/config"
+	"github.com/polzovatel/todo-learning/internal/auth/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebAuthnPasskeys(t *testing.T) {
+	const origin = "http://localhost:8080"
+	server, _ := setupTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.WebAuthnRPID = "localhost"
+		cfg.WebAuthnRPName = "Todo"
+		cfg.WebAuthnOrigin = origin
+	})
+	defer server.Close()
+
+	client := server.Client()
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	post := func(t *testing.T, path, token string, payload any) (int, map[string]interface{}) {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", server.URL+"/api/v1"+path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+	challengeOf := func(result map[string]interface{}) string {
+		return result["publicKey"].(map[string]interface{})["challenge"].(string)
+	}
+
+	creds := map[string]string{"email": "passkey@example.com", "password": "Test123!"}
+	post(t, "/register", "", creds)
+	_, login := post(t, "/login", "", creds)
+	token := login["accessToken"].(string)
+
+	authenticator := webauthn.NewVirtualAuthenticator("localhost", origin)
+	var credentialID []byte
+
+	finishLogin := func(t *testing.T, challenge string) (int, map[string]interface{}) {
+		clientData, authData, signature, err := authenticator.Assert(credentialID, challenge)
+		require.NoError(t, err)
+		return post(t, "/webauthn/login/finish", "", map[string]any{
+			"rawId": b64(credentialID),
+			"response": map[string]string{
+				"clientDataJSON":    b64(clientData),
+				"authenticatorData": b64(authData),
+				"signature":         b64(signature),
+			},
+		})
+	}
+
+	t.Run("registration requires authentication", func(t *testing.T) {
+		status, _ := post(t, "/webauthn/register/begin", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("register passkey", func(t *testing.T) {
+		status, options := post(t, "/webauthn/register/begin", token, nil)
+		require.Equal(t, http.StatusOK, status)
+		publicKey := options["publicKey"].(map[string]interface{})
+		assert.Equal(t, "none", publicKey["attestation"])
+		assert.Equal(t, "localhost", publicKey["rp"].(map[string]interface{})["id"])
+
+		var clientData, attestation []byte
+		var err error
+		credentialID, clientData, attestation, err = authenticator.Register(challengeOf(options))
+		require.NoError(t, err)
+
+		status, result := post(t, "/webauthn/register/finish", token, map[string]any{
+			"rawId": b64(credentialID),
+			"response": map[string]string{
+				"clientDataJSON":    b64(clientData),
+				"attestationObject": b64(attestation),
+			},
+		})
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, b64(credentialID), result["credential"].(map[string]interface{})["credential_id"])
+	})
+
+	t.Run("registration challenge cannot be replayed", func(t *testing.T) {
+		_, options := post(t, "/webauthn/register/begin", token, nil)
+		challenge := challengeOf(options)
+		for _, want := range []int{http.StatusCreated, http.StatusBadRequest} {
+			id, clientData, attestation, err := authenticator.Register(challenge)
+			require.NoError(t, err)
+			status, _ := post(t, "/webauthn/register/finish", token, map[string]any{
+				"rawId": b64(id),
+				"response": map[string]string{
+					"clientDataJSON":    b64(clientData),
+					"attestationObject": b64(attestation),
+				},
+			})
+			assert.Equal(t, want, status)
+		}
+	})
+
+	t.Run("list credentials", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/api/v1/webauthn/credentials", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		assert.Len(t, result["credentials"], 2)
+	})
+
+	t.Run("login with passkey issues tokens", func(t *testing.T) {
+		status, options := post(t, "/webauthn/login/begin", "", map[string]string{"email": creds["email"]})
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, options["publicKey"].(map[string]interface{})["allowCredentials"], 2)
+
+		status, result := finishLogin(t, challengeOf(options))
+		require.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, result["accessToken"])
+		assert.NotEmpty(t, result["refreshToken"])
+
+		req, _ := http.NewRequest("GET", server.URL+"/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer "+result["accessToken"].(string))
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("discoverable login without email", func(t *testing.T) {
+		_, options := post(t, "/webauthn/login/begin", "", nil)
+
+		status, _ := finishLogin(t, challengeOf(options))
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("unknown email gets options without credentials", func(t *testing.T) {
+		status, options := post(t, "/webauthn/login/begin", "", map[string]string{"email": "nobody@example.com"})
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Empty(t, options["publicKey"].(map[string]interface{})["allowCredentials"])
+	})
+
+	t.Run("unknown challenge is rejected", func(t *testing.T) {
+		challenge, _ := webauthn.NewChallenge()
+
+		status, _ := finishLogin(t, challenge)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("cloned authenticator is rejected", func(t *testing.T) {
+		authenticator.SetSignCount(credentialID, 0)
+		_, options := post(t, "/webauthn/login/begin", "", nil)
+
+		status, _ := finishLogin(t, challengeOf(options))
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaces(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	ownerToken := loginAs(t, server, "ws-owner@example.com")
+	ownerID := userIDOf(t, server, ownerToken)
+	memberToken := loginAs(t, server, "ws-member@example.com")
+	memberID := userIDOf(t, server, memberToken)
+	outsiderToken := loginAs(t, server, "ws-outsider@example.com")
+
+	doIn := func(token, workspace, method, path string, body any) (int, map[string]interface{}) {
+		header := http.Header{}
+		if workspace != "" {
+			header.Set("X-Workspace-ID", workspace)
+		}
+		status, _, result := doJSONWithHeader(t, server, token, method, path, body, header)
+		return status, result
+	}
+	titles := func(token, workspace string) []string {
+		status, result := doIn(token, workspace, "GET", "/todos?sort=title", nil)
+		require.Equal(t, http.StatusOK, status)
+		got := []string{}
+		for _, item := range result["todos"].([]interface{}) {
+			got = append(got, item.(map[string]interface{})["title"].(string))
+		}
+		return got
+	}
+
+	status, result := doIn(ownerToken, "", "POST", "/workspaces", map[string]any{"name": "Team"})
+	require.Equal(t, http.StatusCreated, status)
+	team := result["workspace"].(map[string]interface{})["id"].(string)
+
+	status, result = doIn(ownerToken, "", "POST", "/todos", map[string]any{"title": "private"})
+	require.Equal(t, http.StatusCreated, status)
+	privateID := result["todo"].(map[string]interface{})["id"].(string)
+	status, result = doIn(ownerToken, team, "POST", "/todos", map[string]any{"title": "roadmap"})
+	require.Equal(t, http.StatusCreated, status)
+	roadmapID := result["todo"].(map[string]interface{})["id"].(string)
+	assert.Equal(t, team, result["todo"].(map[string]interface{})["workspace_id"])
+
+	t.Run("lists workspaces with personal first", func(t *testing.T) {
+		status, result := doIn(ownerToken, "", "GET", "/workspaces", nil)
+		require.Equal(t, http.StatusOK, status)
+		workspaces := result["workspaces"].([]interface{})
+		require.Len(t, workspaces, 2)
+		personal := workspaces[0].(map[string]interface{})
+		assert.Equal(t, ownerID, personal["id"])
+		assert.Equal(t, true, personal["personal"])
+		assert.Equal(t, "owner", personal["role"])
+		assert.Equal(t, team, workspaces[1].(map[string]interface{})["id"])
+	})
+
+	t.Run("todos are isolated per workspace", func(t *testing.T) {
+		assert.Equal(t, []string{"private"}, titles(ownerToken, ""))
+		assert.Equal(t, []string{"roadmap"}, titles(ownerToken, team))
+
+		status, _ := doIn(ownerToken, "", "GET", "/todos/"+roadmapID, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		status, _ = doIn(ownerToken, team, "GET", "/todos/"+privateID, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("tags are isolated per workspace", func(t *testing.T) {
+		status, result := doIn(ownerToken, "", "POST", "/tags", map[string]any{"name": "urgent"})
+		require.Equal(t, http.StatusCreated, status)
+		privateTag := result["tag"].(map[string]interface{})["id"].(string)
+
+		status, result = doIn(ownerToken, team, "GET", "/tags", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Empty(t, result["tags"])
+		status, _ = doIn(ownerToken, team, "PUT", "/todos/"+roadmapID+"/tags/"+privateTag, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		status, _ = doIn(ownerToken, team, "DELETE", "/tags/"+privateTag, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+
+		status, _ = doIn(ownerToken, team, "POST", "/tags", map[string]any{"name": "urgent"})
+		assert.Equal(t, http.StatusCreated, status)
+	})
+
+	t.Run("non-members are rejected", func(t *testing.T) {
+		status, _ := doIn(outsiderToken, team, "GET", "/todos", nil)
+		assert.Equal(t, http.StatusForbidden, status)
+		status, _ = doIn(outsiderToken, ownerID, "GET", "/todos", nil)
+		assert.Equal(t, http.StatusForbidden, status)
+		status, _ = doIn(outsiderToken, "", "GET", "/workspaces/"+team, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		status, _ = doIn(outsiderToken, "", "POST", "/workspaces/"+team+"/switch", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Empty(t, titles(outsiderToken, ""))
+	})
+
+	t.Run("members see the shared workspace", func(t *testing.T) {
+		status, _ := doIn(memberToken, "", "POST", "/workspaces/"+team+"/members", map[string]any{"email": "ws-outsider@example.com", "role": "member"})
+		assert.Equal(t, http.StatusNotFound, status)
+
+		status, result := doIn(ownerToken, "", "POST", "/workspaces/"+team+"/members", map[string]any{"email": "ws-member@example.com", "role": "member"})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "member", result["member"].(map[string]interface{})["role"])
+
+		// Членство открывает пространство, но доступ к задачам по-прежнему дают владение и шаринг.
+		assert.Empty(t, titles(memberToken, team))
+		status, _ = doIn(memberToken, team, "GET", "/todos/"+roadmapID, nil)
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, _ = doIn(memberToken, "", "POST", "/workspaces/"+team+"/members", map[string]any{"email": "ws-outsider@example.com", "role": "member"})
+		assert.Equal(t, http.StatusForbidden, status)
+		status, _ = doIn(memberToken, "", "PUT", "/workspaces/"+team, map[string]any{"name": "Mine"})
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, result = doIn(ownerToken, "", "GET", "/workspaces/"+team+"/members", nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, result["members"], 2)
+	})
+
+	t.Run("switch issues a token bound to the workspace", func(t *testing.T) {
+		status, result := doIn(ownerToken, "", "POST", "/workspaces/"+team+"/switch", nil)
+		require.Equal(t, http.StatusOK, status)
+		teamToken := result["accessToken"].(string)
+		require.NotEmpty(t, teamToken)
+
+		assert.Equal(t, []string{"roadmap"}, titles(teamToken, ""))
+		// Заголовок важнее утверждения в токене.
+		assert.Equal(t, []string{"private"}, titles(teamToken, ownerID))
+	})
+
+	t.Run("personal workspace and last owner are protected", func(t *testing.T) {
+		status, _ := doIn(ownerToken, "", "DELETE", "/workspaces/"+ownerID, nil)
+		assert.Equal(t, http.StatusConflict, status)
+		status, _ = doIn(ownerToken, "", "DELETE", "/workspaces/"+team+"/members/"+ownerID, nil)
+		assert.Equal(t, http.StatusConflict, status)
+		status, _ = doIn(ownerToken, "", "POST", "/workspaces/"+team+"/members", map[string]any{"email": "ws-owner@example.com", "role": "admin"})
+		assert.Equal(t, http.StatusConflict, status)
+	})
+
+	t.Run("members can leave and deleted workspaces take their todos", func(t *testing.T) {
+		status, _ := doIn(memberToken, "", "DELETE", "/workspaces/"+team+"/members/"+memberID, nil)
+		require.Equal(t, http.StatusOK, status)
+		status, _ = doIn(memberToken, team, "GET", "/todos", nil)
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, _ = doIn(ownerToken, "", "DELETE", "/workspaces/"+team, nil)
+		require.Equal(t, http.StatusOK, status)
+		status, _ = doIn(ownerToken, team, "GET", "/todos", nil)
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, []string{"private"}, titles(ownerToken, ""))
+	})
+}
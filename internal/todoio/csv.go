@@ -0,0 +1,167 @@
+package todoio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+)
+
+// csvColumns — колонки экспорта. При импорте обязателен только title, порядок колонок
+// любой, неизвестные колонки пропускаются.
+var csvColumns = []string{"id", "parent_id", "title", "description", "completed", "priority", "due_at", "remind_at", "recurrence", "project", "tags", "created_at"}
+
+// csvTagSeparator разделяет метки в колонке tags.
+const csvTagSeparator = ";"
+
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+// NewCSVEncoder пишет задачи строками CSV с заголовком csvColumns.
+func NewCSVEncoder(w io.Writer) Encoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	return e.w.Write(csvColumns)
+}
+
+func (e *csvEncoder) Encode(item Item) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	err := e.w.Write([]string{
+		item.ID,
+		item.ParentID,
+		item.Title,
+		item.Description,
+		strconv.FormatBool(item.Completed),
+		item.Priority,
+		formatTime(item.DueAt),
+		formatTime(item.RemindAt),
+		item.Recurrence,
+		item.Project,
+		strings.Join(item.Tags, csvTagSeparator),
+		formatTime(item.CreatedAt),
+	})
+	if err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) Close() error {
+	// Пустой экспорт всё равно содержит заголовок.
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// DecodeCSV читает CSV с заголовком в первой строке.
+func DecodeCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty file", domain.ErrInvalidImport)
+		}
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	columns := headerColumns(header)
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("%w: no title column", domain.ErrInvalidImport)
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, Row{Number: parseErr.StartLine, Err: parseErr.Err})
+				continue
+			}
+			return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+		}
+		line, _ := reader.FieldPos(0)
+		item, err := csvItem(record, columns)
+		rows = append(rows, Row{Number: line, Item: item, Err: err})
+	}
+}
+
+// headerColumns сопоставляет колонкам заголовка их номера; имена без учёта регистра,
+// BOM в начале файла отбрасывается.
+func headerColumns(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	return columns
+}
+
+func csvItem(record []string, columns map[string]int) (Item, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	item := Item{
+		ID:          field("id"),
+		ParentID:    field("parent_id"),
+		Title:       field("title"),
+		Description: field("description"),
+		Priority:    strings.ToLower(field("priority")),
+		Recurrence:  field("recurrence"),
+		Project:     field("project"),
+	}
+	if value := field("completed"); value != "" {
+		completed, err := strconv.ParseBool(value)
+		if err != nil {
+			return item, fmt.Errorf("invalid completed %q", value)
+		}
+		item.Completed = completed
+	}
+	for _, tag := range strings.Split(field("tags"), csvTagSeparator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			item.Tags = append(item.Tags, tag)
+		}
+	}
+	var err error
+	if item.DueAt, err = parseTime(field("due_at")); err != nil {
+		return item, fmt.Errorf("due_at: %w", err)
+	}
+	if item.RemindAt, err = parseTime(field("remind_at")); err != nil {
+		return item, fmt.Errorf("remind_at: %w", err)
+	}
+	if item.CreatedAt, err = parseTime(field("created_at")); err != nil {
+		return item, fmt.Errorf("created_at: %w", err)
+	}
+	return item, nil
+}
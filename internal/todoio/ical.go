@@ -0,0 +1,460 @@
+package todoio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+)
+
+const (
+	icalProdID = "-//todo-learning//todos//EN"
+	// icalProject — нестандартное свойство с именем проекта задачи.
+	icalProject = "X-PROJECT"
+
+	icalDateTime = "20060102T150405Z"
+	icalLocal    = "20060102T150405"
+	icalDate     = "20060102"
+)
+
+type icalEncoder struct {
+	w       io.Writer
+	started bool
+	now     time.Time
+}
+
+// NewICalEncoder пишет задачи компонентами VTODO одного VCALENDAR. Напоминание
+// становится VALARM с абсолютным TRIGGER, метки — CATEGORIES, родитель — RELATED-TO.
+func NewICalEncoder(w io.Writer) Encoder {
+	return &icalEncoder{w: w, now: time.Now().UTC()}
+}
+
+func (e *icalEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	return e.lines(
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:"+icalProdID,
+	)
+}
+
+func (e *icalEncoder) Encode(item Item) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	lines := []string{"BEGIN:VTODO", "DTSTAMP:" + e.now.Format(icalDateTime)}
+	if item.ID != "" {
+		lines = append(lines, "UID:"+icalEscape(item.ID))
+	}
+	if item.CreatedAt != nil {
+		lines = append(lines, "CREATED:"+item.CreatedAt.UTC().Format(icalDateTime))
+	}
+	lines = append(lines, "SUMMARY:"+icalEscape(item.Title))
+	if item.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+icalEscape(item.Description))
+	}
+	if item.Completed {
+		lines = append(lines, "STATUS:COMPLETED")
+	} else {
+		lines = append(lines, "STATUS:NEEDS-ACTION")
+	}
+	if priority := icalPriority(item.Priority); priority != 0 {
+		lines = append(lines, "PRIORITY:"+strconv.Itoa(priority))
+	}
+	if item.DueAt != nil {
+		lines = append(lines, "DUE:"+item.DueAt.UTC().Format(icalDateTime))
+	}
+	if item.Recurrence != "" {
+		lines = append(lines, "RRULE:"+item.Recurrence)
+	}
+	if item.ParentID != "" {
+		lines = append(lines, "RELATED-TO;RELTYPE=PARENT:"+icalEscape(item.ParentID))
+	}
+	if item.Project != "" {
+		lines = append(lines, icalProject+":"+icalEscape(item.Project))
+	}
+	if len(item.Tags) > 0 {
+		escaped := make([]string, len(item.Tags))
+		for i, tag := range item.Tags {
+			escaped[i] = icalEscape(tag)
+		}
+		lines = append(lines, "CATEGORIES:"+strings.Join(escaped, ","))
+	}
+	if item.RemindAt != nil {
+		lines = append(lines,
+			"BEGIN:VALARM",
+			"ACTION:DISPLAY",
+			"DESCRIPTION:"+icalEscape(item.Title),
+			"TRIGGER;VALUE=DATE-TIME:"+item.RemindAt.UTC().Format(icalDateTime),
+			"END:VALARM",
+		)
+	}
+	lines = append(lines, "END:VTODO")
+	return e.lines(lines...)
+}
+
+func (e *icalEncoder) Close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	return e.lines("END:VCALENDAR")
+}
+
+// lines пишет строки содержимого, складывая длинные по 75 октетов (RFC 5545, 3.1).
+func (e *icalEncoder) lines(lines ...string) error {
+	var b strings.Builder
+	for _, line := range lines {
+		limit := 75
+		for len(line) > limit {
+			cut := limit
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			b.WriteString(line[:cut])
+			b.WriteString("\r\n ")
+			line = line[cut:]
+			// Продолжение начинается с пробела, он тоже занимает октет.
+			limit = 74
+		}
+		b.WriteString(line)
+		b.WriteString("\r\n")
+	}
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+// icalPriority переводит приоритет в шкалу PRIORITY: 1 — самый срочный, 9 — наименее.
+func icalPriority(priority string) int {
+	switch priority {
+	case "urgent":
+		return 1
+	case "high":
+		return 3
+	case "normal":
+		return 5
+	case "low":
+		return 9
+	}
+	return 0
+}
+
+func priorityFromICal(value int) string {
+	switch {
+	case value == 0:
+		return ""
+	case value <= 2:
+		return "urgent"
+	case value <= 4:
+		return "high"
+	case value == 5:
+		return "normal"
+	default:
+		return "low"
+	}
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func icalEscape(text string) string {
+	return icalEscaper.Replace(text)
+}
+
+func icalUnescape(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			i++
+			switch text[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(text[i])
+			}
+			continue
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
+
+// splitEscaped делит значение по запятым, кроме экранированных.
+func splitEscaped(value string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// icalProperty — строка содержимого: имя, параметры и значение.
+type icalProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func parseProperty(line string) (icalProperty, error) {
+	// Двоеточие внутри кавычек относится к значению параметра.
+	colon := -1
+	quoted := false
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return icalProperty{}, fmt.Errorf("malformed line %q", line)
+	}
+	parts := strings.Split(line[:colon], ";")
+	prop := icalProperty{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return prop, nil
+}
+
+// icalTime разбирает DATE-TIME в UTC, с TZID или «плавающее» (как UTC) и DATE (полночь UTC).
+func icalTime(prop icalProperty) (time.Time, error) {
+	value := prop.value
+	if prop.params["VALUE"] == "DATE" || len(value) == len(icalDate) {
+		return time.Parse(icalDate, value)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(icalDateTime, value)
+	}
+	location := time.UTC
+	if tzid := prop.params["TZID"]; tzid != "" {
+		loc, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		location = loc
+	}
+	return time.ParseInLocation(icalLocal, value, location)
+}
+
+// icalDuration разбирает DURATION вида [+-]P[nW][nD][T[nH][nM][nS]].
+func icalDuration(value string) (time.Duration, error) {
+	sign := time.Duration(1)
+	rest := value
+	switch {
+	case strings.HasPrefix(rest, "-"):
+		sign, rest = -1, rest[1:]
+	case strings.HasPrefix(rest, "+"):
+		rest = rest[1:]
+	}
+	if !strings.HasPrefix(rest, "P") || len(rest) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	rest = rest[1:]
+
+	var total time.Duration
+	inTime := false
+	number := ""
+	for _, r := range rest {
+		switch {
+		case r == 'T':
+			inTime = true
+		case r >= '0' && r <= '9':
+			number += string(r)
+		default:
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			number = ""
+			unit := map[rune]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+			if inTime {
+				unit = map[rune]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+			}
+			if _, ok := unit[r]; !ok {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			total += time.Duration(n) * unit[r]
+		}
+	}
+	if number != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return sign * total, nil
+}
+
+// icalTodo собирает задачу из свойств VTODO и его VALARM.
+type icalTodo struct {
+	item    Item
+	trigger *icalProperty
+	err     error
+}
+
+func (t *icalTodo) set(prop icalProperty) {
+	if t.err != nil {
+		return
+	}
+	var err error
+	switch prop.name {
+	case "UID":
+		t.item.ID = icalUnescape(prop.value)
+	case "SUMMARY":
+		t.item.Title = icalUnescape(prop.value)
+	case "DESCRIPTION":
+		t.item.Description = icalUnescape(prop.value)
+	case "STATUS":
+		t.item.Completed = strings.EqualFold(prop.value, "COMPLETED")
+	case "COMPLETED":
+		t.item.Completed = true
+	case "PRIORITY":
+		var priority int
+		if priority, err = strconv.Atoi(prop.value); err == nil && (priority < 0 || priority > 9) {
+			err = fmt.Errorf("PRIORITY %d is out of range", priority)
+		}
+		t.item.Priority = priorityFromICal(priority)
+	case "DUE":
+		var due time.Time
+		due, err = icalTime(prop)
+		t.item.DueAt = &due
+	case "CREATED":
+		var created time.Time
+		created, err = icalTime(prop)
+		t.item.CreatedAt = &created
+	case "RRULE":
+		t.item.Recurrence = prop.value
+	case "RELATED-TO":
+		if reltype := prop.params["RELTYPE"]; reltype == "" || strings.EqualFold(reltype, "PARENT") {
+			t.item.ParentID = icalUnescape(prop.value)
+		}
+	case "CATEGORIES":
+		for _, tag := range splitEscaped(prop.value) {
+			if tag = strings.TrimSpace(icalUnescape(tag)); tag != "" {
+				t.item.Tags = append(t.item.Tags, tag)
+			}
+		}
+	case icalProject:
+		t.item.Project = icalUnescape(prop.value)
+	}
+	if err != nil {
+		t.err = fmt.Errorf("%s: %w", prop.name, err)
+	}
+}
+
+// finish считает время напоминания: TRIGGER задан моментом или смещением от DUE.
+func (t *icalTodo) finish() Row {
+	if t.err == nil && t.trigger != nil {
+		if t.trigger.params["VALUE"] == "DATE-TIME" {
+			remind, err := icalTime(*t.trigger)
+			if err != nil {
+				t.err = fmt.Errorf("TRIGGER: %w", err)
+			}
+			t.item.RemindAt = &remind
+		} else if offset, err := icalDuration(t.trigger.value); err != nil {
+			t.err = fmt.Errorf("TRIGGER: %w", err)
+		} else if t.item.DueAt == nil {
+			t.err = fmt.Errorf("TRIGGER: relative alarm needs DUE")
+		} else {
+			remind := t.item.DueAt.Add(offset)
+			t.item.RemindAt = &remind
+		}
+	}
+	return Row{Item: t.item, Err: t.err}
+}
+
+// DecodeICal читает VTODO из VCALENDAR; остальные компоненты (VEVENT, VTIMEZONE и
+// другие) пропускаются. Из будильников берётся первый.
+func DecodeICal(r io.Reader) ([]Row, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+
+	var rows []Row
+	var stack []string
+	var todo *icalTodo
+	for _, line := range lines {
+		prop, err := parseProperty(line)
+		if err != nil {
+			if todo != nil {
+				todo.err = err
+				continue
+			}
+			return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+		}
+		switch prop.name {
+		case "BEGIN":
+			component := strings.ToUpper(prop.value)
+			if len(stack) == 0 && component != "VCALENDAR" {
+				return nil, fmt.Errorf("%w: expected BEGIN:VCALENDAR", domain.ErrInvalidImport)
+			}
+			stack = append(stack, component)
+			if component == "VTODO" && len(stack) == 2 {
+				todo = &icalTodo{}
+			}
+			continue
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1] != strings.ToUpper(prop.value) {
+				return nil, fmt.Errorf("%w: unexpected END:%s", domain.ErrInvalidImport, prop.value)
+			}
+			stack = stack[:len(stack)-1]
+			if todo != nil && len(stack) == 1 {
+				row := todo.finish()
+				row.Number = len(rows) + 1
+				rows = append(rows, row)
+				todo = nil
+			}
+			continue
+		}
+		if todo == nil {
+			continue
+		}
+		switch {
+		case len(stack) == 2:
+			todo.set(prop)
+		case len(stack) == 3 && stack[2] == "VALARM" && prop.name == "TRIGGER" && todo.trigger == nil:
+			todo.trigger = &prop
+		}
+	}
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("%w: missing END:%s", domain.ErrInvalidImport, stack[len(stack)-1])
+	}
+	if rows == nil && len(lines) == 0 {
+		return nil, fmt.Errorf("%w: empty file", domain.ErrInvalidImport)
+	}
+	return rows, nil
+}
+
+// unfold склеивает сложенные строки: продолжение начинается с пробела или табуляции.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,74 @@
+package todoio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+)
+
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+// NewJSONEncoder пишет задачи массивом JSON, по одной задаче на строку.
+func NewJSONEncoder(w io.Writer) Encoder {
+	return &jsonEncoder{w: w}
+}
+
+func (e *jsonEncoder) Encode(item Item) error {
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	prefix := ",\n"
+	if e.count == 0 {
+		prefix = "[\n"
+	}
+	e.count++
+	if _, err := io.WriteString(e.w, prefix); err != nil {
+		return err
+	}
+	_, err = e.w.Write(raw)
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// DecodeJSON читает массив задач в виде Item, элементы разбираются по одному.
+func DecodeJSON(r io.Reader) ([]Row, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("%w: expected a JSON array of todos", domain.ErrInvalidImport)
+	}
+
+	var rows []Row
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+		}
+		row := Row{Number: len(rows) + 1}
+		if err := json.Unmarshal(raw, &row.Item); err != nil {
+			row.Err = err
+		}
+		rows = append(rows, row)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,144 @@
+// Package todoio переводит задачи в форматы обмена и обратно: CSV, JSON, iCalendar
+// (VTODO из RFC 5545) и todo.txt, а также читает резервные копии Todoist и Trello.
+package todoio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+)
+
+// Item — задача в виде, общем для всех форматов. Проект и метки передаются именами,
+// а ID и ParentID — внешние идентификаторы, которые связывают подзадачи с родителем
+// внутри файла.
+type Item struct {
+	ID          string     `json:"id,omitempty"`
+	ParentID    string     `json:"parent_id,omitempty"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Completed   bool       `json:"completed"`
+	Priority    string     `json:"priority,omitempty"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	RemindAt    *time.Time `json:"remind_at,omitempty"`
+	Recurrence  string     `json:"recurrence,omitempty"`
+	Project     string     `json:"project,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+}
+
+// Row — задача, прочитанная из файла, или ошибка её разбора.
+type Row struct {
+	// Number — номер строки файла для CSV и todo.txt и порядковый номер задачи
+	// (с 1) для JSON, iCalendar и ZIP-архивов.
+	Number int
+	Item   Item
+	Err    error
+}
+
+// Encoder пишет задачи по одной, не держа весь файл в памяти.
+type Encoder interface {
+	Encode(item Item) error
+	// Close дописывает окончание файла; сам writer не закрывается.
+	Close() error
+}
+
+// Format — формат обмена.
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	NewEncoder  func(w io.Writer) Encoder
+	// Decode читает все задачи файла. Ошибка отдельной задачи попадает в Row.Err, а
+	// ошибка, после которой файл не прочитать, возвращается с domain.ErrInvalidImport.
+	Decode func(r io.Reader) ([]Row, error)
+}
+
+// Имена форматов в параметре format.
+const (
+	FormatCSV     = "csv"
+	FormatJSON    = "json"
+	FormatICal    = "ical"
+	FormatTodoTxt = "todotxt"
+)
+
+var formats = map[string]Format{
+	FormatCSV:     {Name: FormatCSV, ContentType: "text/csv", Extension: "csv", NewEncoder: NewCSVEncoder, Decode: DecodeCSV},
+	FormatJSON:    {Name: FormatJSON, ContentType: "application/json", Extension: "json", NewEncoder: NewJSONEncoder, Decode: DecodeJSON},
+	FormatICal:    {Name: FormatICal, ContentType: "text/calendar", Extension: "ics", NewEncoder: NewICalEncoder, Decode: DecodeICal},
+	FormatTodoTxt: {Name: FormatTodoTxt, ContentType: "text/plain", Extension: "txt", NewEncoder: NewTodoTxtEncoder, Decode: DecodeTodoTxt},
+}
+
+// Lookup возвращает формат по имени; неизвестное имя — domain.ErrUnsupportedFormat.
+func Lookup(name string) (Format, error) {
+	format, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return Format{}, domain.ErrUnsupportedFormat
+	}
+	return format, nil
+}
+
+// ForContentType подбирает формат по Content-Type тела запроса.
+func ForContentType(contentType string) (Format, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return Format{}, domain.ErrUnsupportedFormat
+	}
+	for _, format := range formats {
+		if format.ContentType == mediaType {
+			return format, nil
+		}
+	}
+	return Format{}, domain.ErrUnsupportedFormat
+}
+
+// Source — сервис, из резервной копии которого импортируются задачи. Вид файла (JSON,
+// CSV или ZIP) Decode определяет по содержимому.
+type Source struct {
+	Name   string
+	Decode func(r io.Reader) ([]Row, error)
+}
+
+// Имена сервисов в параметре source.
+const (
+	SourceTodoist = "todoist"
+	SourceTrello  = "trello"
+)
+
+var sources = map[string]Source{
+	SourceTodoist: {Name: SourceTodoist, Decode: DecodeTodoist},
+	SourceTrello:  {Name: SourceTrello, Decode: DecodeTrello},
+}
+
+// LookupSource возвращает сервис по имени; неизвестное имя — domain.ErrUnsupportedSource.
+func LookupSource(name string) (Source, error) {
+	source, ok := sources[strings.ToLower(name)]
+	if !ok {
+		return Source{}, domain.ErrUnsupportedSource
+	}
+	return source, nil
+}
+
+// looksLikeJSON сообщает, начинается ли data с объекта или массива JSON.
+func looksLikeJSON(data []byte) bool {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\ufeff")), " \t\r\n")
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// parseTime принимает RFC 3339 и дату без времени (полночь UTC).
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, value); err != nil {
+			return nil, fmt.Errorf("invalid time %q, use RFC 3339 or YYYY-MM-DD", value)
+		}
+	}
+	return &t, nil
+}
@@ -0,0 +1,441 @@
+package todoio_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+	"github.com/polzovatel/todo-learning/internal/todoio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func timePtr(value string) *time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func sampleItems() []todoio.Item {
+	return []todoio.Item{
+		{
+			ID:          "a1",
+			Title:       "Plan trip; book hotel, flights",
+			Description: "Line one\nLine two with a very long tail that will certainly need folding in iCalendar output",
+			Priority:    "high",
+			DueAt:       timePtr("2026-05-01T09:30:00Z"),
+			RemindAt:    timePtr("2026-05-01T09:00:00Z"),
+			Recurrence:  "FREQ=WEEKLY;BYDAY=FR",
+			Project:     "Travel",
+			Tags:        []string{"family", "summer"},
+			CreatedAt:   timePtr("2026-04-01T10:00:00Z"),
+		},
+		{ID: "a2", ParentID: "a1", Title: "Pack bags", Completed: true, Priority: "urgent", Project: "Travel"},
+		{ID: "a3", Title: "Water plants", Priority: "normal"},
+	}
+}
+
+func encode(t *testing.T, format todoio.Format, items []todoio.Item) string {
+	var buf bytes.Buffer
+	encoder := format.NewEncoder(&buf)
+	for _, item := range items {
+		require.NoError(t, encoder.Encode(item))
+	}
+	require.NoError(t, encoder.Close())
+	return buf.String()
+}
+
+func decoded(t *testing.T, format todoio.Format, data string) []todoio.Item {
+	rows, err := format.Decode(strings.NewReader(data))
+	require.NoError(t, err)
+	items := make([]todoio.Item, len(rows))
+	for i, row := range rows {
+		require.NoError(t, row.Err, "row %d", row.Number)
+		items[i] = row.Item
+	}
+	return items
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, name := range []string{todoio.FormatCSV, todoio.FormatJSON, todoio.FormatICal} {
+		t.Run(name, func(t *testing.T) {
+			format, err := todoio.Lookup(name)
+			require.NoError(t, err)
+			items := decoded(t, format, encode(t, format, sampleItems()))
+			assert.Equal(t, sampleItems(), items)
+		})
+	}
+
+	t.Run(todoio.FormatTodoTxt, func(t *testing.T) {
+		format, err := todoio.Lookup(todoio.FormatTodoTxt)
+		require.NoError(t, err)
+		data := encode(t, format, sampleItems())
+		assert.Equal(t, "(B) 2026-04-01 Plan trip; book hotel, flights +Travel @family @summer due:2026-05-01T09:30:00Z\n"+
+			"x Pack bags +Travel pri:A\n"+
+			"Water plants\n", data)
+
+		items := decoded(t, format, data)
+		require.Len(t, items, 3)
+		assert.Equal(t, "Plan trip; book hotel, flights", items[0].Title)
+		assert.Equal(t, "high", items[0].Priority)
+		assert.Equal(t, []string{"family", "summer"}, items[0].Tags)
+		assert.Equal(t, sampleItems()[0].DueAt, items[0].DueAt)
+		assert.Equal(t, sampleItems()[0].CreatedAt.Truncate(24*time.Hour), *items[0].CreatedAt)
+		assert.Equal(t, todoio.Item{Title: "Pack bags", Completed: true, Priority: "urgent", Project: "Travel"}, items[1])
+		assert.Equal(t, todoio.Item{Title: "Water plants"}, items[2])
+	})
+
+	t.Run("empty export", func(t *testing.T) {
+		for _, name := range []string{todoio.FormatCSV, todoio.FormatJSON, todoio.FormatICal, todoio.FormatTodoTxt} {
+			format, _ := todoio.Lookup(name)
+			assert.Empty(t, decoded(t, format, encode(t, format, nil)), name)
+		}
+	})
+}
+
+func TestLookup(t *testing.T) {
+	_, err := todoio.Lookup("xlsx")
+	assert.ErrorIs(t, err, domain.ErrUnsupportedFormat)
+
+	format, err := todoio.ForContentType("text/calendar; charset=utf-8")
+	require.NoError(t, err)
+	assert.Equal(t, todoio.FormatICal, format.Name)
+	_, err = todoio.ForContentType("application/pdf")
+	assert.ErrorIs(t, err, domain.ErrUnsupportedFormat)
+}
+
+func TestDecodeCSV(t *testing.T) {
+	format, _ := todoio.Lookup(todoio.FormatCSV)
+	rows, err := format.Decode(strings.NewReader("\ufeffTitle,Completed,Due_At,Extra\n" +
+		"Buy milk,yes,,x\n" +
+		"Call mom,true,2026-03-01,\n" +
+		"Pay rent,false,next week,\n" +
+		"Short row\n"))
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	assert.Equal(t, 2, rows[0].Number)
+	assert.ErrorContains(t, rows[0].Err, "completed")
+	assert.NoError(t, rows[1].Err)
+	assert.Equal(t, "Call mom", rows[1].Item.Title)
+	assert.True(t, rows[1].Item.Completed)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), *rows[1].Item.DueAt)
+	assert.Equal(t, 4, rows[2].Number)
+	assert.ErrorContains(t, rows[2].Err, "due_at")
+	assert.NoError(t, rows[3].Err)
+	assert.Equal(t, "Short row", rows[3].Item.Title)
+
+	_, err = format.Decode(strings.NewReader("name,done\nx,true\n"))
+	assert.ErrorIs(t, err, domain.ErrInvalidImport)
+	_, err = format.Decode(strings.NewReader(""))
+	assert.ErrorIs(t, err, domain.ErrInvalidImport)
+}
+
+func TestDecodeJSON(t *testing.T) {
+	format, _ := todoio.Lookup(todoio.FormatJSON)
+	rows, err := format.Decode(strings.NewReader(`[{"title":"ok","tags":["a"]},{"title":"bad","completed":"yes"}]`))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, []string{"a"}, rows[0].Item.Tags)
+	assert.Equal(t, 2, rows[1].Number)
+	assert.Error(t, rows[1].Err)
+
+	for _, data := range []string{`{"title":"x"}`, `[{"title":"x"}`, `not json`} {
+		_, err := format.Decode(strings.NewReader(data))
+		assert.ErrorIs(t, err, domain.ErrInvalidImport, data)
+	}
+}
+
+func TestDecodeICal(t *testing.T) {
+	format, _ := todoio.Lookup(todoio.FormatICal)
+	data := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Moscow",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"SUMMARY:Not a todo",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"UID:t1",
+		"SUMMARY:Renew pass",
+		" port",
+		"DUE;TZID=Europe/Moscow:20260310T120000",
+		"PRIORITY:2",
+		"CATEGORIES:docs,gov\\,ernment",
+		"COMPLETED:20260309T100000Z",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT1H30M",
+		"END:VALARM",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"SUMMARY:All-day",
+		"DUE;VALUE=DATE:20260401",
+		"RELATED-TO;RELTYPE=SIBLING:t1",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"SUMMARY:Broken",
+		"DUE:someday",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}, "\r\n")
+	rows, err := format.Decode(strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	first := rows[0].Item
+	require.NoError(t, rows[0].Err)
+	assert.Equal(t, "t1", first.ID)
+	assert.Equal(t, "Renew passport", first.Title)
+	assert.True(t, first.Completed)
+	assert.Equal(t, "urgent", first.Priority)
+	assert.Equal(t, []string{"docs", "gov,ernment"}, first.Tags)
+	assert.Equal(t, time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC), first.DueAt.UTC())
+	assert.Equal(t, time.Date(2026, 3, 10, 7, 30, 0, 0, time.UTC), first.RemindAt.UTC())
+
+	require.NoError(t, rows[1].Err)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), *rows[1].Item.DueAt)
+	assert.Empty(t, rows[1].Item.ParentID)
+
+	assert.Equal(t, 3, rows[2].Number)
+	assert.ErrorContains(t, rows[2].Err, "DUE")
+
+	for _, data := range []string{"", "BEGIN:VTODO\r\nEND:VTODO", "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR"} {
+		_, err := format.Decode(strings.NewReader(data))
+		assert.ErrorIs(t, err, domain.ErrInvalidImport, data)
+	}
+}
+
+func TestICalFolding(t *testing.T) {
+	format, _ := todoio.Lookup(todoio.FormatICal)
+	title := strings.Repeat("задача ", 30)
+	data := encode(t, format, []todoio.Item{{Title: title}})
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+	assert.Equal(t, title, decoded(t, format, data)[0].Title)
+}
+
+func TestDecodeTodoTxt(t *testing.T) {
+	format, _ := todoio.Lookup(todoio.FormatTodoTxt)
+	rows, err := format.Decode(strings.NewReader("(A) 2026-01-05 Call bank +Finance @phone due:2026-01-10\n\n" +
+		"x 2026-01-06 2026-01-01 Send report @work\n" +
+		"(D) Read book see http://example.com\n" +
+		"Fix bike due:tomorrow\n"))
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	assert.Equal(t, todoio.Item{
+		Title:     "Call bank",
+		Priority:  "urgent",
+		Project:   "Finance",
+		Tags:      []string{"phone"},
+		DueAt:     timePtr("2026-01-10T00:00:00Z"),
+		CreatedAt: timePtr("2026-01-05T00:00:00Z"),
+	}, rows[0].Item)
+	assert.Equal(t, 3, rows[1].Number)
+	assert.True(t, rows[1].Item.Completed)
+	assert.Equal(t, timePtr("2026-01-01T00:00:00Z"), rows[1].Item.CreatedAt)
+	assert.Equal(t, "Send report", rows[1].Item.Title)
+	assert.Equal(t, "low", rows[2].Item.Priority)
+	assert.Equal(t, "Read book see http://example.com", rows[2].Item.Title)
+	assert.Equal(t, 5, rows[3].Number)
+	assert.ErrorContains(t, rows[3].Err, "due")
+}
+
+func TestLookupSource(t *testing.T) {
+	source, err := todoio.LookupSource("Trello")
+	require.NoError(t, err)
+	assert.Equal(t, todoio.SourceTrello, source.Name)
+	_, err = todoio.LookupSource("asana")
+	assert.ErrorIs(t, err, domain.ErrUnsupportedSource)
+}
+
+const todoistTemplate = "TYPE,CONTENT,DESCRIPTION,PRIORITY,INDENT,AUTHOR,RESPONSIBLE,DATE,DATE_LANG,TIMEZONE\n" +
+	"task,Plan sprint @work @weekly,Before Monday,1,1,Me (1),,2026-03-02 10:00,en,Europe/Berlin\n" +
+	"note,Remember velocity,,,,Me (1),,,,\n" +
+	"task,Review backlog,,4,2,Me (1),,every day,en,\n" +
+	"task,Ask team,,2,3,Me (1),,,,\n" +
+	",,,,,,,,,\n" +
+	"section,Later,,,,,,,,\n" +
+	"task,Retro,,3,2,Me (1),,2026-03-06,en,\n"
+
+func TestDecodeTodoistCSV(t *testing.T) {
+	source, _ := todoio.LookupSource(todoio.SourceTodoist)
+	rows, err := source.Decode(strings.NewReader(todoistTemplate))
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	plan := rows[0].Item
+	assert.Equal(t, 2, rows[0].Number)
+	assert.Equal(t, "Plan sprint", plan.Title)
+	assert.Equal(t, "Before Monday", plan.Description)
+	assert.Equal(t, "urgent", plan.Priority)
+	assert.Equal(t, []string{"work", "weekly"}, plan.Tags)
+	assert.Equal(t, timePtr("2026-03-02T09:00:00Z"), plan.DueAt)
+	assert.Empty(t, plan.Project)
+
+	assert.Equal(t, plan.ID, rows[1].Item.ParentID)
+	assert.Equal(t, "", rows[1].Item.Priority)
+	assert.Nil(t, rows[1].Item.DueAt, "срок словами не переносится")
+	assert.Equal(t, rows[1].Item.ID, rows[2].Item.ParentID)
+	assert.Equal(t, "high", rows[2].Item.Priority)
+	// После раздела INDENT 2 без родителя становится задачей верхнего уровня.
+	assert.Empty(t, rows[3].Item.ParentID)
+	assert.Equal(t, []string{"Later"}, rows[3].Item.Tags)
+
+	_, err = source.Decode(strings.NewReader("title,done\nx,true\n"))
+	assert.ErrorIs(t, err, domain.ErrInvalidImport)
+}
+
+func TestDecodeTodoistArchive(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"Work [2203306141].csv": todoistTemplate,
+		"Inbox [1].csv":         "TYPE,CONTENT,PRIORITY,INDENT\ntask,Buy milk,4,1\n",
+		"readme.txt":            "not a project",
+	} {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		io.WriteString(w, content)
+	}
+	require.NoError(t, archive.Close())
+
+	source, _ := todoio.LookupSource(todoio.SourceTodoist)
+	rows, err := source.Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+	projects := map[string]string{}
+	ids := map[string]bool{}
+	for i, row := range rows {
+		assert.Equal(t, i+1, row.Number)
+		projects[row.Item.Title] = row.Item.Project
+		ids[row.Item.ID] = true
+	}
+	assert.Equal(t, "Work", projects["Retro"])
+	assert.Equal(t, "", projects["Buy milk"])
+	assert.Len(t, ids, 5, "ID строк разных файлов не совпадают")
+
+	_, err = source.Decode(strings.NewReader("PK\x03\x04broken"))
+	assert.ErrorIs(t, err, domain.ErrInvalidImport)
+}
+
+func TestDecodeTodoistJSON(t *testing.T) {
+	source, _ := todoio.LookupSource(todoio.SourceTodoist)
+	rows, err := source.Decode(strings.NewReader(`{
+		"projects": [{"id": "1", "name": "Inbox", "inbox_project": true}, {"id": "2", "name": "Home"}, {"id": 3, "name": "Old", "is_deleted": 1}],
+		"sections": [{"id": "s1", "name": "Kitchen", "project_id": "2"}],
+		"labels": [{"id": 77, "name": "errand"}],
+		"items": [
+			{"id": "b", "content": "Wash dishes", "project_id": "2", "section_id": "s1", "parent_id": "a", "priority": 1, "child_order": 1, "checked": true},
+			{"id": "a", "content": "Clean up", "project_id": "2", "priority": 4, "labels": ["chores"], "child_order": 0,
+			 "due": {"date": "2026-04-01T18:00:00", "timezone": "Europe/Moscow"}},
+			{"id": "c", "content": "Call mom", "project_id": "1", "labels": [77], "parent_id": "done-already", "child_order": 2, "due": {"date": "2026-04-02"}},
+			{"id": "d", "content": "Gone", "project_id": "2", "is_deleted": true},
+			{"id": "e", "content": "Child of gone", "project_id": "2", "parent_id": "d"},
+			{"id": 5, "content": "In deleted project", "project_id": 3}
+		]}`))
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	assert.Equal(t, todoio.Item{
+		ID:       "a",
+		Title:    "Clean up",
+		Priority: "urgent",
+		Project:  "Home",
+		Tags:     []string{"chores"},
+		DueAt:    timePtr("2026-04-01T15:00:00Z"),
+	}, rows[0].Item)
+	assert.Equal(t, 2, rows[0].Number)
+	assert.Equal(t, "a", rows[1].Item.ParentID)
+	assert.True(t, rows[1].Item.Completed)
+	assert.Equal(t, []string{"Kitchen"}, rows[1].Item.Tags)
+	assert.Equal(t, "Call mom", rows[2].Item.Title)
+	assert.Empty(t, rows[2].Item.Project)
+	assert.Empty(t, rows[2].Item.ParentID)
+	assert.Equal(t, []string{"errand"}, rows[2].Item.Tags)
+
+	_, err = source.Decode(strings.NewReader(`{"projects": []}`))
+	assert.ErrorIs(t, err, domain.ErrInvalidImport)
+}
+
+func TestDecodeTrelloJSON(t *testing.T) {
+	source, _ := todoio.LookupSource(todoio.SourceTrello)
+	rows, err := source.Decode(strings.NewReader(`{
+		"name": "Launch",
+		"labels": [{"id": "l1", "name": "", "color": "red"}, {"id": "l2", "name": "Design", "color": "blue"}],
+		"lists": [{"id": "done", "name": "Done", "pos": 2}, {"id": "todo", "name": "To Do", "pos": 1}, {"id": "old", "name": "Old", "closed": true, "pos": 3}],
+		"cards": [
+			{"id": "c2", "name": "Ship it", "idList": "done", "dueComplete": true, "pos": 1, "idLabels": ["l2"]},
+			{"id": "c1", "name": "Write copy", "desc": "Landing page", "idList": "todo", "pos": 5, "idLabels": ["l1", "l2"],
+			 "due": "2026-05-01T12:00:00.000Z", "dueReminder": 60},
+			{"id": "c0", "name": "Archived", "idList": "todo", "closed": true, "pos": 1},
+			{"id": "c3", "name": "In closed list", "idList": "old", "pos": 1}
+		],
+		"checklists": [
+			{"idCard": "c1", "pos": 2, "checkItems": [{"id": "i3", "name": "Publish", "state": "incomplete", "pos": 1}]},
+			{"idCard": "c1", "pos": 1, "checkItems": [
+				{"id": "i2", "name": "Edit", "state": "incomplete", "pos": 2},
+				{"id": "i1", "name": "Draft", "state": "complete", "pos": 1}
+			]}
+		]}`))
+	require.NoError(t, err)
+
+	titles := []string{}
+	for _, row := range rows {
+		titles = append(titles, row.Item.Title)
+	}
+	assert.Equal(t, []string{"Write copy", "Draft", "Edit", "Publish", "Ship it"}, titles)
+	assert.Equal(t, todoio.Item{
+		ID:          "c1",
+		Title:       "Write copy",
+		Description: "Landing page",
+		Project:     "Launch",
+		Tags:        []string{"To Do", "red", "Design"},
+		DueAt:       timePtr("2026-05-01T12:00:00Z"),
+		RemindAt:    timePtr("2026-05-01T11:00:00Z"),
+	}, rows[0].Item)
+	assert.Equal(t, "c1", rows[1].Item.ParentID)
+	assert.True(t, rows[1].Item.Completed)
+	assert.False(t, rows[2].Item.Completed)
+	assert.True(t, rows[4].Item.Completed)
+	assert.Equal(t, []string{"Done", "Design"}, rows[4].Item.Tags)
+
+	_, err = source.Decode(strings.NewReader(`{"name": "Not a board"}`))
+	assert.ErrorIs(t, err, domain.ErrInvalidImport)
+}
+
+func TestDecodeTrelloCSV(t *testing.T) {
+	source, _ := todoio.LookupSource(todoio.SourceTrello)
+	rows, err := source.Decode(strings.NewReader("Card ID,Card Name,Card Description,Labels,Due Date,List Name,Board Name,Archived,Due Complete\n" +
+		"c1,Write copy,Landing page,\"Urgent (red), (green)\",2026-05-01T12:00:00.000Z,To Do,Launch,false,false\n" +
+		"c2,Old idea,,,,To Do,Launch,true,false\n" +
+		"c3,Ship it,,,,Done,Launch,false,true\n" +
+		"c4,Bad date,,,someday,Done,Launch,false,false\n"))
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	assert.Equal(t, todoio.Item{
+		ID:          "c1",
+		Title:       "Write copy",
+		Description: "Landing page",
+		Project:     "Launch",
+		Tags:        []string{"To Do", "Urgent", "green"},
+		DueAt:       timePtr("2026-05-01T12:00:00Z"),
+	}, rows[0].Item)
+	assert.True(t, rows[1].Item.Completed)
+	assert.Equal(t, 5, rows[2].Number)
+	assert.ErrorContains(t, rows[2].Err, "due date")
+
+	_, err = source.Decode(strings.NewReader("TYPE,CONTENT\ntask,x\n"))
+	assert.ErrorIs(t, err, domain.ErrInvalidImport)
+}
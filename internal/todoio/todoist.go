@@ -0,0 +1,419 @@
+package todoio
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+)
+
+// Todoist отдаёт данные в трёх видах: резервная копия из настроек — ZIP с CSV-шаблоном
+// на каждый проект, один такой CSV (экспорт проекта шаблоном) и JSON Sync API с
+// projects, sections, labels и items. Приоритеты p1 и p2 становятся urgent и high,
+// остальные — normal. Раздел переносится меткой, комментарии (note) не переносятся.
+// Срок, записанный словами («every day», «tomorrow»), и повторение не переносятся.
+
+// maxArchiveSize ограничивает распакованный размер резервной копии.
+const maxArchiveSize = 100 << 20
+
+// todoistFileSuffix — « [id]» в имени файла проекта внутри резервной копии.
+var todoistFileSuffix = regexp.MustCompile(`\s*\[[^\]]*\]$`)
+
+// todoistInbox — проект «Входящие»: его задачи импортируются без проекта.
+const todoistInbox = "Inbox"
+
+// DecodeTodoist читает резервную копию Todoist в любом из трёх видов.
+func DecodeTodoist(r io.Reader) ([]Row, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return decodeTodoistArchive(data)
+	case looksLikeJSON(data):
+		return decodeTodoistJSON(data)
+	}
+	return decodeTodoistCSV(bytes.NewReader(data), "", "")
+}
+
+func decodeTodoistArchive(data []byte) ([]Row, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	var rows []Row
+	found := false
+	budget := int64(maxArchiveSize)
+	for i, file := range archive.File {
+		if file.FileInfo().IsDir() || !strings.EqualFold(path.Ext(file.Name), ".csv") {
+			continue
+		}
+		found = true
+		content, err := readArchiveFile(file, budget)
+		if err != nil {
+			return nil, err
+		}
+		budget -= int64(len(content))
+		fileRows, err := decodeTodoistCSV(bytes.NewReader(content), todoistProject(file.Name), strconv.Itoa(i)+":")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		rows = append(rows, fileRows...)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: no CSV files in the archive", domain.ErrInvalidImport)
+	}
+	// Номера строк повторяются в разных файлах, поэтому задачи нумеруются подряд.
+	for i := range rows {
+		rows[i].Number = i + 1
+	}
+	return rows, nil
+}
+
+func readArchiveFile(file *zip.File, budget int64) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(io.LimitReader(rc, budget+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	if int64(len(content)) > budget {
+		return nil, fmt.Errorf("%w: archive is larger than %d MB unpacked", domain.ErrInvalidImport, maxArchiveSize>>20)
+	}
+	return content, nil
+}
+
+// todoistProject достаёт имя проекта из имени файла вида «Work [2203306141].csv».
+func todoistProject(name string) string {
+	base := path.Base(strings.ReplaceAll(name, "\\", "/"))
+	project := strings.TrimSpace(todoistFileSuffix.ReplaceAllString(strings.TrimSuffix(base, path.Ext(base)), ""))
+	if project == todoistInbox {
+		return ""
+	}
+	return project
+}
+
+// decodeTodoistCSV читает CSV-шаблон проекта. Вложенность задаёт INDENT, раздел
+// (TYPE=section) действует до следующего раздела. idPrefix делает ID строк разных
+// файлов архива различными.
+func decodeTodoistCSV(r io.Reader, project, idPrefix string) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty file", domain.ErrInvalidImport)
+		}
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	columns := headerColumns(header)
+	_, hasType := columns["type"]
+	_, hasContent := columns["content"]
+	if !hasType || !hasContent {
+		return nil, fmt.Errorf("%w: not a Todoist CSV, no TYPE and CONTENT columns", domain.ErrInvalidImport)
+	}
+
+	var rows []Row
+	// parents[n] — ID последней задачи с INDENT n+1.
+	var parents []string
+	section := ""
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, Row{Number: parseErr.StartLine, Err: parseErr.Err})
+				continue
+			}
+			return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		switch strings.ToLower(field("type")) {
+		case "section":
+			section = field("content")
+			parents = nil
+			continue
+		case "task":
+		default:
+			// Комментарии и пустые строки-разделители.
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		item := Item{
+			ID:          idPrefix + strconv.Itoa(line),
+			Description: field("description"),
+			Priority:    todoistCSVPriority(field("priority")),
+			Project:     project,
+			DueAt:       todoistDate(field("date"), field("timezone")),
+		}
+		item.Title, item.Tags = todoistLabels(field("content"))
+		if section != "" {
+			item.Tags = append(item.Tags, section)
+		}
+		indent, _ := strconv.Atoi(field("indent"))
+		indent = max(1, min(indent, len(parents)+1))
+		parents = parents[:indent-1]
+		if indent > 1 {
+			item.ParentID = parents[indent-2]
+		}
+		parents = append(parents, item.ID)
+		rows = append(rows, Row{Number: line, Item: item})
+	}
+}
+
+// todoistCSVPriority переводит PRIORITY шаблона: 1 — высший (p1), 4 — по умолчанию.
+func todoistCSVPriority(value string) string {
+	switch value {
+	case "1":
+		return "urgent"
+	case "2":
+		return "high"
+	}
+	return ""
+}
+
+// todoistAPIPriority переводит priority API: там, наоборот, 4 — высший.
+func todoistAPIPriority(value int) string {
+	switch value {
+	case 4:
+		return "urgent"
+	case 3:
+		return "high"
+	}
+	return ""
+}
+
+// todoistLabels отделяет метки «@метка» от текста задачи в CONTENT.
+func todoistLabels(content string) (string, []string) {
+	var title, labels []string
+	for _, word := range strings.Fields(content) {
+		if len(word) > 1 && word[0] == '@' {
+			labels = append(labels, word[1:])
+			continue
+		}
+		title = append(title, word)
+	}
+	return strings.Join(title, " "), labels
+}
+
+// todoistDate разбирает срок: дата без времени — полночь UTC, время без зоны — в зоне
+// timezone (или UTC). Срок словами даёт nil.
+func todoistDate(value, timezone string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t
+	}
+	location := time.UTC
+	if timezone != "" {
+		if loaded, err := time.LoadLocation(timezone); err == nil {
+			location = loaded
+		}
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	for _, layout := range []string{time.DateOnly, "Jan 2 2006", "2 Jan 2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// todoistID — идентификатор Todoist: строка в Sync API v9, число в более старых.
+type todoistID string
+
+func (id *todoistID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*id = ""
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*id = todoistID(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*id = todoistID(number.String())
+	return nil
+}
+
+// todoistFlag — признак Todoist: bool в v9, 0 или 1 в более старых версиях.
+type todoistFlag bool
+
+func (f *todoistFlag) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", "1":
+		*f = true
+	case "false", "0", "null":
+		*f = false
+	default:
+		return fmt.Errorf("invalid flag %s", data)
+	}
+	return nil
+}
+
+type todoistNamed struct {
+	ID        todoistID   `json:"id"`
+	Name      string      `json:"name"`
+	Inbox     todoistFlag `json:"inbox_project"`
+	IsDeleted todoistFlag `json:"is_deleted"`
+}
+
+type todoistItem struct {
+	ID          todoistID `json:"id"`
+	ParentID    todoistID `json:"parent_id"`
+	ProjectID   todoistID `json:"project_id"`
+	SectionID   todoistID `json:"section_id"`
+	Content     string    `json:"content"`
+	Description string    `json:"description"`
+	Priority    int       `json:"priority"`
+	// Labels — имена меток в v9 и их ID в более старых версиях.
+	Labels     []todoistID `json:"labels"`
+	Checked    todoistFlag `json:"checked"`
+	IsDeleted  todoistFlag `json:"is_deleted"`
+	ChildOrder int         `json:"child_order"`
+	Due        *struct {
+		Date     string `json:"date"`
+		Timezone string `json:"timezone"`
+	} `json:"due"`
+}
+
+type todoistBackup struct {
+	Projects []todoistNamed `json:"projects"`
+	Sections []todoistNamed `json:"sections"`
+	Labels   []todoistNamed `json:"labels"`
+	Items    []todoistItem  `json:"items"`
+}
+
+// decodeTodoistJSON читает ответ полной синхронизации. Удалённые задачи и проекты
+// пропускаются; задача, родителя которой в файле нет (он выполнен или удалён),
+// становится задачей верхнего уровня.
+func decodeTodoistJSON(data []byte) ([]Row, error) {
+	var backup todoistBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	if backup.Items == nil {
+		return nil, fmt.Errorf("%w: not a Todoist backup, no items", domain.ErrInvalidImport)
+	}
+
+	projects := make(map[todoistID]string, len(backup.Projects))
+	deletedProjects := make(map[todoistID]bool)
+	for _, project := range backup.Projects {
+		switch {
+		case bool(project.IsDeleted):
+			deletedProjects[project.ID] = true
+		case !bool(project.Inbox):
+			projects[project.ID] = project.Name
+		}
+	}
+	sections := make(map[todoistID]string, len(backup.Sections))
+	for _, section := range backup.Sections {
+		if !section.IsDeleted {
+			sections[section.ID] = section.Name
+		}
+	}
+	labels := make(map[todoistID]string, len(backup.Labels))
+	for _, label := range backup.Labels {
+		labels[label.ID] = label.Name
+	}
+
+	byID := make(map[todoistID]*todoistItem, len(backup.Items))
+	for i := range backup.Items {
+		byID[backup.Items[i].ID] = &backup.Items[i]
+	}
+	// dropped сообщает, пропускается ли задача вместе с удалённым предком; depth
+	// защищает от циклов, их потом найдёт импорт.
+	var dropped func(item *todoistItem, depth int) bool
+	dropped = func(item *todoistItem, depth int) bool {
+		if bool(item.IsDeleted) || deletedProjects[item.ProjectID] {
+			return true
+		}
+		parent, ok := byID[item.ParentID]
+		return ok && depth < len(byID) && dropped(parent, depth+1)
+	}
+
+	var rows []Row
+	var order []int
+	for i := range backup.Items {
+		source := &backup.Items[i]
+		if dropped(source, 0) {
+			continue
+		}
+		item := Item{
+			ID:          string(source.ID),
+			Title:       strings.TrimSpace(source.Content),
+			Description: source.Description,
+			Completed:   bool(source.Checked),
+			Priority:    todoistAPIPriority(source.Priority),
+			Project:     projects[source.ProjectID],
+		}
+		if _, ok := byID[source.ParentID]; ok {
+			item.ParentID = string(source.ParentID)
+		}
+		if source.Due != nil {
+			item.DueAt = todoistDate(source.Due.Date, source.Due.Timezone)
+		}
+		for _, label := range source.Labels {
+			if name, ok := labels[label]; ok {
+				item.Tags = append(item.Tags, name)
+			} else {
+				item.Tags = append(item.Tags, string(label))
+			}
+		}
+		if section, ok := sections[source.SectionID]; ok {
+			item.Tags = append(item.Tags, section)
+		}
+		rows = append(rows, Row{Number: i + 1, Item: item})
+		order = append(order, source.ChildOrder)
+	}
+	// Порядок задач в проекте Todoist хранит в child_order, а не в порядке items.
+	sort.Stable(byOrder{rows: rows, order: order})
+	return rows, nil
+}
+
+// byOrder сортирует строки по ключу order, сохраняя порядок равных.
+type byOrder struct {
+	rows  []Row
+	order []int
+}
+
+func (b byOrder) Len() int           { return len(b.rows) }
+func (b byOrder) Less(i, j int) bool { return b.order[i] < b.order[j] }
+func (b byOrder) Swap(i, j int) {
+	b.rows[i], b.rows[j] = b.rows[j], b.rows[i]
+	b.order[i], b.order[j] = b.order[j], b.order[i]
+}
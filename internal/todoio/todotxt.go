@@ -0,0 +1,166 @@
+package todoio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+)
+
+// В todo.txt у задачи одна строка: описание, родитель, напоминание и повторение не
+// переносятся. Приоритеты: (A) — urgent, (B) — high, без приоритета — normal,
+// (C) и ниже — low.
+
+var (
+	todoTxtPriority = regexp.MustCompile(`^\(([A-Z])\) `)
+	todoTxtDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} `)
+	todoTxtSpaces   = regexp.MustCompile(`\s+`)
+)
+
+type todoTxtEncoder struct {
+	w io.Writer
+}
+
+// NewTodoTxtEncoder пишет задачи строками формата todo.txt.
+func NewTodoTxtEncoder(w io.Writer) Encoder {
+	return &todoTxtEncoder{w: w}
+}
+
+func (e *todoTxtEncoder) Encode(item Item) error {
+	var parts []string
+	priority := todoTxtLetter(item.Priority)
+	if item.Completed {
+		// У выполненной задачи приоритет переносится в pri:, а дата создания без даты
+		// выполнения не пишется — её у нас нет.
+		parts = append(parts, "x")
+	} else {
+		if priority != "" {
+			parts = append(parts, "("+priority+")")
+		}
+		if item.CreatedAt != nil {
+			parts = append(parts, item.CreatedAt.UTC().Format(time.DateOnly))
+		}
+	}
+	parts = append(parts, todoTxtSpaces.ReplaceAllString(strings.TrimSpace(item.Title), " "))
+	if item.Project != "" {
+		parts = append(parts, "+"+todoTxtWord(item.Project))
+	}
+	for _, tag := range item.Tags {
+		parts = append(parts, "@"+todoTxtWord(tag))
+	}
+	if item.DueAt != nil {
+		due := item.DueAt.UTC()
+		if due.Equal(due.Truncate(24 * time.Hour)) {
+			parts = append(parts, "due:"+due.Format(time.DateOnly))
+		} else {
+			parts = append(parts, "due:"+due.Format(time.RFC3339))
+		}
+	}
+	if item.Completed && priority != "" {
+		parts = append(parts, "pri:"+priority)
+	}
+	_, err := io.WriteString(e.w, strings.Join(parts, " ")+"\n")
+	return err
+}
+
+func (e *todoTxtEncoder) Close() error {
+	return nil
+}
+
+// todoTxtWord делает из имени одно слово: пробелы внутри проекта и метки недопустимы.
+func todoTxtWord(name string) string {
+	return todoTxtSpaces.ReplaceAllString(strings.TrimSpace(name), "_")
+}
+
+func todoTxtLetter(priority string) string {
+	switch priority {
+	case "urgent":
+		return "A"
+	case "high":
+		return "B"
+	case "low":
+		return "C"
+	}
+	return ""
+}
+
+func priorityFromLetter(letter string) string {
+	switch letter {
+	case "A":
+		return "urgent"
+	case "B":
+		return "high"
+	}
+	return "low"
+}
+
+// DecodeTodoTxt читает по задаче из каждой непустой строки.
+func DecodeTodoTxt(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var rows []Row
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		item, err := todoTxtItem(line)
+		rows = append(rows, Row{Number: number, Item: item, Err: err})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	return rows, nil
+}
+
+func todoTxtItem(line string) (Item, error) {
+	var item Item
+	rest := line + " "
+	if strings.HasPrefix(rest, "x ") {
+		item.Completed = true
+		rest = rest[2:]
+		// Дата выполнения и за ней, возможно, дата создания.
+		if todoTxtDate.MatchString(rest) {
+			rest = rest[11:]
+		}
+	} else if match := todoTxtPriority.FindStringSubmatch(rest); match != nil {
+		item.Priority = priorityFromLetter(match[1])
+		rest = rest[len(match[0]):]
+	}
+	if todoTxtDate.MatchString(rest) {
+		created, err := time.Parse(time.DateOnly, rest[:10])
+		if err != nil {
+			return item, fmt.Errorf("invalid creation date %q", rest[:10])
+		}
+		item.CreatedAt = &created
+		rest = rest[11:]
+	}
+
+	var title []string
+	for _, word := range strings.Fields(rest) {
+		switch {
+		case len(word) > 1 && word[0] == '+' && item.Project == "":
+			item.Project = word[1:]
+		case len(word) > 1 && word[0] == '@':
+			item.Tags = append(item.Tags, word[1:])
+		case strings.HasPrefix(word, "due:") && len(word) > 4:
+			due, err := parseTime(word[4:])
+			if err != nil {
+				return item, fmt.Errorf("due: %w", err)
+			}
+			item.DueAt = due
+		case strings.HasPrefix(word, "pri:") && len(word) == 5:
+			item.Priority = priorityFromLetter(strings.ToUpper(word[4:]))
+		default:
+			title = append(title, word)
+		}
+	}
+	item.Title = strings.Join(title, " ")
+	return item, nil
+}
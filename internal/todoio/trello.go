@@ -0,0 +1,264 @@
+package todoio
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/polzovatel/todo-learning/internal/domain"
+)
+
+// Trello экспортирует доску в JSON и CSV. Доска становится проектом, карточка — задачей,
+// а список карточки — меткой вместе с метками карточки (метка без имени называется по
+// цвету). Пункты чек-листов становятся подзадачами карточки; выполнены карточка с
+// отметкой срока «выполнено» и отмеченные пункты. Архивные карточки и списки
+// пропускаются. Чек-листы есть только в JSON.
+
+// trelloCSVLabel — метка в колонке Labels CSV: «Имя (цвет)».
+var trelloCSVLabel = regexp.MustCompile(`^(.*?)\s*\(([a-z_]+)\)$`)
+
+// DecodeTrello читает экспорт доски Trello в JSON или CSV.
+func DecodeTrello(r io.Reader) ([]Row, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	if looksLikeJSON(data) {
+		return decodeTrelloJSON(data)
+	}
+	return decodeTrelloCSV(bytes.NewReader(data))
+}
+
+type trelloLabel struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+func (l trelloLabel) tag() string {
+	if name := strings.TrimSpace(l.Name); name != "" {
+		return name
+	}
+	return l.Color
+}
+
+type trelloList struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Closed bool    `json:"closed"`
+	Pos    float64 `json:"pos"`
+}
+
+type trelloCard struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Desc        string        `json:"desc"`
+	Closed      bool          `json:"closed"`
+	IDList      string        `json:"idList"`
+	IDLabels    []string      `json:"idLabels"`
+	Labels      []trelloLabel `json:"labels"`
+	Due         *time.Time    `json:"due"`
+	DueComplete bool          `json:"dueComplete"`
+	// DueReminder — за сколько минут до срока напомнить; -1 — без напоминания.
+	DueReminder *int    `json:"dueReminder"`
+	Pos         float64 `json:"pos"`
+}
+
+type trelloChecklist struct {
+	IDCard     string  `json:"idCard"`
+	Pos        float64 `json:"pos"`
+	CheckItems []struct {
+		ID    string     `json:"id"`
+		Name  string     `json:"name"`
+		State string     `json:"state"`
+		Due   *time.Time `json:"due"`
+		Pos   float64    `json:"pos"`
+	} `json:"checkItems"`
+}
+
+type trelloBoard struct {
+	Name       string            `json:"name"`
+	Labels     []trelloLabel     `json:"labels"`
+	Lists      []trelloList      `json:"lists"`
+	Cards      []trelloCard      `json:"cards"`
+	Checklists []trelloChecklist `json:"checklists"`
+}
+
+// decodeTrelloJSON читает экспорт доски. Карточки идут в порядке списков на доске и
+// своём порядке в списке, за каждой — пункты её чек-листов.
+func decodeTrelloJSON(data []byte) ([]Row, error) {
+	var board trelloBoard
+	if err := json.Unmarshal(data, &board); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	if board.Cards == nil {
+		return nil, fmt.Errorf("%w: not a Trello board export, no cards", domain.ErrInvalidImport)
+	}
+
+	lists := make(map[string]trelloList, len(board.Lists))
+	for _, list := range board.Lists {
+		lists[list.ID] = list
+	}
+	labels := make(map[string]trelloLabel, len(board.Labels))
+	for _, label := range board.Labels {
+		labels[label.ID] = label
+	}
+	checklists := make(map[string][]trelloChecklist)
+	for _, checklist := range board.Checklists {
+		sort.SliceStable(checklist.CheckItems, func(i, j int) bool {
+			return checklist.CheckItems[i].Pos < checklist.CheckItems[j].Pos
+		})
+		checklists[checklist.IDCard] = append(checklists[checklist.IDCard], checklist)
+	}
+
+	cards := board.Cards
+	sort.SliceStable(cards, func(i, j int) bool {
+		// Карточки из неизвестных списков — в конце.
+		left, leftOK := lists[cards[i].IDList]
+		right, rightOK := lists[cards[j].IDList]
+		if leftOK != rightOK {
+			return leftOK
+		}
+		if left.Pos != right.Pos {
+			return left.Pos < right.Pos
+		}
+		return cards[i].Pos < cards[j].Pos
+	})
+
+	var rows []Row
+	for _, card := range cards {
+		list, hasList := lists[card.IDList]
+		if card.Closed || list.Closed {
+			continue
+		}
+		item := Item{
+			ID:          card.ID,
+			Title:       strings.TrimSpace(card.Name),
+			Description: card.Desc,
+			Completed:   card.DueComplete,
+			Project:     board.Name,
+			DueAt:       card.Due,
+		}
+		if card.Due != nil && card.DueReminder != nil && *card.DueReminder >= 0 {
+			remind := card.Due.Add(-time.Duration(*card.DueReminder) * time.Minute)
+			item.RemindAt = &remind
+		}
+		if hasList {
+			item.Tags = append(item.Tags, list.Name)
+		}
+		if card.Labels != nil {
+			for _, label := range card.Labels {
+				item.Tags = append(item.Tags, label.tag())
+			}
+		} else {
+			for _, id := range card.IDLabels {
+				if label, ok := labels[id]; ok {
+					item.Tags = append(item.Tags, label.tag())
+				}
+			}
+		}
+		rows = append(rows, Row{Number: len(rows) + 1, Item: item})
+
+		cardChecklists := checklists[card.ID]
+		sort.SliceStable(cardChecklists, func(i, j int) bool { return cardChecklists[i].Pos < cardChecklists[j].Pos })
+		for _, checklist := range cardChecklists {
+			for _, check := range checklist.CheckItems {
+				rows = append(rows, Row{Number: len(rows) + 1, Item: Item{
+					ID:        check.ID,
+					ParentID:  card.ID,
+					Title:     strings.TrimSpace(check.Name),
+					Completed: check.State == "complete",
+					Project:   board.Name,
+					DueAt:     check.Due,
+				}})
+			}
+		}
+	}
+	return rows, nil
+}
+
+// decodeTrelloCSV читает CSV-экспорт доски: по карточке в строке.
+func decodeTrelloCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty file", domain.ErrInvalidImport)
+		}
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	columns := headerColumns(header)
+	if _, ok := columns["card name"]; !ok {
+		return nil, fmt.Errorf("%w: not a Trello CSV, no Card Name column", domain.ErrInvalidImport)
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, Row{Number: parseErr.StartLine, Err: parseErr.Err})
+				continue
+			}
+			return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if archived, _ := strconv.ParseBool(field("archived")); archived {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		item := Item{
+			ID:          field("card id"),
+			Title:       field("card name"),
+			Description: field("card description"),
+			Project:     field("board name"),
+		}
+		item.Completed, _ = strconv.ParseBool(field("due complete"))
+		if list := field("list name"); list != "" {
+			item.Tags = append(item.Tags, list)
+		}
+		item.Tags = append(item.Tags, trelloCSVLabels(field("labels"))...)
+		item.DueAt, err = parseTime(field("due date"))
+		if err != nil {
+			err = fmt.Errorf("due date: %w", err)
+		}
+		rows = append(rows, Row{Number: line, Item: item, Err: err})
+	}
+}
+
+// trelloCSVLabels разбирает колонку Labels вида «Bug (red), Feature (green)».
+func trelloCSVLabels(value string) []string {
+	var tags []string
+	for _, label := range strings.Split(value, ", ") {
+		label = strings.TrimSpace(label)
+		if match := trelloCSVLabel.FindStringSubmatch(label); match != nil {
+			label = match[1]
+			if label == "" {
+				label = match[2]
+			}
+		}
+		if label != "" {
+			tags = append(tags, label)
+		}
+	}
+	return tags
+}